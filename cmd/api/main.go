@@ -12,8 +12,11 @@ import (
 	"github.com/pratik-mahalle/infraudit/internal/api/handlers"
 	"github.com/pratik-mahalle/infraudit/internal/api/router"
 	"github.com/pratik-mahalle/infraudit/internal/config"
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/job"
 	"github.com/pratik-mahalle/infraudit/internal/integrations"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/secrets"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/validator"
 	"github.com/pratik-mahalle/infraudit/internal/repository/postgres"
 	"github.com/pratik-mahalle/infraudit/internal/scanners"
@@ -111,16 +114,32 @@ func main() {
 
 	log.Info("Successfully connected to database")
 
+	// Load the master keys that encrypt provider credentials at rest. The
+	// keyfile is only created while nothing is encrypted under it.
+	encrypted, err := postgres.HasEncryptedCredentials(context.Background(), db)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to check stored provider credentials")
+	}
+	keyring, err := secrets.LoadKeyring(cfg.Encryption.MasterKeys, cfg.Encryption.ActiveKeyVersion, cfg.Encryption.KeyFile, !encrypted)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load credential encryption keys")
+	}
+	log.WithFields(map[string]interface{}{
+		"active_version": keyring.ActiveVersion(),
+		"versions":       keyring.Versions(),
+	}).Info("Credential encryption keys loaded")
+
 	// Initialize validator
 	val := validator.New()
 
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(db)
 	resourceRepo := postgres.NewResourceRepository(db)
-	providerRepo := postgres.NewProviderRepository(db)
+	providerRepo := postgres.NewProviderRepository(db, keyring)
 	alertRepo := postgres.NewAlertRepository(db)
 	recommendationRepo := postgres.NewRecommendationRepository(db)
 	driftRepo := postgres.NewDriftRepository(db)
+	driftRuleRepo := postgres.NewDriftRuleRepository(db)
 	anomalyRepo := postgres.NewAnomalyRepository(db)
 	baselineRepo := postgres.NewBaselineRepository(db)
 	vulnerabilityRepo := postgres.NewVulnerabilityRepository(db)
@@ -145,13 +164,33 @@ func main() {
 		log.Warn("Gemini API key not configured - recommendation generation will be disabled")
 	}
 
+	// Load and validate drift rules; invalid rule files abort startup
+	driftRules, err := detector.BuiltinRuleSet()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load built-in drift rules")
+	}
+	if cfg.Drift.RulesDir != "" {
+		fileRules, err := detector.LoadRuleSet(os.DirFS(cfg.Drift.RulesDir), cfg.Drift.RulesDir)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load drift rules directory")
+		}
+		if driftRules, err = driftRules.With(fileRules.Rules()); err != nil {
+			log.WithError(err).Fatal("Failed to merge drift rules")
+		}
+	}
+	log.WithFields(map[string]interface{}{
+		"rules": len(driftRules.Rules()),
+	}).Info("Drift rules loaded")
+
 	// Initialize services
 	userService := services.NewUserService(userRepo, log)
 	resourceService := services.NewResourceService(resourceRepo, log)
 	providerService := services.NewProviderService(providerRepo, resourceRepo, log)
+	providerService.(*services.ProviderService).SetStaleResourceGracePeriod(cfg.Provider.StaleResourceGracePeriod)
 	alertService := services.NewAlertService(alertRepo, log)
 	baselineService := services.NewBaselineService(baselineRepo, log)
-	driftService := services.NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	driftService := services.NewDriftService(driftRepo, baselineRepo, resourceRepo, driftRuleRepo, driftRules, log)
+	driftRuleService := services.NewDriftRuleService(driftRuleRepo, driftRules, log)
 	anomalyService := services.NewAnomalyService(anomalyRepo, log)
 	vulnerabilityService := services.NewVulnerabilityService(vulnerabilityRepo, log, trivyScanner, nvdScanner)
 	iacService := services.NewIaCService(iacRepo, resourceService.(*services.ResourceService), driftService.(*services.DriftService))
@@ -174,22 +213,74 @@ func main() {
 	// Initialize cost service
 	costRepo := postgres.NewCostRepository(db)
 	costService := services.NewCostService(costRepo, providerRepo, geminiClient, log)
+	costService.(*services.CostServiceImpl).SetResourceRepository(resourceRepo)
 
 	// Initialize compliance service
 	complianceService := services.NewComplianceService(complianceRepo, driftRepo, vulnerabilityRepo, log)
+	complianceService.(*services.ComplianceServiceImpl).SetResourceRepository(resourceRepo)
+	if err := complianceService.InitializeFrameworks(context.Background()); err != nil {
+		log.ErrorWithErr(err, "Failed to load compliance framework catalogs")
+	}
 
-	// Initialize job service
-	jobService := services.NewJobService(jobRepo, driftService, providerService, log)
-
-	// Initialize remediation service
-	remediationService := services.NewRemediationService(remediationRepo, driftService, vulnerabilityService, log)
+	// Initialize remediation service. Pull requests can be opened on GitHub
+	// and GitLab when a token is configured. Repositories on the server's own
+	// filesystem are only accepted when explicitly allowed.
+	var forges []integrations.Forge
+	if cfg.Remediation.GitHubToken != "" {
+		forges = append(forges, integrations.NewGitHubForge(cfg.Remediation.GitHubToken, cfg.Remediation.GitHubAPIURL))
+	}
+	if cfg.Remediation.GitLabToken != "" {
+		forges = append(forges, integrations.NewGitLabForge(cfg.Remediation.GitLabToken, cfg.Remediation.GitLabURL))
+	}
+	if cfg.Remediation.AllowLocalRepos {
+		forges = append(forges, integrations.NewLocalForge())
+	}
+	gitClient := integrations.NewGitClient(cfg.Remediation.GitPath, cfg.Remediation.CommitAuthorName, cfg.Remediation.CommitAuthorEmail)
+	remediationService := services.NewRemediationService(remediationRepo, driftService, vulnerabilityService, providerRepo, resourceRepo, gitClient, forges, log)
 
 	// Initialize notification service
 	notificationService := services.NewNotificationService(notificationRepo, log, cfg.Provider.SlackWebhookURL)
+	if cfg.Provider.SMTPHost != "" {
+		notificationService.(*services.NotificationService).SetEmailSender(integrations.NewSMTPMailer(integrations.SMTPConfig{
+			Host:     cfg.Provider.SMTPHost,
+			Port:     cfg.Provider.SMTPPort,
+			Username: cfg.Provider.SMTPUser,
+			Password: cfg.Provider.SMTPPassword,
+			From:     cfg.Provider.EmailFrom,
+			FromName: cfg.Provider.EmailFromName,
+			UseTLS:   cfg.Provider.SMTPUseTLS,
+		}))
+	}
+
+	// Budget thresholds are evaluated after every cost sync
+	costService.(*services.CostServiceImpl).SetNotificationService(notificationService)
+	// Resources that appear or vanish between provider syncs trigger webhooks
+	providerService.(*services.ProviderService).SetNotificationService(notificationService)
+	// Owners are warned before approved compliance exceptions expire
+	complianceService.(*services.ComplianceServiceImpl).SetNotificationService(notificationService)
 
 	// Initialize recommendation service
 	recommendationService := services.NewRecommendationService(recommendationRepo, recommendationEngine, log)
 
+	// Initialize job service
+	jobService := services.NewJobService(jobRepo, driftService, providerService, vulnerabilityService, costService, iacService, complianceService, recommendationService, log)
+	jobService.(*services.JobService).SetExecutionPolicy(services.JobExecutionPolicy{
+		DefaultTimeout:  cfg.Scheduler.JobTimeout,
+		RetryBaseDelay:  cfg.Scheduler.RetryBaseDelay,
+		RetryMaxDelay:   cfg.Scheduler.RetryMaxDelay,
+		StopGracePeriod: cfg.Scheduler.StopGracePeriod,
+	})
+	// Every user gets the default scheduled jobs; users can disable them
+	jobService.(*services.JobService).SetUserLister(userRepo)
+
+	// Replicas sharing the database elect one leader to run scheduled work
+	nodeID := cfg.Scheduler.NodeID
+	if nodeID == "" {
+		nodeID = services.DefaultNodeID()
+	}
+	leaderElector := services.NewLeaderElector(jobRepo, job.LeaseScheduler, nodeID, cfg.Scheduler.LeaseTTL, log)
+	jobService.(*services.JobService).SetLeaderElector(leaderElector)
+
 	// Initialize background drift scanner worker
 	driftScanInterval := 30 * time.Minute // Default: scan every 30 minutes
 	driftScanner := worker.NewDriftScanner(
@@ -200,10 +291,15 @@ func main() {
 		driftScanInterval,
 		log,
 	)
+	driftScanner.SetLeaderChecker(leaderElector)
 	log.WithFields(map[string]interface{}{
 		"interval": driftScanInterval.String(),
 	}).Info("Drift scanner worker initialized")
 
+	// Initialize background notification retry worker
+	notificationDispatcher := worker.NewNotificationDispatcher(notificationService, cfg.Scheduler.NotifyInterval, log)
+	notificationDispatcher.SetLeaderChecker(leaderElector)
+
 	// Initialize handlers
 	handlers := &router.Handlers{
 		Health:         handlers.NewHealthHandler(db, log),
@@ -213,6 +309,7 @@ func main() {
 		Alert:          handlers.NewAlertHandler(alertService, log, val),
 		Recommendation: handlers.NewRecommendationHandler(recommendationService, log, val),
 		Drift:          handlers.NewDriftHandler(driftService, log, val),
+		DriftRule:      handlers.NewDriftRuleHandler(driftRuleService, log, val),
 		Anomaly:        handlers.NewAnomalyHandler(anomalyService, log, val),
 		Baseline:       handlers.NewBaselineHandler(baselineService, log),
 		Vulnerability:  handlers.NewVulnerabilityHandler(vulnerabilityService, log, val),
@@ -243,10 +340,24 @@ func main() {
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	defer workerCancel()
 
+	// Campaign for leadership before the workers that depend on it start
+	leaderElector.Start(workerCtx)
+
 	// Start background drift scanner
 	go driftScanner.Start(workerCtx)
 	log.Info("Background drift scanner started")
 
+	// Start background notification dispatcher
+	go notificationDispatcher.Start(workerCtx)
+	log.Info("Background notification dispatcher started")
+
+	// Start job scheduler
+	if cfg.Scheduler.Enabled {
+		if err := jobService.Start(workerCtx); err != nil {
+			log.WithError(err).Fatal("Failed to start job scheduler")
+		}
+	}
+
 	// Start server in goroutine
 	// Start server in goroutine
 	go func() {
@@ -273,6 +384,7 @@ func main() {
 	log.Info("Server shutting down...")
 
 	// Stop background workers
+	jobService.Stop()
 	workerCancel()
 	log.Info("Background workers stopped")
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -8,10 +9,24 @@ import (
 	"sort"
 
 	"github.com/pratik-mahalle/infraudit/internal/config"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/secrets"
 	"github.com/pratik-mahalle/infraudit/internal/repository/postgres"
 )
 
+const usage = `Usage: migrate [command]
+
+Commands:
+  up                     Run pending migrations (default)
+  rotate-key             Add a new master key to the keyfile and make it active
+  reencrypt-credentials  Re-encrypt provider credentials under the active master key
+`
+
 func main() {
+	command := "up"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -19,6 +34,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	switch command {
+	case "up":
+	case "rotate-key":
+		rotateKey(cfg)
+		return
+	case "reencrypt-credentials":
+		reencryptCredentials(cfg)
+		return
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
 	// Connect to database
 	db, err := postgres.New(cfg.Database)
 	if err != nil {
@@ -107,6 +135,52 @@ func main() {
 	fmt.Println("\nAll migrations completed successfully!")
 }
 
+// rotateKey adds a new master key to the keyfile. Credentials keep opening
+// with their old key until reencrypt-credentials has run.
+func rotateKey(cfg *config.Config) {
+	if cfg.Encryption.MasterKeys != "" {
+		fmt.Fprintln(os.Stderr, "Master keys are set in CREDENTIALS_MASTER_KEYS; add a new version:key pair there instead")
+		os.Exit(1)
+	}
+
+	version, err := secrets.AddKeyfileVersion(cfg.Encryption.KeyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to add master key: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Master key version %d added to %s and made active\n", version, cfg.Encryption.KeyFile)
+	fmt.Println("Run 'migrate reencrypt-credentials' to move stored credentials to it")
+}
+
+// reencryptCredentials moves every stored credential to the active master key
+func reencryptCredentials(cfg *config.Config) {
+	db, err := postgres.New(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	encrypted, err := postgres.HasEncryptedCredentials(context.Background(), db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check stored credentials: %v\n", err)
+		os.Exit(1)
+	}
+	keyring, err := secrets.LoadKeyring(cfg.Encryption.MasterKeys, cfg.Encryption.ActiveKeyVersion, cfg.Encryption.KeyFile, !encrypted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load master keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo := postgres.NewProviderRepository(db, keyring).(*postgres.ProviderRepository)
+	count, err := repo.ReencryptCredentials(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to re-encrypt credentials after %d accounts: %v\n", count, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Re-encrypted %d provider accounts under master key version %d\n", count, keyring.ActiveVersion())
+}
+
 func getMigratedVersions(db *sql.DB) (map[string]bool, error) {
 	rows, err := db.Query("SELECT name FROM migrations")
 	if err != nil {
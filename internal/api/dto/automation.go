@@ -50,6 +50,24 @@ type JobExecutionResponse struct {
 	CreatedAt    time.Time       `json:"created_at"`
 }
 
+// LeaseResponse represents a lease held by a node
+type LeaseResponse struct {
+	Name        string    `json:"name"`
+	Holder      string    `json:"holder"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// SchedulerStatusResponse represents the scheduler status of the node that
+// served the request and the leases held by all nodes
+type SchedulerStatusResponse struct {
+	NodeID    string          `json:"node_id"`
+	IsRunning bool            `json:"is_running"`
+	IsLeader  bool            `json:"is_leader"`
+	Leases    []LeaseResponse `json:"leases"`
+}
+
 // TriggerJobRequest represents a request to trigger a job
 type TriggerJobRequest struct {
 	Config map[string]interface{} `json:"config,omitempty"`
@@ -90,6 +108,17 @@ type RemediationStrategyDTO struct {
 	Description string                 `json:"description"`
 	Steps       []RemediationStepDTO   `json:"steps"`
 	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+
+	// IaC PR specific
+	Repository   string `json:"repository,omitempty"`
+	Branch       string `json:"branch,omitempty"`
+	FilePath     string `json:"file_path,omitempty"`
+	PatchContent string `json:"patch_content,omitempty"`
+
+	// Cloud API specific
+	Provider  string `json:"provider,omitempty"`
+	APIAction string `json:"api_action,omitempty"`
+	APIParams string `json:"api_params,omitempty"`
 }
 
 // RemediationStepDTO represents a remediation step
@@ -228,7 +257,10 @@ type WebhookDeliveryResponse struct {
 	EventType      string     `json:"event_type"`
 	Status         string     `json:"status"`
 	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
 	RetryCount     int        `json:"retry_count"`
+	AttemptedAt    *time.Time `json:"attempted_at,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
 	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 }
@@ -59,30 +59,55 @@ type CostDataPointDTO struct {
 
 // CostForecastResponse represents cost forecast
 type CostForecastResponse struct {
-	Provider        string  `json:"provider"`
-	Period          string  `json:"period"`
-	ForecastedCost  float64 `json:"forecasted_cost"`
-	ConfidenceLevel float64 `json:"confidence_level"`
-	LowerBound      float64 `json:"lower_bound"`
-	UpperBound      float64 `json:"upper_bound"`
-	Currency        string  `json:"currency"`
-	EndDate         string  `json:"end_date"`
+	Provider        string             `json:"provider"`
+	Period          string             `json:"period"`
+	Method          string             `json:"method"`
+	Granularity     string             `json:"granularity"`
+	ForecastedCost  float64            `json:"forecasted_cost"`
+	ConfidenceLevel float64            `json:"confidence_level"`
+	LowerBound      float64            `json:"lower_bound"`
+	UpperBound      float64            `json:"upper_bound"`
+	Points          []ForecastPointDTO `json:"points"`
+	HistoryDays     int                `json:"history_days"`
+	MAPE            float64            `json:"mape"`
+	BacktestDays    int                `json:"backtest_days"`
+	Currency        string             `json:"currency"`
+	EndDate         string             `json:"end_date"`
+}
+
+// ForecastPointDTO represents the forecast for one day, week or month
+type ForecastPointDTO struct {
+	Date           string  `json:"date"`
+	ForecastedCost float64 `json:"forecasted_cost"`
+	LowerBound     float64 `json:"lower_bound"`
+	UpperBound     float64 `json:"upper_bound"`
 }
 
 // CostAnomalyResponse represents a cost anomaly
 type CostAnomalyResponse struct {
-	ID           string    `json:"id"`
-	Provider     string    `json:"provider"`
-	ServiceName  string    `json:"service_name"`
-	ResourceID   *string   `json:"resource_id,omitempty"`
-	AnomalyType  string    `json:"anomaly_type"`
-	ExpectedCost float64   `json:"expected_cost"`
-	ActualCost   float64   `json:"actual_cost"`
-	Deviation    float64   `json:"deviation"`
-	Severity     string    `json:"severity"`
-	Status       string    `json:"status"`
-	Notes        string    `json:"notes,omitempty"`
-	DetectedAt   time.Time `json:"detected_at"`
+	ID           string          `json:"id"`
+	Provider     string          `json:"provider"`
+	ServiceName  string          `json:"service_name"`
+	ResourceID   *string         `json:"resource_id,omitempty"`
+	AnomalyType  string          `json:"anomaly_type"`
+	ExpectedCost float64         `json:"expected_cost"`
+	ActualCost   float64         `json:"actual_cost"`
+	Deviation    float64         `json:"deviation"`
+	Severity     string          `json:"severity"`
+	Status       string          `json:"status"`
+	Notes        string          `json:"notes,omitempty"`
+	Drivers      []CostDriverDTO `json:"drivers,omitempty"`
+	DetectedAt   time.Time       `json:"detected_at"`
+}
+
+// CostDriverDTO represents a service or region that drove an anomaly
+type CostDriverDTO struct {
+	Dimension    string  `json:"dimension"`
+	Name         string  `json:"name"`
+	ExpectedCost float64 `json:"expected_cost"`
+	ActualCost   float64 `json:"actual_cost"`
+	Delta        float64 `json:"delta"`
+	Share        float64 `json:"share"`
 }
 
 // ListAnomaliesResponse represents a list of anomalies
@@ -120,6 +145,158 @@ type ListOptimizationsResponse struct {
 	Total         int64                      `json:"total"`
 }
 
+// BudgetScopeDTO selects the costs counted against a budget
+type BudgetScopeDTO struct {
+	Provider    string `json:"provider,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	Region      string `json:"region,omitempty"`
+	TagKey      string `json:"tag_key,omitempty"`
+	TagValue    string `json:"tag_value,omitempty"`
+}
+
+// BudgetThresholdDTO is a percentage of the budget that fires an alert
+type BudgetThresholdDTO struct {
+	Percent float64 `json:"percent"`
+	Type    string  `json:"type"` // actual, forecasted
+}
+
+// CreateBudgetRequest represents a request to create a budget
+type CreateBudgetRequest struct {
+	Name       string               `json:"name" validate:"required"`
+	Period     string               `json:"period" validate:"required,oneof=monthly quarterly"`
+	Amount     float64              `json:"amount" validate:"required,gt=0"`
+	Currency   string               `json:"currency,omitempty"`
+	Scope      BudgetScopeDTO       `json:"scope"`
+	Thresholds []BudgetThresholdDTO `json:"thresholds,omitempty"`
+	IsEnabled  *bool                `json:"is_enabled,omitempty"`
+}
+
+// UpdateBudgetRequest represents a request to update a budget. Omitted
+// fields keep their current value.
+type UpdateBudgetRequest struct {
+	Name       *string              `json:"name,omitempty"`
+	Period     *string              `json:"period,omitempty"`
+	Amount     *float64             `json:"amount,omitempty"`
+	Currency   *string              `json:"currency,omitempty"`
+	Scope      *BudgetScopeDTO      `json:"scope,omitempty"`
+	Thresholds []BudgetThresholdDTO `json:"thresholds,omitempty"`
+	IsEnabled  *bool                `json:"is_enabled,omitempty"`
+}
+
+// BudgetResponse represents a budget
+type BudgetResponse struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Period     string               `json:"period"`
+	Amount     float64              `json:"amount"`
+	Currency   string               `json:"currency"`
+	Scope      BudgetScopeDTO       `json:"scope"`
+	Thresholds []BudgetThresholdDTO `json:"thresholds"`
+	IsEnabled  bool                 `json:"is_enabled"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// ListBudgetsResponse represents a list of budgets
+type ListBudgetsResponse struct {
+	Budgets []BudgetResponse `json:"budgets"`
+	Total   int              `json:"total"`
+}
+
+// BudgetAlertResponse represents a fired budget threshold
+type BudgetAlertResponse struct {
+	PeriodKey        string    `json:"period_key"`
+	ThresholdType    string    `json:"threshold_type"`
+	ThresholdPercent float64   `json:"threshold_percent"`
+	Spend            float64   `json:"spend"`
+	BudgetAmount     float64   `json:"budget_amount"`
+	TriggeredAt      time.Time `json:"triggered_at"`
+}
+
+// BudgetStatusResponse represents the spend of a budget in its current period
+type BudgetStatusResponse struct {
+	Budget           BudgetResponse        `json:"budget"`
+	PeriodStart      string                `json:"period_start"`
+	PeriodEnd        string                `json:"period_end"`
+	ActualSpend      float64               `json:"actual_spend"`
+	ProjectedSpend   float64               `json:"projected_spend"`
+	PercentUsed      float64               `json:"percent_used"`
+	ProjectedPercent float64               `json:"projected_percent"`
+	Crossed          []BudgetThresholdDTO  `json:"crossed"`
+	Alerts           []BudgetAlertResponse `json:"alerts"`
+}
+
+// AllocationSplitDTO shares the costs of one group among the others
+type AllocationSplitDTO struct {
+	Group   string             `json:"group"`
+	Method  string             `json:"method"`
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+// CreateAllocationRuleRequest represents a request to create an allocation rule
+type CreateAllocationRuleRequest struct {
+	Name    string               `json:"name"`
+	TagKeys []string             `json:"tag_keys"`
+	Aliases map[string]string    `json:"aliases,omitempty"`
+	Splits  []AllocationSplitDTO `json:"splits,omitempty"`
+}
+
+// UpdateAllocationRuleRequest represents a request to update an allocation
+// rule. Omitted fields keep their current values.
+type UpdateAllocationRuleRequest struct {
+	Name    *string              `json:"name,omitempty"`
+	TagKeys []string             `json:"tag_keys,omitempty"`
+	Aliases map[string]string    `json:"aliases,omitempty"`
+	Splits  []AllocationSplitDTO `json:"splits,omitempty"`
+}
+
+// AllocationRuleResponse represents an allocation rule
+type AllocationRuleResponse struct {
+	ID        string               `json:"id,omitempty"`
+	Name      string               `json:"name"`
+	TagKeys   []string             `json:"tag_keys"`
+	Aliases   map[string]string    `json:"aliases,omitempty"`
+	Splits    []AllocationSplitDTO `json:"splits,omitempty"`
+	CreatedAt *time.Time           `json:"created_at,omitempty"`
+	UpdatedAt *time.Time           `json:"updated_at,omitempty"`
+}
+
+// ListAllocationRulesResponse represents a list of allocation rules
+type ListAllocationRulesResponse struct {
+	Rules []AllocationRuleResponse `json:"rules"`
+	Total int                      `json:"total"`
+}
+
+// AllocationReportResponse represents costs allocated to groups
+type AllocationReportResponse struct {
+	Rule        AllocationRuleResponse    `json:"rule"`
+	Provider    string                    `json:"provider,omitempty"`
+	StartDate   string                    `json:"start_date"`
+	EndDate     string                    `json:"end_date"`
+	Granularity string                    `json:"granularity"`
+	TotalCost   float64                   `json:"total_cost"`
+	Currency    string                    `json:"currency"`
+	Groups      []AllocationGroupResponse `json:"groups"`
+}
+
+// AllocationGroupResponse represents one group's allocated costs
+type AllocationGroupResponse struct {
+	Name       string                     `json:"name"`
+	DirectCost float64                    `json:"direct_cost"`
+	SharedCost float64                    `json:"shared_cost"`
+	TotalCost  float64                    `json:"total_cost"`
+	Percentage float64                    `json:"percentage"`
+	Trend      []AllocationPeriodResponse `json:"trend"`
+}
+
+// AllocationPeriodResponse represents a group's cost in one period
+type AllocationPeriodResponse struct {
+	Start      string  `json:"start"`
+	DirectCost float64 `json:"direct_cost"`
+	SharedCost float64 `json:"shared_cost"`
+	TotalCost  float64 `json:"total_cost"`
+}
+
 // ======= Compliance DTOs =======
 
 // ComplianceFrameworkResponse represents a compliance framework
@@ -130,6 +307,14 @@ type ComplianceFrameworkResponse struct {
 	Description string `json:"description"`
 	Provider    string `json:"provider,omitempty"`
 	IsEnabled   bool   `json:"is_enabled"`
+	Source      string `json:"source"`
+	Revision    int    `json:"revision"`
+}
+
+// ImportFrameworkResponse is returned after a framework catalog is imported
+type ImportFrameworkResponse struct {
+	Framework ComplianceFrameworkResponse `json:"framework"`
+	Controls  int                         `json:"controls"`
 }
 
 // ListFrameworksResponse represents a list of frameworks
@@ -165,6 +350,7 @@ type ComplianceAssessmentResponse struct {
 	PassedControls        int       `json:"passed_controls"`
 	FailedControls        int       `json:"failed_controls"`
 	NotApplicableControls int       `json:"not_applicable_controls"`
+	ExceptedControls      int       `json:"excepted_controls"`
 	CompliancePercent     float64   `json:"compliance_percent"`
 	Status                string    `json:"status"`
 }
@@ -185,6 +371,7 @@ type ComplianceOverviewResponse struct {
 	TotalControls      int                      `json:"total_controls"`
 	PassedControls     int                      `json:"passed_controls"`
 	FailedControls     int                      `json:"failed_controls"`
+	ExceptedControls   int                      `json:"excepted_controls"`
 	CompliancePercent  float64                  `json:"compliance_percent"`
 	ByFramework        []FrameworkComplianceDTO `json:"by_framework"`
 	TopFailingControls []AssessmentFindingDTO   `json:"top_failing_controls,omitempty"`
@@ -198,6 +385,7 @@ type FrameworkComplianceDTO struct {
 	TotalControls     int     `json:"total_controls"`
 	PassedControls    int     `json:"passed_controls"`
 	FailedControls    int     `json:"failed_controls"`
+	ExceptedControls  int     `json:"excepted_controls"`
 	CompliancePercent float64 `json:"compliance_percent"`
 	LastAssessment    string  `json:"last_assessment,omitempty"`
 }
@@ -212,6 +400,24 @@ type AssessmentFindingDTO struct {
 	AffectedCount     int      `json:"affected_count"`
 	AffectedResources []string `json:"affected_resources,omitempty"`
 	Remediation       string   `json:"remediation"`
+	Evidence          string   `json:"evidence,omitempty"`
+	ExceptedResources []string `json:"excepted_resources,omitempty"`
+	ExceptionIDs      []string `json:"exception_ids,omitempty"`
+}
+
+// RequestExceptionRequest represents a request to except a failing control
+type RequestExceptionRequest struct {
+	FrameworkID   string    `json:"framework_id" validate:"required"`
+	ControlID     string    `json:"control_id" validate:"required"`
+	ResourceID    string    `json:"resource_id,omitempty"`
+	Justification string    `json:"justification" validate:"required"`
+	ExpiresAt     time.Time `json:"expires_at" validate:"required"`
+}
+
+// ApproveExceptionRequest represents a request to approve or reject an exception
+type ApproveExceptionRequest struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 // ComplianceTrendResponse represents compliance trend
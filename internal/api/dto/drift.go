@@ -17,6 +17,21 @@ type DriftDTO struct {
 	BaselineConfig  string                 `json:"baselineConfig,omitempty"`
 	CurrentConfig   string                 `json:"currentConfig,omitempty"`
 	RemediationTips []string               `json:"remediationTips,omitempty"`
+	LastSeenAt      *time.Time             `json:"lastSeenAt,omitempty"`
+	OccurrenceCount int                    `json:"occurrenceCount"`
+	ResolvedAt      *time.Time             `json:"resolvedAt,omitempty"`
+	Changes         []DriftChangeDTO       `json:"changes,omitempty"`
+}
+
+// DriftChangeDTO represents a single field-level change of a drift
+type DriftChangeDTO struct {
+	Path       string      `json:"path"`
+	Field      string      `json:"field"`
+	OldValue   interface{} `json:"oldValue"`
+	NewValue   interface{} `json:"newValue"`
+	ChangeType string      `json:"changeType"`
+	RuleID     string      `json:"ruleId,omitempty"`
+	Severity   string      `json:"severity,omitempty"`
 }
 
 // CreateDriftRequest represents a drift creation request
@@ -58,3 +73,48 @@ type DriftSummaryDTO struct {
 	Remediated int            `json:"remediated"`
 	ByType     map[string]int `json:"byType,omitempty"`
 }
+
+// DriftRuleDTO represents a drift classification rule in API responses.
+// Field names follow the rule file format so rules can be copied between
+// files and the API.
+type DriftRuleDTO struct {
+	ID            string                 `json:"id"`
+	Description   string                 `json:"description"`
+	ResourceTypes []string               `json:"resource_types,omitempty"`
+	Field         string                 `json:"field"`
+	ChangeTypes   []string               `json:"change_types,omitempty"`
+	Old           *DriftRulePredicateDTO `json:"old,omitempty"`
+	New           *DriftRulePredicateDTO `json:"new,omitempty"`
+	Severity      string                 `json:"severity"`
+	DriftType     string                 `json:"drift_type"`
+	Enabled       bool                   `json:"enabled"`
+	Builtin       bool                   `json:"builtin"`
+	Source        string                 `json:"source,omitempty"`
+	CreatedAt     *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt     *time.Time             `json:"updated_at,omitempty"`
+}
+
+// DriftRulePredicateDTO constrains the old or new value of a change
+type DriftRulePredicateDTO struct {
+	Equals    interface{}   `json:"equals,omitempty"`
+	NotEquals interface{}   `json:"not_equals,omitempty"`
+	In        []interface{} `json:"in,omitempty"`
+	Contains  []string      `json:"contains,omitempty"`
+	Matches   string        `json:"matches,omitempty"`
+	Truthy    *bool         `json:"truthy,omitempty"`
+	Exists    *bool         `json:"exists,omitempty"`
+}
+
+// DriftRuleRequest represents a custom drift rule create or update request
+type DriftRuleRequest struct {
+	ID            string                 `json:"id" validate:"required"`
+	Description   string                 `json:"description"`
+	ResourceTypes []string               `json:"resource_types,omitempty"`
+	Field         string                 `json:"field" validate:"required"`
+	ChangeTypes   []string               `json:"change_types,omitempty"`
+	Old           *DriftRulePredicateDTO `json:"old,omitempty"`
+	New           *DriftRulePredicateDTO `json:"new,omitempty"`
+	Severity      string                 `json:"severity" validate:"required,oneof=critical high medium low"`
+	DriftType     string                 `json:"drift_type" validate:"required"`
+	Enabled       *bool                  `json:"enabled,omitempty"`
+}
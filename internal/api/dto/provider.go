@@ -4,20 +4,46 @@ import "time"
 
 // ProviderDTO represents a cloud provider account in API responses
 type ProviderDTO struct {
+	ID          int64      `json:"id"`
 	Provider    string     `json:"provider"`
+	Name        string     `json:"name"`
 	IsConnected bool       `json:"is_connected"`
 	LastSynced  *time.Time `json:"last_synced,omitempty"`
+	SyncStatus  string     `json:"sync_status,omitempty"`
+	SyncMessage string     `json:"sync_message,omitempty"`
+	// Verification is the result of checking the credentials, returned
+	// when the account has just been connected
+	Verification *ProviderVerificationDTO `json:"verification,omitempty"`
+}
+
+// ProviderVerificationDTO is the result of verifying provider credentials:
+// who they act as and which read permissions resource sync needs are missing
+type ProviderVerificationDTO struct {
+	Identity           string   `json:"identity"`
+	Account            string   `json:"account"`
+	PermissionsChecked []string `json:"permissions_checked"`
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
 }
 
 // ConnectProviderRequest represents a provider connection request
 type ConnectProviderRequest struct {
 	Provider string `json:"provider" validate:"required,oneof=aws gcp azure"`
+	// Name of the account; accounts without a name are called "default"
+	Name string `json:"name,omitempty" validate:"omitempty,max=100"`
 
 	// AWS credentials
 	AWSAccessKeyID     *string `json:"aws_access_key_id,omitempty"`
 	AWSSecretAccessKey *string `json:"aws_secret_access_key,omitempty"`
 	AWSRegion          *string `json:"aws_region,omitempty"`
 
+	// AWS roles, for connecting without long-lived access keys. The role
+	// chain is assumed in order from the access keys or the server's
+	// instance profile. Each target is connected as its own account.
+	AWSRoleChain              []AWSRoleRequest `json:"aws_role_chain,omitempty"`
+	AWSTargets                []AWSRoleRequest `json:"aws_targets,omitempty"`
+	AWSOrganizationRoleName   *string          `json:"aws_organization_role_name,omitempty"`
+	AWSOrganizationExternalID *string          `json:"aws_organization_external_id,omitempty"`
+
 	// GCP credentials
 	GCPProjectID          *string `json:"gcp_project_id,omitempty"`
 	GCPServiceAccountJSON *string `json:"gcp_service_account_json,omitempty"`
@@ -31,6 +57,20 @@ type ConnectProviderRequest struct {
 	AzureLocation       *string `json:"azure_location,omitempty"`
 }
 
+// AWSRoleRequest names an IAM role to assume. The name is only used for
+// targets and defaults to the role's account ID.
+type AWSRoleRequest struct {
+	Name       string `json:"name,omitempty"`
+	RoleARN    string `json:"role_arn"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+// AWSExternalIDResponse is the external ID the trust policy of a role must
+// require when the server assumes it with its own AWS identity
+type AWSExternalIDResponse struct {
+	ExternalID string `json:"external_id"`
+}
+
 // SyncProviderRequest represents a provider sync request
 type SyncProviderRequest struct {
 	Provider string `json:"provider" validate:"required,oneof=aws gcp azure"`
@@ -39,6 +79,8 @@ type SyncProviderRequest struct {
 // ProviderStatusResponse represents provider status information
 type ProviderStatusResponse struct {
 	Provider      string     `json:"provider"`
+	AccountID     int64      `json:"account_id"`
+	AccountName   string     `json:"account_name"`
 	IsConnected   bool       `json:"is_connected"`
 	LastSynced    *time.Time `json:"last_synced,omitempty"`
 	ResourceCount int        `json:"resource_count"`
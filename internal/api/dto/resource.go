@@ -8,6 +8,7 @@ type ResourceDTO struct {
 	ID            int64             `json:"id"`
 	ResourceID    string            `json:"resourceId,omitempty"`
 	Provider      string            `json:"provider"`
+	AccountID     int64             `json:"accountId,omitempty"`
 	Name          string            `json:"name"`
 	Type          string            `json:"type"`
 	Region        string            `json:"region"`
@@ -17,6 +18,9 @@ type ResourceDTO struct {
 	Configuration string            `json:"configuration,omitempty"`
 	CreatedAt     time.Time         `json:"createdAt"`
 	UpdatedAt     time.Time         `json:"updatedAt,omitempty"`
+	// MissingSince is set on terminated resources that vanished from their
+	// provider and will be deleted after a grace period
+	MissingSince *time.Time `json:"missingSince,omitempty"`
 }
 
 // CreateResourceRequest represents a resource creation request
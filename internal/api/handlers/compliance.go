@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pratik-mahalle/infraudit/internal/api/dto"
 	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 )
 
@@ -39,14 +42,7 @@ func (h *ComplianceHandler) ListFrameworks(w http.ResponseWriter, r *http.Reques
 	}
 
 	for _, f := range frameworks {
-		response.Frameworks = append(response.Frameworks, dto.ComplianceFrameworkResponse{
-			ID:          f.ID,
-			Name:        f.Name,
-			Version:     f.Version,
-			Description: f.Description,
-			Provider:    f.Provider,
-			IsEnabled:   f.IsEnabled,
-		})
+		response.Frameworks = append(response.Frameworks, convertFrameworkToDTO(f))
 	}
 
 	respondJSON(w, http.StatusOK, response)
@@ -66,13 +62,50 @@ func (h *ComplianceHandler) GetFramework(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, dto.ComplianceFrameworkResponse{
-		ID:          framework.ID,
-		Name:        framework.Name,
-		Version:     framework.Version,
-		Description: framework.Description,
-		Provider:    framework.Provider,
-		IsEnabled:   framework.IsEnabled,
+	respondJSON(w, http.StatusOK, convertFrameworkToDTO(framework))
+}
+
+// maxFrameworkImportBytes caps the size of an imported framework catalog
+const maxFrameworkImportBytes = 4 << 20
+
+// ImportFramework handles POST /api/v1/compliance/frameworks/import. The
+// body is a framework catalog, read as YAML when the content type says so
+// and as JSON otherwise.
+func (h *ComplianceHandler) ImportFramework(w http.ResponseWriter, r *http.Request) {
+	if getUserIDFromContext(r.Context()) == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFrameworkImportBytes))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "catalog is too large or could not be read")
+		return
+	}
+
+	format := "json"
+	if strings.Contains(strings.ToLower(r.Header.Get("Content-Type")), "yaml") {
+		format = "yaml"
+	}
+
+	catalog, err := h.complianceService.ImportFramework(r.Context(), data, format)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+			message := appErr.Message
+			if details, ok := appErr.Details.(string); ok && details != "" {
+				message += ": " + details
+			}
+			respondError(w, appErr.StatusCode, message)
+			return
+		}
+		h.logger.ErrorWithErr(err, "Failed to import framework")
+		respondError(w, http.StatusInternalServerError, "failed to import framework")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, dto.ImportFrameworkResponse{
+		Framework: convertFrameworkToDTO(catalog.Framework),
+		Controls:  len(catalog.Controls),
 	})
 }
 
@@ -183,6 +216,7 @@ func (h *ComplianceHandler) RunAssessment(w http.ResponseWriter, r *http.Request
 		PassedControls:        assessment.PassedControls,
 		FailedControls:        assessment.FailedControls,
 		NotApplicableControls: assessment.NotApplicableControls,
+		ExceptedControls:      assessment.ExceptedControls,
 		CompliancePercent:     assessment.CompliancePercent,
 		Status:                assessment.Status,
 	})
@@ -225,6 +259,7 @@ func (h *ComplianceHandler) ListAssessments(w http.ResponseWriter, r *http.Reque
 			PassedControls:        a.PassedControls,
 			FailedControls:        a.FailedControls,
 			NotApplicableControls: a.NotApplicableControls,
+			ExceptedControls:      a.ExceptedControls,
 			CompliancePercent:     a.CompliancePercent,
 			Status:                a.Status,
 		})
@@ -261,6 +296,9 @@ func (h *ComplianceHandler) GetAssessment(w http.ResponseWriter, r *http.Request
 				AffectedCount:     f.AffectedCount,
 				AffectedResources: f.AffectedResources,
 				Remediation:       f.Remediation,
+				Evidence:          f.Evidence,
+				ExceptedResources: f.ExceptedResources,
+				ExceptionIDs:      f.ExceptionIDs,
 			})
 		}
 	}
@@ -275,6 +313,7 @@ func (h *ComplianceHandler) GetAssessment(w http.ResponseWriter, r *http.Request
 			PassedControls:        assessment.PassedControls,
 			FailedControls:        assessment.FailedControls,
 			NotApplicableControls: assessment.NotApplicableControls,
+			ExceptedControls:      assessment.ExceptedControls,
 			CompliancePercent:     assessment.CompliancePercent,
 			Status:                assessment.Status,
 		},
@@ -301,6 +340,7 @@ func (h *ComplianceHandler) GetOverview(w http.ResponseWriter, r *http.Request)
 		TotalControls:     overview.TotalControls,
 		PassedControls:    overview.PassedControls,
 		FailedControls:    overview.FailedControls,
+		ExceptedControls:  overview.ExceptedControls,
 		CompliancePercent: overview.CompliancePercent,
 		ByFramework:       make([]dto.FrameworkComplianceDTO, 0, len(overview.ByFramework)),
 		BySeverity:        overview.BySeverity,
@@ -313,6 +353,7 @@ func (h *ComplianceHandler) GetOverview(w http.ResponseWriter, r *http.Request)
 			TotalControls:     fc.TotalControls,
 			PassedControls:    fc.PassedControls,
 			FailedControls:    fc.FailedControls,
+			ExceptedControls:  fc.ExceptedControls,
 			CompliancePercent: fc.CompliancePercent,
 			LastAssessment:    fc.LastAssessment,
 		})
@@ -401,26 +442,265 @@ func (h *ComplianceHandler) GetFailingControls(w http.ResponseWriter, r *http.Re
 			AffectedCount:     f.AffectedCount,
 			AffectedResources: f.AffectedResources,
 			Remediation:       f.Remediation,
+			Evidence:          f.Evidence,
+			ExceptedResources: f.ExceptedResources,
+			ExceptionIDs:      f.ExceptionIDs,
 		})
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{"failing_controls": response})
 }
 
-// ExportAssessment handles GET /api/v1/compliance/assessments/{id}/export
+// GetResourceCompliance handles GET /api/v1/compliance/resources/{id}
+func (h *ComplianceHandler) GetResourceCompliance(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	resourceID := chi.URLParam(r, "id")
+	if resourceID == "" {
+		respondError(w, http.StatusBadRequest, "resource id is required")
+		return
+	}
+
+	status, err := h.complianceService.GetResourceCompliance(r.Context(), userID, resourceID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			respondError(w, http.StatusNotFound, "resource not found")
+			return
+		}
+		h.logger.ErrorWithErr(err, "Failed to get resource compliance")
+		respondError(w, http.StatusInternalServerError, "failed to get resource compliance")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// GetCrosswalk handles GET /api/v1/compliance/crosswalk
+func (h *ComplianceHandler) GetCrosswalk(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	crosswalk, err := h.complianceService.GetCrosswalk(r.Context(), userID, r.URL.Query().Get("framework_id"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			respondError(w, http.StatusNotFound, "framework not found")
+			return
+		}
+		h.logger.ErrorWithErr(err, "Failed to get compliance crosswalk")
+		respondError(w, http.StatusInternalServerError, "failed to get crosswalk")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, crosswalk)
+}
+
+// reportContentTypes maps report formats to their content type and file
+// name suffix
+var reportContentTypes = map[string][2]string{
+	compliance.ReportFormatHTML:  {"text/html; charset=utf-8", ".html"},
+	compliance.ReportFormatPDF:   {"application/pdf", ".pdf"},
+	compliance.ReportFormatCSV:   {"text/csv", ".csv"},
+	compliance.ReportFormatOSCAL: {"application/json", "-oscal.json"},
+}
+
+// ExportAssessment handles GET /api/v1/compliance/assessments/{id}/export.
+// format=html, pdf, csv or oscal downloads a rendered report; the default
+// is the JSON export.
 func (h *ComplianceHandler) ExportAssessment(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
 	assessmentID := chi.URLParam(r, "id")
 	if assessmentID == "" {
 		respondError(w, http.StatusBadRequest, "assessment id is required")
 		return
 	}
 
-	export, err := h.complianceService.ExportAssessment(r.Context(), assessmentID)
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format != "" && format != compliance.ReportFormatJSON {
+		contentType, ok := reportContentTypes[format]
+		if !ok {
+			respondError(w, http.StatusBadRequest, "format must be json, html, pdf, csv or oscal")
+			return
+		}
+
+		report, err := h.complianceService.GenerateReport(r.Context(), userID, assessmentID, format)
+		if err != nil {
+			h.respondComplianceError(w, err, "failed to generate report")
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType[0])
+		w.Header().Set("Content-Disposition", `attachment; filename="compliance-report-`+assessmentID+contentType[1]+`"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(report); err != nil {
+			h.logger.ErrorWithErr(err, "Failed to write compliance report")
+		}
+		return
+	}
+
+	export, err := h.complianceService.ExportAssessment(r.Context(), userID, assessmentID)
 	if err != nil {
-		h.logger.ErrorWithErr(err, "Failed to export assessment")
-		respondError(w, http.StatusNotFound, "assessment not found")
+		h.respondComplianceError(w, err, "failed to export assessment")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, export)
 }
+
+func convertFrameworkToDTO(f *compliance.Framework) dto.ComplianceFrameworkResponse {
+	return dto.ComplianceFrameworkResponse{
+		ID:          f.ID,
+		Name:        f.Name,
+		Version:     f.Version,
+		Description: f.Description,
+		Provider:    f.Provider,
+		IsEnabled:   f.IsEnabled,
+		Source:      f.Source,
+		Revision:    f.Revision,
+	}
+}
+
+// ListExceptions handles GET /api/v1/compliance/exceptions
+func (h *ComplianceHandler) ListExceptions(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	exceptions, err := h.complianceService.ListExceptions(r.Context(), userID, compliance.ExceptionFilter{
+		FrameworkID: query.Get("framework_id"),
+		ControlID:   query.Get("control_id"),
+		ResourceID:  query.Get("resource_id"),
+		Status:      query.Get("status"),
+	})
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to list compliance exceptions")
+		respondError(w, http.StatusInternalServerError, "failed to list exceptions")
+		return
+	}
+	if exceptions == nil {
+		exceptions = []*compliance.Exception{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"exceptions": exceptions,
+		"total":      len(exceptions),
+	})
+}
+
+// RequestException handles POST /api/v1/compliance/exceptions
+func (h *ComplianceHandler) RequestException(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.RequestExceptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	exception, err := h.complianceService.RequestException(r.Context(), userID, &compliance.Exception{
+		FrameworkID:   req.FrameworkID,
+		ControlID:     req.ControlID,
+		ResourceID:    req.ResourceID,
+		Justification: req.Justification,
+		ExpiresAt:     req.ExpiresAt,
+	})
+	if err != nil {
+		h.respondComplianceError(w, err, "failed to request exception")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, exception)
+}
+
+// GetException handles GET /api/v1/compliance/exceptions/{id}
+func (h *ComplianceHandler) GetException(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	exception, err := h.complianceService.GetException(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondComplianceError(w, err, "failed to get exception")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, exception)
+}
+
+// ApproveException handles POST /api/v1/compliance/exceptions/{id}/approve.
+// The body approves or rejects a pending exception, as for remediation actions.
+func (h *ComplianceHandler) ApproveException(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.ApproveExceptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	exceptionID := chi.URLParam(r, "id")
+	var exception *compliance.Exception
+	var err error
+	if req.Approved {
+		exception, err = h.complianceService.ApproveException(r.Context(), userID, exceptionID)
+	} else {
+		exception, err = h.complianceService.RejectException(r.Context(), userID, exceptionID, req.Reason)
+	}
+	if err != nil {
+		h.respondComplianceError(w, err, "failed to review exception")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, exception)
+}
+
+// RevokeException handles POST /api/v1/compliance/exceptions/{id}/revoke
+func (h *ComplianceHandler) RevokeException(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	exception, err := h.complianceService.RevokeException(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondComplianceError(w, err, "failed to revoke exception")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, exception)
+}
+
+// respondComplianceError reports validation, not-found and conflict errors
+// to the client and hides everything else behind a generic message
+func (h *ComplianceHandler) respondComplianceError(w http.ResponseWriter, err error, message string) {
+	if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+		if detail, ok := appErr.Details.(string); ok && detail != "" {
+			respondError(w, appErr.StatusCode, appErr.Message+": "+detail)
+			return
+		}
+		respondError(w, appErr.StatusCode, appErr.Message)
+		return
+	}
+	h.logger.ErrorWithErr(err, message)
+	respondError(w, http.StatusInternalServerError, message)
+}
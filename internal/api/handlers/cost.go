@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pratik-mahalle/infraudit/internal/api/dto"
 	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/utils"
 )
 
 // CostHandler handles cost-related HTTP requests
@@ -161,23 +168,41 @@ func (h *CostHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
 		days = 30
 	}
 
-	forecast, err := h.costService.GetCostForecast(r.Context(), userID, provider, days)
+	opts := cost.ForecastOptions{
+		Method:      r.URL.Query().Get("method"),
+		Granularity: r.URL.Query().Get("granularity"),
+	}
+
+	forecast, err := h.costService.GetCostForecast(r.Context(), userID, provider, days, opts)
 	if err != nil {
-		h.logger.ErrorWithErr(err, "Failed to get cost forecast")
-		respondError(w, http.StatusInternalServerError, "failed to get forecast")
+		h.respondCostError(w, err, "failed to get forecast")
 		return
 	}
 
 	response := dto.CostForecastResponse{
 		Provider:        forecast.Provider,
 		Period:          forecast.Period,
+		Method:          forecast.Method,
+		Granularity:     forecast.Granularity,
 		ForecastedCost:  forecast.ForecastedCost,
 		ConfidenceLevel: forecast.ConfidenceLevel,
 		LowerBound:      forecast.LowerBound,
 		UpperBound:      forecast.UpperBound,
+		Points:          make([]dto.ForecastPointDTO, 0, len(forecast.Points)),
+		HistoryDays:     forecast.HistoryDays,
+		MAPE:            forecast.MAPE,
+		BacktestDays:    forecast.BacktestDays,
 		Currency:        forecast.Currency,
 		EndDate:         forecast.EndDate.Format("2006-01-02"),
 	}
+	for _, p := range forecast.Points {
+		response.Points = append(response.Points, dto.ForecastPointDTO{
+			Date:           p.Date.Format("2006-01-02"),
+			ForecastedCost: p.ForecastedCost,
+			LowerBound:     p.LowerBound,
+			UpperBound:     p.UpperBound,
+		})
+	}
 
 	respondJSON(w, http.StatusOK, response)
 }
@@ -196,7 +221,7 @@ func (h *CostHandler) SyncCosts(w http.ResponseWriter, r *http.Request) {
 	if provider != "" {
 		err = h.costService.SyncCosts(r.Context(), userID, provider)
 	} else {
-		err = h.costService.SyncAllProviders(r.Context(), userID)
+		_, err = h.costService.SyncAllProviders(r.Context(), userID)
 	}
 
 	if err != nil {
@@ -236,20 +261,7 @@ func (h *CostHandler) ListAnomalies(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, a := range anomalies {
-		response.Anomalies = append(response.Anomalies, dto.CostAnomalyResponse{
-			ID:           a.ID,
-			Provider:     a.Provider,
-			ServiceName:  a.ServiceName,
-			ResourceID:   a.ResourceID,
-			AnomalyType:  a.AnomalyType,
-			ExpectedCost: a.ExpectedCost,
-			ActualCost:   a.ActualCost,
-			Deviation:    a.Deviation,
-			Severity:     a.Severity,
-			Status:       a.Status,
-			Notes:        a.Notes,
-			DetectedAt:   a.DetectedAt,
-		})
+		response.Anomalies = append(response.Anomalies, mapAnomalyToResponse(a))
 	}
 
 	respondJSON(w, http.StatusOK, response)
@@ -272,9 +284,15 @@ func (h *CostHandler) DetectAnomalies(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	detected := make([]dto.CostAnomalyResponse, 0, len(anomalies))
+	for _, a := range anomalies {
+		detected = append(detected, mapAnomalyToResponse(a))
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message":  "anomaly detection completed",
-		"detected": len(anomalies),
+		"message":   "anomaly detection completed",
+		"detected":  len(anomalies),
+		"anomalies": detected,
 	})
 }
 
@@ -306,26 +324,44 @@ func (h *CostHandler) ListOptimizations(w http.ResponseWriter, r *http.Request)
 	}
 
 	for _, o := range optimizations {
-		response.Optimizations = append(response.Optimizations, dto.CostOptimizationResponse{
-			ID:               o.ID,
-			Provider:         o.Provider,
-			ResourceID:       o.ResourceID,
-			ResourceType:     o.ResourceType,
-			OptimizationType: o.OptimizationType,
-			Title:            o.Title,
-			Description:      o.Description,
-			CurrentCost:      o.CurrentCost,
-			EstimatedSavings: o.EstimatedSavings,
-			SavingsPercent:   o.SavingsPercent,
-			Implementation:   o.Implementation,
-			Status:           o.Status,
-			Details:          o.Details,
-		})
+		response.Optimizations = append(response.Optimizations, mapOptimizationToResponse(o))
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// AnalyzeOptimizations handles POST /api/v1/costs/optimizations/analyze
+func (h *CostHandler) AnalyzeOptimizations(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	provider := r.URL.Query().Get("provider")
+
+	optimizations, err := h.costService.GenerateOptimizations(r.Context(), userID, provider)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to analyze optimizations")
+		respondError(w, http.StatusInternalServerError, "failed to analyze optimizations")
+		return
+	}
+
+	found := make([]dto.CostOptimizationResponse, 0, len(optimizations))
+	var savings float64
+	for _, o := range optimizations {
+		found = append(found, mapOptimizationToResponse(o))
+		savings += o.EstimatedSavings
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":           "optimization analysis completed",
+		"found":             len(optimizations),
+		"estimated_savings": savings,
+		"optimizations":     found,
+	})
+}
+
 // GetSavings handles GET /api/v1/costs/savings
 func (h *CostHandler) GetSavings(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r.Context())
@@ -346,3 +382,575 @@ func (h *CostHandler) GetSavings(w http.ResponseWriter, r *http.Request) {
 		"currency":          "USD",
 	})
 }
+
+// ListBudgets handles GET /api/v1/costs/budgets
+func (h *CostHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	budgets, err := h.costService.ListBudgets(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to list budgets")
+		respondError(w, http.StatusInternalServerError, "failed to list budgets")
+		return
+	}
+
+	response := dto.ListBudgetsResponse{
+		Budgets: make([]dto.BudgetResponse, 0, len(budgets)),
+		Total:   len(budgets),
+	}
+	for _, b := range budgets {
+		response.Budgets = append(response.Budgets, mapBudgetToResponse(b))
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// CreateBudget handles POST /api/v1/costs/budgets
+func (h *CostHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.CreateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	budget := &cost.Budget{
+		Name:       req.Name,
+		Period:     req.Period,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+		Scope:      mapBudgetScope(req.Scope),
+		Thresholds: mapBudgetThresholds(req.Thresholds),
+		IsEnabled:  req.IsEnabled == nil || *req.IsEnabled,
+	}
+
+	created, err := h.costService.CreateBudget(r.Context(), userID, budget)
+	if err != nil {
+		h.respondCostError(w, err, "failed to create budget")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, mapBudgetToResponse(created))
+}
+
+// GetBudget handles GET /api/v1/costs/budgets/{id}
+func (h *CostHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	budget, err := h.costService.GetBudget(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondCostError(w, err, "failed to get budget")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mapBudgetToResponse(budget))
+}
+
+// UpdateBudget handles PUT /api/v1/costs/budgets/{id}
+func (h *CostHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.UpdateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	budget, err := h.costService.GetBudget(r.Context(), userID, id)
+	if err != nil {
+		h.respondCostError(w, err, "failed to get budget")
+		return
+	}
+
+	if req.Name != nil {
+		budget.Name = *req.Name
+	}
+	if req.Period != nil {
+		budget.Period = *req.Period
+	}
+	if req.Amount != nil {
+		budget.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		budget.Currency = *req.Currency
+	}
+	if req.Scope != nil {
+		budget.Scope = mapBudgetScope(*req.Scope)
+	}
+	if req.Thresholds != nil {
+		budget.Thresholds = mapBudgetThresholds(req.Thresholds)
+	}
+	if req.IsEnabled != nil {
+		budget.IsEnabled = *req.IsEnabled
+	}
+
+	updated, err := h.costService.UpdateBudget(r.Context(), userID, id, budget)
+	if err != nil {
+		h.respondCostError(w, err, "failed to update budget")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mapBudgetToResponse(updated))
+}
+
+// DeleteBudget handles DELETE /api/v1/costs/budgets/{id}
+func (h *CostHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.costService.DeleteBudget(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.respondCostError(w, err, "failed to delete budget")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "budget deleted"})
+}
+
+// GetBudgetStatus handles GET /api/v1/costs/budgets/{id}/status
+func (h *CostHandler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.costService.GetBudgetStatus(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondCostError(w, err, "failed to get budget status")
+		return
+	}
+
+	response := dto.BudgetStatusResponse{
+		Budget:           mapBudgetToResponse(status.Budget),
+		PeriodStart:      status.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:        status.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		ActualSpend:      status.ActualSpend,
+		ProjectedSpend:   status.ProjectedSpend,
+		PercentUsed:      status.PercentUsed,
+		ProjectedPercent: status.ProjectedPercent,
+		Crossed:          mapBudgetThresholdsToDTO(status.Crossed),
+		Alerts:           make([]dto.BudgetAlertResponse, 0, len(status.Alerts)),
+	}
+	for _, a := range status.Alerts {
+		response.Alerts = append(response.Alerts, dto.BudgetAlertResponse{
+			PeriodKey:        a.PeriodKey,
+			ThresholdType:    a.ThresholdType,
+			ThresholdPercent: a.ThresholdPercent,
+			Spend:            a.Spend,
+			BudgetAmount:     a.BudgetAmount,
+			TriggeredAt:      a.TriggeredAt,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// GetAllocation handles GET /api/v1/costs/allocation. The report groups
+// costs by a saved rule (rule_id) or by ad hoc tag keys (tag_keys, comma
+// separated); format=csv returns one row per group and period for showback.
+func (h *CostHandler) GetAllocation(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	q := r.URL.Query()
+	query := cost.AllocationQuery{
+		RuleID:      q.Get("rule_id"),
+		Provider:    q.Get("provider"),
+		Granularity: q.Get("granularity"),
+	}
+	for _, key := range strings.Split(q.Get("tag_keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			query.TagKeys = append(query.TagKeys, key)
+		}
+	}
+
+	var err error
+	if v := q.Get("start"); v != "" {
+		if query.StartDate, err = time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, "start must be a date like 2006-01-02")
+			return
+		}
+	}
+	if v := q.Get("end"); v != "" {
+		if query.EndDate, err = time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, "end must be a date like 2006-01-02")
+			return
+		}
+		// Include the whole end day
+		query.EndDate = query.EndDate.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	report, err := h.costService.GetCostAllocation(r.Context(), userID, query)
+	if err != nil {
+		h.respondCostError(w, err, "failed to get cost allocation")
+		return
+	}
+
+	if format == "csv" {
+		h.writeAllocationCSV(w, report)
+		return
+	}
+
+	response := dto.AllocationReportResponse{
+		Rule:        mapAllocationRuleToResponse(report.Rule),
+		Provider:    report.Provider,
+		StartDate:   report.StartDate.Format("2006-01-02"),
+		EndDate:     report.EndDate.Format("2006-01-02"),
+		Granularity: report.Granularity,
+		TotalCost:   report.TotalCost,
+		Currency:    report.Currency,
+		Groups:      make([]dto.AllocationGroupResponse, 0, len(report.Groups)),
+	}
+	for _, g := range report.Groups {
+		group := dto.AllocationGroupResponse{
+			Name:       g.Name,
+			DirectCost: g.DirectCost,
+			SharedCost: g.SharedCost,
+			TotalCost:  g.TotalCost,
+			Percentage: g.Percentage,
+			Trend:      make([]dto.AllocationPeriodResponse, 0, len(g.Trend)),
+		}
+		for _, p := range g.Trend {
+			group.Trend = append(group.Trend, dto.AllocationPeriodResponse{
+				Start:      p.Start.Format("2006-01-02"),
+				DirectCost: p.DirectCost,
+				SharedCost: p.SharedCost,
+				TotalCost:  p.TotalCost,
+			})
+		}
+		response.Groups = append(response.Groups, group)
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// writeAllocationCSV writes an allocation report as CSV
+func (h *CostHandler) writeAllocationCSV(w http.ResponseWriter, report *cost.AllocationReport) {
+	filename := fmt.Sprintf("cost-allocation-%s-%s.csv",
+		report.StartDate.Format("20060102"), report.EndDate.Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"group", "period_start", "direct_cost", "shared_cost", "total_cost", "currency"})
+	for _, g := range report.Groups {
+		for _, p := range g.Trend {
+			_ = writer.Write([]string{
+				utils.CSVText(g.Name),
+				p.Start.Format("2006-01-02"),
+				strconv.FormatFloat(p.DirectCost, 'f', 2, 64),
+				strconv.FormatFloat(p.SharedCost, 'f', 2, 64),
+				strconv.FormatFloat(p.TotalCost, 'f', 2, 64),
+				report.Currency,
+			})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.ErrorWithErr(err, "Failed to write allocation CSV")
+	}
+}
+
+// ListAllocationRules handles GET /api/v1/costs/allocation/rules
+func (h *CostHandler) ListAllocationRules(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rules, err := h.costService.ListAllocationRules(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to list allocation rules")
+		respondError(w, http.StatusInternalServerError, "failed to list allocation rules")
+		return
+	}
+
+	response := dto.ListAllocationRulesResponse{
+		Rules: make([]dto.AllocationRuleResponse, 0, len(rules)),
+		Total: len(rules),
+	}
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, mapAllocationRuleToResponse(rule))
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// CreateAllocationRule handles POST /api/v1/costs/allocation/rules
+func (h *CostHandler) CreateAllocationRule(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.CreateAllocationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	rule := &cost.AllocationRule{
+		Name:    req.Name,
+		TagKeys: req.TagKeys,
+		Aliases: req.Aliases,
+		Splits:  mapAllocationSplits(req.Splits),
+	}
+
+	created, err := h.costService.CreateAllocationRule(r.Context(), userID, rule)
+	if err != nil {
+		h.respondCostError(w, err, "failed to create allocation rule")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, mapAllocationRuleToResponse(created))
+}
+
+// GetAllocationRule handles GET /api/v1/costs/allocation/rules/{id}
+func (h *CostHandler) GetAllocationRule(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rule, err := h.costService.GetAllocationRule(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondCostError(w, err, "failed to get allocation rule")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mapAllocationRuleToResponse(rule))
+}
+
+// UpdateAllocationRule handles PUT /api/v1/costs/allocation/rules/{id}
+func (h *CostHandler) UpdateAllocationRule(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.UpdateAllocationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	rule, err := h.costService.GetAllocationRule(r.Context(), userID, id)
+	if err != nil {
+		h.respondCostError(w, err, "failed to get allocation rule")
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.TagKeys != nil {
+		rule.TagKeys = req.TagKeys
+	}
+	if req.Aliases != nil {
+		rule.Aliases = req.Aliases
+	}
+	if req.Splits != nil {
+		rule.Splits = mapAllocationSplits(req.Splits)
+	}
+
+	updated, err := h.costService.UpdateAllocationRule(r.Context(), userID, id, rule)
+	if err != nil {
+		h.respondCostError(w, err, "failed to update allocation rule")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mapAllocationRuleToResponse(updated))
+}
+
+// DeleteAllocationRule handles DELETE /api/v1/costs/allocation/rules/{id}
+func (h *CostHandler) DeleteAllocationRule(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.costService.DeleteAllocationRule(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.respondCostError(w, err, "failed to delete allocation rule")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "allocation rule deleted"})
+}
+
+// respondCostError reports validation and not-found errors to the client
+// and hides everything else behind a generic message
+func (h *CostHandler) respondCostError(w http.ResponseWriter, err error, message string) {
+	if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+		if detail, ok := appErr.Details.(string); ok && detail != "" {
+			respondError(w, appErr.StatusCode, appErr.Message+": "+detail)
+			return
+		}
+		respondError(w, appErr.StatusCode, appErr.Message)
+		return
+	}
+	h.logger.ErrorWithErr(err, message)
+	respondError(w, http.StatusInternalServerError, message)
+}
+
+func mapAnomalyToResponse(a *cost.CostAnomaly) dto.CostAnomalyResponse {
+	resp := dto.CostAnomalyResponse{
+		ID:           a.ID,
+		Provider:     a.Provider,
+		ServiceName:  a.ServiceName,
+		ResourceID:   a.ResourceID,
+		AnomalyType:  a.AnomalyType,
+		ExpectedCost: a.ExpectedCost,
+		ActualCost:   a.ActualCost,
+		Deviation:    a.Deviation,
+		Severity:     a.Severity,
+		Status:       a.Status,
+		Notes:        a.Notes,
+		DetectedAt:   a.DetectedAt,
+	}
+	for _, d := range a.Drivers {
+		resp.Drivers = append(resp.Drivers, dto.CostDriverDTO{
+			Dimension:    d.Dimension,
+			Name:         d.Name,
+			ExpectedCost: d.ExpectedCost,
+			ActualCost:   d.ActualCost,
+			Delta:        d.Delta,
+			Share:        d.Share,
+		})
+	}
+	return resp
+}
+
+func mapOptimizationToResponse(o *cost.CostOptimization) dto.CostOptimizationResponse {
+	return dto.CostOptimizationResponse{
+		ID:               o.ID,
+		Provider:         o.Provider,
+		ResourceID:       o.ResourceID,
+		ResourceType:     o.ResourceType,
+		OptimizationType: o.OptimizationType,
+		Title:            o.Title,
+		Description:      o.Description,
+		CurrentCost:      o.CurrentCost,
+		EstimatedSavings: o.EstimatedSavings,
+		SavingsPercent:   o.SavingsPercent,
+		Implementation:   o.Implementation,
+		Status:           o.Status,
+		Details:          o.Details,
+	}
+}
+
+func mapBudgetToResponse(b *cost.Budget) dto.BudgetResponse {
+	return dto.BudgetResponse{
+		ID:       b.ID,
+		Name:     b.Name,
+		Period:   b.Period,
+		Amount:   b.Amount,
+		Currency: b.Currency,
+		Scope: dto.BudgetScopeDTO{
+			Provider:    b.Scope.Provider,
+			ServiceName: b.Scope.ServiceName,
+			Region:      b.Scope.Region,
+			TagKey:      b.Scope.TagKey,
+			TagValue:    b.Scope.TagValue,
+		},
+		Thresholds: mapBudgetThresholdsToDTO(b.Thresholds),
+		IsEnabled:  b.IsEnabled,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+}
+
+func mapBudgetScope(s dto.BudgetScopeDTO) cost.BudgetScope {
+	return cost.BudgetScope{
+		Provider:    s.Provider,
+		ServiceName: s.ServiceName,
+		Region:      s.Region,
+		TagKey:      s.TagKey,
+		TagValue:    s.TagValue,
+	}
+}
+
+func mapBudgetThresholds(thresholds []dto.BudgetThresholdDTO) []cost.BudgetThreshold {
+	result := make([]cost.BudgetThreshold, 0, len(thresholds))
+	for _, t := range thresholds {
+		result = append(result, cost.BudgetThreshold{Percent: t.Percent, Type: t.Type})
+	}
+	return result
+}
+
+func mapBudgetThresholdsToDTO(thresholds []cost.BudgetThreshold) []dto.BudgetThresholdDTO {
+	result := make([]dto.BudgetThresholdDTO, 0, len(thresholds))
+	for _, t := range thresholds {
+		result = append(result, dto.BudgetThresholdDTO{Percent: t.Percent, Type: t.Type})
+	}
+	return result
+}
+
+func mapAllocationRuleToResponse(rule *cost.AllocationRule) dto.AllocationRuleResponse {
+	resp := dto.AllocationRuleResponse{
+		ID:      rule.ID,
+		Name:    rule.Name,
+		TagKeys: rule.TagKeys,
+		Aliases: rule.Aliases,
+	}
+	for _, split := range rule.Splits {
+		resp.Splits = append(resp.Splits, dto.AllocationSplitDTO{
+			Group:   split.Group,
+			Method:  split.Method,
+			Weights: split.Weights,
+		})
+	}
+	if !rule.CreatedAt.IsZero() {
+		createdAt, updatedAt := rule.CreatedAt, rule.UpdatedAt
+		resp.CreatedAt, resp.UpdatedAt = &createdAt, &updatedAt
+	}
+	return resp
+}
+
+func mapAllocationSplits(splits []dto.AllocationSplitDTO) []cost.AllocationSplit {
+	result := make([]cost.AllocationSplit, 0, len(splits))
+	for _, split := range splits {
+		result = append(result, cost.AllocationSplit{
+			Group:   split.Group,
+			Method:  split.Method,
+			Weights: split.Weights,
+		})
+	}
+	return result
+}
@@ -79,7 +79,7 @@ func (h *DriftHandler) List(w http.ResponseWriter, r *http.Request) {
 // @Tags Drifts
 // @Produce json
 // @Param id path int true "Drift ID"
-// @Success 200 {object} dto.DriftDTO "Drift details"
+// @Success 200 {object} dto.DriftDTO "Drift details, including field-level changes"
 // @Failure 404 {object} utils.ErrorResponse "Drift not found"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Security BearerAuth
@@ -98,7 +98,10 @@ func (h *DriftHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteSuccess(w, http.StatusOK, convertDriftToDTO(d))
+	result := convertDriftToDTO(d)
+	result.Changes = convertDriftChangesToDTO(d.Changes)
+
+	utils.WriteSuccess(w, http.StatusOK, result)
 }
 
 // Create creates a new drift
@@ -271,7 +274,30 @@ func convertDriftToDTO(d *drift.Drift) dto.DriftDTO {
 		DetectedAt:    d.DetectedAt,
 		Status:        d.Status,
 		RemediationTips: getRemediationTips(d.DriftType, d.Severity),
+		LastSeenAt:      d.LastSeenAt,
+		OccurrenceCount: d.OccurrenceCount,
+		ResolvedAt:      d.ResolvedAt,
+	}
+}
+
+// convertDriftChangesToDTO converts structured drift changes to DTOs
+func convertDriftChangesToDTO(changes []drift.Change) []dto.DriftChangeDTO {
+	if len(changes) == 0 {
+		return nil
+	}
+	dtos := make([]dto.DriftChangeDTO, len(changes))
+	for i, c := range changes {
+		dtos[i] = dto.DriftChangeDTO{
+			Path:       c.Path,
+			Field:      c.Field,
+			OldValue:   c.OldValue,
+			NewValue:   c.NewValue,
+			ChangeType: c.ChangeType,
+			RuleID:     c.RuleID,
+			Severity:   c.Severity,
+		}
 	}
+	return dtos
 }
 
 // getRemediationTips returns remediation suggestions based on drift type
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pratik-mahalle/infraudit/internal/api/dto"
+	"github.com/pratik-mahalle/infraudit/internal/api/middleware"
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/utils"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/validator"
+)
+
+type DriftRuleHandler struct {
+	service   drift.RuleService
+	logger    *logger.Logger
+	validator *validator.Validator
+}
+
+func NewDriftRuleHandler(service drift.RuleService, log *logger.Logger, val *validator.Validator) *DriftRuleHandler {
+	return &DriftRuleHandler{service: service, logger: log, validator: val}
+}
+
+// List returns built-in and custom drift rules
+// @Summary List drift rules
+// @Description Get the drift classification rules in effect for the user, with optional filtering
+// @Tags Drift Rules
+// @Produce json
+// @Param resource_type query string false "Filter by resource type"
+// @Param severity query string false "Filter by severity"
+// @Param drift_type query string false "Filter by drift type"
+// @Param source query string false "Filter by source (builtin, custom)"
+// @Success 200 {array} dto.DriftRuleDTO "List of drift rules"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /drift-rules [get]
+func (h *DriftRuleHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+
+	filter := drift.RuleFilter{
+		ResourceType: r.URL.Query().Get("resource_type"),
+		Severity:     r.URL.Query().Get("severity"),
+		DriftType:    r.URL.Query().Get("drift_type"),
+		Source:       r.URL.Query().Get("source"),
+	}
+
+	rules, err := h.service.List(r.Context(), userID, filter)
+	if err != nil {
+		utils.WriteError(w, errors.Internal("Failed to list drift rules", err))
+		return
+	}
+
+	dtos := make([]dto.DriftRuleDTO, len(rules))
+	for i, rule := range rules {
+		dtos[i] = convertDriftRuleToDTO(rule)
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, dtos)
+}
+
+// Get returns a single drift rule by ID
+// @Summary Get drift rule
+// @Description Get a drift rule by ID; a custom rule takes precedence over a built-in with the same ID
+// @Tags Drift Rules
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} dto.DriftRuleDTO "Drift rule"
+// @Failure 404 {object} utils.ErrorResponse "Drift rule not found"
+// @Security BearerAuth
+// @Router /drift-rules/{id} [get]
+func (h *DriftRuleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+
+	rule, err := h.service.Get(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, err, "Failed to get drift rule")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, convertDriftRuleToDTO(rule))
+}
+
+// Create creates a custom drift rule
+// @Summary Create drift rule
+// @Description Create a custom drift rule. A rule with the ID of a built-in rule overrides it.
+// @Tags Drift Rules
+// @Accept json
+// @Produce json
+// @Param request body dto.DriftRuleRequest true "Rule definition"
+// @Success 201 {object} dto.DriftRuleDTO "Drift rule created"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or validation error"
+// @Failure 409 {object} utils.ErrorResponse "Drift rule already exists"
+// @Security BearerAuth
+// @Router /drift-rules [post]
+func (h *DriftRuleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+
+	var req dto.DriftRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if errs := h.validator.Validate(req); len(errs) > 0 {
+		utils.WriteError(w, errors.ValidationError("Validation failed", errs))
+		return
+	}
+
+	rule, err := h.service.Create(r.Context(), userID, convertDriftRuleRequest(req))
+	if err != nil {
+		h.writeError(w, err, "Failed to create drift rule")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, convertDriftRuleToDTO(rule))
+}
+
+// Update replaces a custom drift rule
+// @Summary Update drift rule
+// @Description Replace the definition of a custom drift rule
+// @Tags Drift Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param request body dto.DriftRuleRequest true "Rule definition"
+// @Success 200 {object} dto.DriftRuleDTO "Drift rule updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or validation error"
+// @Failure 404 {object} utils.ErrorResponse "Drift rule not found"
+// @Security BearerAuth
+// @Router /drift-rules/{id} [put]
+func (h *DriftRuleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	id := chi.URLParam(r, "id")
+
+	var req dto.DriftRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid request body"))
+		return
+	}
+	if req.ID == "" {
+		req.ID = id
+	}
+
+	if errs := h.validator.Validate(req); len(errs) > 0 {
+		utils.WriteError(w, errors.ValidationError("Validation failed", errs))
+		return
+	}
+	if req.ID != id {
+		utils.WriteError(w, errors.BadRequest("Rule ID in body does not match path"))
+		return
+	}
+
+	rule, err := h.service.Update(r.Context(), userID, id, convertDriftRuleRequest(req))
+	if err != nil {
+		h.writeError(w, err, "Failed to update drift rule")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, convertDriftRuleToDTO(rule))
+}
+
+// Delete deletes a custom drift rule
+// @Summary Delete drift rule
+// @Description Delete a custom drift rule; built-in rules can only be overridden
+// @Tags Drift Rules
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} utils.SuccessResponse "Drift rule deleted successfully"
+// @Failure 400 {object} utils.ErrorResponse "Built-in rules cannot be deleted"
+// @Failure 404 {object} utils.ErrorResponse "Drift rule not found"
+// @Security BearerAuth
+// @Router /drift-rules/{id} [delete]
+func (h *DriftRuleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+
+	if err := h.service.Delete(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, err, "Failed to delete drift rule")
+		return
+	}
+
+	utils.WriteSuccessWithMessage(w, http.StatusOK, "Drift rule deleted successfully", nil)
+}
+
+func (h *DriftRuleHandler) writeError(w http.ResponseWriter, err error, message string) {
+	if appErr, ok := err.(*errors.AppError); ok {
+		utils.WriteError(w, appErr)
+		return
+	}
+	utils.WriteError(w, errors.Internal(message, err))
+}
+
+// convertDriftRuleRequest converts a rule request to a domain rule
+func convertDriftRuleRequest(req dto.DriftRuleRequest) *drift.Rule {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &drift.Rule{
+		ID:            req.ID,
+		Description:   req.Description,
+		ResourceTypes: req.ResourceTypes,
+		Field:         req.Field,
+		ChangeTypes:   req.ChangeTypes,
+		Old:           convertPredicateFromDTO(req.Old),
+		New:           convertPredicateFromDTO(req.New),
+		Severity:      req.Severity,
+		DriftType:     req.DriftType,
+		Enabled:       enabled,
+	}
+}
+
+// convertDriftRuleToDTO converts a drift rule domain model to DTO
+func convertDriftRuleToDTO(rule *drift.Rule) dto.DriftRuleDTO {
+	d := dto.DriftRuleDTO{
+		ID:            rule.ID,
+		Description:   rule.Description,
+		ResourceTypes: rule.ResourceTypes,
+		Field:         rule.Field,
+		ChangeTypes:   rule.ChangeTypes,
+		Old:           convertPredicateToDTO(rule.Old),
+		New:           convertPredicateToDTO(rule.New),
+		Severity:      rule.Severity,
+		DriftType:     rule.DriftType,
+		Enabled:       rule.Enabled,
+		Builtin:       rule.Builtin,
+		Source:        rule.Source,
+	}
+	if !rule.CreatedAt.IsZero() {
+		d.CreatedAt = &rule.CreatedAt
+	}
+	if !rule.UpdatedAt.IsZero() {
+		d.UpdatedAt = &rule.UpdatedAt
+	}
+	return d
+}
+
+func convertPredicateFromDTO(p *dto.DriftRulePredicateDTO) *drift.ValuePredicate {
+	if p == nil {
+		return nil
+	}
+	return &drift.ValuePredicate{
+		Equals: p.Equals, NotEquals: p.NotEquals, In: p.In, Contains: p.Contains,
+		Matches: p.Matches, Truthy: p.Truthy, Exists: p.Exists,
+	}
+}
+
+func convertPredicateToDTO(p *drift.ValuePredicate) *dto.DriftRulePredicateDTO {
+	if p == nil {
+		return nil
+	}
+	return &dto.DriftRulePredicateDTO{
+		Equals: p.Equals, NotEquals: p.NotEquals, In: p.In, Contains: p.Contains,
+		Matches: p.Matches, Truthy: p.Truthy, Exists: p.Exists,
+	}
+}
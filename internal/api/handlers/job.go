@@ -292,6 +292,34 @@ func (h *JobHandler) GetJobTypes(w http.ResponseWriter, _ *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{"job_types": types})
 }
 
+// GetSchedulerStatus handles GET /api/v1/jobs/scheduler/status
+func (h *JobHandler) GetSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.jobService.SchedulerStatus(r.Context())
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to get scheduler status")
+		respondError(w, http.StatusInternalServerError, "failed to get scheduler status")
+		return
+	}
+
+	response := dto.SchedulerStatusResponse{
+		NodeID:    status.NodeID,
+		IsRunning: status.IsRunning,
+		IsLeader:  status.IsLeader,
+		Leases:    make([]dto.LeaseResponse, len(status.Leases)),
+	}
+	for i, l := range status.Leases {
+		response.Leases[i] = dto.LeaseResponse{
+			Name:        l.Name,
+			Holder:      l.Holder,
+			AcquiredAt:  l.AcquiredAt,
+			HeartbeatAt: l.HeartbeatAt,
+			ExpiresAt:   l.ExpiresAt,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
 // Helper functions
 
 func mapJobToResponse(j *job.ScheduledJob) dto.JobResponse {
@@ -344,6 +344,84 @@ func (h *NotificationHandler) TestWebhook(w http.ResponseWriter, r *http.Request
 	respondJSON(w, http.StatusOK, map[string]string{"message": "test webhook sent"})
 }
 
+// ListWebhookDeliveries handles GET /api/v1/webhooks/{id}/deliveries
+func (h *NotificationHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	deliveries, total, err := h.notificationService.ListDeliveries(r.Context(), webhook.ID, limit, offset)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to list webhook deliveries")
+		respondError(w, http.StatusInternalServerError, "failed to list webhook deliveries")
+		return
+	}
+
+	response := dto.ListWebhookDeliveriesResponse{
+		Deliveries: make([]dto.WebhookDeliveryResponse, 0, len(deliveries)),
+		Total:      total,
+	}
+	for _, d := range deliveries {
+		response.Deliveries = append(response.Deliveries, mapDeliveryToResponse(d))
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// RedeliverWebhook handles POST /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver
+func (h *NotificationHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID := chi.URLParam(r, "deliveryId")
+	if deliveryID == "" {
+		respondError(w, http.StatusBadRequest, "delivery id is required")
+		return
+	}
+
+	delivery, err := h.notificationService.RedeliverWebhook(r.Context(), webhook.ID, deliveryID)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to redeliver webhook")
+		respondError(w, http.StatusNotFound, "webhook delivery not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mapDeliveryToResponse(delivery))
+}
+
+// ownedWebhook loads the webhook in the URL and checks that it belongs to the
+// caller, writing an error response when it does not
+func (h *NotificationHandler) ownedWebhook(w http.ResponseWriter, r *http.Request) (*notification.Webhook, bool) {
+	userID := getUserIDFromContext(r.Context())
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+
+	webhookID := chi.URLParam(r, "id")
+	if webhookID == "" {
+		respondError(w, http.StatusBadRequest, "webhook id is required")
+		return nil, false
+	}
+
+	webhook, err := h.notificationService.GetWebhook(r.Context(), webhookID)
+	if err != nil || webhook.UserID != userID {
+		respondError(w, http.StatusNotFound, "webhook not found")
+		return nil, false
+	}
+
+	return webhook, true
+}
+
 // GetAvailableEvents handles GET /api/v1/webhooks/events
 func (h *NotificationHandler) GetAvailableEvents(w http.ResponseWriter, _ *http.Request) {
 	events := []dto.WebhookEventInfo{
@@ -379,3 +457,18 @@ func mapWebhookToResponse(wh *notification.Webhook) dto.WebhookResponse {
 		UpdatedAt:     wh.UpdatedAt,
 	}
 }
+
+func mapDeliveryToResponse(d *notification.WebhookDelivery) dto.WebhookDeliveryResponse {
+	return dto.WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventType:      string(d.EventType),
+		Status:         string(d.Status),
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		RetryCount:     d.RetryCount,
+		AttemptedAt:    d.AttemptedAt,
+		NextAttemptAt:  d.NextAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
@@ -3,12 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pratik-mahalle/infraudit/internal/api/dto"
 	"github.com/pratik-mahalle/infraudit/internal/api/middleware"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/utils"
@@ -31,7 +35,7 @@ func NewProviderHandler(service provider.Service, log *logger.Logger, val *valid
 
 // List returns all connected providers
 // @Summary List connected providers
-// @Description Get a list of all connected cloud providers
+// @Description Get a list of all connected cloud provider accounts
 // @Tags Providers
 // @Produce json
 // @Success 200 {array} dto.ProviderDTO "List of connected providers"
@@ -48,28 +52,77 @@ func (h *ProviderHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert to DTOs (hide credentials)
-	dtos := make([]dto.ProviderDTO, len(providers))
-	for i, p := range providers {
-		dtos[i] = dto.ProviderDTO{
-			Provider:    p.Provider,
-			IsConnected: p.IsConnected,
-			LastSynced:  p.LastSynced,
+	utils.WriteSuccess(w, http.StatusOK, toProviderDTOs(providers))
+}
+
+// ListAccounts returns the accounts of one provider
+// @Summary List provider accounts
+// @Description Get the connected accounts of a cloud provider
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Success 200 {array} dto.ProviderDTO "Provider accounts"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts [get]
+func (h *ProviderHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accounts, err := h.service.ListAccounts(r.Context(), userID, providerType)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to list provider accounts")
+		utils.WriteError(w, errors.Internal("Failed to list provider accounts", err))
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, toProviderDTOs(accounts))
+}
+
+// GetAccount returns one provider account
+// @Summary Get provider account
+// @Description Get a connected cloud provider account by ID
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param accountId path int true "Account ID"
+// @Success 200 {object} dto.ProviderDTO "Provider account"
+// @Failure 400 {object} utils.ErrorResponse "Invalid account ID"
+// @Failure 404 {object} utils.ErrorResponse "Account not found"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts/{accountId} [get]
+func (h *ProviderHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "accountId"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid account ID"))
+		return
+	}
+
+	account, err := h.service.GetAccount(r.Context(), userID, providerType, accountID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to get provider account", err))
 		}
+		return
 	}
 
-	utils.WriteSuccess(w, http.StatusOK, dtos)
+	utils.WriteSuccess(w, http.StatusOK, toProviderDTO(account))
 }
 
 // Connect connects a cloud provider
 // @Summary Connect cloud provider
-// @Description Connect a cloud provider (AWS, Azure, or GCP) with credentials
+// @Description Connect a cloud provider account (AWS, Azure, or GCP) with credentials. Accounts are told apart by name; connecting an existing name replaces its credentials. AWS accounts can be connected by assuming IAM roles instead of with access keys; each of aws_targets becomes its own account, and aws_organization_role_name connects every member account of the organization.
 // @Tags Providers
 // @Accept json
 // @Produce json
 // @Param provider path string true "Provider type (aws, azure, gcp)"
 // @Param request body dto.ConnectProviderRequest true "Provider credentials"
-// @Success 200 {object} utils.SuccessResponse "Provider connected successfully"
+// @Success 200 {object} utils.SuccessResponse{data=dto.ProviderDTO} "Provider connected successfully"
 // @Failure 400 {object} utils.ErrorResponse "Invalid request or validation error"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Security BearerAuth
@@ -91,24 +144,68 @@ func (h *ProviderHandler) Connect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build credentials based on provider type
+	creds, appErr := credentialsFromRequest(providerType, &req)
+	if appErr != nil {
+		utils.WriteError(w, appErr)
+		return
+	}
+
+	if providerType == provider.ProviderAWS && len(req.AWSTargets) > 0 {
+		h.connectAWSTargets(w, r, userID, creds, req.AWSTargets)
+		return
+	}
+
+	account, err := h.service.ConnectAccount(r.Context(), userID, providerType, req.Name, creds)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to connect provider")
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to connect provider", err))
+		}
+		return
+	}
+
+	// Auto-sync resources after successful connection. An organization
+	// account brings its member accounts along, so sync them all.
+	if creds.AWSOrganizationRoleName != "" {
+		h.autoSync(userID, providerType, 0)
+	} else {
+		h.autoSync(userID, providerType, account.ID)
+	}
+
+	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider connected successfully", toProviderDTO(account))
+}
+
+// credentialsFromRequest builds provider credentials from a connection
+// request
+func credentialsFromRequest(providerType string, req *dto.ConnectProviderRequest) (provider.Credentials, *errors.AppError) {
 	creds := provider.Credentials{}
 	switch providerType {
 	case "aws":
-		if req.AWSAccessKeyID == nil || req.AWSSecretAccessKey == nil {
-			utils.WriteError(w, errors.BadRequest("AWS credentials required"))
-			return
+		if (req.AWSAccessKeyID == nil || req.AWSSecretAccessKey == nil) && len(req.AWSRoleChain) == 0 && len(req.AWSTargets) == 0 {
+			return creds, errors.BadRequest("AWS credentials or a role to assume required")
+		}
+		if req.AWSAccessKeyID != nil && req.AWSSecretAccessKey != nil {
+			creds.AWSAccessKeyID = *req.AWSAccessKeyID
+			creds.AWSSecretAccessKey = *req.AWSSecretAccessKey
 		}
-		creds.AWSAccessKeyID = *req.AWSAccessKeyID
-		creds.AWSSecretAccessKey = *req.AWSSecretAccessKey
 		if req.AWSRegion != nil {
 			creds.AWSRegion = *req.AWSRegion
 		}
+		for _, role := range req.AWSRoleChain {
+			creds.AWSRoleChain = append(creds.AWSRoleChain, provider.AWSRole{RoleARN: role.RoleARN, ExternalID: role.ExternalID})
+		}
+		if req.AWSOrganizationRoleName != nil {
+			creds.AWSOrganizationRoleName = *req.AWSOrganizationRoleName
+		}
+		if req.AWSOrganizationExternalID != nil {
+			creds.AWSOrganizationExternalID = *req.AWSOrganizationExternalID
+		}
 
 	case "gcp":
 		if req.GCPProjectID == nil || req.GCPServiceAccountJSON == nil {
-			utils.WriteError(w, errors.BadRequest("GCP credentials required"))
-			return
+			return creds, errors.BadRequest("GCP credentials required")
 		}
 		creds.GCPProjectID = *req.GCPProjectID
 		creds.GCPServiceAccountJSON = *req.GCPServiceAccountJSON
@@ -118,8 +215,7 @@ func (h *ProviderHandler) Connect(w http.ResponseWriter, r *http.Request) {
 
 	case "azure":
 		if req.AzureTenantID == nil || req.AzureClientID == nil || req.AzureClientSecret == nil || req.AzureSubscriptionID == nil {
-			utils.WriteError(w, errors.BadRequest("Azure credentials required"))
-			return
+			return creds, errors.BadRequest("Azure credentials required")
 		}
 		creds.AzureTenantID = *req.AzureTenantID
 		creds.AzureClientID = *req.AzureClientID
@@ -130,12 +226,44 @@ func (h *ProviderHandler) Connect(w http.ResponseWriter, r *http.Request) {
 		}
 
 	default:
-		utils.WriteError(w, errors.BadRequest("Unsupported provider type"))
-		return
+		return creds, errors.BadRequest("Unsupported provider type")
 	}
+	return creds, nil
+}
 
-	if err := h.service.Connect(r.Context(), userID, providerType, creds); err != nil {
-		h.logger.ErrorWithErr(err, "Failed to connect provider")
+// connectAWSTargets connects one AWS account per role target and responds
+// with the connected accounts
+func (h *ProviderHandler) connectAWSTargets(w http.ResponseWriter, r *http.Request, userID int64, base provider.Credentials, roles []dto.AWSRoleRequest) {
+	targets := make([]provider.AWSTarget, len(roles))
+	for i, role := range roles {
+		targets[i] = provider.AWSTarget{Name: role.Name, RoleARN: role.RoleARN, ExternalID: role.ExternalID}
+	}
+
+	accounts, err := h.service.ConnectAWSTargets(r.Context(), userID, base, targets)
+	for _, account := range accounts {
+		h.autoSync(userID, provider.ProviderAWS, account.ID)
+	}
+	if err != nil && len(accounts) > 0 {
+		// Some targets connected: report them and name the ones that failed
+		h.logger.ErrorWithErr(err, "Failed to connect some AWS role targets")
+		message := fmt.Sprintf("Connected %d of %d AWS accounts", len(accounts), len(targets))
+		if appErr, ok := err.(*errors.AppError); ok {
+			if failures, ok := appErr.Details.(map[string]string); ok {
+				names := make([]string, 0, len(failures))
+				for name := range failures {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					message += fmt.Sprintf("; %s: %s", name, failures[name])
+				}
+			}
+		}
+		utils.WriteSuccessWithMessage(w, http.StatusOK, message, toProviderDTOs(accounts))
+		return
+	}
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to connect AWS role targets")
 		if appErr, ok := err.(*errors.AppError); ok {
 			utils.WriteError(w, appErr)
 		} else {
@@ -144,25 +272,142 @@ func (h *ProviderHandler) Connect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Auto-sync resources after successful connection
+	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider accounts connected successfully", toProviderDTOs(accounts))
+}
+
+// Test verifies credentials without connecting them
+// @Summary Test provider credentials
+// @Description Verify credentials against the provider's API without saving them. The response names the identity the credentials act as and any read permissions resource sync needs that the identity lacks.
+// @Tags Providers
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param request body dto.ConnectProviderRequest true "Provider credentials"
+// @Success 200 {object} utils.SuccessResponse{data=dto.ProviderVerificationDTO} "Credentials verified"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or validation error"
+// @Failure 401 {object} utils.ErrorResponse "Credentials rejected by the provider"
+// @Security BearerAuth
+// @Router /providers/{provider}/test [post]
+func (h *ProviderHandler) Test(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	var req dto.ConnectProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	req.Provider = providerType // Override with URL param
+
+	if errs := h.validator.Validate(req); len(errs) > 0 {
+		utils.WriteError(w, errors.ValidationError("Validation failed", errs))
+		return
+	}
+
+	creds, appErr := credentialsFromRequest(providerType, &req)
+	if appErr != nil {
+		utils.WriteError(w, appErr)
+		return
+	}
+
+	verification, err := h.service.TestConnection(r.Context(), userID, providerType, creds)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to verify credentials", err))
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, toVerificationDTO(verification))
+}
+
+// GetAWSExternalID returns the user's AWS external ID
+// @Summary Get AWS external ID
+// @Description Get the external ID that roles assumed with the server's own AWS identity, such as its instance profile, must require in their trust policy. The ID is generated on first use and never changes.
+// @Tags Providers
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=dto.AWSExternalIDResponse} "AWS external ID"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /providers/aws/external-id [get]
+func (h *ProviderHandler) GetAWSExternalID(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+
+	externalID, err := h.service.AWSExternalID(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorWithErr(err, "Failed to get AWS external ID")
+		utils.WriteError(w, errors.Internal("Failed to get AWS external ID", err))
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, dto.AWSExternalIDResponse{ExternalID: externalID})
+}
+
+// VerifyAccount verifies the stored credentials of a provider account
+// @Summary Verify provider account
+// @Description Verify a connected account's credentials against the provider's API and report any read permissions resource sync needs that are missing
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param accountId path int true "Account ID"
+// @Success 200 {object} utils.SuccessResponse{data=dto.ProviderVerificationDTO} "Credentials verified"
+// @Failure 400 {object} utils.ErrorResponse "Invalid account ID"
+// @Failure 401 {object} utils.ErrorResponse "Credentials rejected by the provider"
+// @Failure 404 {object} utils.ErrorResponse "Account not found"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts/{accountId}/verify [post]
+func (h *ProviderHandler) VerifyAccount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "accountId"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid account ID"))
+		return
+	}
+
+	verification, err := h.service.VerifyAccount(r.Context(), userID, providerType, accountID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to verify provider account", err))
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, toVerificationDTO(verification))
+}
+
+// autoSync syncs a newly connected account in the background, or every
+// account of the provider when accountID is 0
+func (h *ProviderHandler) autoSync(userID int64, providerType string, accountID int64) {
 	go func() {
 		syncCtx := context.Background()
-		if err := h.service.Sync(syncCtx, userID, providerType); err != nil {
-			h.logger.ErrorWithErr(err, "Auto-sync after connect failed")
+		var err error
+		if accountID == 0 {
+			err = h.service.Sync(syncCtx, userID, providerType)
 		} else {
-			h.logger.WithFields(map[string]interface{}{
-				"user_id":  userID,
-				"provider": providerType,
-			}).Info("Auto-sync after connect completed")
+			err = h.service.SyncAccount(syncCtx, userID, providerType, accountID)
 		}
+		if err != nil {
+			h.logger.ErrorWithErr(err, "Auto-sync after connect failed")
+			return
+		}
+		h.logger.WithFields(map[string]interface{}{
+			"user_id":    userID,
+			"provider":   providerType,
+			"account_id": accountID,
+		}).Info("Auto-sync after connect completed")
 	}()
-
-	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider connected successfully", nil)
 }
 
 // Sync syncs resources from a provider
 // @Summary Sync provider resources
-// @Description Sync resources from a connected cloud provider
+// @Description Sync resources from every connected account of a cloud provider
 // @Tags Providers
 // @Produce json
 // @Param provider path string true "Provider type (aws, azure, gcp)"
@@ -188,9 +433,89 @@ func (h *ProviderHandler) Sync(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider sync initiated", nil)
 }
 
+// SyncAccount syncs resources from one provider account
+// @Summary Sync provider account resources
+// @Description Sync resources from one connected cloud provider account
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param accountId path int true "Account ID"
+// @Success 200 {object} utils.SuccessResponse "Provider account sync initiated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid account ID or not connected"
+// @Failure 404 {object} utils.ErrorResponse "Account not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts/{accountId}/sync [post]
+func (h *ProviderHandler) SyncAccount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "accountId"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid account ID"))
+		return
+	}
+
+	if err := h.service.SyncAccount(r.Context(), userID, providerType, accountID); err != nil {
+		h.logger.ErrorWithErr(err, "Failed to sync provider account")
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to sync provider account", err))
+		}
+		return
+	}
+
+	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider account sync initiated", nil)
+}
+
+// ListSyncRuns lists the sync changelogs of a provider account
+// @Summary List provider account syncs
+// @Description List what each sync of a provider account added, updated, removed and purged, newest first. Removed resources vanished from the provider and are kept as terminated until the grace period ends; purged ones were then deleted.
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param accountId path int true "Account ID"
+// @Param limit query int false "Maximum number of syncs (default 20)"
+// @Success 200 {object} utils.SuccessResponse{data=[]resource.SyncRun} "Sync changelogs"
+// @Failure 400 {object} utils.ErrorResponse "Invalid account ID"
+// @Failure 404 {object} utils.ErrorResponse "Account not found"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts/{accountId}/syncs [get]
+func (h *ProviderHandler) ListSyncRuns(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "accountId"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid account ID"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := h.service.ListSyncRuns(r.Context(), userID, providerType, accountID, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to list provider syncs", err))
+		}
+		return
+	}
+	if runs == nil {
+		runs = []*resource.SyncRun{}
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, runs)
+}
+
 // Disconnect disconnects a provider
 // @Summary Disconnect provider
-// @Description Disconnect a cloud provider and remove stored credentials
+// @Description Disconnect every account of a cloud provider and remove stored credentials
 // @Tags Providers
 // @Produce json
 // @Param provider path string true "Provider type (aws, azure, gcp)"
@@ -216,9 +541,45 @@ func (h *ProviderHandler) Disconnect(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider disconnected successfully", nil)
 }
 
-// GetStatus gets the sync status for all providers
+// DisconnectAccount disconnects one provider account
+// @Summary Disconnect provider account
+// @Description Disconnect one cloud provider account, removing its credentials and synced resources
+// @Tags Providers
+// @Produce json
+// @Param provider path string true "Provider type (aws, azure, gcp)"
+// @Param accountId path int true "Account ID"
+// @Success 200 {object} utils.SuccessResponse "Provider account disconnected successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid account ID"
+// @Failure 404 {object} utils.ErrorResponse "Account not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /providers/{provider}/accounts/{accountId} [delete]
+func (h *ProviderHandler) DisconnectAccount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r)
+	providerType := chi.URLParam(r, "provider")
+
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "accountId"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, errors.BadRequest("Invalid account ID"))
+		return
+	}
+
+	if err := h.service.DisconnectAccount(r.Context(), userID, providerType, accountID); err != nil {
+		h.logger.ErrorWithErr(err, "Failed to disconnect provider account")
+		if appErr, ok := err.(*errors.AppError); ok {
+			utils.WriteError(w, appErr)
+		} else {
+			utils.WriteError(w, errors.Internal("Failed to disconnect provider account", err))
+		}
+		return
+	}
+
+	utils.WriteSuccessWithMessage(w, http.StatusOK, "Provider account disconnected successfully", nil)
+}
+
+// GetStatus gets the sync status for all provider accounts
 // @Summary Get provider sync status
-// @Description Get the sync status and resource counts for all providers
+// @Description Get the sync status and resource counts for every provider account
 // @Tags Providers
 // @Produce json
 // @Success 200 {array} dto.ProviderStatusResponse "Provider sync statuses"
@@ -240,6 +601,8 @@ func (h *ProviderHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	for i, s := range statuses {
 		dtos[i] = dto.ProviderStatusResponse{
 			Provider:      s.Provider,
+			AccountID:     s.AccountID,
+			AccountName:   s.AccountName,
 			IsConnected:   s.IsConnected,
 			LastSynced:    s.LastSynced,
 			ResourceCount: s.ResourceCount,
@@ -250,3 +613,37 @@ func (h *ProviderHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteSuccess(w, http.StatusOK, dtos)
 }
+
+// toProviderDTO converts a provider account to its DTO, leaving out credentials
+func toProviderDTO(p *provider.Provider) dto.ProviderDTO {
+	return dto.ProviderDTO{
+		ID:           p.ID,
+		Provider:     p.Provider,
+		Name:         p.Name,
+		IsConnected:  p.IsConnected,
+		LastSynced:   p.LastSynced,
+		SyncStatus:   p.SyncStatus,
+		SyncMessage:  p.SyncMessage,
+		Verification: toVerificationDTO(p.Verification),
+	}
+}
+
+func toVerificationDTO(v *provider.Verification) *dto.ProviderVerificationDTO {
+	if v == nil {
+		return nil
+	}
+	return &dto.ProviderVerificationDTO{
+		Identity:           v.Identity,
+		Account:            v.Account,
+		PermissionsChecked: v.PermissionsChecked,
+		MissingPermissions: v.MissingPermissions,
+	}
+}
+
+func toProviderDTOs(providers []*provider.Provider) []dto.ProviderDTO {
+	dtos := make([]dto.ProviderDTO, len(providers))
+	for i, p := range providers {
+		dtos[i] = toProviderDTO(p)
+	}
+	return dtos
+}
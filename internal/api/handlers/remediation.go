@@ -346,10 +346,17 @@ func mapActionToResponse(a *remediation.Action) dto.RemediationActionResponse {
 
 func mapStrategyToDTO(s *remediation.Strategy) *dto.RemediationStrategyDTO {
 	d := &dto.RemediationStrategyDTO{
-		Type:        string(s.Type),
-		Description: s.Description,
-		Parameters:  s.Parameters,
-		Steps:       make([]dto.RemediationStepDTO, 0, len(s.Steps)),
+		Type:         string(s.Type),
+		Description:  s.Description,
+		Parameters:   s.Parameters,
+		Steps:        make([]dto.RemediationStepDTO, 0, len(s.Steps)),
+		Repository:   s.Repository,
+		Branch:       s.Branch,
+		FilePath:     s.FilePath,
+		PatchContent: s.PatchContent,
+		Provider:     s.Provider,
+		APIAction:    s.APIAction,
+		APIParams:    s.APIParams,
 	}
 
 	for _, step := range s.Steps {
@@ -366,10 +373,17 @@ func mapStrategyToDTO(s *remediation.Strategy) *dto.RemediationStrategyDTO {
 
 func mapDTOToStrategy(d *dto.RemediationStrategyDTO) *remediation.Strategy {
 	s := &remediation.Strategy{
-		Type:        remediation.RemediationType(d.Type),
-		Description: d.Description,
-		Parameters:  d.Parameters,
-		Steps:       make([]remediation.RemediationStep, 0, len(d.Steps)),
+		Type:         remediation.RemediationType(d.Type),
+		Description:  d.Description,
+		Parameters:   d.Parameters,
+		Steps:        make([]remediation.RemediationStep, 0, len(d.Steps)),
+		Repository:   d.Repository,
+		Branch:       d.Branch,
+		FilePath:     d.FilePath,
+		PatchContent: d.PatchContent,
+		Provider:     d.Provider,
+		APIAction:    d.APIAction,
+		APIParams:    d.APIParams,
 	}
 
 	for _, step := range d.Steps {
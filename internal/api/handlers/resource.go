@@ -35,6 +35,7 @@ func NewResourceHandler(service resource.Service, log *logger.Logger, val *valid
 // @Tags Resources
 // @Produce json
 // @Param provider query string false "Filter by provider (aws, azure, gcp)"
+// @Param account_id query int false "Filter by provider account ID"
 // @Param type query string false "Filter by resource type"
 // @Param region query string false "Filter by region"
 // @Param status query string false "Filter by status"
@@ -52,6 +53,7 @@ func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 	resourceType := r.URL.Query().Get("type")
 	region := r.URL.Query().Get("region")
 	status := r.URL.Query().Get("status")
+	accountID, _ := strconv.ParseInt(r.URL.Query().Get("account_id"), 10, 64)
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
 
@@ -63,10 +65,11 @@ func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filter := resource.Filter{
-		Provider: provider,
-		Type:     resourceType,
-		Region:   region,
-		Status:   status,
+		Provider:  provider,
+		AccountID: accountID,
+		Type:      resourceType,
+		Region:    region,
+		Status:    status,
 	}
 
 	offset := (page - 1) * pageSize
@@ -81,12 +84,14 @@ func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 	dtos := make([]dto.ResourceDTO, len(resources))
 	for i, res := range resources {
 		dtos[i] = dto.ResourceDTO{
-			Provider:   res.Provider,
-			ResourceID: res.ResourceID,
-			Name:       res.Name,
-			Type:       res.Type,
-			Region:     res.Region,
-			Status:     res.Status,
+			Provider:     res.Provider,
+			AccountID:    res.AccountID,
+			ResourceID:   res.ResourceID,
+			Name:         res.Name,
+			Type:         res.Type,
+			Region:       res.Region,
+			Status:       res.Status,
+			MissingSince: res.MissingSince,
 		}
 	}
 
@@ -121,12 +126,14 @@ func (h *ResourceHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resourceDTO := dto.ResourceDTO{
-		Provider:   res.Provider,
-		ResourceID: res.ResourceID,
-		Name:       res.Name,
-		Type:       res.Type,
-		Region:     res.Region,
-		Status:     res.Status,
+		Provider:     res.Provider,
+		AccountID:    res.AccountID,
+		ResourceID:   res.ResourceID,
+		Name:         res.Name,
+		Type:         res.Type,
+		Region:       res.Region,
+		Status:       res.Status,
+		MissingSince: res.MissingSince,
 	}
 
 	utils.WriteSuccess(w, http.StatusOK, resourceDTO)
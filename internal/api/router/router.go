@@ -22,6 +22,7 @@ type Handlers struct {
 	Alert          *handlers.AlertHandler
 	Recommendation *handlers.RecommendationHandler
 	Drift          *handlers.DriftHandler
+	DriftRule      *handlers.DriftRuleHandler
 	Anomaly        *handlers.AnomalyHandler
 	Baseline       *handlers.BaselineHandler
 	Vulnerability  *handlers.VulnerabilityHandler
@@ -102,9 +103,17 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 		r.Route("/api/v1/providers", func(r chi.Router) {
 			r.Get("/", h.Provider.List)
 			r.Get("/status", h.Provider.GetStatus)
+			r.Get("/aws/external-id", h.Provider.GetAWSExternalID)
 			r.Post("/{provider}/connect", h.Provider.Connect)
+			r.Post("/{provider}/test", h.Provider.Test)
 			r.Post("/{provider}/sync", h.Provider.Sync)
 			r.Delete("/{provider}", h.Provider.Disconnect)
+			r.Get("/{provider}/accounts", h.Provider.ListAccounts)
+			r.Get("/{provider}/accounts/{accountId}", h.Provider.GetAccount)
+			r.Post("/{provider}/accounts/{accountId}/sync", h.Provider.SyncAccount)
+			r.Post("/{provider}/accounts/{accountId}/verify", h.Provider.VerifyAccount)
+			r.Get("/{provider}/accounts/{accountId}/syncs", h.Provider.ListSyncRuns)
+			r.Delete("/{provider}/accounts/{accountId}", h.Provider.DisconnectAccount)
 		})
 
 		// Alerts
@@ -139,6 +148,15 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 			r.Delete("/{id}", h.Drift.Delete)
 		})
 
+		// Drift rules
+		r.Route("/api/v1/drift-rules", func(r chi.Router) {
+			r.Get("/", h.DriftRule.List)
+			r.Post("/", h.DriftRule.Create)
+			r.Get("/{id}", h.DriftRule.Get)
+			r.Put("/{id}", h.DriftRule.Update)
+			r.Delete("/{id}", h.DriftRule.Delete)
+		})
+
 		// Anomalies
 		r.Route("/api/v1/anomalies", func(r chi.Router) {
 			r.Get("/", h.Anomaly.List)
@@ -238,6 +256,24 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 			r.Get("/forecast", h.Cost.GetForecast)
 			r.Post("/sync", h.Cost.SyncCosts)
 			r.Get("/savings", h.Cost.GetSavings)
+			r.Route("/budgets", func(r chi.Router) {
+				r.Get("/", h.Cost.ListBudgets)
+				r.Post("/", h.Cost.CreateBudget)
+				r.Get("/{id}", h.Cost.GetBudget)
+				r.Put("/{id}", h.Cost.UpdateBudget)
+				r.Delete("/{id}", h.Cost.DeleteBudget)
+				r.Get("/{id}/status", h.Cost.GetBudgetStatus)
+			})
+			r.Route("/allocation", func(r chi.Router) {
+				r.Get("/", h.Cost.GetAllocation)
+				r.Route("/rules", func(r chi.Router) {
+					r.Get("/", h.Cost.ListAllocationRules)
+					r.Post("/", h.Cost.CreateAllocationRule)
+					r.Get("/{id}", h.Cost.GetAllocationRule)
+					r.Put("/{id}", h.Cost.UpdateAllocationRule)
+					r.Delete("/{id}", h.Cost.DeleteAllocationRule)
+				})
+			})
 			r.Get("/{provider}", h.Cost.GetByProvider)
 			r.Route("/anomalies", func(r chi.Router) {
 				r.Get("/", h.Cost.ListAnomalies)
@@ -245,6 +281,7 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 			})
 			r.Route("/optimizations", func(r chi.Router) {
 				r.Get("/", h.Cost.ListOptimizations)
+				r.Post("/analyze", h.Cost.AnalyzeOptimizations)
 			})
 		})
 
@@ -256,10 +293,13 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 		r.Route("/api/v1/compliance", func(r chi.Router) {
 			r.Get("/overview", h.Compliance.GetOverview)
 			r.Get("/trend", h.Compliance.GetTrend)
+			r.Get("/crosswalk", h.Compliance.GetCrosswalk)
 			r.Post("/assess", h.Compliance.RunAssessment)
 			r.Get("/controls/failing", h.Compliance.GetFailingControls)
+			r.Get("/resources/{id}", h.Compliance.GetResourceCompliance)
 			r.Route("/frameworks", func(r chi.Router) {
 				r.Get("/", h.Compliance.ListFrameworks)
+				r.Post("/import", h.Compliance.ImportFramework)
 				r.Get("/{id}", h.Compliance.GetFramework)
 				r.Post("/{id}/enable", h.Compliance.EnableFramework)
 				r.Post("/{id}/disable", h.Compliance.DisableFramework)
@@ -270,6 +310,13 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 				r.Get("/{id}", h.Compliance.GetAssessment)
 				r.Get("/{id}/export", h.Compliance.ExportAssessment)
 			})
+			r.Route("/exceptions", func(r chi.Router) {
+				r.Get("/", h.Compliance.ListExceptions)
+				r.Post("/", h.Compliance.RequestException)
+				r.Get("/{id}", h.Compliance.GetException)
+				r.Post("/{id}/approve", h.Compliance.ApproveException)
+				r.Post("/{id}/revoke", h.Compliance.RevokeException)
+			})
 		})
 
 		// ============================================
@@ -281,6 +328,7 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 			r.Get("/", h.Job.ListJobs)
 			r.Post("/", h.Job.CreateJob)
 			r.Get("/types", h.Job.GetJobTypes)
+			r.Get("/scheduler/status", h.Job.GetSchedulerStatus)
 			r.Get("/{id}", h.Job.GetJob)
 			r.Put("/{id}", h.Job.UpdateJob)
 			r.Delete("/{id}", h.Job.DeleteJob)
@@ -331,6 +379,8 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 			r.Put("/{id}", h.Notification.UpdateWebhook)
 			r.Delete("/{id}", h.Notification.DeleteWebhook)
 			r.Post("/{id}/test", h.Notification.TestWebhook)
+			r.Get("/{id}/deliveries", h.Notification.ListWebhookDeliveries)
+			r.Post("/{id}/deliveries/{deliveryId}/redeliver", h.Notification.RedeliverWebhook)
 		})
 
 		// ============================================
@@ -379,9 +429,17 @@ func New(cfg *config.Config, log *logger.Logger, h *Handlers, resolveUser middle
 		// Providers aliases
 		r.Get("/api/providers", h.Provider.List)
 		r.Get("/api/providers/status", h.Provider.GetStatus)
+		r.Get("/api/providers/aws/external-id", h.Provider.GetAWSExternalID)
 		r.Post("/api/providers/{provider}/connect", h.Provider.Connect)
+		r.Post("/api/providers/{provider}/test", h.Provider.Test)
 		r.Post("/api/providers/{provider}/sync", h.Provider.Sync)
 		r.Delete("/api/providers/{provider}", h.Provider.Disconnect)
+		r.Get("/api/providers/{provider}/accounts", h.Provider.ListAccounts)
+		r.Get("/api/providers/{provider}/accounts/{accountId}", h.Provider.GetAccount)
+		r.Post("/api/providers/{provider}/accounts/{accountId}/sync", h.Provider.SyncAccount)
+		r.Post("/api/providers/{provider}/accounts/{accountId}/verify", h.Provider.VerifyAccount)
+		r.Get("/api/providers/{provider}/accounts/{accountId}/syncs", h.Provider.ListSyncRuns)
+		r.Delete("/api/providers/{provider}/accounts/{accountId}", h.Provider.DisconnectAccount)
 
 		// Baselines aliases
 		r.Get("/api/baselines", h.Baseline.ListBaselines)
@@ -3,8 +3,10 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func newComplianceCmd() *cobra.Command {
@@ -18,10 +20,13 @@ func newComplianceCmd() *cobra.Command {
 	cmd.AddCommand(newComplianceFrameworkCmd())
 	cmd.AddCommand(newComplianceEnableCmd())
 	cmd.AddCommand(newComplianceDisableCmd())
+	cmd.AddCommand(newComplianceImportCmd())
 	cmd.AddCommand(newComplianceAssessCmd())
 	cmd.AddCommand(newComplianceAssessmentsCmd())
 	cmd.AddCommand(newComplianceExportCmd())
 	cmd.AddCommand(newComplianceFailingControlsCmd())
+	cmd.AddCommand(newComplianceCrosswalkCmd())
+	cmd.AddCommand(newComplianceExceptionCmd())
 
 	return cmd
 }
@@ -104,6 +109,38 @@ func newComplianceDisableCmd() *cobra.Command {
 	}
 }
 
+func newComplianceImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a custom framework catalog",
+		Long: `Import a custom compliance framework from a catalog file.
+
+The file uses the same format as the built-in catalogs (YAML or JSON) and
+defines the framework, its categories and its controls. Importing a
+framework again replaces its controls.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+
+			// The API takes JSON; YAML is a superset, so decode it first
+			var catalog interface{}
+			if err := yaml.Unmarshal(content, &catalog); err != nil {
+				return fmt.Errorf("failed to parse catalog: %w", err)
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/compliance/frameworks/import", catalog, &result); err != nil {
+				return fmt.Errorf("failed to import framework: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
 func newComplianceAssessCmd() *cobra.Command {
 	var framework string
 
@@ -152,19 +189,50 @@ func newComplianceAssessmentsCmd() *cobra.Command {
 }
 
 func newComplianceExportCmd() *cobra.Command {
-	return &cobra.Command{
+	var format, file string
+
+	cmd := &cobra.Command{
 		Use:   "export <assessment-id>",
 		Short: "Export assessment report",
-		Args:  cobra.ExactArgs(1),
+		Long: `Export an assessment report.
+
+--format json (the default) prints the export like any other command.
+html, pdf, csv and oscal download a rendered report: a printable HTML or
+PDF report, one CSV row per control, or OSCAL assessment results. The
+report is written to --file, or to stdout when no file is given.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
-			var result interface{}
-			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/compliance/assessments/"+args[0]+"/export", nil, &result); err != nil {
+			path := "/api/v1/compliance/assessments/" + args[0] + "/export"
+
+			if format == "" || format == "json" {
+				var result interface{}
+				if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
+					return fmt.Errorf("failed to export assessment: %w", err)
+				}
+				return printOutput(result)
+			}
+
+			report, err := apiClient.Download(ctx, path+"?"+buildQueryParams(map[string]string{"format": format}))
+			if err != nil {
 				return fmt.Errorf("failed to export assessment: %w", err)
 			}
-			return printOutput(result)
+			if file == "" {
+				_, err = os.Stdout.Write(report)
+				return err
+			}
+			if err := os.WriteFile(file, report, 0o644); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			fmt.Printf("Report written to %s\n", file)
+			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&format, "format", "json", "report format (json, html, pdf, csv, oscal)")
+	cmd.Flags().StringVar(&file, "file", "", "write the report to this file")
+
+	return cmd
 }
 
 func newComplianceFailingControlsCmd() *cobra.Command {
@@ -181,3 +249,32 @@ func newComplianceFailingControlsCmd() *cobra.Command {
 		},
 	}
 }
+
+func newComplianceCrosswalkCmd() *cobra.Command {
+	var framework string
+
+	cmd := &cobra.Command{
+		Use:   "crosswalk",
+		Short: "Show linked controls across frameworks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			path := "/api/v1/compliance/crosswalk"
+			params := buildQueryParams(map[string]string{
+				"framework_id": framework,
+			})
+			if params != "" {
+				path += "?" + params
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
+				return fmt.Errorf("failed to get crosswalk: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&framework, "framework", "", "show every control of this framework ID")
+
+	return cmd
+}
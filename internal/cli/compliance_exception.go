@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newComplianceExceptionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exception",
+		Short: "Manage compliance exceptions (accepted risks)",
+	}
+
+	cmd.AddCommand(newComplianceExceptionListCmd())
+	cmd.AddCommand(newComplianceExceptionRequestCmd())
+	cmd.AddCommand(newComplianceExceptionGetCmd())
+	cmd.AddCommand(newComplianceExceptionApproveCmd())
+	cmd.AddCommand(newComplianceExceptionRejectCmd())
+	cmd.AddCommand(newComplianceExceptionRevokeCmd())
+
+	return cmd
+}
+
+func newComplianceExceptionListCmd() *cobra.Command {
+	var framework, control, resourceID, status string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List compliance exceptions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			path := "/api/v1/compliance/exceptions"
+			params := buildQueryParams(map[string]string{
+				"framework_id": framework,
+				"control_id":   control,
+				"resource_id":  resourceID,
+				"status":       status,
+			})
+			if params != "" {
+				path += "?" + params
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
+				return fmt.Errorf("failed to list exceptions: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&framework, "framework", "", "filter by framework ID")
+	cmd.Flags().StringVar(&control, "control", "", "filter by control ID")
+	cmd.Flags().StringVar(&resourceID, "resource", "", "filter by resource ID")
+	cmd.Flags().StringVar(&status, "status", "", "filter by status (pending, approved, rejected, revoked, expired)")
+
+	return cmd
+}
+
+func newComplianceExceptionRequestCmd() *cobra.Command {
+	var framework, control, resourceID, justification, expires string
+
+	cmd := &cobra.Command{
+		Use:   "request",
+		Short: "Request an exception for a failing control",
+		Long: `Request an exception that accepts the risk of a failing control.
+
+Without --resource the exception covers every resource of the control.
+The exception takes effect once approved and lapses at --expires.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if framework == "" || control == "" || justification == "" || expires == "" {
+				return fmt.Errorf("--framework, --control, --justification and --expires are required")
+			}
+			expiresAt, err := time.Parse("2006-01-02", expires)
+			if err != nil {
+				if expiresAt, err = time.Parse(time.RFC3339, expires); err != nil {
+					return fmt.Errorf("invalid --expires %q (expected YYYY-MM-DD or RFC 3339)", expires)
+				}
+			}
+
+			body := map[string]interface{}{
+				"framework_id":  framework,
+				"control_id":    control,
+				"justification": justification,
+				"expires_at":    expiresAt.Format(time.RFC3339),
+			}
+			if resourceID != "" {
+				body["resource_id"] = resourceID
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/compliance/exceptions", body, &result); err != nil {
+				return fmt.Errorf("failed to request exception: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&framework, "framework", "", "framework ID")
+	cmd.Flags().StringVar(&control, "control", "", "control ID")
+	cmd.Flags().StringVar(&resourceID, "resource", "", "only except this resource")
+	cmd.Flags().StringVar(&justification, "justification", "", "why the risk is accepted")
+	cmd.Flags().StringVar(&expires, "expires", "", "expiry date (YYYY-MM-DD or RFC 3339)")
+
+	return cmd
+}
+
+func newComplianceExceptionGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <exception-id>",
+		Short: "Show an exception",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/compliance/exceptions/"+args[0], nil, &result); err != nil {
+				return fmt.Errorf("failed to get exception: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
+func newComplianceExceptionApproveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve <exception-id>",
+		Short: "Approve a pending exception",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			body := map[string]interface{}{"approved": true}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/compliance/exceptions/"+args[0]+"/approve", body, nil); err != nil {
+				return fmt.Errorf("failed to approve exception: %w", err)
+			}
+			fmt.Printf("Exception %s approved\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newComplianceExceptionRejectCmd() *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "reject <exception-id>",
+		Short: "Reject a pending exception",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			body := map[string]interface{}{"approved": false, "reason": reason}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/compliance/exceptions/"+args[0]+"/approve", body, nil); err != nil {
+				return fmt.Errorf("failed to reject exception: %w", err)
+			}
+			fmt.Printf("Exception %s rejected\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "reason for the rejection")
+
+	return cmd
+}
+
+func newComplianceExceptionRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <exception-id>",
+		Short: "Revoke a pending or approved exception",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/compliance/exceptions/"+args[0]+"/revoke", nil, nil); err != nil {
+				return fmt.Errorf("failed to revoke exception: %w", err)
+			}
+			fmt.Printf("Exception %s revoked\n", args[0])
+			return nil
+		},
+	}
+}
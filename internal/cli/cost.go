@@ -20,7 +20,10 @@ func newCostCmd() *cobra.Command {
 	cmd.AddCommand(newCostAnomaliesCmd())
 	cmd.AddCommand(newCostDetectAnomaliesCmd())
 	cmd.AddCommand(newCostOptimizationsCmd())
+	cmd.AddCommand(newCostAnalyzeOptimizationsCmd())
 	cmd.AddCommand(newCostSavingsCmd())
+	cmd.AddCommand(newCostBudgetCmd())
+	cmd.AddCommand(newCostAllocationCmd())
 
 	return cmd
 }
@@ -72,17 +75,22 @@ func newCostTrendsCmd() *cobra.Command {
 }
 
 func newCostForecastCmd() *cobra.Command {
-	var provider, days string
+	var provider, days, method, granularity string
 
 	cmd := &cobra.Command{
 		Use:   "forecast",
 		Short: "Show cost forecast",
+		Long: `Show forecast costs with 95% prediction intervals. By default the forecast
+fits trend and weekly seasonality (Holt-Winters) when there are at least two
+weeks of history, and reports its backtest error (MAPE).`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			path := "/api/v1/costs/forecast"
 			params := buildQueryParams(map[string]string{
-				"provider": provider,
-				"days":     days,
+				"provider":    provider,
+				"days":        days,
+				"method":      method,
+				"granularity": granularity,
 			})
 			if params != "" {
 				path += "?" + params
@@ -98,6 +106,8 @@ func newCostForecastCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&provider, "provider", "", "filter by provider")
 	cmd.Flags().StringVar(&days, "days", "", "forecast days (30, 60, 90)")
+	cmd.Flags().StringVar(&method, "method", "", "forecast method (auto, holt_winters, linear, mean)")
+	cmd.Flags().StringVar(&granularity, "granularity", "", "forecast point granularity (daily, weekly, monthly)")
 
 	return cmd
 }
@@ -185,6 +195,42 @@ func newCostOptimizationsCmd() *cobra.Command {
 	}
 }
 
+func newCostAnalyzeOptimizationsCmd() *cobra.Command {
+	var provider string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-optimizations",
+		Short: "Find cost optimizations in synced resources",
+		Long: `Check synced resources for stopped instances that are still billed,
+unattached volumes, previous-generation instance types and buckets without
+lifecycle rules, and price each finding from cost records or list prices.
+Findings that are already pending are refreshed rather than duplicated.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			fmt.Println("Running optimization analysis...")
+
+			path := "/api/v1/costs/optimizations/analyze"
+			if params := buildQueryParams(map[string]string{"provider": provider}); params != "" {
+				path += "?" + params
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "POST", path, nil, &result); err != nil {
+				return fmt.Errorf("optimization analysis failed: %w", err)
+			}
+			fmt.Println("Optimization analysis completed")
+			if result != nil {
+				return printOutput(result)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "analyze a specific provider")
+
+	return cmd
+}
+
 func newCostSavingsCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "savings",
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCostAllocationCmd() *cobra.Command {
+	var ruleID, provider, start, end, granularity string
+	var tagKeys []string
+
+	cmd := &cobra.Command{
+		Use:   "allocation",
+		Short: "Show costs allocated to teams and other tag groups",
+		Long: `Show costs per allocation group with a trend per day, week or month. Group
+costs by a saved rule (--rule) or by tag keys (--tag-key); costs without any of
+the tag keys are reported as "untagged". The report covers the current and the
+two previous months by default. The API returns CSV with format=csv.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			path := "/api/v1/costs/allocation"
+			params := buildQueryParams(map[string]string{
+				"rule_id":     ruleID,
+				"tag_keys":    strings.Join(tagKeys, ","),
+				"provider":    provider,
+				"start":       start,
+				"end":         end,
+				"granularity": granularity,
+			})
+			if params != "" {
+				path += "?" + params
+			}
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
+				return fmt.Errorf("failed to get cost allocation: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&ruleID, "rule", "", "allocation rule ID")
+	cmd.Flags().StringSliceVar(&tagKeys, "tag-key", nil, "group by this tag key, in order of precedence (repeatable)")
+	cmd.Flags().StringVar(&provider, "provider", "", "filter by provider")
+	cmd.Flags().StringVar(&start, "start", "", "first day of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&end, "end", "", "last day of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&granularity, "granularity", "", "trend granularity (daily, weekly, monthly)")
+
+	cmd.AddCommand(newCostAllocationRulesCmd())
+
+	return cmd
+}
+
+func newCostAllocationRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manage cost allocation rules",
+	}
+
+	cmd.AddCommand(newCostAllocationRulesListCmd())
+	cmd.AddCommand(newCostAllocationRulesCreateCmd())
+	cmd.AddCommand(newCostAllocationRulesGetCmd())
+	cmd.AddCommand(newCostAllocationRulesUpdateCmd())
+	cmd.AddCommand(newCostAllocationRulesDeleteCmd())
+
+	return cmd
+}
+
+// allocationRuleFlags holds the flags shared by rule create and update
+type allocationRuleFlags struct {
+	name         string
+	tagKeys      []string
+	aliases      []string
+	proportional []string
+	fixed        []string
+}
+
+func (f *allocationRuleFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "name", "", "rule name")
+	cmd.Flags().StringSliceVar(&f.tagKeys, "tag-key", nil, "group by this tag key, in order of precedence (repeatable)")
+	cmd.Flags().StringSliceVar(&f.aliases, "alias", nil, "map a tag value onto a group, as value=group (repeatable)")
+	cmd.Flags().StringSliceVar(&f.proportional, "split", nil, "share a group's costs in proportion to the other groups' costs (repeatable)")
+	cmd.Flags().StringArrayVar(&f.fixed, "split-fixed", nil, "share a group's costs by percent, as group=team-a:60,team-b:40 (repeatable)")
+}
+
+// body builds the request body from the flags that were set. Aliases and
+// splits are sent as a whole when any of their flags is set.
+func (f *allocationRuleFlags) body(cmd *cobra.Command) (map[string]interface{}, error) {
+	body := map[string]interface{}{}
+	changed := cmd.Flags().Changed
+
+	if changed("name") {
+		body["name"] = f.name
+	}
+	if changed("tag-key") {
+		body["tag_keys"] = f.tagKeys
+	}
+
+	if changed("alias") {
+		aliases := map[string]string{}
+		for _, alias := range f.aliases {
+			value, group, ok := strings.Cut(alias, "=")
+			if !ok {
+				return nil, fmt.Errorf("--alias must be value=group, got %q", alias)
+			}
+			aliases[value] = group
+		}
+		body["aliases"] = aliases
+	}
+
+	if changed("split") || changed("split-fixed") {
+		splits := []map[string]interface{}{}
+		for _, group := range f.proportional {
+			splits = append(splits, map[string]interface{}{"group": group, "method": "proportional"})
+		}
+		for _, spec := range f.fixed {
+			group, targets, ok := strings.Cut(spec, "=")
+			if !ok {
+				return nil, fmt.Errorf("--split-fixed must be group=target:percent,..., got %q", spec)
+			}
+			weights := map[string]float64{}
+			for _, target := range strings.Split(targets, ",") {
+				name, percent, ok := strings.Cut(target, ":")
+				weight, err := strconv.ParseFloat(percent, 64)
+				if !ok || err != nil {
+					return nil, fmt.Errorf("--split-fixed target must be group:percent, got %q", target)
+				}
+				weights[name] = weight
+			}
+			splits = append(splits, map[string]interface{}{"group": group, "method": "fixed", "weights": weights})
+		}
+		body["splits"] = splits
+	}
+
+	return body, nil
+}
+
+func newCostAllocationRulesListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List allocation rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/costs/allocation/rules", nil, &result); err != nil {
+				return fmt.Errorf("failed to list allocation rules: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
+func newCostAllocationRulesCreateCmd() *cobra.Command {
+	var flags allocationRuleFlags
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an allocation rule",
+		Long: `Create an allocation rule. The first of the --tag-key tags present on a cost
+names its group, after --alias mappings. --split and --split-fixed share the
+costs of a group, such as "shared" or "platform", among the other groups.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.name == "" {
+				flags.name = promptInput("Rule name: ")
+			}
+			if len(flags.tagKeys) == 0 {
+				return fmt.Errorf("at least one --tag-key is required")
+			}
+
+			body, err := flags.body(cmd)
+			if err != nil {
+				return err
+			}
+			body["name"] = flags.name
+
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/costs/allocation/rules", body, &result); err != nil {
+				return fmt.Errorf("failed to create allocation rule: %w", err)
+			}
+			fmt.Printf("Allocation rule '%s' created\n", flags.name)
+			return printOutput(result)
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newCostAllocationRulesGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Get an allocation rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/costs/allocation/rules/"+args[0], nil, &result); err != nil {
+				return fmt.Errorf("failed to get allocation rule: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
+func newCostAllocationRulesUpdateCmd() *cobra.Command {
+	var flags allocationRuleFlags
+
+	cmd := &cobra.Command{
+		Use:   "update <id>",
+		Short: "Update an allocation rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := flags.body(cmd)
+			if err != nil {
+				return err
+			}
+			if len(body) == 0 {
+				return fmt.Errorf("nothing to update")
+			}
+
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "PUT", "/api/v1/costs/allocation/rules/"+args[0], body, &result); err != nil {
+				return fmt.Errorf("failed to update allocation rule: %w", err)
+			}
+			fmt.Printf("Allocation rule %s updated\n", args[0])
+			return printOutput(result)
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newCostAllocationRulesDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete an allocation rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if err := apiClient.DoRaw(ctx, "DELETE", "/api/v1/costs/allocation/rules/"+args[0], nil, nil); err != nil {
+				return fmt.Errorf("failed to delete allocation rule: %w", err)
+			}
+			fmt.Printf("Allocation rule %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
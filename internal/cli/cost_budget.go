@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCostBudgetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "budget",
+		Short: "Manage cost budgets and threshold alerts",
+	}
+
+	cmd.AddCommand(newCostBudgetListCmd())
+	cmd.AddCommand(newCostBudgetCreateCmd())
+	cmd.AddCommand(newCostBudgetGetCmd())
+	cmd.AddCommand(newCostBudgetUpdateCmd())
+	cmd.AddCommand(newCostBudgetDeleteCmd())
+	cmd.AddCommand(newCostBudgetStatusCmd())
+
+	return cmd
+}
+
+// budgetFlags holds the flags shared by budget create and update
+type budgetFlags struct {
+	name, period, currency         string
+	amount                         float64
+	provider, service, region, tag string
+	thresholds, forecastThresholds []float64
+	enabled                        bool
+}
+
+func (f *budgetFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "name", "", "budget name")
+	cmd.Flags().StringVar(&f.period, "period", "monthly", "budget period (monthly, quarterly)")
+	cmd.Flags().Float64Var(&f.amount, "amount", 0, "budget amount per period")
+	cmd.Flags().StringVar(&f.currency, "currency", "", "budget currency (default USD)")
+	cmd.Flags().StringVar(&f.provider, "provider", "", "only count costs of this provider")
+	cmd.Flags().StringVar(&f.service, "service", "", "only count costs of this service")
+	cmd.Flags().StringVar(&f.region, "region", "", "only count costs in this region")
+	cmd.Flags().StringVar(&f.tag, "tag", "", "only count costs with this tag (key or key=value)")
+	cmd.Flags().Float64SliceVar(&f.thresholds, "threshold", nil, "alert when actual spend reaches this percent (repeatable)")
+	cmd.Flags().Float64SliceVar(&f.forecastThresholds, "forecast-threshold", nil, "alert when projected spend reaches this percent (repeatable)")
+	cmd.Flags().BoolVar(&f.enabled, "enabled", true, "evaluate the budget after each cost sync")
+}
+
+// body builds the request body from the flags that were set. Scope and
+// thresholds are sent as a whole when any of their flags is set.
+func (f *budgetFlags) body(cmd *cobra.Command) map[string]interface{} {
+	body := map[string]interface{}{}
+	changed := cmd.Flags().Changed
+
+	if changed("name") {
+		body["name"] = f.name
+	}
+	if changed("period") || cmd.Name() == "create" {
+		body["period"] = f.period
+	}
+	if changed("amount") {
+		body["amount"] = f.amount
+	}
+	if changed("currency") {
+		body["currency"] = f.currency
+	}
+	if changed("enabled") {
+		body["is_enabled"] = f.enabled
+	}
+
+	if changed("provider") || changed("service") || changed("region") || changed("tag") {
+		scope := map[string]string{
+			"provider":     f.provider,
+			"service_name": f.service,
+			"region":       f.region,
+		}
+		if f.tag != "" {
+			key, value, _ := strings.Cut(f.tag, "=")
+			scope["tag_key"] = key
+			scope["tag_value"] = value
+		}
+		body["scope"] = scope
+	}
+
+	if changed("threshold") || changed("forecast-threshold") {
+		var thresholds []map[string]interface{}
+		for _, p := range f.thresholds {
+			thresholds = append(thresholds, map[string]interface{}{"percent": p, "type": "actual"})
+		}
+		for _, p := range f.forecastThresholds {
+			thresholds = append(thresholds, map[string]interface{}{"percent": p, "type": "forecasted"})
+		}
+		body["thresholds"] = thresholds
+	}
+
+	return body
+}
+
+func newCostBudgetListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List budgets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/costs/budgets", nil, &result); err != nil {
+				return fmt.Errorf("failed to list budgets: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
+func newCostBudgetCreateCmd() *cobra.Command {
+	var flags budgetFlags
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a budget",
+		Long: `Create a budget. Without --threshold or --forecast-threshold the budget
+alerts at 50%, 80% and 100% of actual spend and when projected to exceed 100%.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.name == "" {
+				flags.name = promptInput("Budget name: ")
+			}
+			if flags.amount <= 0 {
+				return fmt.Errorf("--amount must be greater than zero")
+			}
+
+			ctx := context.Background()
+			body := flags.body(cmd)
+			body["name"] = flags.name
+
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/costs/budgets", body, &result); err != nil {
+				return fmt.Errorf("failed to create budget: %w", err)
+			}
+			fmt.Printf("Budget '%s' created\n", flags.name)
+			return printOutput(result)
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newCostBudgetGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Get a budget",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/costs/budgets/"+args[0], nil, &result); err != nil {
+				return fmt.Errorf("failed to get budget: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
+
+func newCostBudgetUpdateCmd() *cobra.Command {
+	var flags budgetFlags
+
+	cmd := &cobra.Command{
+		Use:   "update <id>",
+		Short: "Update a budget",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body := flags.body(cmd)
+			if len(body) == 0 {
+				return fmt.Errorf("nothing to update")
+			}
+
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "PUT", "/api/v1/costs/budgets/"+args[0], body, &result); err != nil {
+				return fmt.Errorf("failed to update budget: %w", err)
+			}
+			fmt.Printf("Budget %s updated\n", args[0])
+			return printOutput(result)
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newCostBudgetDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a budget",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if err := apiClient.DoRaw(ctx, "DELETE", "/api/v1/costs/budgets/"+args[0], nil, nil); err != nil {
+				return fmt.Errorf("failed to delete budget: %w", err)
+			}
+			fmt.Printf("Budget %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newCostBudgetStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <id>",
+		Short: "Show spend, projection and alerts for the current period",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			var result interface{}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/costs/budgets/"+args[0]+"/status", nil, &result); err != nil {
+				return fmt.Errorf("failed to get budget status: %w", err)
+			}
+			return printOutput(result)
+		},
+	}
+}
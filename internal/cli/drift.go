@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -106,6 +107,28 @@ func newDriftGetCmd() *cobra.Command {
 			fmt.Printf("Resource:    %d\n", drift.ResourceID)
 			fmt.Printf("Description: %s\n", drift.Description)
 			fmt.Printf("Detected:    %s\n", drift.DetectedAt.Format("2006-01-02 15:04:05"))
+			if drift.LastSeenAt != nil {
+				fmt.Printf("Last Seen:   %s (%d occurrences)\n", drift.LastSeenAt.Format("2006-01-02 15:04:05"), drift.Occurrences)
+			}
+			if drift.ResolvedAt != nil {
+				fmt.Printf("Resolved:    %s\n", drift.ResolvedAt.Format("2006-01-02 15:04:05"))
+			}
+
+			if len(drift.Changes) > 0 {
+				fmt.Println()
+				fmt.Println("Changes:")
+				table := NewTable("PATH", "CHANGE", "BASELINE", "CURRENT", "SEVERITY")
+				for _, c := range drift.Changes {
+					table.AddRow(
+						c.Path,
+						c.ChangeType,
+						truncate(formatChangeValue(c.OldValue), 40),
+						truncate(formatChangeValue(c.NewValue), 40),
+						c.Severity,
+					)
+				}
+				table.Render()
+			}
 			return nil
 		},
 	}
@@ -173,3 +196,18 @@ func newDriftResolveCmd() *cobra.Command {
 		},
 	}
 }
+
+// formatChangeValue renders a configuration value for the diff view
+func formatChangeValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return t
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -3,12 +3,64 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/pratik-mahalle/infraudit/pkg/client"
 	"github.com/spf13/cobra"
 )
 
+// providerAccount is a connected provider account as returned by the API
+type providerAccount struct {
+	ID          int64      `json:"id"`
+	Provider    string     `json:"provider"`
+	Name        string     `json:"name"`
+	IsConnected bool       `json:"is_connected"`
+	LastSynced  *time.Time `json:"last_synced,omitempty"`
+	SyncStatus  string     `json:"sync_status,omitempty"`
+	SyncMessage string     `json:"sync_message,omitempty"`
+
+	Verification *providerVerification `json:"verification,omitempty"`
+}
+
+// providerSyncRun is the changelog of one provider account sync
+type providerSyncRun struct {
+	ID       int64     `json:"id"`
+	SyncedAt time.Time `json:"synced_at"`
+	Added    int       `json:"added"`
+	Updated  int       `json:"updated"`
+	Removed  int       `json:"removed"`
+	Purged   int       `json:"purged"`
+	Changes  []struct {
+		ResourceID string   `json:"resource_id"`
+		Name       string   `json:"name"`
+		Type       string   `json:"type"`
+		Change     string   `json:"change"`
+		Fields     []string `json:"fields,omitempty"`
+	} `json:"changes"`
+}
+
+// providerVerification is the result of verifying provider credentials
+type providerVerification struct {
+	Identity           string   `json:"identity"`
+	Account            string   `json:"account"`
+	PermissionsChecked []string `json:"permissions_checked"`
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
+}
+
+// providerAccountStatus is the sync status of a provider account
+type providerAccountStatus struct {
+	Provider      string     `json:"provider"`
+	AccountID     int64      `json:"account_id"`
+	AccountName   string     `json:"account_name"`
+	IsConnected   bool       `json:"is_connected"`
+	LastSynced    *time.Time `json:"last_synced,omitempty"`
+	ResourceCount int        `json:"resource_count"`
+	Status        string     `json:"status"`
+	Message       string     `json:"message,omitempty"`
+}
+
 func newProviderCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "provider",
@@ -20,38 +72,49 @@ func newProviderCmd() *cobra.Command {
 	cmd.AddCommand(newProviderSyncCmd())
 	cmd.AddCommand(newProviderDisconnectCmd())
 	cmd.AddCommand(newProviderStatusCmd())
+	cmd.AddCommand(newProviderVerifyCmd())
+	cmd.AddCommand(newProviderChangesCmd())
+	cmd.AddCommand(newProviderExternalIDCmd())
 
 	return cmd
 }
 
 func newProviderListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "list",
-		Short: "List connected providers",
+		Use:   "list [aws|gcp|azure]",
+		Short: "List connected provider accounts",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/api/v1/providers"
+			if len(args) == 1 {
+				path = "/api/v1/providers/" + args[0] + "/accounts"
+			}
+
 			ctx := context.Background()
-			providers, err := apiClient.Providers().List(ctx, nil)
-			if err != nil {
+			var result struct {
+				Data []providerAccount `json:"data"`
+			}
+			if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
 				return fmt.Errorf("failed to list providers: %w", err)
 			}
 
 			format := getOutputFormat()
 			if format != "table" {
-				return printOutput(providers)
+				return printOutput(result.Data)
 			}
 
-			t := NewTable("ID", "NAME", "TYPE", "STATUS", "LAST SYNCED")
-			for _, p := range providers {
-				lastSync := "never"
-				if p.LastSyncedAt != nil {
-					lastSync = p.LastSyncedAt.Format("2006-01-02 15:04")
+			t := NewTable("ID", "PROVIDER", "ACCOUNT", "STATUS", "LAST SYNCED")
+			for _, p := range result.Data {
+				status := "disconnected"
+				if p.IsConnected {
+					status = "connected"
 				}
 				t.AddRow(
 					strconv.FormatInt(p.ID, 10),
+					p.Provider,
 					p.Name,
-					p.ProviderType,
-					formatStatus(p.Status),
-					lastSync,
+					formatStatus(status),
+					formatLastSynced(p.LastSynced),
 				)
 			}
 			t.Render()
@@ -61,96 +124,171 @@ func newProviderListCmd() *cobra.Command {
 }
 
 func newProviderConnectCmd() *cobra.Command {
+	var account string
+	var roleARNs []string
+	var externalID, orgRole, orgExternalID string
+
 	cmd := &cobra.Command{
 		Use:   "connect <aws|gcp|azure>",
-		Short: "Connect a cloud provider",
-		Args:  cobra.ExactArgs(1),
+		Short: "Connect a cloud provider account",
+		Long: `Connect a cloud provider account. Several accounts of one provider can be
+connected by giving each a name with --account; connecting a name that
+already exists replaces its credentials.
+
+AWS accounts can be connected without long-lived access keys by assuming
+IAM roles with --role-arn. Roles are assumed in the order given, starting
+from the access keys or, when none are entered, the server's instance
+profile. A role assumed from the instance profile must require your
+external ID (see "provider external-id") in its trust policy; it is sent
+with the first role unless --external-id sets it. With --organization-role,
+every member account of the AWS Organization is connected through that
+role as well.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			providerType := args[0]
-			credentials := map[string]interface{}{}
+			body := map[string]interface{}{}
+			if account != "" {
+				body["name"] = account
+			}
 
+			ctx := context.Background()
 			switch providerType {
 			case "aws":
-				credentials["access_key_id"] = promptInput("AWS Access Key ID: ")
-				credentials["secret_access_key"] = promptPassword("AWS Secret Access Key: ")
+				keyless := false
+				if len(roleARNs) == 0 {
+					body["aws_access_key_id"] = promptInput("AWS Access Key ID: ")
+					body["aws_secret_access_key"] = promptPassword("AWS Secret Access Key: ")
+				} else if keyID := promptInput("AWS Access Key ID (blank for the server's instance profile): "); keyID != "" {
+					body["aws_access_key_id"] = keyID
+					body["aws_secret_access_key"] = promptPassword("AWS Secret Access Key: ")
+				} else {
+					keyless = true
+				}
+				if len(roleARNs) > 0 {
+					chain := make([]map[string]string, len(roleARNs))
+					for i, arn := range roleARNs {
+						chain[i] = map[string]string{"role_arn": arn}
+					}
+					if externalID != "" {
+						chain[len(chain)-1]["external_id"] = externalID
+					}
+					if keyless && chain[0]["external_id"] == "" {
+						id, err := fetchAWSExternalID(ctx)
+						if err != nil {
+							return err
+						}
+						chain[0]["external_id"] = id
+					}
+					body["aws_role_chain"] = chain
+				}
+				if orgRole != "" {
+					body["aws_organization_role_name"] = orgRole
+					if orgExternalID != "" {
+						body["aws_organization_external_id"] = orgExternalID
+					}
+				}
 				region := promptInput("AWS Region [us-east-1]: ")
 				if region == "" {
 					region = "us-east-1"
 				}
-				credentials["region"] = region
+				body["aws_region"] = region
 			case "gcp":
-				credentials["project_id"] = promptInput("GCP Project ID: ")
-				credentials["service_account_json"] = promptInput("Path to service account JSON: ")
+				body["gcp_project_id"] = promptInput("GCP Project ID: ")
+				keyPath := promptInput("Path to service account JSON: ")
+				keyJSON, err := os.ReadFile(keyPath)
+				if err != nil {
+					return fmt.Errorf("failed to read service account JSON: %w", err)
+				}
+				body["gcp_service_account_json"] = string(keyJSON)
 			case "azure":
-				credentials["tenant_id"] = promptInput("Azure Tenant ID: ")
-				credentials["client_id"] = promptInput("Azure Client ID: ")
-				credentials["client_secret"] = promptPassword("Azure Client Secret: ")
-				credentials["subscription_id"] = promptInput("Azure Subscription ID: ")
+				body["azure_tenant_id"] = promptInput("Azure Tenant ID: ")
+				body["azure_client_id"] = promptInput("Azure Client ID: ")
+				body["azure_client_secret"] = promptPassword("Azure Client Secret: ")
+				body["azure_subscription_id"] = promptInput("Azure Subscription ID: ")
 			default:
 				return fmt.Errorf("unsupported provider type: %s (use aws, gcp, or azure)", providerType)
 			}
 
-			ctx := context.Background()
-			provider, err := apiClient.Providers().Create(ctx, client.CreateProviderRequest{
-				Name:         providerType + "-account",
-				ProviderType: providerType,
-				Credentials:  credentials,
-			})
-			if err != nil {
+			var result struct {
+				Data providerAccount `json:"data"`
+			}
+			if err := apiClient.DoRaw(ctx, "POST", "/api/v1/providers/"+providerType+"/connect", body, &result); err != nil {
 				return fmt.Errorf("failed to connect provider: %w", err)
 			}
 
-			fmt.Printf("Connected to %s successfully (ID: %d)\n", providerType, provider.ID)
+			fmt.Printf("Connected %s account %q successfully (ID: %d)\n", providerType, result.Data.Name, result.Data.ID)
+			if v := result.Data.Verification; v != nil && len(v.MissingPermissions) > 0 {
+				printMissingPermissions(v)
+			}
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&account, "account", "", "account name (default \"default\")")
+	cmd.Flags().StringSliceVar(&roleARNs, "role-arn", nil, "AWS role to assume, in order (repeatable)")
+	cmd.Flags().StringVar(&externalID, "external-id", "", "external ID of the last AWS role")
+	cmd.Flags().StringVar(&orgRole, "organization-role", "", "role name to assume in each AWS Organization member account")
+	cmd.Flags().StringVar(&orgExternalID, "organization-external-id", "", "external ID of the organization member role")
 	return cmd
 }
 
 func newProviderSyncCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "sync <provider-id>",
+	var account string
+
+	cmd := &cobra.Command{
+		Use:   "sync <aws|gcp|azure>",
 		Short: "Sync resources from a provider",
+		Long:  "Sync resources from every connected account of a provider, or from one account with --account.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id, err := strconv.ParseInt(args[0], 10, 64)
-			if err != nil {
-				return fmt.Errorf("invalid provider ID: %s", args[0])
+			providerType := args[0]
+			ctx := context.Background()
+
+			path := "/api/v1/providers/" + providerType + "/sync"
+			if account != "" {
+				accountID, err := resolveProviderAccount(ctx, providerType, account)
+				if err != nil {
+					return err
+				}
+				path = fmt.Sprintf("/api/v1/providers/%s/accounts/%d/sync", providerType, accountID)
 			}
 
-			ctx := context.Background()
 			fmt.Println("Syncing resources...")
-			result, err := apiClient.Providers().Sync(ctx, id)
-			if err != nil {
+			if err := apiClient.DoRaw(ctx, "POST", path, nil, nil); err != nil {
 				return fmt.Errorf("sync failed: %w", err)
 			}
 
-			fmt.Printf("Sync complete: %d found, %d created, %d updated\n",
-				result.ResourcesFound, result.ResourcesCreated, result.ResourcesUpdated)
-			if len(result.Errors) > 0 {
-				fmt.Printf("Errors: %d\n", len(result.Errors))
-				for _, e := range result.Errors {
-					fmt.Printf("  - %s\n", e)
-				}
-			}
+			fmt.Println("Sync complete")
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&account, "account", "", "only sync this account (name or ID)")
+	return cmd
 }
 
 func newProviderDisconnectCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "disconnect <provider-id>",
+	var account string
+
+	cmd := &cobra.Command{
+		Use:   "disconnect <aws|gcp|azure>",
 		Short: "Disconnect a provider",
+		Long:  "Disconnect every account of a provider, or one account with --account.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id, err := strconv.ParseInt(args[0], 10, 64)
-			if err != nil {
-				return fmt.Errorf("invalid provider ID: %s", args[0])
+			providerType := args[0]
+			ctx := context.Background()
+
+			path := "/api/v1/providers/" + providerType
+			if account != "" {
+				accountID, err := resolveProviderAccount(ctx, providerType, account)
+				if err != nil {
+					return err
+				}
+				path = fmt.Sprintf("/api/v1/providers/%s/accounts/%d", providerType, accountID)
 			}
 
-			ctx := context.Background()
-			if err := apiClient.Providers().Delete(ctx, id); err != nil {
+			if err := apiClient.DoRaw(ctx, "DELETE", path, nil, nil); err != nil {
 				return fmt.Errorf("failed to disconnect provider: %w", err)
 			}
 
@@ -158,39 +296,234 @@ func newProviderDisconnectCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&account, "account", "", "only disconnect this account (name or ID)")
+	return cmd
 }
 
 func newProviderStatusCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "status",
+	var account string
+
+	cmd := &cobra.Command{
+		Use:   "status [aws|gcp|azure]",
 		Short: "Show provider sync status",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
-			providers, err := apiClient.Providers().List(ctx, nil)
-			if err != nil {
+			var result struct {
+				Data []providerAccountStatus `json:"data"`
+			}
+			if err := apiClient.DoRaw(ctx, "GET", "/api/v1/providers/status", nil, &result); err != nil {
 				return fmt.Errorf("failed to get provider status: %w", err)
 			}
 
+			statuses := make([]providerAccountStatus, 0, len(result.Data))
+			for _, s := range result.Data {
+				if len(args) == 1 && s.Provider != args[0] {
+					continue
+				}
+				if account != "" && s.AccountName != account && strconv.FormatInt(s.AccountID, 10) != account {
+					continue
+				}
+				statuses = append(statuses, s)
+			}
+
 			format := getOutputFormat()
 			if format != "table" {
-				return printOutput(providers)
+				return printOutput(statuses)
 			}
 
-			t := NewTable("ID", "TYPE", "STATUS", "LAST SYNCED")
-			for _, p := range providers {
-				lastSync := "never"
-				if p.LastSyncedAt != nil {
-					lastSync = p.LastSyncedAt.Format("2006-01-02 15:04:05")
-				}
+			t := NewTable("ID", "PROVIDER", "ACCOUNT", "STATUS", "RESOURCES", "LAST SYNCED", "MESSAGE")
+			for _, s := range statuses {
 				t.AddRow(
-					strconv.FormatInt(p.ID, 10),
-					p.ProviderType,
-					formatStatus(p.Status),
-					lastSync,
+					strconv.FormatInt(s.AccountID, 10),
+					s.Provider,
+					s.AccountName,
+					formatStatus(s.Status),
+					strconv.Itoa(s.ResourceCount),
+					formatLastSynced(s.LastSynced),
+					s.Message,
 				)
 			}
 			t.Render()
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&account, "account", "", "only show this account (name or ID)")
+	return cmd
+}
+
+func newProviderExternalIDCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "external-id",
+		Short: "Show your AWS external ID",
+		Long: `Show the external ID that AWS roles assumed with the server's own identity,
+such as its instance profile, must require in their trust policy. The ID
+is generated on first use and never changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := fetchAWSExternalID(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Println(id)
+			return nil
+		},
+	}
+}
+
+// fetchAWSExternalID retrieves the user's AWS external ID from the server
+func fetchAWSExternalID(ctx context.Context) (string, error) {
+	var result struct {
+		Data struct {
+			ExternalID string `json:"external_id"`
+		} `json:"data"`
+	}
+	if err := apiClient.DoRaw(ctx, "GET", "/api/v1/providers/aws/external-id", nil, &result); err != nil {
+		return "", fmt.Errorf("failed to get AWS external ID: %w", err)
+	}
+	return result.Data.ExternalID, nil
+}
+
+func newProviderVerifyCmd() *cobra.Command {
+	var account string
+
+	cmd := &cobra.Command{
+		Use:   "verify <aws|gcp|azure>",
+		Short: "Verify provider account credentials",
+		Long: `Verify the credentials of a connected account against the provider's API.
+Shows the identity the credentials act as and any read permissions that
+resource sync needs but the identity lacks.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providerType := args[0]
+			ctx := context.Background()
+
+			if account == "" {
+				account = "default"
+			}
+			accountID, err := resolveProviderAccount(ctx, providerType, account)
+			if err != nil {
+				return err
+			}
+
+			var result struct {
+				Data providerVerification `json:"data"`
+			}
+			path := fmt.Sprintf("/api/v1/providers/%s/accounts/%d/verify", providerType, accountID)
+			if err := apiClient.DoRaw(ctx, "POST", path, nil, &result); err != nil {
+				return fmt.Errorf("verification failed: %w", err)
+			}
+
+			format := getOutputFormat()
+			if format != "table" {
+				return printOutput(result.Data)
+			}
+
+			v := result.Data
+			fmt.Printf("Identity: %s\n", v.Identity)
+			fmt.Printf("Account:  %s\n", v.Account)
+			if len(v.MissingPermissions) == 0 {
+				fmt.Printf("All %d permissions needed for sync are granted\n", len(v.PermissionsChecked))
+				return nil
+			}
+			printMissingPermissions(&v)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&account, "account", "", "account to verify (name or ID, default \"default\")")
+	return cmd
+}
+
+func newProviderChangesCmd() *cobra.Command {
+	var account string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "changes <aws|gcp|azure>",
+		Short: "Show resources added, updated and removed by recent syncs",
+		Long: `Show what recent syncs of a provider account changed. Removed resources
+vanished from the provider and are kept as terminated until the grace
+period ends; purged resources were then deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providerType := args[0]
+			ctx := context.Background()
+
+			if account == "" {
+				account = "default"
+			}
+			accountID, err := resolveProviderAccount(ctx, providerType, account)
+			if err != nil {
+				return err
+			}
+
+			var result struct {
+				Data []providerSyncRun `json:"data"`
+			}
+			path := fmt.Sprintf("/api/v1/providers/%s/accounts/%d/syncs?limit=%d", providerType, accountID, limit)
+			if err := apiClient.DoRaw(ctx, "GET", path, nil, &result); err != nil {
+				return fmt.Errorf("failed to list syncs: %w", err)
+			}
+
+			format := getOutputFormat()
+			if format != "table" {
+				return printOutput(result.Data)
+			}
+
+			t := NewTable("SYNCED", "CHANGE", "RESOURCE", "TYPE", "FIELDS")
+			for _, run := range result.Data {
+				for _, c := range run.Changes {
+					t.AddRow(
+						run.SyncedAt.Local().Format("2006-01-02 15:04"),
+						c.Change,
+						c.ResourceID,
+						c.Type,
+						strings.Join(c.Fields, ", "),
+					)
+				}
+			}
+			t.Render()
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&account, "account", "", "account to show (name or ID, default \"default\")")
+	cmd.Flags().IntVar(&limit, "limit", 10, "number of syncs to show")
+	return cmd
+}
+
+// printMissingPermissions warns about permissions sync needs but lacks
+func printMissingPermissions(v *providerVerification) {
+	fmt.Printf("Warning: missing %d of %d permissions needed for sync:\n", len(v.MissingPermissions), len(v.PermissionsChecked))
+	for _, p := range v.MissingPermissions {
+		fmt.Printf("  - %s\n", p)
+	}
+}
+
+// resolveProviderAccount returns the ID of a provider account given by name
+// or ID
+func resolveProviderAccount(ctx context.Context, providerType, account string) (int64, error) {
+	var result struct {
+		Data []providerAccount `json:"data"`
+	}
+	if err := apiClient.DoRaw(ctx, "GET", "/api/v1/providers/"+providerType+"/accounts", nil, &result); err != nil {
+		return 0, fmt.Errorf("failed to list %s accounts: %w", providerType, err)
+	}
+
+	for _, a := range result.Data {
+		if a.Name == account || strconv.FormatInt(a.ID, 10) == account {
+			return a.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no %s account named %q", providerType, account)
+}
+
+func formatLastSynced(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
 }
@@ -11,15 +11,19 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	Supabase SupabaseConfig
-	OAuth    OAuthConfig
-	Redis    RedisConfig
-	Logging  LoggingConfig
-	Provider ProviderConfig
-	Scanner  ScannerConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Auth        AuthConfig
+	Supabase    SupabaseConfig
+	OAuth       OAuthConfig
+	Redis       RedisConfig
+	Logging     LoggingConfig
+	Provider    ProviderConfig
+	Scanner     ScannerConfig
+	Drift       DriftConfig
+	Remediation RemediationConfig
+	Scheduler   SchedulerConfig
+	Encryption  EncryptionConfig
 }
 
 // SupabaseConfig contains Supabase integration configuration
@@ -111,6 +115,16 @@ type ProviderConfig struct {
 	SlackWebhookURL string
 	SlackChannel    string
 	StripeAPIKey    string
+	SMTPHost        string
+	SMTPPort        int
+	SMTPUser        string
+	SMTPPassword    string
+	SMTPUseTLS      bool
+	EmailFrom       string
+	EmailFromName   string
+	// StaleResourceGracePeriod is how long a resource that vanished from its
+	// provider stays terminated before a sync deletes it
+	StaleResourceGracePeriod time.Duration
 }
 
 // ScannerConfig contains vulnerability scanner configuration
@@ -120,6 +134,43 @@ type ScannerConfig struct {
 	NVDAPIKey     string
 }
 
+// DriftConfig contains drift detection configuration
+type DriftConfig struct {
+	RulesDir string // optional directory of rule files merged over the built-in rules
+}
+
+// RemediationConfig contains configuration for IaC pull-request remediation
+type RemediationConfig struct {
+	GitPath           string
+	CommitAuthorName  string
+	CommitAuthorEmail string
+	GitHubToken       string
+	GitHubAPIURL      string // set for GitHub Enterprise Server
+	GitLabToken       string
+	GitLabURL         string
+	AllowLocalRepos   bool // accept repositories on the server's filesystem; never enable on shared deployments
+}
+
+// SchedulerConfig contains background job scheduler configuration
+type SchedulerConfig struct {
+	Enabled         bool
+	JobTimeout      time.Duration // default per-attempt timeout for jobs without one
+	RetryBaseDelay  time.Duration
+	RetryMaxDelay   time.Duration
+	StopGracePeriod time.Duration // how long a cancelled attempt may take to return
+	NodeID          string        // identifies this replica in leases; defaults to hostname-pid
+	LeaseTTL        time.Duration // how long a lease lasts without a heartbeat
+	NotifyInterval  time.Duration // how often failed notifications and webhook deliveries are retried
+}
+
+// EncryptionConfig contains the master keys that encrypt provider credentials
+// at rest. MasterKeys, when set, takes precedence over the keyfile.
+type EncryptionConfig struct {
+	MasterKeys       string // comma-separated version:base64-key pairs
+	ActiveKeyVersion int    // version new credentials are encrypted with; 0 selects the highest
+	KeyFile          string // local keyfile, created with a new key if missing
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists (ignore errors as it's optional)
@@ -193,12 +244,49 @@ func Load() (*Config, error) {
 			SlackWebhookURL: getEnv("SLACK_WEBHOOK_URL", ""),
 			SlackChannel:    getEnv("SLACK_CHANNEL", "#alerts"),
 			StripeAPIKey:    getEnv("STRIPE_API_KEY", ""),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        getEnvAsInt("SMTP_PORT", 587),
+			SMTPUser:        getEnv("SMTP_USER", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			SMTPUseTLS:      getEnvAsBool("SMTP_USE_TLS", true),
+			EmailFrom:       getEnv("EMAIL_FROM", "noreply@infraaudit.com"),
+			EmailFromName:   getEnv("EMAIL_FROM_NAME", "InfraAudit"),
+
+			StaleResourceGracePeriod: getEnvAsDuration("STALE_RESOURCE_GRACE_PERIOD", 7*24*time.Hour),
 		},
 		Scanner: ScannerConfig{
 			TrivyPath:     getEnv("TRIVY_PATH", "trivy"),
 			TrivyCacheDir: getEnv("TRIVY_CACHE_DIR", "/tmp/trivy-cache"),
 			NVDAPIKey:     getEnv("NVD_API_KEY", ""),
 		},
+		Drift: DriftConfig{
+			RulesDir: getEnv("DRIFT_RULES_DIR", ""),
+		},
+		Remediation: RemediationConfig{
+			GitPath:           getEnv("GIT_PATH", "git"),
+			CommitAuthorName:  getEnv("REMEDIATION_COMMIT_AUTHOR_NAME", "InfraAudit"),
+			CommitAuthorEmail: getEnv("REMEDIATION_COMMIT_AUTHOR_EMAIL", "remediation@infraaudit.local"),
+			GitHubToken:       getEnv("GITHUB_TOKEN", ""),
+			GitHubAPIURL:      getEnv("GITHUB_API_URL", ""),
+			GitLabToken:       getEnv("GITLAB_TOKEN", ""),
+			GitLabURL:         getEnv("GITLAB_URL", ""),
+			AllowLocalRepos:   getEnvAsBool("REMEDIATION_ALLOW_LOCAL_REPOS", false),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:         getEnvAsBool("ENABLE_SCHEDULER", true),
+			JobTimeout:      getEnvAsDuration("JOB_DEFAULT_TIMEOUT", time.Hour),
+			RetryBaseDelay:  getEnvAsDuration("JOB_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:   getEnvAsDuration("JOB_RETRY_MAX_DELAY", 10*time.Minute),
+			StopGracePeriod: getEnvAsDuration("JOB_STOP_GRACE_PERIOD", 30*time.Second),
+			NodeID:          getEnv("NODE_ID", ""),
+			LeaseTTL:        getEnvAsDuration("SCHEDULER_LEASE_TTL", 30*time.Second),
+			NotifyInterval:  getEnvAsDuration("NOTIFICATION_RETRY_INTERVAL", time.Minute),
+		},
+		Encryption: EncryptionConfig{
+			MasterKeys:       getEnv("CREDENTIALS_MASTER_KEYS", ""),
+			ActiveKeyVersion: getEnvAsInt("CREDENTIALS_ACTIVE_KEY_VERSION", 0),
+			KeyFile:          getEnv("CREDENTIALS_KEYFILE", "./credentials.keys"),
+		},
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -0,0 +1,239 @@
+package detector
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalogs/*.yaml
+var builtinCatalogFiles embed.FS
+
+// BuiltinCatalogs returns the framework catalogs compiled from the embedded
+// catalog files, in file name order
+func BuiltinCatalogs() ([]*compliance.Catalog, error) {
+	entries, err := fs.ReadDir(builtinCatalogFiles, "catalogs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog files: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	catalogs := make([]*compliance.Catalog, 0, len(names))
+	seen := make(map[string]string)
+	for _, name := range names {
+		data, err := fs.ReadFile(builtinCatalogFiles, path.Join("catalogs", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalog file %s: %w", name, err)
+		}
+		catalog, err := ParseCatalogFile(name, data)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[catalog.Framework.ID]; ok {
+			return nil, fmt.Errorf("catalog file %s: framework %q is already defined in %s", name, catalog.Framework.ID, other)
+		}
+		seen[catalog.Framework.ID] = name
+		catalog.Framework.Source = compliance.FrameworkSourceBuiltin
+		catalogs = append(catalogs, catalog)
+	}
+
+	// Built-in crosswalk links must point at built-in controls
+	controls := make(map[compliance.ControlRef]bool)
+	for _, catalog := range catalogs {
+		for _, c := range catalog.Controls {
+			controls[compliance.ControlRef{FrameworkID: catalog.Framework.ID, ControlID: c.ControlID}] = true
+		}
+	}
+	for _, catalog := range catalogs {
+		for _, c := range catalog.Controls {
+			for _, ref := range c.Crosswalk {
+				if !controls[ref] {
+					return nil, fmt.Errorf("catalog file %s: control %s links to unknown control %s", seen[catalog.Framework.ID], c.ControlID, ref)
+				}
+			}
+		}
+	}
+	return catalogs, nil
+}
+
+// ParseCatalogFile decodes and validates a framework catalog. JSON is used
+// for .json files and YAML otherwise. Every check is compiled, so a catalog
+// that parses here can be evaluated.
+func ParseCatalogFile(name string, data []byte) (*compliance.Catalog, error) {
+	var file compliance.CatalogFile
+	var err error
+	if strings.EqualFold(path.Ext(name), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog file %s: %w", name, err)
+	}
+
+	if file.Version != compliance.CatalogFileVersion {
+		return nil, fmt.Errorf("catalog file %s: unsupported version %d (expected %d)", name, file.Version, compliance.CatalogFileVersion)
+	}
+
+	fw := file.Framework
+	switch {
+	case strings.TrimSpace(fw.ID) == "":
+		return nil, fmt.Errorf("catalog file %s: framework id is required", name)
+	case len(fw.ID) > 36:
+		return nil, fmt.Errorf("catalog file %s: framework id %q is longer than 36 characters", name, fw.ID)
+	case strings.TrimSpace(fw.Name) == "":
+		return nil, fmt.Errorf("catalog file %s: framework name is required", name)
+	case len(fw.Name) > 100:
+		return nil, fmt.Errorf("catalog file %s: framework name is longer than 100 characters", name)
+	case len(file.Controls) == 0:
+		return nil, fmt.Errorf("catalog file %s: framework %s has no controls", name, fw.ID)
+	}
+
+	catalog := &compliance.Catalog{
+		Framework: &compliance.Framework{
+			ID:          fw.ID,
+			Name:        fw.Name,
+			Version:     fw.Version,
+			Description: fw.Description,
+			Provider:    strings.ToLower(fw.Provider),
+			IsEnabled:   fw.Enabled == nil || *fw.Enabled,
+			Revision:    fw.Revision,
+		},
+	}
+
+	categories := make(map[string]bool, len(file.Categories))
+	for _, c := range file.Categories {
+		categories[c] = true
+	}
+
+	seen := make(map[string]bool, len(file.Controls))
+	for i, c := range file.Controls {
+		if c == nil || strings.TrimSpace(c.ID) == "" {
+			return nil, fmt.Errorf("catalog file %s: control %d has no id", name, i)
+		}
+		if len(c.ID) > 50 {
+			return nil, fmt.Errorf("catalog file %s: control id %q is longer than 50 characters", name, c.ID)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("catalog file %s: duplicate control %s", name, c.ID)
+		}
+		seen[c.ID] = true
+
+		if strings.TrimSpace(c.Title) == "" {
+			return nil, fmt.Errorf("catalog file %s: control %s has no title", name, c.ID)
+		}
+		switch c.Severity {
+		case compliance.SeverityCritical, compliance.SeverityHigh, compliance.SeverityMedium, compliance.SeverityLow:
+		default:
+			return nil, fmt.Errorf("catalog file %s: control %s has invalid severity %q", name, c.ID, c.Severity)
+		}
+		if len(categories) > 0 && !categories[c.Category] {
+			return nil, fmt.Errorf("catalog file %s: control %s has unknown category %q", name, c.ID, c.Category)
+		}
+
+		control := &compliance.Control{
+			FrameworkID:  fw.ID,
+			ControlID:    c.ID,
+			Title:        c.Title,
+			Description:  strings.TrimSpace(c.Description),
+			Category:     c.Category,
+			Severity:     c.Severity,
+			Remediation:  strings.TrimSpace(c.Remediation),
+			ReferenceURL: c.ReferenceURL,
+		}
+		if control.ReferenceURL == "" {
+			control.ReferenceURL = fw.ReferenceURL
+		}
+
+		for j, check := range c.Checks {
+			mapping, err := catalogMapping(check, catalog.Framework.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("catalog file %s: control %s check %d: %v", name, c.ID, j, err)
+			}
+			control.Checks = append(control.Checks, mapping)
+		}
+
+		for _, text := range c.Crosswalk {
+			ref, err := parseControlRef(text)
+			if err != nil {
+				return nil, fmt.Errorf("catalog file %s: control %s: %v", name, c.ID, err)
+			}
+			if ref.FrameworkID == fw.ID {
+				return nil, fmt.Errorf("catalog file %s: control %s links to %s in its own framework", name, c.ID, ref.ControlID)
+			}
+			control.Crosswalk = append(control.Crosswalk, ref)
+		}
+
+		catalog.Controls = append(catalog.Controls, control)
+	}
+
+	return catalog, nil
+}
+
+// catalogMapping converts a catalog check into a control mapping, storing
+// its conditions as a JSON check query
+func catalogMapping(check *compliance.CatalogCheck, frameworkProvider string) (*compliance.ControlMapping, error) {
+	if check == nil {
+		return nil, fmt.Errorf("check is empty")
+	}
+
+	mapping := &compliance.ControlMapping{
+		SecurityRuleType:  check.DriftType,
+		ResourceType:      check.ResourceType,
+		Provider:          strings.ToLower(check.Provider),
+		MappingConfidence: check.Confidence,
+	}
+	if mapping.Provider == "" {
+		mapping.Provider = frameworkProvider
+	}
+	switch mapping.MappingConfidence {
+	case "":
+		mapping.MappingConfidence = "high"
+	case "high", "medium", "low":
+	default:
+		return nil, fmt.Errorf("invalid confidence %q", check.Confidence)
+	}
+
+	hasConditions := len(check.All) > 0 || len(check.Any) > 0
+	switch {
+	case hasConditions && check.ResourceType == "":
+		return nil, fmt.Errorf("configuration checks need a resource_type")
+	case !hasConditions && check.DriftType == "":
+		return nil, fmt.Errorf("check needs conditions or a drift_type")
+	}
+
+	if hasConditions {
+		if _, err := CompileComplianceCheck(&check.Check); err != nil {
+			return nil, err
+		}
+		query, err := json.Marshal(check.Check)
+		if err != nil {
+			return nil, err
+		}
+		mapping.CheckQuery = string(query)
+	}
+	return mapping, nil
+}
+
+// parseControlRef parses a "framework-id:control-id" crosswalk reference.
+// Control IDs may contain colons; framework IDs may not.
+func parseControlRef(text string) (compliance.ControlRef, error) {
+	frameworkID, controlID, ok := strings.Cut(strings.TrimSpace(text), ":")
+	if !ok || frameworkID == "" || controlID == "" {
+		return compliance.ControlRef{}, fmt.Errorf("invalid crosswalk reference %q (expected framework-id:control-id)", text)
+	}
+	return compliance.ControlRef{FrameworkID: frameworkID, ControlID: controlID}, nil
+}
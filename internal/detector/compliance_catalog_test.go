@@ -0,0 +1,129 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+)
+
+func TestBuiltinCatalogs(t *testing.T) {
+	catalogs, err := BuiltinCatalogs()
+	if err != nil {
+		t.Fatalf("BuiltinCatalogs() error = %v", err)
+	}
+
+	// The IDs must match the frameworks seeded by migration 006
+	byID := make(map[string]*compliance.Catalog)
+	for _, c := range catalogs {
+		byID[c.Framework.ID] = c
+	}
+	for _, id := range []string{"cis-aws-v1.5", "cis-gcp-v1.3", "cis-azure-v1.4", "nist-800-53-r5", "soc2-2017", "pci-dss-v4", "hipaa", "iso-27001"} {
+		if byID[id] == nil {
+			t.Errorf("missing built-in catalog %s", id)
+		}
+	}
+
+	for _, c := range catalogs {
+		if c.Framework.Source != compliance.FrameworkSourceBuiltin || c.Framework.Revision < 1 {
+			t.Errorf("%s: source %q revision %d", c.Framework.ID, c.Framework.Source, c.Framework.Revision)
+		}
+		for _, control := range c.Controls {
+			if control.Remediation == "" || !strings.HasPrefix(control.ReferenceURL, "https://") {
+				t.Errorf("%s %s: missing remediation or reference URL", c.Framework.ID, control.ControlID)
+			}
+		}
+	}
+
+	if byID["pci-dss-v4"].Framework.IsEnabled || !byID["cis-aws-v1.5"].Framework.IsEnabled {
+		t.Error("enabled defaults do not match the catalog files")
+	}
+
+	var s3 *compliance.Control
+	for _, control := range byID["cis-aws-v1.5"].Controls {
+		if control.ControlID == "2.1.1" {
+			s3 = control
+		}
+	}
+	if s3 == nil || len(s3.Checks) != 1 {
+		t.Fatalf("CIS AWS 2.1.1 = %+v, want one check", s3)
+	}
+	check := s3.Checks[0]
+	if check.ResourceType != "s3-bucket" || check.Provider != "aws" || check.MappingConfidence != "high" {
+		t.Errorf("check = %+v", check)
+	}
+	if _, err := ParseComplianceCheck(check.CheckQuery); err != nil {
+		t.Errorf("stored check query %s does not parse: %v", check.CheckQuery, err)
+	}
+	if len(s3.Crosswalk) == 0 || s3.Crosswalk[0] != (compliance.ControlRef{FrameworkID: "nist-800-53-r5", ControlID: "SC-28"}) {
+		t.Errorf("CIS AWS 2.1.1 crosswalk = %v", s3.Crosswalk)
+	}
+}
+
+func TestParseCatalogFile_JSON(t *testing.T) {
+	data := `{
+		"version": 1,
+		"framework": {"id": "acme-baseline", "name": "ACME Baseline", "revision": 2},
+		"controls": [{
+			"id": "ACME-1",
+			"title": "Buckets are encrypted",
+			"severity": "high",
+			"checks": [{"resource_type": "gcs-bucket", "provider": "gcp", "all": [{"field": "$.encryption.enabled", "equals": true}]}]
+		}, {
+			"id": "ACME-2",
+			"title": "No open security groups",
+			"severity": "critical",
+			"checks": [{"drift_type": "security_group", "confidence": "medium"}],
+			"crosswalk": ["hipaa:164.312(e)(1)", "iso-27001:A.8.20"]
+		}]
+	}`
+
+	catalog, err := ParseCatalogFile("acme.json", []byte(data))
+	if err != nil {
+		t.Fatalf("ParseCatalogFile() error = %v", err)
+	}
+	if !catalog.Framework.IsEnabled || catalog.Framework.Revision != 2 || len(catalog.Controls) != 2 {
+		t.Fatalf("catalog = %+v", catalog.Framework)
+	}
+	bucket := catalog.Controls[0].Checks[0]
+	if bucket.Provider != "gcp" || bucket.CheckQuery != `{"all":[{"field":"$.encryption.enabled","equals":true}]}` {
+		t.Errorf("configuration check = %+v", bucket)
+	}
+	sg := catalog.Controls[1].Checks[0]
+	if sg.SecurityRuleType != "security_group" || sg.CheckQuery != "" || sg.MappingConfidence != "medium" {
+		t.Errorf("drift check = %+v", sg)
+	}
+	if links := catalog.Controls[1].Crosswalk; len(links) != 2 || links[0].String() != "hipaa:164.312(e)(1)" || links[1].ControlID != "A.8.20" {
+		t.Errorf("crosswalk = %v", links)
+	}
+}
+
+func TestParseCatalogFile_Invalid(t *testing.T) {
+	const framework = "version: 1\nframework: {id: acme, name: ACME}\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unsupported version", "version: 2\nframework: {id: acme, name: ACME}\ncontrols: [{id: A-1, title: T, severity: low}]"},
+		{"missing framework id", "version: 1\nframework: {name: ACME}\ncontrols: [{id: A-1, title: T, severity: low}]"},
+		{"no controls", framework + "controls: []"},
+		{"duplicate control", framework + "controls: [{id: A-1, title: T, severity: low}, {id: A-1, title: U, severity: low}]"},
+		{"missing title", framework + "controls: [{id: A-1, severity: low}]"},
+		{"invalid severity", framework + "controls: [{id: A-1, title: T, severity: urgent}]"},
+		{"unknown category", framework + "categories: [Storage]\ncontrols: [{id: A-1, title: T, severity: low, category: Network}]"},
+		{"check without resource type", framework + "controls: [{id: A-1, title: T, severity: low, checks: [{all: [{field: $.encrypted, equals: true}]}]}]"},
+		{"empty check", framework + "controls: [{id: A-1, title: T, severity: low, checks: [{resource_type: s3-bucket}]}]"},
+		{"invalid selector", framework + "controls: [{id: A-1, title: T, severity: low, checks: [{resource_type: s3-bucket, all: [{field: encrypted, equals: true}]}]}]"},
+		{"invalid confidence", framework + "controls: [{id: A-1, title: T, severity: low, checks: [{drift_type: encryption, confidence: certain}]}]"},
+		{"crosswalk without control", framework + "controls: [{id: A-1, title: T, severity: low, crosswalk: [hipaa]}]"},
+		{"crosswalk to own framework", framework + "controls: [{id: A-1, title: T, severity: low, crosswalk: [\"acme:A-2\"]}]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCatalogFile("acme.yaml", []byte(tt.data)); err == nil {
+				t.Error("ParseCatalogFile() succeeded, want error")
+			}
+		})
+	}
+}
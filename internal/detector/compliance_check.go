@@ -0,0 +1,193 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+)
+
+// ComplianceCheck is a compiled compliance check, ready to be evaluated
+// against resource configurations
+type ComplianceCheck struct {
+	all []*compiledCondition
+	any []*compiledCondition
+}
+
+type compiledCondition struct {
+	field     string
+	selector  []selectorToken
+	predicate *compiledPredicate
+}
+
+// CheckResult is the outcome of a compliance check on one resource. The
+// evidence lists the configuration values the check observed.
+type CheckResult struct {
+	Passed   bool
+	Evidence []string
+}
+
+// selectedValue is a configuration value and the path it was found at
+type selectedValue struct {
+	path  string
+	value interface{}
+}
+
+// ParseComplianceCheck decodes and compiles a JSON-encoded check, as stored
+// in a control mapping's check query
+func ParseComplianceCheck(query string) (*ComplianceCheck, error) {
+	var check compliance.Check
+	if err := json.Unmarshal([]byte(query), &check); err != nil {
+		return nil, fmt.Errorf("invalid check: %w", err)
+	}
+	return CompileComplianceCheck(&check)
+}
+
+// CompileComplianceCheck validates a check and pre-parses its selectors and
+// predicates
+func CompileComplianceCheck(check *compliance.Check) (*ComplianceCheck, error) {
+	if len(check.All) == 0 && len(check.Any) == 0 {
+		return nil, fmt.Errorf("check has no conditions")
+	}
+
+	compile := func(conditions []compliance.CheckCondition) ([]*compiledCondition, error) {
+		compiled := make([]*compiledCondition, 0, len(conditions))
+		for _, cond := range conditions {
+			selector, err := parseSelector(cond.Field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field selector %q: %v", cond.Field, err)
+			}
+			predicate, err := compilePredicate(&cond.ValuePredicate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid predicate for %q: %v", cond.Field, err)
+			}
+			compiled = append(compiled, &compiledCondition{
+				field:     strings.TrimLeft(strings.TrimSpace(cond.Field), "$."),
+				selector:  selector,
+				predicate: predicate,
+			})
+		}
+		return compiled, nil
+	}
+
+	all, err := compile(check.All)
+	if err != nil {
+		return nil, err
+	}
+	anyOf, err := compile(check.Any)
+	if err != nil {
+		return nil, err
+	}
+	return &ComplianceCheck{all: all, any: anyOf}, nil
+}
+
+// Evaluate runs the check against a resource's JSON configuration. Every
+// condition is evaluated, so the evidence is complete even when an early
+// condition already fails the resource.
+func (c *ComplianceCheck) Evaluate(configuration string) (*CheckResult, error) {
+	config := map[string]interface{}{}
+	if strings.TrimSpace(configuration) != "" {
+		if err := json.Unmarshal([]byte(configuration), &config); err != nil {
+			return nil, fmt.Errorf("invalid resource configuration: %w", err)
+		}
+	}
+
+	result := &CheckResult{Passed: true}
+	for _, cond := range c.all {
+		if !cond.evaluate(config, result) {
+			result.Passed = false
+		}
+	}
+	if len(c.any) > 0 {
+		anyPassed := false
+		for _, cond := range c.any {
+			if cond.evaluate(config, result) {
+				anyPassed = true
+			}
+		}
+		result.Passed = result.Passed && anyPassed
+	}
+	return result, nil
+}
+
+// evaluate reports whether every value the condition selects satisfies its
+// predicate and records the values as evidence
+func (cond *compiledCondition) evaluate(config map[string]interface{}, result *CheckResult) bool {
+	var values []selectedValue
+	selectValues(cond.selector, config, "", &values)
+	if len(values) == 0 {
+		result.Evidence = append(result.Evidence, cond.field+" is not set")
+		return cond.predicate.matches(nil)
+	}
+
+	passed := true
+	for _, v := range values {
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s = %s", v.path, evidenceText(v.value)))
+		if !cond.predicate.matches(v.value) {
+			passed = false
+		}
+	}
+	return passed
+}
+
+// selectValues collects the configuration values a selector selects.
+// Selectors descend through objects only; lists are values in their own
+// right, as they are for drift detection.
+func selectValues(tokens []selectorToken, value interface{}, prefix string, out *[]selectedValue) {
+	if len(tokens) == 0 {
+		for _, v := range *out {
+			if v.path == prefix {
+				return
+			}
+		}
+		*out = append(*out, selectedValue{path: prefix, value: value})
+		return
+	}
+
+	obj, isObject := value.(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tok := tokens[0]
+	if tok.kind == tokenRecursive {
+		selectValues(tokens[1:], value, prefix, out)
+		for _, k := range keys {
+			selectValues(tokens, obj[k], joinPath(prefix, k), out)
+		}
+		return
+	}
+
+	if !isObject {
+		return
+	}
+	for _, k := range keys {
+		if ok, _ := path.Match(tok.pattern, strings.ToLower(k)); ok {
+			selectValues(tokens[1:], obj[k], joinPath(prefix, k), out)
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// evidenceText renders a value for evidence, quoting strings so an empty
+// string is distinguishable from a missing value
+func evidenceText(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	if v == nil {
+		return "null"
+	}
+	return valueText(v)
+}
@@ -0,0 +1,98 @@
+package detector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplianceCheck_Evaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		check        string
+		config       string
+		wantPassed   bool
+		wantEvidence []string
+	}{
+		{
+			name:         "encrypted bucket passes",
+			check:        `{"all": [{"field": "$.encryption.enabled", "equals": true}]}`,
+			config:       `{"encryption": {"enabled": true}}`,
+			wantPassed:   true,
+			wantEvidence: []string{"encryption.enabled = true"},
+		},
+		{
+			name:         "never encrypted bucket fails",
+			check:        `{"all": [{"field": "$.encryption.enabled", "equals": true}]}`,
+			config:       `{"encryption": {"enabled": false}}`,
+			wantPassed:   false,
+			wantEvidence: []string{"encryption.enabled = false"},
+		},
+		{
+			name:         "missing field fails an equality check",
+			check:        `{"all": [{"field": "$.encryption.enabled", "equals": true}]}`,
+			config:       `{"bucket_name": "logs"}`,
+			wantPassed:   false,
+			wantEvidence: []string{"encryption.enabled is not set"},
+		},
+		{
+			name:         "every selected value must match",
+			check:        `{"all": [{"field": "$.public_access.block_*", "equals": true}]}`,
+			config:       `{"public_access": {"block_public_acls": true, "block_public_policy": false}}`,
+			wantPassed:   false,
+			wantEvidence: []string{"public_access.block_public_acls = true", "public_access.block_public_policy = false"},
+		},
+		{
+			name:         "recursive descent",
+			check:        `{"all": [{"field": "$..encrypted", "truthy": true}]}`,
+			config:       `{"disks": {"boot": {"encrypted": true}, "data": {"encrypted": true}}}`,
+			wantPassed:   true,
+			wantEvidence: []string{"disks.boot.encrypted = true", "disks.data.encrypted = true"},
+		},
+		{
+			name:         "any condition",
+			check:        `{"any": [{"field": "$.kms_key", "exists": true}, {"field": "$.encryption.enabled", "equals": true}]}`,
+			config:       `{"encryption": {"enabled": true}}`,
+			wantPassed:   true,
+			wantEvidence: []string{"kms_key is not set", "encryption.enabled = true"},
+		},
+		{
+			name:         "list values are checked as text",
+			check:        `{"all": [{"field": "$.lifecycle_rules", "contains": ["Enabled"]}]}`,
+			config:       `{"lifecycle_rules": [{"id": "expire", "status": "Enabled"}]}`,
+			wantPassed:   true,
+			wantEvidence: []string{`lifecycle_rules = [{"id":"expire","status":"Enabled"}]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := ParseComplianceCheck(tt.check)
+			if err != nil {
+				t.Fatalf("ParseComplianceCheck() error = %v", err)
+			}
+			result, err := check.Evaluate(tt.config)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if result.Passed != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v", result.Passed, tt.wantPassed)
+			}
+			if !reflect.DeepEqual(result.Evidence, tt.wantEvidence) {
+				t.Errorf("Evidence = %q, want %q", result.Evidence, tt.wantEvidence)
+			}
+		})
+	}
+}
+
+func TestParseComplianceCheck_Invalid(t *testing.T) {
+	for _, query := range []string{
+		`not json`,
+		`{}`,
+		`{"all": [{"field": "encryption.enabled", "equals": true}]}`,
+		`{"all": [{"field": "$.name", "matches": "("}]}`,
+	} {
+		if _, err := ParseComplianceCheck(query); err == nil {
+			t.Errorf("ParseComplianceCheck(%s) succeeded, want error", query)
+		}
+	}
+}
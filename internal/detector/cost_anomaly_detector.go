@@ -0,0 +1,237 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+// CostAnomalyConfig tunes the cost anomaly detector
+type CostAnomalyConfig struct {
+	HistoryDays    int     // days of accepted history forming the baseline
+	MinHistory     int     // days of history required before a day is evaluated
+	ReportDays     int     // only anomalies within this many trailing days are reported
+	Threshold      float64 // robust z-score a day must exceed to be anomalous
+	MinDeviation   float64 // minimum relative deviation from the baseline, e.g. 0.2 for 20%
+	MinAbsolute    float64 // minimum absolute deviation, in currency units
+	LevelShiftDays int     // consecutive anomalous days in one direction that make a level shift
+}
+
+// DefaultCostAnomalyConfig returns the detector defaults: four weeks of
+// history, a robust z-score of 3.5 and three days for a level shift
+func DefaultCostAnomalyConfig() CostAnomalyConfig {
+	return CostAnomalyConfig{
+		HistoryDays:    28,
+		MinHistory:     14,
+		ReportDays:     7,
+		Threshold:      3.5,
+		MinDeviation:   0.2,
+		MinAbsolute:    1,
+		LevelShiftDays: 3,
+	}
+}
+
+// CostSeriesAnomaly is an anomaly found in a daily cost series. For a level
+// shift, Date is the first day of the new level and the costs are daily
+// averages over the days that confirmed it.
+type CostSeriesAnomaly struct {
+	Type     string // cost.AnomalyTypeSpike, cost.AnomalyTypeDrop or cost.AnomalyTypeLevelShift
+	Date     time.Time
+	Days     int
+	Expected float64
+	Actual   float64
+	Score    float64 // robust z-score; the mean over the days of a level shift
+}
+
+// Deviation returns the percentage deviation of actual from expected cost
+func (a CostSeriesAnomaly) Deviation() float64 {
+	if a.Expected == 0 {
+		if a.Actual == 0 {
+			return 0
+		}
+		return 100
+	}
+	return (a.Actual - a.Expected) / a.Expected * 100
+}
+
+// DetectCostAnomalies finds spikes, drops and level shifts in a daily cost
+// series. Each day is compared against a baseline of the preceding accepted
+// days: the rolling median and MAD of the series after removing day-of-week
+// seasonality. Anomalous days are kept out of the baseline, so a one-off
+// spike does not mask the next one. When LevelShiftDays consecutive days
+// deviate in the same direction they are reported as one level shift instead
+// of separate spikes or drops, and the baseline history is rescaled to the
+// new level so its weekly pattern carries over.
+//
+// The series runs through the day of through, with missing days counted as
+// zero cost, so spend that stops altogether is reported as a drop. A zero
+// through ends the series at its last cost record.
+func DetectCostAnomalies(points []cost.CostDataPoint, through time.Time, cfg CostAnomalyConfig) []CostSeriesAnomaly {
+	days := cost.DailySeries(points, through)
+	if len(days) == 0 {
+		return nil
+	}
+
+	var (
+		found    []CostSeriesAnomaly
+		accepted []cost.CostDataPoint
+		run      []int // indices of consecutive anomalous days in one direction
+		runDir   float64
+		runStart int // index into found of the run's first point anomaly
+	)
+
+	for i, day := range days {
+		if len(accepted) < cfg.MinHistory {
+			accepted = append(accepted, day)
+			run = nil
+			continue
+		}
+
+		history := accepted
+		if len(history) > cfg.HistoryDays {
+			history = history[len(history)-cfg.HistoryDays:]
+		}
+		expected, spread := seasonalBaseline(history, day.Date.Weekday(), cfg)
+		deviation := day.Cost - expected
+		score := deviation / spread
+
+		anomalous := math.Abs(score) >= cfg.Threshold &&
+			math.Abs(deviation) >= cfg.MinAbsolute &&
+			(expected == 0 || math.Abs(deviation)/expected >= cfg.MinDeviation)
+		if !anomalous {
+			accepted = append(accepted, day)
+			run = nil
+			continue
+		}
+
+		dir := math.Copysign(1, deviation)
+		if len(run) == 0 || dir != runDir {
+			run, runDir, runStart = nil, dir, len(found)
+		}
+		run = append(run, i)
+
+		anomalyType := cost.AnomalyTypeSpike
+		if dir < 0 {
+			anomalyType = cost.AnomalyTypeDrop
+		}
+		found = append(found, CostSeriesAnomaly{
+			Type:     anomalyType,
+			Date:     day.Date,
+			Days:     1,
+			Expected: expected,
+			Actual:   day.Cost,
+			Score:    score,
+		})
+
+		if len(run) < cfg.LevelShiftDays {
+			continue
+		}
+
+		// The run is a new level: fold its point anomalies into one shift
+		// and move the baseline to the new level
+		shift := CostSeriesAnomaly{
+			Type: cost.AnomalyTypeLevelShift,
+			Date: days[run[0]].Date,
+			Days: len(run),
+		}
+		for _, a := range found[runStart:] {
+			shift.Expected += a.Expected / float64(len(run))
+			shift.Actual += a.Actual / float64(len(run))
+			shift.Score += a.Score / float64(len(run))
+		}
+		found = append(found[:runStart], shift)
+
+		if shift.Expected > 0 {
+			ratio := shift.Actual / shift.Expected
+			rescaled := make([]cost.CostDataPoint, len(accepted), len(accepted)+len(run))
+			for j, d := range accepted {
+				rescaled[j] = cost.CostDataPoint{Date: d.Date, Cost: d.Cost * ratio}
+			}
+			accepted = rescaled
+		} else {
+			// Nothing to scale from; wait for a full history at the new level
+			accepted = nil
+		}
+		for _, idx := range run {
+			accepted = append(accepted, days[idx])
+		}
+		run = nil
+	}
+
+	cutoff := days[len(days)-1].Date.AddDate(0, 0, -cfg.ReportDays)
+	reported := found[:0]
+	for _, a := range found {
+		if a.Date.After(cutoff) {
+			reported = append(reported, a)
+		}
+	}
+	return reported
+}
+
+// seasonalBaseline returns the expected cost for a weekday and the robust
+// spread around it. Each weekday's factor is the median of that weekday
+// relative to the median of the whole history; the level is the median of
+// the deseasonalized history and the spread its scaled MAD. The spread is
+// floored so a perfectly flat series does not flag every cent of change.
+func seasonalBaseline(history []cost.CostDataPoint, weekday time.Weekday, cfg CostAnomalyConfig) (float64, float64) {
+	values := make([]float64, len(history))
+	byWeekday := make(map[time.Weekday][]float64)
+	for i, d := range history {
+		values[i] = d.Cost
+		byWeekday[d.Date.Weekday()] = append(byWeekday[d.Date.Weekday()], d.Cost)
+	}
+	overall := median(values)
+
+	factors := make(map[time.Weekday]float64, 7)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		factors[wd] = 1
+		if same := byWeekday[wd]; overall > 0 && len(same) >= 2 {
+			factors[wd] = median(same) / overall
+		}
+	}
+
+	deseasonalized := make([]float64, 0, len(history))
+	for _, d := range history {
+		if f := factors[d.Date.Weekday()]; f > 0 {
+			deseasonalized = append(deseasonalized, d.Cost/f)
+		}
+	}
+
+	level := median(deseasonalized)
+	deviations := make([]float64, len(deseasonalized))
+	for i, v := range deseasonalized {
+		deviations[i] = math.Abs(v - level)
+	}
+	mad := 1.4826 * median(deviations)
+
+	factor := factors[weekday]
+	expected := level * factor
+	spread := math.Max(mad*factor, math.Max(0.05*expected, cfg.MinAbsolute/cfg.Threshold))
+	return expected, spread
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// CostAnomalyFingerprint identifies an anomaly by its series, type and day,
+// so repeated detection runs do not store the same anomaly twice
+func CostAnomalyFingerprint(provider, serviceName, anomalyType string, date time.Time) string {
+	key := strings.Join([]string{provider, serviceName, anomalyType, date.UTC().Format("2006-01-02")}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
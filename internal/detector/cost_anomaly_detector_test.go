@@ -0,0 +1,141 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+// weekdaySeries builds a daily series starting on a Monday with 100 on
+// weekdays and 30 on weekends, with small deterministic noise
+func weekdaySeries(days int) []cost.CostDataPoint {
+	start := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC) // a Monday
+	points := make([]cost.CostDataPoint, days)
+	for i := range points {
+		date := start.AddDate(0, 0, i)
+		base := 100.0
+		if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			base = 30
+		}
+		points[i] = cost.CostDataPoint{Date: date, Cost: base + float64(i%3) - 1}
+	}
+	return points
+}
+
+func TestDetectCostAnomalies(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(points []cost.CostDataPoint)
+		wantType []string
+	}{
+		{
+			name:   "weekly seasonality is not anomalous",
+			modify: func([]cost.CostDataPoint) {},
+		},
+		{
+			name:     "spike",
+			modify:   func(p []cost.CostDataPoint) { p[len(p)-2].Cost = 300 },
+			wantType: []string{cost.AnomalyTypeSpike},
+		},
+		{
+			name: "drop",
+			// The last day is a Wednesday
+			modify:   func(p []cost.CostDataPoint) { p[len(p)-1].Cost = 10 },
+			wantType: []string{cost.AnomalyTypeDrop},
+		},
+		{
+			name:     "weekday spend on a weekend",
+			modify:   func(p []cost.CostDataPoint) { p[len(p)-4].Cost = 100 },
+			wantType: []string{cost.AnomalyTypeSpike},
+		},
+		{
+			name: "level shift",
+			modify: func(p []cost.CostDataPoint) {
+				for i := len(p) - 4; i < len(p); i++ {
+					p[i].Cost *= 2
+				}
+			},
+			wantType: []string{cost.AnomalyTypeLevelShift},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := weekdaySeries(45)
+			tt.modify(points)
+
+			got := DetectCostAnomalies(points, time.Time{}, DefaultCostAnomalyConfig())
+			if len(got) != len(tt.wantType) {
+				t.Fatalf("DetectCostAnomalies() = %+v, want types %v", got, tt.wantType)
+			}
+			for i, a := range got {
+				if a.Type != tt.wantType[i] {
+					t.Errorf("anomaly %d type = %s, want %s", i, a.Type, tt.wantType[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectCostAnomalies_LevelShiftBecomesBaseline(t *testing.T) {
+	points := weekdaySeries(60)
+	shiftAt := len(points) - 14
+	for i := shiftAt; i < len(points); i++ {
+		points[i].Cost *= 2
+	}
+
+	cfg := DefaultCostAnomalyConfig()
+	cfg.ReportDays = 30
+	got := DetectCostAnomalies(points, time.Time{}, cfg)
+	if len(got) != 1 {
+		t.Fatalf("DetectCostAnomalies() = %+v, want a single level shift", got)
+	}
+	if got[0].Type != cost.AnomalyTypeLevelShift || !got[0].Date.Equal(points[shiftAt].Date) || got[0].Days != cfg.LevelShiftDays {
+		t.Errorf("level shift = %+v, want it to start at %v", got[0], points[shiftAt].Date)
+	}
+	if got[0].Deviation() < 80 {
+		t.Errorf("Deviation() = %v, want about 100%%", got[0].Deviation())
+	}
+}
+
+func TestDetectCostAnomalies_SpendStops(t *testing.T) {
+	points := weekdaySeries(45)
+	last := points[len(points)-1].Date
+
+	if got := DetectCostAnomalies(points, last, DefaultCostAnomalyConfig()); len(got) != 0 {
+		t.Fatalf("DetectCostAnomalies() = %+v, want none while spend continues", got)
+	}
+
+	// No cost records for the two days after the series ends
+	got := DetectCostAnomalies(points, last.AddDate(0, 0, 2), DefaultCostAnomalyConfig())
+	if len(got) != 2 || got[0].Type != cost.AnomalyTypeDrop || got[0].Actual != 0 || !got[1].Date.Equal(last.AddDate(0, 0, 2)) {
+		t.Errorf("DetectCostAnomalies() = %+v, want drops to zero on both missing days", got)
+	}
+
+	// Records after through are not evaluated
+	points[len(points)-1].Cost = 1000
+	if got := DetectCostAnomalies(points, last.AddDate(0, 0, -1), DefaultCostAnomalyConfig()); len(got) != 0 {
+		t.Errorf("DetectCostAnomalies() = %+v, want the day after through ignored", got)
+	}
+}
+
+func TestDetectCostAnomalies_NotEnoughHistory(t *testing.T) {
+	points := weekdaySeries(10)
+	points[len(points)-1].Cost = 1000
+	if got := DetectCostAnomalies(points, time.Time{}, DefaultCostAnomalyConfig()); len(got) != 0 {
+		t.Errorf("DetectCostAnomalies() = %+v, want none before MinHistory days", got)
+	}
+}
+
+func TestCostAnomalyFingerprint(t *testing.T) {
+	day := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	a := CostAnomalyFingerprint("aws", "EC2", cost.AnomalyTypeSpike, day)
+	if a != CostAnomalyFingerprint("aws", "EC2", cost.AnomalyTypeSpike, day.Add(5*time.Hour)) {
+		t.Error("fingerprint should only depend on the day")
+	}
+	if a == CostAnomalyFingerprint("aws", "EC2", cost.AnomalyTypeDrop, day) ||
+		a == CostAnomalyFingerprint("aws", "", cost.AnomalyTypeSpike, day) {
+		t.Error("fingerprint should differ by type and service")
+	}
+}
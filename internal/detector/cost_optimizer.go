@@ -0,0 +1,340 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+)
+
+const (
+	// hoursPerMonth converts hourly list prices to monthly costs
+	hoursPerMonth = 730
+	// storageLifecycleSavingsRate is the share of a bucket's cost assumed to
+	// be saved by moving objects that are rarely read to a colder class
+	storageLifecycleSavingsRate = 0.3
+	// MinOptimizationSavings is the smallest monthly saving worth reporting
+	MinOptimizationSavings = 1.0
+)
+
+// Cost bases of a ResourceCost
+const (
+	CostBasisResource     = "resource"      // cost records of the resource itself
+	CostBasisServiceShare = "service_share" // an equal share of the service's cost
+	CostBasisListPrice    = "list_price"    // estimated from on-demand list prices
+)
+
+// ResourceCost is what a resource cost over the last 30 days and where the
+// figure comes from
+type ResourceCost struct {
+	Monthly float64
+	Basis   string
+}
+
+// instanceUpgrades maps previous-generation instance and machine types to a
+// current-generation type of the same size, with hourly on-demand list
+// prices (Linux, us-east-1 / us-central1 / East US)
+var instanceUpgrades = map[string]map[string]struct {
+	Price            float64 // hourly price of the current type
+	To               string  // current-generation replacement
+	ReplacementPrice float64 // hourly price of the replacement
+}{
+	cost.ProviderAWS: {
+		"t2.micro":   {Price: 0.0116, To: "t3.micro", ReplacementPrice: 0.0104},
+		"t2.small":   {Price: 0.023, To: "t3.small", ReplacementPrice: 0.0208},
+		"t2.medium":  {Price: 0.0464, To: "t3.medium", ReplacementPrice: 0.0416},
+		"t2.large":   {Price: 0.0928, To: "t3.large", ReplacementPrice: 0.0832},
+		"t2.xlarge":  {Price: 0.1856, To: "t3.xlarge", ReplacementPrice: 0.1664},
+		"t2.2xlarge": {Price: 0.3712, To: "t3.2xlarge", ReplacementPrice: 0.3328},
+		"m4.large":   {Price: 0.10, To: "m6i.large", ReplacementPrice: 0.096},
+		"m4.xlarge":  {Price: 0.20, To: "m6i.xlarge", ReplacementPrice: 0.192},
+		"m4.2xlarge": {Price: 0.40, To: "m6i.2xlarge", ReplacementPrice: 0.384},
+		"m4.4xlarge": {Price: 0.80, To: "m6i.4xlarge", ReplacementPrice: 0.768},
+		"c4.large":   {Price: 0.10, To: "c6i.large", ReplacementPrice: 0.085},
+		"c4.xlarge":  {Price: 0.199, To: "c6i.xlarge", ReplacementPrice: 0.17},
+		"c4.2xlarge": {Price: 0.398, To: "c6i.2xlarge", ReplacementPrice: 0.34},
+		"c4.4xlarge": {Price: 0.796, To: "c6i.4xlarge", ReplacementPrice: 0.68},
+		"r4.large":   {Price: 0.133, To: "r6i.large", ReplacementPrice: 0.126},
+		"r4.xlarge":  {Price: 0.266, To: "r6i.xlarge", ReplacementPrice: 0.252},
+		"r4.2xlarge": {Price: 0.532, To: "r6i.2xlarge", ReplacementPrice: 0.504},
+		"r4.4xlarge": {Price: 1.064, To: "r6i.4xlarge", ReplacementPrice: 1.008},
+		"m3.large":   {Price: 0.133, To: "m6i.large", ReplacementPrice: 0.096},
+		"c3.large":   {Price: 0.105, To: "c6i.large", ReplacementPrice: 0.085},
+		"c3.xlarge":  {Price: 0.21, To: "c6i.xlarge", ReplacementPrice: 0.17},
+		"c3.2xlarge": {Price: 0.42, To: "c6i.2xlarge", ReplacementPrice: 0.34},
+		"r3.large":   {Price: 0.166, To: "r6i.large", ReplacementPrice: 0.126},
+		"r3.xlarge":  {Price: 0.333, To: "r6i.xlarge", ReplacementPrice: 0.252},
+		"r3.2xlarge": {Price: 0.665, To: "r6i.2xlarge", ReplacementPrice: 0.504},
+		"i2.xlarge":  {Price: 0.853, To: "i3.xlarge", ReplacementPrice: 0.312},
+		"i2.2xlarge": {Price: 1.705, To: "i3.2xlarge", ReplacementPrice: 0.624},
+	},
+	cost.ProviderGCP: {
+		"n1-standard-2":  {Price: 0.095, To: "e2-standard-2", ReplacementPrice: 0.067},
+		"n1-standard-4":  {Price: 0.19, To: "e2-standard-4", ReplacementPrice: 0.134},
+		"n1-standard-8":  {Price: 0.38, To: "e2-standard-8", ReplacementPrice: 0.268},
+		"n1-standard-16": {Price: 0.76, To: "e2-standard-16", ReplacementPrice: 0.536},
+		"n1-highmem-2":   {Price: 0.118, To: "e2-highmem-2", ReplacementPrice: 0.09},
+		"n1-highmem-4":   {Price: 0.237, To: "e2-highmem-4", ReplacementPrice: 0.181},
+		"n1-highmem-8":   {Price: 0.474, To: "e2-highmem-8", ReplacementPrice: 0.362},
+		"n1-highcpu-2":   {Price: 0.071, To: "e2-highcpu-2", ReplacementPrice: 0.049},
+		"n1-highcpu-4":   {Price: 0.142, To: "e2-highcpu-4", ReplacementPrice: 0.099},
+		"n1-highcpu-8":   {Price: 0.284, To: "e2-highcpu-8", ReplacementPrice: 0.198},
+	},
+	cost.ProviderAzure: {
+		"Standard_D2_v2":  {Price: 0.146, To: "Standard_D2s_v5", ReplacementPrice: 0.096},
+		"Standard_D3_v2":  {Price: 0.293, To: "Standard_D4s_v5", ReplacementPrice: 0.192},
+		"Standard_D4_v2":  {Price: 0.585, To: "Standard_D8s_v5", ReplacementPrice: 0.384},
+		"Standard_D2_v3":  {Price: 0.096, To: "Standard_D2as_v5", ReplacementPrice: 0.086},
+		"Standard_D4_v3":  {Price: 0.192, To: "Standard_D4as_v5", ReplacementPrice: 0.172},
+		"Standard_D8_v3":  {Price: 0.384, To: "Standard_D8as_v5", ReplacementPrice: 0.344},
+		"Standard_DS2_v2": {Price: 0.146, To: "Standard_D2s_v5", ReplacementPrice: 0.096},
+		"Standard_DS3_v2": {Price: 0.293, To: "Standard_D4s_v5", ReplacementPrice: 0.192},
+	},
+}
+
+// volumePrices are monthly list prices per GB of block storage by volume type
+var volumePrices = map[string]map[string]float64{
+	cost.ProviderAWS: {
+		"gp2": 0.10, "gp3": 0.08, "io1": 0.125, "io2": 0.125,
+		"st1": 0.045, "sc1": 0.015, "standard": 0.05,
+	},
+	cost.ProviderGCP: {
+		"pd-standard": 0.04, "pd-balanced": 0.10, "pd-ssd": 0.17, "pd-extreme": 0.125,
+	},
+	cost.ProviderAzure: {
+		"Standard_LRS": 0.045, "StandardSSD_LRS": 0.075, "StandardSSD_ZRS": 0.094,
+		"Premium_LRS": 0.135, "Premium_ZRS": 0.169, "UltraSSD_LRS": 0.12, "PremiumV2_LRS": 0.12,
+	},
+}
+
+// AnalyzeCostOptimizations applies deterministic rules to synced resources
+// and their costs over the last 30 days, keyed by provider resource ID:
+//
+//   - stopped instances that are still billed, for their disks and addresses
+//   - block storage volumes that are not attached to any instance
+//   - previous-generation instance types with a cheaper current equivalent
+//   - object storage buckets without lifecycle rules
+//
+// Savings are monthly. Findings saving less than MinOptimizationSavings are
+// dropped; the rest are returned with the largest saving first.
+func AnalyzeCostOptimizations(resources []*resource.Resource, costs map[string]ResourceCost) []*cost.CostOptimization {
+	var out []*cost.CostOptimization
+	for _, res := range resources {
+		var config map[string]interface{}
+		if res.Configuration != "" {
+			_ = json.Unmarshal([]byte(res.Configuration), &config)
+		}
+
+		for _, rule := range []func(*resource.Resource, map[string]interface{}, ResourceCost) *cost.CostOptimization{
+			stoppedInstanceRule,
+			unattachedVolumeRule,
+			instanceGenerationRule,
+			storageLifecycleRule,
+		} {
+			opt := rule(res, config, costs[res.ResourceID])
+			if opt == nil || opt.EstimatedSavings < MinOptimizationSavings {
+				continue
+			}
+			resourceID := res.ResourceID
+			opt.Provider = res.Provider
+			opt.ResourceID = &resourceID
+			opt.ResourceType = res.Type
+			opt.Status = cost.OptStatusPending
+			if opt.CurrentCost > 0 {
+				opt.SavingsPercent = opt.EstimatedSavings / opt.CurrentCost * 100
+			}
+			opt.Fingerprint = CostOptimizationFingerprint(res.Provider, res.ResourceID, opt.OptimizationType)
+			out = append(out, opt)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EstimatedSavings > out[j].EstimatedSavings })
+	return out
+}
+
+// CostOptimizationFingerprint identifies a finding by the resource and rule
+// that produced it, so a rerun refreshes it instead of adding another
+func CostOptimizationFingerprint(provider, resourceID, optimizationType string) string {
+	key := strings.Join([]string{provider, resourceID, optimizationType}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// stoppedInstanceRule flags stopped instances that still incur costs. Only
+// the resource's own cost records count, since a stopped instance's compute
+// is not billed and its list price says nothing about its storage.
+func stoppedInstanceRule(res *resource.Resource, _ map[string]interface{}, c ResourceCost) *cost.CostOptimization {
+	stopped := false
+	switch res.Type {
+	case resource.TypeEC2Instance:
+		stopped = res.Status == resource.StatusStopped
+	case resource.TypeGCEInstance:
+		// GCE reports stopped instances as TERMINATED
+		stopped = res.Status == resource.StatusTerminated || res.Status == resource.StatusStopped || res.Status == "suspended"
+	}
+	if !stopped || c.Basis != CostBasisResource || c.Monthly <= 0 {
+		return nil
+	}
+
+	return &cost.CostOptimization{
+		OptimizationType: cost.OptTypeIdleResource,
+		Title:            fmt.Sprintf("Stopped instance %s is still billed", res.Name),
+		Description: fmt.Sprintf("%s is stopped but cost $%.2f over the last 30 days for its attached disks and addresses. "+
+			"Snapshot its disks and terminate it if it is no longer needed.", res.Name, c.Monthly),
+		CurrentCost:      c.Monthly,
+		EstimatedSavings: c.Monthly,
+		Implementation:   "easy",
+		Details:          optimizationDetails("stopped_instance", c.Basis, map[string]interface{}{"status": res.Status}),
+	}
+}
+
+// unattachedVolumeRule flags block storage that no instance uses. The cost
+// is estimated from the volume size when there are no cost records for it.
+func unattachedVolumeRule(res *resource.Resource, config map[string]interface{}, c ResourceCost) *cost.CostOptimization {
+	var volumeType string
+	switch {
+	case res.Type == resource.TypeEBSVolume && res.Status == "available":
+		volumeType = configString(config, "volume_type")
+	case res.Type == resource.TypeGCEDisk && res.Status == "unattached":
+		volumeType = path.Base(configString(config, "type"))
+	case res.Type == resource.TypeAzureDisk && res.Status == "Unattached":
+		volumeType = configString(config, "sku")
+	default:
+		return nil
+	}
+
+	sizeGB := configNumber(config, "size_gb")
+	if c.Basis != CostBasisResource || c.Monthly <= 0 {
+		price, ok := volumePrices[res.Provider][volumeType]
+		if !ok || sizeGB <= 0 {
+			return nil
+		}
+		c = ResourceCost{Monthly: sizeGB * price, Basis: CostBasisListPrice}
+	}
+
+	return &cost.CostOptimization{
+		OptimizationType: cost.OptTypeUnused,
+		Title:            fmt.Sprintf("Delete unattached volume %s", res.Name),
+		Description: fmt.Sprintf("%s (%.0f GB %s) is not attached to any instance and costs about $%.2f a month. "+
+			"Snapshot it if the data may be needed again, then delete it.", res.Name, sizeGB, volumeType, c.Monthly),
+		CurrentCost:      c.Monthly,
+		EstimatedSavings: c.Monthly,
+		Implementation:   "easy",
+		Details: optimizationDetails("unattached_volume", c.Basis, map[string]interface{}{
+			"volume_type": volumeType,
+			"size_gb":     sizeGB,
+		}),
+	}
+}
+
+// instanceGenerationRule flags running instances of a previous-generation
+// type. The saving is the list price difference applied to what the
+// instance actually cost, or to its list price without cost records.
+func instanceGenerationRule(res *resource.Resource, config map[string]interface{}, c ResourceCost) *cost.CostOptimization {
+	var instanceType string
+	switch res.Type {
+	case resource.TypeEC2Instance:
+		if res.Status != resource.StatusRunning {
+			return nil
+		}
+		instanceType = configString(config, "instance_type")
+	case resource.TypeGCEInstance:
+		if res.Status != resource.StatusRunning {
+			return nil
+		}
+		instanceType = path.Base(configString(config, "machine_type"))
+	case resource.TypeAzureVM:
+		// Azure VMs are listed without their power state
+		instanceType = configString(config, "vm_size")
+	default:
+		return nil
+	}
+
+	upgrade, ok := instanceUpgrades[res.Provider][instanceType]
+	if !ok || upgrade.ReplacementPrice >= upgrade.Price {
+		return nil
+	}
+	if c.Basis != CostBasisResource || c.Monthly <= 0 {
+		c = ResourceCost{Monthly: upgrade.Price * hoursPerMonth, Basis: CostBasisListPrice}
+	}
+	savings := c.Monthly * (upgrade.Price - upgrade.ReplacementPrice) / upgrade.Price
+
+	return &cost.CostOptimization{
+		OptimizationType: cost.OptTypeRightsize,
+		Title:            fmt.Sprintf("Move %s from %s to %s", res.Name, instanceType, upgrade.To),
+		Description: fmt.Sprintf("%s runs on the previous-generation %s. %s has the same size and costs $%.4f instead of $%.4f an hour, "+
+			"saving about $%.2f a month.", res.Name, instanceType, upgrade.To, upgrade.ReplacementPrice, upgrade.Price, savings),
+		CurrentCost:      c.Monthly,
+		EstimatedSavings: savings,
+		Implementation:   "moderate",
+		Details: optimizationDetails("previous_generation", c.Basis, map[string]interface{}{
+			"current_type":      instanceType,
+			"recommended_type":  upgrade.To,
+			"current_price":     upgrade.Price,
+			"recommended_price": upgrade.ReplacementPrice,
+		}),
+	}
+}
+
+// storageLifecycleRule flags buckets that keep every object in the standard
+// class indefinitely. The saving assumes a share of the data is rarely read.
+func storageLifecycleRule(res *resource.Resource, config map[string]interface{}, c ResourceCost) *cost.CostOptimization {
+	storageClass := "STANDARD"
+	switch res.Type {
+	case resource.TypeS3Bucket:
+	case resource.TypeGCSBucket:
+		if sc := configString(config, "storage_class"); sc != "" {
+			storageClass = sc
+		}
+		switch storageClass {
+		case "STANDARD", "MULTI_REGIONAL", "REGIONAL":
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	if rules, _ := config["lifecycle_rules"].([]interface{}); len(rules) > 0 || c.Monthly <= 0 {
+		return nil
+	}
+
+	savings := c.Monthly * storageLifecycleSavingsRate
+	return &cost.CostOptimization{
+		OptimizationType: cost.OptTypeStorageClass,
+		Title:            fmt.Sprintf("Add lifecycle rules to bucket %s", res.Name),
+		Description: fmt.Sprintf("%s keeps all objects in the %s class and has no lifecycle rules. Transitioning objects that are rarely "+
+			"read to an infrequent-access or archive class, and expiring old versions, typically saves about %.0f%% of its $%.2f monthly cost.",
+			res.Name, storageClass, storageLifecycleSavingsRate*100, c.Monthly),
+		CurrentCost:      c.Monthly,
+		EstimatedSavings: savings,
+		Implementation:   "easy",
+		Details:          optimizationDetails("storage_lifecycle", c.Basis, map[string]interface{}{"storage_class": storageClass}),
+	}
+}
+
+// optimizationDetails records the rule, the cost basis and the facts a
+// finding was computed from
+func optimizationDetails(rule, basis string, facts map[string]interface{}) json.RawMessage {
+	details := map[string]interface{}{"rule": rule, "cost_basis": basis}
+	for k, v := range facts {
+		details[k] = v
+	}
+	data, _ := json.Marshal(details)
+	return data
+}
+
+func configString(config map[string]interface{}, key string) string {
+	s, _ := config[key].(string)
+	return s
+}
+
+func configNumber(config map[string]interface{}, key string) float64 {
+	n, _ := config[key].(float64)
+	return n
+}
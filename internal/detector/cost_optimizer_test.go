@@ -0,0 +1,97 @@
+package detector
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+)
+
+func testResource(provider, id, resourceType, status string, config map[string]interface{}) *resource.Resource {
+	data, _ := json.Marshal(config)
+	return &resource.Resource{
+		Provider:      provider,
+		ResourceID:    id,
+		Name:          id,
+		Type:          resourceType,
+		Status:        status,
+		Configuration: string(data),
+	}
+}
+
+func TestAnalyzeCostOptimizations(t *testing.T) {
+	resources := []*resource.Resource{
+		testResource(cost.ProviderAWS, "i-stopped", resource.TypeEC2Instance, resource.StatusStopped, map[string]interface{}{"instance_type": "m5.large"}),
+		testResource(cost.ProviderAWS, "i-stopped-free", resource.TypeEC2Instance, resource.StatusStopped, map[string]interface{}{"instance_type": "m5.large"}),
+		testResource(cost.ProviderAWS, "i-old", resource.TypeEC2Instance, resource.StatusRunning, map[string]interface{}{"instance_type": "c4.xlarge"}),
+		testResource(cost.ProviderAWS, "i-current", resource.TypeEC2Instance, resource.StatusRunning, map[string]interface{}{"instance_type": "c6i.xlarge"}),
+		testResource(cost.ProviderAWS, "vol-free", resource.TypeEBSVolume, "available", map[string]interface{}{"volume_type": "gp3", "size_gb": 500}),
+		testResource(cost.ProviderAWS, "vol-used", resource.TypeEBSVolume, "in-use", map[string]interface{}{"volume_type": "gp3", "size_gb": 500}),
+		testResource(cost.ProviderGCP, "disk-free", resource.TypeGCEDisk, "unattached", map[string]interface{}{"type": "projects/p/zones/z/diskTypes/pd-ssd", "size_gb": 100}),
+		testResource(cost.ProviderGCP, "vm-n1", resource.TypeGCEInstance, resource.StatusRunning, map[string]interface{}{"machine_type": "zones/us-central1-a/machineTypes/n1-standard-4"}),
+		testResource(cost.ProviderAWS, "s3-logs", resource.TypeS3Bucket, resource.StatusActive, map[string]interface{}{"bucket_name": "logs"}),
+		testResource(cost.ProviderAWS, "s3-managed", resource.TypeS3Bucket, resource.StatusActive, map[string]interface{}{"lifecycle_rules": []interface{}{map[string]interface{}{"id": "archive"}}}),
+		testResource(cost.ProviderGCP, "gcs-cold", resource.TypeGCSBucket, resource.StatusActive, map[string]interface{}{"storage_class": "COLDLINE"}),
+	}
+	costs := map[string]ResourceCost{
+		"i-stopped":  {Monthly: 12, Basis: CostBasisResource},
+		"i-old":      {Monthly: 100, Basis: CostBasisResource},
+		"s3-logs":    {Monthly: 50, Basis: CostBasisServiceShare},
+		"s3-managed": {Monthly: 50, Basis: CostBasisServiceShare},
+		"gcs-cold":   {Monthly: 50, Basis: CostBasisResource},
+	}
+
+	got := AnalyzeCostOptimizations(resources, costs)
+
+	want := map[string]struct {
+		optType string
+		savings float64
+		basis   string
+	}{
+		"i-stopped": {cost.OptTypeIdleResource, 12, CostBasisResource},
+		"i-old":     {cost.OptTypeRightsize, 100 * (0.199 - 0.17) / 0.199, CostBasisResource},
+		"vol-free":  {cost.OptTypeUnused, 500 * 0.08, CostBasisListPrice},
+		"disk-free": {cost.OptTypeUnused, 100 * 0.17, CostBasisListPrice},
+		"vm-n1":     {cost.OptTypeRightsize, (0.19 - 0.134) * hoursPerMonth, CostBasisListPrice},
+		"s3-logs":   {cost.OptTypeStorageClass, 50 * storageLifecycleSavingsRate, CostBasisServiceShare},
+	}
+	if len(got) != len(want) {
+		for _, o := range got {
+			t.Logf("found %s %s %.2f", *o.ResourceID, o.OptimizationType, o.EstimatedSavings)
+		}
+		t.Fatalf("AnalyzeCostOptimizations() found %d optimizations, want %d", len(got), len(want))
+	}
+
+	for i, o := range got {
+		if i > 0 && o.EstimatedSavings > got[i-1].EstimatedSavings {
+			t.Errorf("optimizations are not sorted by savings")
+		}
+		w, ok := want[*o.ResourceID]
+		if !ok {
+			t.Errorf("unexpected optimization for %s", *o.ResourceID)
+			continue
+		}
+		if o.OptimizationType != w.optType || math.Abs(o.EstimatedSavings-w.savings) > 1e-9 {
+			t.Errorf("%s: got %s saving %v, want %s saving %v", *o.ResourceID, o.OptimizationType, o.EstimatedSavings, w.optType, w.savings)
+		}
+		var details map[string]interface{}
+		if err := json.Unmarshal(o.Details, &details); err != nil || details["cost_basis"] != w.basis {
+			t.Errorf("%s: details = %s", *o.ResourceID, o.Details)
+		}
+		if o.Status != cost.OptStatusPending || o.Fingerprint != CostOptimizationFingerprint(o.Provider, *o.ResourceID, o.OptimizationType) {
+			t.Errorf("%s: status %q, fingerprint %q", *o.ResourceID, o.Status, o.Fingerprint)
+		}
+	}
+}
+
+func TestAnalyzeCostOptimizationsSkipsSmallSavings(t *testing.T) {
+	resources := []*resource.Resource{
+		// 5 GB of gp3 costs 0.40 a month
+		testResource(cost.ProviderAWS, "vol-tiny", resource.TypeEBSVolume, "available", map[string]interface{}{"volume_type": "gp3", "size_gb": 5}),
+	}
+	if got := AnalyzeCostOptimizations(resources, nil); len(got) != 0 {
+		t.Fatalf("AnalyzeCostOptimizations() = %d optimizations, want none", len(got))
+	}
+}
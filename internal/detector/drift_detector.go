@@ -8,20 +8,38 @@ import (
 )
 
 // DriftDetector analyzes configuration changes and identifies security drifts
-type DriftDetector struct{}
+type DriftDetector struct {
+	rules *RuleSet
+}
 
-// NewDriftDetector creates a new drift detector
+// NewDriftDetector creates a new drift detector using the built-in rules
 func NewDriftDetector() *DriftDetector {
-	return &DriftDetector{}
+	return &DriftDetector{rules: DefaultRuleSet()}
+}
+
+// NewDriftDetectorWithRules creates a drift detector that classifies changes
+// with the given rule set
+func NewDriftDetectorWithRules(rules *RuleSet) *DriftDetector {
+	if rules == nil {
+		rules = DefaultRuleSet()
+	}
+	return &DriftDetector{rules: rules}
+}
+
+// Rules returns the rule set used to classify changes
+func (d *DriftDetector) Rules() *RuleSet {
+	return d.rules
 }
 
 // ConfigChange represents a change in configuration
 type ConfigChange struct {
-	Field     string      `json:"field"`
-	OldValue  interface{} `json:"old_value"`
-	NewValue  interface{} `json:"new_value"`
-	Path      string      `json:"path"` // JSON path to the field
-	ChangeType string     `json:"change_type"` // added, removed, modified
+	Field      string      `json:"field"`
+	OldValue   interface{} `json:"old_value"`
+	NewValue   interface{} `json:"new_value"`
+	Path       string      `json:"path"`               // JSON path to the field
+	ChangeType string      `json:"change_type"`        // added, removed, modified
+	RuleID     string      `json:"rule_id,omitempty"`  // rule that classified the change
+	Severity   string      `json:"severity,omitempty"` // severity assigned by the rule
 }
 
 // DetectionResult contains the result of drift detection
@@ -211,8 +229,10 @@ func (d *DriftDetector) analyzeChanges(resourceType string, changes []ConfigChan
 	highestSeverity := drift.SeverityLow
 	driftTypes := make(map[string]bool)
 
-	for _, change := range changes {
-		severity, driftType := d.evaluateChange(resourceType, change)
+	for i := range changes {
+		severity, driftType, ruleID := d.evaluateChange(resourceType, changes[i])
+		changes[i].RuleID = ruleID
+		changes[i].Severity = severity
 		driftTypes[driftType] = true
 
 		if d.severityLevel(severity) > d.severityLevel(highestSeverity) {
@@ -231,6 +251,8 @@ func (d *DriftDetector) analyzeChanges(resourceType string, changes []ConfigChan
 		result.DriftType = drift.TypeIAMPolicy
 	} else if driftTypes[drift.TypeNetworkRule] {
 		result.DriftType = drift.TypeNetworkRule
+	} else if driftTypes[drift.TypeCompliance] {
+		result.DriftType = drift.TypeCompliance
 	} else {
 		result.DriftType = drift.TypeConfigurationChange
 	}
@@ -240,6 +262,22 @@ func (d *DriftDetector) analyzeChanges(resourceType string, changes []ConfigChan
 	return result
 }
 
+// evaluateChange classifies a change with the most severe matching rule
+func (d *DriftDetector) evaluateChange(resourceType string, change ConfigChange) (string, string, string) {
+	var best *drift.Rule
+	for _, rule := range d.rules.Match(resourceType, change) {
+		if best == nil || d.severityLevel(rule.Severity) > d.severityLevel(best.Severity) {
+			best = rule
+		}
+	}
+
+	if best == nil {
+		// Default for unclassified changes
+		return drift.SeverityLow, drift.TypeConfigurationChange, ""
+	}
+	return best.Severity, best.DriftType, best.ID
+}
+
 // severityLevel converts severity string to numeric level
 func (d *DriftDetector) severityLevel(severity string) int {
 	switch severity {
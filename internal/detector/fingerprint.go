@@ -0,0 +1,29 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Fingerprint identifies a drift by the resource it affects and the exact
+// set of changed paths and values, so repeat detections of the same drift
+// can be matched to an existing record. Change order does not matter.
+func Fingerprint(resourceID string, changes []ConfigChange) string {
+	entries := make([]string, 0, len(changes))
+	for _, c := range changes {
+		oldValue, _ := json.Marshal(c.OldValue)
+		newValue, _ := json.Marshal(c.NewValue)
+		entries = append(entries, c.Path+"\x00"+c.ChangeType+"\x00"+string(oldValue)+"\x00"+string(newValue))
+	}
+	sort.Strings(entries)
+
+	h := sha256.New()
+	h.Write([]byte(resourceID))
+	for _, e := range entries {
+		h.Write([]byte{0x1e})
+		h.Write([]byte(e))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
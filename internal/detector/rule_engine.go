@@ -0,0 +1,565 @@
+package detector
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules/*.yaml
+var builtinRuleFiles embed.FS
+
+var (
+	defaultRuleSet     *RuleSet
+	defaultRuleSetErr  error
+	defaultRuleSetOnce sync.Once
+)
+
+// RuleSet is a validated, ordered collection of drift classification rules
+type RuleSet struct {
+	rules []*compiledRule
+}
+
+// compiledRule is a rule with its selector and predicates pre-parsed
+type compiledRule struct {
+	rule          *drift.Rule
+	selector      []selectorToken
+	resourceTypes map[string]bool // nil matches every resource type
+	changeTypes   map[string]bool // nil matches every change type
+	old           *compiledPredicate
+	new           *compiledPredicate
+}
+
+type compiledPredicate struct {
+	spec    *drift.ValuePredicate
+	pattern *regexp.Regexp
+}
+
+type selectorTokenKind int
+
+const (
+	tokenSegment   selectorTokenKind = iota // a single path segment, may contain glob wildcards
+	tokenRecursive                          // ".." - zero or more segments
+)
+
+type selectorToken struct {
+	kind    selectorTokenKind
+	pattern string
+}
+
+// BuiltinRuleSet returns the rule set compiled from the embedded rule files
+func BuiltinRuleSet() (*RuleSet, error) {
+	sub, err := fs.Sub(builtinRuleFiles, "rules")
+	if err != nil {
+		return nil, err
+	}
+	return LoadRuleSet(sub, drift.RuleSourceBuiltin)
+}
+
+// DefaultRuleSet returns the built-in rule set, loading it once. The embedded
+// rule files are covered by tests, so a failure here is a programming error.
+func DefaultRuleSet() *RuleSet {
+	defaultRuleSetOnce.Do(func() {
+		defaultRuleSet, defaultRuleSetErr = BuiltinRuleSet()
+	})
+	if defaultRuleSetErr != nil {
+		panic(fmt.Sprintf("invalid built-in drift rules: %v", defaultRuleSetErr))
+	}
+	return defaultRuleSet
+}
+
+// LoadRuleSet reads every .yaml, .yml and .json rule file in fsys and
+// compiles them into a rule set. Files are applied in name order.
+func LoadRuleSet(fsys fs.FS, source string) (*RuleSet, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(path.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule files: %w", err)
+	}
+	sort.Strings(files)
+
+	var rules []*drift.Rule
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file %s: %w", name, err)
+		}
+
+		fileRules, err := ParseRuleFile(name, data)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range fileRules {
+			r.Builtin = source == drift.RuleSourceBuiltin
+			r.Source = filepath.ToSlash(name)
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	return NewRuleSet(rules)
+}
+
+// ParseRuleFile decodes a versioned rule file. JSON is used for .json files,
+// YAML for everything else.
+func ParseRuleFile(name string, data []byte) ([]*drift.Rule, error) {
+	var file drift.RuleFile
+	var err error
+	if strings.EqualFold(path.Ext(name), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rule file %s: %w", name, err)
+	}
+
+	if file.Version != drift.RuleFileVersion {
+		return nil, fmt.Errorf("rule file %s: unsupported version %d (expected %d)", name, file.Version, drift.RuleFileVersion)
+	}
+
+	for i, r := range file.Rules {
+		if r == nil {
+			return nil, fmt.Errorf("rule file %s: rule %d is empty", name, i)
+		}
+		if len(r.ResourceTypes) == 0 {
+			r.ResourceTypes = append([]string(nil), file.ResourceTypes...)
+		}
+		r.Enabled = true
+	}
+
+	return file.Rules, nil
+}
+
+// NewRuleSet validates and compiles rules. Rule IDs must be unique.
+func NewRuleSet(rules []*drift.Rule) (*RuleSet, error) {
+	rs := &RuleSet{rules: make([]*compiledRule, 0, len(rules))}
+	seen := make(map[string]string, len(rules))
+
+	for _, r := range rules {
+		if prev, ok := seen[r.ID]; ok {
+			return nil, fmt.Errorf("duplicate rule id %q (%s and %s)", r.ID, prev, r.Source)
+		}
+		seen[r.ID] = r.Source
+
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, err
+		}
+		rs.rules = append(rs.rules, cr)
+	}
+
+	return rs, nil
+}
+
+// ValidateRule checks that a rule is well formed without adding it to a set
+func ValidateRule(r *drift.Rule) error {
+	_, err := compileRule(r)
+	return err
+}
+
+// With returns a new rule set where the given rules replace rules with the
+// same ID and are otherwise appended. Disabled rules remove their ID.
+func (rs *RuleSet) With(overrides []*drift.Rule) (*RuleSet, error) {
+	if len(overrides) == 0 {
+		return rs, nil
+	}
+
+	byID := make(map[string]*drift.Rule, len(overrides))
+	for _, r := range overrides {
+		byID[r.ID] = r
+	}
+
+	merged := make([]*drift.Rule, 0, len(rs.rules)+len(overrides))
+	for _, cr := range rs.rules {
+		if _, overridden := byID[cr.rule.ID]; overridden {
+			continue
+		}
+		merged = append(merged, cr.rule)
+	}
+	for _, r := range overrides {
+		if r.Enabled {
+			merged = append(merged, r)
+		}
+	}
+
+	return NewRuleSet(merged)
+}
+
+// Rules returns the rules in evaluation order
+func (rs *RuleSet) Rules() []*drift.Rule {
+	rules := make([]*drift.Rule, len(rs.rules))
+	for i, cr := range rs.rules {
+		rules[i] = cr.rule
+	}
+	return rules
+}
+
+// Lookup returns the rule with the given ID, if present
+func (rs *RuleSet) Lookup(id string) (*drift.Rule, bool) {
+	for _, cr := range rs.rules {
+		if cr.rule.ID == id {
+			return cr.rule, true
+		}
+	}
+	return nil, false
+}
+
+// Match returns every rule that matches a change on a resource of the given type
+func (rs *RuleSet) Match(resourceType string, change ConfigChange) []*drift.Rule {
+	var matched []*drift.Rule
+	normalizedType := normalizeResourceType(resourceType)
+
+	for _, cr := range rs.rules {
+		if cr.matches(normalizedType, change) {
+			matched = append(matched, cr.rule)
+		}
+	}
+	return matched
+}
+
+func compileRule(r *drift.Rule) (*compiledRule, error) {
+	if r == nil {
+		return nil, fmt.Errorf("rule is empty")
+	}
+	if strings.TrimSpace(r.ID) == "" {
+		return nil, fmt.Errorf("rule id is required")
+	}
+
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("rule %q: %s", r.ID, fmt.Sprintf(format, args...))
+	}
+
+	if !isValidSeverity(r.Severity) {
+		return nil, fail("invalid severity %q", r.Severity)
+	}
+	if !isValidDriftType(r.DriftType) {
+		return nil, fail("invalid drift type %q", r.DriftType)
+	}
+
+	selector, err := parseSelector(r.Field)
+	if err != nil {
+		return nil, fail("invalid field selector %q: %v", r.Field, err)
+	}
+
+	cr := &compiledRule{rule: r, selector: selector}
+
+	for _, t := range r.ResourceTypes {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, fail("empty resource type")
+		}
+		if t == "*" {
+			cr.resourceTypes = nil
+			break
+		}
+		if cr.resourceTypes == nil {
+			cr.resourceTypes = make(map[string]bool)
+		}
+		cr.resourceTypes[normalizeResourceType(t)] = true
+	}
+
+	for _, ct := range r.ChangeTypes {
+		switch ct {
+		case drift.ChangeAdded, drift.ChangeRemoved, drift.ChangeModified:
+		default:
+			return nil, fail("invalid change type %q", ct)
+		}
+		if cr.changeTypes == nil {
+			cr.changeTypes = make(map[string]bool)
+		}
+		cr.changeTypes[ct] = true
+	}
+
+	if cr.old, err = compilePredicate(r.Old); err != nil {
+		return nil, fail("invalid old predicate: %v", err)
+	}
+	if cr.new, err = compilePredicate(r.New); err != nil {
+		return nil, fail("invalid new predicate: %v", err)
+	}
+
+	return cr, nil
+}
+
+func compilePredicate(p *drift.ValuePredicate) (*compiledPredicate, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	cp := &compiledPredicate{spec: p}
+	if p.Matches != "" {
+		re, err := regexp.Compile(p.Matches)
+		if err != nil {
+			return nil, err
+		}
+		cp.pattern = re
+	}
+	return cp, nil
+}
+
+// parseSelector parses a JSONPath-style field selector. Supported syntax is
+// "$" followed by ".segment" or "..segment" steps, where "..", recursive
+// descent, matches any depth and segments may use glob wildcards (*, ?).
+func parseSelector(selector string) ([]selectorToken, error) {
+	selector = strings.TrimSpace(selector)
+	if !strings.HasPrefix(selector, "$") {
+		return nil, fmt.Errorf("selector must start with $")
+	}
+
+	rest := selector[1:]
+	if rest == "" {
+		return nil, fmt.Errorf("selector must select a field")
+	}
+
+	var tokens []selectorToken
+	for rest != "" {
+		if !strings.HasPrefix(rest, ".") {
+			return nil, fmt.Errorf("expected '.' at %q", rest)
+		}
+		rest = rest[1:]
+		if strings.HasPrefix(rest, ".") {
+			tokens = append(tokens, selectorToken{kind: tokenRecursive})
+			rest = rest[1:]
+		}
+
+		end := strings.Index(rest, ".")
+		if end < 0 {
+			end = len(rest)
+		}
+		segment := strings.ToLower(rest[:end])
+		if segment == "" {
+			return nil, fmt.Errorf("empty path segment")
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q", segment)
+		}
+		tokens = append(tokens, selectorToken{kind: tokenSegment, pattern: segment})
+		rest = rest[end:]
+	}
+
+	return tokens, nil
+}
+
+func (cr *compiledRule) matches(normalizedType string, change ConfigChange) bool {
+	if cr.resourceTypes != nil && !cr.resourceTypes[normalizedType] {
+		return false
+	}
+	if cr.changeTypes != nil && !cr.changeTypes[change.ChangeType] {
+		return false
+	}
+	if !selectsPath(cr.selector, change.Path) {
+		return false
+	}
+	if cr.old != nil && !cr.old.matches(change.OldValue) {
+		return false
+	}
+	if cr.new != nil && !cr.new.matches(change.NewValue) {
+		return false
+	}
+	return true
+}
+
+// selectsPath reports whether the selector selects the changed field or one
+// of its ancestors, so "$.network" also covers "network.public_ip_address".
+func selectsPath(tokens []selectorToken, changePath string) bool {
+	segments := strings.Split(strings.ToLower(changePath), ".")
+	for n := len(segments); n > 0; n-- {
+		if matchSelector(tokens, segments[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSelector(tokens []selectorToken, segments []string) bool {
+	if len(tokens) == 0 {
+		return len(segments) == 0
+	}
+
+	tok := tokens[0]
+	if tok.kind == tokenRecursive {
+		for i := 0; i <= len(segments); i++ {
+			if matchSelector(tokens[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(tok.pattern, segments[0]); !ok {
+		return false
+	}
+	return matchSelector(tokens[1:], segments[1:])
+}
+
+func (p *compiledPredicate) matches(value interface{}) bool {
+	spec := p.spec
+
+	if spec.Exists != nil && (value != nil) != *spec.Exists {
+		return false
+	}
+	if spec.Truthy != nil && isTruthy(value) != *spec.Truthy {
+		return false
+	}
+	if spec.Equals != nil && !looselyEqual(value, spec.Equals) {
+		return false
+	}
+	if spec.NotEquals != nil && looselyEqual(value, spec.NotEquals) {
+		return false
+	}
+	if len(spec.In) > 0 {
+		found := false
+		for _, candidate := range spec.In {
+			if looselyEqual(value, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(spec.Contains) > 0 {
+		text := strings.ToLower(valueText(value))
+		found := false
+		for _, sub := range spec.Contains {
+			if strings.Contains(text, strings.ToLower(sub)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.pattern != nil && !p.pattern.MatchString(valueText(value)) {
+		return false
+	}
+
+	return true
+}
+
+// isTruthy reports whether a configuration value represents an enabled setting
+func isTruthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "", "false", "disabled", "none", "off", "no", "0", "null":
+			return false
+		}
+		return true
+	case []interface{}:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+// looselyEqual compares configuration values, treating numbers of any type as
+// equal by value and strings case-insensitively
+func looselyEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		return ok && strings.EqualFold(sa, sb)
+	}
+	if ba, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		return ok && ba == bb
+	}
+	return valueText(a) == valueText(b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// valueText renders a value for substring and pattern checks. Structured
+// values are JSON-encoded so nested lists such as CIDR blocks are searchable.
+func valueText(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// normalizeResourceType lets rule files use either "s3-bucket" or "s3_bucket"
+func normalizeResourceType(t string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(t)), "_", "-")
+}
+
+func isValidSeverity(s string) bool {
+	switch s {
+	case drift.SeverityCritical, drift.SeverityHigh, drift.SeverityMedium, drift.SeverityLow:
+		return true
+	}
+	return false
+}
+
+func isValidDriftType(t string) bool {
+	switch t {
+	case drift.TypeConfigurationChange, drift.TypeSecurityGroup, drift.TypeIAMPolicy,
+		drift.TypeNetworkRule, drift.TypeEncryption, drift.TypeCompliance:
+		return true
+	}
+	return false
+}
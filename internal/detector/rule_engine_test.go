@@ -0,0 +1,155 @@
+package detector
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+)
+
+func TestBuiltinRuleSet(t *testing.T) {
+	rs, err := BuiltinRuleSet()
+	if err != nil {
+		t.Fatalf("BuiltinRuleSet() error = %v", err)
+	}
+	if len(rs.Rules()) == 0 {
+		t.Fatal("BuiltinRuleSet() returned no rules")
+	}
+	for _, r := range rs.Rules() {
+		if !r.Builtin || r.Source == "" {
+			t.Errorf("rule %s: builtin = %v, source = %q", r.ID, r.Builtin, r.Source)
+		}
+	}
+}
+
+func TestDriftDetector_Classification(t *testing.T) {
+	d := NewDriftDetector()
+
+	tests := []struct {
+		name         string
+		resourceType string
+		baseline     string
+		current      string
+		wantSeverity string
+		wantType     string
+	}{
+		{
+			name:         "encryption disabled",
+			resourceType: "s3-bucket",
+			baseline:     `{"encryption": {"enabled": true}}`,
+			current:      `{"encryption": {"enabled": false}}`,
+			wantSeverity: drift.SeverityCritical,
+			wantType:     drift.TypeEncryption,
+		},
+		{
+			name:         "public access block disabled",
+			resourceType: "s3_bucket",
+			baseline:     `{"public_access": {"block_public_acls": true}}`,
+			current:      `{"public_access": {"block_public_acls": false}}`,
+			wantSeverity: drift.SeverityCritical,
+			wantType:     drift.TypeSecurityGroup,
+		},
+		{
+			name:         "ingress opened to the world",
+			resourceType: "security_group",
+			baseline:     `{"ingress": [{"cidr": "10.0.0.0/8"}]}`,
+			current:      `{"ingress": [{"cidr": "0.0.0.0/0"}]}`,
+			wantSeverity: drift.SeverityCritical,
+			wantType:     drift.TypeSecurityGroup,
+		},
+		{
+			name:         "unclassified change",
+			resourceType: "ec2-instance",
+			baseline:     `{"instance_type": "t3.micro"}`,
+			current:      `{"instance_type": "t3.large"}`,
+			wantSeverity: drift.SeverityLow,
+			wantType:     drift.TypeConfigurationChange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := d.DetectDrift(tt.resourceType, tt.baseline, tt.current)
+			if err != nil {
+				t.Fatalf("DetectDrift() error = %v", err)
+			}
+			if !result.HasDrift {
+				t.Fatal("DetectDrift() found no drift")
+			}
+			if result.Severity != tt.wantSeverity || result.DriftType != tt.wantType {
+				t.Errorf("DetectDrift() = %s/%s, want %s/%s", result.Severity, result.DriftType, tt.wantSeverity, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestLoadRuleSet_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name: "valid json and yaml files",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte("version: 1\nrules:\n  - id: a\n    field: $.a\n    severity: low\n    drift_type: configuration_change\n")},
+				"b.json": {Data: []byte(`{"version": 1, "rules": [{"id": "b", "field": "$..b*", "severity": "high", "drift_type": "compliance"}]}`)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported version",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte("version: 2\nrules: []\n")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate rule id across files",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte("version: 1\nrules:\n  - id: a\n    field: $.a\n    severity: low\n    drift_type: configuration_change\n")},
+				"b.yaml": {Data: []byte("version: 1\nrules:\n  - id: a\n    field: $.b\n    severity: low\n    drift_type: configuration_change\n")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid regular expression",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte("version: 1\nrules:\n  - id: a\n    field: $.a\n    severity: low\n    drift_type: configuration_change\n    new:\n      matches: \"[\"\n")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown change type",
+			files: fstest.MapFS{
+				"a.yaml": {Data: []byte("version: 1\nrules:\n  - id: a\n    field: $.a\n    change_types: [renamed]\n    severity: low\n    drift_type: configuration_change\n")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRuleSet(tt.files, "test")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadRuleSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleSet_With(t *testing.T) {
+	base := DefaultRuleSet()
+
+	disabled := &drift.Rule{ID: "encryption-disabled", Field: "$..*encrypt*", Severity: drift.SeverityLow, DriftType: drift.TypeEncryption}
+	rs, err := base.With([]*drift.Rule{disabled})
+	if err != nil {
+		t.Fatalf("With() error = %v", err)
+	}
+	if _, ok := rs.Lookup("encryption-disabled"); ok {
+		t.Error("disabled override should remove the built-in rule")
+	}
+	if _, ok := base.Lookup("encryption-disabled"); !ok {
+		t.Error("With() must not modify the base rule set")
+	}
+}
@@ -0,0 +1,81 @@
+package compliance
+
+// Catalog is a framework together with its controls and their checks, as
+// loaded from a catalog file
+type Catalog struct {
+	Framework *Framework
+	Controls  []*Control
+}
+
+// CatalogFile is the on-disk format of a versioned framework catalog
+type CatalogFile struct {
+	Version    int               `json:"version" yaml:"version"`
+	Framework  CatalogFramework  `json:"framework" yaml:"framework"`
+	Categories []string          `json:"categories,omitempty" yaml:"categories,omitempty"` // when set, every control must use one of them
+	Controls   []*CatalogControl `json:"controls" yaml:"controls"`
+}
+
+// CatalogFramework describes the framework a catalog file defines. The
+// revision is bumped whenever the catalog content changes, so unchanged
+// catalogs are not rewritten on startup.
+type CatalogFramework struct {
+	ID           string `json:"id" yaml:"id"`
+	Name         string `json:"name" yaml:"name"`
+	Version      string `json:"version" yaml:"version"`
+	Revision     int    `json:"revision" yaml:"revision"`
+	Description  string `json:"description" yaml:"description"`
+	Provider     string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Enabled      *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`             // defaults to true for new frameworks
+	ReferenceURL string `json:"reference_url,omitempty" yaml:"reference_url,omitempty"` // default for controls without their own
+}
+
+// CatalogControl is a control in a catalog file
+type CatalogControl struct {
+	ID           string          `json:"id" yaml:"id"`
+	Title        string          `json:"title" yaml:"title"`
+	Description  string          `json:"description" yaml:"description"`
+	Category     string          `json:"category" yaml:"category"`
+	Severity     string          `json:"severity" yaml:"severity"`
+	Remediation  string          `json:"remediation" yaml:"remediation"`
+	ReferenceURL string          `json:"reference_url,omitempty" yaml:"reference_url,omitempty"`
+	Checks       []*CatalogCheck `json:"checks,omitempty" yaml:"checks,omitempty"`
+	Crosswalk    []string        `json:"crosswalk,omitempty" yaml:"crosswalk,omitempty"` // equivalent controls as "framework-id:control-id"
+}
+
+// CatalogCheck maps a control to a configuration check on one resource
+// type, to open drifts of a drift type, or to both
+type CatalogCheck struct {
+	ResourceType string `json:"resource_type,omitempty" yaml:"resource_type,omitempty"`
+	Provider     string `json:"provider,omitempty" yaml:"provider,omitempty"`     // defaults to the framework provider
+	Confidence   string `json:"confidence,omitempty" yaml:"confidence,omitempty"` // high, medium, low; defaults to high
+	DriftType    string `json:"drift_type,omitempty" yaml:"drift_type,omitempty"`
+	Check        `yaml:",inline"`
+}
+
+// ControlRef identifies a control by its framework and control ID
+type ControlRef struct {
+	FrameworkID string `json:"framework_id"`
+	ControlID   string `json:"control_id"`
+}
+
+// String formats the reference as used in catalog files
+func (r ControlRef) String() string {
+	return r.FrameworkID + ":" + r.ControlID
+}
+
+// ControlLink is a crosswalk link between equivalent controls of two
+// frameworks. Links are declared by the first control's catalog and apply in
+// both directions: the checks of either control count for the other.
+type ControlLink struct {
+	Control ControlRef
+	Linked  ControlRef
+}
+
+// CatalogFileVersion is the catalog file format version understood by this build
+const CatalogFileVersion = 1
+
+// Framework sources
+const (
+	FrameworkSourceBuiltin = "builtin"
+	FrameworkSourceCustom  = "custom"
+)
@@ -0,0 +1,51 @@
+package compliance
+
+import "time"
+
+// Exception accepts the risk of a failing control. It covers one resource,
+// or every resource of the control when ResourceID is empty, and only
+// excepts findings once approved and until it expires.
+type Exception struct {
+	ID               string     `json:"id"`
+	UserID           int64      `json:"user_id"`
+	FrameworkID      string     `json:"framework_id"`
+	ControlID        string     `json:"control_id"` // e.g., "2.1.1" for CIS
+	ResourceID       string     `json:"resource_id,omitempty"`
+	Justification    string     `json:"justification"`
+	Status           string     `json:"status"` // pending, approved, rejected, revoked, expired
+	RequestedBy      int64      `json:"requested_by"`
+	ApprovedBy       *int64     `json:"approved_by,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	RejectionReason  string     `json:"rejection_reason,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the exception excepts findings at the given time
+func (e *Exception) IsActive(now time.Time) bool {
+	return e.Status == ExceptionStatusApproved && now.Before(e.ExpiresAt)
+}
+
+// Covers reports whether the exception applies to a resource
+func (e *Exception) Covers(resourceID string) bool {
+	return e.ResourceID == "" || e.ResourceID == resourceID
+}
+
+// ExceptionFilter narrows exception listings
+type ExceptionFilter struct {
+	FrameworkID string
+	ControlID   string
+	ResourceID  string
+	Status      string
+}
+
+// Exception status constants
+const (
+	ExceptionStatusPending  = "pending"
+	ExceptionStatusApproved = "approved"
+	ExceptionStatusRejected = "rejected"
+	ExceptionStatusRevoked  = "revoked"
+	ExceptionStatusExpired  = "expired"
+)
@@ -3,6 +3,8 @@ package compliance
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
 )
 
 // Framework represents a compliance framework (CIS, NIST, SOC2, etc.)
@@ -13,6 +15,8 @@ type Framework struct {
 	Description string    `json:"description"`
 	Provider    string    `json:"provider,omitempty"` // aws, gcp, azure, or empty for multi-cloud
 	IsEnabled   bool      `json:"is_enabled"`
+	Source      string    `json:"source"`   // builtin or custom
+	Revision    int       `json:"revision"` // catalog revision the controls were loaded from
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -29,6 +33,11 @@ type Control struct {
 	Remediation  string    `json:"remediation"`
 	ReferenceURL string    `json:"reference_url,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Checks are the mappings defined for the control in its catalog
+	Checks []*ControlMapping `json:"-"`
+	// Crosswalk lists the equivalent controls declared in its catalog
+	Crosswalk []ControlRef `json:"-"`
 }
 
 // ControlMapping maps security rules/drift types to compliance controls
@@ -38,8 +47,25 @@ type ControlMapping struct {
 	SecurityRuleType  string `json:"security_rule_type"` // drift_type or vuln_type
 	ResourceType      string `json:"resource_type"`
 	Provider          string `json:"provider,omitempty"`
-	MappingConfidence string `json:"mapping_confidence"` // high, medium, low
-	CheckQuery        string `json:"check_query,omitempty"`
+	MappingConfidence string `json:"mapping_confidence"`    // high, medium, low
+	CheckQuery        string `json:"check_query,omitempty"` // JSON-encoded Check
+}
+
+// Check is a configuration check run against every in-scope resource. A
+// resource passes when all of the All conditions hold and, if Any is set,
+// at least one of the Any conditions does.
+type Check struct {
+	All []CheckCondition `json:"all,omitempty" yaml:"all,omitempty"`
+	Any []CheckCondition `json:"any,omitempty" yaml:"any,omitempty"`
+}
+
+// CheckCondition constrains the configuration values selected by a
+// JSONPath-style field selector, as used by drift rules. Every selected
+// value must satisfy the predicate; a field that selects nothing is
+// checked as a missing value.
+type CheckCondition struct {
+	Field                string `json:"field" yaml:"field"`
+	drift.ValuePredicate `yaml:",inline"`
 }
 
 // Assessment represents a compliance assessment run
@@ -53,6 +79,7 @@ type Assessment struct {
 	PassedControls        int             `json:"passed_controls"`
 	FailedControls        int             `json:"failed_controls"`
 	NotApplicableControls int             `json:"not_applicable_controls"`
+	ExceptedControls      int             `json:"excepted_controls"`
 	CompliancePercent     float64         `json:"compliance_percent"`
 	Findings              json.RawMessage `json:"findings,omitempty"`
 	Status                string          `json:"status"` // running, completed, failed
@@ -65,11 +92,14 @@ type AssessmentFinding struct {
 	ControlTitle      string   `json:"control_title"`
 	Category          string   `json:"category"`
 	Severity          string   `json:"severity"`
-	Status            string   `json:"status"` // passed, failed, not_applicable
+	Status            string   `json:"status"` // passed, failed, excepted, not_applicable
 	AffectedCount     int      `json:"affected_count"`
 	AffectedResources []string `json:"affected_resources,omitempty"`
 	Remediation       string   `json:"remediation"`
 	Evidence          string   `json:"evidence,omitempty"`
+	SatisfiedBy       []string `json:"satisfied_by,omitempty"` // linked controls whose checks were used
+	ExceptedResources []string `json:"excepted_resources,omitempty"`
+	ExceptionIDs      []string `json:"exception_ids,omitempty"`
 }
 
 // ComplianceStatus represents compliance status for a resource
@@ -87,8 +117,10 @@ type ControlStatus struct {
 	FrameworkID string    `json:"framework_id"`
 	ControlID   string    `json:"control_id"`
 	Title       string    `json:"title"`
-	Status      string    `json:"status"` // passed, failed, not_checked
+	Status      string    `json:"status"` // passed, failed, excepted, not_checked
 	Remediation string    `json:"remediation,omitempty"`
+	Evidence    string    `json:"evidence,omitempty"`
+	ExceptionID string    `json:"exception_id,omitempty"`
 	LastChecked time.Time `json:"last_checked"`
 }
 
@@ -125,6 +157,9 @@ const (
 	ControlStatusFailed        = "failed"
 	ControlStatusNotApplicable = "not_applicable"
 	ControlStatusNotChecked    = "not_checked"
+	// ControlStatusExcepted marks a failing control whose failures are all
+	// covered by approved exceptions
+	ControlStatusExcepted = "excepted"
 )
 
 // Compliance status constants
@@ -12,6 +12,7 @@ type Repository interface {
 	GetFrameworkByName(ctx context.Context, name string) (*Framework, error)
 	ListFrameworks(ctx context.Context) ([]*Framework, error)
 	UpdateFramework(ctx context.Context, framework *Framework) error
+	UpsertCatalog(ctx context.Context, catalog *Catalog) error
 
 	// Controls
 	CreateControl(ctx context.Context, control *Control) error
@@ -25,6 +26,15 @@ type Repository interface {
 	GetMappingsForControl(ctx context.Context, controlID string) ([]*ControlMapping, error)
 	GetMappingsForSecurityRule(ctx context.Context, ruleType string, resourceType string) ([]*ControlMapping, error)
 
+	// Crosswalk
+	ListControlLinks(ctx context.Context) ([]*ControlLink, error)
+
+	// Exceptions
+	CreateException(ctx context.Context, exception *Exception) error
+	GetException(ctx context.Context, userID int64, id string) (*Exception, error)
+	UpdateException(ctx context.Context, exception *Exception) error
+	ListExceptions(ctx context.Context, userID int64, filter ExceptionFilter) ([]*Exception, error)
+
 	// Assessments
 	CreateAssessment(ctx context.Context, assessment *Assessment) error
 	GetAssessment(ctx context.Context, id string) (*Assessment, error)
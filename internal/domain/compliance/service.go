@@ -27,13 +27,24 @@ type Service interface {
 	GetResourceCompliance(ctx context.Context, userID int64, resourceID string) (*ComplianceStatus, error)
 	GetComplianceOverview(ctx context.Context, userID int64) (*ComplianceOverview, error)
 	GetComplianceTrend(ctx context.Context, userID int64, frameworkID string, days int) (*ComplianceTrend, error)
+	GetCrosswalk(ctx context.Context, userID int64, frameworkID string) (*Crosswalk, error)
+
+	// Exceptions
+	RequestException(ctx context.Context, userID int64, exception *Exception) (*Exception, error)
+	GetException(ctx context.Context, userID int64, id string) (*Exception, error)
+	ListExceptions(ctx context.Context, userID int64, filter ExceptionFilter) ([]*Exception, error)
+	ApproveException(ctx context.Context, userID int64, id string) (*Exception, error)
+	RejectException(ctx context.Context, userID int64, id string, reason string) (*Exception, error)
+	RevokeException(ctx context.Context, userID int64, id string) (*Exception, error)
+	NotifyExpiringExceptions(ctx context.Context, userID int64) (int, error)
 
 	// Reports
-	GenerateReport(ctx context.Context, assessmentID string, format string) ([]byte, error)
-	ExportAssessment(ctx context.Context, assessmentID string) (*AssessmentExport, error)
+	GenerateReport(ctx context.Context, userID int64, assessmentID string, format string) ([]byte, error)
+	ExportAssessment(ctx context.Context, userID int64, assessmentID string) (*AssessmentExport, error)
 
 	// Initialization
 	InitializeFrameworks(ctx context.Context) error
+	ImportFramework(ctx context.Context, data []byte, format string) (*Catalog, error)
 }
 
 // ComplianceOverview represents a high-level compliance summary
@@ -41,6 +52,7 @@ type ComplianceOverview struct {
 	TotalControls      int                   `json:"total_controls"`
 	PassedControls     int                   `json:"passed_controls"`
 	FailedControls     int                   `json:"failed_controls"`
+	ExceptedControls   int                   `json:"excepted_controls"`
 	CompliancePercent  float64               `json:"compliance_percent"`
 	ByFramework        []FrameworkCompliance `json:"by_framework"`
 	TopFailingControls []AssessmentFinding   `json:"top_failing_controls"`
@@ -54,10 +66,36 @@ type FrameworkCompliance struct {
 	TotalControls     int     `json:"total_controls"`
 	PassedControls    int     `json:"passed_controls"`
 	FailedControls    int     `json:"failed_controls"`
+	ExceptedControls  int     `json:"excepted_controls"`
 	CompliancePercent float64 `json:"compliance_percent"`
 	LastAssessment    string  `json:"last_assessment,omitempty"`
 }
 
+// Crosswalk is the live compliance posture of the enabled frameworks, with
+// controls shown next to the equivalent controls they are linked to
+type Crosswalk struct {
+	Frameworks []FrameworkCompliance `json:"frameworks"`
+	Rows       []CrosswalkRow        `json:"rows"`
+}
+
+// CrosswalkRow is a control and the controls linked to it
+type CrosswalkRow struct {
+	Control CrosswalkControl   `json:"control"`
+	Linked  []CrosswalkControl `json:"linked"`
+}
+
+// CrosswalkControl is the current status of a control in a crosswalk
+type CrosswalkControl struct {
+	FrameworkID   string   `json:"framework_id"`
+	FrameworkName string   `json:"framework_name"`
+	ControlID     string   `json:"control_id"`
+	Title         string   `json:"title"`
+	Severity      string   `json:"severity"`
+	Status        string   `json:"status"`
+	AffectedCount int      `json:"affected_count"`
+	SatisfiedBy   []string `json:"satisfied_by,omitempty"`
+}
+
 // ComplianceTrend represents compliance changes over time
 type ComplianceTrend struct {
 	FrameworkID   string           `json:"framework_id"`
@@ -83,17 +121,42 @@ type AssessmentExport struct {
 	Framework   *Framework          `json:"framework"`
 	Findings    []AssessmentFinding `json:"findings"`
 	Summary     *ExportSummary      `json:"summary"`
+	Categories  []CategorySummary   `json:"categories"`
+	Exceptions  []*Exception        `json:"exceptions,omitempty"` // exceptions referenced by findings
 	GeneratedAt string              `json:"generated_at"`
 }
 
 // ExportSummary provides a summary for exports
 type ExportSummary struct {
+	TotalControls     int            `json:"total_controls"`
+	Passed            int            `json:"passed"`
+	Failed            int            `json:"failed"`
+	Excepted          int            `json:"excepted"`
+	NotApplicable     int            `json:"not_applicable"`
+	Score             float64        `json:"score"`
+	CriticalCount     int            `json:"critical_count"`
+	HighCount         int            `json:"high_count"`
+	MediumCount       int            `json:"medium_count"`
+	LowCount          int            `json:"low_count"`
+	FailingBySeverity map[string]int `json:"failing_by_severity"`
+}
+
+// CategorySummary breaks assessment results down by control category
+type CategorySummary struct {
+	Category      string  `json:"category"`
 	TotalControls int     `json:"total_controls"`
 	Passed        int     `json:"passed"`
 	Failed        int     `json:"failed"`
+	Excepted      int     `json:"excepted"`
+	NotApplicable int     `json:"not_applicable"`
 	Score         float64 `json:"score"`
-	CriticalCount int     `json:"critical_count"`
-	HighCount     int     `json:"high_count"`
-	MediumCount   int     `json:"medium_count"`
-	LowCount      int     `json:"low_count"`
 }
+
+// Report formats accepted by GenerateReport
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatHTML  = "html"
+	ReportFormatPDF   = "pdf"
+	ReportFormatCSV   = "csv"
+	ReportFormatOSCAL = "oscal" // OSCAL assessment-results JSON
+)
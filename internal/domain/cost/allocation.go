@@ -0,0 +1,153 @@
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// UntaggedGroup receives costs that carry none of a rule's tag keys
+const UntaggedGroup = "untagged"
+
+// AllocationRule assigns costs to allocation groups, such as teams or cost
+// centers, by their tags. The value of the first of TagKeys present on a cost
+// names its group; Aliases map tag values onto group names so variants like
+// "Data" and "data-eng" land in one group. Splits then share out the costs of
+// shared groups.
+type AllocationRule struct {
+	ID        string            `json:"id"`
+	UserID    int64             `json:"user_id"`
+	Name      string            `json:"name"`
+	TagKeys   []string          `json:"tag_keys"`
+	Aliases   map[string]string `json:"aliases,omitempty"`
+	Splits    []AllocationSplit `json:"splits,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// AllocationSplit shares the costs of one group among the others, either in
+// proportion to their own costs in each period or by fixed percentages
+type AllocationSplit struct {
+	Group   string             `json:"group"`
+	Method  string             `json:"method"`            // proportional, fixed
+	Weights map[string]float64 `json:"weights,omitempty"` // fixed only: group -> percent, adding up to 100
+}
+
+// AllocationSplit methods
+const (
+	SplitMethodProportional = "proportional"
+	SplitMethodFixed        = "fixed"
+)
+
+var (
+	ErrAllocationNameRequired = errors.New("allocation rule name is required")
+	ErrAllocationTagKeys      = errors.New("allocation rule needs at least one tag key")
+)
+
+// Validate validates the rule
+func (r *AllocationRule) Validate() error {
+	if r.Name == "" {
+		return ErrAllocationNameRequired
+	}
+	if len(r.TagKeys) == 0 {
+		return ErrAllocationTagKeys
+	}
+	for _, key := range r.TagKeys {
+		if key == "" {
+			return ErrAllocationTagKeys
+		}
+	}
+
+	sources := make(map[string]bool, len(r.Splits))
+	for _, split := range r.Splits {
+		if split.Group == "" {
+			return errors.New("split group is required")
+		}
+		if sources[split.Group] {
+			return fmt.Errorf("group %q is split more than once", split.Group)
+		}
+		sources[split.Group] = true
+	}
+
+	for _, split := range r.Splits {
+		switch split.Method {
+		case SplitMethodProportional:
+			if len(split.Weights) > 0 {
+				return fmt.Errorf("proportional split of %q cannot have weights", split.Group)
+			}
+		case SplitMethodFixed:
+			var total float64
+			for group, weight := range split.Weights {
+				if sources[group] || weight <= 0 {
+					return fmt.Errorf("fixed split of %q has an invalid weight for %q", split.Group, group)
+				}
+				total += weight
+			}
+			if math.Abs(total-100) > 0.01 {
+				return fmt.Errorf("fixed split weights of %q add up to %v, want 100", split.Group, total)
+			}
+		default:
+			return fmt.Errorf("split method must be proportional or fixed, got %q", split.Method)
+		}
+	}
+	return nil
+}
+
+// GroupFor returns the allocation group of a cost record
+func (r *AllocationRule) GroupFor(c *Cost) string {
+	for _, key := range r.TagKeys {
+		value, ok := c.Tag(key)
+		if !ok || value == "" {
+			continue
+		}
+		if alias, ok := r.Aliases[value]; ok {
+			return alias
+		}
+		return value
+	}
+	return UntaggedGroup
+}
+
+// AllocationQuery selects the costs and periods of an allocation report.
+// Either RuleID names a saved rule or TagKeys define an ad hoc rule without
+// aliases or splits.
+type AllocationQuery struct {
+	RuleID      string
+	TagKeys     []string
+	Provider    string
+	StartDate   time.Time
+	EndDate     time.Time
+	Granularity string
+}
+
+// AllocationReport shows how costs were allocated to groups
+type AllocationReport struct {
+	Rule        *AllocationRule   `json:"rule"`
+	Provider    string            `json:"provider,omitempty"`
+	StartDate   time.Time         `json:"start_date"`
+	EndDate     time.Time         `json:"end_date"`
+	Granularity string            `json:"granularity"`
+	TotalCost   float64           `json:"total_cost"`
+	Currency    string            `json:"currency"`
+	Groups      []AllocationGroup `json:"groups"`
+}
+
+// AllocationGroup is one group's share of the costs. SharedCost is what the
+// group received from split groups, negative for a group that was split.
+type AllocationGroup struct {
+	Name       string             `json:"name"`
+	DirectCost float64            `json:"direct_cost"`
+	SharedCost float64            `json:"shared_cost"`
+	TotalCost  float64            `json:"total_cost"`
+	Percentage float64            `json:"percentage"`
+	Trend      []AllocationPeriod `json:"trend"`
+}
+
+// AllocationPeriod is a group's cost in one day, week or month
+type AllocationPeriod struct {
+	Start      time.Time `json:"start"`
+	DirectCost float64   `json:"direct_cost"`
+	SharedCost float64   `json:"shared_cost"`
+	TotalCost  float64   `json:"total_cost"`
+}
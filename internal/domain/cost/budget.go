@@ -0,0 +1,187 @@
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Budget caps spend within a scope over a monthly or quarterly period
+type Budget struct {
+	ID         string            `json:"id"`
+	UserID     int64             `json:"user_id"`
+	Name       string            `json:"name"`
+	Period     string            `json:"period"` // monthly, quarterly
+	Amount     float64           `json:"amount"`
+	Currency   string            `json:"currency"`
+	Scope      BudgetScope       `json:"scope"`
+	Thresholds []BudgetThreshold `json:"thresholds"`
+	IsEnabled  bool              `json:"is_enabled"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// BudgetScope selects the costs counted against a budget. Empty fields match
+// everything; a tag key without a value matches any value of that tag.
+type BudgetScope struct {
+	Provider    string `json:"provider,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	Region      string `json:"region,omitempty"`
+	TagKey      string `json:"tag_key,omitempty"`
+	TagValue    string `json:"tag_value,omitempty"`
+}
+
+// BudgetThreshold fires an alert once spend reaches a percentage of the
+// budget amount. Forecasted thresholds compare projected spend at period end.
+type BudgetThreshold struct {
+	Percent float64 `json:"percent"`
+	Type    string  `json:"type"` // actual, forecasted
+}
+
+// BudgetStatus is the spend of a budget in its current period
+type BudgetStatus struct {
+	Budget           *Budget           `json:"budget"`
+	PeriodStart      time.Time         `json:"period_start"`
+	PeriodEnd        time.Time         `json:"period_end"`
+	ActualSpend      float64           `json:"actual_spend"`
+	ProjectedSpend   float64           `json:"projected_spend"`
+	PercentUsed      float64           `json:"percent_used"`
+	ProjectedPercent float64           `json:"projected_percent"`
+	Crossed          []BudgetThreshold `json:"crossed"`
+	Alerts           []*BudgetAlert    `json:"alerts"`
+}
+
+// BudgetAlert records a threshold that fired within a budget period. Each
+// threshold fires at most once per period.
+type BudgetAlert struct {
+	ID               string    `json:"id"`
+	BudgetID         string    `json:"budget_id"`
+	UserID           int64     `json:"user_id"`
+	PeriodKey        string    `json:"period_key"` // e.g. 2026-10 or 2026-Q4
+	ThresholdType    string    `json:"threshold_type"`
+	ThresholdPercent float64   `json:"threshold_percent"`
+	Spend            float64   `json:"spend"`
+	BudgetAmount     float64   `json:"budget_amount"`
+	TriggeredAt      time.Time `json:"triggered_at"`
+}
+
+// BudgetPeriod constants
+const (
+	BudgetPeriodMonthly   = "monthly"
+	BudgetPeriodQuarterly = "quarterly"
+)
+
+// ThresholdType constants
+const (
+	ThresholdTypeActual     = "actual"
+	ThresholdTypeForecasted = "forecasted"
+)
+
+// Budget validation errors
+var (
+	ErrBudgetNameRequired    = errors.New("budget name is required")
+	ErrInvalidBudgetAmount   = errors.New("budget amount must be greater than zero")
+	ErrInvalidBudgetPeriod   = errors.New("budget period must be monthly or quarterly")
+	ErrInvalidBudgetScope    = errors.New("budget scope tag_value requires tag_key")
+	ErrInvalidBudgetProvider = errors.New("budget scope provider must be aws, gcp or azure")
+)
+
+// DefaultBudgetThresholds returns the thresholds used when a budget sets none
+func DefaultBudgetThresholds() []BudgetThreshold {
+	return []BudgetThreshold{
+		{Percent: 50, Type: ThresholdTypeActual},
+		{Percent: 80, Type: ThresholdTypeActual},
+		{Percent: 100, Type: ThresholdTypeActual},
+		{Percent: 100, Type: ThresholdTypeForecasted},
+	}
+}
+
+// Validate validates the budget
+func (b *Budget) Validate() error {
+	if b.Name == "" {
+		return ErrBudgetNameRequired
+	}
+	if b.Amount <= 0 {
+		return ErrInvalidBudgetAmount
+	}
+	if b.Period != BudgetPeriodMonthly && b.Period != BudgetPeriodQuarterly {
+		return ErrInvalidBudgetPeriod
+	}
+	switch b.Scope.Provider {
+	case "", ProviderAWS, ProviderGCP, ProviderAzure:
+	default:
+		return ErrInvalidBudgetProvider
+	}
+	if b.Scope.TagValue != "" && b.Scope.TagKey == "" {
+		return ErrInvalidBudgetScope
+	}
+	for _, t := range b.Thresholds {
+		if t.Percent <= 0 {
+			return fmt.Errorf("threshold percent must be greater than zero, got %v", t.Percent)
+		}
+		if t.Type != ThresholdTypeActual && t.Type != ThresholdTypeForecasted {
+			return fmt.Errorf("threshold type must be actual or forecasted, got %q", t.Type)
+		}
+	}
+	return nil
+}
+
+// PeriodBounds returns the start of the period containing t and the start of
+// the next period, both in UTC
+func (b *Budget) PeriodBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	if b.Period == BudgetPeriodQuarterly {
+		firstMonth := time.Month((int(t.Month())-1)/3*3 + 1)
+		start := time.Date(t.Year(), firstMonth, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, 0)
+	}
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// PeriodKey identifies the period containing t, e.g. 2026-10 or 2026-Q4
+func (b *Budget) PeriodKey(t time.Time) string {
+	t = t.UTC()
+	if b.Period == BudgetPeriodQuarterly {
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	}
+	return t.Format("2006-01")
+}
+
+// Filter returns the repository filter for the scope. Tags are not part of
+// the filter and are checked with Matches.
+func (s BudgetScope) Filter() Filter {
+	return Filter{
+		Provider:    s.Provider,
+		ServiceName: s.ServiceName,
+		Region:      s.Region,
+	}
+}
+
+// Matches reports whether a cost record falls within the scope
+func (s BudgetScope) Matches(c *Cost) bool {
+	if s.Provider != "" && c.Provider != s.Provider {
+		return false
+	}
+	if s.ServiceName != "" && c.ServiceName != s.ServiceName {
+		return false
+	}
+	if s.Region != "" && c.Region != s.Region {
+		return false
+	}
+	if s.TagKey == "" {
+		return true
+	}
+
+	value, ok := c.Tag(s.TagKey)
+	if !ok {
+		return false
+	}
+	return s.TagValue == "" || value == s.TagValue
+}
+
+// IsNarrowerThanProvider reports whether the scope selects less than the
+// whole provider, so a provider-wide forecast has to be scaled down
+func (s BudgetScope) IsNarrowerThanProvider() bool {
+	return s.ServiceName != "" || s.Region != "" || s.TagKey != ""
+}
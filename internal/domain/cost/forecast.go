@@ -0,0 +1,72 @@
+package cost
+
+import (
+	"errors"
+	"time"
+)
+
+// CostForecast represents predicted future costs. ConfidenceLevel is the
+// coverage of each day's interval. The bounds of weekly and monthly points
+// and of the total are the sums of the daily bounds, so they cover at least
+// that level and are wider than an exact interval for the sum would be.
+type CostForecast struct {
+	Provider        string          `json:"provider"`
+	Period          string          `json:"period"`
+	Method          string          `json:"method"`      // method actually used, see ForecastMethod constants
+	Granularity     string          `json:"granularity"` // daily, weekly, monthly
+	ForecastedCost  float64         `json:"forecasted_cost"`
+	ConfidenceLevel float64         `json:"confidence_level"` // 0-1, per day; summed bounds cover at least this
+	LowerBound      float64         `json:"lower_bound"`
+	UpperBound      float64         `json:"upper_bound"`
+	Points          []ForecastPoint `json:"points"`
+	HistoryDays     int             `json:"history_days"`  // days of history the model was fitted on
+	MAPE            float64         `json:"mape"`          // backtest mean absolute percentage error
+	BacktestDays    int             `json:"backtest_days"` // 0 when the history was too short to backtest
+	Currency        string          `json:"currency"`
+	EndDate         time.Time       `json:"end_date"`
+}
+
+// ForecastPoint is the forecast for one day, week or month. Date is the
+// first forecast day in the bucket.
+type ForecastPoint struct {
+	Date           time.Time `json:"date"`
+	ForecastedCost float64   `json:"forecasted_cost"`
+	LowerBound     float64   `json:"lower_bound"`
+	UpperBound     float64   `json:"upper_bound"`
+}
+
+// Forecast methods. Auto uses Holt-Winters when there are at least two weeks
+// of history and falls back to a linear trend, then to the mean.
+const (
+	ForecastMethodAuto        = "auto"
+	ForecastMethodHoltWinters = "holt_winters"
+	ForecastMethodLinear      = "linear"
+	ForecastMethodMean        = "mean"
+)
+
+var (
+	ErrInvalidForecastMethod      = errors.New("forecast method must be auto, holt_winters, linear or mean")
+	ErrInvalidForecastGranularity = errors.New("forecast granularity must be daily, weekly or monthly")
+)
+
+// ForecastOptions selects the forecast model and how points are grouped.
+// Empty fields use auto and daily.
+type ForecastOptions struct {
+	Method      string
+	Granularity string
+}
+
+// Validate validates the options
+func (o ForecastOptions) Validate() error {
+	switch o.Method {
+	case "", ForecastMethodAuto, ForecastMethodHoltWinters, ForecastMethodLinear, ForecastMethodMean:
+	default:
+		return ErrInvalidForecastMethod
+	}
+	switch o.Granularity {
+	case "", GranularityDaily, GranularityWeekly, GranularityMonthly:
+	default:
+		return ErrInvalidForecastGranularity
+	}
+	return nil
+}
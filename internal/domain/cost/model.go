@@ -2,6 +2,7 @@ package cost
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -9,6 +10,7 @@ import (
 type Cost struct {
 	ID           string          `json:"id"`
 	UserID       int64           `json:"user_id"`
+	AccountID    int64           `json:"account_id,omitempty"` // provider account the record was synced from
 	ResourceID   *string         `json:"resource_id,omitempty"`
 	Provider     string          `json:"provider"`
 	Region       string          `json:"region,omitempty"`
@@ -37,6 +39,43 @@ type CostSummary struct {
 	ByResource map[string]float64 `json:"by_resource,omitempty"`
 }
 
+// Granularity constants group costs by day, week or month
+const (
+	GranularityDaily   = "daily"
+	GranularityWeekly  = "weekly"
+	GranularityMonthly = "monthly"
+)
+
+// PeriodStart returns the start of the day, week (Monday) or month that
+// contains t, in UTC
+func PeriodStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case GranularityWeekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case GranularityMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Tag returns the value of a tag on the cost record as a string
+func (c *Cost) Tag(key string) (string, bool) {
+	if len(c.Tags) == 0 {
+		return "", false
+	}
+	var tags map[string]interface{}
+	if json.Unmarshal(c.Tags, &tags) != nil {
+		return "", false
+	}
+	value, ok := tags[key]
+	if !ok || value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
 // CostTrend represents cost changes over time
 type CostTrend struct {
 	Period        string          `json:"period"` // daily, weekly, monthly
@@ -54,40 +93,72 @@ type CostDataPoint struct {
 	Cost float64   `json:"cost"`
 }
 
-// CostForecast represents predicted future costs
-type CostForecast struct {
-	Provider        string    `json:"provider"`
-	Period          string    `json:"period"`
-	ForecastedCost  float64   `json:"forecasted_cost"`
-	ConfidenceLevel float64   `json:"confidence_level"` // 0-1
-	LowerBound      float64   `json:"lower_bound"`
-	UpperBound      float64   `json:"upper_bound"`
-	Currency        string    `json:"currency"`
-	EndDate         time.Time `json:"end_date"`
+// DailySeries sums points per UTC day and fills days without cost records
+// with zero, from the first record through the day of through, or through
+// the last record when through is zero. Records after through are ignored.
+func DailySeries(points []CostDataPoint, through time.Time) []CostDataPoint {
+	if len(points) == 0 {
+		return nil
+	}
+
+	totals := make(map[time.Time]float64, len(points))
+	var first, last time.Time
+	for _, p := range points {
+		day := PeriodStart(p.Date, GranularityDaily)
+		totals[day] += p.Cost
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	if !through.IsZero() {
+		last = PeriodStart(through, GranularityDaily)
+	}
+
+	var days []CostDataPoint
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, CostDataPoint{Date: day, Cost: totals[day]})
+	}
+	return days
 }
 
 // CostAnomaly represents unusual cost patterns
 type CostAnomaly struct {
-	ID           string    `json:"id"`
-	UserID       int64     `json:"user_id"`
-	Provider     string    `json:"provider"`
-	ServiceName  string    `json:"service_name"`
-	ResourceID   *string   `json:"resource_id,omitempty"`
-	AnomalyType  string    `json:"anomaly_type"` // spike, drop, unusual_pattern
-	ExpectedCost float64   `json:"expected_cost"`
-	ActualCost   float64   `json:"actual_cost"`
-	Deviation    float64   `json:"deviation"` // percentage deviation
-	Severity     string    `json:"severity"`
-	DetectedAt   time.Time `json:"detected_at"`
-	Status       string    `json:"status"` // open, reviewed, resolved
-	Notes        string    `json:"notes,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           string       `json:"id"`
+	UserID       int64        `json:"user_id"`
+	Provider     string       `json:"provider"`
+	ServiceName  string       `json:"service_name"`
+	ResourceID   *string      `json:"resource_id,omitempty"`
+	AnomalyType  string       `json:"anomaly_type"` // spike, drop, level_shift, unusual_pattern
+	ExpectedCost float64      `json:"expected_cost"`
+	ActualCost   float64      `json:"actual_cost"`
+	Deviation    float64      `json:"deviation"` // percentage deviation
+	Severity     string       `json:"severity"`
+	Fingerprint  string       `json:"fingerprint,omitempty"` // identifies the series, type and day
+	Drivers      []CostDriver `json:"drivers,omitempty"`
+	DetectedAt   time.Time    `json:"detected_at"`
+	Status       string       `json:"status"` // open, reviewed, resolved
+	Notes        string       `json:"notes,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// CostDriver is a service or region that contributed to an anomaly's deviation
+type CostDriver struct {
+	Dimension    string  `json:"dimension"` // service, region
+	Name         string  `json:"name"`
+	ExpectedCost float64 `json:"expected_cost"`
+	ActualCost   float64 `json:"actual_cost"`
+	Delta        float64 `json:"delta"`
+	Share        float64 `json:"share"` // fraction of the anomaly's total deviation
 }
 
 // AnomalyType constants
 const (
 	AnomalyTypeSpike          = "spike"
 	AnomalyTypeDrop           = "drop"
+	AnomalyTypeLevelShift     = "level_shift"
 	AnomalyTypeUnusualPattern = "unusual_pattern"
 )
 
@@ -98,6 +169,12 @@ const (
 	AnomalyStatusResolved = "resolved"
 )
 
+// CostDriver dimensions
+const (
+	DriverDimensionService = "service"
+	DriverDimensionRegion  = "region"
+)
+
 // CostOptimization represents a cost savings opportunity
 type CostOptimization struct {
 	ID               string          `json:"id"`
@@ -112,8 +189,9 @@ type CostOptimization struct {
 	EstimatedSavings float64         `json:"estimated_savings"`
 	SavingsPercent   float64         `json:"savings_percent"`
 	Implementation   string          `json:"implementation"` // easy, moderate, complex
-	Status           string          `json:"status"`         // pending, applied, dismissed
+	Status           string          `json:"status"`         // pending, applied, dismissed, resolved
 	Details          json.RawMessage `json:"details,omitempty"`
+	Fingerprint      string          `json:"fingerprint,omitempty"` // identifies the resource and rule
 	CreatedAt        time.Time       `json:"created_at"`
 	UpdatedAt        time.Time       `json:"updated_at"`
 }
@@ -134,8 +212,17 @@ const (
 	OptStatusPending   = "pending"
 	OptStatusApplied   = "applied"
 	OptStatusDismissed = "dismissed"
+	OptStatusResolved  = "resolved" // no longer found by the analysis
 )
 
+// SyncResult summarizes a cost sync across providers
+type SyncResult struct {
+	ProvidersSynced  []string          `json:"providers_synced"`
+	ProvidersSkipped []string          `json:"providers_skipped,omitempty"`
+	RecordsSynced    int               `json:"records_synced"`
+	Errors           map[string]string `json:"errors,omitempty"`
+}
+
 // Filter contains cost query filters
 type Filter struct {
 	Provider    string
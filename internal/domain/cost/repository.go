@@ -17,13 +17,33 @@ type Repository interface {
 	// Anomalies
 	CreateAnomaly(ctx context.Context, anomaly *CostAnomaly) error
 	GetAnomaly(ctx context.Context, id string) (*CostAnomaly, error)
+	GetAnomalyByFingerprint(ctx context.Context, userID int64, fingerprint string) (*CostAnomaly, error)
 	UpdateAnomaly(ctx context.Context, anomaly *CostAnomaly) error
 	ListAnomalies(ctx context.Context, userID int64, status string, limit, offset int) ([]*CostAnomaly, int64, error)
 
 	// Optimizations
 	CreateOptimization(ctx context.Context, opt *CostOptimization) error
 	GetOptimization(ctx context.Context, id string) (*CostOptimization, error)
+	GetOptimizationByFingerprint(ctx context.Context, userID int64, fingerprint string) (*CostOptimization, error)
 	UpdateOptimization(ctx context.Context, opt *CostOptimization) error
 	ListOptimizations(ctx context.Context, userID int64, status string, limit, offset int) ([]*CostOptimization, int64, error)
 	GetTotalPotentialSavings(ctx context.Context, userID int64) (float64, error)
+
+	// Budgets
+	CreateBudget(ctx context.Context, budget *Budget) error
+	GetBudget(ctx context.Context, userID int64, id string) (*Budget, error)
+	UpdateBudget(ctx context.Context, budget *Budget) error
+	DeleteBudget(ctx context.Context, userID int64, id string) error
+	ListBudgets(ctx context.Context, userID int64) ([]*Budget, error)
+	// RecordBudgetAlert stores an alert unless the same threshold already
+	// fired in the period, and reports whether it was stored
+	RecordBudgetAlert(ctx context.Context, alert *BudgetAlert) (bool, error)
+	ListBudgetAlerts(ctx context.Context, budgetID string, periodKey string) ([]*BudgetAlert, error)
+
+	// Allocation rules
+	CreateAllocationRule(ctx context.Context, rule *AllocationRule) error
+	GetAllocationRule(ctx context.Context, userID int64, id string) (*AllocationRule, error)
+	UpdateAllocationRule(ctx context.Context, rule *AllocationRule) error
+	DeleteAllocationRule(ctx context.Context, userID int64, id string) error
+	ListAllocationRules(ctx context.Context, userID int64) ([]*AllocationRule, error)
 }
@@ -9,14 +9,14 @@ import (
 type Service interface {
 	// Cost Sync
 	SyncCosts(ctx context.Context, userID int64, provider string) error
-	SyncAllProviders(ctx context.Context, userID int64) error
+	SyncAllProviders(ctx context.Context, userID int64) (*SyncResult, error)
 
 	// Cost Queries
 	GetCostOverview(ctx context.Context, userID int64) (*CostOverview, error)
 	GetCostsByProvider(ctx context.Context, userID int64, provider string, filter Filter, period string) (*CostSummary, error)
 	GetResourceCosts(ctx context.Context, userID int64, resourceID string, days int) ([]*Cost, error)
 	GetCostTrends(ctx context.Context, userID int64, provider string, period string) (*CostTrend, error)
-	GetCostForecast(ctx context.Context, userID int64, provider string, days int) (*CostForecast, error)
+	GetCostForecast(ctx context.Context, userID int64, provider string, days int, opts ForecastOptions) (*CostForecast, error)
 
 	// Cost Anomalies
 	DetectAnomalies(ctx context.Context, userID int64, provider string) ([]*CostAnomaly, error)
@@ -29,6 +29,23 @@ type Service interface {
 	UpdateOptimizationStatus(ctx context.Context, id string, status string) error
 	GetPotentialSavings(ctx context.Context, userID int64) (float64, error)
 
+	// Budgets
+	CreateBudget(ctx context.Context, userID int64, budget *Budget) (*Budget, error)
+	GetBudget(ctx context.Context, userID int64, id string) (*Budget, error)
+	UpdateBudget(ctx context.Context, userID int64, id string, budget *Budget) (*Budget, error)
+	DeleteBudget(ctx context.Context, userID int64, id string) error
+	ListBudgets(ctx context.Context, userID int64) ([]*Budget, error)
+	GetBudgetStatus(ctx context.Context, userID int64, id string) (*BudgetStatus, error)
+	EvaluateBudgets(ctx context.Context, userID int64) ([]*BudgetAlert, error)
+
+	// Allocation
+	CreateAllocationRule(ctx context.Context, userID int64, rule *AllocationRule) (*AllocationRule, error)
+	GetAllocationRule(ctx context.Context, userID int64, id string) (*AllocationRule, error)
+	UpdateAllocationRule(ctx context.Context, userID int64, id string, rule *AllocationRule) (*AllocationRule, error)
+	DeleteAllocationRule(ctx context.Context, userID int64, id string) error
+	ListAllocationRules(ctx context.Context, userID int64) ([]*AllocationRule, error)
+	GetCostAllocation(ctx context.Context, userID int64, query AllocationQuery) (*AllocationReport, error)
+
 	// Provider-specific
 	GetAWSCosts(ctx context.Context, userID int64, startDate, endDate time.Time) ([]*Cost, error)
 	GetGCPCosts(ctx context.Context, userID int64, startDate, endDate time.Time) ([]*Cost, error)
@@ -4,16 +4,38 @@ import "time"
 
 // Drift represents a security configuration drift
 type Drift struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"user_id"`
-	ResourceID string    `json:"resource_id"`
-	DriftType  string    `json:"drift_type"`
-	Severity   string    `json:"severity"`
-	Details    string    `json:"details"`
-	DetectedAt time.Time `json:"detected_at"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	ID              int64      `json:"id"`
+	UserID          int64      `json:"user_id"`
+	ResourceID      string     `json:"resource_id"`
+	DriftType       string     `json:"drift_type"`
+	Severity        string     `json:"severity"`
+	Details         string     `json:"details"`
+	DetectedAt      time.Time  `json:"detected_at"`
+	Status          string     `json:"status"`
+	Fingerprint     string     `json:"fingerprint,omitempty"` // identifies repeat detections of the same drift
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty"`
+	OccurrenceCount int        `json:"occurrence_count"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	Changes         []Change   `json:"changes,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+}
+
+// Change is a single field-level difference between the baseline and the
+// current configuration of a resource
+type Change struct {
+	Path       string      `json:"path"`
+	Field      string      `json:"field"`
+	OldValue   interface{} `json:"old_value"`
+	NewValue   interface{} `json:"new_value"`
+	ChangeType string      `json:"change_type"` // added, removed, modified
+	RuleID     string      `json:"rule_id,omitempty"`
+	Severity   string      `json:"severity,omitempty"`
+}
+
+// IsOpen reports whether the drift still needs attention
+func (d *Drift) IsOpen() bool {
+	return d.Status != StatusResolved && d.Status != StatusIgnored
 }
 
 // Drift types
@@ -42,10 +64,14 @@ const (
 	StatusIgnored      = "ignored"
 )
 
+// OpenStatuses are the statuses of drifts that still need attention
+var OpenStatuses = []string{StatusDetected, StatusAcknowledged}
+
 // Filter contains drift filtering options
 type Filter struct {
 	ResourceID string
 	DriftType  string
 	Severity   string
 	Status     string
+	Statuses   []string // matches any of these statuses
 }
@@ -22,6 +22,28 @@ type Repository interface {
 	// ListWithPagination retrieves drifts with filters and pagination
 	ListWithPagination(ctx context.Context, userID int64, filter Filter, limit, offset int) ([]*Drift, int64, error)
 
-	// CountBySeverity counts drifts by severity
-	CountBySeverity(ctx context.Context, userID int64) (map[string]int, error)
+	// GetByFingerprint retrieves the most recent unresolved drift with the given fingerprint
+	GetByFingerprint(ctx context.Context, userID int64, fingerprint string) (*Drift, error)
+
+	// CountBySeverity counts drifts by severity, only those with one of the
+	// given statuses if any are given
+	CountBySeverity(ctx context.Context, userID int64, statuses ...string) (map[string]int, error)
+}
+
+// RuleRepository defines the interface for custom drift rule data access
+type RuleRepository interface {
+	// Create creates a new custom rule
+	Create(ctx context.Context, rule *Rule) error
+
+	// GetByID retrieves a custom rule by its rule ID
+	GetByID(ctx context.Context, userID int64, id string) (*Rule, error)
+
+	// Update updates a custom rule
+	Update(ctx context.Context, rule *Rule) error
+
+	// Delete deletes a custom rule
+	Delete(ctx context.Context, userID int64, id string) error
+
+	// List retrieves all custom rules for a user
+	List(ctx context.Context, userID int64) ([]*Rule, error)
 }
@@ -0,0 +1,66 @@
+package drift
+
+import "time"
+
+// Rule is a declarative drift classification rule. Built-in rules are loaded
+// from versioned rule files; custom rules are stored per user.
+type Rule struct {
+	ID            string          `json:"id" yaml:"id"`
+	UserID        int64           `json:"user_id,omitempty" yaml:"-"`
+	Description   string          `json:"description" yaml:"description"`
+	ResourceTypes []string        `json:"resource_types,omitempty" yaml:"resource_types,omitempty"` // empty or "*" matches every type
+	Field         string          `json:"field" yaml:"field"`                                       // JSONPath-style selector, e.g. $.encryption.enabled or $..acl
+	ChangeTypes   []string        `json:"change_types,omitempty" yaml:"change_types,omitempty"`     // added, removed, modified
+	Old           *ValuePredicate `json:"old,omitempty" yaml:"old,omitempty"`
+	New           *ValuePredicate `json:"new,omitempty" yaml:"new,omitempty"`
+	Severity      string          `json:"severity" yaml:"severity"`
+	DriftType     string          `json:"drift_type" yaml:"drift_type"`
+	Enabled       bool            `json:"enabled" yaml:"-"`
+	Builtin       bool            `json:"builtin" yaml:"-"`
+	Source        string          `json:"source,omitempty" yaml:"-"` // rule file the rule was loaded from
+	CreatedAt     time.Time       `json:"created_at,omitempty" yaml:"-"`
+	UpdatedAt     time.Time       `json:"updated_at,omitempty" yaml:"-"`
+}
+
+// ValuePredicate constrains the old or new value of a change. Every set
+// condition must hold for the predicate to match.
+type ValuePredicate struct {
+	Equals    interface{}   `json:"equals,omitempty" yaml:"equals,omitempty"`
+	NotEquals interface{}   `json:"not_equals,omitempty" yaml:"not_equals,omitempty"`
+	In        []interface{} `json:"in,omitempty" yaml:"in,omitempty"`
+	Contains  []string      `json:"contains,omitempty" yaml:"contains,omitempty"` // any substring, also checked inside lists
+	Matches   string        `json:"matches,omitempty" yaml:"matches,omitempty"`   // regular expression
+	Truthy    *bool         `json:"truthy,omitempty" yaml:"truthy,omitempty"`
+	Exists    *bool         `json:"exists,omitempty" yaml:"exists,omitempty"`
+}
+
+// RuleFile is the on-disk format of a versioned rule file
+type RuleFile struct {
+	Version       int      `json:"version" yaml:"version"`
+	ResourceTypes []string `json:"resource_types,omitempty" yaml:"resource_types,omitempty"` // default for rules that don't set their own
+	Rules         []*Rule  `json:"rules" yaml:"rules"`
+}
+
+// RuleFileVersion is the rule file format version understood by this build
+const RuleFileVersion = 1
+
+// Change types
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// RuleFilter contains rule filtering options
+type RuleFilter struct {
+	ResourceType string
+	Severity     string
+	DriftType    string
+	Source       string // builtin or custom
+}
+
+// Rule sources
+const (
+	RuleSourceBuiltin = "builtin"
+	RuleSourceCustom  = "custom"
+)
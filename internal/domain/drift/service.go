@@ -27,4 +27,25 @@ type Service interface {
 
 	// GetSummary gets drift summary by severity
 	GetSummary(ctx context.Context, userID int64) (map[string]int, error)
+
+	// GetOpenSummary counts drifts that still need attention by severity
+	GetOpenSummary(ctx context.Context, userID int64) (map[string]int, error)
+}
+
+// RuleService defines the interface for managing drift classification rules
+type RuleService interface {
+	// List returns built-in and custom rules visible to a user
+	List(ctx context.Context, userID int64, filter RuleFilter) ([]*Rule, error)
+
+	// Get returns a single rule, custom rules taking precedence over built-ins
+	Get(ctx context.Context, userID int64, id string) (*Rule, error)
+
+	// Create validates and stores a custom rule
+	Create(ctx context.Context, userID int64, rule *Rule) (*Rule, error)
+
+	// Update validates and replaces a custom rule
+	Update(ctx context.Context, userID int64, id string, rule *Rule) (*Rule, error)
+
+	// Delete removes a custom rule
+	Delete(ctx context.Context, userID int64, id string) error
 }
@@ -35,6 +35,33 @@ type JobExecution struct {
 	CreatedAt    time.Time       `json:"created_at"`
 }
 
+// Lease is a time-limited claim on a named piece of work, held by one node.
+// The holder renews it with heartbeats; once it expires any node may take it.
+type Lease struct {
+	Name        string    `json:"name"`
+	Holder      string    `json:"holder"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// LeaseScheduler is held by the node that runs scheduled jobs and
+// cluster-wide background workers
+const LeaseScheduler = "scheduler"
+
+// ExecutionLeaseName returns the name of the lease held by the node running an execution
+func ExecutionLeaseName(executionID string) string {
+	return "execution:" + executionID
+}
+
+// SchedulerStatus describes the scheduler on one node and the leases held cluster-wide
+type SchedulerStatus struct {
+	NodeID    string   `json:"node_id"`
+	IsRunning bool     `json:"is_running"`
+	IsLeader  bool     `json:"is_leader"`
+	Leases    []*Lease `json:"leases"`
+}
+
 // JobType represents different types of scheduled jobs
 type JobType string
 
@@ -115,6 +142,16 @@ type JobResult struct {
 	ErrorCount    int                    `json:"error_count,omitempty"`
 	Details       map[string]interface{} `json:"details,omitempty"`
 	Notifications []string               `json:"notifications_sent,omitempty"`
+	Attempts      []ExecutionAttempt     `json:"attempts,omitempty"`
+}
+
+// ExecutionAttempt records one attempt of a job execution. Failed attempts
+// are retried up to JobConfig.RetryCount times.
+type ExecutionAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int       `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
 }
 
 // IsValid checks if the job type is valid
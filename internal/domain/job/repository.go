@@ -1,6 +1,9 @@
 package job
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the job repository interface
 type Repository interface {
@@ -22,4 +25,12 @@ type Repository interface {
 	GetLatestExecution(ctx context.Context, jobID string) (*JobExecution, error)
 	GetRunningExecutions(ctx context.Context) ([]*JobExecution, error)
 	CleanupOldExecutions(ctx context.Context, olderThan interface{}) (int64, error)
+
+	// Leases
+	// AcquireLease takes the named lease for holder, or extends it if holder
+	// already has it. It reports false if another holder's lease has not expired.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	ListLeases(ctx context.Context) ([]*Lease, error)
+	DeleteExpiredLeases(ctx context.Context) (int64, error)
 }
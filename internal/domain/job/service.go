@@ -26,4 +26,5 @@ type Service interface {
 	Start(ctx context.Context) error
 	Stop() error
 	IsRunning() bool
+	SchedulerStatus(ctx context.Context) (*SchedulerStatus, error)
 }
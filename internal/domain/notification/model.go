@@ -56,6 +56,8 @@ type WebhookDelivery struct {
 	ResponseStatus int            `json:"response_status,omitempty"`
 	ResponseBody   string         `json:"response_body,omitempty"`
 	RetryCount     int            `json:"retry_count"`
+	AttemptedAt    *time.Time     `json:"attempted_at,omitempty"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
 	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
 	CreatedAt      time.Time      `json:"created_at"`
 }
@@ -103,6 +105,8 @@ const (
 	DeliveryStatusSent     DeliveryStatus = "sent"
 	DeliveryStatusFailed   DeliveryStatus = "failed"
 	DeliveryStatusRetrying DeliveryStatus = "retrying"
+	// DeliveryStatusDeadLetter marks a webhook delivery that used up its retries
+	DeliveryStatusDeadLetter DeliveryStatus = "dead_letter"
 )
 
 // EventType represents webhook event types
@@ -117,6 +121,8 @@ const (
 	EventScanCompleted        EventType = "scan.completed"
 	EventJobCompleted         EventType = "job.completed"
 	EventJobFailed            EventType = "job.failed"
+	EventResourceCreated      EventType = "resource.created"
+	EventResourceDeleted      EventType = "resource.deleted"
 )
 
 // Notification represents a notification to be sent
@@ -213,6 +219,8 @@ func AllEventTypes() []EventType {
 		EventScanCompleted,
 		EventJobCompleted,
 		EventJobFailed,
+		EventResourceCreated,
+		EventResourceDeleted,
 	}
 }
 
@@ -225,3 +233,43 @@ func DefaultWebhookRetryConfig() WebhookRetryConfig {
 		BackoffFactor:  2,
 	}
 }
+
+// RetryPolicy returns the webhook's retry configuration, with defaults for
+// any field that is missing or not positive
+func (w *Webhook) RetryPolicy() WebhookRetryConfig {
+	policy := DefaultWebhookRetryConfig()
+	if len(w.RetryConfig) == 0 {
+		return policy
+	}
+
+	var cfg WebhookRetryConfig
+	if err := json.Unmarshal(w.RetryConfig, &cfg); err != nil {
+		return policy
+	}
+	if cfg.MaxRetries > 0 {
+		policy.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.InitialDelayMs > 0 {
+		policy.InitialDelayMs = cfg.InitialDelayMs
+	}
+	if cfg.MaxDelayMs > 0 {
+		policy.MaxDelayMs = cfg.MaxDelayMs
+	}
+	if cfg.BackoffFactor > 0 {
+		policy.BackoffFactor = cfg.BackoffFactor
+	}
+	return policy
+}
+
+// Delay returns how long to wait before retry number attempt (starting at 1)
+func (c WebhookRetryConfig) Delay(attempt int) time.Duration {
+	delay := time.Duration(c.InitialDelayMs) * time.Millisecond
+	maxDelay := time.Duration(c.MaxDelayMs) * time.Millisecond
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= time.Duration(c.BackoffFactor)
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
@@ -28,6 +28,7 @@ type Repository interface {
 	// Webhook Deliveries
 	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
 	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
 	GetPendingDeliveries(ctx context.Context) ([]*WebhookDelivery, error)
 	ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*WebhookDelivery, int64, error)
 }
@@ -24,6 +24,10 @@ type Service interface {
 	ListWebhooks(ctx context.Context, userID int64, limit, offset int) ([]*Webhook, int64, error)
 	TestWebhook(ctx context.Context, id string) error
 
+	// Webhook Deliveries
+	ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*WebhookDelivery, int64, error)
+	RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (*WebhookDelivery, error)
+
 	// Webhook Events
 	TriggerEvent(ctx context.Context, userID int64, eventType EventType, data map[string]interface{}) error
 
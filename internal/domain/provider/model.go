@@ -2,18 +2,42 @@ package provider
 
 import "time"
 
-// Provider represents a cloud provider account
+// Provider represents a cloud provider account. A user can connect several
+// accounts of the same provider, told apart by name.
 type Provider struct {
 	ID           int64      `json:"id"`
 	UserID       int64      `json:"user_id"`
 	Provider     string     `json:"provider"`
+	Name         string     `json:"name"`
 	IsConnected  bool       `json:"is_connected"`
 	LastSynced   *time.Time `json:"last_synced,omitempty"`
+	SyncStatus   string     `json:"sync_status,omitempty"`  // outcome of the last sync: synced, failed
+	SyncMessage  string     `json:"sync_message,omitempty"` // error of the last failed sync
 	Credentials  Credentials `json:"-"`
+	Verification *Verification `json:"verification,omitempty"` // set when just connected, not stored
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
+// Verification is the outcome of checking credentials against the
+// provider's API: who they act as, and which of the read permissions
+// resource sync needs were probed and found missing
+type Verification struct {
+	Identity           string   `json:"identity"` // AWS principal ARN, GCP service account, Azure client ID
+	Account            string   `json:"account"`  // AWS account ID, GCP project, Azure subscription
+	PermissionsChecked []string `json:"permissions_checked"`
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
+}
+
+// DefaultAccountName names the account connected without a name
+const DefaultAccountName = "default"
+
+// Sync outcomes recorded on an account
+const (
+	SyncStatusSynced = "synced"
+	SyncStatusFailed = "failed"
+)
+
 // Credentials contains provider-specific credentials
 type Credentials struct {
 	// AWS
@@ -21,6 +45,14 @@ type Credentials struct {
 	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
 	AWSRegion          string `json:"aws_region,omitempty"`
 
+	// AWS roles assumed in order on top of the access keys, or the instance
+	// profile when no keys are given. The last role is the account identity.
+	AWSRoleChain []AWSRole `json:"aws_role_chain,omitempty"`
+	// When set, member accounts of the AWS Organization are discovered and
+	// connected through this role in each of them
+	AWSOrganizationRoleName   string `json:"aws_organization_role_name,omitempty"`
+	AWSOrganizationExternalID string `json:"aws_organization_external_id,omitempty"`
+
 	// GCP
 	GCPProjectID          string `json:"gcp_project_id,omitempty"`
 	GCPServiceAccountJSON string `json:"gcp_service_account_json,omitempty"`
@@ -36,6 +68,19 @@ type Credentials struct {
 	AzureLocation       string `json:"azure_location,omitempty"`
 }
 
+// AWSRole is an IAM role assumed with STS
+type AWSRole struct {
+	RoleARN    string `json:"role_arn"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+// AWSTarget is an AWS account connected by assuming a role in it
+type AWSTarget struct {
+	Name       string `json:"name,omitempty"` // defaults to the account ID of the role
+	RoleARN    string `json:"role_arn"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
 // Provider types
 const (
 	ProviderAWS          = "aws"
@@ -44,9 +89,11 @@ const (
 	ProviderDigitalOcean = "digitalocean"
 )
 
-// SyncStatus represents the sync status
+// SyncStatus represents the sync status of one provider account
 type SyncStatus struct {
 	Provider     string     `json:"provider"`
+	AccountID    int64      `json:"account_id"`
+	AccountName  string     `json:"account_name"`
 	IsConnected  bool       `json:"is_connected"`
 	LastSynced   *time.Time `json:"last_synced,omitempty"`
 	ResourceCount int       `json:"resource_count,omitempty"`
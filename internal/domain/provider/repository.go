@@ -7,21 +7,37 @@ import (
 
 // Repository defines the interface for provider data access
 type Repository interface {
-	// Upsert creates or updates a provider account
+	// Upsert creates or updates the account with the provider's user, type
+	// and name, and sets its ID
 	Upsert(ctx context.Context, provider *Provider) error
 
-	// GetByProvider retrieves a provider account by provider type
+	// GetByID retrieves a provider account by ID
+	GetByID(ctx context.Context, userID int64, id int64) (*Provider, error)
+
+	// GetByProvider retrieves the first account connected for a provider type
 	GetByProvider(ctx context.Context, userID int64, providerType string) (*Provider, error)
 
 	// List retrieves all provider accounts for a user
 	List(ctx context.Context, userID int64) ([]*Provider, error)
 
+	// ListByProvider retrieves the accounts of one provider type
+	ListByProvider(ctx context.Context, userID int64, providerType string) ([]*Provider, error)
+
 	// Delete deletes a provider account
-	Delete(ctx context.Context, userID int64, providerType string) error
+	Delete(ctx context.Context, userID int64, id int64) error
 
-	// UpdateSyncStatus updates the sync status
-	UpdateSyncStatus(ctx context.Context, userID int64, providerType string, lastSynced time.Time) error
+	// UpdateSyncStatus records the outcome of a sync. The last synced time
+	// only moves on a successful sync.
+	UpdateSyncStatus(ctx context.Context, userID int64, id int64, status, message string, syncedAt time.Time) error
 
 	// UpdateConnectionStatus updates the connection status
-	UpdateConnectionStatus(ctx context.Context, userID int64, providerType string, isConnected bool) error
+	UpdateConnectionStatus(ctx context.Context, userID int64, id int64, isConnected bool) error
+
+	// GetAWSExternalID retrieves the external ID a user's AWS roles must
+	// require when they are assumed with the server's own identity
+	GetAWSExternalID(ctx context.Context, userID int64) (string, error)
+
+	// CreateAWSExternalID stores the user's AWS external ID unless the user
+	// already has one
+	CreateAWSExternalID(ctx context.Context, userID int64, externalID string) error
 }
@@ -1,27 +1,61 @@
 package provider
 
-import "context"
+import (
+	"context"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+)
 
 // Service defines the interface for provider business logic
 type Service interface {
-	// Connect connects a cloud provider account
+	// Connect connects the default account of a cloud provider
 	Connect(ctx context.Context, userID int64, providerType string, credentials Credentials) error
 
-	// Disconnect disconnects a cloud provider account
+	// ConnectAccount connects a named cloud provider account, replacing the
+	// credentials of an account with the same name
+	ConnectAccount(ctx context.Context, userID int64, providerType, name string, credentials Credentials) (*Provider, error)
+
+	// ConnectAWSTargets connects one AWS account per target by assuming the
+	// target's role with the base credentials
+	ConnectAWSTargets(ctx context.Context, userID int64, base Credentials, targets []AWSTarget) ([]*Provider, error)
+
+	// Disconnect disconnects all accounts of a cloud provider
 	Disconnect(ctx context.Context, userID int64, providerType string) error
 
-	// List retrieves all connected providers for a user
+	// DisconnectAccount disconnects one provider account
+	DisconnectAccount(ctx context.Context, userID int64, providerType string, accountID int64) error
+
+	// List retrieves all connected provider accounts for a user
 	List(ctx context.Context, userID int64) ([]*Provider, error)
 
-	// GetByProvider retrieves a specific provider account
+	// ListAccounts retrieves the accounts of one provider type
+	ListAccounts(ctx context.Context, userID int64, providerType string) ([]*Provider, error)
+
+	// GetByProvider retrieves the first account of a provider type
 	GetByProvider(ctx context.Context, userID int64, providerType string) (*Provider, error)
 
-	// TestConnection tests the connection to a provider
-	TestConnection(ctx context.Context, providerType string, credentials Credentials) error
+	// GetAccount retrieves a provider account by ID
+	GetAccount(ctx context.Context, userID int64, providerType string, accountID int64) (*Provider, error)
 
-	// Sync syncs resources from a provider
+	// TestConnection verifies credentials against the provider's API
+	TestConnection(ctx context.Context, userID int64, providerType string, credentials Credentials) (*Verification, error)
+
+	// AWSExternalID retrieves the external ID the user's AWS roles must
+	// require when they are assumed with the server's own identity
+	AWSExternalID(ctx context.Context, userID int64) (string, error)
+
+	// VerifyAccount verifies the stored credentials of a provider account
+	VerifyAccount(ctx context.Context, userID int64, providerType string, accountID int64) (*Verification, error)
+
+	// Sync syncs resources from every account of a provider
 	Sync(ctx context.Context, userID int64, providerType string) error
 
-	// GetSyncStatus gets the sync status for all providers
+	// SyncAccount syncs resources from one provider account
+	SyncAccount(ctx context.Context, userID int64, providerType string, accountID int64) error
+
+	// ListSyncRuns lists the sync changelogs of a provider account, newest first
+	ListSyncRuns(ctx context.Context, userID int64, providerType string, accountID int64, limit int) ([]*resource.SyncRun, error)
+
+	// GetSyncStatus gets the sync status of every provider account
 	GetSyncStatus(ctx context.Context, userID int64) ([]*SyncStatus, error)
 }
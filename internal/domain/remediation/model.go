@@ -36,6 +36,13 @@ const (
 	RemediationTypeManual   RemediationType = "manual"
 )
 
+// Cloud API actions supported by the built-in executors
+const (
+	APIActionS3EnableEncryption   = "s3:enable_bucket_encryption"
+	APIActionS3BlockPublicAccess  = "s3:block_public_access"
+	APIActionEC2RevokeOpenIngress = "ec2:revoke_open_ingress"
+)
+
 // ActionStatus represents the status of a remediation action
 type ActionStatus string
 
@@ -7,6 +7,7 @@ type Resource struct {
 	ID            string    `json:"id"`
 	UserID        int64     `json:"user_id"`
 	Provider      string    `json:"provider"`
+	AccountID     int64     `json:"account_id,omitempty"` // provider account the resource was synced from
 	ResourceID    string    `json:"resource_id"`
 	Name          string    `json:"name"`
 	Type          string    `json:"type"`
@@ -14,6 +15,9 @@ type Resource struct {
 	Status        string    `json:"status"`
 	Configuration string    `json:"configuration,omitempty"` // JSON string of full resource config
 	LastScanned   time.Time `json:"last_scanned,omitempty"`
+	// MissingSince is when a sync first found the resource gone from its
+	// provider. It stays terminated until it reappears or is purged.
+	MissingSince *time.Time `json:"missing_since,omitempty"`
 	CreatedAt     time.Time `json:"created_at,omitempty"`
 	UpdatedAt     time.Time `json:"updated_at,omitempty"`
 }
@@ -21,12 +25,15 @@ type Resource struct {
 // Resource types
 const (
 	TypeEC2Instance      = "ec2-instance"
+	TypeEBSVolume        = "ebs-volume"
 	TypeS3Bucket         = "s3-bucket"
 	TypeRDSInstance      = "rds-instance"
 	TypeLambdaFunction   = "lambda-function"
 	TypeGCEInstance      = "gce-instance"
+	TypeGCEDisk          = "gce-disk"
 	TypeGCSBucket        = "gcs-bucket"
 	TypeAzureVM          = "azure-vm"
+	TypeAzureDisk        = "azure-disk"
 	TypeAzureStorage     = "azure-storage"
 )
 
@@ -42,8 +49,9 @@ const (
 
 // Filter contains resource filtering options
 type Filter struct {
-	Provider string
-	Type     string
-	Region   string
-	Status   string
+	Provider  string
+	AccountID int64
+	Type      string
+	Region    string
+	Status    string
 }
@@ -22,9 +22,21 @@ type Repository interface {
 	// ListByProvider retrieves resources by provider
 	ListByProvider(ctx context.Context, userID int64, provider string) ([]*Resource, error)
 
-	// SaveBatch saves multiple resources (used for sync)
-	SaveBatch(ctx context.Context, userID int64, provider string, resources []*Resource) error
+	// ListByAccount retrieves the resources synced from a provider account,
+	// including terminated ones
+	ListByAccount(ctx context.Context, userID int64, accountID int64) ([]*Resource, error)
+
+	// ApplySync saves the diff of a provider account sync and records its
+	// changelog in one transaction
+	ApplySync(ctx context.Context, run *SyncRun, diff *SyncDiff) error
+
+	// ListSyncRuns lists the sync changelogs of a provider account, newest first
+	ListSyncRuns(ctx context.Context, userID int64, accountID int64, limit int) ([]*SyncRun, error)
 
 	// DeleteByProvider deletes all resources for a provider
 	DeleteByProvider(ctx context.Context, userID int64, provider string) error
+
+	// DeleteByAccount deletes the resources synced from a provider account
+	// and its sync changelogs
+	DeleteByAccount(ctx context.Context, userID int64, accountID int64) error
 }
@@ -0,0 +1,140 @@
+package resource
+
+import "time"
+
+// Change types recorded in a sync changelog
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	// ChangeRemoved marks a resource that vanished from its provider and
+	// was marked terminated
+	ChangeRemoved = "removed"
+	// ChangePurged marks a terminated resource deleted after the grace period
+	ChangePurged = "purged"
+)
+
+// Change is a resource change found by a sync
+type Change struct {
+	ResourceID string   `json:"resource_id"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Change     string   `json:"change"`
+	Fields     []string `json:"fields,omitempty"` // fields that changed on an updated resource
+}
+
+// SyncRun is the changelog of one provider account sync
+type SyncRun struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	AccountID int64     `json:"account_id"`
+	SyncedAt  time.Time `json:"synced_at"`
+	Added     int       `json:"added"`
+	Updated   int       `json:"updated"`
+	Removed   int       `json:"removed"`
+	Purged    int       `json:"purged"`
+	Changes   []Change  `json:"changes"`
+}
+
+// SyncDiff is the difference between the stored resources of a provider
+// account and the resources the provider listed
+type SyncDiff struct {
+	Added     []*Resource
+	Updated   []*Resource
+	Unchanged []*Resource
+	// Removed resources are already marked terminated
+	Removed []*Resource
+	Purged  []*Resource
+
+	fields map[string][]string
+}
+
+// DiffSync compares the stored resources of an account with the resources
+// the provider listed. Listed resources are added, updated or unchanged.
+// Stored resources that were not listed are removed: they are marked
+// terminated and kept until they have been missing for the grace period,
+// then purged. A terminated resource that is listed again is updated.
+func DiffSync(stored, listed []*Resource, now time.Time, gracePeriod time.Duration) *SyncDiff {
+	d := &SyncDiff{fields: make(map[string][]string)}
+
+	byID := make(map[string]*Resource, len(stored))
+	for _, res := range stored {
+		byID[res.ResourceID] = res
+	}
+
+	seen := make(map[string]bool, len(listed))
+	for _, res := range listed {
+		seen[res.ResourceID] = true
+		old, ok := byID[res.ResourceID]
+		if !ok {
+			d.Added = append(d.Added, res)
+			continue
+		}
+		fields := changedFields(old, res)
+		if len(fields) == 0 && old.MissingSince == nil {
+			d.Unchanged = append(d.Unchanged, res)
+			continue
+		}
+		d.fields[res.ResourceID] = fields
+		d.Updated = append(d.Updated, res)
+	}
+
+	for _, res := range stored {
+		if seen[res.ResourceID] {
+			continue
+		}
+		if res.MissingSince == nil {
+			removed := *res
+			removed.Status = StatusTerminated
+			removed.MissingSince = &now
+			d.Removed = append(d.Removed, &removed)
+		} else if now.Sub(*res.MissingSince) >= gracePeriod {
+			d.Purged = append(d.Purged, res)
+		}
+	}
+	return d
+}
+
+// Changes returns the changelog of the diff. Unchanged resources are left
+// out.
+func (d *SyncDiff) Changes() []Change {
+	changes := make([]Change, 0, len(d.Added)+len(d.Updated)+len(d.Removed)+len(d.Purged))
+	add := func(resources []*Resource, change string) {
+		for _, res := range resources {
+			changes = append(changes, Change{
+				ResourceID: res.ResourceID,
+				Name:       res.Name,
+				Type:       res.Type,
+				Change:     change,
+				Fields:     d.fields[res.ResourceID],
+			})
+		}
+	}
+	add(d.Added, ChangeAdded)
+	add(d.Updated, ChangeUpdated)
+	add(d.Removed, ChangeRemoved)
+	add(d.Purged, ChangePurged)
+	return changes
+}
+
+// changedFields returns the synced fields that differ between two versions
+// of a resource
+func changedFields(old, res *Resource) []string {
+	var fields []string
+	if old.Name != res.Name {
+		fields = append(fields, "name")
+	}
+	if old.Type != res.Type {
+		fields = append(fields, "type")
+	}
+	if old.Region != res.Region {
+		fields = append(fields, "region")
+	}
+	if old.Status != res.Status {
+		fields = append(fields, "status")
+	}
+	if old.Configuration != res.Configuration {
+		fields = append(fields, "configuration")
+	}
+	return fields
+}
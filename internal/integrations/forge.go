@@ -0,0 +1,281 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RepositoryRef identifies a Git repository hosted on a forge or on the local filesystem
+type RepositoryRef struct {
+	Host  string // e.g. github.com; empty for local repositories
+	Path  string // owner/repo for hosted repositories, absolute path for local ones
+	Local bool
+}
+
+// String returns the repository in host/path form
+func (r RepositoryRef) String() string {
+	if r.Local {
+		return r.Path
+	}
+	return r.Host + "/" + r.Path
+}
+
+// ParseRepository parses a repository reference. Accepted forms are
+// "https://github.com/owner/repo(.git)", "github.com/owner/repo",
+// "git@github.com:owner/repo.git", "file:///path/to/repo" and a local path.
+func ParseRepository(repository string) (RepositoryRef, error) {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return RepositoryRef{}, fmt.Errorf("repository is required")
+	}
+
+	if strings.HasPrefix(repository, "file://") {
+		return RepositoryRef{Path: strings.TrimPrefix(repository, "file://"), Local: true}, nil
+	}
+	if filepath.IsAbs(repository) || strings.HasPrefix(repository, ".") {
+		abs, err := filepath.Abs(repository)
+		if err != nil {
+			return RepositoryRef{}, err
+		}
+		return RepositoryRef{Path: abs, Local: true}, nil
+	}
+
+	var host, path string
+	switch {
+	case strings.HasPrefix(repository, "git@"):
+		rest := strings.TrimPrefix(repository, "git@")
+		parts := strings.SplitN(rest, ":", 2)
+		if len(parts) != 2 {
+			return RepositoryRef{}, fmt.Errorf("invalid repository %q", repository)
+		}
+		host, path = parts[0], parts[1]
+	case strings.Contains(repository, "://"):
+		u, err := url.Parse(repository)
+		if err != nil {
+			return RepositoryRef{}, fmt.Errorf("invalid repository %q: %w", repository, err)
+		}
+		host, path = u.Host, u.Path
+	default:
+		parts := strings.SplitN(repository, "/", 2)
+		if len(parts) != 2 {
+			return RepositoryRef{}, fmt.Errorf("invalid repository %q", repository)
+		}
+		host, path = parts[0], parts[1]
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return RepositoryRef{}, fmt.Errorf("invalid repository %q: expected host/owner/name", repository)
+	}
+	return RepositoryRef{Host: strings.ToLower(host), Path: path}, nil
+}
+
+// PullRequest describes a pull (or merge) request to open on a forge
+type PullRequest struct {
+	Title      string
+	Body       string
+	HeadBranch string
+	BaseBranch string
+}
+
+// Forge is a Git hosting service that remediation pull requests are opened on
+type Forge interface {
+	// Name returns the forge identifier, e.g. "github"
+	Name() string
+
+	// Handles reports whether the forge hosts the repository
+	Handles(repo RepositoryRef) bool
+
+	// CloneURL returns an authenticated URL that can be cloned and pushed to
+	CloneURL(repo RepositoryRef) string
+
+	// CreatePullRequest opens a pull request and returns its web URL
+	CreatePullRequest(ctx context.Context, repo RepositoryRef, pr PullRequest) (string, error)
+}
+
+// GitHubForge opens pull requests through the GitHub REST API
+type GitHubForge struct {
+	token      string
+	host       string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewGitHubForge creates a GitHub forge. apiURL defaults to the public API;
+// set it for GitHub Enterprise Server (e.g. https://github.example.com/api/v3).
+func NewGitHubForge(token, apiURL string) *GitHubForge {
+	host := "github.com"
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	} else if u, err := url.Parse(apiURL); err == nil && u.Host != "" {
+		host = strings.ToLower(u.Host)
+	}
+	return &GitHubForge{
+		token:      token,
+		host:       host,
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the forge identifier
+func (f *GitHubForge) Name() string { return "github" }
+
+// Handles reports whether the repository is hosted on this GitHub instance
+func (f *GitHubForge) Handles(repo RepositoryRef) bool {
+	return !repo.Local && repo.Host == f.host
+}
+
+// CloneURL returns an HTTPS clone URL authenticated with the token
+func (f *GitHubForge) CloneURL(repo RepositoryRef) string {
+	u := url.URL{Scheme: "https", Host: repo.Host, Path: "/" + repo.Path + ".git"}
+	if f.token != "" {
+		u.User = url.UserPassword("x-access-token", f.token)
+	}
+	return u.String()
+}
+
+// CreatePullRequest opens a pull request and returns its URL
+func (f *GitHubForge) CreatePullRequest(ctx context.Context, repo RepositoryRef, pr PullRequest) (string, error) {
+	body := map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.HeadBranch,
+		"base":  pr.BaseBranch,
+	}
+	var resp struct {
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls", f.apiURL, repo.Path)
+	headers := map[string]string{
+		"Authorization": "Bearer " + f.token,
+		"Accept":        "application/vnd.github+json",
+	}
+	if err := postJSON(ctx, f.httpClient, endpoint, headers, body, &resp); err != nil {
+		return "", fmt.Errorf("failed to create GitHub pull request: %w", err)
+	}
+	return resp.HTMLURL, nil
+}
+
+// GitLabForge opens merge requests through the GitLab REST API
+type GitLabForge struct {
+	token      string
+	baseURL    string
+	host       string
+	httpClient *http.Client
+}
+
+// NewGitLabForge creates a GitLab forge. baseURL defaults to https://gitlab.com.
+func NewGitLabForge(token, baseURL string) *GitLabForge {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	host := "gitlab.com"
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = strings.ToLower(u.Host)
+	}
+	return &GitLabForge{
+		token:      token,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		host:       host,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the forge identifier
+func (f *GitLabForge) Name() string { return "gitlab" }
+
+// Handles reports whether the repository is hosted on this GitLab instance
+func (f *GitLabForge) Handles(repo RepositoryRef) bool {
+	return !repo.Local && repo.Host == f.host
+}
+
+// CloneURL returns an HTTPS clone URL authenticated with the token
+func (f *GitLabForge) CloneURL(repo RepositoryRef) string {
+	u := url.URL{Scheme: "https", Host: repo.Host, Path: "/" + repo.Path + ".git"}
+	if f.token != "" {
+		u.User = url.UserPassword("oauth2", f.token)
+	}
+	return u.String()
+}
+
+// CreatePullRequest opens a merge request and returns its URL
+func (f *GitLabForge) CreatePullRequest(ctx context.Context, repo RepositoryRef, pr PullRequest) (string, error) {
+	body := map[string]string{
+		"title":         pr.Title,
+		"description":   pr.Body,
+		"source_branch": pr.HeadBranch,
+		"target_branch": pr.BaseBranch,
+	}
+	var resp struct {
+		WebURL string `json:"web_url"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests", f.baseURL, url.PathEscape(repo.Path))
+	headers := map[string]string{"PRIVATE-TOKEN": f.token}
+	if err := postJSON(ctx, f.httpClient, endpoint, headers, body, &resp); err != nil {
+		return "", fmt.Errorf("failed to create GitLab merge request: %w", err)
+	}
+	return resp.WebURL, nil
+}
+
+// LocalForge treats a repository on the local filesystem (usually a bare
+// repository) as a forge. Pushing the branch is the whole "pull request";
+// the returned URL points at the branch. It is meant for tests and for
+// air-gapped setups that review branches by other means.
+type LocalForge struct{}
+
+// NewLocalForge creates a local forge
+func NewLocalForge() *LocalForge { return &LocalForge{} }
+
+// Name returns the forge identifier
+func (f *LocalForge) Name() string { return "local" }
+
+// Handles reports whether the repository is on the local filesystem
+func (f *LocalForge) Handles(repo RepositoryRef) bool { return repo.Local }
+
+// CloneURL returns the repository path
+func (f *LocalForge) CloneURL(repo RepositoryRef) string { return repo.Path }
+
+// CreatePullRequest returns a file URL for the pushed branch
+func (f *LocalForge) CreatePullRequest(ctx context.Context, repo RepositoryRef, pr PullRequest) (string, error) {
+	return "file://" + repo.Path + "#" + pr.HeadBranch, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, out)
+}
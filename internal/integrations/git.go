@@ -0,0 +1,131 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GitClient wraps the git command line tool
+type GitClient struct {
+	gitPath     string
+	authorName  string
+	authorEmail string
+}
+
+// NewGitClient creates a git client. Commits are authored as the given
+// name and email.
+func NewGitClient(gitPath, authorName, authorEmail string) *GitClient {
+	if gitPath == "" {
+		gitPath = "git"
+	}
+	if authorName == "" {
+		authorName = "InfraAudit"
+	}
+	if authorEmail == "" {
+		authorEmail = "remediation@infraaudit.local"
+	}
+	return &GitClient{
+		gitPath:     gitPath,
+		authorName:  authorName,
+		authorEmail: authorEmail,
+	}
+}
+
+// Clone clones the repository into dir. Credentials in cloneURL are removed
+// from any error message.
+func (g *GitClient) Clone(ctx context.Context, cloneURL, dir, branch string) error {
+	args := []string{"clone", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, cloneURL, dir)
+
+	if _, err := g.Run(ctx, "", args...); err != nil {
+		return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), cloneURL, redactURL(cloneURL)))
+	}
+	return nil
+}
+
+// CurrentBranch returns the branch checked out in dir
+func (g *GitClient) CurrentBranch(ctx context.Context, dir string) (string, error) {
+	return g.Run(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// CreateBranch creates and checks out a new branch
+func (g *GitClient) CreateBranch(ctx context.Context, dir, branch string) error {
+	_, err := g.Run(ctx, dir, "checkout", "-b", branch)
+	return err
+}
+
+// ApplyPatch applies a unified diff to the working tree
+func (g *GitClient) ApplyPatch(ctx context.Context, dir, patch string) error {
+	cmd := g.command(ctx, dir, "apply", "--whitespace=nowarn", "-")
+	cmd.Stdin = strings.NewReader(patch)
+	return runCommand(cmd, "apply")
+}
+
+// CommitAll stages every change in the working tree and commits it. It
+// returns an error if there is nothing to commit.
+func (g *GitClient) CommitAll(ctx context.Context, dir, message string) error {
+	if _, err := g.Run(ctx, dir, "add", "-A"); err != nil {
+		return err
+	}
+	status, err := g.Run(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		return fmt.Errorf("nothing to commit: repository already matches the approved configuration")
+	}
+	_, err = g.Run(ctx, dir,
+		"-c", "user.name="+g.authorName,
+		"-c", "user.email="+g.authorEmail,
+		"commit", "-m", message)
+	return err
+}
+
+// Push pushes the branch to origin
+func (g *GitClient) Push(ctx context.Context, dir, branch string) error {
+	_, err := g.Run(ctx, dir, "push", "origin", branch)
+	return err
+}
+
+// Run runs a git command in dir and returns its trimmed standard output
+func (g *GitClient) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := g.command(ctx, dir, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := runCommand(cmd, args[0]); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (g *GitClient) command(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, g.gitPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	return cmd
+}
+
+func runCommand(cmd *exec.Cmd, name string) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,240 @@
+// Package forecast fits simple time series models to evenly spaced values
+// and projects them forward with prediction intervals.
+package forecast
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInsufficientData is returned when a series is too short for a model
+var ErrInsufficientData = errors.New("insufficient data for forecast model")
+
+// Prediction is a forecast value with the standard error of its prediction
+type Prediction struct {
+	Value  float64
+	StdErr float64
+}
+
+// Model is a fitted forecasting model
+type Model interface {
+	// Forecast returns predictions for the next h steps after the series
+	Forecast(h int) []Prediction
+}
+
+// Z95 is the normal quantile for a two-sided 95% prediction interval
+const Z95 = 1.959964
+
+// Mean fits the series mean. Prediction errors are the residual standard
+// deviation widened for the uncertainty of the mean itself.
+func Mean(y []float64) (Model, error) {
+	if len(y) == 0 {
+		return nil, ErrInsufficientData
+	}
+	mean := 0.0
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+
+	sigma := 0.0
+	if len(y) > 1 {
+		var ss float64
+		for _, v := range y {
+			ss += (v - mean) * (v - mean)
+		}
+		sigma = math.Sqrt(ss / float64(len(y)-1))
+	}
+	return &meanModel{mean: mean, sigma: sigma, n: len(y)}, nil
+}
+
+type meanModel struct {
+	mean, sigma float64
+	n           int
+}
+
+func (m *meanModel) Forecast(h int) []Prediction {
+	stdErr := m.sigma * math.Sqrt(1+1/float64(m.n))
+	out := make([]Prediction, h)
+	for i := range out {
+		out[i] = Prediction{Value: m.mean, StdErr: stdErr}
+	}
+	return out
+}
+
+// Linear fits an ordinary least squares trend line. Prediction errors grow
+// with the distance from the middle of the series.
+func Linear(y []float64) (Model, error) {
+	n := len(y)
+	if n < 3 {
+		return nil, ErrInsufficientData
+	}
+
+	xMean := float64(n-1) / 2
+	yMean := 0.0
+	for _, v := range y {
+		yMean += v
+	}
+	yMean /= float64(n)
+
+	var sxx, sxy float64
+	for i, v := range y {
+		dx := float64(i) - xMean
+		sxx += dx * dx
+		sxy += dx * (v - yMean)
+	}
+	slope := sxy / sxx
+	intercept := yMean - slope*xMean
+
+	var sse float64
+	for i, v := range y {
+		r := v - (intercept + slope*float64(i))
+		sse += r * r
+	}
+	sigma := math.Sqrt(sse / float64(n-2))
+
+	return &linearModel{intercept: intercept, slope: slope, sigma: sigma, n: n, xMean: xMean, sxx: sxx}, nil
+}
+
+type linearModel struct {
+	intercept, slope, sigma float64
+	n                       int
+	xMean, sxx              float64
+}
+
+func (m *linearModel) Forecast(h int) []Prediction {
+	out := make([]Prediction, h)
+	for i := range out {
+		x := float64(m.n + i)
+		dx := x - m.xMean
+		out[i] = Prediction{
+			Value:  m.intercept + m.slope*x,
+			StdErr: m.sigma * math.Sqrt(1+1/float64(m.n)+dx*dx/m.sxx),
+		}
+	}
+	return out
+}
+
+// HoltWinters fits additive Holt-Winters exponential smoothing with a trend
+// and a seasonal cycle of the given period. The smoothing parameters are
+// chosen by a grid search minimizing the one-step-ahead squared error, and
+// the residuals of those one-step forecasts give the prediction errors.
+// At least two full seasons are required.
+func HoltWinters(y []float64, period int) (Model, error) {
+	if period < 2 || len(y) < 2*period {
+		return nil, ErrInsufficientData
+	}
+
+	grid := []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.7, 0.9}
+	var best *holtWintersModel
+	for _, alpha := range grid {
+		for _, beta := range grid[:4] {
+			for _, gamma := range grid {
+				m := fitHoltWinters(y, period, alpha, beta, gamma)
+				if best == nil || m.sse < best.sse {
+					best = m
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+type holtWintersModel struct {
+	alpha, beta, gamma float64
+	period             int
+	level, trend       float64
+	season             []float64 // seasonal components for the next period steps
+	sse, sigma         float64
+}
+
+func fitHoltWinters(y []float64, period int, alpha, beta, gamma float64) *holtWintersModel {
+	// Initial level and trend from the first two seasons, initial seasonal
+	// components from the first season's deviations from its mean
+	var first, second float64
+	for i := 0; i < period; i++ {
+		first += y[i]
+		second += y[period+i]
+	}
+	first /= float64(period)
+	second /= float64(period)
+
+	level := first
+	trend := (second - first) / float64(period)
+	season := make([]float64, period)
+	for i := 0; i < period; i++ {
+		season[i] = y[i] - first
+	}
+
+	var sse float64
+	var count int
+	for t := period; t < len(y); t++ {
+		s := season[t%period]
+		predicted := level + trend + s
+		residual := y[t] - predicted
+		sse += residual * residual
+		count++
+
+		prevLevel := level
+		level = alpha*(y[t]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		season[t%period] = gamma*(y[t]-level) + (1-gamma)*s
+	}
+
+	// Rotate so season[0] applies to the first step after the series
+	next := make([]float64, period)
+	for i := 0; i < period; i++ {
+		next[i] = season[(len(y)+i)%period]
+	}
+
+	return &holtWintersModel{
+		alpha: alpha, beta: beta, gamma: gamma,
+		period: period,
+		level:  level,
+		trend:  trend,
+		season: next,
+		sse:    sse,
+		sigma:  math.Sqrt(sse / float64(count)),
+	}
+}
+
+// Forecast uses the analytical variance of additive Holt-Winters: the
+// h-step error variance is sigma^2 * (1 + sum of c_j^2 for j < h) with
+// c_j = alpha*(1 + j*beta) + gamma when j is a whole number of seasons.
+func (m *holtWintersModel) Forecast(h int) []Prediction {
+	out := make([]Prediction, h)
+	var sumC2 float64
+	for i := range out {
+		step := i + 1
+		out[i] = Prediction{
+			Value:  m.level + float64(step)*m.trend + m.season[i%m.period],
+			StdErr: m.sigma * math.Sqrt(1+sumC2),
+		}
+
+		c := m.alpha * (1 + float64(step)*m.beta)
+		if step%m.period == 0 {
+			c += m.gamma
+		}
+		sumC2 += c * c
+	}
+	return out
+}
+
+// MAPE returns the mean absolute percentage error of predicted against
+// actual values, skipping periods with no actual value. It reports false
+// when no period could be compared.
+func MAPE(actual, predicted []float64) (float64, bool) {
+	var sum float64
+	var n int
+	for i := 0; i < len(actual) && i < len(predicted); i++ {
+		if actual[i] == 0 {
+			continue
+		}
+		sum += math.Abs(actual[i]-predicted[i]) / math.Abs(actual[i])
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n) * 100, true
+}
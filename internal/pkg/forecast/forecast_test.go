@@ -0,0 +1,73 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+// weeklySeries returns n days of a rising trend with a weekly pattern and
+// deterministic noise
+func weeklySeries(n int) []float64 {
+	pattern := []float64{10, 12, 11, 13, 9, -25, -30}
+	y := make([]float64, n)
+	for i := range y {
+		y[i] = 100 + 0.5*float64(i) + pattern[i%7] + float64(i%3) - 1
+	}
+	return y
+}
+
+func TestHoltWinters_TracksTrendAndSeason(t *testing.T) {
+	y := weeklySeries(63)
+	model, err := HoltWinters(y, 7)
+	if err != nil {
+		t.Fatalf("HoltWinters() error = %v", err)
+	}
+
+	future := weeklySeries(77)[63:]
+	predictions := model.Forecast(len(future))
+	predicted := make([]float64, len(predictions))
+	for i, p := range predictions {
+		predicted[i] = p.Value
+		if p.StdErr <= 0 {
+			t.Fatalf("step %d StdErr = %v, want positive", i+1, p.StdErr)
+		}
+		if i > 0 && p.StdErr < predictions[i-1].StdErr {
+			t.Errorf("StdErr shrinks from step %d to %d", i, i+1)
+		}
+	}
+
+	mape, ok := MAPE(future, predicted)
+	if !ok || mape > 5 {
+		t.Errorf("MAPE = %v, want under 5%% on a clean seasonal trend", mape)
+	}
+}
+
+func TestHoltWinters_InsufficientData(t *testing.T) {
+	if _, err := HoltWinters(weeklySeries(13), 7); err != ErrInsufficientData {
+		t.Errorf("HoltWinters() error = %v, want ErrInsufficientData", err)
+	}
+}
+
+func TestLinear(t *testing.T) {
+	model, err := Linear([]float64{10, 12, 14, 16})
+	if err != nil {
+		t.Fatalf("Linear() error = %v", err)
+	}
+	p := model.Forecast(2)
+	if math.Abs(p[0].Value-18) > 1e-9 || math.Abs(p[1].Value-20) > 1e-9 {
+		t.Errorf("Forecast() = %+v, want 18 and 20", p)
+	}
+	if p[0].StdErr != 0 {
+		t.Errorf("StdErr = %v, want 0 for a perfect fit", p[0].StdErr)
+	}
+}
+
+func TestMAPE(t *testing.T) {
+	mape, ok := MAPE([]float64{100, 0, 50}, []float64{110, 5, 40})
+	if !ok || math.Abs(mape-15) > 1e-9 {
+		t.Errorf("MAPE() = %v, %v, want 15 skipping the zero actual", mape, ok)
+	}
+	if _, ok := MAPE([]float64{0}, []float64{1}); ok {
+		t.Error("MAPE() of only zero actuals should not be ok")
+	}
+}
@@ -0,0 +1,300 @@
+// Package pdf writes simple text documents, such as reports, as PDF. Text is
+// set in the standard Helvetica fonts, which every PDF reader provides, so
+// no fonts are embedded and no external tools are needed.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 page size and margins, in points
+const (
+	PageWidth    = 595.28
+	PageHeight   = 841.89
+	Margin       = 50.0
+	ContentWidth = PageWidth - 2*Margin
+)
+
+// footerHeight is kept free at the bottom of every page for the page footer
+const footerHeight = 20.0
+
+// Style is the font and color of a run of text
+type Style struct {
+	Size  float64
+	Bold  bool
+	Color [3]float64 // RGB, 0-1
+}
+
+// Predefined styles
+var (
+	Title   = Style{Size: 18, Bold: true}
+	Heading = Style{Size: 13, Bold: true}
+	Body    = Style{Size: 9.5}
+	Strong  = Style{Size: 9.5, Bold: true}
+	Small   = Style{Size: 8, Color: [3]float64{0.35, 0.35, 0.35}}
+)
+
+// Document is a PDF document under construction. Content flows from the top
+// of the first page and new pages are started as needed.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// New creates an empty document. The title is stored in the document
+// information and repeated in the footer of every page.
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+// ensure starts a new page unless height points fit on the current one
+func (d *Document) ensure(height float64) {
+	if d.y-height < Margin+footerHeight {
+		d.newPage()
+	}
+}
+
+func lineHeight(style Style) float64 {
+	return style.Size * 1.35
+}
+
+// Text writes a paragraph, wrapped to the content width. Newlines start
+// new lines.
+func (d *Document) Text(text string, style Style) {
+	lines := Wrap(text, style, ContentWidth)
+	for _, line := range lines {
+		d.ensure(lineHeight(style))
+		d.y -= lineHeight(style)
+		d.show(Margin, d.y, line, style)
+	}
+}
+
+// Row writes a table row. Each cell is wrapped to its column width and the
+// row is as tall as its tallest cell. A row is never split across pages.
+func (d *Document) Row(cells []string, widths []float64, style Style) {
+	columns := make([][]string, len(cells))
+	lines := 1
+	for i, cell := range cells {
+		columns[i] = Wrap(cell, style, widths[i]-6)
+		if len(columns[i]) > lines {
+			lines = len(columns[i])
+		}
+	}
+
+	height := float64(lines) * lineHeight(style)
+	d.ensure(height + 4)
+	x := Margin
+	for i, column := range columns {
+		y := d.y
+		for _, line := range column {
+			y -= lineHeight(style)
+			d.show(x, y, line, style)
+		}
+		x += widths[i]
+	}
+	d.y -= height + 2
+	d.rule(0.9)
+	d.y -= 2
+}
+
+// Gap adds vertical space
+func (d *Document) Gap(points float64) {
+	d.y -= points
+}
+
+// Rule draws a horizontal line across the content width
+func (d *Document) Rule() {
+	d.ensure(4)
+	d.y -= 2
+	d.rule(0.6)
+	d.y -= 2
+}
+
+func (d *Document) rule(gray float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		gray, Margin, d.y, PageWidth-Margin, d.y)
+}
+
+func (d *Document) show(x, y float64, text string, style Style) {
+	font := "F1"
+	if style.Bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT %.3f %.3f %.3f rg /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		style.Color[0], style.Color[1], style.Color[2], font, style.Size, x, y, escape(text))
+}
+
+// Bytes renders the document, adding a footer with the title and page
+// number to every page
+func (d *Document) Bytes() []byte {
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "BT 0.35 0.35 0.35 rg /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n", Margin, Margin-10, escape(d.title))
+		fmt.Fprintf(page, "BT 0.35 0.35 0.35 rg /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n",
+			PageWidth-Margin-Width(footer, Style{Size: 8}), Margin-10, footer)
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed; each page adds a page and a content object
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (InfraAudit) >>", escape(d.title)))
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// Wrap breaks text into lines no wider than width points. Words longer
+// than a line are broken mid-word.
+func Wrap(text string, style Style, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if Width(candidate, style) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for Width(word, style) > width {
+				n := fit(word, style, width)
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit returns how many leading bytes of word fit in width, at least one
+// character
+func fit(word string, style Style, width float64) int {
+	n := 0
+	for i, r := range word {
+		if i > 0 && Width(word[:i+utf8.RuneLen(r)], style) > width {
+			break
+		}
+		n = i + utf8.RuneLen(r)
+	}
+	return n
+}
+
+// Width returns the width of text in points
+func Width(text string, style Style) float64 {
+	widths := &helvetica
+	if style.Bold {
+		widths = &helveticaBold
+	}
+	total := 0
+	for _, c := range []byte(toLatin1(text)) {
+		if c >= 32 && c <= 126 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * style.Size / 1000
+}
+
+// escape encodes text as the body of a PDF string literal
+func escape(text string) string {
+	var b strings.Builder
+	for _, c := range []byte(toLatin1(text)) {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32:
+			b.WriteByte(' ')
+		case c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// toLatin1 maps text to single bytes. Characters outside Latin-1 become "?".
+func toLatin1(text string) string {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '–' || r == '—':
+			b = append(b, '-')
+		case r == '‘' || r == '’':
+			b = append(b, '\'')
+		case r == '“' || r == '”':
+			b = append(b, '"')
+		case r < 256:
+			b = append(b, byte(r))
+		default:
+			b = append(b, '?')
+		}
+	}
+	return string(b)
+}
+
+// Glyph widths of the printable ASCII characters (32-126) in thousandths
+// of the font size, from the Adobe font metrics of the standard fonts
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
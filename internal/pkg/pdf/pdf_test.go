@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	lines := Wrap("the quick brown fox jumps over the lazy dog\n\nsecond paragraph", Body, 100)
+	for _, line := range lines {
+		if Width(line, Body) > 100 {
+			t.Errorf("line %q is %.1f points wide, want at most 100", line, Width(line, Body))
+		}
+	}
+	joined := strings.Join(lines, "|")
+	if joined != "the quick brown fox|jumps over the lazy|dog||second paragraph" {
+		t.Errorf("Wrap() = %q", joined)
+	}
+
+	long := Wrap("arn:aws:s3:::a-bucket-name-that-is-much-too-long-for-one-line", Body, 60)
+	if len(long) < 2 || strings.Join(long, "") != "arn:aws:s3:::a-bucket-name-that-is-much-too-long-for-one-line" {
+		t.Errorf("Wrap() of a long word = %q", long)
+	}
+}
+
+func TestDocument_Bytes(t *testing.T) {
+	doc := New("Report (draft)")
+	doc.Text("Compliance Report", Title)
+	for i := 0; i < 120; i++ {
+		doc.Row([]string{fmt.Sprintf("%d", i), "Control with a (parenthesized) title", "failed"}, []float64{40, 300, 155}, Body)
+	}
+	out := doc.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("document is not framed as a PDF")
+	}
+	if len(doc.pages) < 2 {
+		t.Fatalf("got %d pages, want the rows to flow onto a second page", len(doc.pages))
+	}
+	if !bytes.Contains(out, []byte(`(Control with a \(parenthesized\) title)`)) {
+		t.Error("parentheses in text are not escaped")
+	}
+	if !bytes.Contains(out, []byte(fmt.Sprintf("(Page 2 of %d)", len(doc.pages)))) {
+		t.Error("page footer is missing")
+	}
+
+	// Every xref entry must point at the start of its object
+	start, err := strconv.Atoi(string(regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)[1]))
+	if err != nil || !bytes.HasPrefix(out[start:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[start:], -1)
+	if len(entries) != 5+2*len(doc.pages) {
+		t.Fatalf("got %d xref entries, want %d", len(entries), 5+2*len(doc.pages))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
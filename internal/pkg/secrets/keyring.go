@@ -0,0 +1,301 @@
+// Package secrets encrypts small secrets, such as cloud provider credentials,
+// for storage at rest. Each secret is sealed with its own AES-256-GCM data
+// key, and the data key is wrapped by a versioned master key. Rotating the
+// master key only requires re-wrapping data keys, and a database dump
+// without the master keys reveals nothing.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size of master and data keys in bytes (AES-256)
+const KeySize = 32
+
+var (
+	ErrUnknownKeyVersion = errors.New("unknown master key version")
+	ErrDecrypt           = errors.New("failed to decrypt secret")
+	ErrKeyfileMissing    = errors.New("credential keyfile is missing")
+)
+
+// Envelope is a sealed secret: the ciphertext, its data key wrapped by a
+// master key, and the version of that master key
+type Envelope struct {
+	Ciphertext []byte
+	DataKey    []byte
+	KeyVersion int
+}
+
+// Keyring holds the master keys by version. New secrets are sealed with the
+// active version; older versions remain available to open existing secrets
+// until they have been re-encrypted.
+type Keyring struct {
+	keys   map[int][]byte
+	active int
+}
+
+// NewKeyring creates a keyring. An active version of 0 selects the highest
+// version.
+func NewKeyring(keys map[int][]byte, active int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one master key is required")
+	}
+	k := &Keyring{keys: make(map[int][]byte, len(keys))}
+	for version, key := range keys {
+		if version < 1 {
+			return nil, fmt.Errorf("master key version must be positive, got %d", version)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key %d must be %d bytes, got %d", version, KeySize, len(key))
+		}
+		k.keys[version] = append([]byte(nil), key...)
+		if active == 0 && version > k.active {
+			k.active = version
+		}
+	}
+	if active != 0 {
+		if _, ok := keys[active]; !ok {
+			return nil, fmt.Errorf("active master key version %d: %w", active, ErrUnknownKeyVersion)
+		}
+		k.active = active
+	}
+	return k, nil
+}
+
+// ActiveVersion returns the version new secrets are sealed with
+func (k *Keyring) ActiveVersion() int {
+	return k.active
+}
+
+// Versions returns the master key versions in ascending order
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keys))
+	for version := range k.keys {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Seal encrypts plaintext under a new data key wrapped by the active master
+// key. The additional data is authenticated but not stored; the same value
+// must be passed to Open.
+func (k *Keyring) Seal(plaintext, additionalData []byte) (*Envelope, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(k.keys[k.active], dataKey, []byte(strconv.Itoa(k.active)))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Ciphertext: ciphertext, DataKey: wrapped, KeyVersion: k.active}, nil
+}
+
+// Open decrypts a sealed secret
+func (k *Keyring) Open(e *Envelope, additionalData []byte) ([]byte, error) {
+	masterKey, ok := k.keys[e.KeyVersion]
+	if !ok {
+		return nil, fmt.Errorf("version %d: %w", e.KeyVersion, ErrUnknownKeyVersion)
+	}
+	dataKey, err := open(masterKey, e.DataKey, []byte(strconv.Itoa(e.KeyVersion)))
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, e.Ciphertext, additionalData)
+}
+
+// seal encrypts with AES-GCM and prepends the random nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// GenerateKey returns a new random master key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	return key, nil
+}
+
+// ParseKeys parses master keys given as comma-separated version:key pairs
+// with base64-encoded keys, e.g. "1:q83v...,2:VGhp..."
+func ParseKeys(spec string) (map[int][]byte, error) {
+	keys := make(map[int][]byte)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		versionStr, encoded, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("master key %q must be version:base64-key", pair)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key version %q", versionStr)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d is not valid base64: %w", version, err)
+		}
+		if _, dup := keys[version]; dup {
+			return nil, fmt.Errorf("master key version %d is given twice", version)
+		}
+		keys[version] = key
+	}
+	return keys, nil
+}
+
+// keyfile is the JSON layout of a local keyfile
+type keyfile struct {
+	Active int               `json:"active"`
+	Keys   map[string]string `json:"keys"` // version -> base64 key
+}
+
+// LoadKeyfile reads a keyring from a local keyfile
+func LoadKeyfile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf keyfile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid keyfile %s: %w", path, err)
+	}
+
+	keys := make(map[int][]byte, len(kf.Keys))
+	for versionStr, encoded := range kf.Keys {
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %q in keyfile %s", versionStr, path)
+		}
+		if keys[version], err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("key %d in keyfile %s is not valid base64: %w", version, path, err)
+		}
+	}
+	return NewKeyring(keys, kf.Active)
+}
+
+// AddKeyfileVersion generates a new master key, adds it to the keyfile as
+// the active version and returns that version. The keyfile is created if it
+// does not exist.
+func AddKeyfileVersion(path string) (int, error) {
+	kf := keyfile{Keys: map[string]string{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &kf); err != nil {
+			return 0, fmt.Errorf("invalid keyfile %s: %w", path, err)
+		}
+		if kf.Keys == nil {
+			kf.Keys = map[string]string{}
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return 0, err
+	}
+
+	version := 1
+	for versionStr := range kf.Keys {
+		if v, err := strconv.Atoi(versionStr); err == nil && v >= version {
+			version = v + 1
+		}
+	}
+	key, err := GenerateKey()
+	if err != nil {
+		return 0, err
+	}
+	kf.Keys[strconv.Itoa(version)] = base64.StdEncoding.EncodeToString(key)
+	kf.Active = version
+
+	out, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	// Write beside the keyfile and rename so a failed write never loses keys
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(out, '\n'), 0o600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return version, nil
+}
+
+// LoadKeyring returns the keyring from master keys when they are given and
+// from the keyfile otherwise. A missing keyfile is created with a new key
+// only when createKeyfile is set, which callers do when no stored secret is
+// encrypted yet; otherwise a lost keyfile would silently be replaced by a key
+// that cannot open anything.
+func LoadKeyring(masterKeys string, activeVersion int, keyfilePath string, createKeyfile bool) (*Keyring, error) {
+	if strings.TrimSpace(masterKeys) != "" {
+		keys, err := ParseKeys(masterKeys)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyring(keys, activeVersion)
+	}
+	if keyfilePath == "" {
+		return nil, errors.New("either master keys or a keyfile must be configured")
+	}
+
+	if _, err := os.Stat(keyfilePath); errors.Is(err, os.ErrNotExist) {
+		if !createKeyfile {
+			return nil, fmt.Errorf("%w: %s does not exist but stored credentials are encrypted; restore it or set the master keys", ErrKeyfileMissing, keyfilePath)
+		}
+		if _, err := AddKeyfileVersion(keyfilePath); err != nil {
+			return nil, fmt.Errorf("failed to create keyfile: %w", err)
+		}
+	}
+	return LoadKeyfile(keyfilePath)
+}
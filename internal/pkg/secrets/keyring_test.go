@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func testKeyring(t *testing.T, versions ...int) *Keyring {
+	t.Helper()
+	keys := make(map[int][]byte, len(versions))
+	for _, v := range versions {
+		key, err := GenerateKey()
+		if err != nil {
+			t.Fatalf("GenerateKey() error = %v", err)
+		}
+		keys[v] = key
+	}
+	k, err := NewKeyring(keys, 0)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return k
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	k := testKeyring(t, 1, 2)
+	if k.ActiveVersion() != 2 {
+		t.Fatalf("ActiveVersion() = %d, want 2", k.ActiveVersion())
+	}
+
+	secret := []byte(`{"aws_secret_access_key":"wJalrXUtnFEMI"}`)
+	e, err := k.Seal(secret, []byte("user:1"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if e.KeyVersion != 2 || bytes.Contains(e.Ciphertext, []byte("wJalrXUtnFEMI")) {
+		t.Fatalf("Seal() = %+v", e)
+	}
+
+	got, err := k.Open(e, []byte("user:1"))
+	if err != nil || !bytes.Equal(got, secret) {
+		t.Fatalf("Open() = %q, %v", got, err)
+	}
+	if _, err := k.Open(e, []byte("user:2")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Open() with other additional data error = %v, want ErrDecrypt", err)
+	}
+
+	tampered := *e
+	tampered.KeyVersion = 1
+	if _, err := k.Open(&tampered, []byte("user:1")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Open() under the wrong key version error = %v, want ErrDecrypt", err)
+	}
+	tampered.KeyVersion = 3
+	if _, err := k.Open(&tampered, []byte("user:1")); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Fatalf("Open() with an unknown key version error = %v, want ErrUnknownKeyVersion", err)
+	}
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	key, _ := GenerateKey()
+	tests := []struct {
+		name   string
+		keys   map[int][]byte
+		active int
+	}{
+		{"no keys", nil, 0},
+		{"short key", map[int][]byte{1: key[:16]}, 0},
+		{"zero version", map[int][]byte{0: key}, 0},
+		{"unknown active version", map[int][]byte{1: key}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.keys, tt.active); err == nil {
+				t.Fatal("NewKeyring() should fail")
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key, _ := GenerateKey()
+	encoded := base64.StdEncoding.EncodeToString(key)
+
+	keys, err := ParseKeys("1:" + encoded + ", 3:" + encoded)
+	if err != nil || len(keys) != 2 || !bytes.Equal(keys[3], key) {
+		t.Fatalf("ParseKeys() = %v, %v", keys, err)
+	}
+	for _, spec := range []string{encoded, "x:" + encoded, "1:not-base64!", "1:" + encoded + ",1:" + encoded} {
+		if _, err := ParseKeys(spec); err == nil {
+			t.Errorf("ParseKeys(%q) should fail", spec)
+		}
+	}
+}
+
+func TestKeyfileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.keys")
+
+	// A missing keyfile is only created when nothing is encrypted yet
+	if _, err := LoadKeyring("", 0, path, false); !errors.Is(err, ErrKeyfileMissing) {
+		t.Fatalf("LoadKeyring() on a missing keyfile with encrypted secrets error = %v, want ErrKeyfileMissing", err)
+	}
+	k1, err := LoadKeyring("", 0, path, true)
+	if err != nil || k1.ActiveVersion() != 1 {
+		t.Fatalf("LoadKeyring() on a missing keyfile = %v, %v", k1, err)
+	}
+	e, err := k1.Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	version, err := AddKeyfileVersion(path)
+	if err != nil || version != 2 {
+		t.Fatalf("AddKeyfileVersion() = %d, %v", version, err)
+	}
+	k2, err := LoadKeyfile(path)
+	if err != nil || k2.ActiveVersion() != 2 || len(k2.Versions()) != 2 {
+		t.Fatalf("LoadKeyfile() = %v, %v", k2, err)
+	}
+	if got, err := k2.Open(e, nil); err != nil || string(got) != "secret" {
+		t.Fatalf("Open() of a secret sealed before rotation = %q, %v", got, err)
+	}
+}
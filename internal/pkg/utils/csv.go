@@ -0,0 +1,16 @@
+package utils
+
+// CSVText neutralizes text for a CSV cell. Spreadsheets run cells starting
+// with =, +, -, @, tab or carriage return as formulas, so such text, which
+// may come from tags or cloud resource names, is prefixed with a quote.
+// Only use it for text columns: it would turn negative numbers into text.
+func CSVText(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,21 @@
+package utils
+
+import "testing"
+
+func TestCSVText(t *testing.T) {
+	tests := map[string]string{
+		"":                    "",
+		"team-a":              "team-a",
+		"=HYPERLINK(\"x\")":   "'=HYPERLINK(\"x\")",
+		"+1":                  "'+1",
+		"-2+3":                "'-2+3",
+		"@SUM(A1)":            "'@SUM(A1)",
+		"\t=1":                "'\t=1",
+		"arn:aws:s3:::bucket": "arn:aws:s3:::bucket",
+	}
+	for in, want := range tests {
+		if got := CSVText(in); got != want {
+			t.Errorf("CSVText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -16,24 +14,20 @@ import (
 	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 )
 
+// AWSCredentials identify an AWS account. Without access keys the default
+// credential chain is used, such as an instance profile. When RoleChain is
+// set, its roles are assumed in order and the last one is the identity used.
 type AWSCredentials struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	Region          string
+	RoleChain       []AWSRole
 }
 
-// AWSListResources fetches EC2 instances and S3 buckets concurrently across regions.
+// AWSListResources fetches EC2 instances, EBS volumes and S3 buckets
+// concurrently across regions.
 func AWSListResources(ctx context.Context, creds AWSCredentials) ([]resource.Resource, error) {
-	var cfg aws.Config
-	var err error
-	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx,
-			awsconfig.WithRegion(nonEmpty(creds.Region, "us-east-1")),
-			awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(creds.AccessKeyID, creds.SecretAccessKey, "")),
-		)
-	} else {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(nonEmpty(creds.Region, "us-east-1")))
-	}
+	cfg, err := loadAWSConfig(ctx, creds)
 	if err != nil {
 		return nil, err
 	}
@@ -65,8 +59,10 @@ func AWSListResources(ctx context.Context, creds AWSCredentials) ([]resource.Res
 			defer wg.Done()
 			defer func() { <-sem }()
 			ec2Resources := fetchEC2InRegion(ctx, cfg, region)
+			volumes := fetchEBSVolumesInRegion(ctx, cfg, region)
 			mu.Lock()
 			resources = append(resources, ec2Resources...)
+			resources = append(resources, volumes...)
 			mu.Unlock()
 		}(region)
 	}
@@ -205,6 +201,66 @@ func buildEC2Configuration(inst types.Instance, tags map[string]string) map[stri
 	return config
 }
 
+// fetchEBSVolumesInRegion lists EBS volumes. The status is the volume state,
+// so unattached volumes are "available".
+func fetchEBSVolumesInRegion(ctx context.Context, cfg aws.Config, region string) []resource.Resource {
+	out := []resource.Resource{}
+	cfgRegional := cfg
+	cfgRegional.Region = region
+	ec2c := ec2.NewFromConfig(cfgRegional)
+	p := ec2.NewDescribeVolumesPaginator(ec2c, &ec2.DescribeVolumesInput{})
+
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			log.Printf("aws ec2 describe volumes error in %s: %v", region, err)
+			break
+		}
+		for _, vol := range page.Volumes {
+			id := ptrString(vol.VolumeId)
+			name := id
+			tags := make(map[string]string)
+			for _, t := range vol.Tags {
+				if t.Key != nil && t.Value != nil {
+					if *t.Key == "Name" {
+						name = *t.Value
+					}
+					tags[*t.Key] = *t.Value
+				}
+			}
+
+			attachments := make([]string, 0, len(vol.Attachments))
+			for _, a := range vol.Attachments {
+				attachments = append(attachments, ptrString(a.InstanceId))
+			}
+			config := map[string]interface{}{
+				"volume_id":   id,
+				"volume_type": string(vol.VolumeType),
+				"size_gb":     ptrInt32(vol.Size),
+				"state":       string(vol.State),
+				"encrypted":   ptrBool(vol.Encrypted),
+				"attachments": attachments,
+				"tags":        tags,
+			}
+			if vol.CreateTime != nil {
+				config["create_time"] = vol.CreateTime.String()
+			}
+			configJSON, _ := json.Marshal(config)
+
+			out = append(out, resource.Resource{
+				ResourceID:    id,
+				Name:          name,
+				Type:          resource.TypeEBSVolume,
+				Provider:      "aws",
+				Region:        region,
+				Status:        string(vol.State),
+				Configuration: string(configJSON),
+			})
+		}
+	}
+	return out
+}
+
 func fetchS3Buckets(ctx context.Context, cfg aws.Config) []resource.Resource {
 	out := []resource.Resource{}
 	s3c := s3.NewFromConfig(cfg)
@@ -307,6 +363,26 @@ func buildS3Configuration(ctx context.Context, s3c *s3.Client, bucketName string
 		config["acl"] = grants
 	}
 
+	// Get lifecycle rules; buckets without any return an error
+	if lcResp, err := s3c.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: &bucketName,
+	}); err == nil && len(lcResp.Rules) > 0 {
+		rules := make([]map[string]interface{}, 0, len(lcResp.Rules))
+		for _, rule := range lcResp.Rules {
+			transitions := make([]string, 0, len(rule.Transitions))
+			for _, t := range rule.Transitions {
+				transitions = append(transitions, string(t.StorageClass))
+			}
+			rules = append(rules, map[string]interface{}{
+				"id":          ptrString(rule.ID),
+				"status":      string(rule.Status),
+				"transitions": transitions,
+				"expiration":  rule.Expiration != nil,
+			})
+		}
+		config["lifecycle_rules"] = rules
+	}
+
 	// Get bucket tags
 	if tagResp, err := s3c.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{
 		Bucket: &bucketName,
@@ -331,6 +407,14 @@ func ptrString(s *string) string {
 	return *s
 }
 
+// ptrInt32 returns the value of an int32 pointer or zero
+func ptrInt32(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
 func nonEmpty(v string, def string) string {
 	if v == "" {
 		return def
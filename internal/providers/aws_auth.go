@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// AWSRole is an IAM role assumed with STS. The external ID is required by
+// roles that third parties are allowed to assume.
+type AWSRole struct {
+	RoleARN    string
+	ExternalID string
+}
+
+// AWSOrganizationAccount is a member account of an AWS Organization
+type AWSOrganizationAccount struct {
+	ID     string
+	Name   string
+	Email  string
+	Status string
+}
+
+const (
+	// awsRoleSessionName identifies our sessions in the target account's CloudTrail
+	awsRoleSessionName = "infraudit"
+	// awsSessionExpiryWindow refreshes assumed role sessions this long
+	// before they expire, so a sync never starts on a dying session
+	awsSessionExpiryWindow = 5 * time.Minute
+	// maxAWSSessions bounds the number of cached role chains
+	maxAWSSessions = 256
+)
+
+// awsSessions caches credential providers by the credentials they were
+// built from. Assumed role sessions are reused across syncs until they are
+// about to expire and are then refreshed by the cache.
+var awsSessions = newAWSSessionCache(maxAWSSessions)
+
+// awsSessionCache is a least recently used cache of credential providers.
+// Entries for credentials that are no longer used, such as those of
+// disconnected accounts or replaced keys, age out once it is full.
+type awsSessionCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // of *awsSessionEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type awsSessionEntry struct {
+	key      string
+	provider aws.CredentialsProvider
+}
+
+func newAWSSessionCache(limit int) *awsSessionCache {
+	return &awsSessionCache{limit: limit, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the cached provider for the key, building and caching it on
+// a miss and evicting the least recently used entry when the cache is full
+func (c *awsSessionCache) get(key string, build func() aws.CredentialsProvider) aws.CredentialsProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*awsSessionEntry).provider
+	}
+
+	p := build()
+	c.entries[key] = c.order.PushFront(&awsSessionEntry{key: key, provider: p})
+	if c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*awsSessionEntry).key)
+	}
+	return p
+}
+
+// AWSRoleARN returns the ARN of a role in an account
+func AWSRoleARN(accountID, roleName string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, roleName)
+}
+
+// loadAWSConfig builds an AWS config from the credentials. The base identity
+// is the static access keys or, without them, the default credential chain
+// (environment, instance profile, task role). Each role in the chain is then
+// assumed in turn with the previous identity.
+func loadAWSConfig(ctx context.Context, creds AWSCredentials) (aws.Config, error) {
+	region := nonEmpty(creds.Region, "us-east-1")
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(creds.AccessKeyID, creds.SecretAccessKey, ""),
+		))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil || len(creds.RoleChain) == 0 {
+		return cfg, err
+	}
+
+	cfg.Credentials = assumeRoleChain(cfg, creds)
+	return cfg, nil
+}
+
+// assumeRoleChain returns the cached credential provider for the last role
+// of the chain, building and caching the chain on first use
+func assumeRoleChain(base aws.Config, creds AWSCredentials) aws.CredentialsProvider {
+	return awsSessions.get(awsSessionKey(creds), func() aws.CredentialsProvider {
+		cfg := base.Copy()
+		for _, role := range creds.RoleChain {
+			assumer := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = awsRoleSessionName
+				if role.ExternalID != "" {
+					o.ExternalID = aws.String(role.ExternalID)
+				}
+			})
+			cfg = cfg.Copy()
+			cfg.Credentials = aws.NewCredentialsCache(assumer, func(o *aws.CredentialsCacheOptions) {
+				o.ExpiryWindow = awsSessionExpiryWindow
+			})
+		}
+		return cfg.Credentials
+	})
+}
+
+// awsSessionKey identifies a base identity and role chain without keeping
+// the secret key in memory as a map key
+func awsSessionKey(creds AWSCredentials) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", creds.AccessKeyID, creds.SecretAccessKey, creds.Region)
+	for _, role := range creds.RoleChain {
+		fmt.Fprintf(h, "\x00%s\x00%s", role.RoleARN, role.ExternalID)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AWSCallerAccountID returns the ID of the account the credentials act in
+func AWSCallerAccountID(ctx context.Context, creds AWSCredentials) (string, error) {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return "", err
+	}
+	out, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Account), nil
+}
+
+// AWSListOrganizationAccounts lists the active member accounts of the AWS
+// Organization the credentials belong to, leaving out the caller's own
+// account. The credentials must act in the management account or a
+// delegated administrator account.
+func AWSListOrganizationAccounts(ctx context.Context, creds AWSCredentials) ([]AWSOrganizationAccount, error) {
+	callerID, err := AWSCallerAccountID(ctx, creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []AWSOrganizationAccount
+	paginator := organizations.NewListAccountsPaginator(organizations.NewFromConfig(cfg), &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization accounts: %w", err)
+		}
+		for _, a := range page.Accounts {
+			id := aws.ToString(a.Id)
+			if a.Status != orgtypes.AccountStatusActive || id == callerID {
+				continue
+			}
+			accounts = append(accounts, AWSOrganizationAccount{
+				ID:     id,
+				Name:   aws.ToString(a.Name),
+				Email:  aws.ToString(a.Email),
+				Status: string(a.Status),
+			})
+		}
+	}
+	return accounts, nil
+}
@@ -8,8 +8,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 
@@ -19,20 +17,10 @@ import (
 // FetchAWSCosts retrieves cost data from AWS Cost Explorer for the last 30 days.
 // AWS Cost Explorer API is only accessible from us-east-1.
 func FetchAWSCosts(ctx context.Context, creds AWSCredentials) ([]cost.Cost, error) {
-	var cfg aws.Config
-	var err error
-
 	// Cost Explorer is only available in us-east-1
-	region := "us-east-1"
-
-	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx,
-			awsconfig.WithRegion(region),
-			awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(creds.AccessKeyID, creds.SecretAccessKey, "")),
-		)
-	} else {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
-	}
+	creds.Region = "us-east-1"
+
+	cfg, err := loadAWSConfig(ctx, creds)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -0,0 +1,243 @@
+package providers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// S3Encryption is the default server-side encryption configuration of a bucket
+type S3Encryption struct {
+	Algorithm        string `json:"algorithm"` // AES256, aws:kms or aws:kms:dsse
+	KMSKeyID         string `json:"kms_key_id,omitempty"`
+	BucketKeyEnabled bool   `json:"bucket_key_enabled,omitempty"`
+}
+
+// S3PublicAccessBlock is the public access block configuration of a bucket
+type S3PublicAccessBlock struct {
+	BlockPublicACLs       bool `json:"block_public_acls"`
+	IgnorePublicACLs      bool `json:"ignore_public_acls"`
+	BlockPublicPolicy     bool `json:"block_public_policy"`
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
+}
+
+// SecurityGroupRule is a single ingress permission of an EC2 security group
+type SecurityGroupRule struct {
+	Protocol  string   `json:"protocol"`
+	FromPort  int32    `json:"from_port"`
+	ToPort    int32    `json:"to_port"`
+	CIDRs     []string `json:"cidrs,omitempty"`
+	IPv6CIDRs []string `json:"ipv6_cidrs,omitempty"`
+}
+
+// AWSGetBucketEncryption returns the bucket's default encryption, or nil if none is configured
+func AWSGetBucketEncryption(ctx context.Context, creds AWSCredentials, bucket string) (*S3Encryption, error) {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s3.NewFromConfig(cfg).GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: &bucket})
+	if err != nil {
+		if isAWSErrorCode(err, "ServerSideEncryptionConfigurationNotFoundError") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if resp.ServerSideEncryptionConfiguration == nil || len(resp.ServerSideEncryptionConfiguration.Rules) == 0 {
+		return nil, nil
+	}
+
+	rule := resp.ServerSideEncryptionConfiguration.Rules[0]
+	enc := &S3Encryption{BucketKeyEnabled: aws.ToBool(rule.BucketKeyEnabled)}
+	if def := rule.ApplyServerSideEncryptionByDefault; def != nil {
+		enc.Algorithm = string(def.SSEAlgorithm)
+		enc.KMSKeyID = aws.ToString(def.KMSMasterKeyID)
+	}
+	return enc, nil
+}
+
+// AWSPutBucketEncryption sets the bucket's default encryption
+func AWSPutBucketEncryption(ctx context.Context, creds AWSCredentials, bucket string, enc S3Encryption) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	def := &s3types.ServerSideEncryptionByDefault{SSEAlgorithm: s3types.ServerSideEncryption(enc.Algorithm)}
+	if enc.KMSKeyID != "" {
+		def.KMSMasterKeyID = aws.String(enc.KMSKeyID)
+	}
+
+	_, err = s3.NewFromConfig(cfg).PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+		Bucket: &bucket,
+		ServerSideEncryptionConfiguration: &s3types.ServerSideEncryptionConfiguration{
+			Rules: []s3types.ServerSideEncryptionRule{{
+				ApplyServerSideEncryptionByDefault: def,
+				BucketKeyEnabled:                   aws.Bool(enc.BucketKeyEnabled),
+			}},
+		},
+	})
+	return err
+}
+
+// AWSDeleteBucketEncryption removes the bucket's default encryption configuration
+func AWSDeleteBucketEncryption(ctx context.Context, creds AWSCredentials, bucket string) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s3.NewFromConfig(cfg).DeleteBucketEncryption(ctx, &s3.DeleteBucketEncryptionInput{Bucket: &bucket})
+	return err
+}
+
+// AWSGetPublicAccessBlock returns the bucket's public access block, or nil if none is configured
+func AWSGetPublicAccessBlock(ctx context.Context, creds AWSCredentials, bucket string) (*S3PublicAccessBlock, error) {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s3.NewFromConfig(cfg).GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: &bucket})
+	if err != nil {
+		if isAWSErrorCode(err, "NoSuchPublicAccessBlockConfiguration") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if resp.PublicAccessBlockConfiguration == nil {
+		return nil, nil
+	}
+
+	pab := resp.PublicAccessBlockConfiguration
+	return &S3PublicAccessBlock{
+		BlockPublicACLs:       ptrBool(pab.BlockPublicAcls),
+		IgnorePublicACLs:      ptrBool(pab.IgnorePublicAcls),
+		BlockPublicPolicy:     ptrBool(pab.BlockPublicPolicy),
+		RestrictPublicBuckets: ptrBool(pab.RestrictPublicBuckets),
+	}, nil
+}
+
+// AWSPutPublicAccessBlock sets the bucket's public access block
+func AWSPutPublicAccessBlock(ctx context.Context, creds AWSCredentials, bucket string, pab S3PublicAccessBlock) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s3.NewFromConfig(cfg).PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket: &bucket,
+		PublicAccessBlockConfiguration: &s3types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(pab.BlockPublicACLs),
+			IgnorePublicAcls:      aws.Bool(pab.IgnorePublicACLs),
+			BlockPublicPolicy:     aws.Bool(pab.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.Bool(pab.RestrictPublicBuckets),
+		},
+	})
+	return err
+}
+
+// AWSDeletePublicAccessBlock removes the bucket's public access block
+func AWSDeletePublicAccessBlock(ctx context.Context, creds AWSCredentials, bucket string) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s3.NewFromConfig(cfg).DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{Bucket: &bucket})
+	return err
+}
+
+// AWSGetSecurityGroupIngress returns the ingress rules of a security group
+func AWSGetSecurityGroupIngress(ctx context.Context, creds AWSCredentials, groupID string) ([]SecurityGroupRule, error) {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ec2.NewFromConfig(cfg).DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		GroupIds: []string{groupID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.SecurityGroups) == 0 {
+		return nil, errors.New("security group not found: " + groupID)
+	}
+
+	var rules []SecurityGroupRule
+	for _, perm := range resp.SecurityGroups[0].IpPermissions {
+		rule := SecurityGroupRule{
+			Protocol: aws.ToString(perm.IpProtocol),
+			FromPort: aws.ToInt32(perm.FromPort),
+			ToPort:   aws.ToInt32(perm.ToPort),
+		}
+		for _, r := range perm.IpRanges {
+			rule.CIDRs = append(rule.CIDRs, aws.ToString(r.CidrIp))
+		}
+		for _, r := range perm.Ipv6Ranges {
+			rule.IPv6CIDRs = append(rule.IPv6CIDRs, aws.ToString(r.CidrIpv6))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// AWSRevokeSecurityGroupIngress removes ingress rules from a security group
+func AWSRevokeSecurityGroupIngress(ctx context.Context, creds AWSCredentials, groupID string, rules []SecurityGroupRule) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = ec2.NewFromConfig(cfg).RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+		GroupId:       &groupID,
+		IpPermissions: toIPPermissions(rules),
+	})
+	return err
+}
+
+// AWSAuthorizeSecurityGroupIngress adds ingress rules to a security group
+func AWSAuthorizeSecurityGroupIngress(ctx context.Context, creds AWSCredentials, groupID string, rules []SecurityGroupRule) error {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = ec2.NewFromConfig(cfg).AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:       &groupID,
+		IpPermissions: toIPPermissions(rules),
+	})
+	return err
+}
+
+func toIPPermissions(rules []SecurityGroupRule) []ec2types.IpPermission {
+	perms := make([]ec2types.IpPermission, 0, len(rules))
+	for _, rule := range rules {
+		perm := ec2types.IpPermission{
+			IpProtocol: aws.String(rule.Protocol),
+			FromPort:   aws.Int32(rule.FromPort),
+			ToPort:     aws.Int32(rule.ToPort),
+		}
+		for _, cidr := range rule.CIDRs {
+			perm.IpRanges = append(perm.IpRanges, ec2types.IpRange{CidrIp: aws.String(cidr)})
+		}
+		for _, cidr := range rule.IPv6CIDRs {
+			perm.Ipv6Ranges = append(perm.Ipv6Ranges, ec2types.Ipv6Range{CidrIpv6: aws.String(cidr)})
+		}
+		perms = append(perms, perm)
+	}
+	return perms
+}
+
+func isAWSErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
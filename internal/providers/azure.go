@@ -127,6 +127,44 @@ func AzureListResources(ctx context.Context, creds AzureCredentials) ([]resource
 				log.Printf("azure vmss client error: %v", err)
 			}
 
+			diskClient, err := armcompute.NewDisksClient(creds.SubscriptionID, cred, nil)
+			if err == nil {
+				diskPager := diskClient.NewListByResourceGroupPager(group, nil)
+				for diskPager.More() {
+					diskPage, err := diskPager.NextPage(ctx)
+					if err != nil {
+						log.Printf("azure list disks error: %v", err)
+						break
+					}
+					for _, disk := range diskPage.Value {
+						region := creds.Location
+						if disk.Location != nil && *disk.Location != "" {
+							region = *disk.Location
+						}
+
+						// The status is the disk state, "Unattached" when no VM uses it
+						config := buildAzureDiskConfiguration(disk)
+						configJSON, _ := json.Marshal(config)
+						status := resource.StatusUnknown
+						if disk.Properties != nil && disk.Properties.DiskState != nil {
+							status = string(*disk.Properties.DiskState)
+						}
+
+						out = append(out, resource.Resource{
+							ResourceID:    ptrStr(disk.ID),
+							Name:          ptrStr(disk.Name),
+							Type:          resource.TypeAzureDisk,
+							Provider:      "azure",
+							Region:        region,
+							Status:        status,
+							Configuration: string(configJSON),
+						})
+					}
+				}
+			} else {
+				log.Printf("azure disk client error: %v", err)
+			}
+
 			stClient, err := armstorage.NewAccountsClient(creds.SubscriptionID, cred, nil)
 			if err == nil {
 				stPager := stClient.NewListByResourceGroupPager(group, nil)
@@ -295,6 +333,40 @@ func buildAzureVMSSConfiguration(vmss *armcompute.VirtualMachineScaleSet) map[st
 	return config
 }
 
+// buildAzureDiskConfiguration creates a configuration object for Azure managed disks
+func buildAzureDiskConfiguration(disk *armcompute.Disk) map[string]interface{} {
+	config := map[string]interface{}{
+		"disk_id":    ptrStr(disk.ID),
+		"name":       ptrStr(disk.Name),
+		"location":   ptrStr(disk.Location),
+		"managed_by": ptrStr(disk.ManagedBy),
+	}
+
+	if disk.SKU != nil && disk.SKU.Name != nil {
+		config["sku"] = string(*disk.SKU.Name)
+	}
+	if disk.Properties != nil {
+		if disk.Properties.DiskSizeGB != nil {
+			config["size_gb"] = *disk.Properties.DiskSizeGB
+		}
+		if disk.Properties.DiskState != nil {
+			config["disk_state"] = string(*disk.Properties.DiskState)
+		}
+	}
+
+	if disk.Tags != nil {
+		tags := make(map[string]string)
+		for k, v := range disk.Tags {
+			if v != nil {
+				tags[k] = *v
+			}
+		}
+		config["tags"] = tags
+	}
+
+	return config
+}
+
 // buildAzureStorageConfiguration creates a comprehensive configuration object for Azure Storage accounts
 func buildAzureStorageConfiguration(acc *armstorage.Account) map[string]interface{} {
 	config := map[string]interface{}{
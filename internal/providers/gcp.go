@@ -73,6 +73,58 @@ func GCPListResources(ctx context.Context, creds GCPCredentials) ([]resource.Res
 		log.Printf("gcp compute client error: %v", err)
 	}
 
+	// Persistent disks; disks without users are not attached to an instance
+	diskClient, err := compute.NewDisksRESTClient(ctx, opts...)
+	if err == nil {
+		defer diskClient.Close()
+		it := diskClient.AggregatedList(ctx, &computepb.AggregatedListDisksRequest{Project: creds.ProjectID})
+		for {
+			pair, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				log.Printf("gcp disks aggregated list error: %v", err)
+				break
+			}
+			if pair.Value == nil {
+				continue
+			}
+			for _, disk := range pair.Value.Disks {
+				status := "attached"
+				if len(disk.GetUsers()) == 0 {
+					status = "unattached"
+				}
+
+				config := map[string]interface{}{
+					"disk_id": strconv.FormatUint(disk.GetId(), 10),
+					"name":    disk.GetName(),
+					"type":    disk.GetType(),
+					"size_gb": disk.GetSizeGb(),
+					"zone":    disk.GetZone(),
+					"status":  disk.GetStatus(),
+					"users":   disk.GetUsers(),
+				}
+				if len(disk.Labels) > 0 {
+					config["labels"] = disk.Labels
+				}
+				configJSON, _ := json.Marshal(config)
+
+				out = append(out, resource.Resource{
+					ResourceID:    strconv.FormatUint(disk.GetId(), 10),
+					Name:          disk.GetName(),
+					Type:          resource.TypeGCEDisk,
+					Provider:      "gcp",
+					Region:        disk.GetZone(),
+					Status:        status,
+					Configuration: string(configJSON),
+				})
+			}
+		}
+	} else {
+		log.Printf("gcp disks client error: %v", err)
+	}
+
 	// Cloud Storage buckets
 	stClient, err := storage.NewClient(ctx, opts...)
 	if err == nil {
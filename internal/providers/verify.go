@@ -0,0 +1,271 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	armcompute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	armresources "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/option"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+)
+
+// permissionProbe makes the cheapest call that needs a permission the sync
+// relies on
+type permissionProbe struct {
+	permission string
+	call       func(ctx context.Context) error
+}
+
+// runProbes runs each probe and records its permission as missing when the
+// provider denies the call. Any other failure aborts verification.
+func runProbes(ctx context.Context, v *provider.Verification, probes []permissionProbe, denied func(error) bool) error {
+	for _, probe := range probes {
+		v.PermissionsChecked = append(v.PermissionsChecked, probe.permission)
+		err := probe.call(ctx)
+		switch {
+		case err == nil:
+		case denied(err):
+			v.MissingPermissions = append(v.MissingPermissions, probe.permission)
+		default:
+			return fmt.Errorf("failed to check %s: %w", probe.permission, err)
+		}
+	}
+	return nil
+}
+
+// AWSVerify checks the credentials with STS GetCallerIdentity and probes the
+// read permissions resource sync needs. EC2 calls are made as dry runs.
+func AWSVerify(ctx context.Context, creds AWSCredentials) (*provider.Verification, error) {
+	cfg, err := loadAWSConfig(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("sts GetCallerIdentity failed: %w", err)
+	}
+	v := &provider.Verification{
+		Identity: aws.ToString(identity.Arn),
+		Account:  aws.ToString(identity.Account),
+	}
+
+	ec2c := ec2.NewFromConfig(cfg)
+	s3c := s3.NewFromConfig(cfg)
+	probes := []permissionProbe{
+		{"ec2:DescribeRegions", func(ctx context.Context) error {
+			_, err := ec2c.DescribeRegions(ctx, &ec2.DescribeRegionsInput{DryRun: aws.Bool(true)})
+			return awsDryRun(err)
+		}},
+		{"ec2:DescribeInstances", func(ctx context.Context) error {
+			_, err := ec2c.DescribeInstances(ctx, &ec2.DescribeInstancesInput{DryRun: aws.Bool(true)})
+			return awsDryRun(err)
+		}},
+		{"ec2:DescribeVolumes", func(ctx context.Context) error {
+			_, err := ec2c.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{DryRun: aws.Bool(true)})
+			return awsDryRun(err)
+		}},
+		{"s3:ListAllMyBuckets", func(ctx context.Context) error {
+			_, err := s3c.ListBuckets(ctx, &s3.ListBucketsInput{MaxBuckets: aws.Int32(1)})
+			return err
+		}},
+	}
+
+	err = runProbes(ctx, v, probes, func(err error) bool {
+		return isAWSErrorCode(err, "UnauthorizedOperation") || isAWSErrorCode(err, "AccessDenied")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// awsDryRun turns the error EC2 returns for an allowed dry run into success
+func awsDryRun(err error) error {
+	if isAWSErrorCode(err, "DryRunOperation") {
+		return nil
+	}
+	return err
+}
+
+// gcpSyncPermissions are the IAM permissions resource sync needs on the project
+var gcpSyncPermissions = []string{
+	"resourcemanager.projects.get",
+	"compute.instances.list",
+	"compute.disks.list",
+	"storage.buckets.list",
+}
+
+// GCPVerify fetches a token for the service account, gets the project and
+// asks the project which of the permissions resource sync needs it holds.
+// Only service account keys are accepted.
+func GCPVerify(ctx context.Context, creds GCPCredentials) (*provider.Verification, error) {
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(creds.ServiceAccountJSON), cloudresourcemanager.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	tokenSource := jwtConfig.TokenSource(ctx)
+	if _, err := tokenSource.Token(); err != nil {
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+
+	crm, err := cloudresourcemanager.NewService(ctx, option.WithTokenSource(tokenSource))
+	if err != nil {
+		return nil, err
+	}
+	project, err := crm.Projects.Get(creds.ProjectID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", creds.ProjectID, err)
+	}
+
+	granted, err := crm.Projects.TestIamPermissions(creds.ProjectID, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: gcpSyncPermissions,
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to test permissions: %w", err)
+	}
+	held := make(map[string]bool, len(granted.Permissions))
+	for _, p := range granted.Permissions {
+		held[p] = true
+	}
+
+	v := &provider.Verification{
+		Identity:           jwtConfig.Email,
+		Account:            project.ProjectId,
+		PermissionsChecked: append([]string(nil), gcpSyncPermissions...),
+	}
+	for _, p := range gcpSyncPermissions {
+		if !held[p] {
+			v.MissingPermissions = append(v.MissingPermissions, p)
+		}
+	}
+	return v, nil
+}
+
+// AzureVerify fetches a token for the service principal, gets the
+// subscription and probes the read permissions resource sync needs
+func AzureVerify(ctx context.Context, creds AzureCredentials) (*provider.Verification, error) {
+	cred, err := azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}}); err != nil {
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+
+	subscription, err := azureGetSubscription(ctx, cred, creds.SubscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription %s: %w", creds.SubscriptionID, err)
+	}
+	v := &provider.Verification{
+		Identity: creds.ClientID,
+		Account:  subscription,
+	}
+
+	rgClient, err := armresources.NewResourceGroupsClient(creds.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	vmClient, err := armcompute.NewVirtualMachinesClient(creds.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	vmssClient, err := armcompute.NewVirtualMachineScaleSetsClient(creds.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	diskClient, err := armcompute.NewDisksClient(creds.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	storageClient, err := armstorage.NewAccountsClient(creds.SubscriptionID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	probes := []permissionProbe{
+		{"Microsoft.Resources/subscriptions/resourceGroups/read", func(ctx context.Context) error {
+			_, err := rgClient.NewListPager(nil).NextPage(ctx)
+			return err
+		}},
+		{"Microsoft.Compute/virtualMachines/read", func(ctx context.Context) error {
+			_, err := vmClient.NewListAllPager(nil).NextPage(ctx)
+			return err
+		}},
+		{"Microsoft.Compute/virtualMachineScaleSets/read", func(ctx context.Context) error {
+			_, err := vmssClient.NewListAllPager(nil).NextPage(ctx)
+			return err
+		}},
+		{"Microsoft.Compute/disks/read", func(ctx context.Context) error {
+			_, err := diskClient.NewListPager(nil).NextPage(ctx)
+			return err
+		}},
+		{"Microsoft.Storage/storageAccounts/read", func(ctx context.Context) error {
+			_, err := storageClient.NewListPager(nil).NextPage(ctx)
+			return err
+		}},
+	}
+
+	err = runProbes(ctx, v, probes, func(err error) bool {
+		var respErr *azcore.ResponseError
+		return errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// azureGetSubscription gets a subscription and returns its display name and
+// ID. The subscriptions client lives in a module we do not otherwise need,
+// so the call is made through the ARM pipeline.
+func azureGetSubscription(ctx context.Context, cred azcore.TokenCredential, subscriptionID string) (string, error) {
+	client, err := arm.NewClient("infraudit", "v1", cred, nil)
+	if err != nil {
+		return "", err
+	}
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(client.Endpoint(), "/subscriptions", url.PathEscape(subscriptionID)))
+	if err != nil {
+		return "", err
+	}
+	query := req.Raw().URL.Query()
+	query.Set("api-version", "2022-12-01")
+	req.Raw().URL.RawQuery = query.Encode()
+
+	resp, err := client.Pipeline().Do(req)
+	if err != nil {
+		return "", err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return "", runtime.NewResponseError(resp)
+	}
+
+	var sub struct {
+		SubscriptionID string `json:"subscriptionId"`
+		DisplayName    string `json:"displayName"`
+	}
+	if err := runtime.UnmarshalAsJSON(resp, &sub); err != nil {
+		return "", err
+	}
+	if sub.DisplayName == "" {
+		return sub.SubscriptionID, nil
+	}
+	return fmt.Sprintf("%s (%s)", sub.DisplayName, sub.SubscriptionID), nil
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+const exceptionColumns = `id, user_id, framework_id, control_id, resource_id, justification, status, requested_by, approved_by, approved_at, rejection_reason, expires_at, expiry_notified_at, created_at, updated_at`
+
+// CreateException creates a new compliance exception
+func (r *ComplianceRepository) CreateException(ctx context.Context, e *compliance.Exception) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	query := `
+		INSERT INTO compliance_exceptions (` + exceptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		e.ID, e.UserID, e.FrameworkID, e.ControlID, e.ResourceID, e.Justification, e.Status,
+		e.RequestedBy, e.ApprovedBy, e.ApprovedAt, e.RejectionReason, e.ExpiresAt, e.ExpiryNotifiedAt,
+		e.CreatedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create exception", err)
+	}
+	return nil
+}
+
+// GetException retrieves an exception owned by a user
+func (r *ComplianceRepository) GetException(ctx context.Context, userID int64, id string) (*compliance.Exception, error) {
+	query := `SELECT ` + exceptionColumns + ` FROM compliance_exceptions WHERE user_id = $1 AND id = $2`
+
+	e, err := scanException(r.db.QueryRowContext(ctx, query, userID, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Exception")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get exception", err)
+	}
+	return e, nil
+}
+
+// UpdateException updates the status, approval and notification fields of an exception
+func (r *ComplianceRepository) UpdateException(ctx context.Context, e *compliance.Exception) error {
+	e.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE compliance_exceptions
+		SET status = $1, approved_by = $2, approved_at = $3, rejection_reason = $4,
+			expires_at = $5, expiry_notified_at = $6, updated_at = $7
+		WHERE user_id = $8 AND id = $9
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		e.Status, e.ApprovedBy, e.ApprovedAt, e.RejectionReason,
+		e.ExpiresAt, e.ExpiryNotifiedAt, e.UpdatedAt,
+		e.UserID, e.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update exception", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Exception")
+	}
+	return nil
+}
+
+// ListExceptions lists the exceptions of a user, soonest expiry first
+func (r *ComplianceRepository) ListExceptions(ctx context.Context, userID int64, filter compliance.ExceptionFilter) ([]*compliance.Exception, error) {
+	query := `SELECT ` + exceptionColumns + ` FROM compliance_exceptions WHERE user_id = $1`
+	args := []interface{}{userID}
+
+	for _, f := range []struct{ column, value string }{
+		{"framework_id", filter.FrameworkID},
+		{"control_id", filter.ControlID},
+		{"resource_id", filter.ResourceID},
+		{"status", filter.Status},
+	} {
+		if f.value != "" {
+			args = append(args, f.value)
+			query += fmt.Sprintf(" AND %s = $%d", f.column, len(args))
+		}
+	}
+	query += " ORDER BY expires_at, created_at"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list exceptions", err)
+	}
+	defer rows.Close()
+
+	var exceptions []*compliance.Exception
+	for rows.Next() {
+		e, err := scanException(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan exception", err)
+		}
+		exceptions = append(exceptions, e)
+	}
+	return exceptions, rows.Err()
+}
+
+// exceptionScanner is implemented by *sql.Row and *sql.Rows
+type exceptionScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanException scans a row selected with exceptionColumns
+func scanException(row exceptionScanner) (*compliance.Exception, error) {
+	e := &compliance.Exception{}
+	var resourceID, rejectionReason sql.NullString
+	var approvedBy sql.NullInt64
+	var approvedAt, notifiedAt sql.NullTime
+
+	err := row.Scan(
+		&e.ID, &e.UserID, &e.FrameworkID, &e.ControlID, &resourceID, &e.Justification, &e.Status,
+		&e.RequestedBy, &approvedBy, &approvedAt, &rejectionReason, &e.ExpiresAt, &notifiedAt,
+		&e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e.ResourceID = resourceID.String
+	e.RejectionReason = rejectionReason.String
+	if approvedBy.Valid {
+		e.ApprovedBy = &approvedBy.Int64
+	}
+	if approvedAt.Valid {
+		e.ApprovedAt = &approvedAt.Time
+	}
+	if notifiedAt.Valid {
+		e.ExpiryNotifiedAt = &notifiedAt.Time
+	}
+	return e, nil
+}
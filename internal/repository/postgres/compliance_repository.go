@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 )
 
 // ComplianceRepository implements compliance.Repository
@@ -21,18 +22,24 @@ func NewComplianceRepository(db *sql.DB) *ComplianceRepository {
 	return &ComplianceRepository{db: db}
 }
 
+// frameworkColumns are the columns scanned by scanFramework
+const frameworkColumns = `id, name, version, description, provider, is_enabled, source, catalog_revision, created_at, updated_at`
+
 // CreateFramework creates a new compliance framework
 func (r *ComplianceRepository) CreateFramework(ctx context.Context, f *compliance.Framework) error {
 	if f.ID == "" {
 		f.ID = uuid.New().String()
 	}
+	if f.Source == "" {
+		f.Source = compliance.FrameworkSourceCustom
+	}
 
 	query := `
-		INSERT INTO compliance_frameworks (id, name, version, description, provider, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO compliance_frameworks (id, name, version, description, provider, is_enabled, source, catalog_revision, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		f.ID, f.Name, f.Version, f.Description, f.Provider, f.IsEnabled,
+		f.ID, f.Name, f.Version, f.Description, nullString(f.Provider), f.IsEnabled, f.Source, f.Revision,
 		time.Now(), time.Now(),
 	)
 	return err
@@ -40,55 +47,27 @@ func (r *ComplianceRepository) CreateFramework(ctx context.Context, f *complianc
 
 // GetFramework retrieves a framework by ID
 func (r *ComplianceRepository) GetFramework(ctx context.Context, id string) (*compliance.Framework, error) {
-	query := `
-		SELECT id, name, version, description, provider, is_active, created_at, updated_at
-		FROM compliance_frameworks
-		WHERE id = $1
-	`
-	f := &compliance.Framework{}
-	var provider sql.NullString
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&f.ID, &f.Name, &f.Version, &f.Description, &provider, &f.IsEnabled,
-		&f.CreatedAt, &f.UpdatedAt,
-	)
-	if provider.Valid {
-		f.Provider = provider.String
+	query := `SELECT ` + frameworkColumns + ` FROM compliance_frameworks WHERE id = $1`
+	f, err := scanFramework(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Framework")
 	}
-	if err != nil {
-		return nil, err
-	}
-	return f, nil
+	return f, err
 }
 
 // GetFrameworkByName retrieves a framework by name
 func (r *ComplianceRepository) GetFrameworkByName(ctx context.Context, name string) (*compliance.Framework, error) {
-	query := `
-		SELECT id, name, version, description, provider, is_active, created_at, updated_at
-		FROM compliance_frameworks
-		WHERE name = $1
-	`
-	f := &compliance.Framework{}
-	var provider sql.NullString
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&f.ID, &f.Name, &f.Version, &f.Description, &provider, &f.IsEnabled,
-		&f.CreatedAt, &f.UpdatedAt,
-	)
-	if provider.Valid {
-		f.Provider = provider.String
-	}
-	if err != nil {
-		return nil, err
+	query := `SELECT ` + frameworkColumns + ` FROM compliance_frameworks WHERE name = $1`
+	f, err := scanFramework(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Framework")
 	}
-	return f, nil
+	return f, err
 }
 
 // ListFrameworks lists all frameworks
 func (r *ComplianceRepository) ListFrameworks(ctx context.Context) ([]*compliance.Framework, error) {
-	query := `
-		SELECT id, name, version, description, provider, is_active, created_at, updated_at
-		FROM compliance_frameworks
-		ORDER BY name
-	`
+	query := `SELECT ` + frameworkColumns + ` FROM compliance_frameworks ORDER BY name`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -97,15 +76,7 @@ func (r *ComplianceRepository) ListFrameworks(ctx context.Context) ([]*complianc
 
 	var frameworks []*compliance.Framework
 	for rows.Next() {
-		f := &compliance.Framework{}
-		var provider sql.NullString
-		err := rows.Scan(
-			&f.ID, &f.Name, &f.Version, &f.Description, &provider, &f.IsEnabled,
-			&f.CreatedAt, &f.UpdatedAt,
-		)
-		if provider.Valid {
-			f.Provider = provider.String
-		}
+		f, err := scanFramework(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -115,13 +86,184 @@ func (r *ComplianceRepository) ListFrameworks(ctx context.Context) ([]*complianc
 	return frameworks, rows.Err()
 }
 
+// frameworkScanner is implemented by *sql.Row and *sql.Rows
+type frameworkScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFramework scans a row selected with frameworkColumns
+func scanFramework(row frameworkScanner) (*compliance.Framework, error) {
+	f := &compliance.Framework{}
+	var version, description, provider, source sql.NullString
+	var revision sql.NullInt64
+	err := row.Scan(
+		&f.ID, &f.Name, &version, &description, &provider, &f.IsEnabled, &source, &revision,
+		&f.CreatedAt, &f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	f.Version = version.String
+	f.Description = description.String
+	f.Provider = provider.String
+	f.Source = source.String
+	f.Revision = int(revision.Int64)
+	return f, nil
+}
+
 // UpdateFramework updates a framework
 func (r *ComplianceRepository) UpdateFramework(ctx context.Context, f *compliance.Framework) error {
-	query := `UPDATE compliance_frameworks SET is_active = $1, updated_at = $2 WHERE id = $3`
+	query := `UPDATE compliance_frameworks SET is_enabled = $1, updated_at = $2 WHERE id = $3`
 	_, err := r.db.ExecContext(ctx, query, f.IsEnabled, time.Now(), f.ID)
 	return err
 }
 
+// UpsertCatalog writes a framework catalog in one transaction. Controls are
+// matched on their control ID so existing control rows, and the assessments
+// that reference them, keep their IDs. Each control's mappings are replaced
+// and controls no longer in the catalog are deleted. An existing framework
+// keeps its enabled flag.
+func (r *ComplianceRepository) UpsertCatalog(ctx context.Context, catalog *compliance.Catalog) error {
+	f := catalog.Framework
+	if f.Source == "" {
+		f.Source = compliance.FrameworkSourceCustom
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO compliance_frameworks (id, name, version, description, provider, is_enabled, source, catalog_revision, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			version = excluded.version,
+			description = excluded.description,
+			provider = excluded.provider,
+			source = excluded.source,
+			catalog_revision = excluded.catalog_revision,
+			updated_at = excluded.updated_at
+	`, f.ID, f.Name, f.Version, f.Description, nullString(f.Provider), f.IsEnabled, f.Source, f.Revision, now, now)
+	if err != nil {
+		return errors.DatabaseError("Failed to save framework", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, control_id FROM compliance_controls WHERE framework_id = $1`, f.ID)
+	if err != nil {
+		return errors.DatabaseError("Failed to list controls", err)
+	}
+	existing := make(map[string]string)
+	for rows.Next() {
+		var id, controlID string
+		if err := rows.Scan(&id, &controlID); err != nil {
+			rows.Close()
+			return errors.DatabaseError("Failed to scan control", err)
+		}
+		existing[controlID] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.DatabaseError("Failed to iterate controls", err)
+	}
+
+	for _, c := range catalog.Controls {
+		c.FrameworkID = f.ID
+		if id, ok := existing[c.ControlID]; ok {
+			c.ID = id
+			delete(existing, c.ControlID)
+			_, err = tx.ExecContext(ctx, `
+				UPDATE compliance_controls
+				SET title = $1, description = $2, category = $3, severity = $4, remediation = $5, reference_url = $6
+				WHERE id = $7
+			`, c.Title, c.Description, c.Category, c.Severity, c.Remediation, c.ReferenceURL, c.ID)
+		} else {
+			c.ID = uuid.New().String()
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO compliance_controls (id, framework_id, control_id, title, description, category, severity, remediation, reference_url, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			`, c.ID, c.FrameworkID, c.ControlID, c.Title, c.Description, c.Category, c.Severity, c.Remediation, c.ReferenceURL, now)
+		}
+		if err != nil {
+			return errors.DatabaseError("Failed to save control", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM compliance_mappings WHERE control_id = $1`, c.ID); err != nil {
+			return errors.DatabaseError("Failed to replace control mappings", err)
+		}
+		for _, m := range c.Checks {
+			m.ID = uuid.New().String()
+			m.ControlID = c.ID
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO compliance_mappings (id, control_id, security_rule_type, resource_type, provider, mapping_confidence, check_query, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, m.ID, m.ControlID, nullString(m.SecurityRuleType), nullString(m.ResourceType), nullString(m.Provider),
+				nullString(m.MappingConfidence), nullString(m.CheckQuery), now)
+			if err != nil {
+				return errors.DatabaseError("Failed to save control mapping", err)
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM compliance_control_links WHERE framework_id = $1`, f.ID); err != nil {
+		return errors.DatabaseError("Failed to replace control links", err)
+	}
+	for _, c := range catalog.Controls {
+		for _, ref := range c.Crosswalk {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO compliance_control_links (id, framework_id, control_id, linked_framework_id, linked_control_id, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, uuid.New().String(), f.ID, c.ControlID, ref.FrameworkID, ref.ControlID, now)
+			if err != nil {
+				return errors.DatabaseError("Failed to save control link", err)
+			}
+		}
+	}
+
+	for _, id := range existing {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM compliance_mappings WHERE control_id = $1`, id); err != nil {
+			return errors.DatabaseError("Failed to delete control mappings", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM compliance_controls WHERE id = $1`, id); err != nil {
+			return errors.DatabaseError("Failed to delete control", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit catalog", err)
+	}
+	return nil
+}
+
+// ListControlLinks lists every cross-framework control link
+func (r *ComplianceRepository) ListControlLinks(ctx context.Context) ([]*compliance.ControlLink, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT framework_id, control_id, linked_framework_id, linked_control_id
+		FROM compliance_control_links
+		ORDER BY framework_id, control_id, linked_framework_id, linked_control_id
+	`)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list control links", err)
+	}
+	defer rows.Close()
+
+	var links []*compliance.ControlLink
+	for rows.Next() {
+		l := &compliance.ControlLink{}
+		if err := rows.Scan(&l.Control.FrameworkID, &l.Control.ControlID, &l.Linked.FrameworkID, &l.Linked.ControlID); err != nil {
+			return nil, errors.DatabaseError("Failed to scan control link", err)
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Failed to iterate control links", err)
+	}
+	return links, nil
+}
+
 // CreateControl creates a new compliance control
 func (r *ComplianceRepository) CreateControl(ctx context.Context, c *compliance.Control) error {
 	if c.ID == "" {
@@ -129,7 +271,7 @@ func (r *ComplianceRepository) CreateControl(ctx context.Context, c *compliance.
 	}
 
 	query := `
-		INSERT INTO compliance_controls (id, framework_id, control_id, title, description, category, severity, remediation_steps, reference_url, created_at)
+		INSERT INTO compliance_controls (id, framework_id, control_id, title, description, category, severity, remediation, reference_url, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 	_, err := r.db.ExecContext(ctx, query,
@@ -142,7 +284,7 @@ func (r *ComplianceRepository) CreateControl(ctx context.Context, c *compliance.
 // GetControl retrieves a control by ID
 func (r *ComplianceRepository) GetControl(ctx context.Context, id string) (*compliance.Control, error) {
 	query := `
-		SELECT id, framework_id, control_id, title, description, category, severity, remediation_steps, reference_url, created_at
+		SELECT id, framework_id, control_id, title, description, category, severity, remediation, reference_url, created_at
 		FROM compliance_controls
 		WHERE id = $1
 	`
@@ -160,7 +302,7 @@ func (r *ComplianceRepository) GetControl(ctx context.Context, id string) (*comp
 // GetControlByFrameworkAndID retrieves a control by framework ID and control ID
 func (r *ComplianceRepository) GetControlByFrameworkAndID(ctx context.Context, frameworkID, controlID string) (*compliance.Control, error) {
 	query := `
-		SELECT id, framework_id, control_id, title, description, category, severity, remediation_steps, reference_url, created_at
+		SELECT id, framework_id, control_id, title, description, category, severity, remediation, reference_url, created_at
 		FROM compliance_controls
 		WHERE framework_id = $1 AND control_id = $2
 	`
@@ -169,6 +311,9 @@ func (r *ComplianceRepository) GetControlByFrameworkAndID(ctx context.Context, f
 		&c.ID, &c.FrameworkID, &c.ControlID, &c.Title, &c.Description, &c.Category,
 		&c.Severity, &c.Remediation, &c.ReferenceURL, &c.CreatedAt,
 	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Control")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +324,7 @@ func (r *ComplianceRepository) GetControlByFrameworkAndID(ctx context.Context, f
 func (r *ComplianceRepository) ListControls(ctx context.Context, frameworkID string, category string) ([]*compliance.Control, error) {
 	paramN := 1
 	query := fmt.Sprintf(`
-		SELECT id, framework_id, control_id, title, description, category, severity, remediation_steps, reference_url, created_at
+		SELECT id, framework_id, control_id, title, description, category, severity, remediation, reference_url, created_at
 		FROM compliance_controls
 		WHERE framework_id = $%d
 	`, paramN)
@@ -231,11 +376,12 @@ func (r *ComplianceRepository) CreateMapping(ctx context.Context, m *compliance.
 	}
 
 	query := `
-		INSERT INTO compliance_mappings (id, control_id, rule_type, rule_config, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO compliance_mappings (id, control_id, security_rule_type, resource_type, provider, mapping_confidence, check_query, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		m.ID, m.ControlID, m.SecurityRuleType, m.CheckQuery, time.Now(),
+		m.ID, m.ControlID, nullString(m.SecurityRuleType), nullString(m.ResourceType), nullString(m.Provider),
+		nullString(m.MappingConfidence), nullString(m.CheckQuery), time.Now(),
 	)
 	return err
 }
@@ -243,39 +389,32 @@ func (r *ComplianceRepository) CreateMapping(ctx context.Context, m *compliance.
 // GetMappingsForControl retrieves mappings for a control
 func (r *ComplianceRepository) GetMappingsForControl(ctx context.Context, controlID string) ([]*compliance.ControlMapping, error) {
 	query := `
-		SELECT id, control_id, rule_type, rule_config
+		SELECT id, control_id, security_rule_type, resource_type, provider, mapping_confidence, check_query
 		FROM compliance_mappings
 		WHERE control_id = $1
+		ORDER BY created_at, id
 	`
-	rows, err := r.db.QueryContext(ctx, query, controlID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var mappings []*compliance.ControlMapping
-	for rows.Next() {
-		m := &compliance.ControlMapping{}
-		err := rows.Scan(
-			&m.ID, &m.ControlID, &m.SecurityRuleType, &m.CheckQuery,
-		)
-		if err != nil {
-			return nil, err
-		}
-		mappings = append(mappings, m)
-	}
-
-	return mappings, rows.Err()
+	return r.queryMappings(ctx, query, controlID)
 }
 
-// GetMappingsForSecurityRule retrieves mappings for a security rule type
+// GetMappingsForSecurityRule retrieves mappings for a security rule type.
+// Mappings without a resource type apply to every resource type.
 func (r *ComplianceRepository) GetMappingsForSecurityRule(ctx context.Context, ruleType string, resourceType string) ([]*compliance.ControlMapping, error) {
 	query := `
-		SELECT id, control_id, rule_type, rule_config
+		SELECT id, control_id, security_rule_type, resource_type, provider, mapping_confidence, check_query
 		FROM compliance_mappings
-		WHERE rule_type = $1
+		WHERE security_rule_type = $1
 	`
-	rows, err := r.db.QueryContext(ctx, query, ruleType)
+	args := []interface{}{ruleType}
+	if resourceType != "" {
+		query += " AND (resource_type IS NULL OR resource_type = '' OR resource_type = $2)"
+		args = append(args, resourceType)
+	}
+	return r.queryMappings(ctx, query, args...)
+}
+
+func (r *ComplianceRepository) queryMappings(ctx context.Context, query string, args ...interface{}) ([]*compliance.ControlMapping, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -284,12 +423,18 @@ func (r *ComplianceRepository) GetMappingsForSecurityRule(ctx context.Context, r
 	var mappings []*compliance.ControlMapping
 	for rows.Next() {
 		m := &compliance.ControlMapping{}
+		var ruleType, resourceType, provider, confidence, checkQuery sql.NullString
 		err := rows.Scan(
-			&m.ID, &m.ControlID, &m.SecurityRuleType, &m.CheckQuery,
+			&m.ID, &m.ControlID, &ruleType, &resourceType, &provider, &confidence, &checkQuery,
 		)
 		if err != nil {
 			return nil, err
 		}
+		m.SecurityRuleType = ruleType.String
+		m.ResourceType = resourceType.String
+		m.Provider = provider.String
+		m.MappingConfidence = confidence.String
+		m.CheckQuery = checkQuery.String
 		mappings = append(mappings, m)
 	}
 
@@ -305,12 +450,12 @@ func (r *ComplianceRepository) CreateAssessment(ctx context.Context, a *complian
 	findingsJSON, _ := json.Marshal(a.Findings)
 
 	query := `
-		INSERT INTO compliance_assessments (id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, skipped_controls, compliance_percentage, results, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO compliance_assessments (id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, not_applicable_controls, excepted_controls, compliance_percent, findings, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		a.ID, a.UserID, a.FrameworkID, a.FrameworkName, a.AssessmentDate,
-		a.TotalControls, a.PassedControls, a.FailedControls, a.NotApplicableControls,
+		a.TotalControls, a.PassedControls, a.FailedControls, a.NotApplicableControls, a.ExceptedControls,
 		a.CompliancePercent, findingsJSON, a.Status, time.Now(),
 	)
 	return err
@@ -319,16 +464,19 @@ func (r *ComplianceRepository) CreateAssessment(ctx context.Context, a *complian
 // GetAssessment retrieves an assessment by ID
 func (r *ComplianceRepository) GetAssessment(ctx context.Context, id string) (*compliance.Assessment, error) {
 	query := `
-		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, skipped_controls, compliance_percentage, results, status, created_at
+		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, not_applicable_controls, excepted_controls, compliance_percent, findings, status, created_at
 		FROM compliance_assessments
 		WHERE id = $1
 	`
 	a := &compliance.Assessment{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&a.ID, &a.UserID, &a.FrameworkID, &a.FrameworkName, &a.AssessmentDate,
-		&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls,
+		&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls, &a.ExceptedControls,
 		&a.CompliancePercent, &a.Findings, &a.Status, &a.CreatedAt,
 	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Assessment")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -341,13 +489,13 @@ func (r *ComplianceRepository) UpdateAssessment(ctx context.Context, a *complian
 
 	query := `
 		UPDATE compliance_assessments
-		SET total_controls = $1, passed_controls = $2, failed_controls = $3, skipped_controls = $4,
-		    compliance_percentage = $5, results = $6, status = $7
-		WHERE id = $8
+		SET total_controls = $1, passed_controls = $2, failed_controls = $3, not_applicable_controls = $4,
+		    excepted_controls = $5, compliance_percent = $6, findings = $7, status = $8
+		WHERE id = $9
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		a.TotalControls, a.PassedControls, a.FailedControls, a.NotApplicableControls,
-		a.CompliancePercent, findingsJSON, a.Status, a.ID,
+		a.ExceptedControls, a.CompliancePercent, findingsJSON, a.Status, a.ID,
 	)
 	return err
 }
@@ -373,7 +521,7 @@ func (r *ComplianceRepository) ListAssessments(ctx context.Context, userID int64
 
 	paramN = 1
 	query := fmt.Sprintf(`
-		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, skipped_controls, compliance_percentage, results, status, created_at
+		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, not_applicable_controls, excepted_controls, compliance_percent, findings, status, created_at
 		FROM compliance_assessments
 		WHERE user_id = $%d
 	`, paramN)
@@ -400,7 +548,7 @@ func (r *ComplianceRepository) ListAssessments(ctx context.Context, userID int64
 		a := &compliance.Assessment{}
 		err := rows.Scan(
 			&a.ID, &a.UserID, &a.FrameworkID, &a.FrameworkName, &a.AssessmentDate,
-			&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls,
+			&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls, &a.ExceptedControls,
 			&a.CompliancePercent, &a.Findings, &a.Status, &a.CreatedAt,
 		)
 		if err != nil {
@@ -415,7 +563,7 @@ func (r *ComplianceRepository) ListAssessments(ctx context.Context, userID int64
 // GetLatestAssessment retrieves the latest assessment for a framework
 func (r *ComplianceRepository) GetLatestAssessment(ctx context.Context, userID int64, frameworkID string) (*compliance.Assessment, error) {
 	query := `
-		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, skipped_controls, compliance_percentage, results, status, created_at
+		SELECT id, user_id, framework_id, framework_name, assessment_date, total_controls, passed_controls, failed_controls, not_applicable_controls, excepted_controls, compliance_percent, findings, status, created_at
 		FROM compliance_assessments
 		WHERE user_id = $1 AND framework_id = $2 AND status = 'completed'
 		ORDER BY assessment_date DESC
@@ -424,7 +572,7 @@ func (r *ComplianceRepository) GetLatestAssessment(ctx context.Context, userID i
 	a := &compliance.Assessment{}
 	err := r.db.QueryRowContext(ctx, query, userID, frameworkID).Scan(
 		&a.ID, &a.UserID, &a.FrameworkID, &a.FrameworkName, &a.AssessmentDate,
-		&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls,
+		&a.TotalControls, &a.PassedControls, &a.FailedControls, &a.NotApplicableControls, &a.ExceptedControls,
 		&a.CompliancePercent, &a.Findings, &a.Status, &a.CreatedAt,
 	)
 	if err != nil {
@@ -432,3 +580,11 @@ func (r *ComplianceRepository) GetLatestAssessment(ctx context.Context, userID i
 	}
 	return a, nil
 }
+
+// nullString stores unset optional mapping fields as NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+)
+
+func TestComplianceRepository_ControlsAndMappings(t *testing.T) {
+	repo := NewComplianceRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	framework, err := repo.GetFrameworkByName(ctx, "CIS AWS Foundations Benchmark")
+	if err != nil {
+		t.Fatalf("GetFrameworkByName() error = %v", err)
+	}
+	framework.IsEnabled = false
+	if err := repo.UpdateFramework(ctx, framework); err != nil {
+		t.Fatalf("UpdateFramework() error = %v", err)
+	}
+	if got, _ := repo.GetFramework(ctx, framework.ID); got.IsEnabled {
+		t.Error("framework is still enabled after UpdateFramework")
+	}
+
+	control := &compliance.Control{FrameworkID: framework.ID, ControlID: "2.1.1", Title: "S3 encryption", Remediation: "Enable default encryption"}
+	if err := repo.CreateControl(ctx, control); err != nil {
+		t.Fatalf("CreateControl() error = %v", err)
+	}
+	if got, err := repo.GetControlByFrameworkAndID(ctx, framework.ID, "2.1.1"); err != nil || got.Remediation != control.Remediation {
+		t.Fatalf("GetControlByFrameworkAndID() = %+v, %v", got, err)
+	}
+
+	check := &compliance.ControlMapping{
+		ControlID:         control.ID,
+		ResourceType:      "s3-bucket",
+		Provider:          "aws",
+		MappingConfidence: "high",
+		CheckQuery:        `{"all": [{"field": "$.encryption.enabled", "equals": true}]}`,
+	}
+	driftMapping := &compliance.ControlMapping{ControlID: control.ID, SecurityRuleType: "encryption"}
+	for _, m := range []*compliance.ControlMapping{check, driftMapping} {
+		if err := repo.CreateMapping(ctx, m); err != nil {
+			t.Fatalf("CreateMapping() error = %v", err)
+		}
+	}
+
+	mappings, err := repo.GetMappingsForControl(ctx, control.ID)
+	if err != nil {
+		t.Fatalf("GetMappingsForControl() error = %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("got %d mappings, want 2", len(mappings))
+	}
+	for _, m := range mappings {
+		want := driftMapping
+		if m.ID == check.ID {
+			want = check
+		}
+		if *m != *want {
+			t.Errorf("mapping = %+v, want %+v", m, want)
+		}
+	}
+
+	byRule, err := repo.GetMappingsForSecurityRule(ctx, "encryption", "s3-bucket")
+	if err != nil || len(byRule) != 1 || byRule[0].ID != driftMapping.ID {
+		t.Errorf("GetMappingsForSecurityRule() = %v, %v, want the drift mapping", byRule, err)
+	}
+}
+
+func TestComplianceRepository_Assessments(t *testing.T) {
+	repo := NewComplianceRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	a := &compliance.Assessment{
+		UserID:         1,
+		FrameworkID:    "cis-aws-v1.5",
+		FrameworkName:  "CIS AWS Foundations Benchmark",
+		AssessmentDate: time.Now().UTC(),
+		Status:         compliance.AssessmentStatusRunning,
+	}
+	if err := repo.CreateAssessment(ctx, a); err != nil {
+		t.Fatalf("CreateAssessment() error = %v", err)
+	}
+
+	findings, _ := json.Marshal([]compliance.AssessmentFinding{{ControlID: "2.1.1", Status: compliance.ControlStatusFailed, Evidence: "plain-bucket: encryption.enabled = false"}})
+	a.TotalControls, a.PassedControls, a.FailedControls, a.NotApplicableControls = 4, 1, 1, 1
+	a.ExceptedControls = 1
+	a.CompliancePercent = 50
+	a.Findings = findings
+	a.Status = compliance.AssessmentStatusCompleted
+	if err := repo.UpdateAssessment(ctx, a); err != nil {
+		t.Fatalf("UpdateAssessment() error = %v", err)
+	}
+
+	got, err := repo.GetLatestAssessment(ctx, 1, "cis-aws-v1.5")
+	if err != nil {
+		t.Fatalf("GetLatestAssessment() error = %v", err)
+	}
+	if got.ID != a.ID || got.NotApplicableControls != 1 || got.ExceptedControls != 1 || got.CompliancePercent != 50 {
+		t.Errorf("assessment = %+v", got)
+	}
+	var gotFindings []compliance.AssessmentFinding
+	if err := json.Unmarshal(got.Findings, &gotFindings); err != nil || len(gotFindings) != 1 || gotFindings[0].Evidence == "" {
+		t.Errorf("findings = %s, %v", got.Findings, err)
+	}
+
+	list, total, err := repo.ListAssessments(ctx, 1, "", 10, 0)
+	if err != nil || total != 1 || len(list) != 1 {
+		t.Errorf("ListAssessments() = %d of %d, %v", len(list), total, err)
+	}
+}
+
+func TestComplianceRepository_Exceptions(t *testing.T) {
+	repo := NewComplianceRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	framework, err := repo.GetFrameworkByName(ctx, "CIS AWS Foundations Benchmark")
+	if err != nil {
+		t.Fatalf("GetFrameworkByName() error = %v", err)
+	}
+	expires := time.Now().UTC().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	scoped := &compliance.Exception{
+		UserID: 1, FrameworkID: framework.ID, ControlID: "2.1.1", ResourceID: "legacy-bucket",
+		Justification: "Replaced next quarter", Status: compliance.ExceptionStatusPending, RequestedBy: 1, ExpiresAt: expires,
+	}
+	wide := &compliance.Exception{
+		UserID: 1, FrameworkID: framework.ID, ControlID: "2.2.1",
+		Justification: "No EBS data", Status: compliance.ExceptionStatusPending, RequestedBy: 1, ExpiresAt: expires.Add(time.Hour),
+	}
+	for _, e := range []*compliance.Exception{scoped, wide} {
+		if err := repo.CreateException(ctx, e); err != nil {
+			t.Fatalf("CreateException() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetException(ctx, 1, scoped.ID)
+	if err != nil {
+		t.Fatalf("GetException() error = %v", err)
+	}
+	if got.ResourceID != "legacy-bucket" || got.ApprovedBy != nil || !got.ExpiresAt.Equal(expires) {
+		t.Errorf("exception = %+v", got)
+	}
+	if _, err := repo.GetException(ctx, 2, scoped.ID); err == nil {
+		t.Error("GetException() for another user succeeded, want not found")
+	}
+
+	approver := int64(7)
+	now := time.Now().UTC().Truncate(time.Second)
+	got.Status = compliance.ExceptionStatusApproved
+	got.ApprovedBy = &approver
+	got.ApprovedAt = &now
+	got.ExpiryNotifiedAt = &now
+	if err := repo.UpdateException(ctx, got); err != nil {
+		t.Fatalf("UpdateException() error = %v", err)
+	}
+	if err := repo.UpdateException(ctx, &compliance.Exception{ID: scoped.ID, UserID: 2}); err == nil {
+		t.Error("UpdateException() for another user succeeded, want not found")
+	}
+
+	approved, err := repo.ListExceptions(ctx, 1, compliance.ExceptionFilter{Status: compliance.ExceptionStatusApproved})
+	if err != nil || len(approved) != 1 {
+		t.Fatalf("ListExceptions(approved) = %d, %v, want 1", len(approved), err)
+	}
+	if e := approved[0]; e.ApprovedBy == nil || *e.ApprovedBy != 7 || e.ApprovedAt == nil || e.ExpiryNotifiedAt == nil {
+		t.Errorf("approved exception = %+v", e)
+	}
+
+	all, err := repo.ListExceptions(ctx, 1, compliance.ExceptionFilter{FrameworkID: framework.ID})
+	if err != nil || len(all) != 2 || all[0].ID != scoped.ID || all[1].ResourceID != "" {
+		t.Errorf("ListExceptions() = %+v, %v, want both, soonest expiry first", all, err)
+	}
+}
+
+func TestComplianceRepository_UpsertCatalog(t *testing.T) {
+	repo := NewComplianceRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	// Seeded by migration 006 as disabled, before any catalog was loaded
+	seeded, err := repo.GetFramework(ctx, "pci-dss-v4")
+	if err != nil {
+		t.Fatalf("GetFramework() error = %v", err)
+	}
+	if seeded.Source != compliance.FrameworkSourceBuiltin || seeded.Revision != 0 || seeded.IsEnabled {
+		t.Fatalf("seeded framework = %+v", seeded)
+	}
+
+	newCatalog := func(revision int, controls ...*compliance.Control) *compliance.Catalog {
+		return &compliance.Catalog{
+			Framework: &compliance.Framework{ID: "pci-dss-v4", Name: "PCI DSS", Version: "4.0", IsEnabled: true, Source: compliance.FrameworkSourceBuiltin, Revision: revision},
+			Controls:  controls,
+		}
+	}
+	check := func() *compliance.ControlMapping {
+		return &compliance.ControlMapping{ResourceType: "s3-bucket", Provider: "aws", MappingConfidence: "high", CheckQuery: `{"all":[{"field":"$.encryption.enabled","equals":true}]}`}
+	}
+
+	first := newCatalog(1,
+		&compliance.Control{ControlID: "3.5.1", Title: "PAN is unreadable", Severity: "critical", Checks: []*compliance.ControlMapping{check()},
+			Crosswalk: []compliance.ControlRef{{FrameworkID: "nist-800-53-r5", ControlID: "SC-28"}, {FrameworkID: "hipaa", ControlID: "164.312(a)(2)(iv)"}}},
+		&compliance.Control{ControlID: "12.10.1", Title: "Incident response plan", Severity: "medium"},
+	)
+	if err := repo.UpsertCatalog(ctx, first); err != nil {
+		t.Fatalf("UpsertCatalog() error = %v", err)
+	}
+	if links, err := repo.ListControlLinks(ctx); err != nil || len(links) != 2 {
+		t.Fatalf("ListControlLinks() = %d links, %v, want 2", len(links), err)
+	}
+	pan, err := repo.GetControlByFrameworkAndID(ctx, "pci-dss-v4", "3.5.1")
+	if err != nil {
+		t.Fatalf("GetControlByFrameworkAndID() error = %v", err)
+	}
+
+	second := newCatalog(2,
+		&compliance.Control{ControlID: "3.5.1", Title: "PAN is rendered unreadable", Severity: "critical", Remediation: "Encrypt it", Checks: []*compliance.ControlMapping{check()},
+			Crosswalk: []compliance.ControlRef{{FrameworkID: "nist-800-53-r5", ControlID: "SC-28"}}},
+		&compliance.Control{ControlID: "4.2.1", Title: "Strong cryptography in transit", Severity: "critical"},
+	)
+	if err := repo.UpsertCatalog(ctx, second); err != nil {
+		t.Fatalf("UpsertCatalog() second run error = %v", err)
+	}
+
+	framework, _ := repo.GetFramework(ctx, "pci-dss-v4")
+	if framework.Revision != 2 || framework.IsEnabled {
+		t.Errorf("framework = %+v, want revision 2 and still disabled", framework)
+	}
+
+	controls, err := repo.ListControls(ctx, "pci-dss-v4", "")
+	if err != nil {
+		t.Fatalf("ListControls() error = %v", err)
+	}
+	if len(controls) != 2 {
+		t.Fatalf("got %d controls, want 2", len(controls))
+	}
+	for _, c := range controls {
+		if c.ControlID == "12.10.1" {
+			t.Error("control removed from the catalog was kept")
+		}
+		if c.ControlID == "3.5.1" && (c.ID != pan.ID || c.Title != "PAN is rendered unreadable" || c.Remediation != "Encrypt it") {
+			t.Errorf("updated control = %+v, want ID %s kept and fields updated", c, pan.ID)
+		}
+	}
+
+	mappings, _ := repo.GetMappingsForControl(ctx, pan.ID)
+	if len(mappings) != 1 {
+		t.Errorf("got %d mappings after two upserts, want 1", len(mappings))
+	}
+
+	links, err := repo.ListControlLinks(ctx)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("ListControlLinks() after second upsert = %d links, %v, want 1", len(links), err)
+	}
+	want := compliance.ControlLink{
+		Control: compliance.ControlRef{FrameworkID: "pci-dss-v4", ControlID: "3.5.1"},
+		Linked:  compliance.ControlRef{FrameworkID: "nist-800-53-r5", ControlID: "SC-28"},
+	}
+	if *links[0] != want {
+		t.Errorf("link = %+v, want %+v", *links[0], want)
+	}
+
+	if _, err := repo.GetFramework(ctx, "missing"); err == nil {
+		t.Error("GetFramework(missing) succeeded, want error")
+	}
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+const allocationRuleColumns = `id, user_id, name, tag_keys, aliases, splits, created_at, updated_at`
+
+// CreateAllocationRule creates a new allocation rule
+func (r *CostRepository) CreateAllocationRule(ctx context.Context, rule *cost.AllocationRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	tagKeys, aliases, splits, err := marshalAllocationRule(rule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO cost_allocation_rules (` + allocationRuleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		rule.ID, rule.UserID, rule.Name, tagKeys, aliases, splits, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create allocation rule", err)
+	}
+	return nil
+}
+
+// GetAllocationRule retrieves an allocation rule owned by a user
+func (r *CostRepository) GetAllocationRule(ctx context.Context, userID int64, id string) (*cost.AllocationRule, error) {
+	query := `SELECT ` + allocationRuleColumns + ` FROM cost_allocation_rules WHERE user_id = $1 AND id = $2`
+
+	rule, err := scanAllocationRule(r.db.QueryRowContext(ctx, query, userID, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Allocation rule")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get allocation rule", err)
+	}
+	return rule, nil
+}
+
+// UpdateAllocationRule updates an allocation rule
+func (r *CostRepository) UpdateAllocationRule(ctx context.Context, rule *cost.AllocationRule) error {
+	rule.UpdatedAt = time.Now()
+
+	tagKeys, aliases, splits, err := marshalAllocationRule(rule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE cost_allocation_rules
+		SET name = $1, tag_keys = $2, aliases = $3, splits = $4, updated_at = $5
+		WHERE user_id = $6 AND id = $7
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Name, tagKeys, aliases, splits, rule.UpdatedAt, rule.UserID, rule.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update allocation rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Allocation rule")
+	}
+	return nil
+}
+
+// DeleteAllocationRule deletes an allocation rule
+func (r *CostRepository) DeleteAllocationRule(ctx context.Context, userID int64, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cost_allocation_rules WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete allocation rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Allocation rule")
+	}
+	return nil
+}
+
+// ListAllocationRules lists the allocation rules of a user
+func (r *CostRepository) ListAllocationRules(ctx context.Context, userID int64) ([]*cost.AllocationRule, error) {
+	query := `SELECT ` + allocationRuleColumns + ` FROM cost_allocation_rules WHERE user_id = $1 ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list allocation rules", err)
+	}
+	defer rows.Close()
+
+	var rules []*cost.AllocationRule
+	for rows.Next() {
+		rule, err := scanAllocationRule(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan allocation rule", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// marshalAllocationRule encodes the JSON columns of a rule
+func marshalAllocationRule(rule *cost.AllocationRule) (tagKeys, aliases, splits string, err error) {
+	tagKeysJSON, err := json.Marshal(rule.TagKeys)
+	if err != nil {
+		return "", "", "", errors.DatabaseError("Failed to marshal allocation tag keys", err)
+	}
+	aliasesJSON, err := json.Marshal(rule.Aliases)
+	if err != nil {
+		return "", "", "", errors.DatabaseError("Failed to marshal allocation aliases", err)
+	}
+	splitsJSON, err := json.Marshal(rule.Splits)
+	if err != nil {
+		return "", "", "", errors.DatabaseError("Failed to marshal allocation splits", err)
+	}
+	return string(tagKeysJSON), string(aliasesJSON), string(splitsJSON), nil
+}
+
+// allocationRuleScanner is satisfied by *sql.Row and *sql.Rows
+type allocationRuleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAllocationRule(row allocationRuleScanner) (*cost.AllocationRule, error) {
+	var rule cost.AllocationRule
+	var tagKeys, aliases, splits sql.NullString
+
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.Name, &tagKeys, &aliases, &splits, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range []struct {
+		value sql.NullString
+		dest  interface{}
+	}{
+		{tagKeys, &rule.TagKeys},
+		{aliases, &rule.Aliases},
+		{splits, &rule.Splits},
+	} {
+		if column.value.Valid && column.value.String != "" {
+			if err := json.Unmarshal([]byte(column.value.String), column.dest); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &rule, nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+func TestCostRepository_AllocationRules(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	rule := &cost.AllocationRule{
+		UserID:  1,
+		Name:    "Teams",
+		TagKeys: []string{"team", "owner"},
+		Aliases: map[string]string{"data-eng": "data"},
+		Splits: []cost.AllocationSplit{
+			{Group: "shared", Method: cost.SplitMethodProportional},
+			{Group: "platform", Method: cost.SplitMethodFixed, Weights: map[string]float64{"data": 60, "web": 40}},
+		},
+	}
+	if err := repo.CreateAllocationRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAllocationRule() error = %v", err)
+	}
+
+	got, err := repo.GetAllocationRule(ctx, 1, rule.ID)
+	if err != nil {
+		t.Fatalf("GetAllocationRule() error = %v", err)
+	}
+	if len(got.TagKeys) != 2 || got.TagKeys[1] != "owner" || got.Aliases["data-eng"] != "data" ||
+		len(got.Splits) != 2 || got.Splits[1].Weights["web"] != 40 {
+		t.Fatalf("GetAllocationRule() = %+v", got)
+	}
+	if _, err := repo.GetAllocationRule(ctx, 2, rule.ID); err == nil {
+		t.Fatal("GetAllocationRule() of another user's rule should fail")
+	}
+
+	got.Name = "Cost centers"
+	got.TagKeys = []string{"cost-center"}
+	got.Aliases = nil
+	got.Splits = nil
+	if err := repo.UpdateAllocationRule(ctx, got); err != nil {
+		t.Fatalf("UpdateAllocationRule() error = %v", err)
+	}
+	rules, err := repo.ListAllocationRules(ctx, 1)
+	if err != nil || len(rules) != 1 || rules[0].Name != "Cost centers" || rules[0].TagKeys[0] != "cost-center" || len(rules[0].Splits) != 0 {
+		t.Fatalf("ListAllocationRules() = %+v, %v", rules, err)
+	}
+
+	if err := repo.DeleteAllocationRule(ctx, 1, rule.ID); err != nil {
+		t.Fatalf("DeleteAllocationRule() error = %v", err)
+	}
+	if err := repo.DeleteAllocationRule(ctx, 1, rule.ID); err == nil {
+		t.Fatal("DeleteAllocationRule() of a deleted rule should fail")
+	}
+}
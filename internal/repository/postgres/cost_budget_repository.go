@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+const budgetColumns = `id, user_id, name, period, amount, currency, provider, service_name, region, tag_key, tag_value, thresholds, is_enabled, created_at, updated_at`
+
+// CreateBudget creates a new budget
+func (r *CostRepository) CreateBudget(ctx context.Context, b *cost.Budget) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	now := time.Now()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+
+	thresholds, err := json.Marshal(b.Thresholds)
+	if err != nil {
+		return errors.DatabaseError("Failed to marshal budget thresholds", err)
+	}
+
+	query := `
+		INSERT INTO cost_budgets (` + budgetColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		b.ID, b.UserID, b.Name, b.Period, b.Amount, b.Currency,
+		b.Scope.Provider, b.Scope.ServiceName, b.Scope.Region, b.Scope.TagKey, b.Scope.TagValue,
+		string(thresholds), b.IsEnabled, b.CreatedAt, b.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create budget", err)
+	}
+	return nil
+}
+
+// GetBudget retrieves a budget owned by a user
+func (r *CostRepository) GetBudget(ctx context.Context, userID int64, id string) (*cost.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM cost_budgets WHERE user_id = $1 AND id = $2`
+
+	b, err := scanBudget(r.db.QueryRowContext(ctx, query, userID, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Budget")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get budget", err)
+	}
+	return b, nil
+}
+
+// UpdateBudget updates a budget
+func (r *CostRepository) UpdateBudget(ctx context.Context, b *cost.Budget) error {
+	b.UpdatedAt = time.Now()
+
+	thresholds, err := json.Marshal(b.Thresholds)
+	if err != nil {
+		return errors.DatabaseError("Failed to marshal budget thresholds", err)
+	}
+
+	query := `
+		UPDATE cost_budgets
+		SET name = $1, period = $2, amount = $3, currency = $4, provider = $5, service_name = $6,
+			region = $7, tag_key = $8, tag_value = $9, thresholds = $10, is_enabled = $11, updated_at = $12
+		WHERE user_id = $13 AND id = $14
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		b.Name, b.Period, b.Amount, b.Currency, b.Scope.Provider, b.Scope.ServiceName,
+		b.Scope.Region, b.Scope.TagKey, b.Scope.TagValue, string(thresholds), b.IsEnabled, b.UpdatedAt,
+		b.UserID, b.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update budget", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Budget")
+	}
+	return nil
+}
+
+// DeleteBudget deletes a budget and its alert history
+func (r *CostRepository) DeleteBudget(ctx context.Context, userID int64, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cost_budgets WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete budget", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Budget")
+	}
+
+	// SQLite does not enforce the cascade unless foreign keys are enabled
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM cost_budget_alerts WHERE budget_id = $1`, id); err != nil {
+		return errors.DatabaseError("Failed to delete budget alerts", err)
+	}
+	return nil
+}
+
+// ListBudgets lists the budgets of a user
+func (r *CostRepository) ListBudgets(ctx context.Context, userID int64) ([]*cost.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM cost_budgets WHERE user_id = $1 ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list budgets", err)
+	}
+	defer rows.Close()
+
+	var budgets []*cost.Budget
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan budget", err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// RecordBudgetAlert stores an alert unless the same threshold already fired
+// in the period. It reports whether the alert was stored.
+func (r *CostRepository) RecordBudgetAlert(ctx context.Context, a *cost.BudgetAlert) (bool, error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	if a.TriggeredAt.IsZero() {
+		a.TriggeredAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO cost_budget_alerts (id, budget_id, user_id, period_key, threshold_type, threshold_percent, spend, budget_amount, triggered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (budget_id, period_key, threshold_type, threshold_percent) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		a.ID, a.BudgetID, a.UserID, a.PeriodKey, a.ThresholdType, a.ThresholdPercent,
+		a.Spend, a.BudgetAmount, a.TriggeredAt,
+	)
+	if err != nil {
+		return false, errors.DatabaseError("Failed to record budget alert", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.DatabaseError("Failed to record budget alert", err)
+	}
+	return rows > 0, nil
+}
+
+// ListBudgetAlerts lists the alerts of a budget, optionally limited to one period
+func (r *CostRepository) ListBudgetAlerts(ctx context.Context, budgetID string, periodKey string) ([]*cost.BudgetAlert, error) {
+	query := `
+		SELECT id, budget_id, user_id, period_key, threshold_type, threshold_percent, spend, budget_amount, triggered_at
+		FROM cost_budget_alerts
+		WHERE budget_id = $1
+	`
+	args := []interface{}{budgetID}
+	if periodKey != "" {
+		query += " AND period_key = $2"
+		args = append(args, periodKey)
+	}
+	query += " ORDER BY triggered_at DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list budget alerts", err)
+	}
+	defer rows.Close()
+
+	var alerts []*cost.BudgetAlert
+	for rows.Next() {
+		a := &cost.BudgetAlert{}
+		err := rows.Scan(
+			&a.ID, &a.BudgetID, &a.UserID, &a.PeriodKey, &a.ThresholdType, &a.ThresholdPercent,
+			&a.Spend, &a.BudgetAmount, &a.TriggeredAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan budget alert", err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// budgetScanner is satisfied by *sql.Row and *sql.Rows
+type budgetScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBudget(row budgetScanner) (*cost.Budget, error) {
+	var b cost.Budget
+	var currency, provider, serviceName, region, tagKey, tagValue, thresholds sql.NullString
+
+	err := row.Scan(
+		&b.ID, &b.UserID, &b.Name, &b.Period, &b.Amount, &currency,
+		&provider, &serviceName, &region, &tagKey, &tagValue,
+		&thresholds, &b.IsEnabled, &b.CreatedAt, &b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Currency = currency.String
+	b.Scope = cost.BudgetScope{
+		Provider:    provider.String,
+		ServiceName: serviceName.String,
+		Region:      region.String,
+		TagKey:      tagKey.String,
+		TagValue:    tagValue.String,
+	}
+	if thresholds.Valid && thresholds.String != "" {
+		if err := json.Unmarshal([]byte(thresholds.String), &b.Thresholds); err != nil {
+			return nil, err
+		}
+	}
+	return &b, nil
+}
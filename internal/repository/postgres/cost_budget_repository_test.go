@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+func TestCostRepository_Budgets(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	budget := &cost.Budget{
+		UserID:     1,
+		Name:       "Data team",
+		Period:     cost.BudgetPeriodMonthly,
+		Amount:     500,
+		Currency:   "USD",
+		Scope:      cost.BudgetScope{Provider: cost.ProviderAWS, TagKey: "team", TagValue: "data"},
+		Thresholds: cost.DefaultBudgetThresholds(),
+		IsEnabled:  true,
+	}
+	if err := repo.CreateBudget(ctx, budget); err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+
+	got, err := repo.GetBudget(ctx, 1, budget.ID)
+	if err != nil {
+		t.Fatalf("GetBudget() error = %v", err)
+	}
+	if got.Scope != budget.Scope || len(got.Thresholds) != 4 || got.Amount != 500 || !got.IsEnabled {
+		t.Fatalf("GetBudget() = %+v", got)
+	}
+	if _, err := repo.GetBudget(ctx, 2, budget.ID); err == nil {
+		t.Fatal("GetBudget() of another user's budget should fail")
+	}
+
+	got.Amount = 750
+	got.Period = cost.BudgetPeriodQuarterly
+	got.IsEnabled = false
+	if err := repo.UpdateBudget(ctx, got); err != nil {
+		t.Fatalf("UpdateBudget() error = %v", err)
+	}
+	budgets, err := repo.ListBudgets(ctx, 1)
+	if err != nil || len(budgets) != 1 || budgets[0].Amount != 750 || budgets[0].Period != cost.BudgetPeriodQuarterly || budgets[0].IsEnabled {
+		t.Fatalf("ListBudgets() = %+v, %v", budgets, err)
+	}
+
+	if err := repo.DeleteBudget(ctx, 1, budget.ID); err != nil {
+		t.Fatalf("DeleteBudget() error = %v", err)
+	}
+	if err := repo.DeleteBudget(ctx, 1, budget.ID); err == nil {
+		t.Fatal("DeleteBudget() of a deleted budget should fail")
+	}
+}
+
+func TestCostRepository_RecordBudgetAlertDeduplicates(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	budget := &cost.Budget{UserID: 1, Name: "AWS", Period: cost.BudgetPeriodMonthly, Amount: 100, IsEnabled: true}
+	if err := repo.CreateBudget(ctx, budget); err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+
+	alert := func(period string) *cost.BudgetAlert {
+		return &cost.BudgetAlert{
+			BudgetID:         budget.ID,
+			UserID:           1,
+			PeriodKey:        period,
+			ThresholdType:    cost.ThresholdTypeActual,
+			ThresholdPercent: 80,
+			Spend:            82.5,
+			BudgetAmount:     100,
+			TriggeredAt:      time.Now(),
+		}
+	}
+
+	for i, tc := range []struct {
+		period string
+		want   bool
+	}{
+		{"2026-10", true},
+		{"2026-10", false},
+		{"2026-11", true},
+	} {
+		created, err := repo.RecordBudgetAlert(ctx, alert(tc.period))
+		if err != nil {
+			t.Fatalf("RecordBudgetAlert() #%d error = %v", i, err)
+		}
+		if created != tc.want {
+			t.Errorf("RecordBudgetAlert() #%d for %s = %v, want %v", i, tc.period, created, tc.want)
+		}
+	}
+
+	alerts, err := repo.ListBudgetAlerts(ctx, budget.ID, "2026-10")
+	if err != nil || len(alerts) != 1 || alerts[0].Spend != 82.5 {
+		t.Fatalf("ListBudgetAlerts() = %+v, %v", alerts, err)
+	}
+
+	if err := repo.DeleteBudget(ctx, 1, budget.ID); err != nil {
+		t.Fatalf("DeleteBudget() error = %v", err)
+	}
+	if alerts, _ := repo.ListBudgetAlerts(ctx, budget.ID, ""); len(alerts) != 0 {
+		t.Fatalf("DeleteBudget() left %d alerts behind", len(alerts))
+	}
+}
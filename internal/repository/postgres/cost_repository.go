@@ -3,11 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 )
 
 // CostRepository implements cost.Repository
@@ -27,11 +30,11 @@ func (r *CostRepository) CreateCost(ctx context.Context, c *cost.Cost) error {
 	}
 
 	query := `
-		INSERT INTO resource_costs (id, user_id, resource_id, provider, region, service_name, cost_date, daily_cost, monthly_cost, currency, tags, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO resource_costs (id, user_id, account_id, resource_id, provider, region, service_name, cost_date, daily_cost, monthly_cost, currency, tags, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		c.ID, c.UserID, c.ResourceID, c.Provider, c.Region, c.ServiceName,
+		c.ID, c.UserID, nullAccountID(c.AccountID), c.ResourceID, c.Provider, c.Region, c.ServiceName,
 		c.CostDate, c.DailyCost, c.MonthlyCost, c.Currency, c.Tags,
 		time.Now(),
 	)
@@ -42,7 +45,7 @@ func (r *CostRepository) CreateCost(ctx context.Context, c *cost.Cost) error {
 func (r *CostRepository) GetCostsByDateRange(ctx context.Context, userID int64, filter cost.Filter, startDate, endDate time.Time) ([]*cost.Cost, error) {
 	paramN := 1
 	query := fmt.Sprintf(`
-		SELECT id, user_id, resource_id, provider, region, service_name, cost_date, daily_cost, monthly_cost, currency, tags, created_at
+		SELECT id, user_id, COALESCE(account_id, 0), resource_id, provider, region, service_name, cost_date, daily_cost, monthly_cost, currency, tags, created_at
 		FROM resource_costs
 		WHERE user_id = $%d AND cost_date BETWEEN $%d AND $%d
 	`, paramN, paramN+1, paramN+2)
@@ -82,7 +85,7 @@ func (r *CostRepository) GetCostsByDateRange(ctx context.Context, userID int64,
 	for rows.Next() {
 		c := &cost.Cost{}
 		err := rows.Scan(
-			&c.ID, &c.UserID, &c.ResourceID, &c.Provider, &c.Region, &c.ServiceName,
+			&c.ID, &c.UserID, &c.AccountID, &c.ResourceID, &c.Provider, &c.Region, &c.ServiceName,
 			&c.CostDate, &c.DailyCost, &c.MonthlyCost, &c.Currency, &c.Tags,
 			&c.CreatedAt,
 		)
@@ -95,11 +98,13 @@ func (r *CostRepository) GetCostsByDateRange(ctx context.Context, userID int64,
 	return costs, rows.Err()
 }
 
-// GetCostSummary retrieves aggregated cost data
+// GetCostSummary retrieves aggregated cost data. The provider, service and
+// region filters apply to the total and to each breakdown.
 func (r *CostRepository) GetCostSummary(ctx context.Context, userID int64, filter cost.Filter, startDate, endDate time.Time) (*cost.CostSummary, error) {
 	summary := &cost.CostSummary{
 		Currency:   "USD",
 		Period:     "custom",
+		Provider:   filter.Provider,
 		StartDate:  startDate,
 		EndDate:    endDate,
 		ByService:  make(map[string]float64),
@@ -107,22 +112,27 @@ func (r *CostRepository) GetCostSummary(ctx context.Context, userID int64, filte
 		ByResource: make(map[string]float64),
 	}
 
-	paramN := 1
-	query := fmt.Sprintf(`
-		SELECT COALESCE(SUM(daily_cost), 0)
-		FROM resource_costs
-		WHERE user_id = $%d AND cost_date BETWEEN $%d AND $%d
-	`, paramN, paramN+1, paramN+2)
+	where := `user_id = $1 AND cost_date BETWEEN $2 AND $3`
 	args := []interface{}{userID, startDate, endDate}
-	paramN += 3
+	paramN := 4
 
 	if filter.Provider != "" {
-		query += fmt.Sprintf(" AND provider = $%d", paramN)
+		where += fmt.Sprintf(" AND provider = $%d", paramN)
 		args = append(args, filter.Provider)
 		paramN++
-		summary.Provider = filter.Provider
+	}
+	if filter.ServiceName != "" {
+		where += fmt.Sprintf(" AND service_name = $%d", paramN)
+		args = append(args, filter.ServiceName)
+		paramN++
+	}
+	if filter.Region != "" {
+		where += fmt.Sprintf(" AND region = $%d", paramN)
+		args = append(args, filter.Region)
+		paramN++
 	}
 
+	query := `SELECT COALESCE(SUM(daily_cost), 0) FROM resource_costs WHERE ` + where
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&summary.TotalCost)
 	if err != nil {
 		return nil, err
@@ -131,48 +141,45 @@ func (r *CostRepository) GetCostSummary(ctx context.Context, userID int64, filte
 	serviceQuery := `
 		SELECT service_name, COALESCE(SUM(daily_cost), 0) as total
 		FROM resource_costs
-		WHERE user_id = $1 AND cost_date BETWEEN $2 AND $3
+		WHERE ` + where + `
 		GROUP BY service_name
 		ORDER BY total DESC
 	`
-	rows, err := r.db.QueryContext(ctx, serviceQuery, userID, startDate, endDate)
-	if err != nil {
+	if err := r.sumCostsBy(ctx, serviceQuery, args, summary.ByService); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var service string
-		var total float64
-		if err := rows.Scan(&service, &total); err != nil {
-			return nil, err
-		}
-		summary.ByService[service] = total
-	}
 
 	regionQuery := `
 		SELECT region, COALESCE(SUM(daily_cost), 0) as total
 		FROM resource_costs
-		WHERE user_id = $1 AND cost_date BETWEEN $2 AND $3 AND region IS NOT NULL
+		WHERE ` + where + ` AND region IS NOT NULL
 		GROUP BY region
 		ORDER BY total DESC
 	`
-	rows, err = r.db.QueryContext(ctx, regionQuery, userID, startDate, endDate)
-	if err != nil {
+	if err := r.sumCostsBy(ctx, regionQuery, args, summary.ByRegion); err != nil {
 		return nil, err
 	}
+
+	return summary, nil
+}
+
+// sumCostsBy runs a query returning (name, total) rows into totals
+func (r *CostRepository) sumCostsBy(ctx context.Context, query string, args []interface{}, totals map[string]float64) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var region string
+		var name string
 		var total float64
-		if err := rows.Scan(&region, &total); err != nil {
-			return nil, err
+		if err := rows.Scan(&name, &total); err != nil {
+			return err
 		}
-		summary.ByRegion[region] = total
+		totals[name] = total
 	}
-
-	return summary, nil
+	return rows.Err()
 }
 
 // GetDailyCosts retrieves daily cost data points
@@ -221,39 +228,51 @@ func (r *CostRepository) DeleteCostsByDate(ctx context.Context, userID int64, be
 	return err
 }
 
+const costAnomalyColumns = `id, user_id, provider, service_name, anomaly_type, expected_cost, actual_cost, deviation_percentage, severity, status, description, fingerprint, drivers, detected_at, created_at`
+
 // CreateAnomaly creates a new cost anomaly record
 func (r *CostRepository) CreateAnomaly(ctx context.Context, a *cost.CostAnomaly) error {
-	if a.ID == "" {
-		a.ID = uuid.New().String()
+	drivers, err := json.Marshal(a.Drivers)
+	if err != nil {
+		return err
 	}
+	a.CreatedAt = time.Now()
 
 	query := `
-		INSERT INTO cost_anomalies (id, user_id, service_name, anomaly_type, expected_cost, actual_cost, deviation_percentage, severity, status, description, detected_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO cost_anomalies (user_id, provider, service_name, anomaly_type, expected_cost, actual_cost, deviation_percentage, severity, status, description, fingerprint, drivers, detected_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
 	`
-	_, err := r.db.ExecContext(ctx, query,
-		a.ID, a.UserID, a.ServiceName, a.AnomalyType,
+	var id int64
+	err = r.db.QueryRowContext(ctx, query,
+		a.UserID, a.Provider, a.ServiceName, a.AnomalyType,
 		a.ExpectedCost, a.ActualCost, a.Deviation, a.Severity, a.Status, a.Notes,
-		a.DetectedAt, time.Now(),
-	)
-	return err
+		a.Fingerprint, string(drivers), a.DetectedAt, a.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return err
+	}
+	a.ID = strconv.FormatInt(id, 10)
+	return nil
 }
 
 // GetAnomaly retrieves an anomaly by ID
 func (r *CostRepository) GetAnomaly(ctx context.Context, id string) (*cost.CostAnomaly, error) {
-	query := `
-		SELECT id, user_id, service_name, anomaly_type, expected_cost, actual_cost, deviation_percentage, severity, status, description, detected_at, created_at
-		FROM cost_anomalies
-		WHERE id = $1
-	`
-	a := &cost.CostAnomaly{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&a.ID, &a.UserID, &a.ServiceName, &a.AnomalyType,
-		&a.ExpectedCost, &a.ActualCost, &a.Deviation, &a.Severity, &a.Status, &a.Notes,
-		&a.DetectedAt, &a.CreatedAt,
-	)
+	query := `SELECT ` + costAnomalyColumns + ` FROM cost_anomalies WHERE id = $1`
+	return scanCostAnomaly(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetAnomalyByFingerprint retrieves a user's anomaly with the given
+// fingerprint, whatever its status
+func (r *CostRepository) GetAnomalyByFingerprint(ctx context.Context, userID int64, fingerprint string) (*cost.CostAnomaly, error) {
+	query := `SELECT ` + costAnomalyColumns + ` FROM cost_anomalies WHERE user_id = $1 AND fingerprint = $2 ORDER BY id LIMIT 1`
+
+	a, err := scanCostAnomaly(r.db.QueryRowContext(ctx, query, userID, fingerprint))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Cost anomaly")
+	}
 	if err != nil {
-		return nil, err
+		return nil, errors.DatabaseError("Failed to get cost anomaly", err)
 	}
 	return a, nil
 }
@@ -285,11 +304,7 @@ func (r *CostRepository) ListAnomalies(ctx context.Context, userID int64, status
 	}
 
 	paramN = 1
-	query := fmt.Sprintf(`
-		SELECT id, user_id, service_name, anomaly_type, expected_cost, actual_cost, deviation_percentage, severity, status, description, detected_at, created_at
-		FROM cost_anomalies
-		WHERE user_id = $%d
-	`, paramN)
+	query := fmt.Sprintf(`SELECT %s FROM cost_anomalies WHERE user_id = $%d`, costAnomalyColumns, paramN)
 	queryArgs := []interface{}{userID}
 	paramN++
 
@@ -310,12 +325,7 @@ func (r *CostRepository) ListAnomalies(ctx context.Context, userID int64, status
 
 	var anomalies []*cost.CostAnomaly
 	for rows.Next() {
-		a := &cost.CostAnomaly{}
-		err := rows.Scan(
-			&a.ID, &a.UserID, &a.ServiceName, &a.AnomalyType,
-			&a.ExpectedCost, &a.ActualCost, &a.Deviation, &a.Severity, &a.Status, &a.Notes,
-			&a.DetectedAt, &a.CreatedAt,
-		)
+		a, err := scanCostAnomaly(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -325,48 +335,113 @@ func (r *CostRepository) ListAnomalies(ctx context.Context, userID int64, status
 	return anomalies, total, rows.Err()
 }
 
+// anomalyScanner is satisfied by *sql.Row and *sql.Rows
+type anomalyScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCostAnomaly scans a row selected with costAnomalyColumns. Rows migrated
+// from the original table have no provider, service, description or drivers.
+func scanCostAnomaly(row anomalyScanner) (*cost.CostAnomaly, error) {
+	var a cost.CostAnomaly
+	var id int64
+	var provider, serviceName, status, description, drivers sql.NullString
+
+	err := row.Scan(
+		&id, &a.UserID, &provider, &serviceName, &a.AnomalyType,
+		&a.ExpectedCost, &a.ActualCost, &a.Deviation, &a.Severity, &status, &description,
+		&a.Fingerprint, &drivers, &a.DetectedAt, &a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.ID = strconv.FormatInt(id, 10)
+	a.Provider = provider.String
+	a.ServiceName = serviceName.String
+	a.Status = status.String
+	a.Notes = description.String
+	if drivers.Valid && drivers.String != "" && drivers.String != "null" {
+		if err := json.Unmarshal([]byte(drivers.String), &a.Drivers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal anomaly drivers: %w", err)
+		}
+	}
+	return &a, nil
+}
+
+const costOptimizationColumns = `id, user_id, provider, resource_id, resource_type, optimization_type, title, description, current_cost, estimated_savings, savings_percent, implementation, status, details, fingerprint, created_at, updated_at`
+
 // CreateOptimization creates a new optimization record
 func (r *CostRepository) CreateOptimization(ctx context.Context, o *cost.CostOptimization) error {
 	if o.ID == "" {
 		o.ID = uuid.New().String()
 	}
+	now := time.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
 
 	query := `
-		INSERT INTO cost_optimizations (id, user_id, provider, resource_id, type, title, description, current_cost, estimated_savings, savings_percentage, implementation_effort, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		INSERT INTO cost_optimizations (` + costOptimizationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		o.ID, o.UserID, o.Provider, o.ResourceID, o.OptimizationType,
+		o.ID, o.UserID, o.Provider, o.ResourceID, o.ResourceType, o.OptimizationType,
 		o.Title, o.Description, o.CurrentCost, o.EstimatedSavings, o.SavingsPercent,
-		o.Implementation, o.Status, time.Now(), time.Now(),
+		o.Implementation, o.Status, string(o.Details), o.Fingerprint, o.CreatedAt, o.UpdatedAt,
 	)
-	return err
+	if err != nil {
+		return errors.DatabaseError("Failed to create cost optimization", err)
+	}
+	return nil
 }
 
 // GetOptimization retrieves an optimization by ID
 func (r *CostRepository) GetOptimization(ctx context.Context, id string) (*cost.CostOptimization, error) {
-	query := `
-		SELECT id, user_id, provider, resource_id, type, title, description, current_cost, estimated_savings, savings_percentage, implementation_effort, status, created_at, updated_at
-		FROM cost_optimizations
-		WHERE id = $1
-	`
-	o := &cost.CostOptimization{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&o.ID, &o.UserID, &o.Provider, &o.ResourceID, &o.OptimizationType,
-		&o.Title, &o.Description, &o.CurrentCost, &o.EstimatedSavings, &o.SavingsPercent,
-		&o.Implementation, &o.Status, &o.CreatedAt, &o.UpdatedAt,
-	)
+	query := `SELECT ` + costOptimizationColumns + ` FROM cost_optimizations WHERE id = $1`
+
+	o, err := scanCostOptimization(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Cost optimization")
+	}
 	if err != nil {
-		return nil, err
+		return nil, errors.DatabaseError("Failed to get cost optimization", err)
 	}
 	return o, nil
 }
 
-// UpdateOptimization updates an optimization
+// GetOptimizationByFingerprint retrieves a user's most recent optimization
+// with the given fingerprint, whatever its status
+func (r *CostRepository) GetOptimizationByFingerprint(ctx context.Context, userID int64, fingerprint string) (*cost.CostOptimization, error) {
+	query := `SELECT ` + costOptimizationColumns + ` FROM cost_optimizations WHERE user_id = $1 AND fingerprint = $2 ORDER BY created_at DESC LIMIT 1`
+
+	o, err := scanCostOptimization(r.db.QueryRowContext(ctx, query, userID, fingerprint))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Cost optimization")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get cost optimization", err)
+	}
+	return o, nil
+}
+
+// UpdateOptimization updates an optimization's findings and status
 func (r *CostRepository) UpdateOptimization(ctx context.Context, o *cost.CostOptimization) error {
-	query := `UPDATE cost_optimizations SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, o.Status, time.Now(), o.ID)
-	return err
+	o.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE cost_optimizations
+		SET title = $1, description = $2, current_cost = $3, estimated_savings = $4, savings_percent = $5,
+			implementation = $6, status = $7, details = $8, updated_at = $9
+		WHERE id = $10
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		o.Title, o.Description, o.CurrentCost, o.EstimatedSavings, o.SavingsPercent,
+		o.Implementation, o.Status, string(o.Details), o.UpdatedAt, o.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update cost optimization", err)
+	}
+	return nil
 }
 
 // ListOptimizations lists optimizations
@@ -389,11 +464,7 @@ func (r *CostRepository) ListOptimizations(ctx context.Context, userID int64, st
 	}
 
 	paramN = 1
-	query := fmt.Sprintf(`
-		SELECT id, user_id, provider, resource_id, type, title, description, current_cost, estimated_savings, savings_percentage, implementation_effort, status, created_at, updated_at
-		FROM cost_optimizations
-		WHERE user_id = $%d
-	`, paramN)
+	query := fmt.Sprintf(`SELECT `+costOptimizationColumns+` FROM cost_optimizations WHERE user_id = $%d`, paramN)
 	queryArgs := []interface{}{userID}
 	paramN++
 
@@ -414,12 +485,7 @@ func (r *CostRepository) ListOptimizations(ctx context.Context, userID int64, st
 
 	var optimizations []*cost.CostOptimization
 	for rows.Next() {
-		o := &cost.CostOptimization{}
-		err := rows.Scan(
-			&o.ID, &o.UserID, &o.Provider, &o.ResourceID, &o.OptimizationType,
-			&o.Title, &o.Description, &o.CurrentCost, &o.EstimatedSavings, &o.SavingsPercent,
-			&o.Implementation, &o.Status, &o.CreatedAt, &o.UpdatedAt,
-		)
+		o, err := scanCostOptimization(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -429,6 +495,40 @@ func (r *CostRepository) ListOptimizations(ctx context.Context, userID int64, st
 	return optimizations, total, rows.Err()
 }
 
+// optimizationScanner is satisfied by *sql.Row and *sql.Rows
+type optimizationScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCostOptimization scans a row selected with costOptimizationColumns
+func scanCostOptimization(row optimizationScanner) (*cost.CostOptimization, error) {
+	var o cost.CostOptimization
+	var resourceID, resourceType, description, implementation, status, details sql.NullString
+	var savingsPercent sql.NullFloat64
+
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.Provider, &resourceID, &resourceType, &o.OptimizationType,
+		&o.Title, &description, &o.CurrentCost, &o.EstimatedSavings, &savingsPercent,
+		&implementation, &status, &details, &o.Fingerprint, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resourceID.Valid {
+		o.ResourceID = &resourceID.String
+	}
+	o.ResourceType = resourceType.String
+	o.Description = description.String
+	o.SavingsPercent = savingsPercent.Float64
+	o.Implementation = implementation.String
+	o.Status = status.String
+	if details.Valid && details.String != "" && details.String != "null" {
+		o.Details = json.RawMessage(details.String)
+	}
+	return &o, nil
+}
+
 // GetTotalPotentialSavings returns total potential savings
 func (r *CostRepository) GetTotalPotentialSavings(ctx context.Context, userID int64) (float64, error) {
 	query := `SELECT COALESCE(SUM(estimated_savings), 0) FROM cost_optimizations WHERE user_id = $1 AND status = 'pending'`
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+func TestCostRepository_AnomalyFingerprint(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	anomaly := &cost.CostAnomaly{
+		UserID:       1,
+		Provider:     cost.ProviderAWS,
+		ServiceName:  "EC2",
+		AnomalyType:  cost.AnomalyTypeLevelShift,
+		ExpectedCost: 100,
+		ActualCost:   200,
+		Deviation:    100,
+		Severity:     "high",
+		Fingerprint:  "abc123",
+		Drivers:      []cost.CostDriver{{Dimension: cost.DriverDimensionRegion, Name: "us-east-1", ExpectedCost: 100, ActualCost: 200, Delta: 100, Share: 1}},
+		Status:       cost.AnomalyStatusOpen,
+		DetectedAt:   time.Now().UTC().Truncate(24 * time.Hour),
+	}
+	if err := repo.CreateAnomaly(ctx, anomaly); err != nil {
+		t.Fatalf("CreateAnomaly() error = %v", err)
+	}
+	if anomaly.ID == "" {
+		t.Fatal("CreateAnomaly() did not set an ID")
+	}
+
+	got, err := repo.GetAnomalyByFingerprint(ctx, 1, "abc123")
+	if err != nil {
+		t.Fatalf("GetAnomalyByFingerprint() error = %v", err)
+	}
+	if got.ID != anomaly.ID || got.Provider != cost.ProviderAWS || len(got.Drivers) != 1 || got.Drivers[0] != anomaly.Drivers[0] {
+		t.Fatalf("GetAnomalyByFingerprint() = %+v", got)
+	}
+	if _, err := repo.GetAnomalyByFingerprint(ctx, 2, "abc123"); err == nil {
+		t.Fatal("GetAnomalyByFingerprint() of another user should fail")
+	}
+
+	// Resolved anomalies still count as already detected
+	got.Status = cost.AnomalyStatusResolved
+	if err := repo.UpdateAnomaly(ctx, got); err != nil {
+		t.Fatalf("UpdateAnomaly() error = %v", err)
+	}
+	if _, err := repo.GetAnomalyByFingerprint(ctx, 1, "abc123"); err != nil {
+		t.Fatalf("GetAnomalyByFingerprint() of a resolved anomaly error = %v", err)
+	}
+
+	listed, total, err := repo.ListAnomalies(ctx, 1, cost.AnomalyStatusResolved, 10, 0)
+	if err != nil || total != 1 || len(listed) != 1 || listed[0].ID != anomaly.ID {
+		t.Fatalf("ListAnomalies() = %v, %d, %v", listed, total, err)
+	}
+}
+
+func TestCostRepository_GetCostSummaryFilters(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	day := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []*cost.Cost{
+		{UserID: 1, AccountID: 7, Provider: cost.ProviderAWS, ServiceName: "EC2", Region: "us-east-1", CostDate: day, DailyCost: 10, Currency: "USD", Tags: []byte(`{}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "S3", Region: "us-west-2", CostDate: day, DailyCost: 5, Currency: "USD"},
+		{UserID: 1, Provider: cost.ProviderGCP, ServiceName: "Compute Engine", Region: "us-central1", CostDate: day, DailyCost: 7, Currency: "USD"},
+	} {
+		if err := repo.CreateCost(ctx, c); err != nil {
+			t.Fatalf("CreateCost() error = %v", err)
+		}
+	}
+
+	summary, err := repo.GetCostSummary(ctx, 1, cost.Filter{Provider: cost.ProviderAWS}, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetCostSummary() error = %v", err)
+	}
+	if summary.TotalCost != 15 || len(summary.ByService) != 2 || len(summary.ByRegion) != 2 || summary.ByService["Compute Engine"] != 0 {
+		t.Fatalf("provider summary = %+v", summary)
+	}
+
+	summary, err = repo.GetCostSummary(ctx, 1, cost.Filter{Provider: cost.ProviderAWS, ServiceName: "EC2"}, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetCostSummary() error = %v", err)
+	}
+	if summary.TotalCost != 10 || len(summary.ByRegion) != 1 || summary.ByRegion["us-east-1"] != 10 {
+		t.Fatalf("service summary = %+v", summary)
+	}
+
+	costs, err := repo.GetCostsByDateRange(ctx, 1, cost.Filter{ServiceName: "EC2"}, day, day.AddDate(0, 0, 1))
+	if err != nil || len(costs) != 1 || costs[0].AccountID != 7 {
+		t.Fatalf("GetCostsByDateRange() = %+v, %v, want the record of account 7", costs, err)
+	}
+}
+
+func TestCostRepository_OptimizationFingerprint(t *testing.T) {
+	repo := NewCostRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	resourceID := "arn:aws:ec2:us-east-1:123456789012:volume/vol-0abc"
+	opt := &cost.CostOptimization{
+		UserID:           1,
+		Provider:         cost.ProviderAWS,
+		ResourceID:       &resourceID,
+		ResourceType:     "ebs-volume",
+		OptimizationType: cost.OptTypeUnused,
+		Title:            "Delete unattached volume",
+		CurrentCost:      20,
+		EstimatedSavings: 20,
+		SavingsPercent:   100,
+		Status:           cost.OptStatusPending,
+		Details:          []byte(`{"rule":"unattached_volume"}`),
+		Fingerprint:      "fp-1",
+	}
+	if err := repo.CreateOptimization(ctx, opt); err != nil {
+		t.Fatalf("CreateOptimization() error = %v", err)
+	}
+
+	got, err := repo.GetOptimizationByFingerprint(ctx, 1, "fp-1")
+	if err != nil {
+		t.Fatalf("GetOptimizationByFingerprint() error = %v", err)
+	}
+	if got.ID != opt.ID || got.ResourceID == nil || *got.ResourceID != resourceID || string(got.Details) != `{"rule":"unattached_volume"}` {
+		t.Fatalf("GetOptimizationByFingerprint() = %+v", got)
+	}
+	if _, err := repo.GetOptimizationByFingerprint(ctx, 2, "fp-1"); err == nil {
+		t.Fatal("GetOptimizationByFingerprint() of another user's finding should fail")
+	}
+
+	if total, err := repo.GetTotalPotentialSavings(ctx, 1); err != nil || total != 20 {
+		t.Fatalf("GetTotalPotentialSavings() = %v, %v", total, err)
+	}
+
+	got.EstimatedSavings = 25
+	got.Status = cost.OptStatusDismissed
+	if err := repo.UpdateOptimization(ctx, got); err != nil {
+		t.Fatalf("UpdateOptimization() error = %v", err)
+	}
+	list, total, err := repo.ListOptimizations(ctx, 1, cost.OptStatusDismissed, 10, 0)
+	if err != nil || total != 1 || len(list) != 1 || list[0].EstimatedSavings != 25 || list[0].Fingerprint != "fp-1" {
+		t.Fatalf("ListOptimizations() = %+v, %d, %v", list, total, err)
+	}
+	if total, err := repo.GetTotalPotentialSavings(ctx, 1); err != nil || total != 0 {
+		t.Fatalf("GetTotalPotentialSavings() after dismissal = %v, %v", total, err)
+	}
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -19,15 +20,75 @@ func NewDriftRepository(db *sql.DB) drift.Repository {
 	return &DriftRepository{db: db}
 }
 
+const driftColumns = `id, user_id, resource_id, type, severity, description, detected_at, status, fingerprint, last_seen_at, occurrence_count, resolved_at, changes`
+
+type driftScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDrift(row driftScanner) (*drift.Drift, error) {
+	var d drift.Drift
+	var fingerprint, changes sql.NullString
+	var lastSeenAt, resolvedAt sql.NullTime
+	var occurrences sql.NullInt64
+
+	err := row.Scan(&d.ID, &d.UserID, &d.ResourceID, &d.DriftType, &d.Severity, &d.Details, &d.DetectedAt, &d.Status,
+		&fingerprint, &lastSeenAt, &occurrences, &resolvedAt, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Valid && changes.String != "" {
+		if err := json.Unmarshal([]byte(changes.String), &d.Changes); err != nil {
+			return nil, err
+		}
+	}
+
+	d.Fingerprint = fingerprint.String
+	d.OccurrenceCount = int(occurrences.Int64)
+	if d.OccurrenceCount == 0 {
+		d.OccurrenceCount = 1
+	}
+	if lastSeenAt.Valid {
+		d.LastSeenAt = &lastSeenAt.Time
+	}
+	if resolvedAt.Valid {
+		d.ResolvedAt = &resolvedAt.Time
+	}
+	return &d, nil
+}
+
+func marshalDriftChanges(changes []drift.Change) (string, error) {
+	if len(changes) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func (r *DriftRepository) Create(ctx context.Context, d *drift.Drift) (int64, error) {
 	now := time.Now()
 	d.CreatedAt = now
 	d.DetectedAt = now
+	if d.LastSeenAt == nil {
+		d.LastSeenAt = &now
+	}
+	if d.OccurrenceCount < 1 {
+		d.OccurrenceCount = 1
+	}
 
-	query := `INSERT INTO drifts (user_id, resource_id, type, severity, description, detected_at, status) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	changes, err := marshalDriftChanges(d.Changes)
+	if err != nil {
+		return 0, errors.DatabaseError("Failed to marshal drift changes", err)
+	}
+
+	query := `INSERT INTO drifts (user_id, resource_id, type, severity, description, detected_at, status, fingerprint, last_seen_at, occurrence_count, changes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
 
 	var id int64
-	err := r.db.QueryRowContext(ctx, query, d.UserID, d.ResourceID, d.DriftType, d.Severity, d.Details, now, d.Status).Scan(&id)
+	err = r.db.QueryRowContext(ctx, query, d.UserID, d.ResourceID, d.DriftType, d.Severity, d.Details, now, d.Status, d.Fingerprint, d.LastSeenAt, d.OccurrenceCount, changes).Scan(&id)
 	if err != nil {
 		return 0, errors.DatabaseError("Failed to create drift", err)
 	}
@@ -36,10 +97,9 @@ func (r *DriftRepository) Create(ctx context.Context, d *drift.Drift) (int64, er
 }
 
 func (r *DriftRepository) GetByID(ctx context.Context, userID int64, id int64) (*drift.Drift, error) {
-	query := `SELECT id, user_id, resource_id, type, severity, description, detected_at, status FROM drifts WHERE user_id = $1 AND id = $2`
+	query := `SELECT ` + driftColumns + ` FROM drifts WHERE user_id = $1 AND id = $2`
 
-	var d drift.Drift
-	err := r.db.QueryRowContext(ctx, query, userID, id).Scan(&d.ID, &d.UserID, &d.ResourceID, &d.DriftType, &d.Severity, &d.Details, &d.DetectedAt, &d.Status)
+	d, err := scanDrift(r.db.QueryRowContext(ctx, query, userID, id))
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound("Drift")
@@ -48,14 +108,34 @@ func (r *DriftRepository) GetByID(ctx context.Context, userID int64, id int64) (
 		return nil, errors.DatabaseError("Failed to get drift", err)
 	}
 
-	return &d, nil
+	return d, nil
+}
+
+func (r *DriftRepository) GetByFingerprint(ctx context.Context, userID int64, fingerprint string) (*drift.Drift, error) {
+	query := `SELECT ` + driftColumns + ` FROM drifts WHERE user_id = $1 AND fingerprint = $2 AND status != $3 ORDER BY id DESC LIMIT 1`
+
+	d, err := scanDrift(r.db.QueryRowContext(ctx, query, userID, fingerprint, drift.StatusResolved))
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Drift")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get drift", err)
+	}
+
+	return d, nil
 }
 
 func (r *DriftRepository) Update(ctx context.Context, d *drift.Drift) error {
 	d.UpdatedAt = time.Now()
-	query := `UPDATE drifts SET resource_id = $1, type = $2, severity = $3, description = $4, status = $5 WHERE user_id = $6 AND id = $7`
+	changes, err := marshalDriftChanges(d.Changes)
+	if err != nil {
+		return errors.DatabaseError("Failed to marshal drift changes", err)
+	}
+
+	query := `UPDATE drifts SET resource_id = $1, type = $2, severity = $3, description = $4, status = $5, fingerprint = $6, last_seen_at = $7, occurrence_count = $8, resolved_at = $9, changes = $10, updated_at = $11 WHERE user_id = $12 AND id = $13`
 
-	result, err := r.db.ExecContext(ctx, query, d.ResourceID, d.DriftType, d.Severity, d.Details, d.Status, d.UserID, d.ID)
+	result, err := r.db.ExecContext(ctx, query, d.ResourceID, d.DriftType, d.Severity, d.Details, d.Status, d.Fingerprint, d.LastSeenAt, d.OccurrenceCount, d.ResolvedAt, changes, d.UpdatedAt, d.UserID, d.ID)
 	if err != nil {
 		return errors.DatabaseError("Failed to update drift", err)
 	}
@@ -108,8 +188,17 @@ func (r *DriftRepository) List(ctx context.Context, userID int64, filter drift.F
 		args = append(args, filter.Status)
 		paramN++
 	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = fmt.Sprintf("$%d", paramN)
+			args = append(args, status)
+			paramN++
+		}
+		where = append(where, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
 
-	query := fmt.Sprintf(`SELECT id, user_id, resource_id, type, severity, description, detected_at, status FROM drifts WHERE %s ORDER BY id DESC`, strings.Join(where, " AND "))
+	query := fmt.Sprintf(`SELECT `+driftColumns+` FROM drifts WHERE %s ORDER BY id DESC`, strings.Join(where, " AND "))
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -120,12 +209,11 @@ func (r *DriftRepository) List(ctx context.Context, userID int64, filter drift.F
 	// Pre-allocate slice with reasonable capacity
 	drifts := make([]*drift.Drift, 0, 100)
 	for rows.Next() {
-		var d drift.Drift
-		err := rows.Scan(&d.ID, &d.UserID, &d.ResourceID, &d.DriftType, &d.Severity, &d.Details, &d.DetectedAt, &d.Status)
+		d, err := scanDrift(rows)
 		if err != nil {
 			return nil, errors.DatabaseError("Failed to scan drift", err)
 		}
-		drifts = append(drifts, &d)
+		drifts = append(drifts, d)
 	}
 
 	return drifts, rows.Err()
@@ -156,7 +244,7 @@ func (r *DriftRepository) ListWithPagination(ctx context.Context, userID int64,
 		return nil, 0, errors.DatabaseError("Failed to count drifts", err)
 	}
 
-	query := fmt.Sprintf(`SELECT id, user_id, resource_id, type, severity, description, detected_at, status FROM drifts WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d`, whereClause, paramN, paramN+1)
+	query := fmt.Sprintf(`SELECT `+driftColumns+` FROM drifts WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d`, whereClause, paramN, paramN+1)
 
 	args = append(args, limit, offset)
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -168,21 +256,30 @@ func (r *DriftRepository) ListWithPagination(ctx context.Context, userID int64,
 	// Pre-allocate slice with expected capacity
 	drifts := make([]*drift.Drift, 0, limit)
 	for rows.Next() {
-		var d drift.Drift
-		err := rows.Scan(&d.ID, &d.UserID, &d.ResourceID, &d.DriftType, &d.Severity, &d.Details, &d.DetectedAt, &d.Status)
+		d, err := scanDrift(rows)
 		if err != nil {
 			return nil, 0, errors.DatabaseError("Failed to scan drift", err)
 		}
-		drifts = append(drifts, &d)
+		drifts = append(drifts, d)
 	}
 
 	return drifts, total, rows.Err()
 }
 
-func (r *DriftRepository) CountBySeverity(ctx context.Context, userID int64) (map[string]int, error) {
-	query := `SELECT severity, COUNT(*) FROM drifts WHERE user_id = $1 GROUP BY severity`
+func (r *DriftRepository) CountBySeverity(ctx context.Context, userID int64, statuses ...string) (map[string]int, error) {
+	where := "user_id = $1"
+	args := []interface{}{userID}
+	if len(statuses) > 0 {
+		placeholders := make([]string, len(statuses))
+		for i, status := range statuses {
+			placeholders[i] = fmt.Sprintf("$%d", i+2)
+			args = append(args, status)
+		}
+		where += fmt.Sprintf(" AND status IN (%s)", strings.Join(placeholders, ", "))
+	}
+	query := fmt.Sprintf(`SELECT severity, COUNT(*) FROM drifts WHERE %s GROUP BY severity`, where)
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.DatabaseError("Failed to count drifts by severity", err)
 	}
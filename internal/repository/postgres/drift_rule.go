@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+// DriftRuleRepository handles database operations for custom drift rules
+type DriftRuleRepository struct {
+	db *sql.DB
+}
+
+// NewDriftRuleRepository creates a new drift rule repository
+func NewDriftRuleRepository(db *sql.DB) drift.RuleRepository {
+	return &DriftRuleRepository{db: db}
+}
+
+const driftRuleColumns = `rule_id, user_id, description, resource_types, field, change_types, old_predicate, new_predicate, severity, drift_type, is_enabled, created_at, updated_at`
+
+// Create stores a new custom rule
+func (r *DriftRuleRepository) Create(ctx context.Context, rule *drift.Rule) error {
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	resourceTypes, changeTypes, oldPredicate, newPredicate, err := marshalDriftRule(rule)
+	if err != nil {
+		return errors.DatabaseError("Failed to marshal drift rule", err)
+	}
+
+	query := `
+		INSERT INTO drift_rules
+		(id, rule_id, user_id, description, resource_types, field, change_types, old_predicate, new_predicate, severity, drift_type, is_enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		uuid.New().String(),
+		rule.ID,
+		rule.UserID,
+		rule.Description,
+		resourceTypes,
+		rule.Field,
+		changeTypes,
+		oldPredicate,
+		newPredicate,
+		rule.Severity,
+		rule.DriftType,
+		rule.Enabled,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create drift rule", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a custom rule by its rule ID
+func (r *DriftRuleRepository) GetByID(ctx context.Context, userID int64, id string) (*drift.Rule, error) {
+	query := `SELECT ` + driftRuleColumns + ` FROM drift_rules WHERE user_id = $1 AND rule_id = $2`
+
+	rule, err := scanDriftRule(r.db.QueryRowContext(ctx, query, userID, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Drift rule")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get drift rule", err)
+	}
+
+	return rule, nil
+}
+
+// Update updates a custom rule
+func (r *DriftRuleRepository) Update(ctx context.Context, rule *drift.Rule) error {
+	rule.UpdatedAt = time.Now()
+
+	resourceTypes, changeTypes, oldPredicate, newPredicate, err := marshalDriftRule(rule)
+	if err != nil {
+		return errors.DatabaseError("Failed to marshal drift rule", err)
+	}
+
+	query := `
+		UPDATE drift_rules
+		SET description = $1, resource_types = $2, field = $3, change_types = $4, old_predicate = $5,
+			new_predicate = $6, severity = $7, drift_type = $8, is_enabled = $9, updated_at = $10
+		WHERE user_id = $11 AND rule_id = $12
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Description,
+		resourceTypes,
+		rule.Field,
+		changeTypes,
+		oldPredicate,
+		newPredicate,
+		rule.Severity,
+		rule.DriftType,
+		rule.Enabled,
+		rule.UpdatedAt,
+		rule.UserID,
+		rule.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update drift rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Drift rule")
+	}
+
+	return nil
+}
+
+// Delete deletes a custom rule
+func (r *DriftRuleRepository) Delete(ctx context.Context, userID int64, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM drift_rules WHERE user_id = $1 AND rule_id = $2", userID, id)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete drift rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NotFound("Drift rule")
+	}
+
+	return nil
+}
+
+// List lists all custom rules of a user
+func (r *DriftRuleRepository) List(ctx context.Context, userID int64) ([]*drift.Rule, error) {
+	query := `SELECT ` + driftRuleColumns + ` FROM drift_rules WHERE user_id = $1 ORDER BY rule_id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list drift rules", err)
+	}
+	defer rows.Close()
+
+	var rules []*drift.Rule
+	for rows.Next() {
+		rule, err := scanDriftRule(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan drift rule", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+type driftRuleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDriftRule(row driftRuleScanner) (*drift.Rule, error) {
+	var rule drift.Rule
+	var description, resourceTypes, changeTypes, oldPredicate, newPredicate sql.NullString
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&description,
+		&resourceTypes,
+		&rule.Field,
+		&changeTypes,
+		&oldPredicate,
+		&newPredicate,
+		&rule.Severity,
+		&rule.DriftType,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Description = description.String
+	rule.Source = drift.RuleSourceCustom
+
+	if resourceTypes.Valid && resourceTypes.String != "" {
+		if err := json.Unmarshal([]byte(resourceTypes.String), &rule.ResourceTypes); err != nil {
+			return nil, err
+		}
+	}
+	if changeTypes.Valid && changeTypes.String != "" {
+		if err := json.Unmarshal([]byte(changeTypes.String), &rule.ChangeTypes); err != nil {
+			return nil, err
+		}
+	}
+	if oldPredicate.Valid && oldPredicate.String != "" && oldPredicate.String != "null" {
+		rule.Old = &drift.ValuePredicate{}
+		if err := json.Unmarshal([]byte(oldPredicate.String), rule.Old); err != nil {
+			return nil, err
+		}
+	}
+	if newPredicate.Valid && newPredicate.String != "" && newPredicate.String != "null" {
+		rule.New = &drift.ValuePredicate{}
+		if err := json.Unmarshal([]byte(newPredicate.String), rule.New); err != nil {
+			return nil, err
+		}
+	}
+
+	return &rule, nil
+}
+
+func marshalDriftRule(rule *drift.Rule) (resourceTypes, changeTypes, oldPredicate, newPredicate sql.NullString, err error) {
+	marshal := func(v interface{}, empty bool) (sql.NullString, error) {
+		if empty {
+			return sql.NullString{}, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		return sql.NullString{String: string(data), Valid: true}, nil
+	}
+
+	if resourceTypes, err = marshal(rule.ResourceTypes, len(rule.ResourceTypes) == 0); err != nil {
+		return
+	}
+	if changeTypes, err = marshal(rule.ChangeTypes, len(rule.ChangeTypes) == 0); err != nil {
+		return
+	}
+	if oldPredicate, err = marshal(rule.Old, rule.Old == nil); err != nil {
+		return
+	}
+	newPredicate, err = marshal(rule.New, rule.New == nil)
+	return
+}
@@ -706,3 +706,83 @@ func (r *JobRepository) CleanupOldExecutions(ctx context.Context, olderThan inte
 	rowsAffected, _ := result.RowsAffected()
 	return rowsAffected, nil
 }
+
+// AcquireLease takes the named lease for holder, or extends it if holder
+// already has it. The upsert only overwrites a row that holder owns or that
+// has expired, so concurrent callers cannot both win. The same statement
+// works on PostgreSQL and SQLite.
+func (r *JobRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO job_leases (name, holder, acquired_at, heartbeat_at, expires_at)
+		VALUES ($1, $2, $3, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			holder = excluded.holder,
+			acquired_at = CASE WHEN job_leases.holder = excluded.holder THEN job_leases.acquired_at ELSE excluded.acquired_at END,
+			heartbeat_at = excluded.heartbeat_at,
+			expires_at = excluded.expires_at
+		WHERE job_leases.holder = excluded.holder OR job_leases.expires_at < excluded.heartbeat_at
+	`
+
+	// Stored in UTC so that expiry comparisons agree across nodes and drivers
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, query, name, holder, now, now.Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ReleaseLease gives up a lease held by holder
+func (r *JobRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM job_leases WHERE name = $1 AND holder = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// ListLeases retrieves all leases that have not expired
+func (r *JobRepository) ListLeases(ctx context.Context) ([]*job.Lease, error) {
+	query := `
+		SELECT name, holder, acquired_at, heartbeat_at, expires_at
+		FROM job_leases
+		WHERE expires_at >= $1
+		ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leases: %w", err)
+	}
+	defer rows.Close()
+
+	var leases []*job.Lease
+	for rows.Next() {
+		var l job.Lease
+		if err := rows.Scan(&l.Name, &l.Holder, &l.AcquiredAt, &l.HeartbeatAt, &l.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lease: %w", err)
+		}
+		leases = append(leases, &l)
+	}
+
+	return leases, rows.Err()
+}
+
+// DeleteExpiredLeases removes leases left behind by nodes that stopped renewing them
+func (r *JobRepository) DeleteExpiredLeases(ctx context.Context) (int64, error) {
+	query := `DELETE FROM job_leases WHERE expires_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired leases: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected, nil
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/job"
+	"github.com/pratik-mahalle/infraudit/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// newLeaseTestDB creates an in-memory SQLite database with the job_leases table
+func newLeaseTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1) // every connection to :memory: is a separate database
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := fs.ReadFile(migrations.GetFS(), "013_add_job_leases.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+	return db
+}
+
+func TestJobRepository_Leases(t *testing.T) {
+	repo := NewJobRepository(newLeaseTestDB(t))
+	ctx := context.Background()
+
+	acquire := func(name, holder string, ttl time.Duration, want bool) {
+		t.Helper()
+		got, err := repo.AcquireLease(ctx, name, holder, ttl)
+		if err != nil {
+			t.Fatalf("AcquireLease(%s, %s) error = %v", name, holder, err)
+		}
+		if got != want {
+			t.Fatalf("AcquireLease(%s, %s) = %v, want %v", name, holder, got, want)
+		}
+	}
+
+	acquire(job.LeaseScheduler, "node-a", time.Minute, true)
+	acquire(job.LeaseScheduler, "node-b", time.Minute, false)
+	acquire(job.LeaseScheduler, "node-a", time.Minute, true) // heartbeat
+
+	leases, err := repo.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() error = %v", err)
+	}
+	if len(leases) != 1 || leases[0].Holder != "node-a" || !leases[0].ExpiresAt.After(leases[0].HeartbeatAt) {
+		t.Fatalf("ListLeases() = %+v", leases)
+	}
+
+	// Only the holder can release a lease
+	if err := repo.ReleaseLease(ctx, job.LeaseScheduler, "node-b"); err != nil {
+		t.Fatalf("ReleaseLease() error = %v", err)
+	}
+	acquire(job.LeaseScheduler, "node-b", time.Minute, false)
+	if err := repo.ReleaseLease(ctx, job.LeaseScheduler, "node-a"); err != nil {
+		t.Fatalf("ReleaseLease() error = %v", err)
+	}
+	acquire(job.LeaseScheduler, "node-b", time.Minute, true)
+
+	// An expired lease can be taken over and is no longer listed
+	acquire("execution:1", "node-a", -time.Second, true)
+	if leases, _ := repo.ListLeases(ctx); len(leases) != 1 {
+		t.Errorf("ListLeases() returned %d leases, want only the unexpired one", len(leases))
+	}
+	acquire("execution:1", "node-b", -time.Second, true)
+	if n, err := repo.DeleteExpiredLeases(ctx); err != nil || n != 1 {
+		t.Errorf("DeleteExpiredLeases() = %d, %v; want 1", n, err)
+	}
+}
@@ -205,7 +205,7 @@ func (r *NotificationRepository) CreateLog(ctx context.Context, l *notification.
 	}
 
 	query := `
-		INSERT INTO notification_logs (id, user_id, channel, event_type, status, priority, message, error_message, retry_count, sent_at, created_at)
+		INSERT INTO notification_logs (id, user_id, channel, notification_type, status, priority, payload, error_message, retry_count, sent_at, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
@@ -259,7 +259,7 @@ func (r *NotificationRepository) UpdateLog(ctx context.Context, l *notification.
 
 	// Update message (payload) if needed
 	if len(l.Payload) > 0 {
-		updateQuery := `UPDATE notification_logs SET message = $1 WHERE id = $2`
+		updateQuery := `UPDATE notification_logs SET payload = $1 WHERE id = $2`
 		r.db.ExecContext(ctx, updateQuery, string(payloadJSON), l.ID)
 	}
 
@@ -269,7 +269,7 @@ func (r *NotificationRepository) UpdateLog(ctx context.Context, l *notification.
 // ListLogs lists notification logs with filtering
 func (r *NotificationRepository) ListLogs(ctx context.Context, filter notification.LogFilter, limit, offset int) ([]*notification.Log, int64, error) {
 	baseSelect := `
-		SELECT id, user_id, channel, event_type, status, priority, message, error_message, retry_count, sent_at, created_at
+		SELECT id, user_id, channel, notification_type, status, priority, payload, error_message, retry_count, sent_at, created_at
 		FROM notification_logs
 		WHERE 1=1
 	`
@@ -289,7 +289,7 @@ func (r *NotificationRepository) ListLogs(ctx context.Context, filter notificati
 		paramN++
 	}
 	if filter.NotificationType != "" {
-		queryFilters += fmt.Sprintf(" AND event_type = $%d", paramN)
+		queryFilters += fmt.Sprintf(" AND notification_type = $%d", paramN)
 		args = append(args, string(filter.NotificationType))
 		paramN++
 	}
@@ -369,7 +369,7 @@ func (r *NotificationRepository) ListLogs(ctx context.Context, filter notificati
 // GetPendingLogs retrieves pending notification logs
 func (r *NotificationRepository) GetPendingLogs(ctx context.Context) ([]*notification.Log, error) {
 	query := `
-		SELECT id, user_id, channel, event_type, status, priority, message, error_message, retry_count, sent_at, created_at
+		SELECT id, user_id, channel, notification_type, status, priority, payload, error_message, retry_count, sent_at, created_at
 		FROM notification_logs
 		WHERE status IN ('pending', 'retrying')
 		ORDER BY priority DESC, created_at ASC
@@ -439,7 +439,7 @@ func (r *NotificationRepository) CreateWebhook(ctx context.Context, w *notificat
 	retryJSON, _ := json.Marshal(w.RetryConfig)
 
 	query := `
-		INSERT INTO webhooks (id, user_id, name, url, secret, event_types, is_enabled, retry_config, last_triggered_at, created_at, updated_at)
+		INSERT INTO webhooks (id, user_id, name, url, secret, events, is_enabled, retry_config, last_triggered, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
@@ -470,7 +470,7 @@ func (r *NotificationRepository) CreateWebhook(ctx context.Context, w *notificat
 // GetWebhook retrieves a webhook by ID
 func (r *NotificationRepository) GetWebhook(ctx context.Context, id string) (*notification.Webhook, error) {
 	query := `
-		SELECT id, user_id, name, url, secret, event_types, is_enabled, retry_config, last_triggered_at, created_at, updated_at
+		SELECT id, user_id, name, url, secret, events, is_enabled, retry_config, last_triggered, created_at, updated_at
 		FROM webhooks
 		WHERE id = $1
 	`
@@ -527,7 +527,7 @@ func (r *NotificationRepository) UpdateWebhook(ctx context.Context, w *notificat
 
 	query := `
 		UPDATE webhooks
-		SET name = $1, url = $2, secret = $3, event_types = $4, is_enabled = $5, retry_config = $6, last_triggered_at = $7, updated_at = $8
+		SET name = $1, url = $2, secret = $3, events = $4, is_enabled = $5, retry_config = $6, last_triggered = $7, updated_at = $8
 		WHERE id = $9
 	`
 
@@ -583,7 +583,7 @@ func (r *NotificationRepository) ListWebhooks(ctx context.Context, userID int64,
 	}
 
 	query := `
-		SELECT id, user_id, name, url, secret, event_types, is_enabled, retry_config, last_triggered_at, created_at, updated_at
+		SELECT id, user_id, name, url, secret, events, is_enabled, retry_config, last_triggered, created_at, updated_at
 		FROM webhooks
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -641,7 +641,7 @@ func (r *NotificationRepository) ListWebhooks(ctx context.Context, userID int64,
 // GetWebhooksForEvent retrieves webhooks subscribed to an event
 func (r *NotificationRepository) GetWebhooksForEvent(ctx context.Context, userID int64, eventType notification.EventType) ([]*notification.Webhook, error) {
 	query := `
-		SELECT id, user_id, name, url, secret, event_types, is_enabled, retry_config, last_triggered_at, created_at, updated_at
+		SELECT id, user_id, name, url, secret, events, is_enabled, retry_config, last_triggered, created_at, updated_at
 		FROM webhooks
 		WHERE user_id = $1 AND is_enabled = true
 	`
@@ -702,6 +702,8 @@ func (r *NotificationRepository) GetWebhooksForEvent(ctx context.Context, userID
 
 // ===== Webhook Deliveries =====
 
+const deliveryColumns = `id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, attempted_at, next_attempt_at, delivered_at, created_at`
+
 // CreateDelivery creates a new webhook delivery
 func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *notification.WebhookDelivery) error {
 	if d.ID == "" {
@@ -709,8 +711,8 @@ func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *notifica
 	}
 
 	query := `
-		INSERT INTO webhook_deliveries (id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, attempted_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	now := time.Now()
@@ -723,6 +725,8 @@ func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *notifica
 		d.ResponseStatus,
 		d.ResponseBody,
 		d.RetryCount,
+		d.AttemptedAt,
+		d.NextAttemptAt,
 		d.DeliveredAt,
 		now,
 	)
@@ -739,8 +743,9 @@ func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *notifica
 func (r *NotificationRepository) UpdateDelivery(ctx context.Context, d *notification.WebhookDelivery) error {
 	query := `
 		UPDATE webhook_deliveries
-		SET status = $1, response_status = $2, response_body = $3, retry_count = $4, attempted_at = $5
-		WHERE id = $6
+		SET status = $1, response_status = $2, response_body = $3, retry_count = $4,
+			attempted_at = $5, next_attempt_at = $6, delivered_at = $7
+		WHERE id = $8
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -748,6 +753,8 @@ func (r *NotificationRepository) UpdateDelivery(ctx context.Context, d *notifica
 		d.ResponseStatus,
 		d.ResponseBody,
 		d.RetryCount,
+		d.AttemptedAt,
+		d.NextAttemptAt,
 		d.DeliveredAt,
 		d.ID,
 	)
@@ -759,17 +766,34 @@ func (r *NotificationRepository) UpdateDelivery(ctx context.Context, d *notifica
 	return nil
 }
 
-// GetPendingDeliveries retrieves pending webhook deliveries
+// GetDelivery retrieves a webhook delivery by ID
+func (r *NotificationRepository) GetDelivery(ctx context.Context, id string) (*notification.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	d, err := scanDelivery(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetPendingDeliveries retrieves webhook deliveries that are due for an
+// attempt: pending or retrying, with no next attempt time or one in the past
 func (r *NotificationRepository) GetPendingDeliveries(ctx context.Context) ([]*notification.WebhookDelivery, error) {
 	query := `
-		SELECT id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, attempted_at, created_at
+		SELECT ` + deliveryColumns + `
 		FROM webhook_deliveries
-		WHERE status = 'pending'
+		WHERE status IN ('pending', 'retrying')
+			AND (next_attempt_at IS NULL OR next_attempt_at <= $1)
 		ORDER BY created_at ASC
 		LIMIT 100
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, time.Now())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending deliveries: %w", err)
 	}
@@ -777,40 +801,14 @@ func (r *NotificationRepository) GetPendingDeliveries(ctx context.Context) ([]*n
 
 	var deliveries []*notification.WebhookDelivery
 	for rows.Next() {
-		var d notification.WebhookDelivery
-		var eventType, status string
-		var deliveredAt sql.NullTime
-		var responseBody sql.NullString
-
-		err := rows.Scan(
-			&d.ID,
-			&d.WebhookID,
-			&eventType,
-			&d.Payload,
-			&status,
-			&d.ResponseStatus,
-			&responseBody,
-			&d.RetryCount,
-			&deliveredAt,
-			&d.CreatedAt,
-		)
+		d, err := scanDelivery(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
 		}
-
-		d.EventType = notification.EventType(eventType)
-		d.Status = notification.DeliveryStatus(status)
-		if responseBody.Valid {
-			d.ResponseBody = responseBody.String
-		}
-		if deliveredAt.Valid {
-			d.DeliveredAt = &deliveredAt.Time
-		}
-
-		deliveries = append(deliveries, &d)
+		deliveries = append(deliveries, d)
 	}
 
-	return deliveries, nil
+	return deliveries, rows.Err()
 }
 
 // ListDeliveries lists webhook deliveries
@@ -823,7 +821,7 @@ func (r *NotificationRepository) ListDeliveries(ctx context.Context, webhookID s
 	}
 
 	query := `
-		SELECT id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, attempted_at, created_at
+		SELECT ` + deliveryColumns + `
 		FROM webhook_deliveries
 		WHERE webhook_id = $1
 		ORDER BY created_at DESC
@@ -838,38 +836,60 @@ func (r *NotificationRepository) ListDeliveries(ctx context.Context, webhookID s
 
 	var deliveries []*notification.WebhookDelivery
 	for rows.Next() {
-		var d notification.WebhookDelivery
-		var eventType, status string
-		var deliveredAt sql.NullTime
-		var responseBody sql.NullString
-
-		err := rows.Scan(
-			&d.ID,
-			&d.WebhookID,
-			&eventType,
-			&d.Payload,
-			&status,
-			&d.ResponseStatus,
-			&responseBody,
-			&d.RetryCount,
-			&deliveredAt,
-			&d.CreatedAt,
-		)
+		d, err := scanDelivery(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
 		}
+		deliveries = append(deliveries, d)
+	}
 
-		d.EventType = notification.EventType(eventType)
-		d.Status = notification.DeliveryStatus(status)
-		if responseBody.Valid {
-			d.ResponseBody = responseBody.String
-		}
-		if deliveredAt.Valid {
-			d.DeliveredAt = &deliveredAt.Time
-		}
+	return deliveries, total, rows.Err()
+}
 
-		deliveries = append(deliveries, &d)
+// deliveryScanner is implemented by *sql.Row and *sql.Rows
+type deliveryScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanDelivery scans a webhook delivery selected with deliveryColumns
+func scanDelivery(row deliveryScanner) (*notification.WebhookDelivery, error) {
+	var d notification.WebhookDelivery
+	var eventType, status string
+	var responseStatus sql.NullInt64
+	var responseBody sql.NullString
+	var attemptedAt, nextAttemptAt, deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&eventType,
+		&d.Payload,
+		&status,
+		&responseStatus,
+		&responseBody,
+		&d.RetryCount,
+		&attemptedAt,
+		&nextAttemptAt,
+		&deliveredAt,
+		&d.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d.EventType = notification.EventType(eventType)
+	d.Status = notification.DeliveryStatus(status)
+	d.ResponseStatus = int(responseStatus.Int64)
+	d.ResponseBody = responseBody.String
+	if attemptedAt.Valid {
+		d.AttemptedAt = &attemptedAt.Time
+	}
+	if nextAttemptAt.Valid {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
 	}
 
-	return deliveries, total, nil
+	return &d, nil
 }
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// newMigratedTestDB creates an in-memory SQLite database with every migration applied
+func newMigratedTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1) // every connection to :memory: is a separate database
+	t.Cleanup(func() { db.Close() })
+
+	if err := RunMigrations(db, migrations.GetFS()); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+func TestNotificationRepository_Logs(t *testing.T) {
+	repo := NewNotificationRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	log := &notification.Log{
+		UserID:           1,
+		Channel:          notification.ChannelSlack,
+		NotificationType: notification.NotificationTypeDriftAlert,
+		Status:           notification.DeliveryStatusPending,
+		Priority:         notification.PriorityHigh,
+		Payload:          json.RawMessage(`{"title":"Drift"}`),
+	}
+	if err := repo.CreateLog(ctx, log); err != nil {
+		t.Fatalf("CreateLog() error = %v", err)
+	}
+
+	log.Status = notification.DeliveryStatusRetrying
+	log.RetryCount = 1
+	log.ErrorMessage = "slack unavailable"
+	if err := repo.UpdateLog(ctx, log); err != nil {
+		t.Fatalf("UpdateLog() error = %v", err)
+	}
+
+	pending, err := repo.GetPendingLogs(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingLogs() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].RetryCount != 1 || pending[0].NotificationType != notification.NotificationTypeDriftAlert {
+		t.Fatalf("GetPendingLogs() = %+v", pending)
+	}
+
+	logs, total, err := repo.ListLogs(ctx, notification.LogFilter{UserID: 1, NotificationType: notification.NotificationTypeDriftAlert}, 10, 0)
+	if err != nil {
+		t.Fatalf("ListLogs() error = %v", err)
+	}
+	if total != 1 || len(logs) != 1 || logs[0].Status != notification.DeliveryStatusRetrying {
+		t.Fatalf("ListLogs() = %+v, total %d", logs, total)
+	}
+}
+
+func TestNotificationRepository_Deliveries(t *testing.T) {
+	repo := NewNotificationRepository(newMigratedTestDB(t))
+	ctx := context.Background()
+
+	webhook := &notification.Webhook{
+		UserID:    1,
+		Name:      "ops",
+		URL:       "https://hooks.example.com/infraudit",
+		Events:    []notification.EventType{notification.EventDriftDetected},
+		IsEnabled: true,
+	}
+	if err := repo.CreateWebhook(ctx, webhook); err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	subscribed, err := repo.GetWebhooksForEvent(ctx, 1, notification.EventDriftDetected)
+	if err != nil || len(subscribed) != 1 {
+		t.Fatalf("GetWebhooksForEvent() = %v, %v", subscribed, err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	due := &notification.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventType: notification.EventDriftDetected,
+		Payload:   `{"event":"drift.detected"}`,
+		Status:    notification.DeliveryStatusPending,
+	}
+	notDue := &notification.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventType:     notification.EventDriftDetected,
+		Payload:       `{"event":"drift.detected"}`,
+		Status:        notification.DeliveryStatusRetrying,
+		NextAttemptAt: &later,
+	}
+	for _, d := range []*notification.WebhookDelivery{due, notDue} {
+		if err := repo.CreateDelivery(ctx, d); err != nil {
+			t.Fatalf("CreateDelivery() error = %v", err)
+		}
+	}
+
+	pending, err := repo.GetPendingDeliveries(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingDeliveries() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != due.ID {
+		t.Fatalf("GetPendingDeliveries() = %+v, want only %s", pending, due.ID)
+	}
+
+	now := time.Now()
+	due.Status = notification.DeliveryStatusDeadLetter
+	due.RetryCount = 4
+	due.ResponseStatus = 503
+	due.ResponseBody = "unavailable"
+	due.AttemptedAt = &now
+	if err := repo.UpdateDelivery(ctx, due); err != nil {
+		t.Fatalf("UpdateDelivery() error = %v", err)
+	}
+
+	got, err := repo.GetDelivery(ctx, due.ID)
+	if err != nil {
+		t.Fatalf("GetDelivery() error = %v", err)
+	}
+	if got.Status != notification.DeliveryStatusDeadLetter || got.RetryCount != 4 || got.ResponseStatus != 503 || got.AttemptedAt == nil {
+		t.Fatalf("GetDelivery() = %+v", got)
+	}
+
+	if _, err := repo.GetDelivery(ctx, "missing"); err == nil {
+		t.Fatal("GetDelivery() of a missing delivery should fail")
+	}
+
+	deliveries, total, err := repo.ListDeliveries(ctx, webhook.ID, 10, 0)
+	if err != nil || total != 2 || len(deliveries) != 2 {
+		t.Fatalf("ListDeliveries() = %d deliveries, total %d, err %v", len(deliveries), total, err)
+	}
+}
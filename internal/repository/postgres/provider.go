@@ -3,66 +3,63 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/secrets"
 )
 
-// ProviderRepository implements provider.Repository
+// ProviderRepository implements provider.Repository. Credentials are
+// envelope-encrypted with the keyring before they are stored.
 type ProviderRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	keyring *secrets.Keyring
 }
 
 // NewProviderRepository creates a new provider repository
-func NewProviderRepository(db *sql.DB) provider.Repository {
-	return &ProviderRepository{db: db}
+func NewProviderRepository(db *sql.DB, keyring *secrets.Keyring) provider.Repository {
+	return &ProviderRepository{db: db, keyring: keyring}
 }
 
-// Upsert creates or updates a provider account
+// Upsert creates or updates the account with the provider's user, type and
+// name, and sets its ID
 func (r *ProviderRepository) Upsert(ctx context.Context, p *provider.Provider) error {
 	now := time.Now()
 	p.UpdatedAt = now
+	if p.Name == "" {
+		p.Name = provider.DefaultAccountName
+	}
 
-	// Compute the region based on provider type (DB uses single generic column)
-	var regionValue string
-	switch p.Provider {
-	case "aws":
-		regionValue = p.Credentials.AWSRegion
-	case "gcp":
-		regionValue = p.Credentials.GCPRegion
-	case "azure":
-		regionValue = p.Credentials.AzureLocation
+	envelope, err := r.sealCredentials(p.UserID, p.Credentials)
+	if err != nil {
+		return err
 	}
 
 	query := `
 		INSERT INTO provider_accounts (
-			user_id, provider, is_connected, last_synced,
-			access_key_id, secret_access_key, region,
-			project_id, service_account_json,
-			tenant_id, client_id, client_secret, subscription_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		ON CONFLICT(user_id, provider) DO UPDATE SET
+			user_id, provider, name, is_connected, last_synced,
+			credentials, credentials_data_key, credentials_key_version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT(user_id, provider, name) DO UPDATE SET
 			is_connected = excluded.is_connected,
 			last_synced = excluded.last_synced,
-			access_key_id = excluded.access_key_id,
-			secret_access_key = excluded.secret_access_key,
-			region = excluded.region,
-			project_id = excluded.project_id,
-			service_account_json = excluded.service_account_json,
-			tenant_id = excluded.tenant_id,
-			client_id = excluded.client_id,
-			client_secret = excluded.client_secret,
-			subscription_id = excluded.subscription_id
+			credentials = excluded.credentials,
+			credentials_data_key = excluded.credentials_data_key,
+			credentials_key_version = excluded.credentials_key_version,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		p.UserID, p.Provider, p.IsConnected, p.LastSynced,
-		p.Credentials.AWSAccessKeyID, p.Credentials.AWSSecretAccessKey, regionValue,
-		p.Credentials.GCPProjectID, p.Credentials.GCPServiceAccountJSON,
-		p.Credentials.AzureTenantID, p.Credentials.AzureClientID, p.Credentials.AzureClientSecret,
-		p.Credentials.AzureSubscriptionID,
-	)
+	err = r.db.QueryRowContext(ctx, query,
+		p.UserID, p.Provider, p.Name, p.IsConnected, p.LastSynced,
+		base64.StdEncoding.EncodeToString(envelope.Ciphertext),
+		base64.StdEncoding.EncodeToString(envelope.DataKey),
+		envelope.KeyVersion, now,
+	).Scan(&p.ID, &p.CreatedAt)
 	if err != nil {
 		return errors.DatabaseError("Failed to upsert provider", err)
 	}
@@ -70,48 +67,111 @@ func (r *ProviderRepository) Upsert(ctx context.Context, p *provider.Provider) e
 	return nil
 }
 
-// scanProvider scans a provider row from the DB generic columns into the Go struct
-func scanProvider(scan func(dest ...any) error) (*provider.Provider, error) {
-	var p provider.Provider
+// credentialsAAD binds stored credentials to their owner, so ciphertext
+// copied into another user's row does not decrypt
+func credentialsAAD(userID int64) []byte {
+	return []byte(fmt.Sprintf("provider_accounts:%d", userID))
+}
+
+// sealCredentials encrypts credentials with the active master key
+func (r *ProviderRepository) sealCredentials(userID int64, creds provider.Credentials) (*secrets.Envelope, error) {
+	if r.keyring == nil {
+		return nil, errors.Internal("Credential encryption is not configured", nil)
+	}
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return nil, errors.Internal("Failed to encode credentials", err)
+	}
+	envelope, err := r.keyring.Seal(plaintext, credentialsAAD(userID))
+	if err != nil {
+		return nil, errors.Internal("Failed to encrypt credentials", err)
+	}
+	return envelope, nil
+}
+
+// openCredentials decrypts stored credentials. Key version 0 marks plain JSON
+// carried over from before credentials were encrypted.
+func (r *ProviderRepository) openCredentials(userID int64, ciphertext, dataKey sql.NullString, keyVersion int) (provider.Credentials, error) {
 	var creds provider.Credentials
-	var region string
+	if !ciphertext.Valid || ciphertext.String == "" {
+		return creds, nil
+	}
+
+	plaintext := []byte(ciphertext.String)
+	if keyVersion != 0 {
+		if r.keyring == nil {
+			return creds, fmt.Errorf("credentials are encrypted with key version %d but no keyring is configured", keyVersion)
+		}
+		sealed, err := base64.StdEncoding.DecodeString(ciphertext.String)
+		if err != nil {
+			return creds, err
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(dataKey.String)
+		if err != nil {
+			return creds, err
+		}
+		plaintext, err = r.keyring.Open(&secrets.Envelope{Ciphertext: sealed, DataKey: wrapped, KeyVersion: keyVersion}, credentialsAAD(userID))
+		if err != nil {
+			return creds, err
+		}
+	}
+
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return creds, err
+	}
+	return creds, nil
+}
+
+// scanProvider scans a provider row and decrypts its credentials. It also
+// returns the master key version the credentials were stored under.
+func (r *ProviderRepository) scanProvider(scan func(dest ...any) error) (*provider.Provider, int, error) {
+	var p provider.Provider
+	var ciphertext, dataKey sql.NullString
+	var keyVersion int
 
 	err := scan(
-		&p.UserID, &p.Provider, &p.IsConnected, &p.LastSynced,
-		&creds.AWSAccessKeyID, &creds.AWSSecretAccessKey, &region,
-		&creds.GCPProjectID, &creds.GCPServiceAccountJSON,
-		&creds.AzureTenantID, &creds.AzureClientID, &creds.AzureClientSecret,
-		&creds.AzureSubscriptionID,
+		&p.ID, &p.UserID, &p.Provider, &p.Name, &p.IsConnected, &p.LastSynced,
+		&p.SyncStatus, &p.SyncMessage,
+		&ciphertext, &dataKey, &keyVersion, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Map the generic region column to the provider-specific field
-	switch p.Provider {
-	case "aws":
-		creds.AWSRegion = region
-	case "gcp":
-		creds.GCPRegion = region
-	case "azure":
-		creds.AzureLocation = region
+	p.Credentials, err = r.openCredentials(p.UserID, ciphertext, dataKey, keyVersion)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decrypt %s credentials: %w", p.Provider, err)
 	}
-
-	p.Credentials = creds
-	return &p, nil
+	return &p, keyVersion, nil
 }
 
-const providerSelectCols = `user_id, provider, is_connected, last_synced,
-	access_key_id, secret_access_key, region,
-	project_id, service_account_json,
-	tenant_id, client_id, client_secret, subscription_id`
+const providerSelectCols = `id, user_id, provider, name, is_connected, last_synced,
+	sync_status, sync_message,
+	credentials, credentials_data_key, credentials_key_version, created_at, updated_at`
 
-// GetByProvider retrieves a provider account by provider type
+// GetByID retrieves a provider account by ID
+func (r *ProviderRepository) GetByID(ctx context.Context, userID int64, id int64) (*provider.Provider, error) {
+	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1 AND id = $2`
+
+	row := r.db.QueryRowContext(ctx, query, userID, id)
+	p, _, err := r.scanProvider(row.Scan)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Provider account")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get provider account", err)
+	}
+
+	return p, nil
+}
+
+// GetByProvider retrieves the first account connected for a provider type
 func (r *ProviderRepository) GetByProvider(ctx context.Context, userID int64, providerType string) (*provider.Provider, error) {
-	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1 AND provider = $2`
+	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1 AND provider = $2 ORDER BY id LIMIT 1`
 
 	row := r.db.QueryRowContext(ctx, query, userID, providerType)
-	p, err := scanProvider(row.Scan)
+	p, _, err := r.scanProvider(row.Scan)
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound("Provider")
@@ -125,9 +185,18 @@ func (r *ProviderRepository) GetByProvider(ctx context.Context, userID int64, pr
 
 // List retrieves all provider accounts for a user
 func (r *ProviderRepository) List(ctx context.Context, userID int64) ([]*provider.Provider, error) {
-	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1`
+	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1 ORDER BY provider, name`
+	return r.list(ctx, query, userID)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+// ListByProvider retrieves the accounts of one provider type
+func (r *ProviderRepository) ListByProvider(ctx context.Context, userID int64, providerType string) ([]*provider.Provider, error) {
+	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE user_id = $1 AND provider = $2 ORDER BY name`
+	return r.list(ctx, query, userID, providerType)
+}
+
+func (r *ProviderRepository) list(ctx context.Context, query string, args ...interface{}) ([]*provider.Provider, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.DatabaseError("Failed to list providers", err)
 	}
@@ -135,7 +204,7 @@ func (r *ProviderRepository) List(ctx context.Context, userID int64) ([]*provide
 
 	var providers []*provider.Provider
 	for rows.Next() {
-		p, err := scanProvider(rows.Scan)
+		p, _, err := r.scanProvider(rows.Scan)
 		if err != nil {
 			return nil, errors.DatabaseError("Failed to scan provider", err)
 		}
@@ -150,10 +219,10 @@ func (r *ProviderRepository) List(ctx context.Context, userID int64) ([]*provide
 }
 
 // Delete deletes a provider account
-func (r *ProviderRepository) Delete(ctx context.Context, userID int64, providerType string) error {
-	query := `DELETE FROM provider_accounts WHERE user_id = $1 AND provider = $2`
+func (r *ProviderRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	query := `DELETE FROM provider_accounts WHERE user_id = $1 AND id = $2`
 
-	result, err := r.db.ExecContext(ctx, query, userID, providerType)
+	result, err := r.db.ExecContext(ctx, query, userID, id)
 	if err != nil {
 		return errors.DatabaseError("Failed to delete provider", err)
 	}
@@ -164,17 +233,23 @@ func (r *ProviderRepository) Delete(ctx context.Context, userID int64, providerT
 	}
 
 	if rows == 0 {
-		return errors.NotFound("Provider")
+		return errors.NotFound("Provider account")
 	}
 
 	return nil
 }
 
-// UpdateSyncStatus updates the sync status
-func (r *ProviderRepository) UpdateSyncStatus(ctx context.Context, userID int64, providerType string, lastSynced time.Time) error {
-	query := `UPDATE provider_accounts SET last_synced = $1 WHERE user_id = $2 AND provider = $3`
+// UpdateSyncStatus records the outcome of a sync. The last synced time only
+// moves on a successful sync.
+func (r *ProviderRepository) UpdateSyncStatus(ctx context.Context, userID int64, id int64, status, message string, syncedAt time.Time) error {
+	query := `
+		UPDATE provider_accounts
+		SET sync_status = $1, sync_message = $2,
+			last_synced = CASE WHEN $1 = 'synced' THEN $3 ELSE last_synced END
+		WHERE user_id = $4 AND id = $5
+	`
 
-	result, err := r.db.ExecContext(ctx, query, lastSynced, userID, providerType)
+	result, err := r.db.ExecContext(ctx, query, status, message, syncedAt, userID, id)
 	if err != nil {
 		return errors.DatabaseError("Failed to update sync status", err)
 	}
@@ -185,17 +260,17 @@ func (r *ProviderRepository) UpdateSyncStatus(ctx context.Context, userID int64,
 	}
 
 	if rows == 0 {
-		return errors.NotFound("Provider")
+		return errors.NotFound("Provider account")
 	}
 
 	return nil
 }
 
 // UpdateConnectionStatus updates the connection status
-func (r *ProviderRepository) UpdateConnectionStatus(ctx context.Context, userID int64, providerType string, isConnected bool) error {
-	query := `UPDATE provider_accounts SET is_connected = $1 WHERE user_id = $2 AND provider = $3`
+func (r *ProviderRepository) UpdateConnectionStatus(ctx context.Context, userID int64, id int64, isConnected bool) error {
+	query := `UPDATE provider_accounts SET is_connected = $1 WHERE user_id = $2 AND id = $3`
 
-	result, err := r.db.ExecContext(ctx, query, isConnected, userID, providerType)
+	result, err := r.db.ExecContext(ctx, query, isConnected, userID, id)
 	if err != nil {
 		return errors.DatabaseError("Failed to update connection status", err)
 	}
@@ -206,8 +281,99 @@ func (r *ProviderRepository) UpdateConnectionStatus(ctx context.Context, userID
 	}
 
 	if rows == 0 {
-		return errors.NotFound("Provider")
+		return errors.NotFound("Provider account")
 	}
 
 	return nil
 }
+
+// GetAWSExternalID retrieves the user's AWS external ID
+func (r *ProviderRepository) GetAWSExternalID(ctx context.Context, userID int64) (string, error) {
+	var externalID string
+	err := r.db.QueryRowContext(ctx, `SELECT external_id FROM aws_external_ids WHERE user_id = $1`, userID).Scan(&externalID)
+	if err == sql.ErrNoRows {
+		return "", errors.NotFound("AWS external ID")
+	}
+	if err != nil {
+		return "", errors.DatabaseError("Failed to get AWS external ID", err)
+	}
+	return externalID, nil
+}
+
+// CreateAWSExternalID stores the user's AWS external ID. An existing ID is
+// kept, so concurrent callers end up with the same one.
+func (r *ProviderRepository) CreateAWSExternalID(ctx context.Context, userID int64, externalID string) error {
+	query := `INSERT INTO aws_external_ids (user_id, external_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, userID, externalID, time.Now()); err != nil {
+		return errors.DatabaseError("Failed to create AWS external ID", err)
+	}
+	return nil
+}
+
+// HasEncryptedCredentials reports whether any stored credential is
+// encrypted under a master key. Startup uses it to tell a fresh
+// installation, whose keyfile may be created, from one that lost its keyfile.
+func HasEncryptedCredentials(ctx context.Context, db *sql.DB) (bool, error) {
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM provider_accounts WHERE credentials_key_version > 0`).Scan(&count); err != nil {
+		return false, errors.DatabaseError("Failed to count encrypted provider credentials", err)
+	}
+	return count > 0, nil
+}
+
+// ReencryptCredentials re-encrypts every stored credential that is not yet
+// under the active master key, including plain credentials carried over from
+// before encryption. It returns the number of accounts re-encrypted. Once it
+// has run, older master keys can be removed from the keyring.
+func (r *ProviderRepository) ReencryptCredentials(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, errors.Internal("Credential encryption is not configured", nil)
+	}
+
+	query := `SELECT ` + providerSelectCols + ` FROM provider_accounts WHERE credentials_key_version <> $1`
+	rows, err := r.db.QueryContext(ctx, query, r.keyring.ActiveVersion())
+	if err != nil {
+		return 0, errors.DatabaseError("Failed to list provider credentials", err)
+	}
+	var stale []*provider.Provider
+	var versions []int
+	for rows.Next() {
+		p, keyVersion, err := r.scanProvider(rows.Scan)
+		if err != nil {
+			rows.Close()
+			return 0, errors.DatabaseError("Failed to read provider credentials", err)
+		}
+		stale = append(stale, p)
+		versions = append(versions, keyVersion)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.DatabaseError("Failed to iterate provider credentials", err)
+	}
+
+	updated := 0
+	for i, p := range stale {
+		envelope, err := r.sealCredentials(p.UserID, p.Credentials)
+		if err != nil {
+			return updated, err
+		}
+		// Only replace the row if nothing saved it in the meantime
+		result, err := r.db.ExecContext(ctx, `
+			UPDATE provider_accounts
+			SET credentials = $1, credentials_data_key = $2, credentials_key_version = $3
+			WHERE id = $4 AND credentials_key_version = $5
+		`,
+			base64.StdEncoding.EncodeToString(envelope.Ciphertext),
+			base64.StdEncoding.EncodeToString(envelope.DataKey),
+			envelope.KeyVersion, p.ID, versions[i],
+		)
+		if err != nil {
+			return updated, errors.DatabaseError("Failed to re-encrypt provider credentials", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/secrets"
+)
+
+func newTestKeyring(t *testing.T, keys map[int][]byte) *secrets.Keyring {
+	t.Helper()
+	k, err := secrets.NewKeyring(keys, 0)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return k
+}
+
+func TestProviderRepository_EncryptsCredentials(t *testing.T) {
+	db := newMigratedTestDB(t)
+	ctx := context.Background()
+
+	key1, _ := secrets.GenerateKey()
+	key2, _ := secrets.GenerateKey()
+	repo := NewProviderRepository(db, newTestKeyring(t, map[int][]byte{1: key1}))
+	if encrypted, err := HasEncryptedCredentials(ctx, db); err != nil || encrypted {
+		t.Fatalf("HasEncryptedCredentials() on an empty table = %v, %v", encrypted, err)
+	}
+
+	p := &provider.Provider{
+		UserID:      1,
+		Provider:    provider.ProviderAWS,
+		IsConnected: true,
+		Credentials: provider.Credentials{
+			AWSAccessKeyID:     "AKIAEXAMPLE",
+			AWSSecretAccessKey: "wJalrXUtnFEMI",
+			AWSRegion:          "us-east-1",
+		},
+	}
+	if err := repo.Upsert(ctx, p); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	var stored string
+	var version int
+	if err := db.QueryRow(`SELECT credentials, credentials_key_version FROM provider_accounts WHERE user_id = 1`).Scan(&stored, &version); err != nil {
+		t.Fatalf("query error = %v", err)
+	}
+	if version != 1 || strings.Contains(stored, "wJalrXUtnFEMI") || strings.Contains(stored, "AKIAEXAMPLE") {
+		t.Fatalf("stored credentials = %q (version %d), want ciphertext", stored, version)
+	}
+	if encrypted, err := HasEncryptedCredentials(ctx, db); err != nil || !encrypted {
+		t.Fatalf("HasEncryptedCredentials() = %v, %v, want true", encrypted, err)
+	}
+
+	got, err := repo.GetByProvider(ctx, 1, provider.ProviderAWS)
+	if err != nil {
+		t.Fatalf("GetByProvider() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Credentials, p.Credentials) || !got.IsConnected {
+		t.Fatalf("GetByProvider() = %+v", got)
+	}
+
+	// A row carried over from before encryption is still readable
+	if _, err := db.Exec(`INSERT INTO provider_accounts (user_id, provider, credentials, credentials_key_version)
+		VALUES (1, 'gcp', '{"gcp_project_id":"proj","gcp_service_account_json":"{}"}', 0)`); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+	legacy, err := repo.GetByProvider(ctx, 1, provider.ProviderGCP)
+	if err != nil || legacy.Credentials.GCPProjectID != "proj" {
+		t.Fatalf("GetByProvider() of a plain row = %+v, %v", legacy, err)
+	}
+
+	// Rotate: a keyring with a new active key re-encrypts both rows
+	rotated := NewProviderRepository(db, newTestKeyring(t, map[int][]byte{1: key1, 2: key2})).(*ProviderRepository)
+	count, err := rotated.ReencryptCredentials(ctx)
+	if err != nil || count != 2 {
+		t.Fatalf("ReencryptCredentials() = %d, %v", count, err)
+	}
+	if count, err := rotated.ReencryptCredentials(ctx); err != nil || count != 0 {
+		t.Fatalf("second ReencryptCredentials() = %d, %v", count, err)
+	}
+
+	// The old key can now be dropped
+	onlyNew := NewProviderRepository(db, newTestKeyring(t, map[int][]byte{2: key2}))
+	providers, err := onlyNew.List(ctx, 1)
+	if err != nil || len(providers) != 2 {
+		t.Fatalf("List() after rotation = %+v, %v", providers, err)
+	}
+	for _, p := range providers {
+		if p.Provider == provider.ProviderAWS && p.Credentials.AWSSecretAccessKey != "wJalrXUtnFEMI" {
+			t.Fatalf("AWS credentials after rotation = %+v", p.Credentials)
+		}
+	}
+
+	// Without the right key the credentials do not open
+	other, _ := secrets.GenerateKey()
+	if _, err := NewProviderRepository(db, newTestKeyring(t, map[int][]byte{2: other})).GetByProvider(ctx, 1, provider.ProviderAWS); err == nil {
+		t.Fatal("GetByProvider() with the wrong master key should fail")
+	}
+}
+
+func TestProviderRepository_MultipleAccounts(t *testing.T) {
+	db := newMigratedTestDB(t)
+	ctx := context.Background()
+
+	key, _ := secrets.GenerateKey()
+	repo := NewProviderRepository(db, newTestKeyring(t, map[int][]byte{1: key}))
+	resources := NewResourceRepository(db)
+
+	creds := provider.Credentials{AWSAccessKeyID: "AKIAEXAMPLE", AWSSecretAccessKey: "secret"}
+	dev := &provider.Provider{UserID: 1, Provider: provider.ProviderAWS, Name: "dev", IsConnected: true, Credentials: creds}
+	prod := &provider.Provider{UserID: 1, Provider: provider.ProviderAWS, Name: "prod", IsConnected: true, Credentials: creds}
+	for _, p := range []*provider.Provider{dev, prod} {
+		if err := repo.Upsert(ctx, p); err != nil {
+			t.Fatalf("Upsert() error = %v", err)
+		}
+	}
+	if dev.ID == 0 || dev.ID == prod.ID {
+		t.Fatalf("account IDs = %d, %d, want distinct IDs", dev.ID, prod.ID)
+	}
+
+	// Upserting an existing name keeps its ID
+	again := &provider.Provider{UserID: 1, Provider: provider.ProviderAWS, Name: "prod", IsConnected: true, Credentials: creds}
+	if err := repo.Upsert(ctx, again); err != nil || again.ID != prod.ID {
+		t.Fatalf("Upsert() of an existing name = %d, %v, want ID %d", again.ID, err, prod.ID)
+	}
+
+	accounts, err := repo.ListByProvider(ctx, 1, provider.ProviderAWS)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("ListByProvider() = %d accounts, %v, want 2", len(accounts), err)
+	}
+
+	syncedAt := time.Now().UTC().Truncate(time.Second)
+	if err := repo.UpdateSyncStatus(ctx, 1, prod.ID, provider.SyncStatusFailed, "access denied", syncedAt); err != nil {
+		t.Fatalf("UpdateSyncStatus() error = %v", err)
+	}
+	got, err := repo.GetByID(ctx, 1, prod.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "prod" || got.SyncStatus != provider.SyncStatusFailed || got.SyncMessage != "access denied" || got.LastSynced != nil {
+		t.Errorf("GetByID() after a failed sync = %+v", got)
+	}
+	if _, err := repo.GetByID(ctx, 2, prod.ID); err == nil {
+		t.Error("GetByID() of another user's account should fail")
+	}
+
+	// Resources are reconciled per account
+	sync := func(accountID int64, gracePeriod time.Duration, ids ...string) *resource.SyncRun {
+		t.Helper()
+		listed := make([]*resource.Resource, len(ids))
+		for i, id := range ids {
+			listed[i] = &resource.Resource{UserID: 1, Provider: provider.ProviderAWS, ResourceID: id, Name: id, Type: "ec2", Region: "us-east-1", Status: "running"}
+		}
+		stored, err := resources.ListByAccount(ctx, 1, accountID)
+		if err != nil {
+			t.Fatalf("ListByAccount() error = %v", err)
+		}
+		now := time.Now().UTC().Truncate(time.Second)
+		diff := resource.DiffSync(stored, listed, now, gracePeriod)
+		run := &resource.SyncRun{UserID: 1, Provider: provider.ProviderAWS, AccountID: accountID, SyncedAt: now,
+			Added: len(diff.Added), Updated: len(diff.Updated), Removed: len(diff.Removed), Purged: len(diff.Purged), Changes: diff.Changes()}
+		if err := resources.ApplySync(ctx, run, diff); err != nil {
+			t.Fatalf("ApplySync() error = %v", err)
+		}
+		return run
+	}
+	sync(dev.ID, time.Hour, "i-dev")
+	sync(prod.ID, time.Hour, "i-prod-1", "i-prod-2")
+	sync(prod.ID, time.Hour, "i-prod-3")
+
+	devResources, total, err := resources.List(ctx, 1, resource.Filter{AccountID: dev.ID}, 10, 0)
+	if err != nil || total != 1 || devResources[0].ResourceID != "i-dev" || devResources[0].AccountID != dev.ID || devResources[0].LastScanned.IsZero() {
+		t.Fatalf("List() of dev = %+v (%d), %v", devResources, total, err)
+	}
+	terminated, total, _ := resources.List(ctx, 1, resource.Filter{AccountID: prod.ID, Status: resource.StatusTerminated}, 10, 0)
+	if total != 2 || terminated[0].MissingSince == nil {
+		t.Errorf("terminated prod resources after resync = %+v, want i-prod-1 and i-prod-2 with missing_since", terminated)
+	}
+
+	if run := sync(prod.ID, 0, "i-prod-3"); run.Purged != 2 {
+		t.Errorf("sync after the grace period purged %d, want 2", run.Purged)
+	}
+	if _, total, _ := resources.List(ctx, 1, resource.Filter{AccountID: prod.ID}, 10, 0); total != 1 {
+		t.Errorf("prod resources after purge = %d, want 1", total)
+	}
+	runs, err := resources.ListSyncRuns(ctx, 1, prod.ID, 10)
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("ListSyncRuns() = %d runs, %v, want 3", len(runs), err)
+	}
+	if runs[0].Purged != 2 || runs[1].Added != 1 || runs[1].Removed != 2 || len(runs[1].Changes) != 3 {
+		t.Errorf("ListSyncRuns() = %+v, %+v, want newest first with changes", runs[0], runs[1])
+	}
+
+	if err := resources.DeleteByAccount(ctx, 1, prod.ID); err != nil {
+		t.Fatalf("DeleteByAccount() error = %v", err)
+	}
+	if err := repo.Delete(ctx, 1, prod.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	all, _ := resources.ListByProvider(ctx, 1, provider.ProviderAWS)
+	if len(all) != 1 {
+		t.Errorf("resources after deleting prod = %d, want 1", len(all))
+	}
+	if runs, _ := resources.ListSyncRuns(ctx, 1, prod.ID, 10); len(runs) != 0 {
+		t.Errorf("sync runs after deleting prod = %d, want 0", len(runs))
+	}
+	if accounts, _ := repo.List(ctx, 1); len(accounts) != 1 || accounts[0].ID != dev.ID {
+		t.Errorf("List() after deleting prod = %+v", accounts)
+	}
+}
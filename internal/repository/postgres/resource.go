@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -28,12 +29,12 @@ func (r *ResourceRepository) Create(ctx context.Context, res *resource.Resource)
 	res.UpdatedAt = now
 
 	query := `
-		INSERT INTO resources (user_id, provider, cloud_id, name, type, region, status, configuration)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO resources (user_id, provider, account_id, resource_id, name, resource_type, region, status, configuration)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
-		res.UserID, res.Provider, res.ResourceID, res.Name, res.Type, res.Region, res.Status, res.Configuration,
+		res.UserID, res.Provider, nullAccountID(res.AccountID), res.ResourceID, res.Name, res.Type, res.Region, res.Status, res.Configuration,
 	)
 	if err != nil {
 		return errors.DatabaseError("Failed to create resource", err)
@@ -45,15 +46,12 @@ func (r *ResourceRepository) Create(ctx context.Context, res *resource.Resource)
 // GetByID retrieves a resource by ID
 func (r *ResourceRepository) GetByID(ctx context.Context, userID int64, resourceID string) (*resource.Resource, error) {
 	query := `
-		SELECT user_id, provider, cloud_id, name, type, region, status, COALESCE(configuration, '')
+		SELECT ` + resourceSelectCols + `
 		FROM resources
-		WHERE user_id = $1 AND cloud_id = $2
+		WHERE user_id = $1 AND resource_id = $2
 	`
 
-	var res resource.Resource
-	err := r.db.QueryRowContext(ctx, query, userID, resourceID).Scan(
-		&res.UserID, &res.Provider, &res.ResourceID, &res.Name, &res.Type, &res.Region, &res.Status, &res.Configuration,
-	)
+	res, err := scanResource(r.db.QueryRowContext(ctx, query, userID, resourceID).Scan)
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound("Resource")
@@ -62,7 +60,7 @@ func (r *ResourceRepository) GetByID(ctx context.Context, userID int64, resource
 		return nil, errors.DatabaseError("Failed to get resource", err)
 	}
 
-	return &res, nil
+	return res, nil
 }
 
 // Update updates a resource
@@ -71,8 +69,8 @@ func (r *ResourceRepository) Update(ctx context.Context, res *resource.Resource)
 
 	query := `
 		UPDATE resources
-		SET name = $1, type = $2, region = $3, status = $4, configuration = $5
-		WHERE user_id = $6 AND cloud_id = $7
+		SET name = $1, resource_type = $2, region = $3, status = $4, configuration = $5
+		WHERE user_id = $6 AND resource_id = $7
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -96,7 +94,7 @@ func (r *ResourceRepository) Update(ctx context.Context, res *resource.Resource)
 
 // Delete deletes a resource
 func (r *ResourceRepository) Delete(ctx context.Context, userID int64, resourceID string) error {
-	query := `DELETE FROM resources WHERE user_id = $1 AND cloud_id = $2`
+	query := `DELETE FROM resources WHERE user_id = $1 AND resource_id = $2`
 
 	result, err := r.db.ExecContext(ctx, query, userID, resourceID)
 	if err != nil {
@@ -128,8 +126,13 @@ func (r *ResourceRepository) List(ctx context.Context, userID int64, filter reso
 		args = append(args, filter.Provider)
 		paramN++
 	}
+	if filter.AccountID != 0 {
+		where = append(where, fmt.Sprintf("account_id = $%d", paramN))
+		args = append(args, filter.AccountID)
+		paramN++
+	}
 	if filter.Type != "" {
-		where = append(where, fmt.Sprintf("type = $%d", paramN))
+		where = append(where, fmt.Sprintf("resource_type = $%d", paramN))
 		args = append(args, filter.Type)
 		paramN++
 	}
@@ -156,10 +159,10 @@ func (r *ResourceRepository) List(ctx context.Context, userID int64, filter reso
 
 	// Get resources
 	query := fmt.Sprintf(`
-		SELECT user_id, provider, cloud_id, name, type, region, status, COALESCE(configuration, '')
+		SELECT `+resourceSelectCols+`
 		FROM resources
 		WHERE %s
-		ORDER BY provider, cloud_id
+		ORDER BY provider, resource_id
 		LIMIT $%d OFFSET $%d
 	`, whereClause, paramN, paramN+1)
 
@@ -173,12 +176,11 @@ func (r *ResourceRepository) List(ctx context.Context, userID int64, filter reso
 	// Pre-allocate slice with expected capacity to avoid repeated allocations
 	resources := make([]*resource.Resource, 0, limit)
 	for rows.Next() {
-		var res resource.Resource
-		err := rows.Scan(&res.UserID, &res.Provider, &res.ResourceID, &res.Name, &res.Type, &res.Region, &res.Status, &res.Configuration)
+		res, err := scanResource(rows.Scan)
 		if err != nil {
 			return nil, 0, errors.DatabaseError("Failed to scan resource", err)
 		}
-		resources = append(resources, &res)
+		resources = append(resources, res)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -191,10 +193,10 @@ func (r *ResourceRepository) List(ctx context.Context, userID int64, filter reso
 // ListByProvider retrieves resources by provider
 func (r *ResourceRepository) ListByProvider(ctx context.Context, userID int64, provider string) ([]*resource.Resource, error) {
 	query := `
-		SELECT user_id, provider, cloud_id, name, type, region, status, COALESCE(configuration, '')
+		SELECT ` + resourceSelectCols + `
 		FROM resources
 		WHERE user_id = $1 AND provider = $2
-		ORDER BY cloud_id
+		ORDER BY resource_id
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, userID, provider)
@@ -205,12 +207,11 @@ func (r *ResourceRepository) ListByProvider(ctx context.Context, userID int64, p
 
 	var resources []*resource.Resource
 	for rows.Next() {
-		var res resource.Resource
-		err := rows.Scan(&res.UserID, &res.Provider, &res.ResourceID, &res.Name, &res.Type, &res.Region, &res.Status, &res.Configuration)
+		res, err := scanResource(rows.Scan)
 		if err != nil {
 			return nil, errors.DatabaseError("Failed to scan resource", err)
 		}
-		resources = append(resources, &res)
+		resources = append(resources, res)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -220,37 +221,115 @@ func (r *ResourceRepository) ListByProvider(ctx context.Context, userID int64, p
 	return resources, nil
 }
 
-// SaveBatch saves multiple resources (used for sync)
-func (r *ResourceRepository) SaveBatch(ctx context.Context, userID int64, provider string, resources []*resource.Resource) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+// ListByAccount retrieves the resources synced from a provider account,
+// including terminated ones
+func (r *ResourceRepository) ListByAccount(ctx context.Context, userID int64, accountID int64) ([]*resource.Resource, error) {
+	query := `
+		SELECT ` + resourceSelectCols + `
+		FROM resources
+		WHERE user_id = $1 AND account_id = $2
+		ORDER BY resource_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, accountID)
 	if err != nil {
-		return errors.DatabaseError("Failed to begin transaction", err)
+		return nil, errors.DatabaseError("Failed to list resources by account", err)
 	}
-	defer tx.Rollback()
+	defer rows.Close()
+
+	var resources []*resource.Resource
+	for rows.Next() {
+		res, err := scanResource(rows.Scan)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan resource", err)
+		}
+		resources = append(resources, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Failed to iterate resources", err)
+	}
+
+	return resources, nil
+}
 
-	// Delete existing resources for this provider
-	_, err = tx.ExecContext(ctx, "DELETE FROM resources WHERE user_id = $1 AND provider = $2", userID, provider)
+// ApplySync saves the diff of a provider account sync and records its
+// changelog in one transaction. A listed resource stored under another
+// account moves to this one.
+func (r *ResourceRepository) ApplySync(ctx context.Context, run *resource.SyncRun, diff *resource.SyncDiff) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.DatabaseError("Failed to delete old resources", err)
+		return errors.DatabaseError("Failed to begin transaction", err)
 	}
+	defer tx.Rollback()
 
-	// Insert new resources
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO resources (user_id, provider, cloud_id, name, type, region, status, configuration)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	upsert, err := tx.PrepareContext(ctx, `
+		INSERT INTO resources (user_id, provider, account_id, resource_id, name, resource_type, region, status, configuration, last_scanned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, provider, resource_id) DO UPDATE SET
+			account_id = excluded.account_id,
+			name = excluded.name,
+			resource_type = excluded.resource_type,
+			region = excluded.region,
+			status = excluded.status,
+			configuration = excluded.configuration,
+			last_scanned = excluded.last_scanned,
+			missing_since = NULL,
+			updated_at = CURRENT_TIMESTAMP
 	`)
 	if err != nil {
 		return errors.DatabaseError("Failed to prepare statement", err)
 	}
-	defer stmt.Close()
+	defer upsert.Close()
+
+	for _, group := range [][]*resource.Resource{diff.Added, diff.Updated} {
+		for _, res := range group {
+			_, err := upsert.ExecContext(ctx, run.UserID, run.Provider, run.AccountID, res.ResourceID, res.Name, res.Type, res.Region, res.Status, res.Configuration, run.SyncedAt)
+			if err != nil {
+				return errors.DatabaseError("Failed to save resource", err)
+			}
+		}
+	}
 
-	for _, res := range resources {
-		_, err := stmt.ExecContext(ctx, userID, provider, res.ResourceID, res.Name, res.Type, res.Region, res.Status, res.Configuration)
+	for _, res := range diff.Unchanged {
+		_, err := tx.ExecContext(ctx, "UPDATE resources SET last_scanned = $1 WHERE user_id = $2 AND account_id = $3 AND resource_id = $4",
+			run.SyncedAt, run.UserID, run.AccountID, res.ResourceID)
 		if err != nil {
-			return errors.DatabaseError("Failed to insert resource", err)
+			return errors.DatabaseError("Failed to update resource", err)
 		}
 	}
 
+	for _, res := range diff.Removed {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE resources SET status = $1, missing_since = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE user_id = $3 AND account_id = $4 AND resource_id = $5
+		`, res.Status, res.MissingSince, run.UserID, run.AccountID, res.ResourceID)
+		if err != nil {
+			return errors.DatabaseError("Failed to mark resource terminated", err)
+		}
+	}
+
+	for _, res := range diff.Purged {
+		_, err := tx.ExecContext(ctx, "DELETE FROM resources WHERE user_id = $1 AND account_id = $2 AND resource_id = $3",
+			run.UserID, run.AccountID, res.ResourceID)
+		if err != nil {
+			return errors.DatabaseError("Failed to purge resource", err)
+		}
+	}
+
+	changes, err := json.Marshal(run.Changes)
+	if err != nil {
+		return errors.Internal("Failed to marshal sync changes", err)
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO resource_sync_runs (user_id, provider, account_id, synced_at, added, updated, removed, purged, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, run.UserID, run.Provider, run.AccountID, run.SyncedAt, run.Added, run.Updated, run.Removed, run.Purged, string(changes)).Scan(&run.ID)
+	if err != nil {
+		return errors.DatabaseError("Failed to record sync run", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.DatabaseError("Failed to commit transaction", err)
 	}
@@ -258,6 +337,46 @@ func (r *ResourceRepository) SaveBatch(ctx context.Context, userID int64, provid
 	return nil
 }
 
+// ListSyncRuns lists the sync changelogs of a provider account, newest first
+func (r *ResourceRepository) ListSyncRuns(ctx context.Context, userID int64, accountID int64, limit int) ([]*resource.SyncRun, error) {
+	query := `
+		SELECT id, user_id, provider, account_id, synced_at, added, updated, removed, purged, COALESCE(changes, '')
+		FROM resource_sync_runs
+		WHERE user_id = $1 AND account_id = $2
+		ORDER BY synced_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, accountID, limit)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list sync runs", err)
+	}
+	defer rows.Close()
+
+	var runs []*resource.SyncRun
+	for rows.Next() {
+		var run resource.SyncRun
+		var changes string
+		err := rows.Scan(&run.ID, &run.UserID, &run.Provider, &run.AccountID, &run.SyncedAt,
+			&run.Added, &run.Updated, &run.Removed, &run.Purged, &changes)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan sync run", err)
+		}
+		if changes != "" {
+			if err := json.Unmarshal([]byte(changes), &run.Changes); err != nil {
+				return nil, errors.DatabaseError("Failed to decode sync changes", err)
+			}
+		}
+		runs = append(runs, &run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Failed to iterate sync runs", err)
+	}
+
+	return runs, nil
+}
+
 // DeleteByProvider deletes all resources for a provider
 func (r *ResourceRepository) DeleteByProvider(ctx context.Context, userID int64, provider string) error {
 	query := `DELETE FROM resources WHERE user_id = $1 AND provider = $2`
@@ -269,3 +388,48 @@ func (r *ResourceRepository) DeleteByProvider(ctx context.Context, userID int64,
 
 	return nil
 }
+
+// DeleteByAccount deletes the resources synced from a provider account
+// and its sync changelogs
+func (r *ResourceRepository) DeleteByAccount(ctx context.Context, userID int64, accountID int64) error {
+	query := `DELETE FROM resources WHERE user_id = $1 AND account_id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, userID, accountID)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete resources by account", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `DELETE FROM resource_sync_runs WHERE user_id = $1 AND account_id = $2`, userID, accountID)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete sync runs by account", err)
+	}
+
+	return nil
+}
+
+const resourceSelectCols = `user_id, provider, COALESCE(account_id, 0), resource_id, name, resource_type,
+	COALESCE(region, ''), COALESCE(status, ''), COALESCE(configuration, ''), last_scanned, missing_since`
+
+// scanResource scans a resource row selected with resourceSelectCols
+func scanResource(scan func(dest ...any) error) (*resource.Resource, error) {
+	var res resource.Resource
+	var lastScanned, missingSince sql.NullTime
+	err := scan(&res.UserID, &res.Provider, &res.AccountID, &res.ResourceID, &res.Name, &res.Type, &res.Region, &res.Status, &res.Configuration,
+		&lastScanned, &missingSince)
+	if err != nil {
+		return nil, err
+	}
+	res.LastScanned = lastScanned.Time
+	if missingSince.Valid {
+		res.MissingSince = &missingSince.Time
+	}
+	return &res, nil
+}
+
+// nullAccountID stores resources created outside a sync without an account
+func nullAccountID(accountID int64) interface{} {
+	if accountID == 0 {
+		return nil
+	}
+	return accountID
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+// ExceptionExpiryNotice is how long before an approved exception expires
+// its owner is notified
+const ExceptionExpiryNotice = 14 * 24 * time.Hour
+
+// RequestException records a pending exception for a control, optionally
+// narrowed to one resource. It excepts nothing until approved.
+func (s *ComplianceServiceImpl) RequestException(ctx context.Context, userID int64, exception *compliance.Exception) (*compliance.Exception, error) {
+	exception.Justification = strings.TrimSpace(exception.Justification)
+	switch {
+	case exception.FrameworkID == "" || exception.ControlID == "":
+		return nil, errors.ValidationError("Invalid exception", "framework_id and control_id are required")
+	case exception.Justification == "":
+		return nil, errors.ValidationError("Invalid exception", "justification is required")
+	case !exception.ExpiresAt.After(time.Now()):
+		return nil, errors.ValidationError("Invalid exception", "expires_at must be in the future")
+	}
+
+	if _, err := s.repo.GetFramework(ctx, exception.FrameworkID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetControlByFrameworkAndID(ctx, exception.FrameworkID, exception.ControlID); err != nil {
+		return nil, err
+	}
+	if exception.ResourceID != "" && s.resourceRepo != nil {
+		if _, err := s.resourceRepo.GetByID(ctx, userID, exception.ResourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.repo.ListExceptions(ctx, userID, compliance.ExceptionFilter{
+		FrameworkID: exception.FrameworkID,
+		ControlID:   exception.ControlID,
+		ResourceID:  exception.ResourceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Status == compliance.ExceptionStatusPending || e.Status == compliance.ExceptionStatusApproved {
+			return nil, errors.Conflict("An exception for this control and resource is already " + e.Status)
+		}
+	}
+
+	exception.ID = ""
+	exception.UserID = userID
+	exception.RequestedBy = userID
+	exception.Status = compliance.ExceptionStatusPending
+	exception.ApprovedBy = nil
+	exception.ApprovedAt = nil
+	exception.RejectionReason = ""
+	exception.ExpiryNotifiedAt = nil
+	if err := s.repo.CreateException(ctx, exception); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"exception_id": exception.ID,
+		"framework_id": exception.FrameworkID,
+		"control_id":   exception.ControlID,
+		"resource_id":  exception.ResourceID,
+	}).Info("Compliance exception requested")
+
+	return exception, nil
+}
+
+// GetException retrieves an exception
+func (s *ComplianceServiceImpl) GetException(ctx context.Context, userID int64, id string) (*compliance.Exception, error) {
+	return s.repo.GetException(ctx, userID, id)
+}
+
+// ListExceptions lists exceptions
+func (s *ComplianceServiceImpl) ListExceptions(ctx context.Context, userID int64, filter compliance.ExceptionFilter) ([]*compliance.Exception, error) {
+	return s.repo.ListExceptions(ctx, userID, filter)
+}
+
+// ApproveException approves a pending exception. Its findings count as
+// excepted from the next assessment until it expires.
+func (s *ComplianceServiceImpl) ApproveException(ctx context.Context, userID int64, id string) (*compliance.Exception, error) {
+	exception, err := s.repo.GetException(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if exception.Status != compliance.ExceptionStatusPending {
+		return nil, errors.Conflict("Exception is not pending approval")
+	}
+	now := time.Now()
+	if !exception.ExpiresAt.After(now) {
+		return nil, errors.Conflict("Exception has already expired")
+	}
+
+	exception.Status = compliance.ExceptionStatusApproved
+	exception.ApprovedBy = &userID
+	exception.ApprovedAt = &now
+
+	if err := s.repo.UpdateException(ctx, exception); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"exception_id": id,
+		"approved_by":  userID,
+		"expires_at":   exception.ExpiresAt.Format(time.RFC3339),
+	}).Info("Compliance exception approved")
+
+	return exception, nil
+}
+
+// RejectException rejects a pending exception
+func (s *ComplianceServiceImpl) RejectException(ctx context.Context, userID int64, id string, reason string) (*compliance.Exception, error) {
+	exception, err := s.repo.GetException(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if exception.Status != compliance.ExceptionStatusPending {
+		return nil, errors.Conflict("Exception is not pending approval")
+	}
+
+	exception.Status = compliance.ExceptionStatusRejected
+	exception.RejectionReason = reason
+
+	if err := s.repo.UpdateException(ctx, exception); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"exception_id": id,
+		"reason":       reason,
+	}).Info("Compliance exception rejected")
+
+	return exception, nil
+}
+
+// RevokeException withdraws a pending or approved exception before it expires
+func (s *ComplianceServiceImpl) RevokeException(ctx context.Context, userID int64, id string) (*compliance.Exception, error) {
+	exception, err := s.repo.GetException(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if exception.Status != compliance.ExceptionStatusPending && exception.Status != compliance.ExceptionStatusApproved {
+		return nil, errors.Conflict("Exception is " + exception.Status + " and cannot be revoked")
+	}
+
+	exception.Status = compliance.ExceptionStatusRevoked
+
+	if err := s.repo.UpdateException(ctx, exception); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"exception_id": id,
+	}).Info("Compliance exception revoked")
+
+	return exception, nil
+}
+
+// NotifyExpiringExceptions marks lapsed approved exceptions as expired and
+// sends one notice for each approved exception that expires within
+// ExceptionExpiryNotice. It returns the number of notices sent.
+func (s *ComplianceServiceImpl) NotifyExpiringExceptions(ctx context.Context, userID int64) (int, error) {
+	exceptions, err := s.repo.ListExceptions(ctx, userID, compliance.ExceptionFilter{Status: compliance.ExceptionStatusApproved})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	notified := 0
+	for _, e := range exceptions {
+		if !e.IsActive(now) {
+			e.Status = compliance.ExceptionStatusExpired
+			if err := s.repo.UpdateException(ctx, e); err != nil {
+				return notified, err
+			}
+			s.logger.WithFields(map[string]interface{}{
+				"exception_id": e.ID,
+			}).Info("Compliance exception expired")
+			continue
+		}
+
+		if e.ExpiryNotifiedAt != nil || e.ExpiresAt.Sub(now) > ExceptionExpiryNotice || s.notifier == nil {
+			continue
+		}
+		s.notifyExceptionExpiry(ctx, e)
+		e.ExpiryNotifiedAt = &now
+		if err := s.repo.UpdateException(ctx, e); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// notifyExceptionExpiry sends a notice that an exception expires soon
+func (s *ComplianceServiceImpl) notifyExceptionExpiry(ctx context.Context, e *compliance.Exception) {
+	scope := "all resources"
+	if e.ResourceID != "" {
+		scope = e.ResourceID
+	}
+
+	n := &notification.Notification{
+		Type:     notification.NotificationTypeComplianceAlert,
+		Priority: notification.PriorityMedium,
+		Title:    fmt.Sprintf("Compliance exception for %s %s expires soon", e.FrameworkID, e.ControlID),
+		Message: fmt.Sprintf("The exception for control %s of %s (%s) expires on %s. Renew it or remediate the control before then.",
+			e.ControlID, e.FrameworkID, scope, e.ExpiresAt.Format("2006-01-02")),
+		UserID: e.UserID,
+		Data: map[string]interface{}{
+			"exception_id": e.ID,
+			"framework_id": e.FrameworkID,
+			"control_id":   e.ControlID,
+			"resource_id":  e.ResourceID,
+			"expires_at":   e.ExpiresAt.Format(time.RFC3339),
+		},
+	}
+
+	if err := s.notifier.Send(ctx, n); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id":      e.UserID,
+			"exception_id": e.ID,
+		}).ErrorWithErr(err, "Failed to send exception expiry notice")
+	}
+}
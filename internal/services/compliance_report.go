@@ -0,0 +1,604 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/pdf"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/utils"
+)
+
+// reportSeverities lists severities from most to least severe
+var reportSeverities = []string{
+	compliance.SeverityCritical,
+	compliance.SeverityHigh,
+	compliance.SeverityMedium,
+	compliance.SeverityLow,
+}
+
+func severityRank(severity string) int {
+	for i, s := range reportSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(reportSeverities)
+}
+
+// reportView is the data shared by the HTML and PDF reports
+type reportView struct {
+	Title        string
+	Framework    string
+	AssessmentID string
+	AssessedAt   string
+	GeneratedAt  string
+	Summary      *compliance.ExportSummary
+	Severities   []severityCount
+	Categories   []compliance.CategorySummary
+	Failing      []reportFinding // failed controls, most severe first
+	Excepted     []reportFinding // controls with excepted resources
+	Findings     []compliance.AssessmentFinding
+}
+
+type severityCount struct {
+	Severity string
+	Count    int
+}
+
+// reportFinding is a finding with the exceptions that cover its resources
+type reportFinding struct {
+	compliance.AssessmentFinding
+	Exceptions []*compliance.Exception
+}
+
+func newReportView(export *compliance.AssessmentExport) *reportView {
+	a := export.Assessment
+	framework := a.FrameworkName
+	if export.Framework != nil && export.Framework.Version != "" {
+		framework += " " + export.Framework.Version
+	}
+
+	view := &reportView{
+		Title:        a.FrameworkName + " Compliance Report",
+		Framework:    framework,
+		AssessmentID: a.ID,
+		AssessedAt:   a.AssessmentDate.UTC().Format("2006-01-02 15:04 MST"),
+		GeneratedAt:  export.GeneratedAt,
+		Summary:      export.Summary,
+		Categories:   export.Categories,
+		Findings:     export.Findings,
+	}
+	if t, err := time.Parse(time.RFC3339, export.GeneratedAt); err == nil {
+		view.GeneratedAt = t.UTC().Format("2006-01-02 15:04 MST")
+	}
+	for _, severity := range reportSeverities {
+		view.Severities = append(view.Severities, severityCount{severity, export.Summary.FailingBySeverity[severity]})
+	}
+	for i := range view.Categories {
+		if view.Categories[i].Category == "" {
+			view.Categories[i].Category = "Uncategorized"
+		}
+	}
+
+	exceptions := make(map[string]*compliance.Exception, len(export.Exceptions))
+	for _, e := range export.Exceptions {
+		exceptions[e.ID] = e
+	}
+	for _, f := range export.Findings {
+		rf := reportFinding{AssessmentFinding: f}
+		for _, id := range f.ExceptionIDs {
+			if e, ok := exceptions[id]; ok {
+				rf.Exceptions = append(rf.Exceptions, e)
+			}
+		}
+		if f.Status == compliance.ControlStatusFailed {
+			view.Failing = append(view.Failing, rf)
+		}
+		if len(f.ExceptedResources) > 0 {
+			view.Excepted = append(view.Excepted, rf)
+		}
+	}
+	sort.SliceStable(view.Failing, func(i, j int) bool {
+		return severityRank(view.Failing[i].Severity) < severityRank(view.Failing[j].Severity)
+	})
+
+	return view
+}
+
+// statusLabel turns a control status into display text
+func statusLabel(status string) string {
+	if status == compliance.ControlStatusNotApplicable {
+		return "Not applicable"
+	}
+	return strings.ToUpper(status[:1]) + status[1:]
+}
+
+var reportTemplateFuncs = htmltemplate.FuncMap{
+	"status": statusLabel,
+	"upper":  strings.ToUpper,
+	"percent": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+	},
+	"date": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02")
+	},
+	"join": strings.Join,
+}
+
+var complianceReportHTML = htmltemplate.Must(htmltemplate.New("report").Funcs(reportTemplateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2937; margin: 2rem auto; max-width: 960px; font-size: 14px; line-height: 1.45; }
+  h1 { font-size: 24px; margin-bottom: 0.2rem; }
+  h2 { font-size: 18px; border-bottom: 2px solid #e5e7eb; padding-bottom: 0.3rem; margin-top: 2rem; }
+  h3 { font-size: 15px; margin: 0 0 0.4rem; }
+  .meta { color: #6b7280; font-size: 12px; }
+  .score { font-size: 40px; font-weight: 700; }
+  .cards { display: flex; gap: 0.75rem; margin: 1rem 0; }
+  .card { flex: 1; border: 1px solid #e5e7eb; border-radius: 6px; padding: 0.6rem 0.8rem; }
+  .card .n { font-size: 22px; font-weight: 700; }
+  table { border-collapse: collapse; width: 100%; margin: 0.5rem 0; }
+  th, td { text-align: left; padding: 0.35rem 0.5rem; border-bottom: 1px solid #e5e7eb; vertical-align: top; }
+  th { background: #f9fafb; font-size: 12px; text-transform: uppercase; color: #4b5563; }
+  .finding { border: 1px solid #e5e7eb; border-left: 4px solid #dc2626; border-radius: 4px; padding: 0.75rem 1rem; margin: 0.75rem 0; page-break-inside: avoid; }
+  .finding.excepted { border-left-color: #d97706; }
+  .sev { display: inline-block; font-size: 11px; font-weight: 700; padding: 0 0.4rem; border-radius: 3px; color: #fff; background: #6b7280; }
+  .sev.critical { background: #7f1d1d; } .sev.high { background: #dc2626; } .sev.medium { background: #d97706; } .sev.low { background: #2563eb; }
+  .passed { color: #15803d; } .failed { color: #b91c1c; } .excepted { color: #b45309; } .not_applicable { color: #6b7280; }
+  pre { background: #f9fafb; border: 1px solid #e5e7eb; padding: 0.5rem; white-space: pre-wrap; word-break: break-all; font-size: 12px; }
+  ul.resources { margin: 0.2rem 0 0.5rem; padding-left: 1.2rem; font-family: monospace; font-size: 12px; }
+  @media print { body { margin: 0; max-width: none; } h2 { page-break-after: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">{{.Framework}} &middot; Assessment {{.AssessmentID}} &middot; Assessed {{.AssessedAt}} &middot; Generated {{.GeneratedAt}}</div>
+
+<h2>Executive summary</h2>
+<div class="score">{{percent .Summary.Score}}</div>
+<div class="meta">Compliance score: passed controls out of the controls that apply. Excepted controls are not scored.</div>
+<div class="cards">
+  <div class="card"><div class="n">{{.Summary.TotalControls}}</div>Controls</div>
+  <div class="card"><div class="n passed">{{.Summary.Passed}}</div>Passed</div>
+  <div class="card"><div class="n failed">{{.Summary.Failed}}</div>Failed</div>
+  <div class="card"><div class="n excepted">{{.Summary.Excepted}}</div>Excepted</div>
+  <div class="card"><div class="n not_applicable">{{.Summary.NotApplicable}}</div>Not applicable</div>
+</div>
+<table>
+  <tr><th>Failing controls by severity</th>{{range .Severities}}<th>{{.Severity}}</th>{{end}}</tr>
+  <tr><td></td>{{range .Severities}}<td>{{.Count}}</td>{{end}}</tr>
+</table>
+
+<h2>Results by category</h2>
+<table>
+  <tr><th>Category</th><th>Controls</th><th>Passed</th><th>Failed</th><th>Excepted</th><th>Not applicable</th><th>Score</th></tr>
+  {{range .Categories}}<tr><td>{{.Category}}</td><td>{{.TotalControls}}</td><td>{{.Passed}}</td><td>{{.Failed}}</td><td>{{.Excepted}}</td><td>{{.NotApplicable}}</td><td>{{percent .Score}}</td></tr>
+  {{end}}
+</table>
+
+<h2>Failing controls</h2>
+{{range .Failing}}<div class="finding">
+  <h3><span class="sev {{.Severity}}">{{upper .Severity}}</span> {{.ControlID}} &mdash; {{.ControlTitle}}</h3>
+  <div class="meta">{{.Category}}{{if .SatisfiedBy}} &middot; Evaluated with the checks of {{join .SatisfiedBy ", "}}{{end}}</div>
+  <p><strong>Affected resources ({{.AffectedCount}})</strong></p>
+  <ul class="resources">{{range .AffectedResources}}<li>{{.}}</li>{{end}}</ul>
+  {{if .Evidence}}<p><strong>Evidence</strong></p><pre>{{.Evidence}}</pre>{{end}}
+  {{if .Remediation}}<p><strong>Remediation:</strong> {{.Remediation}}</p>{{end}}
+</div>
+{{else}}<p>No controls failed.</p>
+{{end}}
+{{if .Excepted}}
+<h2>Accepted risks</h2>
+{{range .Excepted}}<div class="finding excepted">
+  <h3><span class="sev {{.Severity}}">{{upper .Severity}}</span> {{.ControlID}} &mdash; {{.ControlTitle}} <span class="{{.Status}}">({{status .Status}})</span></h3>
+  <p><strong>Excepted resources</strong></p>
+  <ul class="resources">{{range .ExceptedResources}}<li>{{.}}</li>{{end}}</ul>
+  {{range .Exceptions}}<p><strong>Exception {{.ID}}</strong> &middot; {{if .ResourceID}}{{.ResourceID}}{{else}}all resources{{end}} &middot; expires {{date .ExpiresAt}}{{if .ApprovedAt}} &middot; approved {{date .ApprovedAt}}{{end}}<br>{{.Justification}}</p>{{end}}
+</div>
+{{end}}{{end}}
+<h2>All controls</h2>
+<table>
+  <tr><th>Control</th><th>Title</th><th>Category</th><th>Severity</th><th>Status</th></tr>
+  {{range .Findings}}<tr><td>{{.ControlID}}</td><td>{{.ControlTitle}}</td><td>{{.Category}}</td><td>{{.Severity}}</td><td class="{{.Status}}">{{status .Status}}</td></tr>
+  {{end}}
+</table>
+</body>
+</html>
+`))
+
+// renderComplianceHTML renders a printable HTML report
+func renderComplianceHTML(export *compliance.AssessmentExport) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := complianceReportHTML.Execute(&buf, newReportView(export)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderCompliancePDF renders the HTML report's content as PDF
+func renderCompliancePDF(export *compliance.AssessmentExport) []byte {
+	view := newReportView(export)
+	doc := pdf.New(view.Title)
+
+	doc.Text(view.Title, pdf.Title)
+	doc.Text(fmt.Sprintf("%s - Assessment %s - Assessed %s - Generated %s",
+		view.Framework, view.AssessmentID, view.AssessedAt, view.GeneratedAt), pdf.Small)
+
+	doc.Gap(12)
+	doc.Text("Executive summary", pdf.Heading)
+	doc.Rule()
+	s := view.Summary
+	doc.Text("Compliance score: "+strconv.FormatFloat(s.Score, 'f', 1, 64)+"%", pdf.Heading)
+	doc.Text("Passed controls out of the controls that apply. Excepted controls are not scored.", pdf.Small)
+	doc.Gap(4)
+	counts := []float64{99, 99, 99, 99, 99}
+	doc.Row([]string{"Controls", "Passed", "Failed", "Excepted", "Not applicable"}, counts, pdf.Strong)
+	doc.Row([]string{strconv.Itoa(s.TotalControls), strconv.Itoa(s.Passed), strconv.Itoa(s.Failed),
+		strconv.Itoa(s.Excepted), strconv.Itoa(s.NotApplicable)}, counts, pdf.Body)
+	var severities []string
+	for _, sc := range view.Severities {
+		severities = append(severities, fmt.Sprintf("%s %d", sc.Severity, sc.Count))
+	}
+	doc.Text("Failing controls by severity: "+strings.Join(severities, ", "), pdf.Body)
+
+	doc.Gap(12)
+	doc.Text("Results by category", pdf.Heading)
+	doc.Rule()
+	categoryWidths := []float64{165, 50, 50, 50, 55, 60, 65}
+	doc.Row([]string{"Category", "Controls", "Passed", "Failed", "Excepted", "N/A", "Score"}, categoryWidths, pdf.Strong)
+	for _, c := range view.Categories {
+		doc.Row([]string{c.Category, strconv.Itoa(c.TotalControls), strconv.Itoa(c.Passed), strconv.Itoa(c.Failed),
+			strconv.Itoa(c.Excepted), strconv.Itoa(c.NotApplicable), strconv.FormatFloat(c.Score, 'f', 1, 64) + "%"}, categoryWidths, pdf.Body)
+	}
+
+	doc.Gap(12)
+	doc.Text("Failing controls", pdf.Heading)
+	doc.Rule()
+	if len(view.Failing) == 0 {
+		doc.Text("No controls failed.", pdf.Body)
+	}
+	for _, f := range view.Failing {
+		doc.Gap(6)
+		doc.Text(fmt.Sprintf("[%s] %s - %s", strings.ToUpper(f.Severity), f.ControlID, f.ControlTitle), pdf.Strong)
+		if len(f.SatisfiedBy) > 0 {
+			doc.Text("Evaluated with the checks of "+strings.Join(f.SatisfiedBy, ", "), pdf.Small)
+		}
+		doc.Text(fmt.Sprintf("Affected resources (%d): %s", f.AffectedCount, strings.Join(f.AffectedResources, ", ")), pdf.Body)
+		if f.Evidence != "" {
+			doc.Text("Evidence:\n"+f.Evidence, pdf.Small)
+		}
+		if f.Remediation != "" {
+			doc.Text("Remediation: "+f.Remediation, pdf.Body)
+		}
+	}
+
+	if len(view.Excepted) > 0 {
+		doc.Gap(12)
+		doc.Text("Accepted risks", pdf.Heading)
+		doc.Rule()
+		for _, f := range view.Excepted {
+			doc.Gap(6)
+			doc.Text(fmt.Sprintf("[%s] %s - %s (%s)", strings.ToUpper(f.Severity), f.ControlID, f.ControlTitle, statusLabel(f.Status)), pdf.Strong)
+			doc.Text("Excepted resources: "+strings.Join(f.ExceptedResources, ", "), pdf.Body)
+			for _, e := range f.Exceptions {
+				scope := "all resources"
+				if e.ResourceID != "" {
+					scope = e.ResourceID
+				}
+				doc.Text(fmt.Sprintf("Exception %s (%s), expires %s: %s", e.ID, scope, e.ExpiresAt.UTC().Format("2006-01-02"), e.Justification), pdf.Small)
+			}
+		}
+	}
+
+	doc.Gap(12)
+	doc.Text("All controls", pdf.Heading)
+	doc.Rule()
+	controlWidths := []float64{70, 225, 70, 60, 70}
+	doc.Row([]string{"Control", "Title", "Category", "Severity", "Status"}, controlWidths, pdf.Strong)
+	for _, f := range view.Findings {
+		doc.Row([]string{f.ControlID, f.ControlTitle, f.Category, f.Severity, statusLabel(f.Status)}, controlWidths, pdf.Body)
+	}
+
+	return doc.Bytes()
+}
+
+// renderComplianceCSV renders one row per finding. Text cells are
+// neutralized, since titles and resource IDs may come from user input.
+func renderComplianceCSV(export *compliance.AssessmentExport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{
+		"framework", "control_id", "control_title", "category", "severity", "status",
+		"affected_count", "affected_resources", "excepted_resources", "exception_ids",
+		"satisfied_by", "remediation", "evidence",
+	})
+	for _, f := range export.Findings {
+		_ = writer.Write([]string{
+			utils.CSVText(export.Assessment.FrameworkID),
+			utils.CSVText(f.ControlID),
+			utils.CSVText(f.ControlTitle),
+			utils.CSVText(f.Category),
+			utils.CSVText(f.Severity),
+			utils.CSVText(f.Status),
+			strconv.Itoa(f.AffectedCount),
+			utils.CSVText(strings.Join(f.AffectedResources, ";")),
+			utils.CSVText(strings.Join(f.ExceptedResources, ";")),
+			utils.CSVText(strings.Join(f.ExceptionIDs, ";")),
+			utils.CSVText(strings.Join(f.SatisfiedBy, ";")),
+			utils.CSVText(f.Remediation),
+			utils.CSVText(f.Evidence),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// OSCAL assessment results, limited to the fields the report fills in. See
+// https://pages.nist.gov/OSCAL/reference/latest/assessment-results/json-reference/
+const oscalVersion = "1.1.2"
+
+type oscalDocument struct {
+	AssessmentResults oscalAssessmentResults `json:"assessment-results"`
+}
+
+type oscalAssessmentResults struct {
+	UUID     string        `json:"uuid"`
+	Metadata oscalMetadata `json:"metadata"`
+	ImportAP oscalImportAP `json:"import-ap"`
+	Results  []oscalResult `json:"results"`
+}
+
+type oscalMetadata struct {
+	Title        string `json:"title"`
+	LastModified string `json:"last-modified"`
+	Version      string `json:"version"`
+	OSCALVersion string `json:"oscal-version"`
+}
+
+type oscalImportAP struct {
+	Href string `json:"href"`
+}
+
+type oscalResult struct {
+	UUID             string                `json:"uuid"`
+	Title            string                `json:"title"`
+	Description      string                `json:"description"`
+	Start            string                `json:"start"`
+	ReviewedControls oscalReviewedControls `json:"reviewed-controls"`
+	Observations     []oscalObservation    `json:"observations,omitempty"`
+	Risks            []oscalRisk           `json:"risks,omitempty"`
+	Findings         []oscalFinding        `json:"findings,omitempty"`
+}
+
+type oscalReviewedControls struct {
+	ControlSelections []oscalControlSelection `json:"control-selections"`
+}
+
+type oscalControlSelection struct {
+	IncludeControls []oscalControlRef `json:"include-controls"`
+}
+
+type oscalControlRef struct {
+	ControlID string `json:"control-id"`
+}
+
+type oscalObservation struct {
+	UUID             string          `json:"uuid"`
+	Title            string          `json:"title"`
+	Description      string          `json:"description"`
+	Methods          []string        `json:"methods"`
+	Types            []string        `json:"types"`
+	Subjects         []oscalSubject  `json:"subjects,omitempty"`
+	RelevantEvidence []oscalEvidence `json:"relevant-evidence,omitempty"`
+	Collected        string          `json:"collected"`
+}
+
+type oscalSubject struct {
+	SubjectUUID string `json:"subject-uuid"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+}
+
+type oscalEvidence struct {
+	Description string `json:"description"`
+}
+
+type oscalRisk struct {
+	UUID        string `json:"uuid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Statement   string `json:"statement"`
+	Status      string `json:"status"`
+	Deadline    string `json:"deadline,omitempty"`
+}
+
+type oscalFinding struct {
+	UUID                string                `json:"uuid"`
+	Title               string                `json:"title"`
+	Description         string                `json:"description"`
+	Target              oscalTarget           `json:"target"`
+	RelatedObservations []oscalRelatedUUID    `json:"related-observations,omitempty"`
+	RelatedRisks        []oscalRelatedRiskRef `json:"related-risks,omitempty"`
+}
+
+type oscalTarget struct {
+	Type     string            `json:"type"`
+	TargetID string            `json:"target-id"`
+	Status   oscalTargetStatus `json:"status"`
+}
+
+type oscalTargetStatus struct {
+	State string `json:"state"`
+}
+
+type oscalRelatedUUID struct {
+	ObservationUUID string `json:"observation-uuid"`
+}
+
+type oscalRelatedRiskRef struct {
+	RiskUUID string `json:"risk-uuid"`
+}
+
+// renderComplianceOSCAL renders an OSCAL assessment-results document.
+// Controls that passed, failed or were excepted become findings; failing
+// and excepted resources are recorded as observations, and every exception
+// becomes a risk with an approved deviation. Controls that did not apply
+// are reviewed but have no finding. UUIDs are derived from the assessment,
+// so exporting the same assessment twice gives the same document.
+func renderComplianceOSCAL(export *compliance.AssessmentExport) ([]byte, error) {
+	a := export.Assessment
+	id := func(parts ...string) string {
+		return uuid.NewSHA1(uuid.NameSpaceURL, []byte("infraudit:compliance:"+a.ID+":"+strings.Join(parts, ":"))).String()
+	}
+	collected := a.AssessmentDate.UTC().Format(time.RFC3339)
+
+	result := oscalResult{
+		UUID:  id("result"),
+		Title: a.FrameworkName + " automated assessment",
+		Description: fmt.Sprintf("Automated assessment of %d controls: %d passed, %d failed, %d excepted, %d not applicable. Compliance score %.1f%%.",
+			a.TotalControls, a.PassedControls, a.FailedControls, a.ExceptedControls, a.NotApplicableControls, a.CompliancePercent),
+		Start: collected,
+	}
+
+	exceptions := make(map[string]*compliance.Exception, len(export.Exceptions))
+	for _, e := range export.Exceptions {
+		exceptions[e.ID] = e
+	}
+
+	selection := oscalControlSelection{IncludeControls: make([]oscalControlRef, 0, len(export.Findings))}
+	for _, f := range export.Findings {
+		selection.IncludeControls = append(selection.IncludeControls, oscalControlRef{ControlID: oscalControlID(f.ControlID)})
+		if f.Status == compliance.ControlStatusNotApplicable {
+			continue
+		}
+
+		finding := oscalFinding{
+			UUID:        id("finding", f.ControlID),
+			Title:       f.ControlID + " " + f.ControlTitle,
+			Description: statusLabel(f.Status) + ".",
+			Target: oscalTarget{
+				Type:     "objective-id",
+				TargetID: oscalControlID(f.ControlID),
+				Status:   oscalTargetStatus{State: "satisfied"},
+			},
+		}
+		if f.Status != compliance.ControlStatusPassed {
+			finding.Target.Status.State = "not-satisfied"
+		}
+		if len(f.SatisfiedBy) > 0 {
+			finding.Description += " Evaluated with the checks of " + strings.Join(f.SatisfiedBy, ", ") + "."
+		}
+
+		if len(f.AffectedResources) > 0 {
+			obs := oscalObservation{
+				UUID:        id("observation", f.ControlID),
+				Title:       f.ControlID + " failing resources",
+				Description: fmt.Sprintf("Resources failing %s: %d.", f.ControlID, len(f.AffectedResources)),
+				Methods:     []string{"AUTOMATED"},
+				Types:       []string{"finding"},
+				Subjects:    oscalSubjects(f.AffectedResources, id),
+				Collected:   collected,
+			}
+			if f.Evidence != "" {
+				obs.RelevantEvidence = []oscalEvidence{{Description: f.Evidence}}
+			}
+			result.Observations = append(result.Observations, obs)
+			finding.RelatedObservations = append(finding.RelatedObservations, oscalRelatedUUID{ObservationUUID: obs.UUID})
+		}
+		if len(f.ExceptedResources) > 0 {
+			obs := oscalObservation{
+				UUID:        id("observation", f.ControlID, "excepted"),
+				Title:       f.ControlID + " excepted resources",
+				Description: fmt.Sprintf("Resources failing %s under an approved exception: %d.", f.ControlID, len(f.ExceptedResources)),
+				Methods:     []string{"AUTOMATED"},
+				Types:       []string{"finding"},
+				Subjects:    oscalSubjects(f.ExceptedResources, id),
+				Collected:   collected,
+			}
+			result.Observations = append(result.Observations, obs)
+			finding.RelatedObservations = append(finding.RelatedObservations, oscalRelatedUUID{ObservationUUID: obs.UUID})
+		}
+
+		for _, exceptionID := range f.ExceptionIDs {
+			e, ok := exceptions[exceptionID]
+			if !ok {
+				continue
+			}
+			scope := "all resources"
+			if e.ResourceID != "" {
+				scope = e.ResourceID
+			}
+			risk := oscalRisk{
+				UUID:        id("risk", e.ID),
+				Title:       "Accepted risk for " + f.ControlID,
+				Description: fmt.Sprintf("Exception %s covers %s of control %s.", e.ID, scope, f.ControlID),
+				Statement:   e.Justification,
+				Status:      "deviation-approved",
+				Deadline:    e.ExpiresAt.UTC().Format(time.RFC3339),
+			}
+			result.Risks = append(result.Risks, risk)
+			finding.RelatedRisks = append(finding.RelatedRisks, oscalRelatedRiskRef{RiskUUID: risk.UUID})
+		}
+
+		result.Findings = append(result.Findings, finding)
+	}
+	result.ReviewedControls.ControlSelections = []oscalControlSelection{selection}
+
+	doc := oscalDocument{AssessmentResults: oscalAssessmentResults{
+		UUID: id("assessment-results"),
+		Metadata: oscalMetadata{
+			Title:        a.FrameworkName + " Assessment Results",
+			LastModified: collected,
+			Version:      a.ID,
+			OSCALVersion: oscalVersion,
+		},
+		ImportAP: oscalImportAP{Href: "#" + a.FrameworkID},
+		Results:  []oscalResult{result},
+	}}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// oscalControlID turns a control ID into an OSCAL token the way NIST
+// catalogs spell them: "AC-2" becomes "ac-2" and "2.1.1" becomes
+// "control-2.1.1", since tokens must start with a letter
+func oscalControlID(controlID string) string {
+	id := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, controlID)
+	if first, _ := utf8.DecodeRuneInString(id); !unicode.IsLetter(first) && first != '_' {
+		id = "control-" + id
+	}
+	return id
+}
+
+func oscalSubjects(resourceIDs []string, id func(parts ...string) string) []oscalSubject {
+	subjects := make([]oscalSubject, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		subjects = append(subjects, oscalSubject{
+			SubjectUUID: id("resource", resourceID),
+			Type:        "resource",
+			Title:       resourceID,
+		})
+	}
+	return subjects
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+// newReportedComplianceService runs an assessment where 2.1.1 fails on
+// plain-bucket and is excepted for legacy-bucket, and 5.1 passes
+func newReportedComplianceService(t *testing.T) *ComplianceServiceImpl {
+	t.Helper()
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "legacy-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+	)
+	repo.controls[0].Category = "Storage"
+	repo.controls[0].Severity = compliance.SeverityHigh
+	repo.exceptions = []*compliance.Exception{
+		{ID: "e1", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "legacy-bucket", Justification: "Archive bucket, retired in Q3",
+			Status: compliance.ExceptionStatusApproved, ExpiresAt: time.Now().Add(24 * time.Hour)},
+	}
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "cis-aws", FrameworkName: "CIS AWS", AssessmentDate: time.Now()}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[0])
+	return svc
+}
+
+func TestComplianceService_ExportAssessment(t *testing.T) {
+	svc := newReportedComplianceService(t)
+
+	export, err := svc.ExportAssessment(context.Background(), 1, "a1")
+	if err != nil {
+		t.Fatalf("ExportAssessment() error = %v", err)
+	}
+	if _, err := svc.ExportAssessment(context.Background(), 2, "a1"); !isNotFound(err) {
+		t.Errorf("ExportAssessment() of another user's assessment error = %v, want not found", err)
+	}
+	if export.Summary.FailingBySeverity[compliance.SeverityHigh] != 1 || export.Summary.NotApplicable != 2 {
+		t.Errorf("summary = %+v, want one failing high control and two not applicable", export.Summary)
+	}
+	if len(export.Categories) != 2 || export.Categories[0].Category != "Storage" || export.Categories[0].Failed != 1 || export.Categories[0].Score != 0 {
+		t.Errorf("categories = %+v, want Storage failing first", export.Categories)
+	}
+	if len(export.Exceptions) != 1 || export.Exceptions[0].ID != "e1" {
+		t.Errorf("exceptions = %v, want [e1]", export.Exceptions)
+	}
+}
+
+func TestComplianceService_GenerateReport(t *testing.T) {
+	svc := newReportedComplianceService(t)
+	ctx := context.Background()
+
+	html, err := svc.GenerateReport(ctx, 1, "a1", "html")
+	if err != nil {
+		t.Fatalf("GenerateReport(html) error = %v", err)
+	}
+	for _, want := range []string{"CIS AWS Compliance Report", "50.0%", "2.1.1 &mdash; S3 encryption", "plain-bucket", "Archive bucket, retired in Q3", "Enable default encryption"} {
+		if !bytes.Contains(html, []byte(want)) {
+			t.Errorf("HTML report is missing %q", want)
+		}
+	}
+
+	pdf, err := svc.GenerateReport(ctx, 1, "a1", "PDF")
+	if err != nil {
+		t.Fatalf("GenerateReport(pdf) error = %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.Contains(pdf, []byte("[HIGH] 2.1.1 - S3 encryption")) {
+		t.Error("PDF report is missing the failing control")
+	}
+
+	out, err := svc.GenerateReport(ctx, 1, "a1", "csv")
+	if err != nil {
+		t.Fatalf("GenerateReport(csv) error = %v", err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 5 || rows[0][1] != "control_id" {
+		t.Fatalf("got %d CSV rows, want a header and 4 controls", len(rows))
+	}
+	if row := rows[1]; row[1] != "2.1.1" || row[5] != "failed" || row[7] != "plain-bucket" || row[8] != "legacy-bucket" || row[9] != "e1" {
+		t.Errorf("2.1.1 row = %v", row)
+	}
+
+	out, err = svc.GenerateReport(ctx, 1, "a1", "oscal")
+	if err != nil {
+		t.Fatalf("GenerateReport(oscal) error = %v", err)
+	}
+	var doc oscalDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid OSCAL: %v", err)
+	}
+	result := doc.AssessmentResults.Results[0]
+	states := make(map[string]string)
+	for _, f := range result.Findings {
+		states[f.Target.TargetID] = f.Target.Status.State
+	}
+	if len(states) != 2 || states["control-2.1.1"] != "not-satisfied" || states["control-5.1"] != "satisfied" {
+		t.Errorf("finding states = %v, want 2.1.1 not-satisfied and 5.1 satisfied", states)
+	}
+	if len(result.Observations) != 2 || len(result.Risks) != 1 || result.Risks[0].Status != "deviation-approved" {
+		t.Errorf("got %d observations and risks %+v, want 2 observations and an approved deviation", len(result.Observations), result.Risks)
+	}
+	if again, _ := svc.GenerateReport(ctx, 1, "a1", "oscal"); !bytes.Equal(again, out) {
+		t.Error("OSCAL document changes between exports")
+	}
+
+	if _, err := svc.GenerateReport(ctx, 1, "a1", "docx"); !isBadRequest(err) {
+		t.Errorf("GenerateReport(docx) error = %v, want bad request", err)
+	}
+	if _, err := svc.GenerateReport(ctx, 1, "missing", "html"); !isNotFound(err) {
+		t.Errorf("GenerateReport(missing) error = %v, want not found", err)
+	}
+	if _, err := svc.GenerateReport(ctx, 2, "a1", "csv"); !isNotFound(err) {
+		t.Errorf("GenerateReport() of another user's assessment error = %v, want not found", err)
+	}
+}
+
+func TestRenderComplianceCSV_NeutralizesFormulas(t *testing.T) {
+	export := &compliance.AssessmentExport{
+		Assessment: &compliance.Assessment{FrameworkID: "cis-aws"},
+		Findings: []compliance.AssessmentFinding{{
+			ControlID:         "2.1.1",
+			ControlTitle:      "=HYPERLINK(\"http://example.com\")",
+			Status:            compliance.ControlStatusFailed,
+			AffectedCount:     1,
+			AffectedResources: []string{"@bucket"},
+		}},
+	}
+	out, err := renderComplianceCSV(export)
+	if err != nil {
+		t.Fatalf("renderComplianceCSV() error = %v", err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if row := rows[1]; row[2] != "'=HYPERLINK(\"http://example.com\")" || row[6] != "1" || row[7] != "'@bucket" {
+		t.Errorf("row = %v, want the title and resources quoted and the count left alone", row)
+	}
+}
+
+func isBadRequest(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeBadRequest
+}
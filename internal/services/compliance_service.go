@@ -3,21 +3,34 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/detector"
 	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
 	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/domain/vulnerability"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 )
 
+// maxEvidenceResources caps the failing resources whose evidence is kept in
+// a finding. Every failing resource is still listed as affected.
+const maxEvidenceResources = 25
+
 // ComplianceService implements compliance.Service
 type ComplianceServiceImpl struct {
-	repo      compliance.Repository
-	driftRepo drift.Repository
-	vulnRepo  vulnerability.Repository
-	logger    *logger.Logger
+	repo         compliance.Repository
+	driftRepo    drift.Repository
+	vulnRepo     vulnerability.Repository
+	resourceRepo resource.Repository
+	notifier     notification.Service
+	logger       *logger.Logger
 }
 
 // NewComplianceService creates a new compliance service
@@ -35,6 +48,17 @@ func NewComplianceService(
 	}
 }
 
+// SetResourceRepository enables configuration checks, which are evaluated
+// against the synced resources
+func (s *ComplianceServiceImpl) SetResourceRepository(resourceRepo resource.Repository) {
+	s.resourceRepo = resourceRepo
+}
+
+// SetNotificationService enables notices for exceptions about to expire
+func (s *ComplianceServiceImpl) SetNotificationService(notifier notification.Service) {
+	s.notifier = notifier
+}
+
 // ListFrameworks lists all available compliance frameworks
 func (s *ComplianceServiceImpl) ListFrameworks(ctx context.Context) ([]*compliance.Framework, error) {
 	return s.repo.ListFrameworks(ctx)
@@ -147,11 +171,50 @@ func (s *ComplianceServiceImpl) executeAssessment(ctx context.Context, assessmen
 		return
 	}
 
+	resources, err := s.listCheckedResources(ctx, assessment.UserID, framework.Provider)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"assessment_id": assessment.ID,
+		}).ErrorWithErr(err, "Failed to list resources for compliance assessment")
+		assessment.Status = compliance.AssessmentStatusFailed
+		s.repo.UpdateAssessment(ctx, assessment)
+		return
+	}
+
+	links, err := s.loadControlLinks(ctx)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"assessment_id": assessment.ID,
+		}).ErrorWithErr(err, "Failed to load control links for compliance assessment")
+		assessment.Status = compliance.AssessmentStatusFailed
+		s.repo.UpdateAssessment(ctx, assessment)
+		return
+	}
+
+	exceptions, err := s.loadActiveExceptions(ctx, assessment.UserID, assessment.AssessmentDate)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"assessment_id": assessment.ID,
+		}).ErrorWithErr(err, "Failed to load exceptions for compliance assessment")
+		assessment.Status = compliance.AssessmentStatusFailed
+		s.repo.UpdateAssessment(ctx, assessment)
+		return
+	}
+
 	assessment.TotalControls = len(controls)
 	var findings []compliance.AssessmentFinding
+	cache := newCheckCache()
 
 	for _, control := range controls {
-		finding := s.evaluateControl(ctx, assessment.UserID, control)
+		finding, err := s.evaluateControl(ctx, assessment.UserID, control, resources, links, exceptions, cache)
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"assessment_id": assessment.ID,
+			}).ErrorWithErr(err, "Failed to evaluate control for compliance assessment")
+			assessment.Status = compliance.AssessmentStatusFailed
+			s.repo.UpdateAssessment(ctx, assessment)
+			return
+		}
 		findings = append(findings, finding)
 
 		switch finding.Status {
@@ -159,13 +222,16 @@ func (s *ComplianceServiceImpl) executeAssessment(ctx context.Context, assessmen
 			assessment.PassedControls++
 		case compliance.ControlStatusFailed:
 			assessment.FailedControls++
+		case compliance.ControlStatusExcepted:
+			assessment.ExceptedControls++
 		case compliance.ControlStatusNotApplicable:
 			assessment.NotApplicableControls++
 		}
 	}
 
-	// Calculate compliance percentage
-	applicable := assessment.TotalControls - assessment.NotApplicableControls
+	// Calculate compliance percentage. Excepted controls count neither for
+	// nor against it.
+	applicable := assessment.TotalControls - assessment.NotApplicableControls - assessment.ExceptedControls
 	if applicable > 0 {
 		assessment.CompliancePercent = (float64(assessment.PassedControls) / float64(applicable)) * 100
 	}
@@ -184,40 +250,331 @@ func (s *ComplianceServiceImpl) executeAssessment(ctx context.Context, assessmen
 	}).Info("Compliance assessment completed")
 }
 
-// evaluateControl evaluates a single control
-func (s *ComplianceServiceImpl) evaluateControl(ctx context.Context, userID int64, control *compliance.Control) compliance.AssessmentFinding {
+// evaluateControl evaluates a single control. Mappings with a check query
+// are evaluated against the configuration of every in-scope resource;
+// mappings without one fall back to open drifts of their rule type. The
+// mappings of linked controls count as the control's own. A control with no
+// in-scope resources and no drift mappings does not apply. Failing resources
+// covered by an active exception are moved to the excepted resources, and a
+// control whose failures are all excepted is excepted.
+func (s *ComplianceServiceImpl) evaluateControl(ctx context.Context, userID int64, control *compliance.Control, resources []*resource.Resource, links controlLinks, exceptions exceptionIndex, cache *checkCache) (compliance.AssessmentFinding, error) {
 	finding := compliance.AssessmentFinding{
 		ControlID:    control.ControlID,
 		ControlTitle: control.Title,
 		Category:     control.Category,
 		Severity:     control.Severity,
 		Remediation:  control.Remediation,
-		Status:       compliance.ControlStatusPassed, // Default to passed
+		Status:       compliance.ControlStatusNotApplicable,
+	}
+
+	mappings, satisfiedBy, err := s.effectiveMappings(ctx, control, links)
+	if err != nil {
+		return finding, fmt.Errorf("failed to load mappings of control %s: %w", control.ControlID, err)
+	}
+	finding.SatisfiedBy = satisfiedBy
+
+	var checkMappings []*compliance.ControlMapping
+	affected := make(map[string]bool)
+	for _, mapping := range mappings {
+		if mapping.CheckQuery != "" {
+			checkMappings = append(checkMappings, mapping)
+			continue
+		}
+		if mapping.SecurityRuleType == "" {
+			continue
+		}
+		finding.Status = compliance.ControlStatusPassed
+		for _, resourceID := range s.checkForViolations(ctx, userID, mapping, cache) {
+			if !affected[resourceID] {
+				affected[resourceID] = true
+				finding.AffectedResources = append(finding.AffectedResources, resourceID)
+			}
+		}
+	}
+
+	results := s.runChecks(checkMappings, resources, cache)
+	var evidence []string
+	failed := 0
+	for _, result := range results {
+		if result.passed {
+			continue
+		}
+		failed++
+		if !affected[result.resource.ResourceID] {
+			affected[result.resource.ResourceID] = true
+			finding.AffectedResources = append(finding.AffectedResources, result.resource.ResourceID)
+		}
+		if len(evidence) < maxEvidenceResources {
+			evidence = append(evidence, fmt.Sprintf("%s: %s", result.resource.ResourceID, strings.Join(result.evidence, ", ")))
+		}
+	}
+
+	if len(results) > 0 {
+		finding.Status = compliance.ControlStatusPassed
+		summary := fmt.Sprintf("%d of %d resources failed the check", failed, len(results))
+		if failed > maxEvidenceResources {
+			summary += fmt.Sprintf(", showing the first %d", maxEvidenceResources)
+		}
+		finding.Evidence = strings.Join(append([]string{summary}, evidence...), "\n")
+	}
+	if len(finding.AffectedResources) > 0 {
+		finding.Status = compliance.ControlStatusFailed
+		exceptions.apply(control, &finding)
+		finding.AffectedCount = len(finding.AffectedResources)
+	}
+
+	return finding, nil
+}
+
+// exceptionIndex holds the active exceptions of a user by control
+type exceptionIndex map[compliance.ControlRef][]*compliance.Exception
+
+// loadActiveExceptions loads and indexes the exceptions that are active at now
+func (s *ComplianceServiceImpl) loadActiveExceptions(ctx context.Context, userID int64, now time.Time) (exceptionIndex, error) {
+	exceptions, err := s.repo.ListExceptions(ctx, userID, compliance.ExceptionFilter{Status: compliance.ExceptionStatusApproved})
+	if err != nil {
+		return nil, err
+	}
+	index := make(exceptionIndex)
+	for _, e := range exceptions {
+		if e.IsActive(now) {
+			ref := compliance.ControlRef{FrameworkID: e.FrameworkID, ControlID: e.ControlID}
+			index[ref] = append(index[ref], e)
+		}
+	}
+	return index, nil
+}
+
+// covering returns the exception of a control that covers a resource, or nil
+func (idx exceptionIndex) covering(control *compliance.Control, resourceID string) *compliance.Exception {
+	for _, e := range idx[compliance.ControlRef{FrameworkID: control.FrameworkID, ControlID: control.ControlID}] {
+		if e.Covers(resourceID) {
+			return e
+		}
+	}
+	return nil
+}
+
+// apply moves the affected resources of a failed finding that are covered
+// by exceptions to its excepted resources
+func (idx exceptionIndex) apply(control *compliance.Control, finding *compliance.AssessmentFinding) {
+	var remaining []string
+	used := make(map[string]bool)
+	for _, resourceID := range finding.AffectedResources {
+		e := idx.covering(control, resourceID)
+		if e == nil {
+			remaining = append(remaining, resourceID)
+			continue
+		}
+		finding.ExceptedResources = append(finding.ExceptedResources, resourceID)
+		if !used[e.ID] {
+			used[e.ID] = true
+			finding.ExceptionIDs = append(finding.ExceptionIDs, e.ID)
+		}
+	}
+	finding.AffectedResources = remaining
+	if len(remaining) == 0 {
+		finding.Status = compliance.ControlStatusExcepted
+	}
+}
+
+// controlLinks indexes crosswalk links in both directions
+type controlLinks map[compliance.ControlRef][]compliance.ControlRef
+
+// indexControlLinks builds the link index, dropping links declared on both sides
+func indexControlLinks(links []*compliance.ControlLink) controlLinks {
+	index := make(controlLinks)
+	add := func(from, to compliance.ControlRef) {
+		for _, ref := range index[from] {
+			if ref == to {
+				return
+			}
+		}
+		index[from] = append(index[from], to)
+	}
+	for _, l := range links {
+		add(l.Control, l.Linked)
+		add(l.Linked, l.Control)
+	}
+	return index
+}
+
+// loadControlLinks loads and indexes every crosswalk link
+func (s *ComplianceServiceImpl) loadControlLinks(ctx context.Context) (controlLinks, error) {
+	links, err := s.repo.ListControlLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return indexControlLinks(links), nil
+}
+
+// effectiveMappings returns a control's own mappings followed by those of
+// its linked controls, and the linked controls that contributed any. Links
+// are followed one step, not transitively. Links to controls of frameworks
+// that are not installed are ignored.
+func (s *ComplianceServiceImpl) effectiveMappings(ctx context.Context, control *compliance.Control, links controlLinks) ([]*compliance.ControlMapping, []string, error) {
+	mappings, err := s.repo.GetMappingsForControl(ctx, control.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var satisfiedBy []string
+	for _, ref := range links[compliance.ControlRef{FrameworkID: control.FrameworkID, ControlID: control.ControlID}] {
+		linked, err := s.repo.GetControlByFrameworkAndID(ctx, ref.FrameworkID, ref.ControlID)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		linkedMappings, err := s.repo.GetMappingsForControl(ctx, linked.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(linkedMappings) > 0 {
+			mappings = append(mappings, linkedMappings...)
+			satisfiedBy = append(satisfiedBy, ref.String())
+		}
+	}
+	return mappings, satisfiedBy, nil
+}
+
+// checkKey identifies the result of a mapping's check on one resource
+type checkKey struct {
+	mappingID  string
+	resourceID string
+}
+
+// checkCache keeps check results and drift violations for one evaluation
+// run, so checks shared by linked controls run once per resource
+type checkCache struct {
+	checks     map[string]*detector.ComplianceCheck
+	results    map[checkKey]*detector.CheckResult
+	violations map[string][]string
+}
+
+func newCheckCache() *checkCache {
+	return &checkCache{
+		checks:     make(map[string]*detector.ComplianceCheck),
+		results:    make(map[checkKey]*detector.CheckResult),
+		violations: make(map[string][]string),
 	}
+}
+
+// resourceCheck is the outcome of a control's checks on one resource
+type resourceCheck struct {
+	resource *resource.Resource
+	passed   bool
+	evidence []string
+}
 
-	// Get mappings for this control
-	mappings, _ := s.repo.GetMappingsForControl(ctx, control.ID)
-	if len(mappings) == 0 {
-		finding.Status = compliance.ControlStatusNotApplicable
-		return finding
+// runChecks evaluates the check queries of a control's mappings against
+// every resource in their scope. A resource covered by several mappings
+// passes only if it passes all of them. Invalid checks and unreadable
+// configurations are logged and skipped. A nil cache disables caching.
+func (s *ComplianceServiceImpl) runChecks(mappings []*compliance.ControlMapping, resources []*resource.Resource, cache *checkCache) []*resourceCheck {
+	if cache == nil {
+		cache = newCheckCache()
 	}
+	var results []*resourceCheck
+	byResource := make(map[string]*resourceCheck)
 
-	// Check for violations based on mappings
 	for _, mapping := range mappings {
-		violations := s.checkForViolations(ctx, userID, mapping)
-		if len(violations) > 0 {
-			finding.Status = compliance.ControlStatusFailed
-			finding.AffectedCount = len(violations)
-			finding.AffectedResources = violations
-			break
+		check, ok := cache.checks[mapping.ID]
+		if !ok {
+			var err error
+			check, err = detector.ParseComplianceCheck(mapping.CheckQuery)
+			if err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"mapping_id": mapping.ID,
+					"control_id": mapping.ControlID,
+					"error":      err.Error(),
+				}).Warn("Skipping invalid compliance check")
+			}
+			cache.checks[mapping.ID] = check
+		}
+		if check == nil {
+			continue
+		}
+
+		for _, res := range resources {
+			if !mappingCovers(mapping, res) {
+				continue
+			}
+			key := checkKey{mappingID: mapping.ID, resourceID: res.ResourceID}
+			outcome, ok := cache.results[key]
+			if !ok {
+				var err error
+				outcome, err = check.Evaluate(res.Configuration)
+				if err != nil {
+					s.logger.WithFields(map[string]interface{}{
+						"resource_id": res.ResourceID,
+						"error":       err.Error(),
+					}).Warn("Skipping compliance check on unreadable configuration")
+				}
+				cache.results[key] = outcome
+			}
+			if outcome == nil {
+				continue
+			}
+
+			result, ok := byResource[res.ResourceID]
+			if !ok {
+				result = &resourceCheck{resource: res, passed: true}
+				byResource[res.ResourceID] = result
+				results = append(results, result)
+			}
+			result.passed = result.passed && outcome.Passed
+			result.evidence = append(result.evidence, outcome.Evidence...)
 		}
 	}
 
-	return finding
+	return results
+}
+
+// mappingCovers reports whether a resource is in a mapping's scope.
+// Terminated resources are never checked.
+func mappingCovers(mapping *compliance.ControlMapping, res *resource.Resource) bool {
+	if res.Status == resource.StatusTerminated {
+		return false
+	}
+	if mapping.Provider != "" && !strings.EqualFold(mapping.Provider, res.Provider) {
+		return false
+	}
+	normalize := func(t string) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(t)), "_", "-")
+	}
+	return mapping.ResourceType == "" || normalize(mapping.ResourceType) == normalize(res.Type)
+}
+
+// listCheckedResources returns the synced resources configuration checks
+// run against, limited to one provider when it is given
+func (s *ComplianceServiceImpl) listCheckedResources(ctx context.Context, userID int64, providerName string) ([]*resource.Resource, error) {
+	if s.resourceRepo == nil {
+		return nil, nil
+	}
+
+	providers := []string{provider.ProviderAWS, provider.ProviderGCP, provider.ProviderAzure}
+	if providerName != "" {
+		providers = []string{providerName}
+	}
+
+	var resources []*resource.Resource
+	for _, p := range providers {
+		res, err := s.resourceRepo.ListByProvider(ctx, userID, p)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, res...)
+	}
+	return resources, nil
 }
 
 // checkForViolations checks for violations based on a control mapping
-func (s *ComplianceServiceImpl) checkForViolations(ctx context.Context, userID int64, mapping *compliance.ControlMapping) []string {
+func (s *ComplianceServiceImpl) checkForViolations(ctx context.Context, userID int64, mapping *compliance.ControlMapping, cache *checkCache) []string {
+	if violations, ok := cache.violations[mapping.SecurityRuleType]; ok {
+		return violations
+	}
 	var violations []string
 
 	// Check drifts
@@ -230,6 +587,7 @@ func (s *ComplianceServiceImpl) checkForViolations(ctx context.Context, userID i
 		}
 	}
 
+	cache.violations[mapping.SecurityRuleType] = violations
 	return violations
 }
 
@@ -248,14 +606,99 @@ func (s *ComplianceServiceImpl) GetLatestAssessment(ctx context.Context, userID
 	return s.repo.GetLatestAssessment(ctx, userID, frameworkID)
 }
 
-// GetResourceCompliance retrieves compliance status for a resource
+// GetResourceCompliance evaluates the controls of every enabled framework
+// whose checks cover the resource. Controls that only map to drift types
+// are not checked per resource.
 func (s *ComplianceServiceImpl) GetResourceCompliance(ctx context.Context, userID int64, resourceID string) (*compliance.ComplianceStatus, error) {
-	// TODO: Implement resource-level compliance checking
-	return &compliance.ComplianceStatus{
-		ResourceID:    resourceID,
-		OverallStatus: compliance.StatusCompliant,
-		LastChecked:   time.Now(),
-	}, nil
+	if s.resourceRepo == nil {
+		return nil, fmt.Errorf("resource repository not configured")
+	}
+	res, err := s.resourceRepo.GetByID(ctx, userID, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	frameworks, err := s.repo.ListFrameworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status := &compliance.ComplianceStatus{
+		ResourceID:      res.ResourceID,
+		ResourceType:    res.Type,
+		Provider:        res.Provider,
+		ControlStatuses: []compliance.ControlStatus{},
+		OverallStatus:   compliance.StatusCompliant,
+		LastChecked:     now,
+	}
+
+	links, err := s.loadControlLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := s.loadActiveExceptions(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	cache := newCheckCache()
+
+	passed, failed := 0, 0
+	for _, framework := range frameworks {
+		if !framework.IsEnabled || (framework.Provider != "" && !strings.EqualFold(framework.Provider, res.Provider)) {
+			continue
+		}
+		controls, err := s.repo.ListControls(ctx, framework.ID, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, control := range controls {
+			mappings, _, err := s.effectiveMappings(ctx, control, links)
+			if err != nil {
+				return nil, err
+			}
+			var checkMappings []*compliance.ControlMapping
+			for _, m := range mappings {
+				if m.CheckQuery != "" {
+					checkMappings = append(checkMappings, m)
+				}
+			}
+
+			results := s.runChecks(checkMappings, []*resource.Resource{res}, cache)
+			if len(results) == 0 {
+				continue
+			}
+
+			cs := compliance.ControlStatus{
+				FrameworkID: framework.ID,
+				ControlID:   control.ControlID,
+				Title:       control.Title,
+				Status:      compliance.ControlStatusPassed,
+				Evidence:    strings.Join(results[0].evidence, ", "),
+				LastChecked: now,
+			}
+			if results[0].passed {
+				passed++
+			} else if e := exceptions.covering(control, res.ResourceID); e != nil {
+				cs.Status = compliance.ControlStatusExcepted
+				cs.ExceptionID = e.ID
+			} else {
+				cs.Status = compliance.ControlStatusFailed
+				cs.Remediation = control.Remediation
+				failed++
+			}
+			status.ControlStatuses = append(status.ControlStatuses, cs)
+		}
+	}
+
+	switch {
+	case failed > 0 && passed > 0:
+		status.OverallStatus = compliance.StatusPartial
+	case failed > 0:
+		status.OverallStatus = compliance.StatusNonCompliant
+	}
+	return status, nil
 }
 
 // GetComplianceOverview returns a high-level compliance summary
@@ -286,6 +729,7 @@ func (s *ComplianceServiceImpl) GetComplianceOverview(ctx context.Context, userI
 			TotalControls:     latest.TotalControls,
 			PassedControls:    latest.PassedControls,
 			FailedControls:    latest.FailedControls,
+			ExceptedControls:  latest.ExceptedControls,
 			CompliancePercent: latest.CompliancePercent,
 			LastAssessment:    latest.AssessmentDate.Format(time.RFC3339),
 		}
@@ -294,10 +738,12 @@ func (s *ComplianceServiceImpl) GetComplianceOverview(ctx context.Context, userI
 		overview.TotalControls += latest.TotalControls
 		overview.PassedControls += latest.PassedControls
 		overview.FailedControls += latest.FailedControls
+		overview.ExceptedControls += latest.ExceptedControls
 	}
 
-	if overview.TotalControls > 0 {
-		overview.CompliancePercent = (float64(overview.PassedControls) / float64(overview.TotalControls)) * 100
+	// Accepted risks do not drag the overall score down
+	if scored := overview.TotalControls - overview.ExceptedControls; scored > 0 {
+		overview.CompliancePercent = (float64(overview.PassedControls) / float64(scored)) * 100
 	}
 
 	return overview, nil
@@ -343,27 +789,167 @@ func (s *ComplianceServiceImpl) GetComplianceTrend(ctx context.Context, userID i
 	return trend, nil
 }
 
-// GenerateReport generates a compliance report
-func (s *ComplianceServiceImpl) GenerateReport(ctx context.Context, assessmentID string, format string) ([]byte, error) {
-	assessment, err := s.repo.GetAssessment(ctx, assessmentID)
+// GetCrosswalk evaluates every control of the enabled frameworks against the
+// current resources and lines linked controls up next to each other. Checks
+// shared by linked controls run once. With a framework ID, every control of
+// that framework gets a row; otherwise rows are the controls that declare
+// links in their catalog.
+func (s *ComplianceServiceImpl) GetCrosswalk(ctx context.Context, userID int64, frameworkID string) (*compliance.Crosswalk, error) {
+	if frameworkID != "" {
+		if _, err := s.repo.GetFramework(ctx, frameworkID); err != nil {
+			return nil, err
+		}
+	}
+
+	frameworks, err := s.repo.ListFrameworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.repo.ListControlLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := indexControlLinks(links)
+	resources, err := s.listCheckedResources(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := s.loadActiveExceptions(ctx, userID, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
-	export := &compliance.AssessmentExport{
-		Assessment:  assessment,
-		GeneratedAt: time.Now().Format(time.RFC3339),
+	view := &compliance.Crosswalk{
+		Frameworks: make([]compliance.FrameworkCompliance, 0),
+		Rows:       make([]compliance.CrosswalkRow, 0),
+	}
+	cache := newCheckCache()
+	statuses := make(map[compliance.ControlRef]compliance.CrosswalkControl)
+	var anchors []compliance.ControlRef
+
+	for _, framework := range frameworks {
+		if !framework.IsEnabled && framework.ID != frameworkID {
+			continue
+		}
+		controls, err := s.repo.ListControls(ctx, framework.ID, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var scoped []*resource.Resource
+		for _, res := range resources {
+			if framework.Provider == "" || strings.EqualFold(framework.Provider, res.Provider) {
+				scoped = append(scoped, res)
+			}
+		}
+
+		fc := compliance.FrameworkCompliance{
+			FrameworkID:   framework.ID,
+			FrameworkName: framework.Name,
+			TotalControls: len(controls),
+		}
+		notApplicable := 0
+		for _, control := range controls {
+			finding, err := s.evaluateControl(ctx, userID, control, scoped, index, exceptions, cache)
+			if err != nil {
+				return nil, err
+			}
+			switch finding.Status {
+			case compliance.ControlStatusPassed:
+				fc.PassedControls++
+			case compliance.ControlStatusFailed:
+				fc.FailedControls++
+			case compliance.ControlStatusExcepted:
+				fc.ExceptedControls++
+			case compliance.ControlStatusNotApplicable:
+				notApplicable++
+			}
+
+			ref := compliance.ControlRef{FrameworkID: framework.ID, ControlID: control.ControlID}
+			statuses[ref] = compliance.CrosswalkControl{
+				FrameworkID:   framework.ID,
+				FrameworkName: framework.Name,
+				ControlID:     control.ControlID,
+				Title:         control.Title,
+				Severity:      control.Severity,
+				Status:        finding.Status,
+				AffectedCount: finding.AffectedCount,
+				SatisfiedBy:   finding.SatisfiedBy,
+			}
+			if frameworkID == "" || framework.ID == frameworkID {
+				anchors = append(anchors, ref)
+			}
+		}
+		if applicable := fc.TotalControls - notApplicable - fc.ExceptedControls; applicable > 0 {
+			fc.CompliancePercent = (float64(fc.PassedControls) / float64(applicable)) * 100
+		}
+		view.Frameworks = append(view.Frameworks, fc)
 	}
 
-	return json.Marshal(export)
+	declared := make(map[compliance.ControlRef]bool, len(links))
+	for _, l := range links {
+		declared[l.Control] = true
+	}
+	for _, ref := range anchors {
+		if frameworkID == "" && !declared[ref] {
+			continue
+		}
+		row := compliance.CrosswalkRow{
+			Control: statuses[ref],
+			Linked:  make([]compliance.CrosswalkControl, 0),
+		}
+		for _, linked := range index[ref] {
+			if status, ok := statuses[linked]; ok {
+				row.Linked = append(row.Linked, status)
+			}
+		}
+		view.Rows = append(view.Rows, row)
+	}
+
+	return view, nil
 }
 
-// ExportAssessment exports an assessment
-func (s *ComplianceServiceImpl) ExportAssessment(ctx context.Context, assessmentID string) (*compliance.AssessmentExport, error) {
+// GenerateReport renders one of the user's assessments as a report in one
+// of the compliance.ReportFormat formats; an empty format means JSON
+func (s *ComplianceServiceImpl) GenerateReport(ctx context.Context, userID int64, assessmentID string, format string) ([]byte, error) {
+	format = strings.ToLower(format)
+	switch format {
+	case "", compliance.ReportFormatJSON, compliance.ReportFormatHTML, compliance.ReportFormatPDF,
+		compliance.ReportFormatCSV, compliance.ReportFormatOSCAL:
+	default:
+		return nil, errors.BadRequest("Unsupported report format " + format)
+	}
+
+	export, err := s.ExportAssessment(ctx, userID, assessmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case compliance.ReportFormatHTML:
+		return renderComplianceHTML(export)
+	case compliance.ReportFormatPDF:
+		return renderCompliancePDF(export), nil
+	case compliance.ReportFormatCSV:
+		return renderComplianceCSV(export)
+	case compliance.ReportFormatOSCAL:
+		return renderComplianceOSCAL(export)
+	default:
+		return json.MarshalIndent(export, "", "  ")
+	}
+}
+
+// ExportAssessment exports one of the user's assessments with its
+// findings, a summary, a per-category breakdown and the exceptions its
+// findings refer to. Other users' assessments are not found.
+func (s *ComplianceServiceImpl) ExportAssessment(ctx context.Context, userID int64, assessmentID string) (*compliance.AssessmentExport, error) {
 	assessment, err := s.repo.GetAssessment(ctx, assessmentID)
 	if err != nil {
 		return nil, err
 	}
+	if assessment.UserID != userID {
+		return nil, errors.NotFound("Assessment")
+	}
 
 	framework, _ := s.repo.GetFramework(ctx, assessment.FrameworkID)
 
@@ -371,12 +957,20 @@ func (s *ComplianceServiceImpl) ExportAssessment(ctx context.Context, assessment
 	json.Unmarshal(assessment.Findings, &findings)
 
 	summary := &compliance.ExportSummary{
-		TotalControls: assessment.TotalControls,
-		Passed:        assessment.PassedControls,
-		Failed:        assessment.FailedControls,
-		Score:         assessment.CompliancePercent,
+		TotalControls:     assessment.TotalControls,
+		Passed:            assessment.PassedControls,
+		Failed:            assessment.FailedControls,
+		Excepted:          assessment.ExceptedControls,
+		NotApplicable:     assessment.NotApplicableControls,
+		Score:             assessment.CompliancePercent,
+		FailingBySeverity: make(map[string]int),
 	}
 
+	var categories []compliance.CategorySummary
+	categoryIndex := make(map[string]int)
+	var exceptions []*compliance.Exception
+	seenExceptions := make(map[string]bool)
+
 	for _, f := range findings {
 		switch f.Severity {
 		case compliance.SeverityCritical:
@@ -388,6 +982,45 @@ func (s *ComplianceServiceImpl) ExportAssessment(ctx context.Context, assessment
 		case compliance.SeverityLow:
 			summary.LowCount++
 		}
+		if f.Status == compliance.ControlStatusFailed {
+			summary.FailingBySeverity[f.Severity]++
+		}
+
+		i, ok := categoryIndex[f.Category]
+		if !ok {
+			i = len(categories)
+			categoryIndex[f.Category] = i
+			categories = append(categories, compliance.CategorySummary{Category: f.Category})
+		}
+		c := &categories[i]
+		c.TotalControls++
+		switch f.Status {
+		case compliance.ControlStatusPassed:
+			c.Passed++
+		case compliance.ControlStatusFailed:
+			c.Failed++
+		case compliance.ControlStatusExcepted:
+			c.Excepted++
+		case compliance.ControlStatusNotApplicable:
+			c.NotApplicable++
+		}
+
+		for _, id := range f.ExceptionIDs {
+			if seenExceptions[id] {
+				continue
+			}
+			seenExceptions[id] = true
+			// Exceptions deleted since the assessment are left out
+			if e, err := s.repo.GetException(ctx, assessment.UserID, id); err == nil {
+				exceptions = append(exceptions, e)
+			}
+		}
+	}
+	for i := range categories {
+		c := &categories[i]
+		if scored := c.Passed + c.Failed; scored > 0 {
+			c.Score = float64(c.Passed) / float64(scored) * 100
+		}
 	}
 
 	return &compliance.AssessmentExport{
@@ -395,36 +1028,106 @@ func (s *ComplianceServiceImpl) ExportAssessment(ctx context.Context, assessment
 		Framework:   framework,
 		Findings:    findings,
 		Summary:     summary,
+		Categories:  categories,
+		Exceptions:  exceptions,
 		GeneratedAt: time.Now().Format(time.RFC3339),
 	}, nil
 }
 
-// InitializeFrameworks initializes built-in compliance frameworks
+// InitializeFrameworks loads the built-in framework catalogs. A catalog is
+// written when its framework is missing or was loaded from an older
+// revision, so restarts with unchanged catalogs do no writes. Custom
+// frameworks that took a built-in ID or name are left alone.
 func (s *ComplianceServiceImpl) InitializeFrameworks(ctx context.Context) error {
-	// The frameworks are seeded via migration, but we can add controls here
-	// For now, we check if CIS AWS controls exist and add them if not
-
-	framework, err := s.repo.GetFrameworkByName(ctx, "CIS AWS Foundations Benchmark")
+	catalogs, err := detector.BuiltinCatalogs()
 	if err != nil {
-		return nil // Framework not found, skip
+		return err
 	}
 
-	count, _ := s.repo.CountControls(ctx, framework.ID)
-	if count > 0 {
-		return nil // Controls already exist
+	for _, catalog := range catalogs {
+		framework := catalog.Framework
+		existing, err := s.repo.GetFramework(ctx, framework.ID)
+		if err == nil && existing.Source != compliance.FrameworkSourceBuiltin {
+			s.logger.WithFields(map[string]interface{}{
+				"framework_id": framework.ID,
+			}).Warn("Custom compliance framework uses a built-in ID; not loading the built-in catalog")
+			continue
+		}
+		if err == nil && existing.Revision >= framework.Revision {
+			continue
+		}
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+
+		if named, err := s.repo.GetFrameworkByName(ctx, framework.Name); err == nil && named.ID != framework.ID {
+			s.logger.WithFields(map[string]interface{}{
+				"framework_id": framework.ID,
+				"name":         framework.Name,
+			}).Warn("Another compliance framework uses a built-in name; not loading the built-in catalog")
+			continue
+		}
+
+		if err := s.repo.UpsertCatalog(ctx, catalog); err != nil {
+			return fmt.Errorf("failed to load compliance framework %s: %w", framework.ID, err)
+		}
+		s.logger.WithFields(map[string]interface{}{
+			"framework": framework.Name,
+			"revision":  framework.Revision,
+			"controls":  len(catalog.Controls),
+		}).Info("Loaded compliance framework catalog")
 	}
 
-	// Add CIS AWS controls
-	cisControls := compliance.CISAWSControls()
-	for _, control := range cisControls {
-		control.FrameworkID = framework.ID
-		s.repo.CreateControl(ctx, control)
+	return nil
+}
+
+// ImportFramework validates and stores a custom framework catalog. format
+// is "json" or "yaml". Importing a framework again replaces its controls.
+func (s *ComplianceServiceImpl) ImportFramework(ctx context.Context, data []byte, format string) (*compliance.Catalog, error) {
+	name := "import.json"
+	if strings.EqualFold(format, "yaml") || strings.EqualFold(format, "yml") {
+		name = "import.yaml"
+	}
+	catalog, err := detector.ParseCatalogFile(name, data)
+	if err != nil {
+		return nil, errors.ValidationError("Invalid framework catalog", strings.TrimPrefix(err.Error(), "catalog file "+name+": "))
+	}
+	framework := catalog.Framework
+	framework.Source = compliance.FrameworkSourceCustom
+
+	existing, err := s.repo.GetFramework(ctx, framework.ID)
+	switch {
+	case err == nil && existing.Source == compliance.FrameworkSourceBuiltin:
+		return nil, errors.Conflict("Framework " + framework.ID + " is built in and cannot be replaced")
+	case err != nil && !isNotFound(err):
+		return nil, err
+	}
+	if named, err := s.repo.GetFrameworkByName(ctx, framework.Name); err == nil && named.ID != framework.ID {
+		return nil, errors.Conflict("Framework name " + framework.Name + " is already used by " + named.ID)
+	}
+	if existing != nil {
+		framework.IsEnabled = existing.IsEnabled
+	}
+	for _, control := range catalog.Controls {
+		for _, ref := range control.Crosswalk {
+			_, err := s.repo.GetControlByFrameworkAndID(ctx, ref.FrameworkID, ref.ControlID)
+			if isNotFound(err) {
+				return nil, errors.ValidationError("Invalid framework catalog", "control "+control.ControlID+" links to unknown control "+ref.String())
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.repo.UpsertCatalog(ctx, catalog); err != nil {
+		return nil, err
 	}
 
 	s.logger.WithFields(map[string]interface{}{
-		"framework": framework.Name,
-		"controls":  len(cisControls),
-	}).Info("Initialized compliance framework controls")
+		"framework_id": framework.ID,
+		"controls":     len(catalog.Controls),
+	}).Info("Imported compliance framework")
 
-	return nil
+	return catalog, nil
 }
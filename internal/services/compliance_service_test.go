@@ -0,0 +1,600 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+// fakeComplianceRepo keeps frameworks, controls and mappings in memory
+type fakeComplianceRepo struct {
+	compliance.Repository
+	frameworks []*compliance.Framework
+	controls   []*compliance.Control
+	mappings   []*compliance.ControlMapping
+	links      []*compliance.ControlLink
+	exceptions []*compliance.Exception
+	updated    *compliance.Assessment
+	upserted   []*compliance.Catalog
+
+	mappingsErr error // returned by GetMappingsForControl when set
+}
+
+func (f *fakeComplianceRepo) GetFramework(ctx context.Context, id string) (*compliance.Framework, error) {
+	for _, fw := range f.frameworks {
+		if fw.ID == id {
+			return fw, nil
+		}
+	}
+	return nil, errors.NotFound("Framework")
+}
+
+func (f *fakeComplianceRepo) GetFrameworkByName(ctx context.Context, name string) (*compliance.Framework, error) {
+	for _, fw := range f.frameworks {
+		if fw.Name == name {
+			return fw, nil
+		}
+	}
+	return nil, errors.NotFound("Framework")
+}
+
+func (f *fakeComplianceRepo) UpsertCatalog(ctx context.Context, catalog *compliance.Catalog) error {
+	f.upserted = append(f.upserted, catalog)
+	return nil
+}
+
+func (f *fakeComplianceRepo) ListFrameworks(ctx context.Context) ([]*compliance.Framework, error) {
+	return f.frameworks, nil
+}
+
+func (f *fakeComplianceRepo) ListControls(ctx context.Context, frameworkID string, category string) ([]*compliance.Control, error) {
+	var controls []*compliance.Control
+	for _, c := range f.controls {
+		if c.FrameworkID == frameworkID {
+			controls = append(controls, c)
+		}
+	}
+	return controls, nil
+}
+
+func (f *fakeComplianceRepo) GetControlByFrameworkAndID(ctx context.Context, frameworkID, controlID string) (*compliance.Control, error) {
+	for _, c := range f.controls {
+		if c.FrameworkID == frameworkID && c.ControlID == controlID {
+			return c, nil
+		}
+	}
+	return nil, errors.NotFound("Control")
+}
+
+func (f *fakeComplianceRepo) ListControlLinks(ctx context.Context) ([]*compliance.ControlLink, error) {
+	return f.links, nil
+}
+
+func (f *fakeComplianceRepo) GetMappingsForControl(ctx context.Context, controlID string) ([]*compliance.ControlMapping, error) {
+	if f.mappingsErr != nil {
+		return nil, f.mappingsErr
+	}
+	var mappings []*compliance.ControlMapping
+	for _, m := range f.mappings {
+		if m.ControlID == controlID {
+			mappings = append(mappings, m)
+		}
+	}
+	return mappings, nil
+}
+
+func (f *fakeComplianceRepo) CreateException(ctx context.Context, e *compliance.Exception) error {
+	e.ID = fmt.Sprintf("exc-%d", len(f.exceptions)+1)
+	f.exceptions = append(f.exceptions, e)
+	return nil
+}
+
+func (f *fakeComplianceRepo) GetException(ctx context.Context, userID int64, id string) (*compliance.Exception, error) {
+	for _, e := range f.exceptions {
+		if e.UserID == userID && e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, errors.NotFound("Exception")
+}
+
+func (f *fakeComplianceRepo) UpdateException(ctx context.Context, e *compliance.Exception) error {
+	_, err := f.GetException(ctx, e.UserID, e.ID)
+	return err
+}
+
+func (f *fakeComplianceRepo) ListExceptions(ctx context.Context, userID int64, filter compliance.ExceptionFilter) ([]*compliance.Exception, error) {
+	var exceptions []*compliance.Exception
+	for _, e := range f.exceptions {
+		if e.UserID == userID &&
+			(filter.FrameworkID == "" || e.FrameworkID == filter.FrameworkID) &&
+			(filter.ControlID == "" || e.ControlID == filter.ControlID) &&
+			(filter.ResourceID == "" || e.ResourceID == filter.ResourceID) &&
+			(filter.Status == "" || e.Status == filter.Status) {
+			exceptions = append(exceptions, e)
+		}
+	}
+	return exceptions, nil
+}
+
+func (f *fakeComplianceRepo) UpdateAssessment(ctx context.Context, a *compliance.Assessment) error {
+	f.updated = a
+	return nil
+}
+
+func (f *fakeComplianceRepo) GetAssessment(ctx context.Context, id string) (*compliance.Assessment, error) {
+	if f.updated == nil || f.updated.ID != id {
+		return nil, errors.NotFound("Assessment")
+	}
+	return f.updated, nil
+}
+
+// newCheckedComplianceService returns a service over a CIS AWS framework
+// with an S3 encryption check, an EBS encryption check, a drift-mapped
+// control and a control without mappings
+func newCheckedComplianceService(resources ...*resource.Resource) (*ComplianceServiceImpl, *fakeComplianceRepo, *testutil.MockDriftRepository) {
+	repo := &fakeComplianceRepo{
+		frameworks: []*compliance.Framework{{ID: "cis-aws", Name: "CIS AWS", Provider: "aws", IsEnabled: true}},
+		controls: []*compliance.Control{
+			{ID: "c1", FrameworkID: "cis-aws", ControlID: "2.1.1", Title: "S3 encryption", Remediation: "Enable default encryption"},
+			{ID: "c2", FrameworkID: "cis-aws", ControlID: "2.2.1", Title: "EBS encryption"},
+			{ID: "c3", FrameworkID: "cis-aws", ControlID: "5.1", Title: "No open SSH"},
+			{ID: "c4", FrameworkID: "cis-aws", ControlID: "1.4", Title: "No root access keys"},
+		},
+		mappings: []*compliance.ControlMapping{
+			{ID: "m1", ControlID: "c1", ResourceType: resource.TypeS3Bucket, Provider: "aws", CheckQuery: `{"all": [{"field": "$.encryption.enabled", "equals": true}]}`},
+			{ID: "m2", ControlID: "c2", ResourceType: resource.TypeEBSVolume, Provider: "aws", CheckQuery: `{"all": [{"field": "$.encrypted", "equals": true}]}`},
+			{ID: "m3", ControlID: "c3", SecurityRuleType: drift.TypeSecurityGroup},
+		},
+	}
+
+	resourceRepo := testutil.NewMockResourceRepository()
+	for _, r := range resources {
+		resourceRepo.Create(context.Background(), r)
+	}
+	driftRepo := testutil.NewMockDriftRepository()
+
+	svc := NewComplianceService(repo, driftRepo, nil, logger.New(logger.Config{Level: "error", Format: "json"})).(*ComplianceServiceImpl)
+	svc.SetResourceRepository(resourceRepo)
+	return svc, repo, driftRepo
+}
+
+func TestComplianceService_AssessmentChecksConfiguration(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "encrypted-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": true}}`},
+		// Never encrypted, so there is no drift to find
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+		&resource.Resource{UserID: 1, ResourceID: "deleted-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Status: resource.StatusTerminated, Configuration: `{}`},
+		&resource.Resource{UserID: 2, ResourceID: "other-users-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{}`},
+	)
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "cis-aws"}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[0])
+
+	if assessment.Status != compliance.AssessmentStatusCompleted {
+		t.Fatalf("Status = %s, want completed", assessment.Status)
+	}
+	// 2.1.1 fails, 2.2.1 has no volumes, 5.1 passes on drifts, 1.4 is unmapped
+	if assessment.PassedControls != 1 || assessment.FailedControls != 1 || assessment.NotApplicableControls != 2 {
+		t.Errorf("passed/failed/not applicable = %d/%d/%d, want 1/1/2",
+			assessment.PassedControls, assessment.FailedControls, assessment.NotApplicableControls)
+	}
+	if assessment.CompliancePercent != 50 {
+		t.Errorf("CompliancePercent = %v, want 50", assessment.CompliancePercent)
+	}
+
+	findings := findingsByControl(t, repo.updated)
+	s3 := findings["2.1.1"]
+	if s3.Status != compliance.ControlStatusFailed || s3.AffectedCount != 1 || s3.AffectedResources[0] != "plain-bucket" {
+		t.Errorf("2.1.1 = %s affecting %v, want failed affecting [plain-bucket]", s3.Status, s3.AffectedResources)
+	}
+	if !strings.HasPrefix(s3.Evidence, "1 of 2 resources failed the check\n") ||
+		!strings.Contains(s3.Evidence, "plain-bucket: encryption.enabled = false") {
+		t.Errorf("2.1.1 evidence = %q", s3.Evidence)
+	}
+	if findings["2.2.1"].Status != compliance.ControlStatusNotApplicable {
+		t.Errorf("2.2.1 status = %s, want not_applicable", findings["2.2.1"].Status)
+	}
+	if findings["5.1"].Status != compliance.ControlStatusPassed {
+		t.Errorf("5.1 status = %s, want passed", findings["5.1"].Status)
+	}
+	if findings["1.4"].Status != compliance.ControlStatusNotApplicable {
+		t.Errorf("1.4 status = %s, want not_applicable", findings["1.4"].Status)
+	}
+}
+
+func TestComplianceService_AssessmentCombinesDriftsAndChecks(t *testing.T) {
+	svc, repo, driftRepo := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "vol-1", Provider: "aws", Type: resource.TypeEBSVolume, Configuration: `{"encrypted": false}`},
+		&resource.Resource{UserID: 1, ResourceID: "vol-2", Provider: "aws", Type: resource.TypeEBSVolume, Configuration: `{"encrypted": false}`},
+	)
+	driftRepo.Create(context.Background(), &drift.Drift{UserID: 1, ResourceID: "i-123", DriftType: drift.TypeSecurityGroup, Status: drift.StatusDetected})
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "cis-aws"}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[0])
+
+	findings := findingsByControl(t, repo.updated)
+	ebs := findings["2.2.1"]
+	sort.Strings(ebs.AffectedResources)
+	if ebs.Status != compliance.ControlStatusFailed || strings.Join(ebs.AffectedResources, ",") != "vol-1,vol-2" {
+		t.Errorf("2.2.1 = %s affecting %v, want failed affecting [vol-1 vol-2]", ebs.Status, ebs.AffectedResources)
+	}
+	if ssh := findings["5.1"]; ssh.Status != compliance.ControlStatusFailed || ssh.AffectedCount != 1 {
+		t.Errorf("5.1 = %s affecting %d, want failed affecting 1", ssh.Status, ssh.AffectedCount)
+	}
+}
+
+func TestComplianceService_AssessmentFailsOnMappingError(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+	)
+	repo.mappingsErr = stderrors.New("database is locked")
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "cis-aws"}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[0])
+
+	if repo.updated == nil || repo.updated.Status != compliance.AssessmentStatusFailed {
+		t.Fatalf("assessment saved as %+v, want failed", repo.updated)
+	}
+	if len(repo.updated.Findings) != 0 {
+		t.Errorf("failed assessment has findings %s", repo.updated.Findings)
+	}
+
+	if _, err := svc.GetCrosswalk(context.Background(), 1, ""); err == nil {
+		t.Error("GetCrosswalk() error = nil, want the mapping error")
+	}
+}
+
+func TestComplianceService_GetResourceCompliance(t *testing.T) {
+	svc, _, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+	)
+
+	status, err := svc.GetResourceCompliance(context.Background(), 1, "plain-bucket")
+	if err != nil {
+		t.Fatalf("GetResourceCompliance() error = %v", err)
+	}
+	if status.OverallStatus != compliance.StatusNonCompliant || status.ResourceType != resource.TypeS3Bucket {
+		t.Errorf("status = %s for %s, want non_compliant for s3-bucket", status.OverallStatus, status.ResourceType)
+	}
+	if len(status.ControlStatuses) != 1 {
+		t.Fatalf("got %d control statuses, want 1", len(status.ControlStatuses))
+	}
+	cs := status.ControlStatuses[0]
+	if cs.ControlID != "2.1.1" || cs.Status != compliance.ControlStatusFailed ||
+		cs.Evidence != "encryption.enabled = false" || cs.Remediation != "Enable default encryption" {
+		t.Errorf("control status = %+v", cs)
+	}
+
+	if _, err := svc.GetResourceCompliance(context.Background(), 2, "plain-bucket"); err == nil {
+		t.Error("GetResourceCompliance() for another user's resource succeeded, want error")
+	}
+}
+
+func TestComplianceService_InitializeFrameworks(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService()
+	repo.frameworks = []*compliance.Framework{
+		// Current, so it is skipped
+		{ID: "cis-aws-v1.5", Name: "CIS AWS Foundations Benchmark", Source: compliance.FrameworkSourceBuiltin, Revision: 2},
+		// Seeded by migration without a catalog
+		{ID: "pci-dss-v4", Name: "PCI DSS", Source: compliance.FrameworkSourceBuiltin},
+		// Imported under a built-in ID, so it is left alone
+		{ID: "hipaa", Name: "HIPAA", Source: compliance.FrameworkSourceCustom},
+	}
+
+	if err := svc.InitializeFrameworks(context.Background()); err != nil {
+		t.Fatalf("InitializeFrameworks() error = %v", err)
+	}
+
+	loaded := make(map[string]bool)
+	for _, c := range repo.upserted {
+		loaded[c.Framework.ID] = true
+	}
+	if loaded["cis-aws-v1.5"] || loaded["hipaa"] || !loaded["pci-dss-v4"] || !loaded["iso-27001"] {
+		t.Errorf("loaded catalogs = %v", loaded)
+	}
+}
+
+func TestComplianceService_ImportFramework(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService()
+	repo.frameworks = []*compliance.Framework{
+		{ID: "cis-aws-v1.5", Name: "CIS AWS Foundations Benchmark", Source: compliance.FrameworkSourceBuiltin, Revision: 1},
+	}
+	catalog := func(id, name string) []byte {
+		return []byte(`{"version": 1, "framework": {"id": "` + id + `", "name": "` + name + `"}, "controls": [{"id": "A-1", "title": "Encrypt buckets", "severity": "high"}]}`)
+	}
+
+	for _, tt := range []struct{ id, name string }{
+		{"cis-aws-v1.5", "My CIS"},
+		{"acme", "CIS AWS Foundations Benchmark"},
+	} {
+		_, err := svc.ImportFramework(context.Background(), catalog(tt.id, tt.name), "json")
+		var appErr *errors.AppError
+		if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeConflict {
+			t.Errorf("ImportFramework(%s, %s) error = %v, want conflict", tt.id, tt.name, err)
+		}
+	}
+
+	if _, err := svc.ImportFramework(context.Background(), []byte("version: 1\nframework: {id: acme}"), "yaml"); err == nil {
+		t.Error("ImportFramework() of an invalid catalog succeeded, want error")
+	}
+	unknownLink := []byte(`{"version": 1, "framework": {"id": "acme", "name": "ACME Baseline"}, "controls": [{"id": "A-1", "title": "Encrypt buckets", "severity": "high", "crosswalk": ["cis-aws:9.9"]}]}`)
+	_, err := svc.ImportFramework(context.Background(), unknownLink, "json")
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeValidation {
+		t.Errorf("ImportFramework() with a link to an unknown control error = %v, want validation error", err)
+	}
+
+	imported, err := svc.ImportFramework(context.Background(), catalog("acme", "ACME Baseline"), "json")
+	if err != nil {
+		t.Fatalf("ImportFramework() error = %v", err)
+	}
+	if imported.Framework.Source != compliance.FrameworkSourceCustom || len(repo.upserted) != 1 {
+		t.Errorf("imported %+v, upserted %d catalogs", imported.Framework, len(repo.upserted))
+	}
+}
+
+// addLinkedNIST adds an enabled NIST framework whose SC-28 is linked from
+// both CIS encryption controls, and an unlinked AC-2
+func addLinkedNIST(repo *fakeComplianceRepo) {
+	repo.frameworks = append(repo.frameworks, &compliance.Framework{ID: "nist", Name: "NIST 800-53", IsEnabled: true})
+	repo.controls = append(repo.controls,
+		&compliance.Control{ID: "n1", FrameworkID: "nist", ControlID: "SC-28", Title: "Protection of Information at Rest"},
+		&compliance.Control{ID: "n2", FrameworkID: "nist", ControlID: "AC-2", Title: "Account Management"},
+	)
+	repo.links = []*compliance.ControlLink{
+		{Control: compliance.ControlRef{FrameworkID: "cis-aws", ControlID: "2.1.1"}, Linked: compliance.ControlRef{FrameworkID: "nist", ControlID: "SC-28"}},
+		{Control: compliance.ControlRef{FrameworkID: "cis-aws", ControlID: "2.2.1"}, Linked: compliance.ControlRef{FrameworkID: "nist", ControlID: "SC-28"}},
+	}
+}
+
+func TestComplianceService_AssessmentUsesLinkedChecks(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+	)
+	addLinkedNIST(repo)
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "nist"}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[1])
+
+	findings := findingsByControl(t, repo.updated)
+	sc28 := findings["SC-28"]
+	if sc28.Status != compliance.ControlStatusFailed || sc28.AffectedCount != 1 || sc28.AffectedResources[0] != "plain-bucket" {
+		t.Errorf("SC-28 = %s affecting %v, want failed affecting [plain-bucket]", sc28.Status, sc28.AffectedResources)
+	}
+	if strings.Join(sc28.SatisfiedBy, ",") != "cis-aws:2.1.1,cis-aws:2.2.1" {
+		t.Errorf("SC-28 satisfied by %v", sc28.SatisfiedBy)
+	}
+	if ac2 := findings["AC-2"]; ac2.Status != compliance.ControlStatusNotApplicable || len(ac2.SatisfiedBy) != 0 {
+		t.Errorf("AC-2 = %s satisfied by %v, want not_applicable", ac2.Status, ac2.SatisfiedBy)
+	}
+}
+
+func TestComplianceService_GetCrosswalk(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+	)
+	addLinkedNIST(repo)
+
+	view, err := svc.GetCrosswalk(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("GetCrosswalk() error = %v", err)
+	}
+	if len(view.Frameworks) != 2 || view.Frameworks[1].FailedControls != 1 {
+		t.Errorf("frameworks = %+v", view.Frameworks)
+	}
+	if len(view.Rows) != 2 {
+		t.Fatalf("got %d rows, want the 2 controls that declare links", len(view.Rows))
+	}
+	row := view.Rows[0]
+	if row.Control.ControlID != "2.1.1" || row.Control.Status != compliance.ControlStatusFailed ||
+		len(row.Linked) != 1 || row.Linked[0].ControlID != "SC-28" || row.Linked[0].Status != compliance.ControlStatusFailed {
+		t.Errorf("row = %+v", row)
+	}
+
+	view, err = svc.GetCrosswalk(context.Background(), 1, "nist")
+	if err != nil {
+		t.Fatalf("GetCrosswalk(nist) error = %v", err)
+	}
+	if len(view.Rows) != 2 || view.Rows[0].Control.ControlID != "SC-28" || len(view.Rows[0].Linked) != 2 || len(view.Rows[1].Linked) != 0 {
+		t.Errorf("nist rows = %+v", view.Rows)
+	}
+
+	if _, err := svc.GetCrosswalk(context.Background(), 1, "unknown"); !isNotFound(err) {
+		t.Errorf("GetCrosswalk(unknown) error = %v, want not found", err)
+	}
+}
+
+func TestComplianceService_AssessmentAppliesExceptions(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "legacy-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{"encryption": {"enabled": false}}`},
+		&resource.Resource{UserID: 1, ResourceID: "vol-1", Provider: "aws", Type: resource.TypeEBSVolume, Configuration: `{"encrypted": false}`},
+	)
+	now := time.Now()
+	repo.exceptions = []*compliance.Exception{
+		{ID: "e1", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "legacy-bucket", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(24 * time.Hour)},
+		{ID: "e2", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "plain-bucket", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(-time.Hour)},
+		{ID: "e3", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "plain-bucket", Status: compliance.ExceptionStatusPending, ExpiresAt: now.Add(24 * time.Hour)},
+		{ID: "e4", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.2.1", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(24 * time.Hour)},
+		{ID: "e5", UserID: 2, FrameworkID: "cis-aws", ControlID: "2.1.1", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(24 * time.Hour)},
+	}
+
+	assessment := &compliance.Assessment{ID: "a1", UserID: 1, FrameworkID: "cis-aws", AssessmentDate: now}
+	svc.executeAssessment(context.Background(), assessment, repo.frameworks[0])
+
+	// 2.1.1 still fails on plain-bucket, 2.2.1 is excepted, 5.1 passes
+	if assessment.PassedControls != 1 || assessment.FailedControls != 1 || assessment.ExceptedControls != 1 {
+		t.Errorf("passed/failed/excepted = %d/%d/%d, want 1/1/1",
+			assessment.PassedControls, assessment.FailedControls, assessment.ExceptedControls)
+	}
+	if assessment.CompliancePercent != 50 {
+		t.Errorf("CompliancePercent = %v, want 50 with the excepted control left out", assessment.CompliancePercent)
+	}
+
+	findings := findingsByControl(t, repo.updated)
+	s3 := findings["2.1.1"]
+	if s3.Status != compliance.ControlStatusFailed || s3.AffectedCount != 1 || strings.Join(s3.AffectedResources, ",") != "plain-bucket" {
+		t.Errorf("2.1.1 = %s affecting %v, want failed affecting [plain-bucket]", s3.Status, s3.AffectedResources)
+	}
+	if strings.Join(s3.ExceptedResources, ",") != "legacy-bucket" || strings.Join(s3.ExceptionIDs, ",") != "e1" {
+		t.Errorf("2.1.1 excepted %v by %v, want [legacy-bucket] by [e1]", s3.ExceptedResources, s3.ExceptionIDs)
+	}
+	if ebs := findings["2.2.1"]; ebs.Status != compliance.ControlStatusExcepted || ebs.AffectedCount != 0 || strings.Join(ebs.ExceptionIDs, ",") != "e4" {
+		t.Errorf("2.2.1 = %s affecting %d by %v, want excepted by [e4]", ebs.Status, ebs.AffectedCount, ebs.ExceptionIDs)
+	}
+
+	status, err := svc.GetResourceCompliance(context.Background(), 1, "legacy-bucket")
+	if err != nil {
+		t.Fatalf("GetResourceCompliance() error = %v", err)
+	}
+	if cs := status.ControlStatuses[0]; cs.Status != compliance.ControlStatusExcepted || cs.ExceptionID != "e1" || status.OverallStatus != compliance.StatusCompliant {
+		t.Errorf("legacy-bucket = %s with %+v, want compliant with 2.1.1 excepted by e1", status.OverallStatus, cs)
+	}
+}
+
+func TestComplianceService_ExceptionApproval(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService(
+		&resource.Resource{UserID: 1, ResourceID: "plain-bucket", Provider: "aws", Type: resource.TypeS3Bucket, Configuration: `{}`},
+	)
+	ctx := context.Background()
+	expires := time.Now().Add(30 * 24 * time.Hour)
+
+	invalid := []*compliance.Exception{
+		{FrameworkID: "cis-aws", ControlID: "9.9", Justification: "legacy", ExpiresAt: expires},
+		{FrameworkID: "cis-aws", ControlID: "2.1.1", ExpiresAt: expires},
+		{FrameworkID: "cis-aws", ControlID: "2.1.1", Justification: "legacy", ExpiresAt: time.Now().Add(-time.Hour)},
+		{FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "missing-bucket", Justification: "legacy", ExpiresAt: expires},
+	}
+	for _, e := range invalid {
+		if _, err := svc.RequestException(ctx, 1, e); err == nil {
+			t.Errorf("RequestException(%+v) succeeded, want error", e)
+		}
+	}
+
+	requested, err := svc.RequestException(ctx, 1, &compliance.Exception{
+		FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "plain-bucket", Justification: " Replaced next quarter ", ExpiresAt: expires,
+		Status: compliance.ExceptionStatusApproved,
+	})
+	if err != nil {
+		t.Fatalf("RequestException() error = %v", err)
+	}
+	if requested.Status != compliance.ExceptionStatusPending || requested.RequestedBy != 1 || requested.Justification != "Replaced next quarter" {
+		t.Errorf("requested = %+v, want pending, requested by 1", requested)
+	}
+	if _, err := svc.RequestException(ctx, 1, &compliance.Exception{
+		FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "plain-bucket", Justification: "again", ExpiresAt: expires,
+	}); !isConflict(err) {
+		t.Errorf("duplicate RequestException() error = %v, want conflict", err)
+	}
+
+	if _, err := svc.ApproveException(ctx, 2, requested.ID); !isNotFound(err) {
+		t.Errorf("ApproveException() by another user error = %v, want not found", err)
+	}
+	approved, err := svc.ApproveException(ctx, 1, requested.ID)
+	if err != nil {
+		t.Fatalf("ApproveException() error = %v", err)
+	}
+	if approved.Status != compliance.ExceptionStatusApproved || approved.ApprovedBy == nil || *approved.ApprovedBy != 1 || approved.ApprovedAt == nil {
+		t.Errorf("approved = %+v", approved)
+	}
+	if _, err := svc.ApproveException(ctx, 1, requested.ID); !isConflict(err) {
+		t.Errorf("second ApproveException() error = %v, want conflict", err)
+	}
+	if _, err := svc.RejectException(ctx, 1, requested.ID, "too late"); !isConflict(err) {
+		t.Errorf("RejectException() of approved exception error = %v, want conflict", err)
+	}
+
+	revoked, err := svc.RevokeException(ctx, 1, requested.ID)
+	if err != nil || revoked.Status != compliance.ExceptionStatusRevoked {
+		t.Fatalf("RevokeException() = %+v, %v, want revoked", revoked, err)
+	}
+	if _, err := svc.RevokeException(ctx, 1, requested.ID); !isConflict(err) {
+		t.Errorf("second RevokeException() error = %v, want conflict", err)
+	}
+
+	again, err := svc.RequestException(ctx, 1, &compliance.Exception{
+		FrameworkID: "cis-aws", ControlID: "2.1.1", Justification: "all buckets", ExpiresAt: expires,
+	})
+	if err != nil {
+		t.Fatalf("RequestException() after revoke error = %v", err)
+	}
+	rejected, err := svc.RejectException(ctx, 1, again.ID, "encrypt them instead")
+	if err != nil || rejected.Status != compliance.ExceptionStatusRejected || rejected.RejectionReason != "encrypt them instead" {
+		t.Errorf("RejectException() = %+v, %v, want rejected with reason", rejected, err)
+	}
+	if len(repo.exceptions) != 2 {
+		t.Errorf("stored %d exceptions, want 2", len(repo.exceptions))
+	}
+}
+
+func TestComplianceService_NotifyExpiringExceptions(t *testing.T) {
+	svc, repo, _ := newCheckedComplianceService()
+	notifier := &recordingNotifier{}
+	svc.SetNotificationService(notifier)
+
+	now := time.Now()
+	notified := now.Add(-24 * time.Hour)
+	repo.exceptions = []*compliance.Exception{
+		{ID: "soon", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", ResourceID: "plain-bucket", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(3 * 24 * time.Hour)},
+		{ID: "later", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.1.1", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(60 * 24 * time.Hour)},
+		{ID: "lapsed", UserID: 1, FrameworkID: "cis-aws", ControlID: "2.2.1", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(-time.Hour)},
+		{ID: "warned", UserID: 1, FrameworkID: "cis-aws", ControlID: "5.1", Status: compliance.ExceptionStatusApproved, ExpiresAt: now.Add(2 * 24 * time.Hour), ExpiryNotifiedAt: &notified},
+		{ID: "pending", UserID: 1, FrameworkID: "cis-aws", ControlID: "1.4", Status: compliance.ExceptionStatusPending, ExpiresAt: now.Add(24 * time.Hour)},
+	}
+
+	count, err := svc.NotifyExpiringExceptions(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("NotifyExpiringExceptions() error = %v", err)
+	}
+	if count != 1 || len(notifier.sent) != 1 {
+		t.Fatalf("notified %d, sent %d, want 1 and 1", count, len(notifier.sent))
+	}
+	n := notifier.sent[0]
+	if n.UserID != 1 || n.Data["exception_id"] != "soon" || !strings.Contains(n.Message, "plain-bucket") {
+		t.Errorf("notification = %+v", n)
+	}
+	if repo.exceptions[0].ExpiryNotifiedAt == nil {
+		t.Error("ExpiryNotifiedAt was not recorded")
+	}
+	if repo.exceptions[2].Status != compliance.ExceptionStatusExpired {
+		t.Errorf("lapsed exception status = %s, want expired", repo.exceptions[2].Status)
+	}
+
+	if count, _ := svc.NotifyExpiringExceptions(context.Background(), 1); count != 0 || len(notifier.sent) != 1 {
+		t.Errorf("second run notified %d, want 0", count)
+	}
+}
+
+func isConflict(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeConflict
+}
+
+func findingsByControl(t *testing.T, assessment *compliance.Assessment) map[string]compliance.AssessmentFinding {
+	t.Helper()
+	if assessment == nil {
+		t.Fatal("assessment was not saved")
+	}
+	var findings []compliance.AssessmentFinding
+	if err := json.Unmarshal(assessment.Findings, &findings); err != nil {
+		t.Fatalf("invalid findings: %v", err)
+	}
+	byControl := make(map[string]compliance.AssessmentFinding, len(findings))
+	for _, f := range findings {
+		byControl[f.ControlID] = f
+	}
+	return byControl
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+// CreateAllocationRule creates an allocation rule for a user
+func (s *CostServiceImpl) CreateAllocationRule(ctx context.Context, userID int64, rule *cost.AllocationRule) (*cost.AllocationRule, error) {
+	rule.ID = ""
+	rule.UserID = userID
+
+	if err := rule.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid allocation rule", err.Error())
+	}
+
+	if err := s.repo.CreateAllocationRule(ctx, rule); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to create allocation rule")
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":  userID,
+		"rule_id":  rule.ID,
+		"tag_keys": rule.TagKeys,
+	}).Info("Allocation rule created")
+
+	return rule, nil
+}
+
+// GetAllocationRule returns an allocation rule owned by the user
+func (s *CostServiceImpl) GetAllocationRule(ctx context.Context, userID int64, id string) (*cost.AllocationRule, error) {
+	return s.repo.GetAllocationRule(ctx, userID, id)
+}
+
+// UpdateAllocationRule replaces an allocation rule owned by the user
+func (s *CostServiceImpl) UpdateAllocationRule(ctx context.Context, userID int64, id string, rule *cost.AllocationRule) (*cost.AllocationRule, error) {
+	existing, err := s.repo.GetAllocationRule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = id
+	rule.UserID = userID
+	rule.CreatedAt = existing.CreatedAt
+
+	if err := rule.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid allocation rule", err.Error())
+	}
+
+	if err := s.repo.UpdateAllocationRule(ctx, rule); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to update allocation rule")
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"rule_id": id,
+	}).Info("Allocation rule updated")
+
+	return rule, nil
+}
+
+// DeleteAllocationRule deletes an allocation rule owned by the user
+func (s *CostServiceImpl) DeleteAllocationRule(ctx context.Context, userID int64, id string) error {
+	if err := s.repo.DeleteAllocationRule(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"rule_id": id,
+	}).Info("Allocation rule deleted")
+
+	return nil
+}
+
+// ListAllocationRules lists the allocation rules of a user
+func (s *CostServiceImpl) ListAllocationRules(ctx context.Context, userID int64) ([]*cost.AllocationRule, error) {
+	return s.repo.ListAllocationRules(ctx, userID)
+}
+
+// GetCostAllocation allocates costs in the query window to groups by their
+// tags and reports each group's direct costs, its share of split groups and
+// its cost per day, week or month. The window defaults to the current and
+// the two previous calendar months, grouped by month.
+func (s *CostServiceImpl) GetCostAllocation(ctx context.Context, userID int64, query cost.AllocationQuery) (*cost.AllocationReport, error) {
+	rule, err := s.allocationRule(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Granularity == "" {
+		query.Granularity = cost.GranularityMonthly
+	}
+	switch query.Granularity {
+	case cost.GranularityDaily, cost.GranularityWeekly, cost.GranularityMonthly:
+	default:
+		return nil, errors.ValidationError("Invalid allocation query", "granularity must be daily, weekly or monthly")
+	}
+
+	now := time.Now().UTC()
+	if query.EndDate.IsZero() {
+		query.EndDate = now
+	}
+	if query.StartDate.IsZero() {
+		query.StartDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -2, 0)
+	}
+	if query.EndDate.Before(query.StartDate) {
+		return nil, errors.ValidationError("Invalid allocation query", "end date is before start date")
+	}
+
+	costs, err := s.repo.GetCostsByDateRange(ctx, userID, cost.Filter{Provider: query.Provider}, query.StartDate, query.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get costs: %w", err)
+	}
+
+	direct := make(map[string]map[time.Time]float64)
+	periodSet := make(map[time.Time]bool)
+	for _, c := range costs {
+		group := rule.GroupFor(c)
+		period := cost.PeriodStart(c.CostDate, query.Granularity)
+		if direct[group] == nil {
+			direct[group] = make(map[time.Time]float64)
+		}
+		direct[group][period] += c.DailyCost
+		periodSet[period] = true
+	}
+
+	periods := make([]time.Time, 0, len(periodSet))
+	for period := range periodSet {
+		periods = append(periods, period)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Before(periods[j]) })
+
+	shared := splitSharedCosts(rule.Splits, direct, periods)
+
+	report := &cost.AllocationReport{
+		Rule:        rule,
+		Provider:    query.Provider,
+		StartDate:   query.StartDate,
+		EndDate:     query.EndDate,
+		Granularity: query.Granularity,
+		Currency:    "USD",
+		Groups:      []cost.AllocationGroup{},
+	}
+
+	names := make(map[string]bool, len(direct)+len(shared))
+	for name := range direct {
+		names[name] = true
+	}
+	for name := range shared {
+		names[name] = true
+	}
+	for name := range names {
+		group := cost.AllocationGroup{Name: name, Trend: make([]cost.AllocationPeriod, 0, len(periods))}
+		for _, period := range periods {
+			p := cost.AllocationPeriod{
+				Start:      period,
+				DirectCost: direct[name][period],
+				SharedCost: shared[name][period],
+			}
+			p.TotalCost = p.DirectCost + p.SharedCost
+			group.DirectCost += p.DirectCost
+			group.SharedCost += p.SharedCost
+			group.Trend = append(group.Trend, p)
+		}
+		group.TotalCost = group.DirectCost + group.SharedCost
+		report.TotalCost += group.DirectCost
+		report.Groups = append(report.Groups, group)
+	}
+
+	for i := range report.Groups {
+		if report.TotalCost > 0 {
+			report.Groups[i].Percentage = report.Groups[i].TotalCost / report.TotalCost * 100
+		}
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].TotalCost != report.Groups[j].TotalCost {
+			return report.Groups[i].TotalCost > report.Groups[j].TotalCost
+		}
+		return report.Groups[i].Name < report.Groups[j].Name
+	})
+
+	return report, nil
+}
+
+// allocationRule returns the saved rule named by the query, or an ad hoc
+// rule over the query's tag keys
+func (s *CostServiceImpl) allocationRule(ctx context.Context, userID int64, query cost.AllocationQuery) (*cost.AllocationRule, error) {
+	if query.RuleID != "" {
+		return s.repo.GetAllocationRule(ctx, userID, query.RuleID)
+	}
+	if len(query.TagKeys) == 0 {
+		return nil, errors.ValidationError("Invalid allocation query", "rule_id or tag_keys is required")
+	}
+
+	rule := &cost.AllocationRule{
+		UserID:  userID,
+		Name:    strings.Join(query.TagKeys, ", "),
+		TagKeys: query.TagKeys,
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid allocation query", err.Error())
+	}
+	return rule, nil
+}
+
+// splitSharedCosts applies split rules period by period and returns the
+// costs each group received, negative for the split groups themselves.
+// Proportional splits follow the direct costs of the groups that are not
+// split. A period in which a split has nowhere to go keeps its costs.
+func splitSharedCosts(splits []cost.AllocationSplit, direct map[string]map[time.Time]float64, periods []time.Time) map[string]map[time.Time]float64 {
+	shared := make(map[string]map[time.Time]float64)
+	add := func(group string, period time.Time, amount float64) {
+		if shared[group] == nil {
+			shared[group] = make(map[time.Time]float64)
+		}
+		shared[group][period] += amount
+	}
+
+	sources := make(map[string]bool, len(splits))
+	for _, split := range splits {
+		sources[split.Group] = true
+	}
+
+	for _, split := range splits {
+		for _, period := range periods {
+			amount := direct[split.Group][period]
+			if amount == 0 {
+				continue
+			}
+
+			weights := split.Weights
+			if split.Method == cost.SplitMethodProportional {
+				weights = make(map[string]float64)
+				for group, byPeriod := range direct {
+					if !sources[group] && byPeriod[period] > 0 {
+						weights[group] = byPeriod[period]
+					}
+				}
+			}
+
+			var total float64
+			for _, w := range weights {
+				total += w
+			}
+			if total == 0 {
+				continue
+			}
+
+			for group, w := range weights {
+				add(group, period, amount*w/total)
+			}
+			add(split.Group, period, -amount)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+)
+
+const (
+	// anomalyHistoryDays is how much cost history anomaly detection reads
+	anomalyHistoryDays = 90
+	// anomalyDriverBaselineDays is how far before an anomaly the cost mix
+	// used to attribute it is taken from
+	anomalyDriverBaselineDays = 28
+	// maxAnomalyDrivers is the number of drivers kept per dimension
+	maxAnomalyDrivers = 3
+)
+
+// costSeriesKey identifies a daily cost series. An empty service is the
+// provider's total.
+type costSeriesKey struct {
+	provider string
+	service  string
+}
+
+// DetectAnomalies runs the seasonal detector over each provider's total cost
+// and over each of its services, and stores anomalies that were not already
+// detected by an earlier run. Only new anomalies are returned. Series are
+// evaluated through the last complete day, so a series without cost records
+// on its last days is reported as a drop to zero. A new level shift
+// supersedes the spikes or drops stored for its days by earlier runs.
+func (s *CostServiceImpl) DetectAnomalies(ctx context.Context, userID int64, provider string) ([]*cost.CostAnomaly, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -anomalyHistoryDays)
+	lastCompleteDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+
+	costs, err := s.repo.GetCostsByDateRange(ctx, userID, cost.Filter{Provider: provider}, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make(map[costSeriesKey][]cost.CostDataPoint)
+	for _, c := range costs {
+		point := cost.CostDataPoint{Date: c.CostDate, Cost: c.DailyCost}
+		total := costSeriesKey{provider: c.Provider}
+		series[total] = append(series[total], point)
+		if c.ServiceName != "" {
+			key := costSeriesKey{provider: c.Provider, service: c.ServiceName}
+			series[key] = append(series[key], point)
+		}
+	}
+
+	keys := make([]costSeriesKey, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].service < keys[j].service
+	})
+
+	cfg := detector.DefaultCostAnomalyConfig()
+	var anomalies []*cost.CostAnomaly
+	for _, key := range keys {
+		points := series[key]
+		seriesStart := points[0].Date
+		for _, p := range points {
+			if p.Date.Before(seriesStart) {
+				seriesStart = p.Date
+			}
+		}
+
+		for _, found := range detector.DetectCostAnomalies(points, lastCompleteDay, cfg) {
+			fingerprint := detector.CostAnomalyFingerprint(key.provider, key.service, found.Type, found.Date)
+			if _, err := s.repo.GetAnomalyByFingerprint(ctx, userID, fingerprint); err == nil {
+				continue
+			} else if !isNotFound(err) {
+				return nil, err
+			}
+
+			deviation := found.Deviation()
+			anomaly := &cost.CostAnomaly{
+				UserID:       userID,
+				Provider:     key.provider,
+				ServiceName:  key.service,
+				AnomalyType:  found.Type,
+				ExpectedCost: found.Expected,
+				ActualCost:   found.Actual,
+				Deviation:    deviation,
+				Severity:     s.getSeverityFromDeviation(math.Abs(deviation)),
+				Fingerprint:  fingerprint,
+				Status:       cost.AnomalyStatusOpen,
+				DetectedAt:   found.Date,
+			}
+
+			drivers, err := s.anomalyDrivers(ctx, userID, key, found, seriesStart)
+			if err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"user_id":  userID,
+					"provider": key.provider,
+					"service":  key.service,
+				}).ErrorWithErr(err, "Failed to attribute cost anomaly")
+			}
+			anomaly.Drivers = drivers
+
+			if err := s.repo.CreateAnomaly(ctx, anomaly); err != nil {
+				return nil, err
+			}
+			anomalies = append(anomalies, anomaly)
+
+			if found.Type == cost.AnomalyTypeLevelShift {
+				if err := s.supersedePointAnomalies(ctx, userID, key, found); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if len(anomalies) > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id":   userID,
+			"provider":  provider,
+			"anomalies": len(anomalies),
+		}).Info("Detected cost anomalies")
+	}
+
+	return anomalies, nil
+}
+
+// supersedePointAnomalies resolves the open spikes and drops of a series
+// that fall on the days of a level shift. Earlier runs store them before
+// enough days confirm the shift; the shift replaces them.
+func (s *CostServiceImpl) supersedePointAnomalies(ctx context.Context, userID int64, key costSeriesKey, shift detector.CostSeriesAnomaly) error {
+	notes := "Superseded by the level shift starting " + shift.Date.Format("2006-01-02")
+	for day := 0; day < shift.Days; day++ {
+		date := shift.Date.AddDate(0, 0, day)
+		for _, anomalyType := range []string{cost.AnomalyTypeSpike, cost.AnomalyTypeDrop} {
+			fingerprint := detector.CostAnomalyFingerprint(key.provider, key.service, anomalyType, date)
+			existing, err := s.repo.GetAnomalyByFingerprint(ctx, userID, fingerprint)
+			if isNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if existing.Status != cost.AnomalyStatusOpen {
+				continue
+			}
+			existing.Status = cost.AnomalyStatusResolved
+			existing.Notes = notes
+			if err := s.repo.UpdateAnomaly(ctx, existing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// anomalyDrivers attributes an anomaly to the services and regions whose
+// spend moved the same way. Each name's expected cost is its share of the
+// spend in the weeks before the anomaly applied to the detector's expected
+// cost, so the deltas add up to the anomaly's total deviation. A provider
+// total is broken down by service and region, a single service by region.
+func (s *CostServiceImpl) anomalyDrivers(ctx context.Context, userID int64, key costSeriesKey, found detector.CostSeriesAnomaly, seriesStart time.Time) ([]cost.CostDriver, error) {
+	filter := cost.Filter{Provider: key.provider, ServiceName: key.service}
+	windowEnd := found.Date.AddDate(0, 0, found.Days).Add(-time.Nanosecond)
+	baselineStart := found.Date.AddDate(0, 0, -anomalyDriverBaselineDays)
+	if baselineStart.Before(seriesStart) {
+		baselineStart = seriesStart
+	}
+
+	actual, err := s.repo.GetCostSummary(ctx, userID, filter, found.Date, windowEnd)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := s.repo.GetCostSummary(ctx, userID, filter, baselineStart, found.Date.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	expectedTotal := found.Expected * float64(found.Days)
+	deviation := actual.TotalCost - expectedTotal
+
+	var drivers []cost.CostDriver
+	if key.service == "" {
+		drivers = append(drivers, topCostDrivers(cost.DriverDimensionService, actual.ByService, baseline.ByService, baseline.TotalCost, expectedTotal, deviation)...)
+	}
+	drivers = append(drivers, topCostDrivers(cost.DriverDimensionRegion, actual.ByRegion, baseline.ByRegion, baseline.TotalCost, expectedTotal, deviation)...)
+	return drivers, nil
+}
+
+// topCostDrivers returns the names whose deviation has the same sign as the
+// total deviation, largest first
+func topCostDrivers(dimension string, actual, baseline map[string]float64, baselineTotal, expectedTotal, deviation float64) []cost.CostDriver {
+	if deviation == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(actual)+len(baseline))
+	for name := range actual {
+		names[name] = true
+	}
+	for name := range baseline {
+		names[name] = true
+	}
+
+	var drivers []cost.CostDriver
+	for name := range names {
+		expected := 0.0
+		if baselineTotal > 0 {
+			expected = baseline[name] / baselineTotal * expectedTotal
+		}
+		delta := actual[name] - expected
+		if delta == 0 || math.Signbit(delta) != math.Signbit(deviation) {
+			continue
+		}
+		drivers = append(drivers, cost.CostDriver{
+			Dimension:    dimension,
+			Name:         name,
+			ExpectedCost: expected,
+			ActualCost:   actual[name],
+			Delta:        delta,
+			Share:        delta / deviation,
+		})
+	}
+
+	sort.Slice(drivers, func(i, j int) bool {
+		if math.Abs(drivers[i].Delta) != math.Abs(drivers[j].Delta) {
+			return math.Abs(drivers[i].Delta) > math.Abs(drivers[j].Delta)
+		}
+		return drivers[i].Name < drivers[j].Name
+	})
+	if len(drivers) > maxAnomalyDrivers {
+		drivers = drivers[:maxAnomalyDrivers]
+	}
+	return drivers
+}
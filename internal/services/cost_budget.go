@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+)
+
+// CreateBudget validates and stores a budget
+func (s *CostServiceImpl) CreateBudget(ctx context.Context, userID int64, budget *cost.Budget) (*cost.Budget, error) {
+	budget.ID = ""
+	budget.UserID = userID
+	applyBudgetDefaults(budget)
+
+	if err := budget.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid budget", err.Error())
+	}
+
+	if err := s.repo.CreateBudget(ctx, budget); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to create budget")
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":   userID,
+		"budget_id": budget.ID,
+		"period":    budget.Period,
+		"amount":    budget.Amount,
+	}).Info("Budget created")
+
+	return budget, nil
+}
+
+// GetBudget returns a budget owned by the user
+func (s *CostServiceImpl) GetBudget(ctx context.Context, userID int64, id string) (*cost.Budget, error) {
+	return s.repo.GetBudget(ctx, userID, id)
+}
+
+// UpdateBudget validates and replaces a budget
+func (s *CostServiceImpl) UpdateBudget(ctx context.Context, userID int64, id string, budget *cost.Budget) (*cost.Budget, error) {
+	existing, err := s.repo.GetBudget(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.ID = id
+	budget.UserID = userID
+	budget.CreatedAt = existing.CreatedAt
+	applyBudgetDefaults(budget)
+
+	if err := budget.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid budget", err.Error())
+	}
+
+	if err := s.repo.UpdateBudget(ctx, budget); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to update budget")
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":   userID,
+		"budget_id": id,
+	}).Info("Budget updated")
+
+	return budget, nil
+}
+
+// DeleteBudget removes a budget and its alert history
+func (s *CostServiceImpl) DeleteBudget(ctx context.Context, userID int64, id string) error {
+	if err := s.repo.DeleteBudget(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":   userID,
+		"budget_id": id,
+	}).Info("Budget deleted")
+
+	return nil
+}
+
+// ListBudgets returns the budgets of a user
+func (s *CostServiceImpl) ListBudgets(ctx context.Context, userID int64) ([]*cost.Budget, error) {
+	return s.repo.ListBudgets(ctx, userID)
+}
+
+// GetBudgetStatus returns the spend of a budget in its current period
+// together with the alerts already fired in that period
+func (s *CostServiceImpl) GetBudgetStatus(ctx context.Context, userID int64, id string) (*cost.BudgetStatus, error) {
+	budget, err := s.repo.GetBudget(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status, err := s.budgetStatus(ctx, budget, now)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.repo.ListBudgetAlerts(ctx, budget.ID, budget.PeriodKey(now))
+	if err != nil {
+		return nil, err
+	}
+	status.Alerts = alerts
+
+	return status, nil
+}
+
+// EvaluateBudgets checks every enabled budget of a user against current spend
+// and fires an alert for each threshold crossed for the first time in the
+// current period. It returns the newly fired alerts.
+func (s *CostServiceImpl) EvaluateBudgets(ctx context.Context, userID int64) ([]*cost.BudgetAlert, error) {
+	budgets, err := s.repo.ListBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var fired []*cost.BudgetAlert
+	for _, budget := range budgets {
+		if !budget.IsEnabled {
+			continue
+		}
+
+		status, err := s.budgetStatus(ctx, budget, now)
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id":   userID,
+				"budget_id": budget.ID,
+			}).ErrorWithErr(err, "Failed to evaluate budget")
+			continue
+		}
+
+		// Several thresholds can be crossed by one sync; all are recorded
+		// but only the highest of each type is notified
+		latest := make(map[string]*cost.BudgetAlert)
+		for _, threshold := range status.Crossed {
+			spend := status.ActualSpend
+			if threshold.Type == cost.ThresholdTypeForecasted {
+				spend = status.ProjectedSpend
+			}
+
+			alert := &cost.BudgetAlert{
+				BudgetID:         budget.ID,
+				UserID:           userID,
+				PeriodKey:        budget.PeriodKey(now),
+				ThresholdType:    threshold.Type,
+				ThresholdPercent: threshold.Percent,
+				Spend:            spend,
+				BudgetAmount:     budget.Amount,
+				TriggeredAt:      now,
+			}
+			created, err := s.repo.RecordBudgetAlert(ctx, alert)
+			if err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"budget_id": budget.ID,
+					"threshold": threshold.Percent,
+				}).ErrorWithErr(err, "Failed to record budget alert")
+				continue
+			}
+			if !created {
+				continue
+			}
+
+			fired = append(fired, alert)
+			if prev, ok := latest[alert.ThresholdType]; !ok || alert.ThresholdPercent > prev.ThresholdPercent {
+				latest[alert.ThresholdType] = alert
+			}
+		}
+
+		for _, thresholdType := range []string{cost.ThresholdTypeActual, cost.ThresholdTypeForecasted} {
+			if alert, ok := latest[thresholdType]; ok {
+				s.notifyBudgetAlert(ctx, budget, alert)
+			}
+		}
+	}
+
+	return fired, nil
+}
+
+// evaluateBudgetsAfterSync runs budget evaluation after a cost sync. Failures
+// are logged so they never fail the sync itself.
+func (s *CostServiceImpl) evaluateBudgetsAfterSync(ctx context.Context, userID int64) {
+	alerts, err := s.EvaluateBudgets(ctx, userID)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id": userID,
+		}).ErrorWithErr(err, "Failed to evaluate budgets after cost sync")
+		return
+	}
+	if len(alerts) > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id": userID,
+			"alerts":  len(alerts),
+		}).Info("Budget thresholds crossed")
+	}
+}
+
+// budgetStatus computes period-to-date spend and projected spend at period
+// end. The projection adds the forecast for the rest of the period; for a
+// scope narrower than a provider, the provider forecast is scaled by the
+// scope's share of the provider's spend so far.
+func (s *CostServiceImpl) budgetStatus(ctx context.Context, budget *cost.Budget, now time.Time) (*cost.BudgetStatus, error) {
+	start, end := budget.PeriodBounds(now)
+
+	costs, err := s.repo.GetCostsByDateRange(ctx, budget.UserID, budget.Scope.Filter(), start, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget costs: %w", err)
+	}
+
+	var actual float64
+	for _, c := range costs {
+		if budget.Scope.Matches(c) {
+			actual += c.DailyCost
+		}
+	}
+
+	projected := actual
+	if remainingDays := int(math.Ceil(end.Sub(now).Hours() / 24)); remainingDays > 0 {
+		forecast, err := s.GetCostForecast(ctx, budget.UserID, budget.Scope.Provider, remainingDays, cost.ForecastOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to forecast budget costs: %w", err)
+		}
+
+		remaining := forecast.ForecastedCost
+		if budget.Scope.IsNarrowerThanProvider() {
+			summary, err := s.repo.GetCostSummary(ctx, budget.UserID, cost.Filter{Provider: budget.Scope.Provider}, start, now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get provider costs: %w", err)
+			}
+			share := 0.0
+			if summary != nil && summary.TotalCost > 0 {
+				share = actual / summary.TotalCost
+			}
+			remaining *= share
+		}
+		projected += remaining
+	}
+
+	status := &cost.BudgetStatus{
+		Budget:         budget,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		ActualSpend:    actual,
+		ProjectedSpend: projected,
+		Crossed:        []cost.BudgetThreshold{},
+	}
+	if budget.Amount > 0 {
+		status.PercentUsed = actual / budget.Amount * 100
+		status.ProjectedPercent = projected / budget.Amount * 100
+	}
+
+	for _, threshold := range budget.Thresholds {
+		reached := status.PercentUsed
+		if threshold.Type == cost.ThresholdTypeForecasted {
+			reached = status.ProjectedPercent
+		}
+		if reached >= threshold.Percent {
+			status.Crossed = append(status.Crossed, threshold)
+		}
+	}
+	sort.SliceStable(status.Crossed, func(i, j int) bool {
+		return status.Crossed[i].Percent < status.Crossed[j].Percent
+	})
+
+	return status, nil
+}
+
+// notifyBudgetAlert sends a cost alert for a newly crossed threshold
+func (s *CostServiceImpl) notifyBudgetAlert(ctx context.Context, budget *cost.Budget, alert *cost.BudgetAlert) {
+	if s.notifier == nil {
+		return
+	}
+
+	var title, message string
+	if alert.ThresholdType == cost.ThresholdTypeForecasted {
+		title = fmt.Sprintf("Budget %q is projected to exceed %.0f%%", budget.Name, alert.ThresholdPercent)
+		message = fmt.Sprintf("Spend is projected to reach %.2f %s of the %.2f %s %s budget by the end of %s.",
+			alert.Spend, budget.Currency, budget.Amount, budget.Currency, budget.Period, alert.PeriodKey)
+	} else {
+		title = fmt.Sprintf("Budget %q has reached %.0f%%", budget.Name, alert.ThresholdPercent)
+		message = fmt.Sprintf("Spend so far is %.2f %s of the %.2f %s %s budget for %s.",
+			alert.Spend, budget.Currency, budget.Amount, budget.Currency, budget.Period, alert.PeriodKey)
+	}
+
+	n := &notification.Notification{
+		Type:     notification.NotificationTypeCostAlert,
+		Priority: budgetAlertPriority(alert),
+		Title:    title,
+		Message:  message,
+		UserID:   budget.UserID,
+		Data: map[string]interface{}{
+			"budget_id":         budget.ID,
+			"budget":            budget.Name,
+			"period":            alert.PeriodKey,
+			"threshold_type":    alert.ThresholdType,
+			"threshold_percent": alert.ThresholdPercent,
+			"spend":             fmt.Sprintf("%.2f %s", alert.Spend, budget.Currency),
+			"budget_amount":     fmt.Sprintf("%.2f %s", budget.Amount, budget.Currency),
+		},
+	}
+
+	if err := s.notifier.Send(ctx, n); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id":   budget.UserID,
+			"budget_id": budget.ID,
+		}).ErrorWithErr(err, "Failed to send budget alert")
+	}
+}
+
+// budgetAlertPriority escalates with the threshold; an overrun that already
+// happened is more urgent than a projected one
+func budgetAlertPriority(alert *cost.BudgetAlert) notification.Priority {
+	switch {
+	case alert.ThresholdType == cost.ThresholdTypeActual && alert.ThresholdPercent >= 100:
+		return notification.PriorityCritical
+	case alert.ThresholdPercent >= 80:
+		return notification.PriorityHigh
+	default:
+		return notification.PriorityMedium
+	}
+}
+
+// applyBudgetDefaults fills in the currency and thresholds a budget leaves unset
+func applyBudgetDefaults(budget *cost.Budget) {
+	if budget.Currency == "" {
+		budget.Currency = "USD"
+	}
+	if len(budget.Thresholds) == 0 {
+		budget.Thresholds = cost.DefaultBudgetThresholds()
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/forecast"
+)
+
+const (
+	// forecastHistoryDays is how much cost history forecasts are fitted on
+	forecastHistoryDays = 90
+	// forecastSeasonDays is the seasonal cycle of daily costs
+	forecastSeasonDays = 7
+	// forecastMinBacktestDays and forecastMaxBacktestDays bound the number of
+	// trailing days held out to measure forecast error
+	forecastMinBacktestDays = 7
+	forecastMaxBacktestDays = 14
+)
+
+// GetCostForecast forecasts daily costs for the next days days. The model is
+// fitted on up to 90 days of history; its accuracy is measured by refitting
+// it without the last one to two weeks and comparing the forecast of those
+// days with what was actually spent.
+func (s *CostServiceImpl) GetCostForecast(ctx context.Context, userID int64, provider string, days int, opts cost.ForecastOptions) (*cost.CostForecast, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.ValidationError("Invalid forecast options", err.Error())
+	}
+	if opts.Method == "" {
+		opts.Method = cost.ForecastMethodAuto
+	}
+	if opts.Granularity == "" {
+		opts.Granularity = cost.GranularityDaily
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	result := &cost.CostForecast{
+		Provider:        provider,
+		Period:          "forecast",
+		Method:          opts.Method,
+		Granularity:     opts.Granularity,
+		ConfidenceLevel: 0.95,
+		Points:          []cost.ForecastPoint{},
+		Currency:        "USD",
+		EndDate:         today.AddDate(0, 0, days),
+	}
+	if days <= 0 {
+		return result, nil
+	}
+
+	dataPoints, err := s.repo.GetDailyCosts(ctx, userID, cost.Filter{Provider: provider}, forecastHistoryDays)
+	if err != nil {
+		return nil, err
+	}
+	series := cost.DailySeries(dataPoints, time.Time{})
+	if len(series) == 0 {
+		result.ConfidenceLevel = 0
+		return result, nil
+	}
+	values := make([]float64, len(series))
+	for i, p := range series {
+		values[i] = p.Cost
+	}
+	lastDay := series[len(series)-1].Date
+
+	model, method, err := fitForecastModel(opts.Method, values)
+	if err != nil {
+		return nil, errors.ValidationError("Not enough cost history for the forecast method", err.Error())
+	}
+	result.Method = method
+	result.HistoryDays = len(values)
+	result.MAPE, result.BacktestDays = backtestForecast(method, values)
+
+	// Forecast from the last day with data, which may be before today when
+	// the latest sync has not caught up, and keep the days after today
+	start := today
+	if lastDay.After(start) {
+		start = lastDay
+	}
+	steps := int(start.Sub(lastDay).Hours()/24) + days
+	predictions := model.Forecast(steps)
+
+	var bucket *cost.ForecastPoint
+	var bucketKey time.Time
+	for i, p := range predictions {
+		date := lastDay.AddDate(0, 0, i+1)
+		if !date.After(start) {
+			continue
+		}
+
+		// Bounds of buckets and of the total are sums of the daily 95%
+		// bounds. Daily errors are correlated, so the variance of a sum is
+		// not the sum of the variances; the sum of the bounds is the widest
+		// interval any correlation gives and covers at least 95%.
+		value := math.Max(p.Value, 0)
+		lower := math.Max(p.Value-forecast.Z95*p.StdErr, 0)
+		upper := math.Max(p.Value+forecast.Z95*p.StdErr, 0)
+		result.ForecastedCost += value
+		result.LowerBound += lower
+		result.UpperBound += upper
+
+		key := cost.PeriodStart(date, opts.Granularity)
+		if bucket == nil || !key.Equal(bucketKey) {
+			result.Points = append(result.Points, cost.ForecastPoint{Date: date})
+			bucket, bucketKey = &result.Points[len(result.Points)-1], key
+		}
+		bucket.ForecastedCost += value
+		bucket.LowerBound += lower
+		bucket.UpperBound += upper
+	}
+	result.EndDate = start.AddDate(0, 0, days)
+
+	return result, nil
+}
+
+// fitForecastModel fits the requested model. Auto tries Holt-Winters, then a
+// linear trend, then the mean, and reports which one it used.
+func fitForecastModel(method string, values []float64) (forecast.Model, string, error) {
+	switch method {
+	case cost.ForecastMethodHoltWinters:
+		m, err := forecast.HoltWinters(values, forecastSeasonDays)
+		return m, method, err
+	case cost.ForecastMethodLinear:
+		m, err := forecast.Linear(values)
+		return m, method, err
+	case cost.ForecastMethodMean:
+		m, err := forecast.Mean(values)
+		return m, method, err
+	}
+
+	for _, candidate := range []string{cost.ForecastMethodHoltWinters, cost.ForecastMethodLinear, cost.ForecastMethodMean} {
+		if m, _, err := fitForecastModel(candidate, values); err == nil {
+			return m, candidate, nil
+		}
+	}
+	return nil, method, forecast.ErrInsufficientData
+}
+
+// backtestForecast refits a method without the last days of history and
+// returns the MAPE of its forecast for those days, with the number of days
+// held out. It returns zero days when the history is too short.
+func backtestForecast(method string, values []float64) (float64, int) {
+	holdout := len(values) / 4
+	if holdout < forecastMinBacktestDays {
+		holdout = forecastMinBacktestDays
+	}
+	if holdout > forecastMaxBacktestDays {
+		holdout = forecastMaxBacktestDays
+	}
+	if len(values)-holdout < forecastMinBacktestDays {
+		return 0, 0
+	}
+
+	train, test := values[:len(values)-holdout], values[len(values)-holdout:]
+	model, _, err := fitForecastModel(method, train)
+	if err != nil {
+		return 0, 0
+	}
+
+	predicted := make([]float64, holdout)
+	for i, p := range model.Forecast(holdout) {
+		predicted[i] = math.Max(p.Value, 0)
+	}
+	mape, ok := forecast.MAPE(test, predicted)
+	if !ok {
+		return 0, 0
+	}
+	return mape, holdout
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+)
+
+const (
+	// optimizationCostDays is the window of cost records findings are priced on
+	optimizationCostDays = 30
+	// maxEnrichedOptimizations caps the findings sent for AI advice in one run
+	maxEnrichedOptimizations = 20
+	// optimizationPageSize is the page size used to read pending optimizations
+	optimizationPageSize = 100
+)
+
+// objectStorageServices names the object storage service in each
+// provider's cost records. Buckets without their own cost records get an
+// equal share of it.
+var objectStorageServices = map[string]string{
+	cost.ProviderAWS: "Amazon Simple Storage Service",
+	cost.ProviderGCP: "Cloud Storage",
+}
+
+// GenerateOptimizations analyzes synced resources and their costs with
+// deterministic rules and saves what it finds. A finding that is already
+// pending is refreshed with the latest numbers; one that was applied or
+// dismissed is not raised again. Pending findings of the analyzed providers
+// that the run no longer produces are marked resolved, and are raised again
+// if they reappear. When Gemini is configured, each finding gets advice on
+// how to act on it, but the savings are always computed.
+func (s *CostServiceImpl) GenerateOptimizations(ctx context.Context, userID int64, providerFilter string) ([]*cost.CostOptimization, error) {
+	if s.resourceRepo == nil {
+		s.logger.Info("Resource repository not configured, skipping optimization analysis")
+		return nil, nil
+	}
+
+	providerNames := []string{cost.ProviderAWS, cost.ProviderGCP, cost.ProviderAzure}
+	if providerFilter != "" {
+		providerNames = []string{providerFilter}
+	}
+
+	now := time.Now().UTC()
+	var resources []*resource.Resource
+	var costs []*cost.Cost
+	for _, p := range providerNames {
+		res, err := s.resourceRepo.ListByProvider(ctx, userID, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		if len(res) == 0 {
+			continue
+		}
+		for _, r := range res {
+			// Vanished resources cost nothing and cannot be optimized
+			if r.Status != resource.StatusTerminated {
+				resources = append(resources, r)
+			}
+		}
+
+		records, err := s.repo.GetCostsByDateRange(ctx, userID, cost.Filter{Provider: p}, now.AddDate(0, 0, -optimizationCostDays), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get costs: %w", err)
+		}
+		costs = append(costs, records...)
+	}
+
+	findings := detector.AnalyzeCostOptimizations(resources, resourceCosts(resources, costs))
+	if s.geminiClient != nil && len(findings) > 0 {
+		s.enrichOptimizations(ctx, findings)
+	}
+
+	var saved []*cost.CostOptimization
+	produced := make(map[string]bool, len(findings))
+	for _, opt := range findings {
+		opt.UserID = userID
+		produced[opt.Fingerprint] = true
+
+		existing, err := s.repo.GetOptimizationByFingerprint(ctx, userID, opt.Fingerprint)
+		switch {
+		case err == nil && (existing.Status == cost.OptStatusApplied || existing.Status == cost.OptStatusDismissed):
+			continue
+		case err == nil:
+			opt.ID = existing.ID
+			opt.CreatedAt = existing.CreatedAt
+			err = s.repo.UpdateOptimization(ctx, opt)
+		case isNotFound(err):
+			err = s.repo.CreateOptimization(ctx, opt)
+		}
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"title": opt.Title,
+			}).ErrorWithErr(err, "Failed to save optimization")
+			continue
+		}
+		saved = append(saved, opt)
+	}
+
+	resolved, err := s.resolveStaleOptimizations(ctx, userID, providerNames, produced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stale optimizations: %w", err)
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":   userID,
+		"resources": len(resources),
+		"findings":  len(findings),
+		"saved":     len(saved),
+		"resolved":  resolved,
+	}).Info("Cost optimization analysis completed")
+
+	return saved, nil
+}
+
+// resolveStaleOptimizations marks the pending optimizations of the given
+// providers resolved when their fingerprint is not among the findings of
+// this run: the resource was fixed, removed or is no longer priced as
+// wasteful. Optimizations without a fingerprint predate rule-based
+// analysis and are left alone. It returns how many were resolved.
+func (s *CostServiceImpl) resolveStaleOptimizations(ctx context.Context, userID int64, providerNames []string, produced map[string]bool) (int, error) {
+	analyzed := make(map[string]bool, len(providerNames))
+	for _, p := range providerNames {
+		analyzed[p] = true
+	}
+
+	// Collect first: resolving while paging would shift the pages
+	var stale []*cost.CostOptimization
+	for offset := 0; ; offset += optimizationPageSize {
+		page, _, err := s.repo.ListOptimizations(ctx, userID, cost.OptStatusPending, optimizationPageSize, offset)
+		if err != nil {
+			return 0, err
+		}
+		for _, o := range page {
+			if analyzed[o.Provider] && o.Fingerprint != "" && !produced[o.Fingerprint] {
+				stale = append(stale, o)
+			}
+		}
+		if len(page) < optimizationPageSize {
+			break
+		}
+	}
+
+	for _, o := range stale {
+		o.Status = cost.OptStatusResolved
+		if err := s.repo.UpdateOptimization(ctx, o); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
+
+// resourceCosts returns each resource's cost over the cost window. Cost
+// records are matched on the provider resource ID or, for buckets, the
+// name. Buckets without records of their own share the provider's object
+// storage cost equally.
+func resourceCosts(resources []*resource.Resource, costs []*cost.Cost) map[string]detector.ResourceCost {
+	byResource := make(map[string]float64)
+	storageCost := make(map[string]float64)
+	for _, c := range costs {
+		if c.ResourceID != nil && *c.ResourceID != "" {
+			byResource[*c.ResourceID] += c.DailyCost
+		} else if c.ServiceName == objectStorageServices[c.Provider] {
+			storageCost[c.Provider] += c.DailyCost
+		}
+	}
+
+	result := make(map[string]detector.ResourceCost, len(resources))
+	unpricedBuckets := make(map[string][]string)
+	for _, res := range resources {
+		monthly, ok := byResource[res.ResourceID]
+		if !ok {
+			monthly, ok = byResource[res.Name]
+		}
+		if ok {
+			result[res.ResourceID] = detector.ResourceCost{Monthly: monthly, Basis: detector.CostBasisResource}
+			continue
+		}
+		if res.Type == resource.TypeS3Bucket || res.Type == resource.TypeGCSBucket {
+			unpricedBuckets[res.Provider] = append(unpricedBuckets[res.Provider], res.ResourceID)
+		}
+	}
+
+	for provider, buckets := range unpricedBuckets {
+		share := storageCost[provider] / float64(len(buckets))
+		for _, id := range buckets {
+			result[id] = detector.ResourceCost{Monthly: share, Basis: detector.CostBasisServiceShare}
+		}
+	}
+	return result
+}
+
+// enrichOptimizations asks Gemini how to act on the largest findings and
+// adds its advice to their details. Failures are logged and ignored.
+func (s *CostServiceImpl) enrichOptimizations(ctx context.Context, findings []*cost.CostOptimization) {
+	if len(findings) > maxEnrichedOptimizations {
+		findings = findings[:maxEnrichedOptimizations]
+	}
+
+	type summary struct {
+		Index            int             `json:"index"`
+		Provider         string          `json:"provider"`
+		ResourceType     string          `json:"resource_type"`
+		Title            string          `json:"title"`
+		Description      string          `json:"description"`
+		EstimatedSavings float64         `json:"estimated_monthly_savings"`
+		Details          json.RawMessage `json:"details,omitempty"`
+	}
+	summaries := make([]summary, 0, len(findings))
+	for i, opt := range findings {
+		summaries = append(summaries, summary{
+			Index:            i,
+			Provider:         opt.Provider,
+			ResourceType:     opt.ResourceType,
+			Title:            opt.Title,
+			Description:      opt.Description,
+			EstimatedSavings: opt.EstimatedSavings,
+			Details:          opt.Details,
+		})
+	}
+	findingsJSON, _ := json.MarshalIndent(summaries, "", "  ")
+
+	prompt := fmt.Sprintf(`The following cloud cost optimization findings were computed from resource configuration and billing data.
+For each finding, give short, concrete advice on how to carry it out safely (commands, console steps, risks to check).
+Do not change or re-estimate the savings.
+
+Findings:
+%s
+
+Return ONLY a JSON array of objects like {"index": 0, "advice": "..."}, no additional text.`, string(findingsJSON))
+
+	response, err := s.geminiClient.GenerateContent(ctx, prompt)
+	if err != nil {
+		s.logger.ErrorWithErr(err, "Failed to get optimization advice")
+		return
+	}
+
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+	response = strings.TrimSpace(response)
+
+	var advice []struct {
+		Index  int    `json:"index"`
+		Advice string `json:"advice"`
+	}
+	if err := json.Unmarshal([]byte(response), &advice); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"error":    err.Error(),
+			"response": response[:min(200, len(response))],
+		}).Warn("Failed to parse AI optimization advice")
+		return
+	}
+
+	for _, a := range advice {
+		if a.Index < 0 || a.Index >= len(findings) || a.Advice == "" {
+			continue
+		}
+		details := map[string]interface{}{}
+		_ = json.Unmarshal(findings[a.Index].Details, &details)
+		details["advice"] = a.Advice
+		findings[a.Index].Details, _ = json.Marshal(details)
+	}
+}
@@ -2,13 +2,14 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/integrations"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 	"github.com/pratik-mahalle/infraudit/internal/providers"
@@ -18,52 +19,210 @@ import (
 type CostServiceImpl struct {
 	repo         cost.Repository
 	providerRepo provider.Repository
+	resourceRepo resource.Repository
 	geminiClient *integrations.GeminiClient
+	notifier     notification.Service
+	fetchers     map[string]CostFetcher
 	logger       *logger.Logger
 }
 
+// CostFetcher fetches the recent cost records of one cloud account. Tests
+// substitute fakes.
+type CostFetcher interface {
+	FetchCosts(ctx context.Context, creds provider.Credentials) ([]cost.Cost, error)
+}
+
+// CostFetcherFunc adapts a function to a CostFetcher
+type CostFetcherFunc func(ctx context.Context, creds provider.Credentials) ([]cost.Cost, error)
+
+// FetchCosts calls f
+func (f CostFetcherFunc) FetchCosts(ctx context.Context, creds provider.Credentials) ([]cost.Cost, error) {
+	return f(ctx, creds)
+}
+
+// defaultCostFetchers returns fetchers that call the provider billing APIs.
+// A GCP project without a billing export dataset has no costs to fetch.
+func defaultCostFetchers() map[string]CostFetcher {
+	return map[string]CostFetcher{
+		cost.ProviderAWS: CostFetcherFunc(func(ctx context.Context, c provider.Credentials) ([]cost.Cost, error) {
+			costs, err := providers.FetchAWSCosts(ctx, awsCredentials(c))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch AWS costs: %w", err)
+			}
+			return costs, nil
+		}),
+		cost.ProviderGCP: CostFetcherFunc(func(ctx context.Context, c provider.Credentials) ([]cost.Cost, error) {
+			if c.GCPBillingDataset == "" {
+				return nil, errCostProviderNotConfigured
+			}
+			costs, err := providers.FetchGCPCosts(ctx, providers.GCPBillingCredentials{
+				ProjectID:          c.GCPProjectID,
+				ServiceAccountJSON: c.GCPServiceAccountJSON,
+				BillingDataset:     c.GCPBillingDataset,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch GCP costs: %w", err)
+			}
+			return costs, nil
+		}),
+		cost.ProviderAzure: CostFetcherFunc(func(ctx context.Context, c provider.Credentials) ([]cost.Cost, error) {
+			costs, err := providers.FetchAzureCosts(ctx, azureCredentials(c))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch Azure costs: %w", err)
+			}
+			return costs, nil
+		}),
+	}
+}
+
 // NewCostService creates a new cost service
 func NewCostService(repo cost.Repository, providerRepo provider.Repository, geminiClient *integrations.GeminiClient, log *logger.Logger) cost.Service {
 	return &CostServiceImpl{
 		repo:         repo,
 		providerRepo: providerRepo,
 		geminiClient: geminiClient,
+		fetchers:     defaultCostFetchers(),
 		logger:       log,
 	}
 }
 
-// SyncCosts syncs costs for a specific provider
-func (s *CostServiceImpl) SyncCosts(ctx context.Context, userID int64, provider string) error {
-	s.logger.WithFields(map[string]interface{}{
-		"user_id":  userID,
-		"provider": provider,
-	}).Info("Syncing costs from provider")
+// SetCostFetcher sets the cost fetcher of a provider type (used for testing)
+func (s *CostServiceImpl) SetCostFetcher(providerType string, fetcher CostFetcher) {
+	s.fetchers[providerType] = fetcher
+}
 
-	switch provider {
-	case cost.ProviderAWS:
-		return s.syncAWSCosts(ctx, userID)
-	case cost.ProviderGCP:
-		return s.syncGCPCosts(ctx, userID)
-	case cost.ProviderAzure:
-		return s.syncAzureCosts(ctx, userID)
-	default:
+// SetNotificationService enables budget alerts. Without it budgets are
+// still evaluated and recorded, but nobody is notified.
+func (s *CostServiceImpl) SetNotificationService(notifier notification.Service) {
+	s.notifier = notifier
+}
+
+// SetResourceRepository enables rule-based cost optimizations, which are
+// found in the configuration of synced resources
+func (s *CostServiceImpl) SetResourceRepository(resourceRepo resource.Repository) {
+	s.resourceRepo = resourceRepo
+}
+
+// SyncCosts syncs costs for a specific provider and evaluates budgets
+// against the new data
+func (s *CostServiceImpl) SyncCosts(ctx context.Context, userID int64, provider string) error {
+	count, err := s.syncProviderCosts(ctx, userID, provider)
+	if err == errCostProviderNotConfigured {
 		return nil
 	}
+	if err == nil || count > 0 {
+		s.evaluateBudgetsAfterSync(ctx, userID)
+	}
+	return err
 }
 
 // SyncAllProviders syncs costs from all configured providers
-func (s *CostServiceImpl) SyncAllProviders(ctx context.Context, userID int64) error {
+func (s *CostServiceImpl) SyncAllProviders(ctx context.Context, userID int64) (*cost.SyncResult, error) {
+	result := &cost.SyncResult{}
 	providers := []string{cost.ProviderAWS, cost.ProviderGCP, cost.ProviderAzure}
 	for _, provider := range providers {
-		if err := s.SyncCosts(ctx, userID, provider); err != nil {
+		count, err := s.syncProviderCosts(ctx, userID, provider)
+		switch {
+		case err == errCostProviderNotConfigured:
+			result.ProvidersSkipped = append(result.ProvidersSkipped, provider)
+		case err != nil:
 			s.logger.WithFields(map[string]interface{}{
 				"user_id":  userID,
 				"provider": provider,
 			}).ErrorWithErr(err, "Failed to sync costs")
 			// Continue with other providers
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[provider] = err.Error()
+			// Records of the accounts that did sync are kept
+			result.RecordsSynced += count
+		default:
+			result.ProvidersSynced = append(result.ProvidersSynced, provider)
+			result.RecordsSynced += count
+		}
+	}
+	if len(result.ProvidersSynced) > 0 || result.RecordsSynced > 0 {
+		s.evaluateBudgetsAfterSync(ctx, userID)
+	}
+	return result, nil
+}
+
+// errCostProviderNotConfigured marks a provider that is skipped because it is
+// not connected or lacks billing configuration
+var errCostProviderNotConfigured = errors.New("cost provider not configured")
+
+// syncProviderCosts syncs every connected account of one provider and
+// returns the number of records stored. Each account is fetched with its
+// own credentials and its records carry the account ID. An account that
+// fails does not stop the others; the failures are returned together.
+func (s *CostServiceImpl) syncProviderCosts(ctx context.Context, userID int64, providerType string) (int, error) {
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":  userID,
+		"provider": providerType,
+	}).Info("Syncing costs from provider")
+
+	fetcher, ok := s.fetchers[providerType]
+	if !ok {
+		return 0, errCostProviderNotConfigured
+	}
+	accounts, err := s.providerRepo.ListByProvider(ctx, userID, providerType)
+	if err != nil {
+		return 0, err
+	}
+
+	stored, synced := 0, 0
+	var errs []error
+	for _, account := range accounts {
+		if !account.IsConnected {
+			continue
+		}
+		costs, err := fetcher.FetchCosts(ctx, account.Credentials)
+		if err == errCostProviderNotConfigured {
+			s.logger.WithFields(map[string]interface{}{
+				"provider": providerType,
+				"account":  account.Name,
+			}).Info("Billing data not configured, skipping cost sync")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", account.Name, err))
+			continue
 		}
+		synced++
+
+		count := 0
+		for i := range costs {
+			costs[i].UserID = userID
+			costs[i].AccountID = account.ID
+			if err := s.repo.CreateCost(ctx, &costs[i]); err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"provider": providerType,
+					"account":  account.Name,
+					"service":  costs[i].ServiceName,
+					"date":     costs[i].CostDate,
+				}).ErrorWithErr(err, "Failed to persist cost record")
+				continue
+			}
+			count++
+		}
+		stored += count
+
+		s.logger.WithFields(map[string]interface{}{
+			"user_id":  userID,
+			"provider": providerType,
+			"account":  account.Name,
+			"count":    count,
+		}).Info("Cost sync completed")
 	}
-	return nil
+
+	if synced == 0 && len(errs) == 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"provider": providerType,
+		}).Info("No connected account with billing data, skipping cost sync")
+		return 0, errCostProviderNotConfigured
+	}
+	return stored, errors.Join(errs...)
 }
 
 // GetCostOverview returns a high-level cost summary
@@ -198,106 +357,6 @@ func (s *CostServiceImpl) GetCostTrends(ctx context.Context, userID int64, provi
 	return trend, nil
 }
 
-// GetCostForecast returns cost forecast
-func (s *CostServiceImpl) GetCostForecast(ctx context.Context, userID int64, provider string, days int) (*cost.CostForecast, error) {
-	// Get historical data
-	dataPoints, err := s.repo.GetDailyCosts(ctx, userID, cost.Filter{Provider: provider}, 30)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(dataPoints) == 0 {
-		return &cost.CostForecast{
-			Provider:        provider,
-			Period:          "forecast",
-			ForecastedCost:  0,
-			ConfidenceLevel: 0,
-			Currency:        "USD",
-			EndDate:         time.Now().AddDate(0, 0, days),
-		}, nil
-	}
-
-	// Simple linear extrapolation
-	var sum float64
-	for _, dp := range dataPoints {
-		sum += dp.Cost
-	}
-	avgDailyCost := sum / float64(len(dataPoints))
-	forecasted := avgDailyCost * float64(days)
-
-	return &cost.CostForecast{
-		Provider:        provider,
-		Period:          "forecast",
-		ForecastedCost:  forecasted,
-		ConfidenceLevel: 0.7,
-		LowerBound:      forecasted * 0.8,
-		UpperBound:      forecasted * 1.2,
-		Currency:        "USD",
-		EndDate:         time.Now().AddDate(0, 0, days),
-	}, nil
-}
-
-// DetectAnomalies detects cost anomalies
-func (s *CostServiceImpl) DetectAnomalies(ctx context.Context, userID int64, provider string) ([]*cost.CostAnomaly, error) {
-	// Get historical daily costs
-	dataPoints, err := s.repo.GetDailyCosts(ctx, userID, cost.Filter{Provider: provider}, 30)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(dataPoints) < 7 {
-		return nil, nil // Not enough data
-	}
-
-	// Calculate mean and standard deviation
-	var sum float64
-	for _, dp := range dataPoints {
-		sum += dp.Cost
-	}
-	mean := sum / float64(len(dataPoints))
-
-	var varianceSum float64
-	for _, dp := range dataPoints {
-		diff := dp.Cost - mean
-		varianceSum += diff * diff
-	}
-	stdDev := 0.0
-	if len(dataPoints) > 1 {
-		stdDev = varianceSum / float64(len(dataPoints)-1)
-	}
-
-	// Detect anomalies (costs > 2 standard deviations from mean)
-	var anomalies []*cost.CostAnomaly
-	threshold := mean + (2 * stdDev)
-
-	for _, dp := range dataPoints {
-		if dp.Cost > threshold {
-			deviation := 0.0
-			if mean > 0 {
-				deviation = ((dp.Cost - mean) / mean) * 100
-			}
-
-			anomaly := &cost.CostAnomaly{
-				UserID:       userID,
-				Provider:     provider,
-				AnomalyType:  cost.AnomalyTypeSpike,
-				ExpectedCost: mean,
-				ActualCost:   dp.Cost,
-				Deviation:    deviation,
-				Severity:     s.getSeverityFromDeviation(deviation),
-				Status:       cost.AnomalyStatusOpen,
-				DetectedAt:   dp.Date,
-			}
-
-			if err := s.repo.CreateAnomaly(ctx, anomaly); err == nil {
-				anomalies = append(anomalies, anomaly)
-			}
-		}
-	}
-
-	return anomalies, nil
-}
-
 // GetAnomalies returns cost anomalies
 func (s *CostServiceImpl) GetAnomalies(ctx context.Context, userID int64, status string, limit, offset int) ([]*cost.CostAnomaly, int64, error) {
 	return s.repo.ListAnomalies(ctx, userID, status, limit, offset)
@@ -314,117 +373,6 @@ func (s *CostServiceImpl) UpdateAnomalyStatus(ctx context.Context, id string, st
 	return s.repo.UpdateAnomaly(ctx, anomaly)
 }
 
-// GenerateOptimizations generates cost optimization recommendations using Gemini AI
-func (s *CostServiceImpl) GenerateOptimizations(ctx context.Context, userID int64, providerFilter string) ([]*cost.CostOptimization, error) {
-	if s.geminiClient == nil {
-		s.logger.Info("Gemini client not configured, skipping optimization generation")
-		return nil, nil
-	}
-
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-
-	filter := cost.Filter{}
-	if providerFilter != "" {
-		filter.Provider = providerFilter
-	}
-
-	summary, err := s.repo.GetCostSummary(ctx, userID, filter, monthStart, now)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cost summary: %w", err)
-	}
-	if summary == nil || summary.TotalCost == 0 {
-		return nil, nil
-	}
-
-	summaryJSON, _ := json.MarshalIndent(summary, "", "  ")
-
-	prompt := fmt.Sprintf(`Analyze the following cloud cost summary and provide cost optimization recommendations.
-
-Cost Summary:
-%s
-
-Provide recommendations in JSON format. Each recommendation should have:
-{
-  "provider": "aws|gcp|azure",
-  "resource_type": "type of resource",
-  "optimization_type": "rightsize|unused|reserved_instance|savings_plan|storage_class|idle_resource|region_optimization",
-  "title": "Brief title",
-  "description": "Detailed description with specific action items",
-  "current_cost": estimated_current_monthly_cost,
-  "estimated_savings": estimated_monthly_savings,
-  "savings_percent": percentage_savings,
-  "implementation": "easy|moderate|complex"
-}
-
-Return ONLY a JSON array of recommendations, no additional text.`, string(summaryJSON))
-
-	response, err := s.geminiClient.GenerateContent(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate optimizations: %w", err)
-	}
-
-	// Strip markdown code fences if present
-	response = strings.TrimSpace(response)
-	if strings.HasPrefix(response, "```") {
-		lines := strings.SplitN(response, "\n", 2)
-		if len(lines) > 1 {
-			response = lines[1]
-		}
-		if idx := strings.LastIndex(response, "```"); idx != -1 {
-			response = response[:idx]
-		}
-		response = strings.TrimSpace(response)
-	}
-
-	var rawOptimizations []struct {
-		Provider         string  `json:"provider"`
-		ResourceType     string  `json:"resource_type"`
-		OptimizationType string  `json:"optimization_type"`
-		Title            string  `json:"title"`
-		Description      string  `json:"description"`
-		CurrentCost      float64 `json:"current_cost"`
-		EstimatedSavings float64 `json:"estimated_savings"`
-		SavingsPercent   float64 `json:"savings_percent"`
-		Implementation   string  `json:"implementation"`
-	}
-
-	if err := json.Unmarshal([]byte(response), &rawOptimizations); err != nil {
-		s.logger.WithFields(map[string]interface{}{
-			"error":    err.Error(),
-			"response": response[:min(200, len(response))],
-		}).Warn("Failed to parse AI optimization response")
-		return nil, nil
-	}
-
-	var optimizations []*cost.CostOptimization
-	for _, raw := range rawOptimizations {
-		opt := &cost.CostOptimization{
-			UserID:           userID,
-			Provider:         raw.Provider,
-			ResourceType:     raw.ResourceType,
-			OptimizationType: raw.OptimizationType,
-			Title:            raw.Title,
-			Description:      raw.Description,
-			CurrentCost:      raw.CurrentCost,
-			EstimatedSavings: raw.EstimatedSavings,
-			SavingsPercent:   raw.SavingsPercent,
-			Implementation:   raw.Implementation,
-			Status:           cost.OptStatusPending,
-		}
-
-		if err := s.repo.CreateOptimization(ctx, opt); err != nil {
-			s.logger.WithFields(map[string]interface{}{
-				"title": opt.Title,
-			}).ErrorWithErr(err, "Failed to save optimization")
-			continue
-		}
-		optimizations = append(optimizations, opt)
-	}
-
-	return optimizations, nil
-}
-
 // GetOptimizations returns cost optimizations
 func (s *CostServiceImpl) GetOptimizations(ctx context.Context, userID int64, status string, limit, offset int) ([]*cost.CostOptimization, int64, error) {
 	return s.repo.ListOptimizations(ctx, userID, status, limit, offset)
@@ -462,118 +410,6 @@ func (s *CostServiceImpl) GetAzureCosts(ctx context.Context, userID int64, start
 
 // Helper methods
 
-func (s *CostServiceImpl) syncAWSCosts(ctx context.Context, userID int64) error {
-	providerAccount, err := s.providerRepo.GetByProvider(ctx, userID, "aws")
-	if err != nil || providerAccount == nil || !providerAccount.IsConnected {
-		s.logger.Info("AWS provider not connected, skipping cost sync")
-		return nil
-	}
-
-	creds := providers.AWSCredentials{
-		AccessKeyID:    providerAccount.Credentials.AWSAccessKeyID,
-		SecretAccessKey: providerAccount.Credentials.AWSSecretAccessKey,
-		Region:         providerAccount.Credentials.AWSRegion,
-	}
-
-	costs, err := providers.FetchAWSCosts(ctx, creds)
-	if err != nil {
-		return fmt.Errorf("failed to fetch AWS costs: %w", err)
-	}
-
-	for i := range costs {
-		costs[i].UserID = userID
-		if err := s.repo.CreateCost(ctx, &costs[i]); err != nil {
-			s.logger.WithFields(map[string]interface{}{
-				"service": costs[i].ServiceName,
-				"date":    costs[i].CostDate,
-			}).ErrorWithErr(err, "Failed to persist AWS cost record")
-		}
-	}
-
-	s.logger.WithFields(map[string]interface{}{
-		"user_id": userID,
-		"count":   len(costs),
-	}).Info("AWS cost sync completed")
-	return nil
-}
-
-func (s *CostServiceImpl) syncGCPCosts(ctx context.Context, userID int64) error {
-	providerAccount, err := s.providerRepo.GetByProvider(ctx, userID, "gcp")
-	if err != nil || providerAccount == nil || !providerAccount.IsConnected {
-		s.logger.Info("GCP provider not connected, skipping cost sync")
-		return nil
-	}
-
-	if providerAccount.Credentials.GCPBillingDataset == "" {
-		s.logger.Info("GCP billing dataset not configured, skipping cost sync")
-		return nil
-	}
-
-	creds := providers.GCPBillingCredentials{
-		ProjectID:          providerAccount.Credentials.GCPProjectID,
-		ServiceAccountJSON: providerAccount.Credentials.GCPServiceAccountJSON,
-		BillingDataset:     providerAccount.Credentials.GCPBillingDataset,
-	}
-
-	costs, err := providers.FetchGCPCosts(ctx, creds)
-	if err != nil {
-		return fmt.Errorf("failed to fetch GCP costs: %w", err)
-	}
-
-	for i := range costs {
-		costs[i].UserID = userID
-		if err := s.repo.CreateCost(ctx, &costs[i]); err != nil {
-			s.logger.WithFields(map[string]interface{}{
-				"service": costs[i].ServiceName,
-				"date":    costs[i].CostDate,
-			}).ErrorWithErr(err, "Failed to persist GCP cost record")
-		}
-	}
-
-	s.logger.WithFields(map[string]interface{}{
-		"user_id": userID,
-		"count":   len(costs),
-	}).Info("GCP cost sync completed")
-	return nil
-}
-
-func (s *CostServiceImpl) syncAzureCosts(ctx context.Context, userID int64) error {
-	providerAccount, err := s.providerRepo.GetByProvider(ctx, userID, "azure")
-	if err != nil || providerAccount == nil || !providerAccount.IsConnected {
-		s.logger.Info("Azure provider not connected, skipping cost sync")
-		return nil
-	}
-
-	creds := providers.AzureCredentials{
-		TenantID:       providerAccount.Credentials.AzureTenantID,
-		ClientID:       providerAccount.Credentials.AzureClientID,
-		ClientSecret:   providerAccount.Credentials.AzureClientSecret,
-		SubscriptionID: providerAccount.Credentials.AzureSubscriptionID,
-		Location:       providerAccount.Credentials.AzureLocation,
-	}
-
-	costs, err := providers.FetchAzureCosts(ctx, creds)
-	if err != nil {
-		return fmt.Errorf("failed to fetch Azure costs: %w", err)
-	}
-
-	for i := range costs {
-		costs[i].UserID = userID
-		if err := s.repo.CreateCost(ctx, &costs[i]); err != nil {
-			s.logger.WithFields(map[string]interface{}{
-				"service": costs[i].ServiceName,
-				"date":    costs[i].CostDate,
-			}).ErrorWithErr(err, "Failed to persist Azure cost record")
-		}
-	}
-
-	s.logger.WithFields(map[string]interface{}{
-		"user_id": userID,
-		"count":   len(costs),
-	}).Info("Azure cost sync completed")
-	return nil
-}
-
 func (s *CostServiceImpl) getPeriodDates(period string) (time.Time, time.Time) {
 	now := time.Now()
 	endDate := now
@@ -0,0 +1,694 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+// recordingNotifier captures sent notifications; other methods panic on the
+// nil embedded service
+type recordingNotifier struct {
+	notification.Service
+	mu     sync.Mutex
+	sent   []*notification.Notification
+	events []notification.EventType
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg *notification.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func (n *recordingNotifier) TriggerEvent(ctx context.Context, userID int64, eventType notification.EventType, data map[string]interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, eventType)
+	return nil
+}
+
+func newTestCostService() (*CostServiceImpl, *testutil.MockCostRepository, *recordingNotifier) {
+	repo := testutil.NewMockCostRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	svc := NewCostService(repo, testutil.NewMockProviderRepository(), nil, log).(*CostServiceImpl)
+	notifier := &recordingNotifier{}
+	svc.SetNotificationService(notifier)
+	return svc, repo, notifier
+}
+
+// seedTodaysCosts stores 85 of EC2 and 15 of tagged S3 spend for today
+func seedTodaysCosts(t *testing.T, repo *testutil.MockCostRepository) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, c := range []*cost.Cost{
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", Region: "us-east-1", CostDate: today, DailyCost: 85},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "S3", Region: "us-east-1", CostDate: today, DailyCost: 15, Tags: json.RawMessage(`{"team":"data"}`)},
+	} {
+		if err := repo.CreateCost(context.Background(), c); err != nil {
+			t.Fatalf("CreateCost() error = %v", err)
+		}
+	}
+}
+
+func TestCostService_SyncCostsPerAccount(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+	providers := svc.providerRepo.(*testutil.MockProviderRepository)
+
+	for _, p := range []*provider.Provider{
+		{UserID: 1, Provider: provider.ProviderAWS, Name: "prod", IsConnected: true, Credentials: provider.Credentials{AWSAccessKeyID: "AKIAPROD"}},
+		{UserID: 1, Provider: provider.ProviderAWS, Name: "staging", IsConnected: true, Credentials: provider.Credentials{AWSAccessKeyID: "AKIASTAGING"}},
+		{UserID: 1, Provider: provider.ProviderAWS, Name: "broken", IsConnected: true, Credentials: provider.Credentials{AWSAccessKeyID: "AKIABROKEN"}},
+		{UserID: 1, Provider: provider.ProviderAWS, Name: "old", IsConnected: false, Credentials: provider.Credentials{AWSAccessKeyID: "AKIAOLD"}},
+	} {
+		if err := providers.Upsert(ctx, p); err != nil {
+			t.Fatalf("Upsert() error = %v", err)
+		}
+	}
+
+	var fetched []string
+	svc.SetCostFetcher(cost.ProviderAWS, CostFetcherFunc(func(ctx context.Context, c provider.Credentials) ([]cost.Cost, error) {
+		fetched = append(fetched, c.AWSAccessKeyID)
+		if c.AWSAccessKeyID == "AKIABROKEN" {
+			return nil, errors.New("access denied")
+		}
+		return []cost.Cost{{Provider: cost.ProviderAWS, ServiceName: c.AWSAccessKeyID, CostDate: time.Now().UTC(), DailyCost: 10}}, nil
+	}))
+
+	result, err := svc.SyncAllProviders(ctx, 1)
+	if err != nil {
+		t.Fatalf("SyncAllProviders() error = %v", err)
+	}
+	if len(fetched) != 3 {
+		t.Fatalf("fetched accounts = %v, want the three connected ones", fetched)
+	}
+	// The broken account is reported, and the others' records are kept
+	if result.RecordsSynced != 2 || !strings.Contains(result.Errors[cost.ProviderAWS], "account broken: access denied") {
+		t.Fatalf("SyncAllProviders() = %+v", result)
+	}
+
+	accounts := make(map[string]int64)
+	for _, p := range providers.Providers {
+		accounts[p.Credentials.AWSAccessKeyID] = p.ID
+	}
+	if len(repo.Costs) != 2 {
+		t.Fatalf("stored %d cost records, want 2", len(repo.Costs))
+	}
+	for _, c := range repo.Costs {
+		if c.UserID != 1 || c.AccountID != accounts[c.ServiceName] {
+			t.Fatalf("cost record %+v is not stamped with its account", c)
+		}
+	}
+}
+
+func TestCostService_EvaluateBudgetsFiresEachThresholdOnce(t *testing.T) {
+	svc, repo, notifier := newTestCostService()
+	ctx := context.Background()
+	seedTodaysCosts(t, repo)
+
+	budget, err := svc.CreateBudget(ctx, 1, &cost.Budget{
+		Name:      "AWS",
+		Period:    cost.BudgetPeriodMonthly,
+		Amount:    100,
+		Scope:     cost.BudgetScope{Provider: cost.ProviderAWS},
+		IsEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+	if len(budget.Thresholds) != len(cost.DefaultBudgetThresholds()) || budget.Currency != "USD" {
+		t.Fatalf("CreateBudget() did not apply defaults: %+v", budget)
+	}
+
+	alerts, err := svc.EvaluateBudgets(ctx, 1)
+	if err != nil {
+		t.Fatalf("EvaluateBudgets() error = %v", err)
+	}
+	// 50, 80 and 100% actual, plus 100% forecasted
+	if len(alerts) != 4 {
+		t.Fatalf("EvaluateBudgets() fired %d alerts, want 4", len(alerts))
+	}
+
+	// Only the highest new threshold of each type is notified
+	if len(notifier.sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(notifier.sent))
+	}
+	priorities := map[notification.Priority]bool{}
+	for _, n := range notifier.sent {
+		if n.Type != notification.NotificationTypeCostAlert || n.UserID != 1 {
+			t.Errorf("unexpected notification %+v", n)
+		}
+		priorities[n.Priority] = true
+	}
+	if !priorities[notification.PriorityCritical] || !priorities[notification.PriorityHigh] {
+		t.Errorf("priorities = %v, want critical for the overrun and high for the projection", priorities)
+	}
+
+	alerts, err = svc.EvaluateBudgets(ctx, 1)
+	if err != nil {
+		t.Fatalf("second EvaluateBudgets() error = %v", err)
+	}
+	if len(alerts) != 0 || len(notifier.sent) != 2 {
+		t.Fatalf("second evaluation fired %d alerts and %d notifications in total, want 0 and 2", len(alerts), len(notifier.sent))
+	}
+
+	status, err := svc.GetBudgetStatus(ctx, 1, budget.ID)
+	if err != nil {
+		t.Fatalf("GetBudgetStatus() error = %v", err)
+	}
+	if status.ActualSpend != 100 || status.PercentUsed != 100 || len(status.Alerts) != 4 || len(status.Crossed) != 4 {
+		t.Fatalf("GetBudgetStatus() = %+v", status)
+	}
+}
+
+func TestCostService_BudgetScopeByTag(t *testing.T) {
+	svc, repo, notifier := newTestCostService()
+	ctx := context.Background()
+	seedTodaysCosts(t, repo)
+
+	budget, err := svc.CreateBudget(ctx, 1, &cost.Budget{
+		Name:       "Data team",
+		Period:     cost.BudgetPeriodQuarterly,
+		Amount:     10000,
+		Scope:      cost.BudgetScope{TagKey: "team", TagValue: "data"},
+		Thresholds: []cost.BudgetThreshold{{Percent: 0.1, Type: cost.ThresholdTypeActual}},
+		IsEnabled:  true,
+	})
+	if err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+
+	status, err := svc.GetBudgetStatus(ctx, 1, budget.ID)
+	if err != nil {
+		t.Fatalf("GetBudgetStatus() error = %v", err)
+	}
+	if status.ActualSpend != 15 {
+		t.Errorf("ActualSpend = %v, want only the tagged 15", status.ActualSpend)
+	}
+	// The projection scales the provider forecast by the tag's 15% share
+	if status.ProjectedSpend <= status.ActualSpend {
+		t.Errorf("ProjectedSpend = %v, want more than the actual spend", status.ProjectedSpend)
+	}
+	if got := status.PeriodEnd.Sub(status.PeriodStart); got < 89*24*time.Hour || got > 92*24*time.Hour {
+		t.Errorf("quarterly period spans %v", got)
+	}
+
+	budget.IsEnabled = false
+	if _, err := svc.UpdateBudget(ctx, 1, budget.ID, budget); err != nil {
+		t.Fatalf("UpdateBudget() error = %v", err)
+	}
+	alerts, err := svc.EvaluateBudgets(ctx, 1)
+	if err != nil || len(alerts) != 0 || len(notifier.sent) != 0 {
+		t.Fatalf("disabled budget fired %d alerts, %d notifications, err %v", len(alerts), len(notifier.sent), err)
+	}
+}
+
+func TestCostService_CreateBudgetValidation(t *testing.T) {
+	svc, _, _ := newTestCostService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		budget cost.Budget
+	}{
+		{"missing name", cost.Budget{Period: cost.BudgetPeriodMonthly, Amount: 10}},
+		{"zero amount", cost.Budget{Name: "b", Period: cost.BudgetPeriodMonthly}},
+		{"unknown period", cost.Budget{Name: "b", Period: "weekly", Amount: 10}},
+		{"unknown provider", cost.Budget{Name: "b", Period: cost.BudgetPeriodMonthly, Amount: 10, Scope: cost.BudgetScope{Provider: "oracle"}}},
+		{"tag value without key", cost.Budget{Name: "b", Period: cost.BudgetPeriodMonthly, Amount: 10, Scope: cost.BudgetScope{TagValue: "data"}}},
+		{"bad threshold type", cost.Budget{Name: "b", Period: cost.BudgetPeriodMonthly, Amount: 10, Thresholds: []cost.BudgetThreshold{{Percent: 50, Type: "daily"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.budget
+			if _, err := svc.CreateBudget(ctx, 1, &b); err == nil {
+				t.Error("CreateBudget() should fail")
+			}
+		})
+	}
+}
+
+func TestBudget_PeriodKey(t *testing.T) {
+	at := time.Date(2026, time.November, 15, 12, 0, 0, 0, time.UTC)
+
+	monthly := &cost.Budget{Period: cost.BudgetPeriodMonthly}
+	if got := monthly.PeriodKey(at); got != "2026-11" {
+		t.Errorf("monthly PeriodKey() = %q", got)
+	}
+
+	quarterly := &cost.Budget{Period: cost.BudgetPeriodQuarterly}
+	start, end := quarterly.PeriodBounds(at)
+	if got := quarterly.PeriodKey(at); got != "2026-Q4" {
+		t.Errorf("quarterly PeriodKey() = %q", got)
+	}
+	if !start.Equal(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("quarterly PeriodBounds() = %v, %v", start, end)
+	}
+}
+
+func TestCostService_DetectAnomaliesAttributesAndDedupes(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 35; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		ec2 := 80.0 + float64(i%3)
+		if i == 1 {
+			ec2 = 380
+		}
+		for _, c := range []*cost.Cost{
+			{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", Region: "us-east-1", CostDate: day, DailyCost: ec2},
+			{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "S3", Region: "us-west-2", CostDate: day, DailyCost: 20},
+		} {
+			if err := repo.CreateCost(ctx, c); err != nil {
+				t.Fatalf("CreateCost() error = %v", err)
+			}
+		}
+	}
+
+	anomalies, err := svc.DetectAnomalies(ctx, 1, cost.ProviderAWS)
+	if err != nil {
+		t.Fatalf("DetectAnomalies() error = %v", err)
+	}
+	// The spike shows in the provider total and in the EC2 series, not in S3
+	if len(anomalies) != 2 {
+		t.Fatalf("DetectAnomalies() = %d anomalies, want 2", len(anomalies))
+	}
+
+	for _, a := range anomalies {
+		if a.AnomalyType != cost.AnomalyTypeSpike || a.Fingerprint == "" || !a.DetectedAt.Equal(today.AddDate(0, 0, -1)) {
+			t.Errorf("unexpected anomaly %+v", a)
+		}
+		if a.ServiceName == "S3" {
+			t.Errorf("S3 should not be anomalous: %+v", a)
+		}
+		if len(a.Drivers) == 0 {
+			t.Fatalf("anomaly %+v has no drivers", a)
+		}
+
+		top := a.Drivers[0]
+		switch a.ServiceName {
+		case "":
+			if top.Dimension != cost.DriverDimensionService || top.Name != "EC2" || top.Share < 0.9 {
+				t.Errorf("provider anomaly top driver = %+v, want EC2", top)
+			}
+		case "EC2":
+			if top.Dimension != cost.DriverDimensionRegion || top.Name != "us-east-1" {
+				t.Errorf("EC2 anomaly top driver = %+v, want us-east-1", top)
+			}
+		}
+	}
+
+	again, err := svc.DetectAnomalies(ctx, 1, cost.ProviderAWS)
+	if err != nil {
+		t.Fatalf("second DetectAnomalies() error = %v", err)
+	}
+	if len(again) != 0 || len(repo.Anomalies) != 2 {
+		t.Fatalf("second run returned %d anomalies and stored %d in total, want 0 and 2", len(again), len(repo.Anomalies))
+	}
+}
+
+func TestCostService_DetectAnomaliesLevelShiftAndStoppedSpend(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 35; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		ec2 := 80.0 + float64(i%3)
+		if i <= 3 {
+			ec2 *= 2
+		}
+		if err := repo.CreateCost(ctx, &cost.Cost{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", Region: "us-east-1", CostDate: day, DailyCost: ec2}); err != nil {
+			t.Fatalf("CreateCost() error = %v", err)
+		}
+		// S3 spend stops two days ago
+		if i > 2 {
+			if err := repo.CreateCost(ctx, &cost.Cost{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "S3", Region: "us-west-2", CostDate: day, DailyCost: 20}); err != nil {
+				t.Fatalf("CreateCost() error = %v", err)
+			}
+		}
+	}
+
+	// Earlier runs saw the first two days of the EC2 shift as spikes
+	for _, i := range []int{3, 2} {
+		day := today.AddDate(0, 0, -i)
+		if err := repo.CreateAnomaly(ctx, &cost.CostAnomaly{
+			UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", AnomalyType: cost.AnomalyTypeSpike, Status: cost.AnomalyStatusOpen, DetectedAt: day,
+			Fingerprint: detector.CostAnomalyFingerprint(cost.ProviderAWS, "EC2", cost.AnomalyTypeSpike, day),
+		}); err != nil {
+			t.Fatalf("CreateAnomaly() error = %v", err)
+		}
+	}
+
+	anomalies, err := svc.DetectAnomalies(ctx, 1, cost.ProviderAWS)
+	if err != nil {
+		t.Fatalf("DetectAnomalies() error = %v", err)
+	}
+
+	var ec2Shift bool
+	s3Drops := 0
+	for _, a := range anomalies {
+		switch {
+		case a.ServiceName == "EC2" && a.AnomalyType == cost.AnomalyTypeLevelShift:
+			ec2Shift = a.DetectedAt.Equal(today.AddDate(0, 0, -3))
+		case a.ServiceName == "S3" && a.AnomalyType == cost.AnomalyTypeDrop && a.ActualCost == 0:
+			s3Drops++
+		}
+	}
+	if !ec2Shift || s3Drops != 2 {
+		t.Fatalf("DetectAnomalies() = %+v, want an EC2 level shift and two S3 drops to zero", anomalies)
+	}
+
+	for _, a := range repo.Anomalies {
+		if a.ServiceName == "EC2" && a.AnomalyType == cost.AnomalyTypeSpike && a.Status != cost.AnomalyStatusResolved {
+			t.Errorf("spike on %s is %s, want it superseded by the level shift", a.DetectedAt.Format("2006-01-02"), a.Status)
+		}
+	}
+}
+
+func TestCostService_GetCostForecast(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 42; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		daily := 100.0 + float64(i%3)
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			daily = 40
+		}
+		if err := repo.CreateCost(ctx, &cost.Cost{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", CostDate: day, DailyCost: daily}); err != nil {
+			t.Fatalf("CreateCost() error = %v", err)
+		}
+	}
+
+	daily, err := svc.GetCostForecast(ctx, 1, cost.ProviderAWS, 14, cost.ForecastOptions{})
+	if err != nil {
+		t.Fatalf("GetCostForecast() error = %v", err)
+	}
+	if daily.Method != cost.ForecastMethodHoltWinters || daily.Granularity != cost.GranularityDaily {
+		t.Fatalf("method = %s, granularity = %s", daily.Method, daily.Granularity)
+	}
+	if len(daily.Points) != 14 || !daily.Points[0].Date.Equal(today.AddDate(0, 0, 1)) {
+		t.Fatalf("got %d points starting %v, want 14 starting tomorrow", len(daily.Points), daily.Points[0].Date)
+	}
+	if daily.BacktestDays != 10 || daily.MAPE > 10 {
+		t.Errorf("backtest = %d days with MAPE %v, want 10 days under 10%%", daily.BacktestDays, daily.MAPE)
+	}
+	for _, p := range daily.Points {
+		if p.LowerBound > p.ForecastedCost || p.UpperBound < p.ForecastedCost {
+			t.Errorf("point %+v is outside its interval", p)
+		}
+		weekend := p.Date.Weekday() == time.Saturday || p.Date.Weekday() == time.Sunday
+		if weekend != (p.ForecastedCost < 70) {
+			t.Errorf("point %v = %v does not follow the weekly pattern", p.Date.Weekday(), p.ForecastedCost)
+		}
+	}
+
+	weekly, err := svc.GetCostForecast(ctx, 1, cost.ProviderAWS, 14, cost.ForecastOptions{Granularity: cost.GranularityWeekly})
+	if err != nil {
+		t.Fatalf("weekly GetCostForecast() error = %v", err)
+	}
+	var sum float64
+	for _, p := range weekly.Points {
+		sum += p.ForecastedCost
+	}
+	if len(weekly.Points) < 2 || len(weekly.Points) > 3 || math.Abs(sum-daily.ForecastedCost) > 1e-6 {
+		t.Errorf("weekly points = %+v, want 2-3 buckets adding up to %v", weekly.Points, daily.ForecastedCost)
+	}
+}
+
+func TestCostService_GetCostForecastOptions(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+	seedTodaysCosts(t, repo)
+
+	forecast, err := svc.GetCostForecast(ctx, 1, "", 3, cost.ForecastOptions{})
+	if err != nil {
+		t.Fatalf("GetCostForecast() error = %v", err)
+	}
+	// A single day of history falls back to the mean and cannot be backtested
+	if forecast.Method != cost.ForecastMethodMean || forecast.ForecastedCost != 300 || forecast.BacktestDays != 0 {
+		t.Errorf("GetCostForecast() = %+v", forecast)
+	}
+
+	for _, opts := range []cost.ForecastOptions{
+		{Method: "arima"},
+		{Granularity: "hourly"},
+		{Method: cost.ForecastMethodHoltWinters},
+	} {
+		if _, err := svc.GetCostForecast(ctx, 1, "", 3, opts); err == nil {
+			t.Errorf("GetCostForecast(%+v) should fail", opts)
+		}
+	}
+}
+
+func TestCostService_GetCostAllocationSplitsSharedCosts(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	march := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, time.April, 10, 0, 0, 0, 0, time.UTC)
+	for _, c := range []*cost.Cost{
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", CostDate: march, DailyCost: 60, Tags: json.RawMessage(`{"team":"data"}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "RDS", CostDate: march, DailyCost: 40, Tags: json.RawMessage(`{"team":"Web"}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "S3", CostDate: march, DailyCost: 20, Tags: json.RawMessage(`{"owner":"web"}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "CloudWatch", CostDate: march, DailyCost: 30},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "NAT", CostDate: march, DailyCost: 30, Tags: json.RawMessage(`{"team":"shared"}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EKS", CostDate: march, DailyCost: 50, Tags: json.RawMessage(`{"team":"platform"}`)},
+		{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "EC2", CostDate: april, DailyCost: 10, Tags: json.RawMessage(`{"team":"data"}`)},
+	} {
+		if err := repo.CreateCost(ctx, c); err != nil {
+			t.Fatalf("CreateCost() error = %v", err)
+		}
+	}
+
+	rule, err := svc.CreateAllocationRule(ctx, 1, &cost.AllocationRule{
+		Name:    "Teams",
+		TagKeys: []string{"team", "owner"},
+		Aliases: map[string]string{"Web": "web"},
+		Splits: []cost.AllocationSplit{
+			{Group: "shared", Method: cost.SplitMethodProportional},
+			{Group: "platform", Method: cost.SplitMethodFixed, Weights: map[string]float64{"data": 80, "web": 20}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateAllocationRule() error = %v", err)
+	}
+
+	report, err := svc.GetCostAllocation(ctx, 1, cost.AllocationQuery{
+		RuleID:    rule.ID,
+		StartDate: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, time.April, 30, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("GetCostAllocation() error = %v", err)
+	}
+	if report.Granularity != cost.GranularityMonthly || report.TotalCost != 240 {
+		t.Fatalf("GetCostAllocation() granularity = %q, total = %v", report.Granularity, report.TotalCost)
+	}
+
+	// The shared 30 goes to data, web and untagged in proportion to their
+	// 60, 60 and 30; platform's 50 goes 80/20 to data and web
+	want := map[string]float64{"data": 122, "web": 82, cost.UntaggedGroup: 36, "shared": 0, "platform": 0}
+	var allocated float64
+	for _, g := range report.Groups {
+		if math.Abs(g.TotalCost-want[g.Name]) > 1e-9 {
+			t.Errorf("group %q total = %v, want %v", g.Name, g.TotalCost, want[g.Name])
+		}
+		if len(g.Trend) != 2 {
+			t.Errorf("group %q has %d trend periods, want 2", g.Name, len(g.Trend))
+		}
+		allocated += g.TotalCost
+	}
+	if len(report.Groups) != len(want) || math.Abs(allocated-report.TotalCost) > 1e-9 {
+		t.Fatalf("GetCostAllocation() groups = %+v", report.Groups)
+	}
+
+	data := report.Groups[0]
+	if data.Name != "data" || data.DirectCost != 70 || data.SharedCost != 52 || math.Abs(data.Percentage-122.0/240*100) > 1e-9 {
+		t.Errorf("data group = %+v", data)
+	}
+	if !data.Trend[1].Start.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) || data.Trend[1].TotalCost != 10 {
+		t.Errorf("data April trend = %+v", data.Trend[1])
+	}
+}
+
+func TestCostService_GetCostAllocationByTagKeys(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+	seedTodaysCosts(t, repo)
+
+	report, err := svc.GetCostAllocation(ctx, 1, cost.AllocationQuery{TagKeys: []string{"team"}, Granularity: cost.GranularityDaily})
+	if err != nil {
+		t.Fatalf("GetCostAllocation() error = %v", err)
+	}
+	if len(report.Groups) != 2 || report.Groups[0].Name != cost.UntaggedGroup || report.Groups[0].TotalCost != 85 ||
+		report.Groups[1].Name != "data" || report.Groups[1].TotalCost != 15 {
+		t.Fatalf("GetCostAllocation() groups = %+v", report.Groups)
+	}
+
+	for name, query := range map[string]cost.AllocationQuery{
+		"no rule or tag keys": {},
+		"unknown rule":        {RuleID: "missing"},
+		"bad granularity":     {TagKeys: []string{"team"}, Granularity: "hourly"},
+		"end before start":    {TagKeys: []string{"team"}, StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, -1)},
+	} {
+		if _, err := svc.GetCostAllocation(ctx, 1, query); err == nil {
+			t.Errorf("GetCostAllocation() with %s should fail", name)
+		}
+	}
+}
+
+func TestCostService_CreateAllocationRuleValidation(t *testing.T) {
+	svc, _, _ := newTestCostService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		rule cost.AllocationRule
+	}{
+		{"missing name", cost.AllocationRule{TagKeys: []string{"team"}}},
+		{"no tag keys", cost.AllocationRule{Name: "r"}},
+		{"unknown split method", cost.AllocationRule{Name: "r", TagKeys: []string{"team"}, Splits: []cost.AllocationSplit{{Group: "shared", Method: "even"}}}},
+		{"group split twice", cost.AllocationRule{Name: "r", TagKeys: []string{"team"}, Splits: []cost.AllocationSplit{
+			{Group: "shared", Method: cost.SplitMethodProportional},
+			{Group: "shared", Method: cost.SplitMethodProportional},
+		}}},
+		{"fixed weights under 100", cost.AllocationRule{Name: "r", TagKeys: []string{"team"}, Splits: []cost.AllocationSplit{
+			{Group: "shared", Method: cost.SplitMethodFixed, Weights: map[string]float64{"data": 50, "web": 40}},
+		}}},
+		{"fixed weight to a split group", cost.AllocationRule{Name: "r", TagKeys: []string{"team"}, Splits: []cost.AllocationSplit{
+			{Group: "shared", Method: cost.SplitMethodFixed, Weights: map[string]float64{"platform": 100}},
+			{Group: "platform", Method: cost.SplitMethodProportional},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			if _, err := svc.CreateAllocationRule(ctx, 1, &rule); err == nil {
+				t.Error("CreateAllocationRule() should fail")
+			}
+		})
+	}
+}
+
+func TestCostService_GenerateOptimizationsDedupes(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	if found, err := svc.GenerateOptimizations(ctx, 1, ""); err != nil || found != nil {
+		t.Fatalf("GenerateOptimizations() without resources = %v, %v", found, err)
+	}
+
+	resources := testutil.NewMockResourceRepository()
+	svc.SetResourceRepository(resources)
+	for _, r := range []*resource.Resource{
+		{UserID: 1, Provider: cost.ProviderAWS, ResourceID: "vol-1", Name: "vol-1", Type: resource.TypeEBSVolume, Status: "available",
+			Configuration: `{"volume_type":"gp2","size_gb":200}`},
+		{UserID: 1, Provider: cost.ProviderAWS, ResourceID: "s3-logs", Name: "logs", Type: resource.TypeS3Bucket, Status: resource.StatusActive,
+			Configuration: `{"bucket_name":"logs"}`},
+	} {
+		if err := resources.Create(ctx, r); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	// Service-level S3 spend is shared by the buckets without cost records
+	if err := repo.CreateCost(ctx, &cost.Cost{UserID: 1, Provider: cost.ProviderAWS, ServiceName: "Amazon Simple Storage Service",
+		CostDate: time.Now().UTC().AddDate(0, 0, -1), DailyCost: 40}); err != nil {
+		t.Fatalf("CreateCost() error = %v", err)
+	}
+
+	found, err := svc.GenerateOptimizations(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("GenerateOptimizations() error = %v", err)
+	}
+	if len(found) != 2 || found[0].EstimatedSavings != 20 || found[1].EstimatedSavings != 12 {
+		t.Fatalf("GenerateOptimizations() = %+v", found)
+	}
+
+	// A rerun refreshes the pending findings instead of adding more
+	again, err := svc.GenerateOptimizations(ctx, 1, "")
+	if err != nil || len(again) != 2 || len(repo.Optimizations) != 2 || again[0].ID != found[0].ID {
+		t.Fatalf("rerun = %+v, %v; %d stored", again, err, len(repo.Optimizations))
+	}
+
+	// Dismissed findings are not raised again
+	if err := svc.UpdateOptimizationStatus(ctx, found[0].ID, cost.OptStatusDismissed); err != nil {
+		t.Fatalf("UpdateOptimizationStatus() error = %v", err)
+	}
+	again, err = svc.GenerateOptimizations(ctx, 1, "")
+	if err != nil || len(again) != 1 || again[0].ID != found[1].ID || len(repo.Optimizations) != 2 {
+		t.Fatalf("after dismissal = %+v, %v", again, err)
+	}
+}
+
+func TestCostService_GenerateOptimizationsResolvesStale(t *testing.T) {
+	svc, repo, _ := newTestCostService()
+	ctx := context.Background()
+
+	resources := testutil.NewMockResourceRepository()
+	svc.SetResourceRepository(resources)
+	volume := &resource.Resource{UserID: 1, Provider: cost.ProviderAWS, ResourceID: "vol-1", Name: "vol-1", Type: resource.TypeEBSVolume,
+		Status: "available", Configuration: `{"volume_type":"gp2","size_gb":200}`}
+	if err := resources.Create(ctx, volume); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	found, err := svc.GenerateOptimizations(ctx, 1, "")
+	if err != nil || len(found) != 1 {
+		t.Fatalf("GenerateOptimizations() = %+v, %v", found, err)
+	}
+	id := found[0].ID
+
+	// Analyzing another provider leaves the AWS finding pending
+	if err := resources.Delete(ctx, 1, "vol-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := svc.GenerateOptimizations(ctx, 1, cost.ProviderGCP); err != nil {
+		t.Fatalf("GenerateOptimizations(gcp) error = %v", err)
+	}
+	if got := repo.Optimizations[id].Status; got != cost.OptStatusPending {
+		t.Fatalf("status after gcp run = %q, want pending", got)
+	}
+
+	// The volume is gone, so its finding is resolved and no longer counted
+	if _, err := svc.GenerateOptimizations(ctx, 1, cost.ProviderAWS); err != nil {
+		t.Fatalf("GenerateOptimizations(aws) error = %v", err)
+	}
+	if got := repo.Optimizations[id].Status; got != cost.OptStatusResolved {
+		t.Fatalf("status after aws run = %q, want resolved", got)
+	}
+	if savings, _ := svc.GetPotentialSavings(ctx, 1); savings != 0 {
+		t.Fatalf("GetPotentialSavings() = %v, want 0", savings)
+	}
+
+	// A resolved finding that reappears is pending again
+	if err := resources.Create(ctx, volume); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	again, err := svc.GenerateOptimizations(ctx, 1, "")
+	if err != nil || len(again) != 1 || again[0].ID != id || repo.Optimizations[id].Status != cost.OptStatusPending {
+		t.Fatalf("after reappearing = %+v, %v", again, err)
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"strings"
+
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+)
+
+// DriftRuleService implements drift.RuleService
+type DriftRuleService struct {
+	repo   drift.RuleRepository
+	rules  *detector.RuleSet
+	logger *logger.Logger
+}
+
+// NewDriftRuleService creates a new drift rule service. rules is the
+// validated built-in rule set; custom rules are layered on top of it.
+func NewDriftRuleService(repo drift.RuleRepository, rules *detector.RuleSet, log *logger.Logger) drift.RuleService {
+	if rules == nil {
+		rules = detector.DefaultRuleSet()
+	}
+	return &DriftRuleService{
+		repo:   repo,
+		rules:  rules,
+		logger: log,
+	}
+}
+
+// List returns built-in and custom rules visible to a user. A custom rule
+// with the same ID as a built-in replaces it in the listing.
+func (s *DriftRuleService) List(ctx context.Context, userID int64, filter drift.RuleFilter) ([]*drift.Rule, error) {
+	custom, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := make(map[string]bool, len(custom))
+	for _, r := range custom {
+		overridden[r.ID] = true
+	}
+
+	var rules []*drift.Rule
+	for _, r := range s.rules.Rules() {
+		if !overridden[r.ID] {
+			rules = append(rules, r)
+		}
+	}
+	rules = append(rules, custom...)
+
+	filtered := make([]*drift.Rule, 0, len(rules))
+	for _, r := range rules {
+		if ruleMatchesFilter(r, filter) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	return filtered, nil
+}
+
+// Get returns a single rule, custom rules taking precedence over built-ins
+func (s *DriftRuleService) Get(ctx context.Context, userID int64, id string) (*drift.Rule, error) {
+	r, err := s.repo.GetByID(ctx, userID, id)
+	if err == nil {
+		return r, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	if builtin, ok := s.rules.Lookup(id); ok {
+		return builtin, nil
+	}
+	return nil, errors.NotFound("Drift rule")
+}
+
+// Create validates and stores a custom rule
+func (s *DriftRuleService) Create(ctx context.Context, userID int64, rule *drift.Rule) (*drift.Rule, error) {
+	rule.UserID = userID
+	rule.Builtin = false
+	rule.Source = drift.RuleSourceCustom
+
+	if err := detector.ValidateRule(rule); err != nil {
+		return nil, errors.ValidationError("Invalid drift rule", err.Error())
+	}
+
+	if _, err := s.repo.GetByID(ctx, userID, rule.ID); err == nil {
+		return nil, errors.Conflict("Drift rule already exists")
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, rule); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to create drift rule")
+		return nil, err
+	}
+
+	_, overridesBuiltin := s.rules.Lookup(rule.ID)
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":           userID,
+		"rule_id":           rule.ID,
+		"overrides_builtin": overridesBuiltin,
+	}).Info("Drift rule created")
+
+	return rule, nil
+}
+
+// Update validates and replaces a custom rule
+func (s *DriftRuleService) Update(ctx context.Context, userID int64, id string, rule *drift.Rule) (*drift.Rule, error) {
+	existing, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = id
+	rule.UserID = userID
+	rule.Builtin = false
+	rule.Source = drift.RuleSourceCustom
+	rule.CreatedAt = existing.CreatedAt
+
+	if err := detector.ValidateRule(rule); err != nil {
+		return nil, errors.ValidationError("Invalid drift rule", err.Error())
+	}
+
+	if err := s.repo.Update(ctx, rule); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to update drift rule")
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"rule_id": id,
+	}).Info("Drift rule updated")
+
+	return rule, nil
+}
+
+// Delete removes a custom rule. Built-in rules cannot be deleted, only
+// overridden or disabled by a custom rule with the same ID.
+func (s *DriftRuleService) Delete(ctx context.Context, userID int64, id string) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		if _, ok := s.rules.Lookup(id); ok && isNotFound(err) {
+			return errors.BadRequest("Built-in drift rules cannot be deleted; create a disabled override instead")
+		}
+		return err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"rule_id": id,
+	}).Info("Drift rule deleted")
+
+	return nil
+}
+
+func ruleMatchesFilter(r *drift.Rule, filter drift.RuleFilter) bool {
+	if filter.Severity != "" && r.Severity != filter.Severity {
+		return false
+	}
+	if filter.DriftType != "" && r.DriftType != filter.DriftType {
+		return false
+	}
+	if filter.Source == drift.RuleSourceBuiltin && !r.Builtin {
+		return false
+	}
+	if filter.Source == drift.RuleSourceCustom && r.Builtin {
+		return false
+	}
+	if filter.ResourceType != "" && len(r.ResourceTypes) > 0 {
+		want := strings.ReplaceAll(strings.ToLower(filter.ResourceType), "_", "-")
+		for _, t := range r.ResourceTypes {
+			if t == "*" || strings.ReplaceAll(strings.ToLower(t), "_", "-") == want {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isNotFound(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeNotFound
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/detector"
+	"github.com/pratik-mahalle/infraudit/internal/domain/baseline"
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+func TestDriftRuleService_Create(t *testing.T) {
+	ruleRepo := testutil.NewMockDriftRuleRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := NewDriftRuleService(ruleRepo, nil, log)
+
+	tests := []struct {
+		name    string
+		rule    *drift.Rule
+		wantErr bool
+	}{
+		{
+			name: "valid custom rule",
+			rule: &drift.Rule{
+				ID:        "custom-tag-owner-removed",
+				Field:     "$.tags.owner",
+				Severity:  drift.SeverityMedium,
+				DriftType: drift.TypeCompliance,
+				Enabled:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "override built-in rule",
+			rule: &drift.Rule{
+				ID:        "ssh-changed",
+				Field:     "$..ssh*",
+				Severity:  drift.SeverityHigh,
+				DriftType: drift.TypeSecurityGroup,
+				Enabled:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "duplicate custom rule",
+			rule: &drift.Rule{
+				ID:        "custom-tag-owner-removed",
+				Field:     "$.tags.owner",
+				Severity:  drift.SeverityLow,
+				DriftType: drift.TypeCompliance,
+				Enabled:   true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid selector",
+			rule: &drift.Rule{
+				ID:        "bad-selector",
+				Field:     "tags.owner",
+				Severity:  drift.SeverityLow,
+				DriftType: drift.TypeCompliance,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid severity",
+			rule: &drift.Rule{
+				ID:        "bad-severity",
+				Field:     "$.tags",
+				Severity:  "urgent",
+				DriftType: drift.TypeCompliance,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(context.Background(), 1, tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDriftRuleService_ListAndGet(t *testing.T) {
+	ruleRepo := testutil.NewMockDriftRuleRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := NewDriftRuleService(ruleRepo, nil, log)
+	ctx := context.Background()
+
+	override := &drift.Rule{
+		ID:        "ssh-changed",
+		Field:     "$..ssh*",
+		Severity:  drift.SeverityHigh,
+		DriftType: drift.TypeSecurityGroup,
+		Enabled:   true,
+	}
+	if _, err := service.Create(ctx, 1, override); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	rules, err := service.List(ctx, 1, drift.RuleFilter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(rules) != len(detector.DefaultRuleSet().Rules()) {
+		t.Errorf("List() returned %d rules, want %d (override replaces built-in)", len(rules), len(detector.DefaultRuleSet().Rules()))
+	}
+
+	custom, _ := service.List(ctx, 1, drift.RuleFilter{Source: drift.RuleSourceCustom})
+	if len(custom) != 1 || custom[0].ID != "ssh-changed" {
+		t.Errorf("List(custom) = %v, want only the override", custom)
+	}
+
+	got, err := service.Get(ctx, 1, "ssh-changed")
+	if err != nil || got.Severity != drift.SeverityHigh {
+		t.Errorf("Get() = %v, %v, want custom override", got, err)
+	}
+
+	// Other users still see the built-in rule
+	got, err = service.Get(ctx, 2, "ssh-changed")
+	if err != nil || !got.Builtin {
+		t.Errorf("Get() for other user = %v, %v, want built-in", got, err)
+	}
+
+	if err := service.Delete(ctx, 2, "ssh-changed"); err == nil {
+		t.Error("Delete() of built-in rule should fail")
+	}
+	if err := service.Delete(ctx, 1, "ssh-changed"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+}
+
+func TestDriftService_DetectDriftsWithCustomRules(t *testing.T) {
+	driftRepo := testutil.NewMockDriftRepository()
+	baselineRepo := testutil.NewMockBaselineRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	ruleRepo := testutil.NewMockDriftRuleRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, ruleRepo, nil, log)
+	ctx := context.Background()
+
+	ruleRepo.Create(ctx, &drift.Rule{
+		ID:            "owner-tag-removed",
+		UserID:        1,
+		ResourceTypes: []string{"s3-bucket"},
+		Field:         "$.tags.owner",
+		ChangeTypes:   []string{drift.ChangeRemoved},
+		Severity:      drift.SeverityHigh,
+		DriftType:     drift.TypeCompliance,
+		Enabled:       true,
+	})
+
+	resourceRepo.Create(ctx, &resource.Resource{
+		UserID:        1,
+		ResourceID:    "bucket-1",
+		Type:          "s3-bucket",
+		Configuration: `{"tags": {}}`,
+	})
+	baselineRepo.Create(ctx, &baseline.Baseline{
+		UserID:        1,
+		ResourceID:    "bucket-1",
+		ResourceType:  "s3-bucket",
+		Configuration: `{"tags": {"owner": "team-a"}}`,
+		BaselineType:  baseline.TypeApproved,
+	})
+
+	if err := service.DetectDrifts(ctx, 1); err != nil {
+		t.Fatalf("DetectDrifts() error = %v", err)
+	}
+
+	drifts, _ := driftRepo.List(ctx, 1, drift.Filter{})
+	if len(drifts) != 1 {
+		t.Fatalf("DetectDrifts() recorded %d drifts, want 1", len(drifts))
+	}
+	if drifts[0].Severity != drift.SeverityHigh || drifts[0].DriftType != drift.TypeCompliance {
+		t.Errorf("drift classified as %s/%s, want %s/%s", drifts[0].Severity, drifts[0].DriftType, drift.SeverityHigh, drift.TypeCompliance)
+	}
+}
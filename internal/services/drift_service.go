@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/pratik-mahalle/infraudit/internal/detector"
 	"github.com/pratik-mahalle/infraudit/internal/domain/baseline"
@@ -15,26 +17,56 @@ type DriftService struct {
 	repo         drift.Repository
 	baselineRepo baseline.Repository
 	resourceRepo resource.Repository
+	ruleRepo     drift.RuleRepository
 	detector     *detector.DriftDetector
 	logger       *logger.Logger
 }
 
-// NewDriftService creates a new drift service
+// NewDriftService creates a new drift service. rules is the base rule set
+// used for classification (nil for the built-in rules); ruleRepo, when set,
+// supplies per-user custom rules layered on top of it.
 func NewDriftService(
 	repo drift.Repository,
 	baselineRepo baseline.Repository,
 	resourceRepo resource.Repository,
+	ruleRepo drift.RuleRepository,
+	rules *detector.RuleSet,
 	log *logger.Logger,
 ) drift.Service {
 	return &DriftService{
 		repo:         repo,
 		baselineRepo: baselineRepo,
 		resourceRepo: resourceRepo,
-		detector:     detector.NewDriftDetector(),
+		ruleRepo:     ruleRepo,
+		detector:     detector.NewDriftDetectorWithRules(rules),
 		logger:       log,
 	}
 }
 
+// detectorFor returns a detector that applies the user's custom rules on top
+// of the base rule set, falling back to the base rules if they can't be loaded
+func (s *DriftService) detectorFor(ctx context.Context, userID int64) *detector.DriftDetector {
+	if s.ruleRepo == nil {
+		return s.detector
+	}
+
+	custom, err := s.ruleRepo.List(ctx, userID)
+	if err != nil {
+		s.logger.ErrorWithErr(err, "Failed to load custom drift rules, using base rules")
+		return s.detector
+	}
+	if len(custom) == 0 {
+		return s.detector
+	}
+
+	rules, err := s.detector.Rules().With(custom)
+	if err != nil {
+		s.logger.ErrorWithErr(err, "Invalid custom drift rules, using base rules")
+		return s.detector
+	}
+	return detector.NewDriftDetectorWithRules(rules)
+}
+
 // Create creates a new drift record
 func (s *DriftService) Create(ctx context.Context, d *drift.Drift) (int64, error) {
 	if d.Status == "" {
@@ -130,6 +162,12 @@ func (s *DriftService) UpdateStatus(ctx context.Context, userID int64, id int64,
 	}
 
 	d.Status = status
+	if status == drift.StatusResolved {
+		now := time.Now()
+		d.ResolvedAt = &now
+	} else {
+		d.ResolvedAt = nil
+	}
 	err = s.repo.Update(ctx, d)
 	if err != nil {
 		s.logger.ErrorWithErr(err, "Failed to update drift status")
@@ -151,8 +189,23 @@ func (s *DriftService) DetectDrifts(ctx context.Context, userID int64) error {
 		"user_id": userID,
 	}).Info("Starting drift detection")
 
+	driftDetector := s.detectorFor(ctx, userID)
+
+	// Open drifts by resource, fetched once so auto-resolution needs no per-resource queries
+	openDrifts, err := s.repo.List(ctx, userID, drift.Filter{Statuses: drift.OpenStatuses})
+	if err != nil {
+		s.logger.ErrorWithErr(err, "Failed to list open drifts")
+		return err
+	}
+	openByResource := make(map[string][]*drift.Drift)
+	for _, d := range openDrifts {
+		openByResource[d.ResourceID] = append(openByResource[d.ResourceID], d)
+	}
+
 	driftsDetected := 0
 	driftsCreated := 0
+	driftsUpdated := 0
+	driftsResolved := 0
 	totalResources := 0
 
 	// Process resources in batches to avoid loading everything into memory
@@ -175,6 +228,12 @@ func (s *DriftService) DetectDrifts(ctx context.Context, userID int64) error {
 
 		// Check each resource against its baseline
 		for _, res := range resources {
+		// A resource that vanished from its provider can no longer drift
+		if res.Status == resource.StatusTerminated {
+			driftsResolved += s.resolveOpenDrifts(ctx, openByResource[res.ResourceID], "")
+			continue
+		}
+
 		// Skip resources without configuration data
 		if res.Configuration == "" {
 			s.logger.WithFields(map[string]interface{}{
@@ -213,31 +272,57 @@ func (s *DriftService) DetectDrifts(ctx context.Context, userID int64) error {
 		}
 
 		// Detect drift by comparing configurations
-		result, err := s.detector.DetectDrift(res.Type, resBaseline.Configuration, res.Configuration)
+		result, err := driftDetector.DetectDrift(res.Type, resBaseline.Configuration, res.Configuration)
 		if err != nil {
 			s.logger.ErrorWithErr(err, "Failed to detect drift")
 			continue
 		}
 
-		if result.HasDrift {
-			driftsDetected++
+		if !result.HasDrift {
+			driftsResolved += s.resolveOpenDrifts(ctx, openByResource[res.ResourceID], "")
+			continue
+		}
+
+		driftsDetected++
+		fingerprint := detector.Fingerprint(res.ResourceID, result.Changes)
 
-			// Create drift record
+		existing, err := s.repo.GetByFingerprint(ctx, userID, fingerprint)
+		if err != nil && !isNotFound(err) {
+			s.logger.ErrorWithErr(err, "Failed to look up existing drift")
+			continue
+		}
+		if existing != nil {
+			// Same drift seen again: refresh the existing record
+			now := time.Now()
+			existing.LastSeenAt = &now
+			existing.OccurrenceCount++
+			existing.DriftType = result.DriftType
+			existing.Severity = result.Severity
+			existing.Details = result.Details
+			existing.Changes = toDriftChanges(result.Changes)
+
+			if err := s.repo.Update(ctx, existing); err != nil {
+				s.logger.ErrorWithErr(err, "Failed to update drift record")
+				continue
+			}
+			driftsUpdated++
+		} else {
 			d := &drift.Drift{
-				UserID:     userID,
-				ResourceID: res.ResourceID,
-				DriftType:  result.DriftType,
-				Severity:   result.Severity,
-				Details:    result.Details,
-				Status:     drift.StatusDetected,
+				UserID:          userID,
+				ResourceID:      res.ResourceID,
+				DriftType:       result.DriftType,
+				Severity:        result.Severity,
+				Details:         result.Details,
+				Status:          drift.StatusDetected,
+				Fingerprint:     fingerprint,
+				OccurrenceCount: 1,
+				Changes:         toDriftChanges(result.Changes),
 			}
 
-			_, err := s.repo.Create(ctx, d)
-			if err != nil {
+			if _, err := s.repo.Create(ctx, d); err != nil {
 				s.logger.ErrorWithErr(err, "Failed to create drift record")
 				continue
 			}
-
 			driftsCreated++
 
 			s.logger.WithFields(map[string]interface{}{
@@ -246,6 +331,9 @@ func (s *DriftService) DetectDrifts(ctx context.Context, userID int64) error {
 				"severity":    result.Severity,
 			}).Info("Drift detected and recorded")
 		}
+
+		// Earlier drifts on this resource describe a state that no longer exists
+		driftsResolved += s.resolveOpenDrifts(ctx, openByResource[res.ResourceID], fingerprint)
 	}
 
 		// Check if we've processed all resources
@@ -261,12 +349,66 @@ func (s *DriftService) DetectDrifts(ctx context.Context, userID int64) error {
 		"resources":       totalResources,
 		"drifts_detected": driftsDetected,
 		"drifts_created":  driftsCreated,
+		"drifts_updated":  driftsUpdated,
+		"drifts_resolved": driftsResolved,
 	}).Info("Drift detection completed")
 
 	return nil
 }
 
+// toDriftChanges converts detector changes to the persisted drift form
+func toDriftChanges(changes []detector.ConfigChange) []drift.Change {
+	out := make([]drift.Change, len(changes))
+	for i, c := range changes {
+		out[i] = drift.Change{
+			Path:       c.Path,
+			Field:      c.Field,
+			OldValue:   c.OldValue,
+			NewValue:   c.NewValue,
+			ChangeType: c.ChangeType,
+			RuleID:     c.RuleID,
+			Severity:   c.Severity,
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// resolveOpenDrifts marks the given open drifts of a resource as resolved,
+// except the one with keepFingerprint, and returns how many were resolved
+func (s *DriftService) resolveOpenDrifts(ctx context.Context, open []*drift.Drift, keepFingerprint string) int {
+	resolved := 0
+	now := time.Now()
+	for _, d := range open {
+		if keepFingerprint != "" && d.Fingerprint == keepFingerprint {
+			continue
+		}
+
+		d.Status = drift.StatusResolved
+		d.ResolvedAt = &now
+		if err := s.repo.Update(ctx, d); err != nil {
+			s.logger.ErrorWithErr(err, "Failed to resolve drift")
+			continue
+		}
+		resolved++
+
+		s.logger.WithFields(map[string]interface{}{
+			"drift_id":    d.ID,
+			"resource_id": d.ResourceID,
+		}).Info("Drift automatically resolved")
+	}
+
+	return resolved
+}
+
 // GetSummary gets drift summary by severity
 func (s *DriftService) GetSummary(ctx context.Context, userID int64) (map[string]int, error) {
 	return s.repo.CountBySeverity(ctx, userID)
 }
+
+// GetOpenSummary counts open drifts by severity
+func (s *DriftService) GetOpenSummary(ctx context.Context, userID int64) (map[string]int, error) {
+	return s.repo.CountBySeverity(ctx, userID, drift.OpenStatuses...)
+}
@@ -16,7 +16,7 @@ func TestDriftService_Create(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	tests := []struct {
 		name    string
@@ -90,7 +90,7 @@ func TestDriftService_GetByID(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	d := &drift.Drift{
@@ -150,7 +150,7 @@ func TestDriftService_Update(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	d := &drift.Drift{
@@ -246,7 +246,7 @@ func TestDriftService_UpdateStatus(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	d := &drift.Drift{
@@ -302,7 +302,7 @@ func TestDriftService_Delete(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	d := &drift.Drift{
@@ -331,7 +331,7 @@ func TestDriftService_List(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 
@@ -368,7 +368,7 @@ func TestDriftService_GetSummary(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 
@@ -403,12 +403,36 @@ func TestDriftService_GetSummary(t *testing.T) {
 	}
 }
 
+func TestDriftService_GetOpenSummary(t *testing.T) {
+	driftRepo := testutil.NewMockDriftRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := NewDriftService(driftRepo, testutil.NewMockBaselineRepository(), testutil.NewMockResourceRepository(), nil, nil, log)
+	ctx := context.Background()
+
+	for _, d := range []*drift.Drift{
+		{UserID: 1, Severity: drift.SeverityCritical, Status: drift.StatusDetected},
+		{UserID: 1, Severity: drift.SeverityCritical, Status: drift.StatusAcknowledged},
+		{UserID: 1, Severity: drift.SeverityCritical, Status: drift.StatusResolved},
+		{UserID: 1, Severity: drift.SeverityHigh, Status: drift.StatusIgnored},
+	} {
+		service.Create(ctx, d)
+	}
+
+	summary, err := service.GetOpenSummary(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetOpenSummary() error = %v", err)
+	}
+	if summary[drift.SeverityCritical] != 2 || summary[drift.SeverityHigh] != 0 {
+		t.Errorf("GetOpenSummary() = %v, want 2 critical and no high", summary)
+	}
+}
+
 func TestDriftService_DetectDrifts(t *testing.T) {
 	driftRepo := testutil.NewMockDriftRepository()
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -459,7 +483,7 @@ func TestDriftService_DetectDrifts_NoBaseline(t *testing.T) {
 	baselineRepo := testutil.NewMockBaselineRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -492,3 +516,75 @@ func TestDriftService_DetectDrifts_NoBaseline(t *testing.T) {
 		}
 	}
 }
+
+func TestDriftService_DetectDrifts_Lifecycle(t *testing.T) {
+	driftRepo := testutil.NewMockDriftRepository()
+	baselineRepo := testutil.NewMockBaselineRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	res := &resource.Resource{
+		UserID:        userID,
+		ResourceID:    "bucket-1",
+		Type:          "s3-bucket",
+		Configuration: `{"encryption": {"enabled": false}}`,
+	}
+	resourceRepo.Create(ctx, res)
+	baselineRepo.Create(ctx, &baseline.Baseline{
+		UserID:        userID,
+		ResourceID:    "bucket-1",
+		ResourceType:  "s3-bucket",
+		Configuration: `{"encryption": {"enabled": true}}`,
+		BaselineType:  baseline.TypeApproved,
+	})
+
+	// Repeated runs on the same drifted state update a single record
+	for i := 0; i < 3; i++ {
+		if err := service.DetectDrifts(ctx, userID); err != nil {
+			t.Fatalf("DetectDrifts() error = %v", err)
+		}
+	}
+
+	drifts, _ := driftRepo.List(ctx, userID, drift.Filter{})
+	if len(drifts) != 1 {
+		t.Fatalf("DetectDrifts() recorded %d drifts, want 1", len(drifts))
+	}
+	if drifts[0].OccurrenceCount != 3 {
+		t.Errorf("OccurrenceCount = %d, want 3", drifts[0].OccurrenceCount)
+	}
+	if drifts[0].LastSeenAt == nil || drifts[0].Fingerprint == "" {
+		t.Error("DetectDrifts() did not set LastSeenAt and Fingerprint")
+	}
+	if len(drifts[0].Changes) != 1 {
+		t.Fatalf("DetectDrifts() stored %d changes, want 1", len(drifts[0].Changes))
+	}
+	if c := drifts[0].Changes[0]; c.Path != "encryption.enabled" || c.OldValue != true || c.NewValue != false || c.RuleID == "" {
+		t.Errorf("stored change = %+v, want encryption.enabled true -> false with a rule ID", c)
+	}
+
+	// Back at baseline: the drift is resolved
+	res.Configuration = `{"encryption": {"enabled": true}}`
+	if err := service.DetectDrifts(ctx, userID); err != nil {
+		t.Fatalf("DetectDrifts() error = %v", err)
+	}
+
+	resolved, _ := driftRepo.GetByID(ctx, userID, drifts[0].ID)
+	if resolved.Status != drift.StatusResolved || resolved.ResolvedAt == nil {
+		t.Errorf("drift status = %s, want %s with ResolvedAt set", resolved.Status, drift.StatusResolved)
+	}
+
+	// Regression after resolution opens a new drift
+	res.Configuration = `{"encryption": {"enabled": false}}`
+	if err := service.DetectDrifts(ctx, userID); err != nil {
+		t.Fatalf("DetectDrifts() error = %v", err)
+	}
+
+	drifts, _ = driftRepo.List(ctx, userID, drift.Filter{Status: drift.StatusDetected})
+	if len(drifts) != 1 || drifts[0].OccurrenceCount != 1 {
+		t.Errorf("expected one new open drift after regression, got %d", len(drifts))
+	}
+}
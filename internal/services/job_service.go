@@ -3,24 +3,89 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
 	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/iac"
 	"github.com/pratik-mahalle/infraudit/internal/domain/job"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/recommendation"
+	"github.com/pratik-mahalle/infraudit/internal/domain/user"
+	"github.com/pratik-mahalle/infraudit/internal/domain/vulnerability"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 	"github.com/robfig/cron/v3"
 )
 
+// IaCDriftScanner is the part of the IaC service used by scheduled IaC scans
+type IaCDriftScanner interface {
+	ListDefinitions(ctx context.Context, userID string, iacType *iac.IaCType) ([]*iac.IaCDefinition, error)
+	DetectDrift(ctx context.Context, userID, definitionID string) ([]*iac.IaCDriftResult, error)
+}
+
+// JobUserLister lists the users that get the default scheduled jobs
+type JobUserLister interface {
+	List(ctx context.Context, limit, offset int) ([]*user.User, int64, error)
+}
+
+// defaultJobSeedSchedule is how often users without default jobs are picked up
+// while the scheduler runs
+const defaultJobSeedSchedule = "@hourly"
+
+// JobExecutionPolicy controls how long job attempts may run and how failed
+// attempts are retried
+type JobExecutionPolicy struct {
+	// DefaultTimeout applies to jobs whose config sets no timeout; zero means no limit
+	DefaultTimeout time.Duration
+	// RetryBaseDelay is the delay before the first retry; it doubles for each further retry
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the delay between retries
+	RetryMaxDelay time.Duration
+	// StopGracePeriod is how long a cancelled or timed-out attempt may take
+	// to return before it is considered stuck
+	StopGracePeriod time.Duration
+}
+
+// errAttemptStuck marks an attempt that kept running after it was told to
+// stop. Such attempts are never retried, so two runs never overlap.
+var errAttemptStuck = errors.New("job did not stop after cancellation")
+
+// DefaultJobExecutionPolicy returns the execution policy used unless one is set
+func DefaultJobExecutionPolicy() JobExecutionPolicy {
+	return JobExecutionPolicy{
+		DefaultTimeout:  time.Hour,
+		RetryBaseDelay:  30 * time.Second,
+		RetryMaxDelay:   10 * time.Minute,
+		StopGracePeriod: 30 * time.Second,
+	}
+}
+
 // JobService implements job.Service
 type JobService struct {
-	repo            job.Repository
-	driftService    drift.Service
-	providerService provider.Service
-	logger          *logger.Logger
+	repo                  job.Repository
+	driftService          drift.Service
+	providerService       provider.Service
+	vulnService           vulnerability.Service
+	costService           cost.Service
+	iacService            IaCDriftScanner
+	complianceService     compliance.Service
+	recommendationService recommendation.Service
+	logger                *logger.Logger
+	policy                JobExecutionPolicy
+	users                 JobUserLister
+	elector               *LeaderElector
+
+	// cancel functions of executions running in this process, by execution ID
+	running      map[string]context.CancelFunc
+	runningExecs sync.Mutex
+	// stuck attempts still running after being told to stop, by stuckKey
+	stuck map[string]int
 
 	scheduler    *cron.Cron
 	cronEntries  map[string]cron.EntryID
@@ -34,17 +99,48 @@ func NewJobService(
 	repo job.Repository,
 	driftService drift.Service,
 	providerService provider.Service,
+	vulnService vulnerability.Service,
+	costService cost.Service,
+	iacService IaCDriftScanner,
+	complianceService compliance.Service,
+	recommendationService recommendation.Service,
 	log *logger.Logger,
 ) job.Service {
 	return &JobService{
-		repo:            repo,
-		driftService:    driftService,
-		providerService: providerService,
-		logger:          log,
-		cronEntries:     make(map[string]cron.EntryID),
+		repo:                  repo,
+		driftService:          driftService,
+		providerService:       providerService,
+		vulnService:           vulnService,
+		costService:           costService,
+		iacService:            iacService,
+		complianceService:     complianceService,
+		recommendationService: recommendationService,
+		logger:                log,
+		policy:                DefaultJobExecutionPolicy(),
+		running:               make(map[string]context.CancelFunc),
+		stuck:                 make(map[string]int),
+		cronEntries:           make(map[string]cron.EntryID),
 	}
 }
 
+// SetExecutionPolicy sets the timeout and retry policy for job executions
+func (s *JobService) SetExecutionPolicy(policy JobExecutionPolicy) {
+	s.policy = policy
+}
+
+// SetLeaderElector makes scheduled jobs run only while this node is the
+// elected leader, so several replicas can share one database. Without an
+// elector every scheduled job runs on this node.
+func (s *JobService) SetLeaderElector(elector *LeaderElector) {
+	s.elector = elector
+}
+
+// SetUserLister enables seeding of the default jobs (job.DefaultSchedules)
+// for every user when the scheduler starts and hourly while it runs
+func (s *JobService) SetUserLister(users JobUserLister) {
+	s.users = users
+}
+
 // CreateJob creates a new scheduled job
 func (s *JobService) CreateJob(ctx context.Context, userID int64, jobType job.JobType, schedule string, config *job.JobConfig) (*job.ScheduledJob, error) {
 	// Validate job type
@@ -234,7 +330,8 @@ func (s *JobService) ListExecutions(ctx context.Context, filter job.ExecutionFil
 	return s.repo.ListExecutions(ctx, filter, limit, offset)
 }
 
-// CancelExecution cancels a running execution
+// CancelExecution cancels a running execution. If the execution runs in
+// this process its context is cancelled, which aborts the work in progress.
 func (s *JobService) CancelExecution(ctx context.Context, id string) error {
 	e, err := s.repo.GetExecution(ctx, id)
 	if err != nil {
@@ -245,6 +342,13 @@ func (s *JobService) CancelExecution(ctx context.Context, id string) error {
 		return fmt.Errorf("execution is already completed")
 	}
 
+	s.runningExecs.Lock()
+	cancel, ok := s.running[id]
+	s.runningExecs.Unlock()
+	if ok {
+		cancel()
+	}
+
 	e.Status = job.ExecutionStatusCancelled
 	now := time.Now()
 	e.CompletedAt = &now
@@ -264,7 +368,23 @@ func (s *JobService) Start(ctx context.Context) error {
 		return fmt.Errorf("scheduler is already running")
 	}
 
-	s.scheduler = cron.New(cron.WithSeconds())
+	// Schedules are standard 5-field cron expressions (see job.DefaultSchedules)
+	s.scheduler = cron.New()
+
+	// Seeded jobs are enabled, so they are picked up with the others below
+	if s.users != nil {
+		s.seedAllUsers(ctx)
+		if _, err := s.scheduler.AddFunc(defaultJobSeedSchedule, func() {
+			if !s.isLeader() {
+				return
+			}
+			for _, j := range s.seedAllUsers(context.Background()) {
+				s.scheduleJob(j)
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to schedule default job seeding: %w", err)
+		}
+	}
 
 	// Load all enabled jobs
 	jobs, err := s.repo.GetEnabledJobs(ctx)
@@ -298,6 +418,13 @@ func (s *JobService) Stop() error {
 	s.scheduler.Stop()
 	s.isRunning = false
 
+	// Abort executions still in flight so shutdown is not held up by them
+	s.runningExecs.Lock()
+	for _, cancel := range s.running {
+		cancel()
+	}
+	s.runningExecs.Unlock()
+
 	s.entriesMutex.Lock()
 	s.cronEntries = make(map[string]cron.EntryID)
 	s.entriesMutex.Unlock()
@@ -314,19 +441,41 @@ func (s *JobService) IsRunning() bool {
 	return s.isRunning
 }
 
+// SchedulerStatus reports this node's scheduler state and the leases held
+// by all nodes
+func (s *JobService) SchedulerStatus(ctx context.Context) (*job.SchedulerStatus, error) {
+	leases, err := s.repo.ListLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leases == nil {
+		leases = []*job.Lease{}
+	}
+
+	status := &job.SchedulerStatus{
+		NodeID:    DefaultNodeID(),
+		IsRunning: s.IsRunning(),
+		IsLeader:  s.isLeader(),
+		Leases:    leases,
+	}
+	if s.elector != nil {
+		status.NodeID = s.elector.NodeID()
+	}
+	return status, nil
+}
+
+// isLeader reports whether this node should run scheduled work
+func (s *JobService) isLeader() bool {
+	return s.elector == nil || s.elector.IsLeader()
+}
+
 // scheduleJob adds a job to the cron scheduler
 func (s *JobService) scheduleJob(j *job.ScheduledJob) {
 	s.entriesMutex.Lock()
 	defer s.entriesMutex.Unlock()
 
 	entryID, err := s.scheduler.AddFunc(j.Schedule, func() {
-		ctx := context.Background()
-		if _, err := s.executeJob(ctx, j); err != nil {
-			s.logger.WithFields(map[string]interface{}{
-				"job_id":   j.ID,
-				"job_type": j.JobType,
-			}).ErrorWithErr(err, "Failed to execute scheduled job")
-		}
+		s.runScheduledJob(j)
 	})
 
 	if err != nil {
@@ -346,6 +495,94 @@ func (s *JobService) scheduleJob(j *job.ScheduledJob) {
 	}).Info("Job scheduled")
 }
 
+// seedAllUsers creates the missing default jobs of every user and returns
+// the jobs it created
+func (s *JobService) seedAllUsers(ctx context.Context) []*job.ScheduledJob {
+	const pageSize = 100
+	var created []*job.ScheduledJob
+
+	for offset := 0; ; {
+		users, total, err := s.users.List(ctx, pageSize, offset)
+		if err != nil {
+			s.logger.ErrorWithErr(err, "Failed to list users for default jobs")
+			return created
+		}
+		for _, u := range users {
+			jobs, err := s.seedDefaultJobs(ctx, u.ID)
+			if err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"user_id": u.ID,
+				}).ErrorWithErr(err, "Failed to create default jobs")
+			}
+			created = append(created, jobs...)
+		}
+
+		offset += len(users)
+		if len(users) == 0 || int64(offset) >= total {
+			break
+		}
+	}
+
+	if len(created) > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"jobs_created": len(created),
+		}).Info("Default jobs created")
+	}
+	return created
+}
+
+// seedDefaultJobs creates the default jobs a user does not have yet. Existing
+// jobs are left alone, disabled ones included, so disabling a default job is
+// how a user opts out of it; a deleted default job is recreated.
+func (s *JobService) seedDefaultJobs(ctx context.Context, userID int64) ([]*job.ScheduledJob, error) {
+	jobTypes := make([]job.JobType, 0, len(job.DefaultSchedules))
+	for jobType := range job.DefaultSchedules {
+		jobTypes = append(jobTypes, jobType)
+	}
+	sort.Slice(jobTypes, func(i, k int) bool { return jobTypes[i] < jobTypes[k] })
+
+	var created []*job.ScheduledJob
+	for _, jobType := range jobTypes {
+		existing, err := s.repo.GetJobByUserAndType(ctx, userID, jobType)
+		if err != nil {
+			return created, err
+		}
+		if existing != nil {
+			continue
+		}
+
+		schedule := job.DefaultSchedules[jobType]
+		j := &job.ScheduledJob{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			JobType:   jobType,
+			Schedule:  schedule,
+			IsEnabled: true,
+			NextRun:   s.calculateNextRun(schedule),
+		}
+		if err := s.repo.CreateJob(ctx, j); err != nil {
+			return created, err
+		}
+		created = append(created, j)
+	}
+	return created, nil
+}
+
+// runScheduledJob executes a job when its schedule fires. Every replica's
+// scheduler fires, but only the leader runs the job.
+func (s *JobService) runScheduledJob(j *job.ScheduledJob) {
+	if !s.isLeader() {
+		return
+	}
+
+	if _, err := s.executeJob(context.Background(), j); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"job_id":   j.ID,
+			"job_type": j.JobType,
+		}).ErrorWithErr(err, "Failed to execute scheduled job")
+	}
+}
+
 // unscheduleJob removes a job from the cron scheduler
 func (s *JobService) unscheduleJob(jobID string) {
 	s.entriesMutex.Lock()
@@ -361,8 +598,13 @@ func (s *JobService) unscheduleJob(jobID string) {
 	}
 }
 
-// executeJob executes a job and records the execution
+// executeJob records an execution and runs the job in the background. The
+// execution gets its own context so it can be cancelled and timed out.
 func (s *JobService) executeJob(ctx context.Context, j *job.ScheduledJob) (*job.JobExecution, error) {
+	if s.isStuck(j) {
+		return nil, fmt.Errorf("a previous %s run for this user has not stopped yet", j.JobType)
+	}
+
 	now := time.Now()
 
 	execution := &job.JobExecution{
@@ -384,94 +626,364 @@ func (s *JobService) executeJob(ctx context.Context, j *job.ScheduledJob) (*job.
 		"job_type":     j.JobType,
 	}).Info("Job execution started")
 
-	// Execute the job in a goroutine
+	execCtx, cancel := context.WithCancel(context.Background())
+	s.runningExecs.Lock()
+	s.running[execution.ID] = cancel
+	s.runningExecs.Unlock()
+
+	// The goroutine owns execution from here on; callers get a copy
+	started := *execution
+
 	go func() {
-		execCtx := context.Background()
-		result, err := s.runJobLogic(execCtx, j)
+		defer func() {
+			s.runningExecs.Lock()
+			delete(s.running, execution.ID)
+			s.runningExecs.Unlock()
+			cancel()
+		}()
+		stopHeartbeat := s.heartbeat(execution.ID, cancel)
+		defer stopHeartbeat()
+		s.runExecution(execCtx, j, execution)
+	}()
 
-		completedAt := time.Now()
-		execution.CompletedAt = &completedAt
-		execution.DurationMs = int(completedAt.Sub(now).Milliseconds())
+	return &started, nil
+}
 
-		if err != nil {
-			execution.Status = job.ExecutionStatusFailed
-			execution.ErrorMessage = err.Error()
+// heartbeat holds the execution's lease while it runs, so the scheduler
+// status shows which node runs it, and cancels the execution when it is
+// cancelled through another node. It does nothing without a leader elector.
+func (s *JobService) heartbeat(executionID string, cancel context.CancelFunc) (stop func()) {
+	if s.elector == nil {
+		return func() {}
+	}
+
+	ctx := context.Background()
+	name := job.ExecutionLeaseName(executionID)
+	holder := s.elector.NodeID()
+	ttl := s.elector.ttl
+	renew := func() {
+		if _, err := s.repo.AcquireLease(ctx, name, holder, ttl); err != nil {
 			s.logger.WithFields(map[string]interface{}{
-				"job_id":       j.ID,
-				"execution_id": execution.ID,
-				"error":        err.Error(),
-			}).ErrorWithErr(err, "Job execution failed")
-		} else {
-			execution.Status = job.ExecutionStatusCompleted
-			if result != nil {
-				resultJSON, _ := json.Marshal(result)
-				execution.Result = resultJSON
+				"execution_id": executionID,
+			}).ErrorWithErr(err, "Failed to renew execution lease")
+		}
+	}
+	renew()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renew()
+				if e, err := s.repo.GetExecution(ctx, executionID); err == nil && e.Status == job.ExecutionStatusCancelled {
+					cancel()
+				}
 			}
-			s.logger.WithFields(map[string]interface{}{
-				"job_id":       j.ID,
-				"execution_id": execution.ID,
-				"duration_ms":  execution.DurationMs,
-			}).Info("Job execution completed")
 		}
+	}()
 
-		s.repo.UpdateExecution(execCtx, execution)
+	return func() {
+		close(done)
+		<-stopped
+		if err := s.repo.ReleaseLease(ctx, name, holder); err != nil {
+			s.logger.ErrorWithErr(err, "Failed to release execution lease")
+		}
+	}
+}
+
+// runExecution runs the job, retrying failed attempts with exponential
+// backoff, and records the outcome. Cancelling ctx aborts the current
+// attempt and any remaining retries.
+func (s *JobService) runExecution(ctx context.Context, j *job.ScheduledJob, execution *job.JobExecution) {
+	config := &job.JobConfig{}
+	if len(j.Config) > 0 {
+		// An invalid config is reported by runJobLogic
+		_ = json.Unmarshal(j.Config, config)
+	}
+
+	timeout := s.policy.DefaultTimeout
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
 
-		// Update last run time
-		nextRun := s.calculateNextRun(j.Schedule)
-		s.repo.UpdateLastRun(execCtx, j.ID, completedAt, nextRun)
+	var (
+		result   *job.JobResult
+		err      error
+		attempts []job.ExecutionAttempt
+	)
+	for attempt := 1; ; attempt++ {
+		attemptStart := time.Now()
+		result, err = s.runAttempt(ctx, j, timeout)
+
+		record := job.ExecutionAttempt{
+			Attempt:    attempt,
+			StartedAt:  attemptStart,
+			DurationMs: int(time.Since(attemptStart).Milliseconds()),
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		attempts = append(attempts, record)
+
+		if err == nil || ctx.Err() != nil || errors.Is(err, errAttemptStuck) || attempt > config.RetryCount {
+			break
+		}
+
+		delay := s.retryDelay(attempt)
+		execution.RetryCount = attempt
+		execution.ErrorMessage = err.Error()
+		s.repo.UpdateExecution(context.Background(), execution)
+
+		s.logger.WithFields(map[string]interface{}{
+			"job_id":       j.ID,
+			"execution_id": execution.ID,
+			"attempt":      attempt,
+			"retry_in":     delay.String(),
+			"error":        err.Error(),
+		}).Warn("Job attempt failed, retrying")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	completedAt := time.Now()
+	execution.CompletedAt = &completedAt
+	execution.DurationMs = int(completedAt.Sub(*execution.StartedAt).Milliseconds())
+	execution.RetryCount = len(attempts) - 1
+
+	if result == nil {
+		result = &job.JobResult{ErrorCount: 1}
+	}
+	result.Attempts = attempts
+	resultJSON, _ := json.Marshal(result)
+	execution.Result = resultJSON
+
+	switch {
+	case ctx.Err() != nil:
+		execution.Status = job.ExecutionStatusCancelled
+		execution.ErrorMessage = "execution cancelled"
+		s.logger.WithFields(map[string]interface{}{
+			"job_id":       j.ID,
+			"execution_id": execution.ID,
+		}).Info("Job execution cancelled")
+	case err != nil:
+		execution.Status = job.ExecutionStatusFailed
+		execution.ErrorMessage = err.Error()
+		s.logger.WithFields(map[string]interface{}{
+			"job_id":       j.ID,
+			"execution_id": execution.ID,
+			"attempts":     len(attempts),
+			"error":        err.Error(),
+		}).ErrorWithErr(err, "Job execution failed")
+	default:
+		execution.Status = job.ExecutionStatusCompleted
+		execution.ErrorMessage = ""
+		s.logger.WithFields(map[string]interface{}{
+			"job_id":       j.ID,
+			"execution_id": execution.ID,
+			"attempts":     len(attempts),
+			"duration_ms":  execution.DurationMs,
+		}).Info("Job execution completed")
+	}
+
+	// Persist with a fresh context; ctx may already be cancelled
+	persistCtx := context.Background()
+	s.repo.UpdateExecution(persistCtx, execution)
+
+	// Update last run time
+	nextRun := s.calculateNextRun(j.Schedule)
+	s.repo.UpdateLastRun(persistCtx, j.ID, completedAt, nextRun)
+}
+
+// attemptOutcome is what one run of the job logic returned
+type attemptOutcome struct {
+	result *job.JobResult
+	err    error
+}
+
+// runAttempt runs the job logic once. When ctx is cancelled or the timeout
+// elapses it waits up to the stop grace period for the job logic to return;
+// if it does not, the attempt is marked stuck and errAttemptStuck is returned.
+func (s *JobService) runAttempt(ctx context.Context, j *job.ScheduledJob, timeout time.Duration) (*job.JobResult, error) {
+	attemptCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan attemptOutcome, 1)
+	go func() {
+		result, err := s.runJobLogic(attemptCtx, j)
+		done <- attemptOutcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-attemptCtx.Done():
+	}
+
+	grace := time.NewTimer(s.policy.StopGracePeriod)
+	defer grace.Stop()
+	select {
+	case out := <-done:
+		if out.err == nil {
+			return out.result, nil
+		}
+	case <-grace.C:
+		s.markStuck(j, done)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w within %s", errAttemptStuck, s.policy.StopGracePeriod)
+		}
+		return nil, fmt.Errorf("job timed out after %s and %w within %s", timeout, errAttemptStuck, s.policy.StopGracePeriod)
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, fmt.Errorf("job timed out after %s", timeout)
+}
+
+// stuckKey identifies the work a job does, so that a one-off run and a
+// scheduled run of the same type for the same user count as the same work
+func stuckKey(j *job.ScheduledJob) string {
+	return fmt.Sprintf("%d/%s", j.UserID, j.JobType)
+}
+
+// markStuck records a stuck attempt until its job logic finally returns
+func (s *JobService) markStuck(j *job.ScheduledJob, done <-chan attemptOutcome) {
+	key := stuckKey(j)
+	s.runningExecs.Lock()
+	s.stuck[key]++
+	s.runningExecs.Unlock()
+
+	s.logger.WithFields(map[string]interface{}{
+		"job_id":   j.ID,
+		"job_type": j.JobType,
+		"user_id":  j.UserID,
+	}).Warn("Job attempt did not stop after cancellation")
+
+	go func() {
+		<-done
+		s.runningExecs.Lock()
+		if s.stuck[key]--; s.stuck[key] <= 0 {
+			delete(s.stuck, key)
+		}
+		s.runningExecs.Unlock()
 	}()
+}
 
-	return execution, nil
+// isStuck reports whether an earlier attempt of the same work is still running
+func (s *JobService) isStuck(j *job.ScheduledJob) bool {
+	s.runningExecs.Lock()
+	defer s.runningExecs.Unlock()
+	return s.stuck[stuckKey(j)] > 0
+}
+
+// retryDelay returns the backoff before retrying after the given attempt
+func (s *JobService) retryDelay(attempt int) time.Duration {
+	delay := s.policy.RetryBaseDelay
+	for i := 1; i < attempt && (s.policy.RetryMaxDelay == 0 || delay < s.policy.RetryMaxDelay); i++ {
+		delay *= 2
+	}
+	if s.policy.RetryMaxDelay > 0 && delay > s.policy.RetryMaxDelay {
+		delay = s.policy.RetryMaxDelay
+	}
+	return delay
 }
 
 // runJobLogic executes the actual job logic
 func (s *JobService) runJobLogic(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
-	result := &job.JobResult{
-		Success: true,
-		Details: make(map[string]interface{}),
+	config := &job.JobConfig{}
+	if len(j.Config) > 0 {
+		if err := json.Unmarshal(j.Config, config); err != nil {
+			return nil, fmt.Errorf("invalid job config: %w", err)
+		}
 	}
 
 	switch j.JobType {
 	case job.JobTypeResourceSync:
-		return s.runResourceSyncJob(ctx, j)
+		return s.runResourceSyncJob(ctx, j, config)
 	case job.JobTypeDriftDetection:
 		return s.runDriftDetectionJob(ctx, j)
 	case job.JobTypeVulnerabilityScan:
-		return s.runVulnerabilityScanJob(ctx, j)
-	case job.JobTypeAnomalyDetection:
-		return s.runAnomalyDetectionJob(ctx, j)
+		return s.runVulnerabilityScanJob(ctx, j, config)
+	case job.JobTypeCostSync:
+		return s.runCostSyncJob(ctx, j)
+	case job.JobTypeIaCScan:
+		return s.runIaCScanJob(ctx, j, config)
+	case job.JobTypeComplianceAssessment:
+		return s.runComplianceAssessmentJob(ctx, j, config)
 	case job.JobTypeRecommendation:
 		return s.runRecommendationJob(ctx, j)
+	case job.JobTypeAnomalyDetection:
+		return s.runAnomalyDetectionJob(ctx, j, config)
 	default:
-		return result, nil
+		return nil, fmt.Errorf("unsupported job type: %s", j.JobType)
 	}
 }
 
+// connectedProviders returns the provider types with a connected account,
+// limited to the providers named in the job config when it lists any. Each
+// type is returned once however many accounts it has; the per-provider
+// services work through all of its accounts.
+func (s *JobService) connectedProviders(ctx context.Context, userID int64, config *job.JobConfig) ([]string, error) {
+	providers, err := s.providerService.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+
+	var names []string
+	for _, p := range providers {
+		if !p.IsConnected || containsString(names, p.Provider) {
+			continue
+		}
+		if len(config.Providers) > 0 && !containsString(config.Providers, p.Provider) {
+			continue
+		}
+		names = append(names, p.Provider)
+	}
+	return names, nil
+}
+
 // runResourceSyncJob syncs resources from cloud providers
-func (s *JobService) runResourceSyncJob(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
+func (s *JobService) runResourceSyncJob(ctx context.Context, j *job.ScheduledJob, config *job.JobConfig) (*job.JobResult, error) {
 	result := &job.JobResult{
 		Success: true,
 		Details: make(map[string]interface{}),
 	}
 
-	// Get providers to sync
-	providers, err := s.providerService.List(ctx, j.UserID)
+	providers, err := s.connectedProviders(ctx, j.UserID, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list providers: %w", err)
+		return nil, err
 	}
 
 	syncedCount := 0
 	errorCount := 0
 
 	for _, p := range providers {
-		if !p.IsConnected {
-			continue
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-
-		if err := s.providerService.Sync(ctx, j.UserID, p.Provider); err != nil {
+		if err := s.providerService.Sync(ctx, j.UserID, p); err != nil {
 			s.logger.WithFields(map[string]interface{}{
 				"user_id":  j.UserID,
-				"provider": p.Provider,
+				"provider": p,
 			}).ErrorWithErr(err, "Failed to sync provider")
 			errorCount++
 		} else {
@@ -481,6 +993,7 @@ func (s *JobService) runResourceSyncJob(ctx context.Context, j *job.ScheduledJob
 
 	result.ItemsScanned = syncedCount
 	result.ErrorCount = errorCount
+	result.Success = errorCount == 0
 	result.Details["providers_synced"] = syncedCount
 	result.Details["providers_failed"] = errorCount
 
@@ -498,49 +1011,300 @@ func (s *JobService) runDriftDetectionJob(ctx context.Context, j *job.ScheduledJ
 		return nil, fmt.Errorf("drift detection failed: %w", err)
 	}
 
-	// Get drift summary
-	drifts, _, err := s.driftService.List(ctx, j.UserID, drift.Filter{}, 1000, 0)
-	if err == nil {
-		result.DriftsFound = len(drifts)
-		result.Details["drifts_detected"] = len(drifts)
+	bySeverity, err := s.driftService.GetOpenSummary(ctx, j.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open drifts: %w", err)
 	}
+	for _, count := range bySeverity {
+		result.DriftsFound += count
+	}
+	result.IssuesFound = result.DriftsFound
+	result.Details["drifts_detected"] = result.DriftsFound
+	result.Details["by_severity"] = bySeverity
 
 	return result, nil
 }
 
-// runVulnerabilityScanJob runs vulnerability scanning
-func (s *JobService) runVulnerabilityScanJob(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
-	// This would integrate with the vulnerability service
-	return &job.JobResult{
+// runVulnerabilityScanJob runs cloud-native scans for each connected provider
+// and Trivy scans for any container images listed in the job options
+func (s *JobService) runVulnerabilityScanJob(ctx context.Context, j *job.ScheduledJob, config *job.JobConfig) (*job.JobResult, error) {
+	result := &job.JobResult{
 		Success: true,
-		Details: map[string]interface{}{
-			"message": "Vulnerability scan completed",
-		},
-	}, nil
+		Details: make(map[string]interface{}),
+	}
+
+	providers, err := s.connectedProviders(ctx, j.UserID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.vulnService.ScanWithCloudNative(ctx, j.UserID, p, ""); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id":  j.UserID,
+				"provider": p,
+			}).ErrorWithErr(err, "Failed to run cloud-native scan")
+			result.ErrorCount++
+			continue
+		}
+		result.ItemsScanned++
+	}
+
+	for _, image := range optionStrings(config, "images") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.vulnService.ScanWithTrivy(ctx, j.UserID, image, image); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id": j.UserID,
+				"image":   image,
+			}).ErrorWithErr(err, "Failed to run Trivy scan")
+			result.ErrorCount++
+			continue
+		}
+		result.ItemsScanned++
+	}
+
+	if _, open, err := s.vulnService.List(ctx, j.UserID, vulnerability.Filter{Status: vulnerability.StatusOpen}, 1, 0); err == nil {
+		result.IssuesFound = int(open)
+	}
+
+	result.Success = result.ErrorCount == 0
+	result.Details["targets_scanned"] = result.ItemsScanned
+	result.Details["targets_failed"] = result.ErrorCount
+	result.Details["open_vulnerabilities"] = result.IssuesFound
+
+	return result, nil
 }
 
-// runAnomalyDetectionJob runs anomaly detection
-func (s *JobService) runAnomalyDetectionJob(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
-	// This would integrate with the anomaly service
+// runCostSyncJob syncs cost data from every connected provider
+func (s *JobService) runCostSyncJob(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
+	sync, err := s.costService.SyncAllProviders(ctx, j.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cost sync failed: %w", err)
+	}
+
 	return &job.JobResult{
-		Success: true,
+		Success:      len(sync.Errors) == 0,
+		ItemsScanned: sync.RecordsSynced,
+		ErrorCount:   len(sync.Errors),
 		Details: map[string]interface{}{
-			"message": "Anomaly detection completed",
+			"providers_synced":  sync.ProvidersSynced,
+			"providers_skipped": sync.ProvidersSkipped,
+			"records_synced":    sync.RecordsSynced,
+			"errors":            sync.Errors,
 		},
 	}, nil
 }
 
+// runIaCScanJob compares IaC definitions with deployed resources. It scans
+// the definition named in the job config, or every definition the user has.
+func (s *JobService) runIaCScanJob(ctx context.Context, j *job.ScheduledJob, config *job.JobConfig) (*job.JobResult, error) {
+	result := &job.JobResult{
+		Success: true,
+		Details: make(map[string]interface{}),
+	}
+	userID := fmt.Sprintf("%d", j.UserID)
+
+	definitionIDs := []string{}
+	if config.IaCDefinitionID != "" {
+		definitionIDs = append(definitionIDs, config.IaCDefinitionID)
+	} else {
+		definitions, err := s.iacService.ListDefinitions(ctx, userID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list IaC definitions: %w", err)
+		}
+		for _, d := range definitions {
+			definitionIDs = append(definitionIDs, d.ID)
+		}
+	}
+
+	byCategory := make(map[string]int)
+	for _, id := range definitionIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		drifts, err := s.iacService.DetectDrift(ctx, userID, id)
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id":       j.UserID,
+				"definition_id": id,
+			}).ErrorWithErr(err, "Failed to detect IaC drift")
+			result.ErrorCount++
+			continue
+		}
+		result.ItemsScanned++
+		result.DriftsFound += len(drifts)
+		for _, d := range drifts {
+			byCategory[string(d.DriftCategory)]++
+		}
+	}
+
+	result.IssuesFound = result.DriftsFound
+	result.Success = result.ErrorCount == 0
+	result.Details["definitions_scanned"] = result.ItemsScanned
+	result.Details["definitions_failed"] = result.ErrorCount
+	result.Details["by_category"] = byCategory
+
+	return result, nil
+}
+
+// runComplianceAssessmentJob assesses the frameworks listed in the job
+// options ("framework_ids"), or every enabled framework
+func (s *JobService) runComplianceAssessmentJob(ctx context.Context, j *job.ScheduledJob, config *job.JobConfig) (*job.JobResult, error) {
+	result := &job.JobResult{
+		Success: true,
+		Details: make(map[string]interface{}),
+	}
+
+	frameworkIDs := optionStrings(config, "framework_ids")
+	if len(frameworkIDs) == 0 {
+		frameworks, err := s.complianceService.ListFrameworks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list compliance frameworks: %w", err)
+		}
+		for _, f := range frameworks {
+			if f.IsEnabled {
+				frameworkIDs = append(frameworkIDs, f.ID)
+			}
+		}
+	}
+
+	// Lapsed exceptions are expired and owners warned of upcoming expiries
+	// on every run; the notice window is longer than the weekly schedule
+	expiring, err := s.complianceService.NotifyExpiringExceptions(ctx, j.UserID)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id": j.UserID,
+		}).ErrorWithErr(err, "Failed to check compliance exception expiry")
+		result.ErrorCount++
+	}
+	result.Details["exceptions_expiring"] = expiring
+
+	scores := make(map[string]float64)
+	for _, id := range frameworkIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		assessment, err := s.complianceService.RunAssessment(ctx, j.UserID, id)
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id":      j.UserID,
+				"framework_id": id,
+			}).ErrorWithErr(err, "Failed to run compliance assessment")
+			result.ErrorCount++
+			continue
+		}
+		result.ItemsScanned += assessment.TotalControls
+		result.IssuesFound += assessment.FailedControls
+		scores[id] = assessment.CompliancePercent
+	}
+
+	result.Success = result.ErrorCount == 0
+	result.Details["frameworks_assessed"] = len(scores)
+	result.Details["frameworks_failed"] = result.ErrorCount
+	result.Details["compliance_percent"] = scores
+
+	return result, nil
+}
+
 // runRecommendationJob generates recommendations
 func (s *JobService) runRecommendationJob(ctx context.Context, j *job.ScheduledJob) (*job.JobResult, error) {
-	// This would integrate with the recommendation service
+	_, before, err := s.recommendationService.List(ctx, j.UserID, recommendation.Filter{}, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recommendations: %w", err)
+	}
+
+	if err := s.recommendationService.GenerateRecommendations(ctx, j.UserID); err != nil {
+		return nil, fmt.Errorf("recommendation generation failed: %w", err)
+	}
+
+	_, after, err := s.recommendationService.List(ctx, j.UserID, recommendation.Filter{}, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recommendations: %w", err)
+	}
+	savings, _ := s.recommendationService.GetTotalSavings(ctx, j.UserID)
+
+	created := int(after - before)
+	if created < 0 {
+		created = 0
+	}
 	return &job.JobResult{
-		Success: true,
+		Success:     true,
+		IssuesFound: created,
 		Details: map[string]interface{}{
-			"message": "Recommendations generated",
+			"recommendations_created": created,
+			"recommendations_total":   after,
+			"potential_savings":       savings,
 		},
 	}, nil
 }
 
+// runAnomalyDetectionJob detects cost anomalies for each connected provider
+func (s *JobService) runAnomalyDetectionJob(ctx context.Context, j *job.ScheduledJob, config *job.JobConfig) (*job.JobResult, error) {
+	result := &job.JobResult{
+		Success: true,
+		Details: make(map[string]interface{}),
+	}
+
+	providers, err := s.connectedProviders(ctx, j.UserID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider := make(map[string]int)
+	for _, p := range providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		anomalies, err := s.costService.DetectAnomalies(ctx, j.UserID, p)
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"user_id":  j.UserID,
+				"provider": p,
+			}).ErrorWithErr(err, "Failed to detect cost anomalies")
+			result.ErrorCount++
+			continue
+		}
+		result.ItemsScanned++
+		result.IssuesFound += len(anomalies)
+		byProvider[p] = len(anomalies)
+	}
+
+	result.Success = result.ErrorCount == 0
+	result.Details["providers_analyzed"] = result.ItemsScanned
+	result.Details["anomalies_by_provider"] = byProvider
+
+	return result, nil
+}
+
+// optionStrings reads a string list from the job config options
+func optionStrings(config *job.JobConfig, key string) []string {
+	raw, ok := config.Options[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, v := range raw {
+		if str, ok := v.(string); ok && str != "" {
+			values = append(values, str)
+		}
+	}
+	return values
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // calculateNextRun calculates the next run time for a schedule
 func (s *JobService) calculateNextRun(schedule string) *time.Time {
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
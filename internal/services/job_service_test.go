@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/compliance"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
+	"github.com/pratik-mahalle/infraudit/internal/domain/iac"
+	"github.com/pratik-mahalle/infraudit/internal/domain/job"
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/user"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+// The fakes below embed the domain interfaces and override only the methods
+// the job runners call; anything else panics on the nil embedded value.
+
+type fakeProviderService struct {
+	provider.Service
+	providers []*provider.Provider
+}
+
+func (f *fakeProviderService) List(ctx context.Context, userID int64) ([]*provider.Provider, error) {
+	return f.providers, nil
+}
+
+func (f *fakeProviderService) Sync(ctx context.Context, userID int64, providerType string) error {
+	return nil
+}
+
+type fakeCostService struct {
+	cost.Service
+	sync      *cost.SyncResult
+	anomalies map[string][]*cost.CostAnomaly
+
+	syncCalls   int32
+	failSyncs   int32         // number of initial syncs that fail
+	hangSync    bool          // block until the context is done
+	ignoreCtx   chan struct{} // with hangSync, block until closed instead
+	syncAborted chan struct{} // receives when a hanging sync sees its context end
+}
+
+func (f *fakeCostService) SyncAllProviders(ctx context.Context, userID int64) (*cost.SyncResult, error) {
+	call := atomic.AddInt32(&f.syncCalls, 1)
+	if f.hangSync {
+		if f.ignoreCtx != nil {
+			<-f.ignoreCtx
+			return nil, errors.New("sync released")
+		}
+		<-ctx.Done()
+		if f.syncAborted != nil {
+			f.syncAborted <- struct{}{}
+		}
+		return nil, ctx.Err()
+	}
+	if call <= f.failSyncs {
+		return nil, errors.New("billing API unavailable")
+	}
+	return f.sync, nil
+}
+
+func (f *fakeCostService) DetectAnomalies(ctx context.Context, userID int64, providerType string) ([]*cost.CostAnomaly, error) {
+	anomalies, ok := f.anomalies[providerType]
+	if !ok {
+		return nil, errors.New("no cost data")
+	}
+	return anomalies, nil
+}
+
+type fakeIaCScanner struct {
+	definitions []*iac.IaCDefinition
+	drifts      map[string][]*iac.IaCDriftResult
+}
+
+func (f *fakeIaCScanner) ListDefinitions(ctx context.Context, userID string, iacType *iac.IaCType) ([]*iac.IaCDefinition, error) {
+	return f.definitions, nil
+}
+
+func (f *fakeIaCScanner) DetectDrift(ctx context.Context, userID, definitionID string) ([]*iac.IaCDriftResult, error) {
+	drifts, ok := f.drifts[definitionID]
+	if !ok {
+		return nil, errors.New("definition not found")
+	}
+	return drifts, nil
+}
+
+type fakeComplianceService struct {
+	compliance.Service
+	frameworks  []*compliance.Framework
+	assessments map[string]*compliance.Assessment
+}
+
+func (f *fakeComplianceService) ListFrameworks(ctx context.Context) ([]*compliance.Framework, error) {
+	return f.frameworks, nil
+}
+
+func (f *fakeComplianceService) RunAssessment(ctx context.Context, userID int64, frameworkID string) (*compliance.Assessment, error) {
+	a, ok := f.assessments[frameworkID]
+	if !ok {
+		return nil, errors.New("framework not found")
+	}
+	return a, nil
+}
+
+func (f *fakeComplianceService) NotifyExpiringExceptions(ctx context.Context, userID int64) (int, error) {
+	return 0, nil
+}
+
+func newTestJobService(repo job.Repository) *JobService {
+	service, _ := newTestJobServiceWithCosts(repo)
+	return service
+}
+
+func newTestJobServiceWithCosts(repo job.Repository) (*JobService, *fakeCostService) {
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	providers := &fakeProviderService{providers: []*provider.Provider{
+		{Provider: provider.ProviderAWS, Name: "prod", IsConnected: true},
+		{Provider: provider.ProviderAWS, Name: "staging", IsConnected: true},
+		{Provider: provider.ProviderGCP, IsConnected: true},
+		{Provider: provider.ProviderAzure, IsConnected: false},
+	}}
+	costs := &fakeCostService{
+		sync: &cost.SyncResult{
+			ProvidersSynced:  []string{"aws"},
+			ProvidersSkipped: []string{"gcp"},
+			RecordsSynced:    42,
+			Errors:           map[string]string{},
+		},
+		anomalies: map[string][]*cost.CostAnomaly{
+			"aws": {{}, {}},
+		},
+	}
+	scanner := &fakeIaCScanner{
+		definitions: []*iac.IaCDefinition{{ID: "def-1"}, {ID: "def-2"}, {ID: "def-3"}},
+		drifts: map[string][]*iac.IaCDriftResult{
+			"def-1": {{DriftCategory: iac.DriftCategoryModified}, {DriftCategory: iac.DriftCategoryShadow}},
+			"def-2": {{DriftCategory: iac.DriftCategoryMissing}},
+		},
+	}
+	frameworks := &fakeComplianceService{
+		frameworks: []*compliance.Framework{
+			{ID: "cis", IsEnabled: true},
+			{ID: "soc2", IsEnabled: true},
+			{ID: "pci", IsEnabled: false},
+		},
+		assessments: map[string]*compliance.Assessment{
+			"cis":  {TotalControls: 20, FailedControls: 3},
+			"soc2": {TotalControls: 10, FailedControls: 1},
+			"pci":  {TotalControls: 30, FailedControls: 9},
+		},
+	}
+
+	service := NewJobService(repo, nil, providers, nil, costs, scanner, frameworks, nil, log).(*JobService)
+	service.SetExecutionPolicy(JobExecutionPolicy{
+		DefaultTimeout:  time.Minute,
+		RetryBaseDelay:  time.Millisecond,
+		RetryMaxDelay:   5 * time.Millisecond,
+		StopGracePeriod: time.Second,
+	})
+	return service, costs
+}
+
+// waitForExecution waits until the execution goroutine has finished
+func waitForExecution(t *testing.T, service *JobService, repo *testutil.MockJobRepository, id string) *job.JobExecution {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		service.runningExecs.Lock()
+		_, running := service.running[id]
+		service.runningExecs.Unlock()
+		if !running {
+			e, err := repo.GetExecution(context.Background(), id)
+			if err != nil {
+				t.Fatalf("GetExecution() error = %v", err)
+			}
+			return e
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("execution did not finish")
+	return nil
+}
+
+func TestJobService_RunJobLogic(t *testing.T) {
+	tests := []struct {
+		name         string
+		jobType      job.JobType
+		config       *job.JobConfig
+		wantErr      bool
+		wantSuccess  bool
+		wantScanned  int
+		wantIssues   int
+		wantErrCount int
+	}{
+		{
+			name:        "cost sync",
+			jobType:     job.JobTypeCostSync,
+			wantSuccess: true,
+			wantScanned: 42,
+		},
+		{
+			name:         "iac scan of all definitions",
+			jobType:      job.JobTypeIaCScan,
+			wantSuccess:  false,
+			wantScanned:  2,
+			wantIssues:   3,
+			wantErrCount: 1,
+		},
+		{
+			name:        "iac scan of one definition",
+			jobType:     job.JobTypeIaCScan,
+			config:      &job.JobConfig{IaCDefinitionID: "def-2"},
+			wantSuccess: true,
+			wantScanned: 1,
+			wantIssues:  1,
+		},
+		{
+			name:        "compliance assessment of enabled frameworks",
+			jobType:     job.JobTypeComplianceAssessment,
+			wantSuccess: true,
+			wantScanned: 30,
+			wantIssues:  4,
+		},
+		{
+			name:    "compliance assessment of listed frameworks",
+			jobType: job.JobTypeComplianceAssessment,
+			config: &job.JobConfig{Options: map[string]interface{}{
+				"framework_ids": []interface{}{"pci"},
+			}},
+			wantSuccess: true,
+			wantScanned: 30,
+			wantIssues:  9,
+		},
+		{
+			name:        "resource sync once per connected provider",
+			jobType:     job.JobTypeResourceSync,
+			wantSuccess: true,
+			wantScanned: 2,
+		},
+		{
+			name:         "anomaly detection per connected provider",
+			jobType:      job.JobTypeAnomalyDetection,
+			wantSuccess:  false,
+			wantScanned:  1,
+			wantIssues:   2,
+			wantErrCount: 1,
+		},
+		{
+			name:        "anomaly detection limited to configured providers",
+			jobType:     job.JobTypeAnomalyDetection,
+			config:      &job.JobConfig{Providers: []string{"aws"}},
+			wantSuccess: true,
+			wantScanned: 1,
+			wantIssues:  2,
+		},
+		{
+			name:    "unknown job type",
+			jobType: job.JobType("unknown"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestJobService(testutil.NewMockJobRepository())
+			j := &job.ScheduledJob{ID: "job-1", UserID: 1, JobType: tt.jobType}
+			if tt.config != nil {
+				j.Config, _ = json.Marshal(tt.config)
+			}
+
+			result, err := service.runJobLogic(context.Background(), j)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runJobLogic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if result.ItemsScanned != tt.wantScanned {
+				t.Errorf("ItemsScanned = %d, want %d", result.ItemsScanned, tt.wantScanned)
+			}
+			if result.IssuesFound != tt.wantIssues {
+				t.Errorf("IssuesFound = %d, want %d", result.IssuesFound, tt.wantIssues)
+			}
+			if result.ErrorCount != tt.wantErrCount {
+				t.Errorf("ErrorCount = %d, want %d", result.ErrorCount, tt.wantErrCount)
+			}
+		})
+	}
+}
+
+func TestJobService_StartSchedulesDefaultJobs(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	ctx := context.Background()
+	users := testutil.NewMockUserRepository()
+	users.Users[1] = &user.User{ID: 1}
+	users.Users[2] = &user.User{ID: 2}
+
+	// User 2 has opted out of the cost sync job by disabling it
+	repo.CreateJob(ctx, &job.ScheduledJob{ID: "disabled", UserID: 2, JobType: job.JobTypeCostSync, Schedule: "0 1 * * *"})
+
+	service := newTestJobService(repo)
+	service.SetUserLister(users)
+	if err := service.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer service.Stop()
+
+	if !service.IsRunning() {
+		t.Error("IsRunning() = false after Start()")
+	}
+	if got, want := len(service.cronEntries), 2*len(job.DefaultSchedules)-1; got != want {
+		t.Errorf("scheduled %d jobs, want %d", got, want)
+	}
+	for jobType, schedule := range job.DefaultSchedules {
+		j, _ := repo.GetJobByUserAndType(ctx, 1, jobType)
+		if j == nil || j.Schedule != schedule || !j.IsEnabled {
+			t.Errorf("default %s job for user 1 = %+v", jobType, j)
+		}
+	}
+	if j, _ := repo.GetJobByUserAndType(ctx, 2, job.JobTypeCostSync); j == nil || j.ID != "disabled" || j.IsEnabled {
+		t.Errorf("disabled job was replaced: %+v", j)
+	}
+
+	// Seeding again creates nothing
+	if created := service.seedAllUsers(ctx); len(created) != 0 {
+		t.Errorf("second seeding created %d jobs, want 0", len(created))
+	}
+	if err := service.Start(ctx); err == nil {
+		t.Error("second Start() should fail")
+	}
+}
+
+func TestJobService_ExecutionRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failSyncs    int32
+		retryCount   int
+		wantStatus   job.ExecutionStatus
+		wantAttempts int
+	}{
+		{"succeeds first time", 0, 2, job.ExecutionStatusCompleted, 1},
+		{"succeeds after retries", 2, 2, job.ExecutionStatusCompleted, 3},
+		{"retries exhausted", 5, 1, job.ExecutionStatusFailed, 2},
+		{"no retries configured", 1, 0, job.ExecutionStatusFailed, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testutil.NewMockJobRepository()
+			service, costs := newTestJobServiceWithCosts(repo)
+			costs.failSyncs = tt.failSyncs
+
+			started, err := service.TriggerJobByType(context.Background(), 1, job.JobTypeCostSync, &job.JobConfig{RetryCount: tt.retryCount})
+			if err != nil {
+				t.Fatalf("TriggerJobByType() error = %v", err)
+			}
+			e := waitForExecution(t, service, repo, started.ID)
+
+			if e.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (error %q)", e.Status, tt.wantStatus, e.ErrorMessage)
+			}
+			if e.RetryCount != tt.wantAttempts-1 {
+				t.Errorf("RetryCount = %d, want %d", e.RetryCount, tt.wantAttempts-1)
+			}
+			var result job.JobResult
+			if err := json.Unmarshal(e.Result, &result); err != nil {
+				t.Fatalf("invalid result: %v", err)
+			}
+			if len(result.Attempts) != tt.wantAttempts {
+				t.Fatalf("recorded %d attempts, want %d", len(result.Attempts), tt.wantAttempts)
+			}
+			for i, a := range result.Attempts[:len(result.Attempts)-1] {
+				if a.Attempt != i+1 || a.Error == "" {
+					t.Errorf("attempt %d = %+v, want a recorded failure", i+1, a)
+				}
+			}
+		})
+	}
+}
+
+func TestJobService_ExecutionTimeout(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	service, costs := newTestJobServiceWithCosts(repo)
+	costs.hangSync = true
+	service.policy.DefaultTimeout = 20 * time.Millisecond
+
+	started, err := service.TriggerJobByType(context.Background(), 1, job.JobTypeCostSync, &job.JobConfig{RetryCount: 1})
+	if err != nil {
+		t.Fatalf("TriggerJobByType() error = %v", err)
+	}
+	e := waitForExecution(t, service, repo, started.ID)
+
+	if e.Status != job.ExecutionStatusFailed {
+		t.Errorf("Status = %s, want %s", e.Status, job.ExecutionStatusFailed)
+	}
+	if e.ErrorMessage != "job timed out after 20ms" {
+		t.Errorf("ErrorMessage = %q", e.ErrorMessage)
+	}
+	if calls := atomic.LoadInt32(&costs.syncCalls); calls != 2 {
+		t.Errorf("job ran %d times, want 2", calls)
+	}
+}
+
+func TestJobService_StuckAttemptIsNotRetried(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	service, costs := newTestJobServiceWithCosts(repo)
+	costs.hangSync = true
+	costs.ignoreCtx = make(chan struct{})
+	service.policy.DefaultTimeout = 20 * time.Millisecond
+	service.policy.StopGracePeriod = 20 * time.Millisecond
+	ctx := context.Background()
+
+	started, err := service.TriggerJobByType(ctx, 1, job.JobTypeCostSync, &job.JobConfig{RetryCount: 3})
+	if err != nil {
+		t.Fatalf("TriggerJobByType() error = %v", err)
+	}
+	e := waitForExecution(t, service, repo, started.ID)
+
+	if e.Status != job.ExecutionStatusFailed {
+		t.Errorf("Status = %s, want %s", e.Status, job.ExecutionStatusFailed)
+	}
+	if e.ErrorMessage != "job timed out after 20ms and job did not stop after cancellation within 20ms" {
+		t.Errorf("ErrorMessage = %q", e.ErrorMessage)
+	}
+	if calls := atomic.LoadInt32(&costs.syncCalls); calls != 1 {
+		t.Errorf("job ran %d times, a stuck attempt must not be retried", calls)
+	}
+	if _, err := service.TriggerJobByType(ctx, 1, job.JobTypeCostSync, nil); err == nil {
+		t.Error("TriggerJobByType() should refuse to start while an attempt is stuck")
+	}
+
+	close(costs.ignoreCtx)
+	deadline := time.Now().Add(5 * time.Second)
+	for service.isStuck(&job.ScheduledJob{UserID: 1, JobType: job.JobTypeCostSync}) {
+		if time.Now().After(deadline) {
+			t.Fatal("stuck attempt was not released after it returned")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobService_CancelExecution(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	service, costs := newTestJobServiceWithCosts(repo)
+	costs.hangSync = true
+	costs.syncAborted = make(chan struct{}, 1)
+	ctx := context.Background()
+
+	started, err := service.TriggerJobByType(ctx, 1, job.JobTypeCostSync, &job.JobConfig{RetryCount: 3})
+	if err != nil {
+		t.Fatalf("TriggerJobByType() error = %v", err)
+	}
+	if err := service.CancelExecution(ctx, started.ID); err != nil {
+		t.Fatalf("CancelExecution() error = %v", err)
+	}
+
+	select {
+	case <-costs.syncAborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the execution did not abort the running job")
+	}
+
+	e := waitForExecution(t, service, repo, started.ID)
+	if e.Status != job.ExecutionStatusCancelled {
+		t.Errorf("Status = %s, want %s", e.Status, job.ExecutionStatusCancelled)
+	}
+	if calls := atomic.LoadInt32(&costs.syncCalls); calls != 1 {
+		t.Errorf("job ran %d times, a cancelled execution must not be retried", calls)
+	}
+	if err := service.CancelExecution(ctx, started.ID); err == nil {
+		t.Error("cancelling a finished execution should fail")
+	}
+}
+
+func TestJobService_RetryDelay(t *testing.T) {
+	service := &JobService{policy: JobExecutionPolicy{RetryBaseDelay: time.Second, RetryMaxDelay: 5 * time.Second}}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := service.retryDelay(i + 1); got != w {
+			t.Errorf("retryDelay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestJobService_OnlyLeaderRunsScheduledJobs(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	ctx := context.Background()
+
+	// Another node holds the scheduler lease
+	repo.AcquireLease(ctx, job.LeaseScheduler, "node-a", time.Minute)
+	elector := NewLeaderElector(repo, job.LeaseScheduler, "node-b", time.Minute, log)
+	elector.campaign(ctx)
+
+	service := newTestJobService(repo)
+	service.SetLeaderElector(elector)
+	j := &job.ScheduledJob{ID: "cost", UserID: 1, JobType: job.JobTypeCostSync, Schedule: "0 1 * * *", IsEnabled: true}
+
+	service.runScheduledJob(j)
+	if _, n, _ := repo.ListExecutions(ctx, job.ExecutionFilter{JobID: j.ID}, 10, 0); n != 0 {
+		t.Fatalf("follower ran %d executions, want 0", n)
+	}
+
+	status, err := service.SchedulerStatus(ctx)
+	if err != nil {
+		t.Fatalf("SchedulerStatus() error = %v", err)
+	}
+	if status.NodeID != "node-b" || status.IsLeader || len(status.Leases) != 1 || status.Leases[0].Holder != "node-a" {
+		t.Errorf("SchedulerStatus() = %+v, leases %+v", status, status.Leases)
+	}
+
+	// Once the other node releases the lease this node takes over
+	repo.ReleaseLease(ctx, job.LeaseScheduler, "node-a")
+	elector.campaign(ctx)
+	service.runScheduledJob(j)
+
+	executions, _, _ := repo.ListExecutions(ctx, job.ExecutionFilter{JobID: j.ID}, 10, 0)
+	if len(executions) != 1 {
+		t.Fatalf("leader ran %d executions, want 1", len(executions))
+	}
+	if e := waitForExecution(t, service, repo, executions[0].ID); e.Status != job.ExecutionStatusCompleted {
+		t.Errorf("Status = %s, want %s", e.Status, job.ExecutionStatusCompleted)
+	}
+	if leases, _ := repo.ListLeases(ctx); len(leases) != 1 {
+		t.Errorf("execution lease was not released: %+v", leases)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+)
+
+// LeaseStore is the part of the job repository used for leases
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	DeleteExpiredLeases(ctx context.Context) (int64, error)
+}
+
+// LeaderElector elects one leader among the nodes sharing a database by
+// keeping a lease renewed. A node that fails to renew steps down at once, so
+// a database outage makes work skip a beat rather than run twice.
+type LeaderElector struct {
+	store    LeaseStore
+	name     string
+	nodeID   string
+	ttl      time.Duration
+	isLeader atomic.Bool
+	logger   *logger.Logger
+}
+
+// NewLeaderElector creates a leader elector for the named lease
+func NewLeaderElector(store LeaseStore, name, nodeID string, ttl time.Duration, log *logger.Logger) *LeaderElector {
+	return &LeaderElector{
+		store:  store,
+		name:   name,
+		nodeID: nodeID,
+		ttl:    ttl,
+		logger: log,
+	}
+}
+
+// DefaultNodeID identifies this process as hostname-pid
+func DefaultNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "node"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// NodeID returns the identifier this node holds leases under
+func (e *LeaderElector) NodeID() string {
+	return e.nodeID
+}
+
+// IsLeader reports whether this node currently holds the leader lease
+func (e *LeaderElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Start campaigns for the leader lease once, so IsLeader is settled when it
+// returns, and then keeps renewing it in the background until ctx is
+// cancelled. The lease is released on the way out so another node can take
+// over without waiting for it to expire.
+func (e *LeaderElector) Start(ctx context.Context) {
+	e.campaign(ctx)
+	go e.renew(ctx)
+}
+
+// renew keeps campaigning every third of the lease TTL
+func (e *LeaderElector) renew(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign(ctx)
+		case <-ctx.Done():
+			if e.isLeader.Swap(false) {
+				if err := e.store.ReleaseLease(context.Background(), e.name, e.nodeID); err != nil {
+					e.logger.ErrorWithErr(err, "Failed to release leader lease")
+				}
+			}
+			return
+		}
+	}
+}
+
+// campaign acquires or renews the lease once
+func (e *LeaderElector) campaign(ctx context.Context) {
+	acquired, err := e.store.AcquireLease(ctx, e.name, e.nodeID, e.ttl)
+	if err != nil {
+		e.logger.ErrorWithErr(err, "Failed to renew leader lease")
+		acquired = false
+	}
+
+	if was := e.isLeader.Swap(acquired); was != acquired {
+		e.logger.WithFields(map[string]interface{}{
+			"lease":     e.name,
+			"node_id":   e.nodeID,
+			"is_leader": acquired,
+		}).Info("Leadership changed")
+	}
+
+	// The leader tidies up leases of nodes that died without releasing them
+	if acquired {
+		if _, err := e.store.DeleteExpiredLeases(ctx); err != nil {
+			e.logger.ErrorWithErr(err, "Failed to delete expired leases")
+		}
+	}
+}
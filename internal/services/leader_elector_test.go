@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/job"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+func TestLeaderElector_SingleLeader(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	a := NewLeaderElector(repo, job.LeaseScheduler, "node-a", time.Minute, log)
+	b := NewLeaderElector(repo, job.LeaseScheduler, "node-b", time.Minute, log)
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	a.Start(ctxA)
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	b.Start(ctxB)
+
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("leaders: a = %v, b = %v; want only a", a.IsLeader(), b.IsLeader())
+	}
+
+	// Renewing keeps the lease with the same holder
+	b.campaign(ctxB)
+	a.campaign(ctxA)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("after renewal: a = %v, b = %v; want only a", a.IsLeader(), b.IsLeader())
+	}
+
+	// A stopping leader releases its lease so another node can take over
+	cancelA()
+	deadline := time.Now().Add(5 * time.Second)
+	for a.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("a did not step down")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	b.campaign(ctxB)
+	if !b.IsLeader() {
+		t.Error("b did not take over the released lease")
+	}
+}
+
+func TestLeaderElector_TakesOverExpiredLease(t *testing.T) {
+	repo := testutil.NewMockJobRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	ctx := context.Background()
+
+	// A node that died without releasing its lease
+	repo.Leases[job.LeaseScheduler] = &job.Lease{
+		Name:      job.LeaseScheduler,
+		Holder:    "dead-node",
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	repo.Leases[job.ExecutionLeaseName("abandoned")] = &job.Lease{
+		Name:      job.ExecutionLeaseName("abandoned"),
+		Holder:    "dead-node",
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+
+	e := NewLeaderElector(repo, job.LeaseScheduler, "node-a", time.Minute, log)
+	e.campaign(ctx)
+	if !e.IsLeader() {
+		t.Fatal("expired lease was not taken over")
+	}
+	if got := repo.Leases[job.LeaseScheduler].Holder; got != "node-a" {
+		t.Errorf("lease holder = %q, want node-a", got)
+	}
+	if _, ok := repo.Leases[job.ExecutionLeaseName("abandoned")]; ok {
+		t.Error("leader did not clean up expired leases")
+	}
+}
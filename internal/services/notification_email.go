@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+)
+
+// emailTemplate describes how one notification type is rendered as email.
+// The intro and action lines are shared by the plaintext and HTML bodies.
+type emailTemplate struct {
+	SubjectPrefix string
+	Heading       string
+	Intro         string
+	Action        string
+}
+
+var emailTemplates = map[notification.NotificationType]emailTemplate{
+	notification.NotificationTypeDriftAlert: {
+		SubjectPrefix: "Drift detected",
+		Heading:       "Configuration drift detected",
+		Intro:         "A resource no longer matches its approved baseline.",
+		Action:        "Review the drift and either remediate it or accept the new configuration as the baseline.",
+	},
+	notification.NotificationTypeVulnerabilityAlert: {
+		SubjectPrefix: "Vulnerability found",
+		Heading:       "New vulnerability found",
+		Intro:         "A scan found a vulnerability in one of your resources.",
+		Action:        "Review the affected packages and apply the fixed versions where available.",
+	},
+	notification.NotificationTypeCostAlert: {
+		SubjectPrefix: "Cost alert",
+		Heading:       "Cost budget alert",
+		Intro:         "Spend against one of your budgets crossed an alert threshold.",
+		Action:        "Review the budget status and the biggest cost drivers in the cost overview.",
+	},
+	notification.NotificationTypeDailySummary: {
+		SubjectPrefix: "Daily summary",
+		Heading:       "Your daily InfraAudit summary",
+		Intro:         "Here is what changed across your cloud accounts in the last 24 hours.",
+	},
+	notification.NotificationTypeWeeklySummary: {
+		SubjectPrefix: "Weekly summary",
+		Heading:       "Your weekly InfraAudit summary",
+		Intro:         "Here is what changed across your cloud accounts in the last 7 days.",
+	},
+	notification.NotificationTypeRemediationApproval: {
+		SubjectPrefix: "Approval required",
+		Heading:       "Remediation awaiting approval",
+		Intro:         "A remediation action needs your approval before it can run.",
+		Action:        "Approve or reject the action from the remediation page.",
+	},
+}
+
+// defaultEmailTemplate renders notification types without a template of their own
+var defaultEmailTemplate = emailTemplate{
+	SubjectPrefix: "Notification",
+	Heading:       "InfraAudit notification",
+}
+
+// emailView is the data passed to the email templates
+type emailView struct {
+	emailTemplate
+	Title    string
+	Message  string
+	Priority notification.Priority
+	Color    string
+	Details  []emailDetail
+	SentAt   string
+}
+
+// emailDetail is one row of the details table built from Notification.Data
+type emailDetail struct {
+	Key   string
+	Value string
+}
+
+var emailTextTemplate = texttemplate.Must(texttemplate.New("email.txt").Parse(`{{.Heading}}
+{{if .Intro}}
+{{.Intro}}
+{{end}}
+{{.Title}}
+{{if .Message}}
+{{.Message}}
+{{end}}
+Priority: {{.Priority}}
+{{range .Details}}{{.Key}}: {{.Value}}
+{{end}}{{if .Action}}
+{{.Action}}
+{{end}}
+--
+Sent by InfraAudit at {{.SentAt}}
+`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("email.html").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:Arial,Helvetica,sans-serif;color:#1f2933;">
+<table role="presentation" width="100%" style="max-width:640px;margin:0 auto;background:#ffffff;border-top:4px solid {{.Color}};">
+<tr><td style="padding:24px;">
+<h1 style="margin:0 0 12px;font-size:20px;">{{.Heading}}</h1>
+{{if .Intro}}<p style="margin:0 0 16px;color:#52606d;">{{.Intro}}</p>{{end}}
+<h2 style="margin:0 0 8px;font-size:16px;">{{.Title}}</h2>
+{{if .Message}}<p style="margin:0 0 16px;white-space:pre-line;">{{.Message}}</p>{{end}}
+<table role="presentation" style="border-collapse:collapse;margin:0 0 16px;font-size:14px;">
+<tr><td style="padding:4px 12px 4px 0;color:#52606d;">Priority</td><td style="padding:4px 0;"><strong style="color:{{.Color}};">{{.Priority}}</strong></td></tr>
+{{range .Details}}<tr><td style="padding:4px 12px 4px 0;color:#52606d;">{{.Key}}</td><td style="padding:4px 0;">{{.Value}}</td></tr>
+{{end}}</table>
+{{if .Action}}<p style="margin:0 0 16px;">{{.Action}}</p>{{end}}
+<p style="margin:24px 0 0;font-size:12px;color:#9aa5b1;">Sent by InfraAudit at {{.SentAt}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// renderEmail renders a notification as an email subject with plaintext and
+// HTML bodies
+func renderEmail(n *notification.Notification) (subject, text, html string, err error) {
+	tmpl, ok := emailTemplates[n.Type]
+	if !ok {
+		tmpl = defaultEmailTemplate
+	}
+
+	view := emailView{
+		emailTemplate: tmpl,
+		Title:         n.Title,
+		Message:       n.Message,
+		Priority:      n.Priority,
+		Color:         priorityColor(n.Priority),
+		Details:       emailDetails(n.Data),
+		SentAt:        time.Now().UTC().Format("2006-01-02 15:04 MST"),
+	}
+
+	var textBuf, htmlBuf bytes.Buffer
+	if err := emailTextTemplate.Execute(&textBuf, view); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := emailHTMLTemplate.Execute(&htmlBuf, view); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email HTML: %w", err)
+	}
+
+	subject = fmt.Sprintf("[InfraAudit] %s: %s", tmpl.SubjectPrefix, n.Title)
+	if n.Priority == notification.PriorityCritical {
+		subject = "[CRITICAL] " + subject
+	}
+	return subject, textBuf.String(), htmlBuf.String(), nil
+}
+
+// emailDetails flattens notification data into rows sorted by key
+func emailDetails(data map[string]interface{}) []emailDetail {
+	details := make([]emailDetail, 0, len(data))
+	for k, v := range data {
+		details = append(details, emailDetail{
+			Key:   strings.ReplaceAll(k, "_", " "),
+			Value: fmt.Sprint(v),
+		})
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].Key < details[j].Key })
+	return details
+}
+
+// priorityColor returns the accent color used for a priority
+func priorityColor(p notification.Priority) string {
+	switch p {
+	case notification.PriorityCritical:
+		return "#ff0000"
+	case notification.PriorityHigh:
+		return "#ff8c00"
+	case notification.PriorityMedium:
+		return "#ffcc00"
+	default:
+		return "#36a64f"
+	}
+}
@@ -14,15 +14,33 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/integrations"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 )
 
+const (
+	// maxNotificationAttempts is how often a channel notification is tried
+	// before it is marked failed
+	maxNotificationAttempts = 3
+
+	// pendingDeliveryGrace is how long the background worker leaves a new
+	// notification or webhook delivery to its first attempt, which runs
+	// in-line or in its own goroutine, before treating it as abandoned
+	pendingDeliveryGrace = time.Minute
+)
+
+// EmailSender delivers rendered email messages
+type EmailSender interface {
+	SendEmail(ctx context.Context, msg integrations.EmailMessage) error
+}
+
 // NotificationService implements notification.Service
 type NotificationService struct {
 	repo            notification.Repository
 	logger          *logger.Logger
 	slackWebhookURL string
 	httpClient      *http.Client
+	emailSender     EmailSender
 }
 
 // NewNotificationService creates a new notification service
@@ -41,6 +59,12 @@ func NewNotificationService(
 	}
 }
 
+// SetEmailSender enables the email channel. Without a sender, email
+// deliveries fail instead of being dropped.
+func (s *NotificationService) SetEmailSender(sender EmailSender) {
+	s.emailSender = sender
+}
+
 // Send sends a notification to all enabled channels based on priority
 func (s *NotificationService) Send(ctx context.Context, n *notification.Notification) error {
 	// Get user preferences
@@ -81,8 +105,14 @@ func (s *NotificationService) Send(ctx context.Context, n *notification.Notifica
 	return nil
 }
 
-// SendToChannel sends a notification to a specific channel
+// SendToChannel sends a notification to a specific channel. A failed
+// delivery is left for ProcessPendingNotifications to retry.
 func (s *NotificationService) SendToChannel(ctx context.Context, userID int64, channel notification.Channel, n *notification.Notification) error {
+	// Webhooks are handled separately via TriggerEvent
+	if channel == notification.ChannelWebhook {
+		return nil
+	}
+
 	// Create log entry
 	payloadJSON, _ := json.Marshal(map[string]interface{}{
 		"title":   n.Title,
@@ -104,31 +134,47 @@ func (s *NotificationService) SendToChannel(ctx context.Context, userID int64, c
 		return fmt.Errorf("failed to create log: %w", err)
 	}
 
-	var err error
+	err := s.dispatch(ctx, userID, channel, n)
+	s.recordAttempt(ctx, log, err)
+
+	return err
+}
+
+// dispatch delivers a notification through one channel
+func (s *NotificationService) dispatch(ctx context.Context, userID int64, channel notification.Channel, n *notification.Notification) error {
 	switch channel {
 	case notification.ChannelSlack:
-		err = s.sendSlack(ctx, userID, n)
+		return s.sendSlack(ctx, userID, n)
 	case notification.ChannelEmail:
-		err = s.sendEmail(ctx, userID, n)
-	case notification.ChannelWebhook:
-		// Webhooks are handled separately via TriggerEvent
-		return nil
+		return s.sendEmail(ctx, userID, n)
 	default:
-		err = fmt.Errorf("unsupported channel: %s", channel)
+		return fmt.Errorf("unsupported channel: %s", channel)
 	}
+}
 
-	now := time.Now()
+// recordAttempt stores the outcome of a delivery attempt on its log. Failed
+// attempts are marked for retry until maxNotificationAttempts is reached.
+func (s *NotificationService) recordAttempt(ctx context.Context, log *notification.Log, err error) {
 	if err != nil {
-		log.Status = notification.DeliveryStatusFailed
+		log.RetryCount++
 		log.ErrorMessage = err.Error()
+		if log.RetryCount >= maxNotificationAttempts {
+			log.Status = notification.DeliveryStatusFailed
+		} else {
+			log.Status = notification.DeliveryStatusRetrying
+		}
 	} else {
+		now := time.Now()
 		log.Status = notification.DeliveryStatusSent
+		log.ErrorMessage = ""
 		log.SentAt = &now
 	}
 
-	s.repo.UpdateLog(ctx, log)
-
-	return err
+	if err := s.repo.UpdateLog(ctx, log); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"log_id": log.ID,
+		}).ErrorWithErr(err, "Failed to update notification log")
+	}
 }
 
 // SendImmediate sends a notification immediately without checking preferences
@@ -204,18 +250,7 @@ func (s *NotificationService) sendSlack(ctx context.Context, userID int64, n *no
 
 // buildSlackMessage builds a Slack message payload
 func (s *NotificationService) buildSlackMessage(n *notification.Notification) map[string]interface{} {
-	// Determine color based on priority
-	color := "#36a64f" // default green
-	switch n.Priority {
-	case notification.PriorityCritical:
-		color = "#ff0000" // red
-	case notification.PriorityHigh:
-		color = "#ff8c00" // orange
-	case notification.PriorityMedium:
-		color = "#ffcc00" // yellow
-	case notification.PriorityLow:
-		color = "#36a64f" // green
-	}
+	color := priorityColor(n.Priority)
 
 	// Determine emoji based on notification type
 	emoji := ":bell:"
@@ -252,11 +287,45 @@ func (s *NotificationService) buildSlackMessage(n *notification.Notification) ma
 
 // sendEmail sends a notification via email
 func (s *NotificationService) sendEmail(ctx context.Context, userID int64, n *notification.Notification) error {
-	// TODO: Implement email sending with SendGrid or SMTP
+	if s.emailSender == nil {
+		return fmt.Errorf("email is not configured")
+	}
+
+	// Get user's email config
+	pref, err := s.repo.GetPreference(ctx, userID, notification.ChannelEmail)
+	if err != nil {
+		return fmt.Errorf("failed to get email preference: %w", err)
+	}
+
+	var config notification.EmailConfig
+	if pref != nil && len(pref.Config) > 0 {
+		if err := json.Unmarshal(pref.Config, &config); err != nil {
+			return fmt.Errorf("invalid email preference config: %w", err)
+		}
+	}
+	if len(config.To) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	subject, text, html, err := renderEmail(n)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailSender.SendEmail(ctx, integrations.EmailMessage{
+		To:      config.To,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	}); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
 	s.logger.WithFields(map[string]interface{}{
-		"user_id": userID,
-		"type":    n.Type,
-	}).Info("Email notification queued (not implemented)")
+		"user_id":    userID,
+		"type":       n.Type,
+		"recipients": len(config.To),
+	}).Info("Email notification sent")
 
 	return nil
 }
@@ -387,15 +456,19 @@ func (s *NotificationService) TestWebhook(ctx context.Context, id string) error
 		return err
 	}
 
-	testPayload := map[string]interface{}{
+	testPayload, err := json.Marshal(map[string]interface{}{
 		"event":     "webhook.test",
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"data": map[string]interface{}{
 			"message": "This is a test webhook event from InfraAudit",
 		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	return s.deliverWebhook(ctx, webhook, notification.EventType("webhook.test"), testPayload)
+	_, err = s.deliverWebhook(ctx, webhook, notification.EventType("webhook.test"), testPayload)
+	return err
 }
 
 // TriggerEvent triggers a webhook event for a user
@@ -406,22 +479,28 @@ func (s *NotificationService) TriggerEvent(ctx context.Context, userID int64, ev
 		return fmt.Errorf("failed to get webhooks: %w", err)
 	}
 
-	payload := map[string]interface{}{
+	payloadJSON, err := json.Marshal(map[string]interface{}{
 		"event":     eventType,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"data":      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	for _, webhook := range webhooks {
-		// Create delivery record
+		// Create delivery record. The first attempt runs below; the
+		// background worker only picks the delivery up if that attempt
+		// never records an outcome.
+		claimUntil := time.Now().Add(pendingDeliveryGrace)
 		delivery := &notification.WebhookDelivery{
-			ID:        uuid.New().String(),
-			WebhookID: webhook.ID,
-			EventType: eventType,
-			Status:    notification.DeliveryStatusPending,
+			ID:            uuid.New().String(),
+			WebhookID:     webhook.ID,
+			EventType:     eventType,
+			Payload:       string(payloadJSON),
+			Status:        notification.DeliveryStatusPending,
+			NextAttemptAt: &claimUntil,
 		}
-		payloadJSON, _ := json.Marshal(payload)
-		delivery.Payload = string(payloadJSON)
 
 		if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
 			s.logger.ErrorWithErr(err, "Failed to create webhook delivery record")
@@ -430,32 +509,113 @@ func (s *NotificationService) TriggerEvent(ctx context.Context, userID int64, ev
 
 		// Deliver asynchronously
 		go func(w *notification.Webhook, d *notification.WebhookDelivery) {
-			deliverCtx := context.Background()
-			if err := s.deliverWebhook(deliverCtx, w, eventType, payload); err != nil {
-				d.Status = notification.DeliveryStatusFailed
-				d.ResponseBody = err.Error()
-			} else {
-				d.Status = notification.DeliveryStatusSent
-				now := time.Now()
-				d.DeliveredAt = &now
-			}
-			s.repo.UpdateDelivery(deliverCtx, d)
+			s.attemptDelivery(context.Background(), w, d)
 		}(webhook, delivery)
 	}
 
 	return nil
 }
 
-// deliverWebhook delivers a webhook event
-func (s *NotificationService) deliverWebhook(ctx context.Context, webhook *notification.Webhook, eventType notification.EventType, payload map[string]interface{}) error {
-	payloadJSON, err := json.Marshal(payload)
+// ListDeliveries lists the delivery attempts of a webhook
+func (s *NotificationService) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*notification.WebhookDelivery, int64, error) {
+	return s.repo.ListDeliveries(ctx, webhookID, limit, offset)
+}
+
+// RedeliverWebhook sends the payload of an earlier delivery again as a new
+// delivery. The new delivery is returned whether or not the attempt
+// succeeded; a failed attempt is retried like any other delivery.
+func (s *NotificationService) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (*notification.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(ctx, deliveryID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, err
+	}
+	if original.WebhookID != webhookID {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &notification.WebhookDelivery{
+		ID:        uuid.New().String(),
+		WebhookID: webhook.ID,
+		EventType: original.EventType,
+		Payload:   original.Payload,
+		Status:    notification.DeliveryStatusPending,
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	s.attemptDelivery(ctx, webhook, delivery)
+
+	s.logger.WithFields(map[string]interface{}{
+		"webhook_id":           webhookID,
+		"original_delivery_id": deliveryID,
+		"delivery_id":          delivery.ID,
+		"status":               delivery.Status,
+	}).Info("Webhook redelivered")
+
+	return delivery, nil
+}
+
+// attemptDelivery makes one delivery attempt and records its outcome. A
+// failed attempt is scheduled for retry with the webhook's backoff until its
+// retries are used up, at which point the delivery is dead-lettered.
+func (s *NotificationService) attemptDelivery(ctx context.Context, webhook *notification.Webhook, d *notification.WebhookDelivery) error {
+	status, err := s.deliverWebhook(ctx, webhook, d.EventType, []byte(d.Payload))
+
+	now := time.Now()
+	d.AttemptedAt = &now
+	d.ResponseStatus = status
+	if err != nil {
+		s.scheduleRetry(d, webhook.RetryPolicy(), err)
+	} else {
+		d.Status = notification.DeliveryStatusSent
+		d.ResponseBody = ""
+		d.NextAttemptAt = nil
+		d.DeliveredAt = &now
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"delivery_id": d.ID,
+		}).ErrorWithErr(err, "Failed to update webhook delivery")
 	}
 
+	return err
+}
+
+// scheduleRetry records a failed attempt on d and either schedules the next
+// attempt or moves d to the dead-letter state
+func (s *NotificationService) scheduleRetry(d *notification.WebhookDelivery, policy notification.WebhookRetryConfig, cause error) {
+	d.RetryCount++
+	d.ResponseBody = cause.Error()
+
+	if d.RetryCount > policy.MaxRetries {
+		d.Status = notification.DeliveryStatusDeadLetter
+		d.NextAttemptAt = nil
+		s.logger.WithFields(map[string]interface{}{
+			"webhook_id":  d.WebhookID,
+			"delivery_id": d.ID,
+			"attempts":    d.RetryCount,
+		}).Warn("Webhook delivery moved to dead letter")
+		return
+	}
+
+	next := time.Now().Add(policy.Delay(d.RetryCount))
+	d.Status = notification.DeliveryStatusRetrying
+	d.NextAttemptAt = &next
+}
+
+// deliverWebhook delivers a webhook event and returns the HTTP status code
+// of the response, or 0 when no response was received
+func (s *NotificationService) deliverWebhook(ctx context.Context, webhook *notification.Webhook, eventType notification.EventType, payloadJSON []byte) (int, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payloadJSON))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -470,13 +630,13 @@ func (s *NotificationService) deliverWebhook(ctx context.Context, webhook *notif
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to deliver webhook: %w", err)
+		return 0, fmt.Errorf("failed to deliver webhook: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("webhook returned error status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("webhook returned error status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Update last triggered
@@ -490,7 +650,7 @@ func (s *NotificationService) deliverWebhook(ctx context.Context, webhook *notif
 		"status":     resp.StatusCode,
 	}).Info("Webhook delivered")
 
-	return nil
+	return resp.StatusCode, nil
 }
 
 // signPayload signs the payload with HMAC-SHA256
@@ -500,7 +660,8 @@ func (s *NotificationService) signPayload(payload []byte, secret string) string
 	return "sha256=" + hex.EncodeToString(h.Sum(nil))
 }
 
-// ProcessPendingNotifications processes pending notifications
+// ProcessPendingNotifications retries channel notifications whose earlier
+// attempts failed or never completed
 func (s *NotificationService) ProcessPendingNotifications(ctx context.Context) error {
 	logs, err := s.repo.GetPendingLogs(ctx)
 	if err != nil {
@@ -508,7 +669,11 @@ func (s *NotificationService) ProcessPendingNotifications(ctx context.Context) e
 	}
 
 	for _, log := range logs {
-		// Retry sending
+		// A pending log that is still new has its first attempt in progress
+		if log.Status == notification.DeliveryStatusPending && time.Since(log.CreatedAt) < pendingDeliveryGrace {
+			continue
+		}
+
 		n := &notification.Notification{
 			Type:     log.NotificationType,
 			Priority: log.Priority,
@@ -516,36 +681,27 @@ func (s *NotificationService) ProcessPendingNotifications(ctx context.Context) e
 		}
 
 		// Parse payload
-		var payload map[string]interface{}
-		json.Unmarshal(log.Payload, &payload)
-		if title, ok := payload["title"].(string); ok {
-			n.Title = title
+		var payload struct {
+			Title   string                 `json:"title"`
+			Message string                 `json:"message"`
+			Data    map[string]interface{} `json:"data"`
 		}
-		if message, ok := payload["message"].(string); ok {
-			n.Message = message
-		}
-
-		if err := s.SendToChannel(ctx, log.UserID, log.Channel, n); err != nil {
-			log.RetryCount++
-			if log.RetryCount >= 3 {
-				log.Status = notification.DeliveryStatusFailed
-			} else {
-				log.Status = notification.DeliveryStatusRetrying
-			}
-			log.ErrorMessage = err.Error()
-		} else {
-			log.Status = notification.DeliveryStatusSent
-			now := time.Now()
-			log.SentAt = &now
+		if err := json.Unmarshal(log.Payload, &payload); err != nil {
+			s.recordAttempt(ctx, log, fmt.Errorf("invalid notification payload: %w", err))
+			continue
 		}
+		n.Title = payload.Title
+		n.Message = payload.Message
+		n.Data = payload.Data
 
-		s.repo.UpdateLog(ctx, log)
+		s.recordAttempt(ctx, log, s.dispatch(ctx, log.UserID, log.Channel, n))
 	}
 
 	return nil
 }
 
-// ProcessPendingWebhookDeliveries processes pending webhook deliveries
+// ProcessPendingWebhookDeliveries retries webhook deliveries that are due,
+// following each webhook's retry configuration
 func (s *NotificationService) ProcessPendingWebhookDeliveries(ctx context.Context) error {
 	deliveries, err := s.repo.GetPendingDeliveries(ctx)
 	if err != nil {
@@ -555,25 +711,24 @@ func (s *NotificationService) ProcessPendingWebhookDeliveries(ctx context.Contex
 	for _, d := range deliveries {
 		webhook, err := s.repo.GetWebhook(ctx, d.WebhookID)
 		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"delivery_id": d.ID,
+			}).ErrorWithErr(err, "Failed to get webhook for delivery")
 			continue
 		}
 
-		var payload map[string]interface{}
-		json.Unmarshal([]byte(d.Payload), &payload)
-
-		if err := s.deliverWebhook(ctx, webhook, d.EventType, payload); err != nil {
-			d.RetryCount++
-			if d.RetryCount >= 3 {
-				d.Status = notification.DeliveryStatusFailed
+		// Retrying cannot help while the webhook is switched off
+		if !webhook.IsEnabled {
+			d.Status = notification.DeliveryStatusDeadLetter
+			d.ResponseBody = "webhook is disabled"
+			d.NextAttemptAt = nil
+			if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+				s.logger.ErrorWithErr(err, "Failed to update webhook delivery")
 			}
-			d.ResponseBody = err.Error()
-		} else {
-			d.Status = notification.DeliveryStatusSent
-			now := time.Now()
-			d.DeliveredAt = &now
+			continue
 		}
 
-		s.repo.UpdateDelivery(ctx, d)
+		s.attemptDelivery(ctx, webhook, d)
 	}
 
 	return nil
@@ -0,0 +1,511 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/integrations"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+// fakeSMTPServer is an in-process SMTP server that accepts every message and
+// records what it was sent
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config // STARTTLS is offered when set
+
+	mu         sync.Mutex
+	tlsUsed    bool
+	authPlain  string
+	from       string
+	recipients []string
+	data       string
+}
+
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: l, tlsConfig: tlsConfig}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake.smtp ESMTP ready")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			s.mu.Lock()
+			offerTLS := s.tlsConfig != nil && !s.tlsUsed
+			s.mu.Unlock()
+			if offerTLS {
+				tp.PrintfLine("250-fake.smtp")
+				tp.PrintfLine("250-STARTTLS")
+			} else {
+				tp.PrintfLine("250-fake.smtp")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			s.mu.Lock()
+			s.tlsUsed = true
+			s.mu.Unlock()
+		case "AUTH":
+			_, creds, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(creds)
+			s.mu.Lock()
+			s.authPlain = string(decoded)
+			s.mu.Unlock()
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// selfSignedTLS returns a server config for 127.0.0.1 and a client config
+// that trusts it
+func selfSignedTLS(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake.smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+	return server, client
+}
+
+func newEmailTestService(t *testing.T, smtpCfg integrations.SMTPConfig, to []string) (*NotificationService, *testutil.MockNotificationRepository) {
+	t.Helper()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	repo := testutil.NewMockNotificationRepository()
+	if to != nil {
+		config, _ := json.Marshal(notification.EmailConfig{To: to})
+		repo.CreatePreference(context.Background(), &notification.Preference{
+			ID:        "pref-email",
+			UserID:    1,
+			Channel:   notification.ChannelEmail,
+			IsEnabled: true,
+			Config:    config,
+		})
+	}
+
+	svc := NewNotificationService(repo, log, "").(*NotificationService)
+	svc.SetEmailSender(integrations.NewSMTPMailer(smtpCfg))
+	return svc, repo
+}
+
+func TestNotificationService_SendEmail(t *testing.T) {
+	serverTLS, clientTLS := selfSignedTLS(t)
+	server := newFakeSMTPServer(t, serverTLS)
+
+	svc, repo := newEmailTestService(t, integrations.SMTPConfig{
+		Host:      "127.0.0.1",
+		Port:      server.port(),
+		Username:  "mailer",
+		Password:  "s3cret",
+		From:      "alerts@infraaudit.example",
+		FromName:  "InfraAudit",
+		UseTLS:    true,
+		TLSConfig: clientTLS,
+	}, []string{"ops@example.com", "Sec Team <sec@example.com>"})
+
+	n := &notification.Notification{
+		Type:     notification.NotificationTypeDriftAlert,
+		Priority: notification.PriorityCritical,
+		Title:    "Bucket encryption disabled",
+		Message:  "logs-bucket no longer has default encryption <script>",
+		Data:     map[string]interface{}{"resource_id": "logs-bucket"},
+		UserID:   1,
+	}
+	if err := svc.SendToChannel(context.Background(), 1, notification.ChannelEmail, n); err != nil {
+		t.Fatalf("SendToChannel() error = %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if !server.tlsUsed {
+		t.Error("expected STARTTLS before sending")
+	}
+	if server.authPlain != "\x00mailer\x00s3cret" {
+		t.Errorf("AUTH PLAIN = %q", server.authPlain)
+	}
+	if server.from != "alerts@infraaudit.example" {
+		t.Errorf("MAIL FROM = %q", server.from)
+	}
+	if strings.Join(server.recipients, ",") != "ops@example.com,sec@example.com" {
+		t.Errorf("RCPT TO = %v", server.recipients)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "[CRITICAL] [InfraAudit] Drift detected: Bucket encryption disabled" {
+		t.Errorf("Subject = %q", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, err = %v", msg.Header.Get("Content-Type"), err)
+	}
+	bodies := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[partType] = string(body)
+	}
+
+	text := bodies["text/plain"]
+	if !strings.Contains(text, "Configuration drift detected") || !strings.Contains(text, "resource id: logs-bucket") {
+		t.Errorf("text body missing drift details:\n%s", text)
+	}
+	html := bodies["text/html"]
+	if !strings.Contains(html, "Configuration drift detected") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Errorf("html body missing escaped drift details:\n%s", html)
+	}
+
+	logs, _, _ := repo.ListLogs(context.Background(), notification.LogFilter{UserID: 1}, 10, 0)
+	if len(logs) != 1 || logs[0].Status != notification.DeliveryStatusSent {
+		t.Errorf("expected one sent log, got %+v", logs)
+	}
+}
+
+func TestNotificationService_SendEmailFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		useTLS  bool
+		to      []string
+		wantErr string
+	}{
+		{
+			name:    "no recipients in preference",
+			to:      []string{},
+			wantErr: "no email recipients configured",
+		},
+		{
+			name:    "no email preference",
+			wantErr: "no email recipients configured",
+		},
+		{
+			name:    "server without STARTTLS",
+			useTLS:  true,
+			to:      []string{"ops@example.com"},
+			wantErr: "does not support STARTTLS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, nil)
+			svc, repo := newEmailTestService(t, integrations.SMTPConfig{
+				Host:     "127.0.0.1",
+				Port:     server.port(),
+				Username: "mailer",
+				Password: "s3cret",
+				From:     "alerts@infraaudit.example",
+				UseTLS:   tt.useTLS,
+			}, tt.to)
+
+			n := &notification.Notification{
+				Type:     notification.NotificationTypeWeeklySummary,
+				Priority: notification.PriorityLow,
+				Title:    "Week 42",
+				UserID:   1,
+			}
+			err := svc.SendToChannel(context.Background(), 1, notification.ChannelEmail, n)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SendToChannel() error = %v, want %q", err, tt.wantErr)
+			}
+
+			server.mu.Lock()
+			sent := server.data
+			server.mu.Unlock()
+			if sent != "" {
+				t.Error("expected no message to be sent")
+			}
+
+			logs, _, _ := repo.ListLogs(context.Background(), notification.LogFilter{UserID: 1}, 10, 0)
+			if len(logs) != 1 || logs[0].Status != notification.DeliveryStatusRetrying {
+				t.Errorf("expected one log awaiting retry, got %+v", logs)
+			}
+		})
+	}
+}
+
+func TestRenderEmail_Templates(t *testing.T) {
+	types := []notification.NotificationType{
+		notification.NotificationTypeDriftAlert,
+		notification.NotificationTypeVulnerabilityAlert,
+		notification.NotificationTypeDailySummary,
+		notification.NotificationTypeWeeklySummary,
+		notification.NotificationTypeRemediationApproval,
+		notification.NotificationTypeJobFailed,
+	}
+
+	for _, nt := range types {
+		t.Run(string(nt), func(t *testing.T) {
+			subject, text, html, err := renderEmail(&notification.Notification{
+				Type:     nt,
+				Priority: notification.PriorityMedium,
+				Title:    "Title",
+				Message:  "Message",
+			})
+			if err != nil {
+				t.Fatalf("renderEmail() error = %v", err)
+			}
+			want := defaultEmailTemplate
+			if tmpl, ok := emailTemplates[nt]; ok {
+				want = tmpl
+			}
+			if !strings.HasPrefix(subject, "[InfraAudit] "+want.SubjectPrefix) {
+				t.Errorf("subject = %q", subject)
+			}
+			if !strings.Contains(text, want.Heading) || !strings.Contains(html, want.Heading) {
+				t.Errorf("bodies missing heading %q", want.Heading)
+			}
+		})
+	}
+}
+
+func TestNotificationService_WebhookRetriesThenDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusServiceUnavailable
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	repo := testutil.NewMockNotificationRepository()
+	svc := NewNotificationService(repo, log, "").(*NotificationService)
+	ctx := context.Background()
+
+	retryConfig, _ := json.Marshal(notification.WebhookRetryConfig{MaxRetries: 2, InitialDelayMs: 1, MaxDelayMs: 5, BackoffFactor: 2})
+	repo.CreateWebhook(ctx, &notification.Webhook{
+		ID:          "wh-1",
+		UserID:      1,
+		URL:         server.URL,
+		Events:      []notification.EventType{notification.EventDriftDetected},
+		IsEnabled:   true,
+		RetryConfig: retryConfig,
+	})
+	repo.CreateDelivery(ctx, &notification.WebhookDelivery{
+		ID:        "d-1",
+		WebhookID: "wh-1",
+		EventType: notification.EventDriftDetected,
+		Payload:   `{"event":"drift.detected"}`,
+		Status:    notification.DeliveryStatusPending,
+	})
+
+	// One initial attempt and two retries, then the delivery is dead-lettered
+	wantStatuses := []notification.DeliveryStatus{
+		notification.DeliveryStatusRetrying,
+		notification.DeliveryStatusRetrying,
+		notification.DeliveryStatusDeadLetter,
+	}
+	for i, want := range wantStatuses {
+		time.Sleep(10 * time.Millisecond) // past the backoff delay
+		if err := svc.ProcessPendingWebhookDeliveries(ctx); err != nil {
+			t.Fatalf("ProcessPendingWebhookDeliveries() error = %v", err)
+		}
+		d, _ := repo.GetDelivery(ctx, "d-1")
+		if d.Status != want || d.RetryCount != i+1 || d.ResponseStatus != http.StatusServiceUnavailable {
+			t.Fatalf("attempt %d: delivery = %+v, want status %s", i+1, d, want)
+		}
+		if want == notification.DeliveryStatusRetrying && d.NextAttemptAt == nil {
+			t.Fatalf("attempt %d: retrying delivery has no next attempt time", i+1)
+		}
+	}
+
+	// Dead-lettered deliveries are left alone
+	time.Sleep(10 * time.Millisecond)
+	svc.ProcessPendingWebhookDeliveries(ctx)
+	mu.Lock()
+	if calls != 3 {
+		t.Errorf("webhook called %d times, want 3", calls)
+	}
+	status = http.StatusOK
+	mu.Unlock()
+
+	redelivered, err := svc.RedeliverWebhook(ctx, "wh-1", "d-1")
+	if err != nil {
+		t.Fatalf("RedeliverWebhook() error = %v", err)
+	}
+	if redelivered.ID == "d-1" || redelivered.Status != notification.DeliveryStatusSent || redelivered.Payload != `{"event":"drift.detected"}` {
+		t.Errorf("RedeliverWebhook() = %+v", redelivered)
+	}
+	if original, _ := repo.GetDelivery(ctx, "d-1"); original.Status != notification.DeliveryStatusDeadLetter {
+		t.Errorf("original delivery status = %s, want dead_letter", original.Status)
+	}
+
+	if _, err := svc.RedeliverWebhook(ctx, "wh-other", "d-1"); err == nil {
+		t.Error("RedeliverWebhook() with another webhook's delivery should fail")
+	}
+}
+
+func TestNotificationService_ProcessPendingNotificationsRetriesLog(t *testing.T) {
+	var mu sync.Mutex
+	fail := true
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer server.Close()
+
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	repo := testutil.NewMockNotificationRepository()
+	svc := NewNotificationService(repo, log, server.URL).(*NotificationService)
+	ctx := context.Background()
+
+	n := &notification.Notification{
+		Type:     notification.NotificationTypeDriftAlert,
+		Priority: notification.PriorityHigh,
+		Title:    "Drift on logs-bucket",
+		UserID:   1,
+	}
+	if err := svc.SendToChannel(ctx, 1, notification.ChannelSlack, n); err == nil {
+		t.Fatal("SendToChannel() should fail while Slack is down")
+	}
+
+	logs, _, _ := repo.ListLogs(ctx, notification.LogFilter{UserID: 1}, 10, 0)
+	if len(logs) != 1 || logs[0].Status != notification.DeliveryStatusRetrying || logs[0].RetryCount != 1 {
+		t.Fatalf("after failed send, logs = %+v", logs)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	if err := svc.ProcessPendingNotifications(ctx); err != nil {
+		t.Fatalf("ProcessPendingNotifications() error = %v", err)
+	}
+
+	logs, _, _ = repo.ListLogs(ctx, notification.LogFilter{UserID: 1}, 10, 0)
+	if len(logs) != 1 || logs[0].Status != notification.DeliveryStatusSent {
+		t.Fatalf("after retry, logs = %+v", logs)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || !strings.Contains(received[0], "Drift on logs-bucket") {
+		t.Errorf("Slack received %v", received)
+	}
+}
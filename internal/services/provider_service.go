@@ -2,8 +2,14 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
 	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
@@ -15,6 +21,7 @@ import (
 // This allows mocking for tests
 type CloudProviderClient interface {
 	AWSListResources(ctx context.Context, creds cloudproviders.AWSCredentials) ([]resource.Resource, error)
+	AWSListOrganizationAccounts(ctx context.Context, creds cloudproviders.AWSCredentials) ([]cloudproviders.AWSOrganizationAccount, error)
 	AzureListResources(ctx context.Context, creds cloudproviders.AzureCredentials) ([]resource.Resource, error)
 	GCPListResources(ctx context.Context, creds cloudproviders.GCPCredentials) ([]resource.Resource, error)
 }
@@ -26,6 +33,10 @@ func (c *DefaultCloudProviderClient) AWSListResources(ctx context.Context, creds
 	return cloudproviders.AWSListResources(ctx, creds)
 }
 
+func (c *DefaultCloudProviderClient) AWSListOrganizationAccounts(ctx context.Context, creds cloudproviders.AWSCredentials) ([]cloudproviders.AWSOrganizationAccount, error) {
+	return cloudproviders.AWSListOrganizationAccounts(ctx, creds)
+}
+
 func (c *DefaultCloudProviderClient) AzureListResources(ctx context.Context, creds cloudproviders.AzureCredentials) ([]resource.Resource, error) {
 	return cloudproviders.AzureListResources(ctx, creds)
 }
@@ -34,21 +45,59 @@ func (c *DefaultCloudProviderClient) GCPListResources(ctx context.Context, creds
 	return cloudproviders.GCPListResources(ctx, creds)
 }
 
+// CredentialVerifier checks the credentials of one cloud provider against
+// its API. Tests substitute fakes.
+type CredentialVerifier interface {
+	Verify(ctx context.Context, creds provider.Credentials) (*provider.Verification, error)
+}
+
+// CredentialVerifierFunc adapts a function to a CredentialVerifier
+type CredentialVerifierFunc func(ctx context.Context, creds provider.Credentials) (*provider.Verification, error)
+
+// Verify calls f
+func (f CredentialVerifierFunc) Verify(ctx context.Context, creds provider.Credentials) (*provider.Verification, error) {
+	return f(ctx, creds)
+}
+
+// defaultCredentialVerifiers returns verifiers that call the provider APIs
+func defaultCredentialVerifiers() map[string]CredentialVerifier {
+	return map[string]CredentialVerifier{
+		provider.ProviderAWS: CredentialVerifierFunc(func(ctx context.Context, c provider.Credentials) (*provider.Verification, error) {
+			return cloudproviders.AWSVerify(ctx, awsCredentials(c))
+		}),
+		provider.ProviderGCP: CredentialVerifierFunc(func(ctx context.Context, c provider.Credentials) (*provider.Verification, error) {
+			return cloudproviders.GCPVerify(ctx, gcpCredentials(c))
+		}),
+		provider.ProviderAzure: CredentialVerifierFunc(func(ctx context.Context, c provider.Credentials) (*provider.Verification, error) {
+			return cloudproviders.AzureVerify(ctx, azureCredentials(c))
+		}),
+	}
+}
+
+// DefaultStaleResourceGracePeriod is how long a resource that vanished from
+// its provider stays terminated before it is deleted
+const DefaultStaleResourceGracePeriod = 7 * 24 * time.Hour
+
 // ProviderService implements provider.Service
 type ProviderService struct {
-	providerRepo provider.Repository
-	resourceRepo resource.Repository
-	logger       *logger.Logger
-	client       CloudProviderClient
+	providerRepo     provider.Repository
+	resourceRepo     resource.Repository
+	logger           *logger.Logger
+	client           CloudProviderClient
+	verifiers        map[string]CredentialVerifier
+	notifier         notification.Service
+	staleGracePeriod time.Duration
 }
 
 // NewProviderService creates a new provider service
 func NewProviderService(providerRepo provider.Repository, resourceRepo resource.Repository, log *logger.Logger) provider.Service {
 	return &ProviderService{
-		providerRepo: providerRepo,
-		resourceRepo: resourceRepo,
-		logger:       log,
-		client:       &DefaultCloudProviderClient{},
+		providerRepo:     providerRepo,
+		resourceRepo:     resourceRepo,
+		logger:           log,
+		client:           &DefaultCloudProviderClient{},
+		verifiers:        defaultCredentialVerifiers(),
+		staleGracePeriod: DefaultStaleResourceGracePeriod,
 	}
 }
 
@@ -57,201 +106,562 @@ func (s *ProviderService) SetClient(client CloudProviderClient) {
 	s.client = client
 }
 
-// Connect connects a cloud provider account
+// SetVerifier sets the credential verifier of a provider type (used for testing)
+func (s *ProviderService) SetVerifier(providerType string, verifier CredentialVerifier) {
+	s.verifiers[providerType] = verifier
+}
+
+// SetNotificationService enables resource.created and resource.deleted
+// webhook events for resources that appear or vanish between syncs
+func (s *ProviderService) SetNotificationService(notifier notification.Service) {
+	s.notifier = notifier
+}
+
+// SetStaleResourceGracePeriod sets how long a resource that vanished from
+// its provider stays terminated before it is deleted
+func (s *ProviderService) SetStaleResourceGracePeriod(d time.Duration) {
+	s.staleGracePeriod = d
+}
+
+// Connect connects the default account of a cloud provider
 func (s *ProviderService) Connect(ctx context.Context, userID int64, providerType string, credentials provider.Credentials) error {
+	_, err := s.ConnectAccount(ctx, userID, providerType, provider.DefaultAccountName, credentials)
+	return err
+}
+
+// ConnectAccount connects a named cloud provider account. Connecting an
+// account name again replaces its credentials.
+func (s *ProviderService) ConnectAccount(ctx context.Context, userID int64, providerType, name string, credentials provider.Credentials) (*provider.Provider, error) {
+	if name == "" {
+		name = provider.DefaultAccountName
+	}
 	p := &provider.Provider{
 		UserID:      userID,
 		Provider:    providerType,
+		Name:        name,
 		IsConnected: true,
 		Credentials: credentials,
 	}
 
 	// Test connection before saving
-	if err := s.TestConnection(ctx, providerType, credentials); err != nil {
+	verification, err := s.TestConnection(ctx, userID, providerType, credentials)
+	if err != nil {
 		s.logger.ErrorWithErr(err, "Provider connection test failed")
-		return errors.ProviderAuthError(providerType, err)
+		return nil, err
 	}
+	p.Verification = verification
 
-	err := s.providerRepo.Upsert(ctx, p)
+	err = s.providerRepo.Upsert(ctx, p)
 	if err != nil {
 		s.logger.ErrorWithErr(err, "Failed to save provider")
-		return err
+		return nil, err
 	}
 
 	s.logger.WithFields(map[string]interface{}{
-		"user_id":  userID,
-		"provider": providerType,
+		"user_id":    userID,
+		"provider":   providerType,
+		"account_id": p.ID,
+		"account":    name,
 	}).Info("Provider connected")
 
-	return nil
+	if _, err := s.discoverAWSAccounts(ctx, p); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"user_id":    userID,
+			"account_id": p.ID,
+		}).ErrorWithErr(err, "Failed to discover AWS Organization accounts")
+	}
+
+	return p, nil
 }
 
-// Disconnect disconnects a cloud provider account
+// ConnectAWSTargets connects one AWS account per target by assuming the
+// target's role on top of the base credentials and their role chain.
+// Targets without a name are named after the account ID in their role ARN.
+// A target that fails does not stop the others: the accounts that did
+// connect are returned along with an error detailing each failed target.
+func (s *ProviderService) ConnectAWSTargets(ctx context.Context, userID int64, base provider.Credentials, targets []provider.AWSTarget) ([]*provider.Provider, error) {
+	if len(targets) == 0 {
+		return nil, errors.BadRequest("At least one AWS role target is required")
+	}
+
+	accounts := make([]*provider.Provider, 0, len(targets))
+	failures := make(map[string]string)
+	var firstErr error
+	for _, target := range targets {
+		name := target.Name
+		if name == "" {
+			name = awsRoleAccountID(target.RoleARN)
+		}
+
+		creds := base
+		creds.AWSRoleChain = append(append([]provider.AWSRole(nil), base.AWSRoleChain...), provider.AWSRole{
+			RoleARN:    target.RoleARN,
+			ExternalID: target.ExternalID,
+		})
+		creds.AWSOrganizationRoleName = ""
+		creds.AWSOrganizationExternalID = ""
+
+		p, err := s.ConnectAccount(ctx, userID, provider.ProviderAWS, name, creds)
+		if err != nil {
+			key := name
+			if key == "" {
+				key = target.RoleARN
+			}
+			failures[key] = err.Error()
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		accounts = append(accounts, p)
+	}
+
+	switch {
+	case len(failures) == 0:
+		return accounts, nil
+	case len(targets) == 1:
+		return accounts, firstErr
+	}
+	// The first failure decides the status code
+	aggregate := errors.Wrap(firstErr, errors.ErrCodeInternal,
+		fmt.Sprintf("%d of %d AWS role targets failed to connect", len(failures), len(targets)), http.StatusInternalServerError)
+	var appErr *errors.AppError
+	if stderrors.As(firstErr, &appErr) {
+		aggregate.Code, aggregate.StatusCode = appErr.Code, appErr.StatusCode
+	}
+	return accounts, aggregate.WithDetails(failures)
+}
+
+// discoverAWSAccounts connects the member accounts of the AWS Organization
+// an account belongs to, when the account names a role to assume in them.
+// Members that are already connected get their credentials refreshed.
+func (s *ProviderService) discoverAWSAccounts(ctx context.Context, p *provider.Provider) ([]*provider.Provider, error) {
+	if p.Provider != provider.ProviderAWS || p.Credentials.AWSOrganizationRoleName == "" {
+		return nil, nil
+	}
+
+	members, err := s.client.AWSListOrganizationAccounts(ctx, awsCredentials(p.Credentials))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization accounts: %w", err)
+	}
+
+	targets := make([]provider.AWSTarget, 0, len(members))
+	for _, m := range members {
+		targets = append(targets, provider.AWSTarget{
+			Name:       m.ID,
+			RoleARN:    cloudproviders.AWSRoleARN(m.ID, p.Credentials.AWSOrganizationRoleName),
+			ExternalID: p.Credentials.AWSOrganizationExternalID,
+		})
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	accounts, err := s.ConnectAWSTargets(ctx, p.UserID, p.Credentials, targets)
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":    p.UserID,
+		"account_id": p.ID,
+		"members":    len(members),
+		"connected":  len(accounts),
+	}).Info("AWS Organization accounts discovered")
+	return accounts, err
+}
+
+// Disconnect disconnects all accounts of a cloud provider
 func (s *ProviderService) Disconnect(ctx context.Context, userID int64, providerType string) error {
+	accounts, err := s.providerRepo.ListByProvider(ctx, userID, providerType)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return errors.NotFound("Provider")
+	}
+
+	for _, account := range accounts {
+		if err := s.DisconnectAccount(ctx, userID, providerType, account.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DisconnectAccount deletes a provider account and the resources synced from it
+func (s *ProviderService) DisconnectAccount(ctx context.Context, userID int64, providerType string, accountID int64) error {
+	if _, err := s.GetAccount(ctx, userID, providerType, accountID); err != nil {
+		return err
+	}
+
 	// Delete provider account
-	err := s.providerRepo.Delete(ctx, userID, providerType)
+	err := s.providerRepo.Delete(ctx, userID, accountID)
 	if err != nil {
 		s.logger.ErrorWithErr(err, "Failed to disconnect provider")
 		return err
 	}
 
 	// Delete associated resources
-	err = s.resourceRepo.DeleteByProvider(ctx, userID, providerType)
+	err = s.resourceRepo.DeleteByAccount(ctx, userID, accountID)
 	if err != nil {
-		s.logger.Warnf("Failed to delete resources for provider %s: %v", providerType, err)
+		s.logger.Warnf("Failed to delete resources for %s account %d: %v", providerType, accountID, err)
 	}
 
 	s.logger.WithFields(map[string]interface{}{
-		"user_id":  userID,
-		"provider": providerType,
+		"user_id":    userID,
+		"provider":   providerType,
+		"account_id": accountID,
 	}).Info("Provider disconnected")
 
 	return nil
 }
 
-// List retrieves all connected providers for a user
+// List retrieves all connected provider accounts for a user
 func (s *ProviderService) List(ctx context.Context, userID int64) ([]*provider.Provider, error) {
 	return s.providerRepo.List(ctx, userID)
 }
 
-// GetByProvider retrieves a specific provider account
+// ListAccounts retrieves the accounts of one provider type
+func (s *ProviderService) ListAccounts(ctx context.Context, userID int64, providerType string) ([]*provider.Provider, error) {
+	return s.providerRepo.ListByProvider(ctx, userID, providerType)
+}
+
+// GetByProvider retrieves the first account of a provider type
 func (s *ProviderService) GetByProvider(ctx context.Context, userID int64, providerType string) (*provider.Provider, error) {
 	return s.providerRepo.GetByProvider(ctx, userID, providerType)
 }
 
-// TestConnection tests the connection to a provider
-func (s *ProviderService) TestConnection(ctx context.Context, providerType string, credentials provider.Credentials) error {
-	// This would use the actual cloud provider SDKs to test credentials
-	// For now, basic validation
+// GetAccount retrieves a provider account by ID. An account of another
+// provider type is reported as not found.
+func (s *ProviderService) GetAccount(ctx context.Context, userID int64, providerType string, accountID int64) (*provider.Provider, error) {
+	p, err := s.providerRepo.GetByID(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Provider != providerType {
+		return nil, errors.NotFound("Provider account")
+	}
+	return p, nil
+}
+
+// TestConnection checks that the required credential fields are set and
+// then verifies the credentials against the provider's API. Missing read
+// permissions do not fail the test; they are reported in the result.
+func (s *ProviderService) TestConnection(ctx context.Context, userID int64, providerType string, credentials provider.Credentials) (*provider.Verification, error) {
 	switch providerType {
 	case provider.ProviderAWS:
-		if credentials.AWSAccessKeyID == "" || credentials.AWSSecretAccessKey == "" {
-			return errors.BadRequest("AWS credentials are required")
+		if (credentials.AWSAccessKeyID == "") != (credentials.AWSSecretAccessKey == "") {
+			return nil, errors.BadRequest("AWS access key ID and secret access key must be given together")
+		}
+		// Without access keys the server's own identity, such as its
+		// instance profile, is used, so it may only be used to assume a role
+		if credentials.AWSAccessKeyID == "" && len(credentials.AWSRoleChain) == 0 {
+			return nil, errors.BadRequest("AWS credentials are required")
+		}
+		for _, role := range credentials.AWSRoleChain {
+			if awsRoleAccountID(role.RoleARN) == "" {
+				return nil, errors.BadRequest(fmt.Sprintf("Invalid AWS role ARN %q", role.RoleARN))
+			}
+		}
+		if err := s.checkAWSExternalID(ctx, userID, credentials); err != nil {
+			return nil, err
 		}
 	case provider.ProviderGCP:
 		if credentials.GCPProjectID == "" || credentials.GCPServiceAccountJSON == "" {
-			return errors.BadRequest("GCP credentials are required")
+			return nil, errors.BadRequest("GCP credentials are required")
 		}
 	case provider.ProviderAzure:
 		if credentials.AzureTenantID == "" || credentials.AzureClientID == "" || credentials.AzureClientSecret == "" {
-			return errors.BadRequest("Azure credentials are required")
+			return nil, errors.BadRequest("Azure credentials are required")
 		}
 	default:
-		return errors.BadRequest("Unsupported provider type")
+		return nil, errors.BadRequest("Unsupported provider type")
 	}
 
-	s.logger.WithFields(map[string]interface{}{
+	verifier, ok := s.verifiers[providerType]
+	if !ok {
+		return nil, errors.BadRequest("Unsupported provider type")
+	}
+	verification, err := verifier.Verify(ctx, credentials)
+	if err != nil {
+		return nil, errors.ProviderAuthError(providerType, err)
+	}
+
+	fields := map[string]interface{}{
 		"provider": providerType,
-	}).Info("Provider connection test passed")
+		"identity": verification.Identity,
+		"account":  verification.Account,
+	}
+	if len(verification.MissingPermissions) > 0 {
+		fields["missing_permissions"] = verification.MissingPermissions
+		s.logger.WithFields(fields).Warn("Provider connection test passed with missing permissions")
+	} else {
+		s.logger.WithFields(fields).Info("Provider connection test passed")
+	}
 
-	return nil
+	return verification, nil
 }
 
-// Sync syncs resources from a provider
-func (s *ProviderService) Sync(ctx context.Context, userID int64, providerType string) error {
-	p, err := s.providerRepo.GetByProvider(ctx, userID, providerType)
+// VerifyAccount verifies the stored credentials of a provider account
+func (s *ProviderService) VerifyAccount(ctx context.Context, userID int64, providerType string, accountID int64) (*provider.Verification, error) {
+	p, err := s.GetAccount(ctx, userID, providerType, accountID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return s.TestConnection(ctx, userID, providerType, p.Credentials)
+}
 
-	if !p.IsConnected {
-		return errors.BadRequest("Provider is not connected")
+// AWSExternalID returns the external ID the user's AWS roles must require
+// when they are assumed with the server's own identity, generating it on
+// first use
+func (s *ProviderService) AWSExternalID(ctx context.Context, userID int64) (string, error) {
+	externalID, err := s.providerRepo.GetAWSExternalID(ctx, userID)
+	if err == nil || !isNotFound(err) {
+		return externalID, err
 	}
+	if err := s.providerRepo.CreateAWSExternalID(ctx, userID, uuid.New().String()); err != nil {
+		return "", err
+	}
+	// Another request may have created it first
+	return s.providerRepo.GetAWSExternalID(ctx, userID)
+}
 
-	s.logger.WithFields(map[string]interface{}{
-		"user_id":  userID,
-		"provider": providerType,
-	}).Info("Provider sync initiated")
-
-	var resources []*resource.Resource
+// checkAWSExternalID rejects credentials that would have the server's own
+// identity assume a role without the user's external ID. The server's
+// identity is shared by every user, so without it any user could connect a
+// role that trusts the server but belongs to someone else.
+func (s *ProviderService) checkAWSExternalID(ctx context.Context, userID int64, credentials provider.Credentials) error {
+	if credentials.AWSAccessKeyID != "" || len(credentials.AWSRoleChain) == 0 {
+		return nil
+	}
+	externalID, err := s.AWSExternalID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if credentials.AWSRoleChain[0].ExternalID != externalID {
+		return errors.BadRequest(fmt.Sprintf(
+			"Roles assumed with the server's AWS identity must require your external ID %q; set it on role %s and in its trust policy",
+			externalID, credentials.AWSRoleChain[0].RoleARN))
+	}
+	return nil
+}
 
-	switch providerType {
-	case provider.ProviderAWS:
-		creds := cloudproviders.AWSCredentials{
-			AccessKeyID:     p.Credentials.AWSAccessKeyID,
-			SecretAccessKey: p.Credentials.AWSSecretAccessKey,
-			Region:          p.Credentials.AWSRegion,
-		}
-		res, err := s.client.AWSListResources(ctx, creds)
-		if err != nil {
-			return errors.Internal("Failed to list AWS resources", err)
-		}
-		for i := range res {
-			r := &res[i]
-			r.UserID = userID
-			resources = append(resources, r)
-		}
+// Sync syncs resources from every account of a provider. Accounts are
+// synced independently; the first error is returned after all have run.
+func (s *ProviderService) Sync(ctx context.Context, userID int64, providerType string) error {
+	accounts, err := s.providerRepo.ListByProvider(ctx, userID, providerType)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return errors.NotFound("Provider")
+	}
 
-	case provider.ProviderAzure:
-		creds := cloudproviders.AzureCredentials{
-			TenantID:       p.Credentials.AzureTenantID,
-			ClientID:       p.Credentials.AzureClientID,
-			ClientSecret:   p.Credentials.AzureClientSecret,
-			SubscriptionID: p.Credentials.AzureSubscriptionID,
-			Location:       p.Credentials.AzureLocation,
+	// Pick up accounts that joined an organization since the last sync
+	var firstErr error
+	for _, account := range accounts {
+		if !account.IsConnected {
+			continue
 		}
-		res, err := s.client.AzureListResources(ctx, creds)
-		if err != nil {
-			return errors.Internal("Failed to list Azure resources", err)
+		discovered, err := s.discoverAWSAccounts(ctx, account)
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
-		for i := range res {
-			r := &res[i]
-			r.UserID = userID
-			resources = append(resources, r)
+		for _, d := range discovered {
+			if !containsAccount(accounts, d.ID) {
+				accounts = append(accounts, d)
+			}
 		}
+	}
 
-	case provider.ProviderGCP:
-		creds := cloudproviders.GCPCredentials{
-			ProjectID:          p.Credentials.GCPProjectID,
-			ServiceAccountJSON: p.Credentials.GCPServiceAccountJSON,
-			Region:             p.Credentials.GCPRegion,
-		}
-		res, err := s.client.GCPListResources(ctx, creds)
-		if err != nil {
-			return errors.Internal("Failed to list GCP resources", err)
+	synced := 0
+	for _, account := range accounts {
+		if !account.IsConnected {
+			continue
 		}
-		for i := range res {
-			r := &res[i]
-			r.UserID = userID
-			resources = append(resources, r)
+		synced++
+		if err := s.syncAccount(ctx, account); err != nil && firstErr == nil {
+			firstErr = err
 		}
+	}
+	if synced == 0 {
+		return errors.BadRequest("Provider is not connected")
+	}
+	return firstErr
+}
 
-	default:
-		return errors.BadRequest("Unsupported provider type")
+// SyncAccount syncs resources from one provider account
+func (s *ProviderService) SyncAccount(ctx context.Context, userID int64, providerType string, accountID int64) error {
+	p, err := s.GetAccount(ctx, userID, providerType, accountID)
+	if err != nil {
+		return err
 	}
+	if !p.IsConnected {
+		return errors.BadRequest("Provider is not connected")
+	}
+	return s.syncAccount(ctx, p)
+}
+
+// syncAccount lists the account's resources, reconciles them with the
+// stored ones and records the outcome on the account
+func (s *ProviderService) syncAccount(ctx context.Context, p *provider.Provider) error {
+	s.logger.WithFields(map[string]interface{}{
+		"user_id":    p.UserID,
+		"provider":   p.Provider,
+		"account_id": p.ID,
+	}).Info("Provider sync initiated")
+
+	// The first sync of an account finds everything, which is not news
+	firstSync := p.LastSynced == nil
 
-	// Save resources in batch
-	if len(resources) > 0 {
-		err = s.resourceRepo.SaveBatch(ctx, userID, providerType, resources)
+	var run *resource.SyncRun
+	var diff *resource.SyncDiff
+	syncedAt := time.Now().UTC()
+	resources, err := s.listResources(ctx, p)
+	if err == nil {
+		run, diff, err = s.reconcileResources(ctx, p, resources, syncedAt)
 		if err != nil {
 			s.logger.ErrorWithErr(err, "Failed to save synced resources")
-			return err
 		}
-	} else {
-		// Even if no resources, we might want to ensure existing ones are cleaned up?
-		// SaveBatch likely handles upserts. We might need logic to delete stale resources.
-		// For now, let's assume SaveBatch or DeleteByProvider usage if strict sync required.
-		// But basic logic is simple sync.
-		s.logger.Infof("No resources found for provider %s", providerType)
 	}
 
-	// Update last synced timestamp
-	now := time.Now()
-	err = s.providerRepo.UpdateSyncStatus(ctx, userID, providerType, now)
+	status, message := provider.SyncStatusSynced, ""
+	if err != nil {
+		status, message = provider.SyncStatusFailed, err.Error()
+	}
+	if updateErr := s.providerRepo.UpdateSyncStatus(ctx, p.UserID, p.ID, status, message, syncedAt); updateErr != nil {
+		s.logger.ErrorWithErr(updateErr, "Failed to update sync status")
+	}
 	if err != nil {
-		s.logger.ErrorWithErr(err, "Failed to update sync status")
+		return err
+	}
+
+	if len(resources) == 0 {
+		s.logger.Infof("No resources found for %s account %s", p.Provider, p.Name)
+	}
+
+	if !firstSync {
+		s.triggerResourceEvents(ctx, p, diff)
 	}
 
 	s.logger.WithFields(map[string]interface{}{
-		"user_id":        userID,
-		"provider":       providerType,
+		"user_id":        p.UserID,
+		"provider":       p.Provider,
+		"account_id":     p.ID,
 		"resource_count": len(resources),
+		"added":          run.Added,
+		"updated":        run.Updated,
+		"removed":        run.Removed,
+		"purged":         run.Purged,
 	}).Info("Provider sync completed")
 
 	return nil
 }
 
-// GetSyncStatus gets the sync status for all providers
+// reconcileResources diffs the listed resources against the stored ones,
+// saves the diff and records the sync's changelog
+func (s *ProviderService) reconcileResources(ctx context.Context, p *provider.Provider, listed []*resource.Resource, syncedAt time.Time) (*resource.SyncRun, *resource.SyncDiff, error) {
+	stored, err := s.resourceRepo.ListByAccount(ctx, p.UserID, p.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff := resource.DiffSync(stored, listed, syncedAt, s.staleGracePeriod)
+	run := &resource.SyncRun{
+		UserID:    p.UserID,
+		Provider:  p.Provider,
+		AccountID: p.ID,
+		SyncedAt:  syncedAt,
+		Added:     len(diff.Added),
+		Updated:   len(diff.Updated),
+		Removed:   len(diff.Removed),
+		Purged:    len(diff.Purged),
+		Changes:   diff.Changes(),
+	}
+	if err := s.resourceRepo.ApplySync(ctx, run, diff); err != nil {
+		return nil, nil, err
+	}
+	return run, diff, nil
+}
+
+// triggerResourceEvents sends resource.created webhook events for added
+// resources and resource.deleted events for removed ones
+func (s *ProviderService) triggerResourceEvents(ctx context.Context, p *provider.Provider, diff *resource.SyncDiff) {
+	if s.notifier == nil {
+		return
+	}
+
+	trigger := func(eventType notification.EventType, resources []*resource.Resource) {
+		for _, res := range resources {
+			data := map[string]interface{}{
+				"resource_id":  res.ResourceID,
+				"name":         res.Name,
+				"type":         res.Type,
+				"region":       res.Region,
+				"provider":     p.Provider,
+				"account_id":   p.ID,
+				"account_name": p.Name,
+			}
+			if err := s.notifier.TriggerEvent(ctx, p.UserID, eventType, data); err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"event":       eventType,
+					"resource_id": res.ResourceID,
+				}).ErrorWithErr(err, "Failed to trigger resource event")
+			}
+		}
+	}
+	trigger(notification.EventResourceCreated, diff.Added)
+	trigger(notification.EventResourceDeleted, diff.Removed)
+}
+
+// ListSyncRuns lists the sync changelogs of a provider account, newest first
+func (s *ProviderService) ListSyncRuns(ctx context.Context, userID int64, providerType string, accountID int64, limit int) ([]*resource.SyncRun, error) {
+	if _, err := s.GetAccount(ctx, userID, providerType, accountID); err != nil {
+		return nil, err
+	}
+	return s.resourceRepo.ListSyncRuns(ctx, userID, accountID, limit)
+}
+
+// listResources lists the resources of a provider account, tagged with the
+// user and account
+func (s *ProviderService) listResources(ctx context.Context, p *provider.Provider) ([]*resource.Resource, error) {
+	var res []resource.Resource
+	var err error
+
+	switch p.Provider {
+	case provider.ProviderAWS:
+		// Accounts connected before external IDs were required fail here
+		// until they are reconnected with one
+		if err := s.checkAWSExternalID(ctx, p.UserID, p.Credentials); err != nil {
+			return nil, err
+		}
+		if res, err = s.client.AWSListResources(ctx, awsCredentials(p.Credentials)); err != nil {
+			return nil, errors.Internal("Failed to list AWS resources", err)
+		}
+
+	case provider.ProviderAzure:
+		if res, err = s.client.AzureListResources(ctx, azureCredentials(p.Credentials)); err != nil {
+			return nil, errors.Internal("Failed to list Azure resources", err)
+		}
+
+	case provider.ProviderGCP:
+		if res, err = s.client.GCPListResources(ctx, gcpCredentials(p.Credentials)); err != nil {
+			return nil, errors.Internal("Failed to list GCP resources", err)
+		}
+
+	default:
+		return nil, errors.BadRequest("Unsupported provider type")
+	}
+
+	resources := make([]*resource.Resource, 0, len(res))
+	for i := range res {
+		r := &res[i]
+		r.UserID = p.UserID
+		r.AccountID = p.ID
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// GetSyncStatus gets the sync status of every provider account
 func (s *ProviderService) GetSyncStatus(ctx context.Context, userID int64) ([]*provider.SyncStatus, error) {
 	providers, err := s.providerRepo.List(ctx, userID)
 	if err != nil {
@@ -260,26 +670,90 @@ func (s *ProviderService) GetSyncStatus(ctx context.Context, userID int64) ([]*p
 
 	statuses := make([]*provider.SyncStatus, len(providers))
 	for i, p := range providers {
-		// Get resource count for this provider
-		resources, err := s.resourceRepo.ListByProvider(ctx, userID, p.Provider)
+		// Count the resources synced from this account
+		_, total, err := s.resourceRepo.List(ctx, userID, resource.Filter{Provider: p.Provider, AccountID: p.ID}, 1, 0)
 		resourceCount := 0
 		if err == nil {
-			resourceCount = len(resources)
+			resourceCount = int(total)
 		}
 
 		status := "connected"
-		if !p.IsConnected {
+		switch {
+		case !p.IsConnected:
 			status = "disconnected"
+		case p.SyncStatus == provider.SyncStatusFailed:
+			status = "error"
 		}
 
 		statuses[i] = &provider.SyncStatus{
 			Provider:      p.Provider,
+			AccountID:     p.ID,
+			AccountName:   p.Name,
 			IsConnected:   p.IsConnected,
 			LastSynced:    p.LastSynced,
 			ResourceCount: resourceCount,
 			Status:        status,
+			Message:       p.SyncMessage,
 		}
 	}
 
 	return statuses, nil
 }
+
+// awsCredentials converts stored credentials to the AWS client's credentials
+func awsCredentials(c provider.Credentials) cloudproviders.AWSCredentials {
+	creds := cloudproviders.AWSCredentials{
+		AccessKeyID:     c.AWSAccessKeyID,
+		SecretAccessKey: c.AWSSecretAccessKey,
+		Region:          c.AWSRegion,
+	}
+	for _, role := range c.AWSRoleChain {
+		creds.RoleChain = append(creds.RoleChain, cloudproviders.AWSRole{
+			RoleARN:    role.RoleARN,
+			ExternalID: role.ExternalID,
+		})
+	}
+	return creds
+}
+
+// gcpCredentials converts stored credentials to the GCP client's credentials
+func gcpCredentials(c provider.Credentials) cloudproviders.GCPCredentials {
+	return cloudproviders.GCPCredentials{
+		ProjectID:          c.GCPProjectID,
+		ServiceAccountJSON: c.GCPServiceAccountJSON,
+		Region:             c.GCPRegion,
+	}
+}
+
+// azureCredentials converts stored credentials to the Azure client's credentials
+func azureCredentials(c provider.Credentials) cloudproviders.AzureCredentials {
+	return cloudproviders.AzureCredentials{
+		TenantID:       c.AzureTenantID,
+		ClientID:       c.AzureClientID,
+		ClientSecret:   c.AzureClientSecret,
+		SubscriptionID: c.AzureSubscriptionID,
+		Location:       c.AzureLocation,
+	}
+}
+
+// awsRoleAccountID returns the account ID of an IAM role ARN such as
+// arn:aws:iam::123456789012:role/Audit, or "" when it is not one
+func awsRoleAccountID(roleARN string) string {
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") {
+		return ""
+	}
+	if len(parts[4]) != 12 || strings.Trim(parts[4], "0123456789") != "" {
+		return ""
+	}
+	return parts[4]
+}
+
+func containsAccount(accounts []*provider.Provider, id int64) bool {
+	for _, a := range accounts {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
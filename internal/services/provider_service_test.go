@@ -2,18 +2,39 @@ package services
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	apperrors "github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	cloudproviders "github.com/pratik-mahalle/infraudit/internal/providers"
 	"github.com/pratik-mahalle/infraudit/internal/testutil"
 )
 
+// newTestProviderService returns a provider service whose credential
+// verifiers accept any credentials without calling the provider APIs
+func newTestProviderService(providerRepo provider.Repository, resourceRepo resource.Repository, log *logger.Logger) *ProviderService {
+	service := NewProviderService(providerRepo, resourceRepo, log).(*ProviderService)
+	for _, providerType := range []string{provider.ProviderAWS, provider.ProviderGCP, provider.ProviderAzure} {
+		service.SetVerifier(providerType, CredentialVerifierFunc(func(ctx context.Context, creds provider.Credentials) (*provider.Verification, error) {
+			return &provider.Verification{Identity: "test"}, nil
+		}))
+	}
+	return service
+}
+
 func TestProviderService_Connect(t *testing.T) {
 	providerRepo := testutil.NewMockProviderRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewProviderService(providerRepo, resourceRepo, log)
+	service := newTestProviderService(providerRepo, resourceRepo, log)
 
 	tests := []struct {
 		name         string
@@ -87,7 +108,7 @@ func TestProviderService_Disconnect(t *testing.T) {
 	providerRepo := testutil.NewMockProviderRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewProviderService(providerRepo, resourceRepo, log)
+	service := newTestProviderService(providerRepo, resourceRepo, log)
 
 	ctx := context.Background()
 
@@ -126,7 +147,7 @@ func TestProviderService_List(t *testing.T) {
 	providerRepo := testutil.NewMockProviderRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewProviderService(providerRepo, resourceRepo, log)
+	service := newTestProviderService(providerRepo, resourceRepo, log)
 
 	ctx := context.Background()
 
@@ -174,7 +195,7 @@ func TestProviderService_TestConnection(t *testing.T) {
 	providerRepo := testutil.NewMockProviderRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewProviderService(providerRepo, resourceRepo, log)
+	service := newTestProviderService(providerRepo, resourceRepo, log)
 
 	ctx := context.Background()
 
@@ -241,7 +262,7 @@ func TestProviderService_TestConnection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.TestConnection(ctx, tt.providerType, tt.credentials)
+			_, err := service.TestConnection(ctx, 1, tt.providerType, tt.credentials)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TestConnection() error = %v, wantErr %v", err, tt.wantErr)
@@ -254,7 +275,7 @@ func TestProviderService_GetSyncStatus(t *testing.T) {
 	providerRepo := testutil.NewMockProviderRepository()
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
-	service := NewProviderService(providerRepo, resourceRepo, log)
+	service := newTestProviderService(providerRepo, resourceRepo, log)
 
 	ctx := context.Background()
 
@@ -287,3 +308,372 @@ func TestProviderService_GetSyncStatus(t *testing.T) {
 		t.Errorf("GetSyncStatus() status = %v, want %v", status.Status, "connected")
 	}
 }
+
+func TestProviderService_MultipleAccounts(t *testing.T) {
+	providerRepo := testutil.NewMockProviderRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := newTestProviderService(providerRepo, resourceRepo, log)
+
+	ctx := context.Background()
+	creds := provider.Credentials{AWSAccessKeyID: "key", AWSSecretAccessKey: "secret"}
+
+	dev, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "", creds)
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+	prod, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "prod", creds)
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+	if dev.Name != provider.DefaultAccountName || prod.Name != "prod" || dev.ID == prod.ID {
+		t.Fatalf("accounts = %+v, %+v, want distinct default and prod accounts", dev, prod)
+	}
+
+	// Reconnecting a name replaces its credentials instead of adding an account
+	again, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "prod", creds)
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+	if again.ID != prod.ID {
+		t.Errorf("reconnected account ID = %d, want %d", again.ID, prod.ID)
+	}
+
+	accounts, err := service.ListAccounts(ctx, 1, provider.ProviderAWS)
+	if err != nil {
+		t.Fatalf("ListAccounts() error = %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("ListAccounts() returned %d accounts, want 2", len(accounts))
+	}
+
+	if _, err := service.GetAccount(ctx, 1, provider.ProviderGCP, prod.ID); !isNotFound(err) {
+		t.Errorf("GetAccount() with the wrong provider error = %v, want not found", err)
+	}
+
+	for _, r := range []*resource.Resource{
+		{UserID: 1, Provider: provider.ProviderAWS, AccountID: dev.ID, ResourceID: "i-dev"},
+		{UserID: 1, Provider: provider.ProviderAWS, AccountID: prod.ID, ResourceID: "i-prod-1"},
+		{UserID: 1, Provider: provider.ProviderAWS, AccountID: prod.ID, ResourceID: "i-prod-2"},
+	} {
+		resourceRepo.Create(ctx, r)
+	}
+	providerRepo.UpdateSyncStatus(ctx, 1, prod.ID, provider.SyncStatusFailed, "access denied", time.Now())
+
+	statuses, err := service.GetSyncStatus(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetSyncStatus() error = %v", err)
+	}
+	byAccount := make(map[string]*provider.SyncStatus)
+	for _, s := range statuses {
+		byAccount[s.AccountName] = s
+	}
+	if got := byAccount[provider.DefaultAccountName]; got == nil || got.ResourceCount != 1 || got.Status != "connected" {
+		t.Errorf("default account status = %+v, want 1 resource and connected", got)
+	}
+	if got := byAccount["prod"]; got == nil || got.ResourceCount != 2 || got.Status != "error" || got.Message != "access denied" {
+		t.Errorf("prod account status = %+v, want 2 resources and the sync error", got)
+	}
+
+	if err := service.DisconnectAccount(ctx, 1, provider.ProviderAWS, prod.ID); err != nil {
+		t.Fatalf("DisconnectAccount() error = %v", err)
+	}
+	remaining, _ := resourceRepo.ListByProvider(ctx, 1, provider.ProviderAWS)
+	if len(remaining) != 1 || remaining[0].ResourceID != "i-dev" {
+		t.Errorf("resources after disconnecting prod = %v, want only i-dev", remaining)
+	}
+	if _, err := service.GetByProvider(ctx, 1, provider.ProviderAWS); err != nil {
+		t.Errorf("default account should stay connected: %v", err)
+	}
+}
+
+// fakeCloudClient lists canned AWS resources and Organization accounts
+type fakeCloudClient struct {
+	DefaultCloudProviderClient
+	resources   []resource.Resource
+	orgAccounts []cloudproviders.AWSOrganizationAccount
+	orgCreds    []cloudproviders.AWSCredentials
+}
+
+func (f *fakeCloudClient) AWSListResources(ctx context.Context, creds cloudproviders.AWSCredentials) ([]resource.Resource, error) {
+	return append([]resource.Resource(nil), f.resources...), nil
+}
+
+func (f *fakeCloudClient) AWSListOrganizationAccounts(ctx context.Context, creds cloudproviders.AWSCredentials) ([]cloudproviders.AWSOrganizationAccount, error) {
+	f.orgCreds = append(f.orgCreds, creds)
+	return f.orgAccounts, nil
+}
+
+func TestProviderService_AWSRoles(t *testing.T) {
+	providerRepo := testutil.NewMockProviderRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := newTestProviderService(providerRepo, resourceRepo, log)
+	client := &fakeCloudClient{orgAccounts: []cloudproviders.AWSOrganizationAccount{
+		{ID: "222222222222", Name: "prod", Status: "ACTIVE"},
+		{ID: "333333333333", Name: "dev", Status: "ACTIVE"},
+	}}
+	service.SetClient(client)
+
+	ctx := context.Background()
+	externalID, err := service.AWSExternalID(ctx, 1)
+	if err != nil || externalID == "" {
+		t.Fatalf("AWSExternalID() = %q, %v, want a generated ID", externalID, err)
+	}
+	if again, _ := service.AWSExternalID(ctx, 1); again != externalID {
+		t.Errorf("AWSExternalID() = %q on the second call, want %q", again, externalID)
+	}
+	if other, _ := service.AWSExternalID(ctx, 2); other == externalID {
+		t.Error("AWSExternalID() returned the same ID for two users")
+	}
+	hub := provider.AWSRole{RoleARN: "arn:aws:iam::111111111111:role/Hub", ExternalID: externalID}
+
+	// The server's own identity may only be used to assume a role, and only
+	// with the user's external ID
+	if _, err := service.TestConnection(ctx, 1, provider.ProviderAWS, provider.Credentials{}); err == nil {
+		t.Error("TestConnection() without keys or roles should fail")
+	}
+	for _, id := range []string{"", "someone-elses"} {
+		creds := provider.Credentials{AWSRoleChain: []provider.AWSRole{{RoleARN: hub.RoleARN, ExternalID: id}}}
+		if _, err := service.TestConnection(ctx, 1, provider.ProviderAWS, creds); err == nil {
+			t.Errorf("TestConnection() with external ID %q should fail", id)
+		}
+	}
+	keyed := provider.Credentials{AWSAccessKeyID: "key", AWSSecretAccessKey: "secret", AWSRoleChain: []provider.AWSRole{{RoleARN: hub.RoleARN}}}
+	if _, err := service.TestConnection(ctx, 1, provider.ProviderAWS, keyed); err != nil {
+		t.Errorf("TestConnection() with access keys and a role = %v, want no external ID required", err)
+	}
+	if _, err := service.TestConnection(ctx, 1, provider.ProviderAWS, provider.Credentials{AWSRoleChain: []provider.AWSRole{{RoleARN: "not-an-arn"}}}); err == nil {
+		t.Error("TestConnection() with an invalid role ARN should fail")
+	}
+
+	accounts, err := service.ConnectAWSTargets(ctx, 1, provider.Credentials{AWSRoleChain: []provider.AWSRole{hub}}, []provider.AWSTarget{
+		{RoleARN: "arn:aws:iam::444444444444:role/Audit", ExternalID: "ext-1"},
+		{Name: "sandbox", RoleARN: "arn:aws:iam::555555555555:role/Audit"},
+	})
+	if err != nil {
+		t.Fatalf("ConnectAWSTargets() error = %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].Name != "444444444444" || accounts[1].Name != "sandbox" {
+		t.Fatalf("ConnectAWSTargets() = %+v, want accounts 444444444444 and sandbox", accounts)
+	}
+	chain := accounts[0].Credentials.AWSRoleChain
+	if len(chain) != 2 || chain[0] != hub || chain[1].ExternalID != "ext-1" {
+		t.Errorf("target role chain = %+v, want the hub role then the target", chain)
+	}
+
+	// A failing target does not stop the others
+	accounts, err = service.ConnectAWSTargets(ctx, 1, provider.Credentials{AWSRoleChain: []provider.AWSRole{hub}}, []provider.AWSTarget{
+		{Name: "broken", RoleARN: "not-an-arn"},
+		{Name: "staging", RoleARN: "arn:aws:iam::666666666666:role/Audit"},
+	})
+	if len(accounts) != 1 || accounts[0].Name != "staging" {
+		t.Fatalf("ConnectAWSTargets() with a failing target = %+v, want staging", accounts)
+	}
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ConnectAWSTargets() error = %v, want the bad request of the failed target", err)
+	}
+	if failures, _ := appErr.Details.(map[string]string); len(failures) != 1 || !strings.Contains(failures["broken"], "Invalid AWS role ARN") {
+		t.Errorf("ConnectAWSTargets() failures = %+v, want the broken target", appErr.Details)
+	}
+
+	// Connecting an organization account connects its members through the
+	// organization role, chained after the management account's roles
+	mgmt, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "org", provider.Credentials{
+		AWSRoleChain:              []provider.AWSRole{hub},
+		AWSOrganizationRoleName:   "OrganizationAccountAccessRole",
+		AWSOrganizationExternalID: "ext-org",
+	})
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+	if len(client.orgCreds) != 1 || len(client.orgCreds[0].RoleChain) != 1 {
+		t.Fatalf("organization listed with %+v, want the hub role chain", client.orgCreds)
+	}
+
+	member, err := providerRepo.GetByID(ctx, 1, mgmt.ID+1)
+	if err != nil {
+		t.Fatalf("member account not connected: %v", err)
+	}
+	want := []provider.AWSRole{hub, {RoleARN: "arn:aws:iam::222222222222:role/OrganizationAccountAccessRole", ExternalID: "ext-org"}}
+	if member.Name != "222222222222" || !reflect.DeepEqual(member.Credentials.AWSRoleChain, want) || member.Credentials.AWSOrganizationRoleName != "" {
+		t.Errorf("member account = %+v, want role chain %+v", member, want)
+	}
+
+	all, _ := service.ListAccounts(ctx, 1, provider.ProviderAWS)
+	if len(all) != 6 {
+		t.Errorf("ListAccounts() = %d accounts, want 3 targets, the organization and 2 members", len(all))
+	}
+
+	// Accounts stored without the external ID no longer sync
+	legacy := &provider.Provider{UserID: 1, Provider: provider.ProviderAWS, Name: "legacy", IsConnected: true,
+		Credentials: provider.Credentials{AWSRoleChain: []provider.AWSRole{{RoleARN: hub.RoleARN}}}}
+	if err := providerRepo.Upsert(ctx, legacy); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := service.SyncAccount(ctx, 1, provider.ProviderAWS, legacy.ID); err == nil || !strings.Contains(err.Error(), externalID) {
+		t.Errorf("SyncAccount() of a role without the external ID = %v, want an error naming %q", err, externalID)
+	}
+}
+
+func TestAWSRoleAccountID(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:iam::123456789012:role/Audit":         "123456789012",
+		"arn:aws:iam::123456789012:role/path/to/Audit": "123456789012",
+		"arn:aws-cn:iam::123456789012:role/Audit":      "123456789012",
+		"arn:aws:iam::123456789012:user/alice":         "",
+		"arn:aws:iam::12345:role/Audit":                "",
+		"arn:aws:s3:::bucket":                          "",
+		"123456789012":                                 "",
+	}
+	for arn, want := range tests {
+		if got := awsRoleAccountID(arn); got != want {
+			t.Errorf("awsRoleAccountID(%q) = %q, want %q", arn, got, want)
+		}
+	}
+}
+
+func TestProviderService_Verification(t *testing.T) {
+	providerRepo := testutil.NewMockProviderRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := newTestProviderService(providerRepo, resourceRepo, log)
+
+	ctx := context.Background()
+	creds := provider.Credentials{AWSAccessKeyID: "key", AWSSecretAccessKey: "secret"}
+
+	var verified []provider.Credentials
+	service.SetVerifier(provider.ProviderAWS, CredentialVerifierFunc(func(ctx context.Context, c provider.Credentials) (*provider.Verification, error) {
+		verified = append(verified, c)
+		if c.AWSSecretAccessKey != "secret" {
+			return nil, errors.New("InvalidClientTokenId: The security token included in the request is invalid")
+		}
+		return &provider.Verification{
+			Identity:           "arn:aws:iam::123456789012:user/audit",
+			Account:            "123456789012",
+			PermissionsChecked: []string{"ec2:DescribeInstances", "s3:ListAllMyBuckets"},
+			MissingPermissions: []string{"s3:ListAllMyBuckets"},
+		}, nil
+	}))
+
+	// Missing permissions are reported but do not fail the connection
+	p, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "", creds)
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+	if p.Verification == nil || p.Verification.Account != "123456789012" || len(p.Verification.MissingPermissions) != 1 {
+		t.Errorf("ConnectAccount() verification = %+v", p.Verification)
+	}
+
+	// Rejected credentials fail as an authentication error and are not saved
+	_, err = service.ConnectAccount(ctx, 1, provider.ProviderAWS, "typo", provider.Credentials{AWSAccessKeyID: "key", AWSSecretAccessKey: "secert"})
+	if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeProviderAuth {
+		t.Errorf("ConnectAccount() with rejected credentials error = %v, want a provider auth error", err)
+	}
+	if accounts, _ := service.ListAccounts(ctx, 1, provider.ProviderAWS); len(accounts) != 1 {
+		t.Errorf("ListAccounts() = %d accounts, want only the verified one", len(accounts))
+	}
+
+	// Missing fields are caught before calling the provider
+	calls := len(verified)
+	if _, err := service.TestConnection(ctx, 1, provider.ProviderAWS, provider.Credentials{AWSAccessKeyID: "key"}); err == nil {
+		t.Error("TestConnection() without a secret key should fail")
+	}
+	if len(verified) != calls {
+		t.Error("TestConnection() called the verifier for incomplete credentials")
+	}
+
+	// Stored credentials can be verified again
+	v, err := service.VerifyAccount(ctx, 1, provider.ProviderAWS, p.ID)
+	if err != nil || v.Identity != "arn:aws:iam::123456789012:user/audit" {
+		t.Errorf("VerifyAccount() = %+v, %v", v, err)
+	}
+	if last := verified[len(verified)-1]; !reflect.DeepEqual(last, creds) {
+		t.Errorf("VerifyAccount() verified %+v, want the stored credentials", last)
+	}
+}
+
+func TestProviderService_SyncReconciliation(t *testing.T) {
+	providerRepo := testutil.NewMockProviderRepository()
+	resourceRepo := testutil.NewMockResourceRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	service := newTestProviderService(providerRepo, resourceRepo, log)
+	client := &fakeCloudClient{}
+	service.SetClient(client)
+	notifier := &recordingNotifier{}
+	service.SetNotificationService(notifier)
+	service.SetStaleResourceGracePeriod(24 * time.Hour)
+
+	ctx := context.Background()
+	account, err := service.ConnectAccount(ctx, 1, provider.ProviderAWS, "", provider.Credentials{AWSAccessKeyID: "key", AWSSecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("ConnectAccount() error = %v", err)
+	}
+
+	ec2 := func(id, status string) resource.Resource {
+		return resource.Resource{Provider: provider.ProviderAWS, ResourceID: id, Name: id, Type: resource.TypeEC2Instance, Region: "us-east-1", Status: status}
+	}
+	sync := func() *resource.SyncRun {
+		t.Helper()
+		if err := service.SyncAccount(ctx, 1, provider.ProviderAWS, account.ID); err != nil {
+			t.Fatalf("SyncAccount() error = %v", err)
+		}
+		runs, err := service.ListSyncRuns(ctx, 1, provider.ProviderAWS, account.ID, 1)
+		if err != nil || len(runs) != 1 {
+			t.Fatalf("ListSyncRuns() = %v, %v", runs, err)
+		}
+		return runs[0]
+	}
+
+	// The first sync adds everything without announcing it
+	client.resources = []resource.Resource{ec2("i-1", "running"), ec2("i-2", "running")}
+	if run := sync(); run.Added != 2 || len(run.Changes) != 2 {
+		t.Errorf("first sync = %+v, want 2 added", run)
+	}
+	if len(notifier.events) != 0 {
+		t.Errorf("first sync triggered %v, want no events", notifier.events)
+	}
+
+	// i-1 stopped, i-2 was terminated and i-3 launched
+	client.resources = []resource.Resource{ec2("i-1", "stopped"), ec2("i-3", "running")}
+	run := sync()
+	if run.Added != 1 || run.Updated != 1 || run.Removed != 1 || run.Purged != 0 {
+		t.Errorf("second sync = %+v, want 1 added, updated and removed", run)
+	}
+	for _, c := range run.Changes {
+		if c.ResourceID == "i-1" && (c.Change != resource.ChangeUpdated || !reflect.DeepEqual(c.Fields, []string{"status"})) {
+			t.Errorf("i-1 change = %+v, want a status update", c)
+		}
+	}
+	gone, err := resourceRepo.GetByID(ctx, 1, "i-2")
+	if err != nil || gone.Status != resource.StatusTerminated || gone.MissingSince == nil {
+		t.Errorf("vanished resource = %+v, %v, want it kept as terminated", gone, err)
+	}
+	want := []notification.EventType{notification.EventResourceCreated, notification.EventResourceDeleted}
+	if !reflect.DeepEqual(notifier.events, want) {
+		t.Errorf("events = %v, want %v", notifier.events, want)
+	}
+
+	// Within the grace period nothing changes
+	if run := sync(); len(run.Changes) != 0 {
+		t.Errorf("unchanged sync recorded %+v", run.Changes)
+	}
+
+	// After it the terminated resource is purged
+	service.SetStaleResourceGracePeriod(0)
+	if run := sync(); run.Purged != 1 || run.Changes[0].ResourceID != "i-2" {
+		t.Errorf("sync after the grace period = %+v, want i-2 purged", run)
+	}
+	if _, err := resourceRepo.GetByID(ctx, 1, "i-2"); err == nil {
+		t.Error("purged resource is still stored")
+	}
+	if len(notifier.events) != 2 {
+		t.Errorf("events after purge = %v, want no more", notifier.events)
+	}
+
+	runs, _ := service.ListSyncRuns(ctx, 1, provider.ProviderAWS, account.ID, 10)
+	if len(runs) != 4 || runs[0].Purged != 1 {
+		t.Errorf("ListSyncRuns() = %d runs, want 4 newest first", len(runs))
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/remediation"
+	cloudproviders "github.com/pratik-mahalle/infraudit/internal/providers"
+)
+
+// CloudRemediationClient defines the cloud provider calls used by Cloud API
+// remediation executors. This allows mocking for tests
+type CloudRemediationClient interface {
+	AWSGetBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3Encryption, error)
+	AWSPutBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, enc cloudproviders.S3Encryption) error
+	AWSDeleteBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error
+	AWSGetPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3PublicAccessBlock, error)
+	AWSPutPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, pab cloudproviders.S3PublicAccessBlock) error
+	AWSDeletePublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error
+	AWSGetSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string) ([]cloudproviders.SecurityGroupRule, error)
+	AWSRevokeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error
+	AWSAuthorizeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error
+}
+
+// DefaultCloudRemediationClient calls the provider packages
+type DefaultCloudRemediationClient struct{}
+
+func (c *DefaultCloudRemediationClient) AWSGetBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3Encryption, error) {
+	return cloudproviders.AWSGetBucketEncryption(ctx, creds, bucket)
+}
+
+func (c *DefaultCloudRemediationClient) AWSPutBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, enc cloudproviders.S3Encryption) error {
+	return cloudproviders.AWSPutBucketEncryption(ctx, creds, bucket, enc)
+}
+
+func (c *DefaultCloudRemediationClient) AWSDeleteBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error {
+	return cloudproviders.AWSDeleteBucketEncryption(ctx, creds, bucket)
+}
+
+func (c *DefaultCloudRemediationClient) AWSGetPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3PublicAccessBlock, error) {
+	return cloudproviders.AWSGetPublicAccessBlock(ctx, creds, bucket)
+}
+
+func (c *DefaultCloudRemediationClient) AWSPutPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, pab cloudproviders.S3PublicAccessBlock) error {
+	return cloudproviders.AWSPutPublicAccessBlock(ctx, creds, bucket, pab)
+}
+
+func (c *DefaultCloudRemediationClient) AWSDeletePublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error {
+	return cloudproviders.AWSDeletePublicAccessBlock(ctx, creds, bucket)
+}
+
+func (c *DefaultCloudRemediationClient) AWSGetSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string) ([]cloudproviders.SecurityGroupRule, error) {
+	return cloudproviders.AWSGetSecurityGroupIngress(ctx, creds, groupID)
+}
+
+func (c *DefaultCloudRemediationClient) AWSRevokeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error {
+	return cloudproviders.AWSRevokeSecurityGroupIngress(ctx, creds, groupID, rules)
+}
+
+func (c *DefaultCloudRemediationClient) AWSAuthorizeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error {
+	return cloudproviders.AWSAuthorizeSecurityGroupIngress(ctx, creds, groupID, rules)
+}
+
+// CloudAPITarget is the resource a Cloud API executor acts on
+type CloudAPITarget struct {
+	Credentials provider.Credentials
+	ResourceID  string
+	Params      map[string]interface{}
+}
+
+// param returns a string parameter, or def if it is not set
+func (t CloudAPITarget) param(key, def string) string {
+	if v, ok := t.Params[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (t CloudAPITarget) awsCredentials() cloudproviders.AWSCredentials {
+	creds := awsCredentials(t.Credentials)
+	creds.Region = t.param("region", t.Credentials.AWSRegion)
+	return creds
+}
+
+// CloudAPIExecutor performs one kind of Cloud API remediation. Snapshot is
+// always called before Apply so the change can be undone with Restore.
+type CloudAPIExecutor interface {
+	// Snapshot captures the configuration the executor is about to change
+	Snapshot(ctx context.Context, target CloudAPITarget) (map[string]interface{}, error)
+
+	// Apply makes the change and describes what was done
+	Apply(ctx context.Context, target CloudAPITarget) ([]string, error)
+
+	// Verify re-reads the resource and checks that the change took effect
+	Verify(ctx context.Context, target CloudAPITarget) error
+
+	// Restore puts back the configuration captured by Snapshot
+	Restore(ctx context.Context, target CloudAPITarget, snapshot map[string]interface{}) error
+}
+
+// ExecutorRegistry maps a provider and API action to its executor
+type ExecutorRegistry struct {
+	executors map[string]CloudAPIExecutor
+}
+
+// NewExecutorRegistry creates an empty executor registry
+func NewExecutorRegistry() *ExecutorRegistry {
+	return &ExecutorRegistry{executors: make(map[string]CloudAPIExecutor)}
+}
+
+// NewDefaultExecutorRegistry creates a registry with the built-in executors
+func NewDefaultExecutorRegistry(client CloudRemediationClient) *ExecutorRegistry {
+	r := NewExecutorRegistry()
+	r.Register(provider.ProviderAWS, remediation.APIActionS3EnableEncryption, &s3EncryptionExecutor{client: client})
+	r.Register(provider.ProviderAWS, remediation.APIActionS3BlockPublicAccess, &s3PublicAccessExecutor{client: client})
+	r.Register(provider.ProviderAWS, remediation.APIActionEC2RevokeOpenIngress, &openIngressExecutor{client: client})
+	return r
+}
+
+// Register adds or replaces the executor for a provider and API action
+func (r *ExecutorRegistry) Register(providerType, apiAction string, executor CloudAPIExecutor) {
+	r.executors[executorKey(providerType, apiAction)] = executor
+}
+
+// Lookup returns the executor for a provider and API action
+func (r *ExecutorRegistry) Lookup(providerType, apiAction string) (CloudAPIExecutor, bool) {
+	e, ok := r.executors[executorKey(providerType, apiAction)]
+	return e, ok
+}
+
+func executorKey(providerType, apiAction string) string {
+	return strings.ToLower(providerType) + "/" + strings.ToLower(apiAction)
+}
+
+// s3EncryptionExecutor enables default encryption on an S3 bucket
+type s3EncryptionExecutor struct {
+	client CloudRemediationClient
+}
+
+func (e *s3EncryptionExecutor) Snapshot(ctx context.Context, target CloudAPITarget) (map[string]interface{}, error) {
+	enc, err := e.client.AWSGetBucketEncryption(ctx, target.awsCredentials(), bucketName(target))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bucket encryption: %w", err)
+	}
+	return map[string]interface{}{"encryption": toStateValue(enc)}, nil
+}
+
+func (e *s3EncryptionExecutor) Apply(ctx context.Context, target CloudAPITarget) ([]string, error) {
+	bucket := bucketName(target)
+	enc := cloudproviders.S3Encryption{
+		Algorithm: target.param("sse_algorithm", "AES256"),
+		KMSKeyID:  target.param("kms_key_id", ""),
+	}
+	if err := e.client.AWSPutBucketEncryption(ctx, target.awsCredentials(), bucket, enc); err != nil {
+		return nil, fmt.Errorf("failed to enable bucket encryption: %w", err)
+	}
+	return []string{fmt.Sprintf("Enabled %s default encryption on bucket %s", enc.Algorithm, bucket)}, nil
+}
+
+func (e *s3EncryptionExecutor) Verify(ctx context.Context, target CloudAPITarget) error {
+	enc, err := e.client.AWSGetBucketEncryption(ctx, target.awsCredentials(), bucketName(target))
+	if err != nil {
+		return fmt.Errorf("failed to read bucket encryption: %w", err)
+	}
+	if enc == nil || enc.Algorithm == "" {
+		return fmt.Errorf("bucket %s still has no default encryption", bucketName(target))
+	}
+	return nil
+}
+
+func (e *s3EncryptionExecutor) Restore(ctx context.Context, target CloudAPITarget, snapshot map[string]interface{}) error {
+	var enc *cloudproviders.S3Encryption
+	if err := fromStateValue(snapshot["encryption"], &enc); err != nil {
+		return err
+	}
+	if enc == nil {
+		return e.client.AWSDeleteBucketEncryption(ctx, target.awsCredentials(), bucketName(target))
+	}
+	return e.client.AWSPutBucketEncryption(ctx, target.awsCredentials(), bucketName(target), *enc)
+}
+
+// s3PublicAccessExecutor turns on every public access block setting of a bucket
+type s3PublicAccessExecutor struct {
+	client CloudRemediationClient
+}
+
+func (e *s3PublicAccessExecutor) Snapshot(ctx context.Context, target CloudAPITarget) (map[string]interface{}, error) {
+	pab, err := e.client.AWSGetPublicAccessBlock(ctx, target.awsCredentials(), bucketName(target))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public access block: %w", err)
+	}
+	return map[string]interface{}{"public_access_block": toStateValue(pab)}, nil
+}
+
+func (e *s3PublicAccessExecutor) Apply(ctx context.Context, target CloudAPITarget) ([]string, error) {
+	bucket := bucketName(target)
+	pab := cloudproviders.S3PublicAccessBlock{
+		BlockPublicACLs:       true,
+		IgnorePublicACLs:      true,
+		BlockPublicPolicy:     true,
+		RestrictPublicBuckets: true,
+	}
+	if err := e.client.AWSPutPublicAccessBlock(ctx, target.awsCredentials(), bucket, pab); err != nil {
+		return nil, fmt.Errorf("failed to block public access: %w", err)
+	}
+	return []string{fmt.Sprintf("Blocked all public access on bucket %s", bucket)}, nil
+}
+
+func (e *s3PublicAccessExecutor) Verify(ctx context.Context, target CloudAPITarget) error {
+	pab, err := e.client.AWSGetPublicAccessBlock(ctx, target.awsCredentials(), bucketName(target))
+	if err != nil {
+		return fmt.Errorf("failed to read public access block: %w", err)
+	}
+	if pab == nil || !pab.BlockPublicACLs || !pab.IgnorePublicACLs || !pab.BlockPublicPolicy || !pab.RestrictPublicBuckets {
+		return fmt.Errorf("bucket %s is not fully blocked from public access", bucketName(target))
+	}
+	return nil
+}
+
+func (e *s3PublicAccessExecutor) Restore(ctx context.Context, target CloudAPITarget, snapshot map[string]interface{}) error {
+	var pab *cloudproviders.S3PublicAccessBlock
+	if err := fromStateValue(snapshot["public_access_block"], &pab); err != nil {
+		return err
+	}
+	if pab == nil {
+		return e.client.AWSDeletePublicAccessBlock(ctx, target.awsCredentials(), bucketName(target))
+	}
+	return e.client.AWSPutPublicAccessBlock(ctx, target.awsCredentials(), bucketName(target), *pab)
+}
+
+// openIngressExecutor revokes security group ingress open to 0.0.0.0/0 or ::/0
+type openIngressExecutor struct {
+	client CloudRemediationClient
+}
+
+func (e *openIngressExecutor) Snapshot(ctx context.Context, target CloudAPITarget) (map[string]interface{}, error) {
+	rules, err := e.client.AWSGetSecurityGroupIngress(ctx, target.awsCredentials(), groupID(target))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security group: %w", err)
+	}
+	return map[string]interface{}{"revoked_rules": toStateValue(openIngressRules(rules))}, nil
+}
+
+func (e *openIngressExecutor) Apply(ctx context.Context, target CloudAPITarget) ([]string, error) {
+	group := groupID(target)
+	rules, err := e.client.AWSGetSecurityGroupIngress(ctx, target.awsCredentials(), group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security group: %w", err)
+	}
+
+	open := openIngressRules(rules)
+	if len(open) == 0 {
+		return nil, fmt.Errorf("security group %s has no ingress open to the internet", group)
+	}
+	if err := e.client.AWSRevokeSecurityGroupIngress(ctx, target.awsCredentials(), group, open); err != nil {
+		return nil, fmt.Errorf("failed to revoke ingress: %w", err)
+	}
+
+	changes := make([]string, len(open))
+	for i, rule := range open {
+		changes[i] = fmt.Sprintf("Revoked %s %d-%d from %s on %s", rule.Protocol, rule.FromPort, rule.ToPort,
+			strings.Join(append(append([]string{}, rule.CIDRs...), rule.IPv6CIDRs...), ", "), group)
+	}
+	return changes, nil
+}
+
+func (e *openIngressExecutor) Verify(ctx context.Context, target CloudAPITarget) error {
+	rules, err := e.client.AWSGetSecurityGroupIngress(ctx, target.awsCredentials(), groupID(target))
+	if err != nil {
+		return fmt.Errorf("failed to read security group: %w", err)
+	}
+	if open := openIngressRules(rules); len(open) > 0 {
+		return fmt.Errorf("security group %s still has %d open ingress rules", groupID(target), len(open))
+	}
+	return nil
+}
+
+func (e *openIngressExecutor) Restore(ctx context.Context, target CloudAPITarget, snapshot map[string]interface{}) error {
+	var rules []cloudproviders.SecurityGroupRule
+	if err := fromStateValue(snapshot["revoked_rules"], &rules); err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return e.client.AWSAuthorizeSecurityGroupIngress(ctx, target.awsCredentials(), groupID(target), rules)
+}
+
+// openIngressRules returns the parts of the rules that allow traffic from anywhere
+func openIngressRules(rules []cloudproviders.SecurityGroupRule) []cloudproviders.SecurityGroupRule {
+	var open []cloudproviders.SecurityGroupRule
+	for _, rule := range rules {
+		match := cloudproviders.SecurityGroupRule{Protocol: rule.Protocol, FromPort: rule.FromPort, ToPort: rule.ToPort}
+		for _, cidr := range rule.CIDRs {
+			if cidr == "0.0.0.0/0" {
+				match.CIDRs = append(match.CIDRs, cidr)
+			}
+		}
+		for _, cidr := range rule.IPv6CIDRs {
+			if cidr == "::/0" {
+				match.IPv6CIDRs = append(match.IPv6CIDRs, cidr)
+			}
+		}
+		if len(match.CIDRs) > 0 || len(match.IPv6CIDRs) > 0 {
+			open = append(open, match)
+		}
+	}
+	return open
+}
+
+// bucketName resolves the bucket from the "bucket" parameter or an "s3-" resource ID
+func bucketName(target CloudAPITarget) string {
+	return target.param("bucket", strings.TrimPrefix(target.ResourceID, "s3-"))
+}
+
+// groupID resolves the security group from the "group_id" parameter or the resource ID
+func groupID(target CloudAPITarget) string {
+	return target.param("group_id", target.ResourceID)
+}
+
+// toStateValue converts a typed value to its JSON form for storage in rollback data
+func toStateValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// fromStateValue decodes a value stored by toStateValue
+func fromStateValue(v interface{}, out interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("invalid rollback data: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid rollback data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+)
+
+// iacPatch is the set of edits that bring an IaC file back to the approved
+// configuration. Each drift change is reverted: removed and modified fields
+// are set back to their baseline value and added fields are deleted.
+type iacPatch struct {
+	// Address selects the resource to patch. For Terraform it is
+	// "<type>.<name>"; for YAML it is a dotted path to the mapping that holds
+	// the resource configuration (e.g. "Resources.LogsBucket.Properties").
+	Address string
+	Changes []drift.Change
+}
+
+// apply rewrites content and returns the new file and a description of
+// each edit. Comments and formatting outside the edited fields are kept.
+func (p iacPatch) apply(filePath string, content []byte) ([]byte, []string, error) {
+	changes := make([]drift.Change, len(p.Changes))
+	copy(changes, p.Changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".tf", ".hcl":
+		return p.applyHCL(filePath, content, changes)
+	case ".yaml", ".yml":
+		return p.applyYAML(content, changes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported IaC file type: %s", filePath)
+	}
+}
+
+func (p iacPatch) applyHCL(filePath string, content []byte, changes []drift.Change) ([]byte, []string, error) {
+	var edits []string
+	// Each change is applied to a fresh parse of the previous result so that
+	// blocks created by one change are reused by the next
+	for _, c := range changes {
+		file, diags := hclsyntax.ParseConfig(content, filePath, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+		}
+		block, err := p.findHCLResource(file.Body.(*hclsyntax.Body), filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		segments := strings.Split(c.Path, ".")
+		var edit *textEdit
+		if c.ChangeType == drift.ChangeAdded {
+			edit = removeHCLAttribute(content, block, segments)
+			if edit != nil {
+				edits = append(edits, fmt.Sprintf("Removed %s", c.Path))
+			}
+		} else {
+			if edit, err = setHCLAttribute(content, block, segments, c.OldValue); err != nil {
+				return nil, nil, fmt.Errorf("cannot patch %s: %w", c.Path, err)
+			}
+			edits = append(edits, fmt.Sprintf("Set %s to %s", c.Path, formatPatchValue(c.OldValue)))
+		}
+		if edit != nil {
+			content = edit.apply(content)
+		}
+	}
+	return content, edits, nil
+}
+
+// findHCLResource returns the resource block selected by the patch address,
+// or the only resource in the file when no address is set
+func (p iacPatch) findHCLResource(body *hclsyntax.Body, filePath string) (*hclsyntax.Block, error) {
+	var resources []*hclsyntax.Block
+	for _, block := range body.Blocks {
+		if block.Type != "resource" {
+			continue
+		}
+		if p.Address == "" || (len(block.Labels) == 2 && block.Labels[0]+"."+block.Labels[1] == p.Address) {
+			resources = append(resources, block)
+		}
+	}
+	switch {
+	case len(resources) == 0 && p.Address != "":
+		return nil, fmt.Errorf("resource %s not found in %s", p.Address, filePath)
+	case len(resources) == 0:
+		return nil, fmt.Errorf("no resources found in %s", filePath)
+	case len(resources) > 1:
+		return nil, fmt.Errorf("%s defines %d resources; set the resource_address parameter", filePath, len(resources))
+	}
+	return resources[0], nil
+}
+
+// textEdit replaces the bytes in [start, end) with text
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+func (e *textEdit) apply(content []byte) []byte {
+	out := make([]byte, 0, len(content)+len(e.text))
+	out = append(out, content[:e.start]...)
+	out = append(out, e.text...)
+	return append(out, content[e.end:]...)
+}
+
+// setHCLAttribute sets the attribute at the end of segments, treating
+// earlier segments as nested blocks and creating them when missing
+func setHCLAttribute(content []byte, block *hclsyntax.Block, segments []string, value interface{}) (*textEdit, error) {
+	for i, seg := range segments[:len(segments)-1] {
+		if _, ok := block.Body.Attributes[seg]; ok {
+			return nil, fmt.Errorf("%s is set by an expression, not a block", seg)
+		}
+		nested := findHCLBlock(block.Body, seg)
+		if nested == nil {
+			return insertIntoHCLBlock(content, block, func(indent string) string {
+				return hclNestedText(segments[i:], value, indent)
+			}), nil
+		}
+		block = nested
+	}
+
+	name := segments[len(segments)-1]
+	if attr, ok := block.Body.Attributes[name]; ok {
+		r := attr.Expr.Range()
+		return &textEdit{start: r.Start.Byte, end: r.End.Byte, text: hclLiteral(value, lineIndent(content, attr.SrcRange.Start.Byte))}, nil
+	}
+	return insertIntoHCLBlock(content, block, func(indent string) string {
+		return indent + name + " = " + hclLiteral(value, indent) + "\n"
+	}), nil
+}
+
+func removeHCLAttribute(content []byte, block *hclsyntax.Block, segments []string) *textEdit {
+	for _, seg := range segments[:len(segments)-1] {
+		if block = findHCLBlock(block.Body, seg); block == nil {
+			return nil
+		}
+	}
+	attr, ok := block.Body.Attributes[segments[len(segments)-1]]
+	if !ok {
+		return nil
+	}
+
+	// Remove whole lines so no blank line is left behind
+	start := lineStart(content, attr.SrcRange.Start.Byte)
+	end := attr.SrcRange.End.Byte
+	for end < len(content) && content[end] != '\n' {
+		end++
+	}
+	if end < len(content) {
+		end++
+	}
+	return &textEdit{start: start, end: end}
+}
+
+func findHCLBlock(body *hclsyntax.Body, blockType string) *hclsyntax.Block {
+	for _, b := range body.Blocks {
+		if b.Type == blockType {
+			return b
+		}
+	}
+	return nil
+}
+
+// insertIntoHCLBlock adds lines just before the block's closing brace,
+// indented one level deeper than the block itself
+func insertIntoHCLBlock(content []byte, block *hclsyntax.Block, lines func(indent string) string) *textEdit {
+	indent := lineIndent(content, block.TypeRange.Start.Byte) + "  "
+	closeBrace := block.CloseBraceRange.Start.Byte
+	start := lineStart(content, closeBrace)
+	if strings.TrimSpace(string(content[start:closeBrace])) == "" {
+		return &textEdit{start: start, end: start, text: lines(indent)}
+	}
+	// Closing brace shares a line with other content, e.g. "{}"
+	return &textEdit{start: closeBrace, end: closeBrace, text: "\n" + lines(indent) + indent[:len(indent)-2]}
+}
+
+func hclNestedText(segments []string, value interface{}, indent string) string {
+	if len(segments) == 1 {
+		return indent + segments[0] + " = " + hclLiteral(value, indent) + "\n"
+	}
+	return indent + segments[0] + " {\n" + hclNestedText(segments[1:], value, indent+"  ") + indent + "}\n"
+}
+
+// hclLiteral renders a JSON-decoded value as an HCL expression
+func hclLiteral(v interface{}, indent string) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case string:
+		quoted := strconv.Quote(val)
+		quoted = strings.ReplaceAll(quoted, "${", "$${")
+		return strings.ReplaceAll(quoted, "%{", "%%{")
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = hclLiteral(item, indent)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, k := range keys {
+			key := k
+			if !hclsyntax.ValidIdentifier(k) {
+				key = strconv.Quote(k)
+			}
+			b.WriteString(indent + "  " + key + " = " + hclLiteral(val[k], indent+"  ") + "\n")
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	default:
+		return hclLiteral(formatPatchValue(val), indent)
+	}
+}
+
+func lineStart(content []byte, offset int) int {
+	for offset > 0 && content[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+func lineIndent(content []byte, offset int) string {
+	start := lineStart(content, offset)
+	end := start
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
+
+func (p iacPatch) applyYAML(content []byte, changes []drift.Change) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("YAML document must be a mapping")
+	}
+
+	root := doc.Content[0]
+	if p.Address != "" {
+		root = yamlLookup(root, strings.Split(p.Address, "."))
+		if root == nil {
+			return nil, nil, fmt.Errorf("resource %s not found", p.Address)
+		}
+		if root.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("resource %s is not a mapping", p.Address)
+		}
+	}
+
+	var edits []string
+	for _, c := range changes {
+		segments := strings.Split(c.Path, ".")
+		if c.ChangeType == drift.ChangeAdded {
+			if removeYAMLKey(root, segments) {
+				edits = append(edits, fmt.Sprintf("Removed %s", c.Path))
+			}
+			continue
+		}
+
+		var val yaml.Node
+		if err := val.Encode(c.OldValue); err != nil {
+			return nil, nil, fmt.Errorf("cannot express %s in YAML: %w", c.Path, err)
+		}
+		if err := setYAMLKey(root, segments, &val); err != nil {
+			return nil, nil, fmt.Errorf("cannot patch %s: %w", c.Path, err)
+		}
+		edits = append(edits, fmt.Sprintf("Set %s to %s", c.Path, formatPatchValue(c.OldValue)))
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), edits, nil
+}
+
+func yamlLookup(node *yaml.Node, segments []string) *yaml.Node {
+	for _, seg := range segments {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == seg {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// setYAMLKey sets the key at the end of segments, creating missing
+// mappings on the way. Existing values that are not mappings are never
+// replaced by one.
+func setYAMLKey(node *yaml.Node, segments []string, val *yaml.Node) error {
+	for i, seg := range segments {
+		child := val
+		if i < len(segments)-1 {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		found := false
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value != seg {
+				continue
+			}
+			found = true
+			if child == val {
+				node.Content[j+1] = child
+			} else if node.Content[j+1].Kind == yaml.MappingNode {
+				child = node.Content[j+1]
+			} else {
+				return fmt.Errorf("%s is not a mapping", strings.Join(segments[:i+1], "."))
+			}
+			break
+		}
+		if !found {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, child)
+		}
+		node = child
+	}
+	return nil
+}
+
+func removeYAMLKey(node *yaml.Node, segments []string) bool {
+	parent := yamlLookup(node, segments[:len(segments)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+	key := segments[len(segments)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func formatPatchValue(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/remediation"
+	"github.com/pratik-mahalle/infraudit/internal/integrations"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+func TestIaCPatch_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		address  string
+		content  string
+		changes  []drift.Change
+		want     []string
+		notWant  []string
+		wantErr  bool
+	}{
+		{
+			name:     "terraform modified and removed fields",
+			filePath: "main.tf",
+			content: `# logs bucket
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  acl    = "public-read" # drifted
+
+  versioning {
+    enabled = false
+  }
+}
+`,
+			changes: []drift.Change{
+				{Path: "acl", OldValue: "private", NewValue: "public-read", ChangeType: drift.ChangeModified},
+				{Path: "versioning.enabled", OldValue: true, NewValue: false, ChangeType: drift.ChangeModified},
+				{Path: "encryption.enabled", OldValue: true, ChangeType: drift.ChangeRemoved},
+			},
+			want:    []string{"# logs bucket", `acl    = "private" # drifted`, "enabled = true", "  encryption {\n    enabled = true\n  }\n}"},
+			notWant: []string{"public-read", "enabled = false"},
+		},
+		{
+			name:     "terraform added field is removed",
+			filePath: "main.tf",
+			content:  "resource \"aws_instance\" \"web\" {\n  ami = \"ami-1\"\n  public_ip = true\n}\n",
+			changes: []drift.Change{
+				{Path: "public_ip", NewValue: true, ChangeType: drift.ChangeAdded},
+			},
+			want:    []string{"resource \"aws_instance\" \"web\" {\n  ami = \"ami-1\"\n}\n"},
+			notWant: []string{"public_ip"},
+		},
+		{
+			name:     "terraform address selects resource",
+			filePath: "main.tf",
+			address:  "aws_s3_bucket.b",
+			content:  "resource \"aws_s3_bucket\" \"a\" {\n  acl = \"x\"\n}\n\nresource \"aws_s3_bucket\" \"b\" {\n  acl = \"x\"\n}\n",
+			changes: []drift.Change{
+				{Path: "acl", OldValue: "private", NewValue: "x", ChangeType: drift.ChangeModified},
+			},
+			want: []string{"\"a\" {\n  acl = \"x\"", "\"b\" {\n  acl = \"private\""},
+		},
+		{
+			name:     "terraform ambiguous resource",
+			filePath: "main.tf",
+			content:  "resource \"a\" \"a\" {}\nresource \"b\" \"b\" {}\n",
+			changes:  []drift.Change{{Path: "acl", OldValue: "private", ChangeType: drift.ChangeModified}},
+			wantErr:  true,
+		},
+		{
+			name:     "cloudformation yaml",
+			filePath: "stack.yaml",
+			address:  "Resources.Logs.Properties",
+			content:  "Resources:\n  Logs:\n    Type: AWS::S3::Bucket\n    Properties:\n      AccessControl: PublicRead\n",
+			changes: []drift.Change{
+				{Path: "AccessControl", OldValue: "Private", NewValue: "PublicRead", ChangeType: drift.ChangeModified},
+				{Path: "VersioningConfiguration.Status", OldValue: "Enabled", ChangeType: drift.ChangeRemoved},
+			},
+			want:    []string{"AccessControl: Private", "VersioningConfiguration:\n        Status: Enabled"},
+			notWant: []string{"PublicRead"},
+		},
+		{
+			name:     "cloudformation yaml keeps non-mapping values",
+			filePath: "stack.yaml",
+			address:  "Resources.Logs.Properties",
+			content:  "Resources:\n  Logs:\n    Properties:\n      Tags:\n        - Key: team\n          Value: infra\n",
+			changes: []drift.Change{
+				{Path: "Tags.Owner", OldValue: "infra", ChangeType: drift.ChangeRemoved},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "unsupported file type",
+			filePath: "main.json",
+			content:  "{}",
+			changes:  []drift.Change{{Path: "acl", OldValue: "private", ChangeType: drift.ChangeModified}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := iacPatch{Address: tt.address, Changes: tt.changes}
+			got, edits, err := patch.apply(tt.filePath, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(edits) == 0 {
+				t.Error("apply() reported no edits")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(got), w) {
+					t.Errorf("apply() output missing %q:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(string(got), w) {
+					t.Errorf("apply() output still contains %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRemediationService_ExecuteIaCPR_LocalForge(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+
+	// Bare repository with one Terraform file on main
+	root := t.TempDir()
+	bare := filepath.Join(root, "infra.git")
+	work := filepath.Join(root, "work")
+	runGit(t, "", "init", "--bare", "-b", "main", bare)
+	runGit(t, "", "init", "-b", "main", work)
+	tf := "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n  acl    = \"public-read\"\n}\n"
+	if err := os.WriteFile(filepath.Join(work, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", "-A")
+	runGit(t, work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-m", "initial")
+	runGit(t, work, "push", bare, "main")
+
+	driftRepo := testutil.NewMockDriftRepository()
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	driftService := NewDriftService(driftRepo, testutil.NewMockBaselineRepository(), testutil.NewMockResourceRepository(), nil, nil, log)
+	driftID, _ := driftRepo.Create(ctx, &drift.Drift{
+		UserID:     1,
+		ResourceID: "s3-logs",
+		Changes: []drift.Change{
+			{Path: "acl", OldValue: "private", NewValue: "public-read", ChangeType: drift.ChangeModified},
+		},
+	})
+	driftIDStr := strconv.FormatInt(driftID, 10)
+
+	repo := testutil.NewMockRemediationRepository()
+	forges := []integrations.Forge{integrations.NewLocalForge()}
+	service := NewRemediationService(repo, driftService, nil, testutil.NewMockProviderRepository(), testutil.NewMockResourceRepository(), nil, forges, log).(*RemediationService)
+
+	action := &remediation.Action{
+		ID:              "0123456789abcdef",
+		UserID:          1,
+		DriftID:         &driftIDStr,
+		RemediationType: remediation.RemediationTypeIaCPR,
+		Strategy: &remediation.Strategy{
+			Type:       remediation.RemediationTypeIaCPR,
+			Repository: bare,
+			FilePath:   "main.tf",
+		},
+	}
+
+	result, err := service.executeIaCPR(ctx, action)
+	if err != nil {
+		t.Fatalf("executeIaCPR() error = %v", err)
+	}
+	if result.PullRequestURL != "file://"+bare+"#infraudit/remediation-01234567" {
+		t.Errorf("PullRequestURL = %q", result.PullRequestURL)
+	}
+
+	got := runGit(t, bare, "show", "infraudit/remediation-01234567:main.tf")
+	if !strings.Contains(got, `acl    = "private"`) {
+		t.Errorf("patched main.tf =\n%s", got)
+	}
+	if base := runGit(t, bare, "show", "main:main.tf"); !strings.Contains(base, "public-read") {
+		t.Error("base branch must not be modified")
+	}
+
+	// Running again against an already-fixed branch is an error, not an empty PR
+	action.Strategy.Branch = "second"
+	action.Strategy.Parameters = map[string]interface{}{"base_branch": "infraudit/remediation-01234567"}
+	if _, err := service.executeIaCPR(ctx, action); err == nil {
+		t.Error("executeIaCPR() should fail when there is nothing to change")
+	}
+}
+
+func TestRemediationService_ExecuteIaCPR_LocalRepositoryNotAllowed(t *testing.T) {
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+	repo := testutil.NewMockRemediationRepository()
+	service := NewRemediationService(repo, nil, nil, testutil.NewMockProviderRepository(), testutil.NewMockResourceRepository(), nil, nil, log).(*RemediationService)
+
+	action := &remediation.Action{
+		UserID:          1,
+		RemediationType: remediation.RemediationTypeIaCPR,
+		Strategy: &remediation.Strategy{
+			Type:         remediation.RemediationTypeIaCPR,
+			Repository:   t.TempDir(),
+			FilePath:     "main.tf",
+			PatchContent: "diff --git a/main.tf b/main.tf\n",
+		},
+	}
+	if _, err := service.executeIaCPR(context.Background(), action); err == nil {
+		t.Error("executeIaCPR() should reject local repositories unless the local forge is configured")
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
 	"github.com/pratik-mahalle/infraudit/internal/domain/remediation"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/domain/vulnerability"
+	"github.com/pratik-mahalle/infraudit/internal/integrations"
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 )
 
@@ -19,6 +25,11 @@ type RemediationService struct {
 	repo         remediation.Repository
 	driftService drift.Service
 	vulnService  vulnerability.Service
+	providerRepo provider.Repository
+	resourceRepo resource.Repository
+	executors    *ExecutorRegistry
+	git          *integrations.GitClient
+	forges       []integrations.Forge
 	logger       *logger.Logger
 }
 
@@ -27,16 +38,33 @@ func NewRemediationService(
 	repo remediation.Repository,
 	driftService drift.Service,
 	vulnService vulnerability.Service,
+	providerRepo provider.Repository,
+	resourceRepo resource.Repository,
+	git *integrations.GitClient,
+	forges []integrations.Forge,
 	log *logger.Logger,
 ) remediation.Service {
+	if git == nil {
+		git = integrations.NewGitClient("", "", "")
+	}
 	return &RemediationService{
 		repo:         repo,
 		driftService: driftService,
 		vulnService:  vulnService,
+		providerRepo: providerRepo,
+		resourceRepo: resourceRepo,
+		executors:    NewDefaultExecutorRegistry(&DefaultCloudRemediationClient{}),
+		git:          git,
+		forges:       append([]integrations.Forge(nil), forges...),
 		logger:       log,
 	}
 }
 
+// SetExecutorRegistry sets the Cloud API executor registry (used for testing)
+func (s *RemediationService) SetExecutorRegistry(registry *ExecutorRegistry) {
+	s.executors = registry
+}
+
 // SuggestForDrift generates remediation suggestions for a drift
 func (s *RemediationService) SuggestForDrift(ctx context.Context, driftID string) ([]*remediation.Suggestion, error) {
 	// Parse drift ID as int64
@@ -71,8 +99,8 @@ func (s *RemediationService) generateDriftSuggestions(d *drift.Drift) []*remedia
 			ID:              uuid.New().String(),
 			IssueType:       "drift",
 			IssueID:         fmt.Sprintf("%d", d.ID),
-			Title:           fmt.Sprintf("Update IaC to match deployed configuration for resource %s", d.ResourceID),
-			Description:     "Generate a pull request to update the Infrastructure as Code definition to match the current deployed state.",
+			Title:           fmt.Sprintf("Restore approved configuration in IaC for resource %s", d.ResourceID),
+			Description:     "Generate a pull request that brings the Infrastructure as Code definition back in line with the approved baseline.",
 			Severity:        d.Severity,
 			RemediationType: remediation.RemediationTypeIaCPR,
 			Strategy: &remediation.Strategy{
@@ -80,7 +108,7 @@ func (s *RemediationService) generateDriftSuggestions(d *drift.Drift) []*remedia
 				Description: "Create a pull request with the updated configuration",
 				Steps: []remediation.RemediationStep{
 					{Order: 1, Name: "Analyze drift", Description: "Compare IaC definition with deployed state"},
-					{Order: 2, Name: "Generate patch", Description: "Create patch to align IaC with deployed state"},
+					{Order: 2, Name: "Generate patch", Description: "Create patch to align IaC with the approved state"},
 					{Order: 3, Name: "Create branch", Description: "Create a new branch for the changes"},
 					{Order: 4, Name: "Commit changes", Description: "Commit the patched file"},
 					{Order: 5, Name: "Create PR", Description: "Create a pull request for review"},
@@ -94,6 +122,22 @@ func (s *RemediationService) generateDriftSuggestions(d *drift.Drift) []*remedia
 
 	// Generate Cloud API suggestion for immediate fix
 	if d.Severity == drift.SeverityCritical || d.Severity == drift.SeverityHigh {
+		cloudStrategy := &remediation.Strategy{
+			Type:        remediation.RemediationTypeCloudAPI,
+			Description: "Apply fix via cloud provider API",
+			Steps: []remediation.RemediationStep{
+				{Order: 1, Name: "Validate access", Description: "Verify cloud credentials and permissions"},
+				{Order: 2, Name: "Backup state", Description: "Record current state for rollback"},
+				{Order: 3, Name: "Apply fix", Description: "Execute API call to apply remediation"},
+				{Order: 4, Name: "Verify", Description: "Confirm the fix was applied successfully"},
+			},
+		}
+		if apiAction := cloudAPIActionForDrift(d); apiAction != "" {
+			cloudStrategy.Provider = provider.ProviderAWS
+			cloudStrategy.APIAction = apiAction
+			cloudStrategy.Parameters = map[string]interface{}{"resource_id": d.ResourceID}
+		}
+
 		suggestions = append(suggestions, &remediation.Suggestion{
 			ID:              uuid.New().String(),
 			IssueType:       "drift",
@@ -102,19 +146,10 @@ func (s *RemediationService) generateDriftSuggestions(d *drift.Drift) []*remedia
 			Description:     "Apply the fix directly through the cloud provider API to immediately resolve the security issue.",
 			Severity:        d.Severity,
 			RemediationType: remediation.RemediationTypeCloudAPI,
-			Strategy: &remediation.Strategy{
-				Type:        remediation.RemediationTypeCloudAPI,
-				Description: "Apply fix via cloud provider API",
-				Steps: []remediation.RemediationStep{
-					{Order: 1, Name: "Validate access", Description: "Verify cloud credentials and permissions"},
-					{Order: 2, Name: "Backup state", Description: "Record current state for rollback"},
-					{Order: 3, Name: "Apply fix", Description: "Execute API call to apply remediation"},
-					{Order: 4, Name: "Verify", Description: "Confirm the fix was applied successfully"},
-				},
-			},
-			Risk:          "medium",
-			Impact:        "Immediate fix applied to cloud resource, can be rolled back",
-			EstimatedTime: "2 minutes",
+			Strategy:        cloudStrategy,
+			Risk:            "medium",
+			Impact:          "Immediate fix applied to cloud resource, can be rolled back",
+			EstimatedTime:   "2 minutes",
 		})
 	}
 
@@ -140,6 +175,28 @@ func (s *RemediationService) generateDriftSuggestions(d *drift.Drift) []*remedia
 	return suggestions
 }
 
+// cloudAPIActionForDrift picks the built-in Cloud API action that undoes a
+// drift, based on the rules that classified its changes. Open security group
+// ingress is not suggested: synced instances only record the IDs of their
+// groups, not their rules, so the group to fix cannot be told from a drift.
+// That action can still be requested with an explicit group_id parameter.
+func cloudAPIActionForDrift(d *drift.Drift) string {
+	isBucket := strings.HasPrefix(d.ResourceID, "s3-")
+	for _, c := range d.Changes {
+		switch c.RuleID {
+		case "encryption-disabled":
+			if isBucket {
+				return remediation.APIActionS3EnableEncryption
+			}
+		case "storage-public-access-block-disabled", "storage-public-access-enabled":
+			if isBucket {
+				return remediation.APIActionS3BlockPublicAccess
+			}
+		}
+	}
+	return ""
+}
+
 // generateManualSteps creates manual remediation steps based on drift type
 func (s *RemediationService) generateManualSteps(d *drift.Drift) []remediation.RemediationStep {
 	steps := []remediation.RemediationStep{
@@ -349,34 +406,307 @@ func (s *RemediationService) Execute(ctx context.Context, actionID string) error
 	return nil
 }
 
-// executeIaCPR executes IaC PR remediation
+// executeIaCPR executes IaC PR remediation: it clones the repository, commits
+// the patched IaC file to a new branch and opens a pull request on the forge
+// that hosts the repository
 func (s *RemediationService) executeIaCPR(ctx context.Context, action *remediation.Action) (*remediation.Result, error) {
-	// TODO: Integrate with GitHub/GitLab API
-	// For now, return a placeholder result
+	strategy := action.Strategy
+	if strategy == nil {
+		return nil, fmt.Errorf("action has no strategy")
+	}
+	if strategy.FilePath == "" {
+		return nil, fmt.Errorf("strategy has no file_path")
+	}
+
+	repo, err := integrations.ParseRepository(strategy.Repository)
+	if err != nil {
+		return nil, err
+	}
+	forge := s.forgeFor(repo)
+	if forge == nil {
+		return nil, fmt.Errorf("no forge configured for %s", repo)
+	}
+
+	workDir, err := os.MkdirTemp("", "infraudit-remediation-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+	dir := filepath.Join(workDir, "repo")
+
+	baseBranch := strategyParam(strategy, "base_branch")
+	if err := s.git.Clone(ctx, forge.CloneURL(repo), dir, baseBranch); err != nil {
+		return nil, err
+	}
+	if baseBranch == "" {
+		if baseBranch, err = s.git.CurrentBranch(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	branch := strategy.Branch
+	if branch == "" {
+		branch = "infraudit/remediation-" + shortID(action.ID)
+	}
+	if err := s.git.CreateBranch(ctx, dir, branch); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.patchIaCFile(ctx, action, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	title := fmt.Sprintf("Restore approved configuration in %s", strategy.FilePath)
+	if err := s.git.CommitAll(ctx, dir, title+"\n\n"+strings.Join(changes, "\n")); err != nil {
+		return nil, err
+	}
+	if err := s.git.Push(ctx, dir, branch); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Opened by InfraAudit remediation action %s.\n\n", action.ID)
+	for _, c := range changes {
+		body += "- " + c + "\n"
+	}
+	prURL, err := forge.CreatePullRequest(ctx, repo, integrations.PullRequest{
+		Title:      title,
+		Body:       body,
+		HeadBranch: branch,
+		BaseBranch: baseBranch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"action_id":    action.ID,
+		"repository":   repo.String(),
+		"branch":       branch,
+		"pull_request": prURL,
+	}).Info("IaC remediation pull request opened")
+
 	return &remediation.Result{
 		Success:          true,
-		Message:          "IaC PR generation is configured but requires GitHub/GitLab integration",
+		Message:          fmt.Sprintf("Opened pull request for %s", strategy.FilePath),
+		ChangesMade:      changes,
+		PullRequestURL:   prURL,
 		RollbackPossible: false,
 		Details: map[string]interface{}{
-			"note": "Configure GITHUB_TOKEN or GITLAB_TOKEN to enable PR creation",
+			"forge":       forge.Name(),
+			"repository":  repo.String(),
+			"branch":      branch,
+			"base_branch": baseBranch,
+			"file_path":   strategy.FilePath,
 		},
 	}, nil
 }
 
-// executeCloudAPI executes Cloud API remediation
+// patchIaCFile updates the strategy's file in the cloned repository. An
+// explicit PatchContent is used as-is (a unified diff is applied, anything
+// else replaces the file); otherwise a patch is generated from the drift's
+// changes that restores the approved values.
+func (s *RemediationService) patchIaCFile(ctx context.Context, action *remediation.Action, dir string) ([]string, error) {
+	strategy := action.Strategy
+	path := filepath.Join(dir, filepath.Clean("/"+strategy.FilePath))
+
+	if strategy.PatchContent != "" {
+		if isUnifiedDiff(strategy.PatchContent) {
+			if err := s.git.ApplyPatch(ctx, dir, strategy.PatchContent); err != nil {
+				return nil, err
+			}
+			return []string{fmt.Sprintf("Applied patch to %s", strategy.FilePath)}, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(strategy.PatchContent), 0o644); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Replaced %s", strategy.FilePath)}, nil
+	}
+
+	if action.DriftID == nil {
+		return nil, fmt.Errorf("strategy has no patch_content and action is not linked to a drift")
+	}
+	driftID, err := strconv.ParseInt(*action.DriftID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid drift ID: %w", err)
+	}
+	d, err := s.driftService.GetByID(ctx, action.UserID, driftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drift: %w", err)
+	}
+	if len(d.Changes) == 0 {
+		return nil, fmt.Errorf("drift %d has no recorded changes to revert", driftID)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", strategy.FilePath, err)
+	}
+	patch := iacPatch{Address: strategyParam(strategy, "resource_address"), Changes: d.Changes}
+	patched, changes, err := patch.apply(strategy.FilePath, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, patched, 0o644); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// forgeFor returns the first configured forge that hosts the repository
+func (s *RemediationService) forgeFor(repo integrations.RepositoryRef) integrations.Forge {
+	for _, f := range s.forges {
+		if f.Handles(repo) {
+			return f
+		}
+	}
+	return nil
+}
+
+func strategyParam(strategy *remediation.Strategy, key string) string {
+	if v, ok := strategy.Parameters[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func isUnifiedDiff(patch string) bool {
+	return strings.HasPrefix(patch, "diff --git") || strings.HasPrefix(patch, "--- ")
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// executeCloudAPI executes Cloud API remediation. The original configuration
+// is saved to the action's rollback data before anything is changed, and a
+// change that fails verification is rolled back immediately.
 func (s *RemediationService) executeCloudAPI(ctx context.Context, action *remediation.Action) (*remediation.Result, error) {
-	// TODO: Integrate with cloud provider SDK
-	// For now, return a placeholder result
+	if action.Strategy == nil {
+		return nil, fmt.Errorf("action has no strategy")
+	}
+
+	executor, ok := s.executors.Lookup(action.Strategy.Provider, action.Strategy.APIAction)
+	if !ok {
+		return nil, fmt.Errorf("no executor for provider %q action %q", action.Strategy.Provider, action.Strategy.APIAction)
+	}
+
+	target, err := s.cloudAPITarget(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := executor.Snapshot(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	rollbackData, err := json.Marshal(remediation.RollbackData{
+		OriginalState:   snapshot,
+		CanAutoRollback: true,
+		RollbackSteps: []remediation.RemediationStep{
+			{Order: 1, Name: "Restore configuration", Description: "Restore the configuration captured before remediation"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rollback data: %w", err)
+	}
+	action.RollbackData = rollbackData
+	if err := s.repo.Update(ctx, action); err != nil {
+		return nil, fmt.Errorf("failed to save rollback data: %w", err)
+	}
+
+	changes, err := executor.Apply(ctx, target)
+	if err != nil {
+		// Nothing was changed, so there is nothing to restore
+		return nil, err
+	}
+	if err := executor.Verify(ctx, target); err != nil {
+		if restoreErr := executor.Restore(ctx, target, snapshot); restoreErr != nil {
+			s.logger.ErrorWithErr(restoreErr, "Failed to restore configuration after failed remediation")
+			return nil, fmt.Errorf("%w (restore also failed: %v)", err, restoreErr)
+		}
+		return nil, fmt.Errorf("%w (original configuration restored)", err)
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"action_id":   action.ID,
+		"provider":    action.Strategy.Provider,
+		"api_action":  action.Strategy.APIAction,
+		"resource_id": target.ResourceID,
+	}).Info("Cloud API remediation applied")
+
 	return &remediation.Result{
 		Success:          true,
-		Message:          "Cloud API remediation requires cloud provider credentials",
+		Message:          fmt.Sprintf("Applied %s to %s", action.Strategy.APIAction, target.ResourceID),
+		ChangesMade:      changes,
 		RollbackPossible: true,
 		Details: map[string]interface{}{
-			"note": "Configure cloud provider credentials to enable API remediation",
+			"provider":    action.Strategy.Provider,
+			"api_action":  action.Strategy.APIAction,
+			"resource_id": target.ResourceID,
 		},
 	}, nil
 }
 
+// cloudAPITarget resolves the credentials, resource and parameters for a
+// Cloud API action. Parameters come from the strategy and its APIParams JSON;
+// the resource defaults to the drifted resource. The credentials are those of
+// the provider account the resource was synced from.
+func (s *RemediationService) cloudAPITarget(ctx context.Context, action *remediation.Action) (CloudAPITarget, error) {
+	target := CloudAPITarget{Params: make(map[string]interface{})}
+
+	for k, v := range action.Strategy.Parameters {
+		target.Params[k] = v
+	}
+	if action.Strategy.APIParams != "" {
+		if err := json.Unmarshal([]byte(action.Strategy.APIParams), &target.Params); err != nil {
+			return target, fmt.Errorf("invalid api_params: %w", err)
+		}
+	}
+
+	target.ResourceID = target.param("resource_id", "")
+	if target.ResourceID == "" && action.DriftID != nil {
+		driftID, err := strconv.ParseInt(*action.DriftID, 10, 64)
+		if err != nil {
+			return target, fmt.Errorf("invalid drift ID: %w", err)
+		}
+		d, err := s.driftService.GetByID(ctx, action.UserID, driftID)
+		if err != nil {
+			return target, fmt.Errorf("failed to get drift: %w", err)
+		}
+		target.ResourceID = d.ResourceID
+	}
+	if target.ResourceID == "" {
+		return target, fmt.Errorf("no resource to remediate")
+	}
+
+	// Act with the credentials of the account the resource was synced from
+	res, err := s.resourceRepo.GetByID(ctx, action.UserID, target.ResourceID)
+	if err != nil {
+		return target, fmt.Errorf("failed to get resource %s: %w", target.ResourceID, err)
+	}
+	if res.AccountID == 0 {
+		return target, fmt.Errorf("resource %s has no provider account; sync its account again", target.ResourceID)
+	}
+	account, err := s.providerRepo.GetByID(ctx, action.UserID, res.AccountID)
+	if err != nil {
+		return target, fmt.Errorf("failed to get account of resource %s: %w", target.ResourceID, err)
+	}
+	if account.Provider != action.Strategy.Provider {
+		return target, fmt.Errorf("resource %s belongs to a %s account, not %s", target.ResourceID, account.Provider, action.Strategy.Provider)
+	}
+	target.Credentials = account.Credentials
+
+	return target, nil
+}
+
 // executePolicy executes policy remediation
 func (s *RemediationService) executePolicy(ctx context.Context, action *remediation.Action) (*remediation.Result, error) {
 	// TODO: Integrate with policy management
@@ -457,7 +787,12 @@ func (s *RemediationService) Rollback(ctx context.Context, actionID string) erro
 		return fmt.Errorf("no rollback data available")
 	}
 
-	// TODO: Execute rollback based on rollback data
+	if action.RemediationType == remediation.RemediationTypeCloudAPI {
+		if err := s.rollbackCloudAPI(ctx, action); err != nil {
+			s.logger.ErrorWithErr(err, "Failed to roll back remediation action")
+			return err
+		}
+	}
 
 	action.Status = remediation.ActionStatusRolledBack
 	if err := s.repo.Update(ctx, action); err != nil {
@@ -471,6 +806,33 @@ func (s *RemediationService) Rollback(ctx context.Context, actionID string) erro
 	return nil
 }
 
+// rollbackCloudAPI restores the configuration captured before a Cloud API action
+func (s *RemediationService) rollbackCloudAPI(ctx context.Context, action *remediation.Action) error {
+	if action.Strategy == nil {
+		return fmt.Errorf("action has no strategy")
+	}
+
+	var data remediation.RollbackData
+	if err := json.Unmarshal(action.RollbackData, &data); err != nil {
+		return fmt.Errorf("invalid rollback data: %w", err)
+	}
+	if !data.CanAutoRollback {
+		return fmt.Errorf("action cannot be rolled back automatically")
+	}
+
+	executor, ok := s.executors.Lookup(action.Strategy.Provider, action.Strategy.APIAction)
+	if !ok {
+		return fmt.Errorf("no executor for provider %q action %q", action.Strategy.Provider, action.Strategy.APIAction)
+	}
+
+	target, err := s.cloudAPITarget(ctx, action)
+	if err != nil {
+		return err
+	}
+
+	return executor.Restore(ctx, target, data.OriginalState)
+}
+
 // GetAction retrieves a remediation action
 func (s *RemediationService) GetAction(ctx context.Context, id string) (*remediation.Action, error) {
 	return s.repo.GetByID(ctx, id)
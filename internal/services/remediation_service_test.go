@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
+	"github.com/pratik-mahalle/infraudit/internal/domain/remediation"
+	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+	cloudproviders "github.com/pratik-mahalle/infraudit/internal/providers"
+	"github.com/pratik-mahalle/infraudit/internal/testutil"
+)
+
+// fakeCloudRemediationClient keeps bucket and security group state in memory
+type fakeCloudRemediationClient struct {
+	encryption   map[string]*cloudproviders.S3Encryption
+	publicAccess map[string]*cloudproviders.S3PublicAccessBlock
+	ingress      map[string][]cloudproviders.SecurityGroupRule
+	ignorePuts   bool     // simulate an API call that succeeds but has no effect
+	revokeErr    error    // returned by AWSRevokeSecurityGroupIngress
+	authorized   int      // number of AWSAuthorizeSecurityGroupIngress calls
+	accessKeys   []string // access key IDs of the AWSPutBucketEncryption calls
+}
+
+func newFakeCloudRemediationClient() *fakeCloudRemediationClient {
+	return &fakeCloudRemediationClient{
+		encryption:   make(map[string]*cloudproviders.S3Encryption),
+		publicAccess: make(map[string]*cloudproviders.S3PublicAccessBlock),
+		ingress:      make(map[string][]cloudproviders.SecurityGroupRule),
+	}
+}
+
+func (f *fakeCloudRemediationClient) AWSGetBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3Encryption, error) {
+	return f.encryption[bucket], nil
+}
+
+func (f *fakeCloudRemediationClient) AWSPutBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, enc cloudproviders.S3Encryption) error {
+	f.accessKeys = append(f.accessKeys, creds.AccessKeyID)
+	if !f.ignorePuts {
+		f.encryption[bucket] = &enc
+	}
+	return nil
+}
+
+func (f *fakeCloudRemediationClient) AWSDeleteBucketEncryption(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error {
+	delete(f.encryption, bucket)
+	return nil
+}
+
+func (f *fakeCloudRemediationClient) AWSGetPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) (*cloudproviders.S3PublicAccessBlock, error) {
+	return f.publicAccess[bucket], nil
+}
+
+func (f *fakeCloudRemediationClient) AWSPutPublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string, pab cloudproviders.S3PublicAccessBlock) error {
+	if !f.ignorePuts {
+		f.publicAccess[bucket] = &pab
+	}
+	return nil
+}
+
+func (f *fakeCloudRemediationClient) AWSDeletePublicAccessBlock(ctx context.Context, creds cloudproviders.AWSCredentials, bucket string) error {
+	delete(f.publicAccess, bucket)
+	return nil
+}
+
+func (f *fakeCloudRemediationClient) AWSGetSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string) ([]cloudproviders.SecurityGroupRule, error) {
+	return f.ingress[groupID], nil
+}
+
+func (f *fakeCloudRemediationClient) AWSRevokeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error {
+	if f.revokeErr != nil {
+		return f.revokeErr
+	}
+	if f.ignorePuts {
+		return nil
+	}
+	var kept []cloudproviders.SecurityGroupRule
+	for _, existing := range f.ingress[groupID] {
+		revoked := false
+		for _, r := range rules {
+			if existing.Protocol == r.Protocol && existing.FromPort == r.FromPort && existing.ToPort == r.ToPort {
+				revoked = true
+			}
+		}
+		if !revoked {
+			kept = append(kept, existing)
+		}
+	}
+	f.ingress[groupID] = kept
+	return nil
+}
+
+func (f *fakeCloudRemediationClient) AWSAuthorizeSecurityGroupIngress(ctx context.Context, creds cloudproviders.AWSCredentials, groupID string, rules []cloudproviders.SecurityGroupRule) error {
+	f.authorized++
+	f.ingress[groupID] = append(f.ingress[groupID], rules...)
+	return nil
+}
+
+// newTestRemediationService connects two AWS accounts, prod and staging, and
+// syncs the test resources from staging
+func newTestRemediationService(client CloudRemediationClient) (*RemediationService, *testutil.MockRemediationRepository) {
+	ctx := context.Background()
+	repo := testutil.NewMockRemediationRepository()
+	providerRepo := testutil.NewMockProviderRepository()
+	var accounts []*provider.Provider
+	for _, name := range []string{"prod", "staging"} {
+		account := &provider.Provider{
+			UserID:   1,
+			Provider: provider.ProviderAWS,
+			Name:     name,
+			Credentials: provider.Credentials{
+				AWSAccessKeyID:     "AKIA" + strings.ToUpper(name),
+				AWSSecretAccessKey: "secret",
+				AWSRegion:          "us-east-1",
+			},
+		}
+		providerRepo.Upsert(ctx, account)
+		accounts = append(accounts, account)
+	}
+	resourceRepo := testutil.NewMockResourceRepository()
+	for _, id := range []string{"s3-logs", "s3-assets", "sg-123", "db-1"} {
+		resourceRepo.Create(ctx, &resource.Resource{UserID: 1, Provider: provider.ProviderAWS, AccountID: accounts[1].ID, ResourceID: id})
+	}
+	log := logger.New(logger.Config{Level: "error", Format: "json"})
+
+	service := NewRemediationService(repo, nil, nil, providerRepo, resourceRepo, nil, nil, log).(*RemediationService)
+	service.SetExecutorRegistry(NewDefaultExecutorRegistry(client))
+	return service, repo
+}
+
+func TestRemediationService_ExecuteCloudAPI(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiAction string
+		resource  string
+		setup     func(f *fakeCloudRemediationClient)
+		check     func(t *testing.T, f *fakeCloudRemediationClient)
+		restored  func(t *testing.T, f *fakeCloudRemediationClient)
+	}{
+		{
+			name:      "enable bucket encryption",
+			apiAction: remediation.APIActionS3EnableEncryption,
+			resource:  "s3-logs",
+			setup:     func(f *fakeCloudRemediationClient) {},
+			check: func(t *testing.T, f *fakeCloudRemediationClient) {
+				if enc := f.encryption["logs"]; enc == nil || enc.Algorithm != "AES256" {
+					t.Errorf("encryption = %+v, want AES256", enc)
+				}
+			},
+			restored: func(t *testing.T, f *fakeCloudRemediationClient) {
+				if enc, ok := f.encryption["logs"]; ok {
+					t.Errorf("encryption after rollback = %+v, want none", enc)
+				}
+			},
+		},
+		{
+			name:      "block public access",
+			apiAction: remediation.APIActionS3BlockPublicAccess,
+			resource:  "s3-assets",
+			setup: func(f *fakeCloudRemediationClient) {
+				f.publicAccess["assets"] = &cloudproviders.S3PublicAccessBlock{BlockPublicACLs: true}
+			},
+			check: func(t *testing.T, f *fakeCloudRemediationClient) {
+				pab := f.publicAccess["assets"]
+				if pab == nil || !pab.BlockPublicPolicy || !pab.RestrictPublicBuckets {
+					t.Errorf("public access block = %+v, want all settings enabled", pab)
+				}
+			},
+			restored: func(t *testing.T, f *fakeCloudRemediationClient) {
+				pab := f.publicAccess["assets"]
+				if pab == nil || !pab.BlockPublicACLs || pab.BlockPublicPolicy {
+					t.Errorf("public access block after rollback = %+v, want original", pab)
+				}
+			},
+		},
+		{
+			name:      "revoke open ingress",
+			apiAction: remediation.APIActionEC2RevokeOpenIngress,
+			resource:  "sg-123",
+			setup: func(f *fakeCloudRemediationClient) {
+				f.ingress["sg-123"] = []cloudproviders.SecurityGroupRule{
+					{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRs: []string{"0.0.0.0/0"}},
+					{Protocol: "tcp", FromPort: 443, ToPort: 443, CIDRs: []string{"10.0.0.0/8"}},
+				}
+			},
+			check: func(t *testing.T, f *fakeCloudRemediationClient) {
+				if rules := f.ingress["sg-123"]; len(rules) != 1 || rules[0].FromPort != 443 {
+					t.Errorf("ingress = %+v, want only the private rule", rules)
+				}
+			},
+			restored: func(t *testing.T, f *fakeCloudRemediationClient) {
+				if rules := f.ingress["sg-123"]; len(rules) != 2 {
+					t.Errorf("ingress after rollback = %+v, want both rules", rules)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeCloudRemediationClient()
+			tt.setup(client)
+			service, repo := newTestRemediationService(client)
+			ctx := context.Background()
+
+			action := &remediation.Action{
+				UserID:          1,
+				RemediationType: remediation.RemediationTypeCloudAPI,
+				Status:          remediation.ActionStatusInProgress,
+				Strategy: &remediation.Strategy{
+					Type:       remediation.RemediationTypeCloudAPI,
+					Provider:   provider.ProviderAWS,
+					APIAction:  tt.apiAction,
+					Parameters: map[string]interface{}{"resource_id": tt.resource},
+				},
+			}
+			repo.Create(ctx, action)
+
+			result, err := service.executeCloudAPI(ctx, action)
+			if err != nil {
+				t.Fatalf("executeCloudAPI() error = %v", err)
+			}
+			if !result.Success || !result.RollbackPossible || len(result.ChangesMade) == 0 {
+				t.Errorf("executeCloudAPI() result = %+v", result)
+			}
+			if len(repo.Actions[action.ID].RollbackData) == 0 {
+				t.Error("rollback data was not saved")
+			}
+			tt.check(t, client)
+
+			action.Status = remediation.ActionStatusCompleted
+			if err := service.Rollback(ctx, action.ID); err != nil {
+				t.Fatalf("Rollback() error = %v", err)
+			}
+			if action.Status != remediation.ActionStatusRolledBack {
+				t.Errorf("status after rollback = %s, want %s", action.Status, remediation.ActionStatusRolledBack)
+			}
+			tt.restored(t, client)
+		})
+	}
+}
+
+func TestRemediationService_ExecuteCloudAPI_UsesResourceAccount(t *testing.T) {
+	client := newFakeCloudRemediationClient()
+	service, repo := newTestRemediationService(client)
+	ctx := context.Background()
+	resources := service.resourceRepo.(*testutil.MockResourceRepository)
+	resources.Create(ctx, &resource.Resource{UserID: 1, Provider: provider.ProviderAWS, ResourceID: "s3-unsynced"})
+	resources.Create(ctx, &resource.Resource{UserID: 1, Provider: provider.ProviderAWS, AccountID: 99, ResourceID: "s3-removed"})
+
+	newAction := func(resourceID string) *remediation.Action {
+		action := &remediation.Action{
+			UserID:          1,
+			RemediationType: remediation.RemediationTypeCloudAPI,
+			Strategy: &remediation.Strategy{
+				Provider:   provider.ProviderAWS,
+				APIAction:  remediation.APIActionS3EnableEncryption,
+				Parameters: map[string]interface{}{"resource_id": resourceID},
+			},
+		}
+		repo.Create(ctx, action)
+		return action
+	}
+
+	// The resource is in the staging account, not the first one connected
+	if _, err := service.executeCloudAPI(ctx, newAction("s3-logs")); err != nil {
+		t.Fatalf("executeCloudAPI() error = %v", err)
+	}
+	if len(client.accessKeys) != 1 || client.accessKeys[0] != "AKIASTAGING" {
+		t.Fatalf("remediated with access keys %v, want the staging account's", client.accessKeys)
+	}
+
+	// Without a known account nothing is changed
+	for _, id := range []string{"s3-unsynced", "s3-removed", "s3-unknown"} {
+		if _, err := service.executeCloudAPI(ctx, newAction(id)); err == nil {
+			t.Errorf("executeCloudAPI(%s) should fail without the resource's account", id)
+		}
+	}
+	if len(client.accessKeys) != 1 {
+		t.Fatalf("remediated with access keys %v after failed account lookups", client.accessKeys)
+	}
+}
+
+func TestRemediationService_ExecuteCloudAPI_VerifyFailure(t *testing.T) {
+	client := newFakeCloudRemediationClient()
+	client.ignorePuts = true
+	service, repo := newTestRemediationService(client)
+	ctx := context.Background()
+
+	action := &remediation.Action{
+		UserID:          1,
+		RemediationType: remediation.RemediationTypeCloudAPI,
+		Strategy: &remediation.Strategy{
+			Provider:   provider.ProviderAWS,
+			APIAction:  remediation.APIActionS3EnableEncryption,
+			Parameters: map[string]interface{}{"resource_id": "s3-logs"},
+		},
+	}
+	repo.Create(ctx, action)
+
+	if _, err := service.executeCloudAPI(ctx, action); err == nil {
+		t.Fatal("executeCloudAPI() should fail when verification fails")
+	}
+	if _, ok := client.encryption["logs"]; ok {
+		t.Error("failed remediation should leave the bucket unchanged")
+	}
+}
+
+func TestRemediationService_ExecuteCloudAPI_ApplyFailure(t *testing.T) {
+	client := newFakeCloudRemediationClient()
+	client.ingress["sg-123"] = []cloudproviders.SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRs: []string{"0.0.0.0/0"}},
+	}
+	client.revokeErr = errors.New("UnauthorizedOperation")
+	service, repo := newTestRemediationService(client)
+	ctx := context.Background()
+
+	action := &remediation.Action{
+		UserID:          1,
+		RemediationType: remediation.RemediationTypeCloudAPI,
+		Strategy: &remediation.Strategy{
+			Provider:   provider.ProviderAWS,
+			APIAction:  remediation.APIActionEC2RevokeOpenIngress,
+			Parameters: map[string]interface{}{"resource_id": "sg-123"},
+		},
+	}
+	repo.Create(ctx, action)
+
+	_, err := service.executeCloudAPI(ctx, action)
+	if err == nil || !strings.Contains(err.Error(), "UnauthorizedOperation") {
+		t.Fatalf("executeCloudAPI() error = %v, want the revoke error", err)
+	}
+	if client.authorized != 0 {
+		t.Errorf("rules were re-authorized %d times after a failed revoke", client.authorized)
+	}
+}
+
+func TestRemediationService_RollbackCloudAPI_NoStrategy(t *testing.T) {
+	service, _ := newTestRemediationService(newFakeCloudRemediationClient())
+
+	action := &remediation.Action{RollbackData: []byte(`{"can_auto_rollback":true}`)}
+	if err := service.rollbackCloudAPI(context.Background(), action); err == nil {
+		t.Error("rollbackCloudAPI() should fail for an action without a strategy")
+	}
+}
+
+func TestRemediationService_ExecuteCloudAPI_UnknownAction(t *testing.T) {
+	service, repo := newTestRemediationService(newFakeCloudRemediationClient())
+	ctx := context.Background()
+
+	action := &remediation.Action{
+		UserID: 1,
+		Strategy: &remediation.Strategy{
+			Provider:   provider.ProviderAWS,
+			APIAction:  "rds:enable_encryption",
+			Parameters: map[string]interface{}{"resource_id": "db-1"},
+		},
+	}
+	repo.Create(ctx, action)
+
+	if _, err := service.executeCloudAPI(ctx, action); err == nil {
+		t.Error("executeCloudAPI() should fail for an unregistered action")
+	}
+}
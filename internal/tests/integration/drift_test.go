@@ -19,7 +19,7 @@ func setupDriftTestServices(t *testing.T) (*services.DriftService, *testutil.Moc
 	resourceRepo := testutil.NewMockResourceRepository()
 	log := logger.New(logger.Config{Level: "error", Format: "json"})
 
-	driftService := services.NewDriftService(driftRepo, baselineRepo, resourceRepo, log)
+	driftService := services.NewDriftService(driftRepo, baselineRepo, resourceRepo, nil, nil, log)
 
 	return driftService.(*services.DriftService), resourceRepo, baselineRepo, driftRepo
 }
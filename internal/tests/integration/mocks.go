@@ -12,6 +12,7 @@ type MockCloudProviderClient struct {
 	AWSResources    []resource.Resource
 	AzureResources  []resource.Resource
 	GCPResources    []resource.Resource
+	OrgAccounts     []cloudproviders.AWSOrganizationAccount
 	Err             error
 	AWSListCalled   bool
 	AzureListCalled bool
@@ -26,6 +27,13 @@ func (m *MockCloudProviderClient) AWSListResources(ctx context.Context, creds cl
 	return m.AWSResources, nil
 }
 
+func (m *MockCloudProviderClient) AWSListOrganizationAccounts(ctx context.Context, creds cloudproviders.AWSCredentials) ([]cloudproviders.AWSOrganizationAccount, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.OrgAccounts, nil
+}
+
 func (m *MockCloudProviderClient) AzureListResources(ctx context.Context, creds cloudproviders.AzureCredentials) ([]resource.Resource, error) {
 	m.AzureListCalled = true
 	if m.Err != nil {
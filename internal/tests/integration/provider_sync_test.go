@@ -18,7 +18,7 @@ func TestProviderSync(t *testing.T) {
 	defer testutil.CleanupDB(db)
 
 	logger := logger.New(logger.Config{Level: "error", Format: "console"})
-	providerRepo := postgres.NewProviderRepository(db)
+	providerRepo := postgres.NewProviderRepository(db, testutil.NewTestKeyring(t))
 	resourceRepo := postgres.NewResourceRepository(db)
 
 	// Setup Service
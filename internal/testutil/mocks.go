@@ -3,17 +3,25 @@ package testutil
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/pratik-mahalle/infraudit/internal/domain/alert"
 	"github.com/pratik-mahalle/infraudit/internal/domain/anomaly"
 	"github.com/pratik-mahalle/infraudit/internal/domain/baseline"
+	"github.com/pratik-mahalle/infraudit/internal/domain/cost"
 	"github.com/pratik-mahalle/infraudit/internal/domain/drift"
+	"github.com/pratik-mahalle/infraudit/internal/domain/job"
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
 	"github.com/pratik-mahalle/infraudit/internal/domain/provider"
 	"github.com/pratik-mahalle/infraudit/internal/domain/recommendation"
+	"github.com/pratik-mahalle/infraudit/internal/domain/remediation"
 	"github.com/pratik-mahalle/infraudit/internal/domain/resource"
 	"github.com/pratik-mahalle/infraudit/internal/domain/user"
 	"github.com/pratik-mahalle/infraudit/internal/domain/vulnerability"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/errors"
 )
 
 // MockUserRepository is a mock implementation of user.Repository
@@ -128,6 +136,7 @@ func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*us
 // MockResourceRepository is a mock implementation of resource.Repository
 type MockResourceRepository struct {
 	Resources   map[string]*resource.Resource
+	SyncRuns    []*resource.SyncRun
 	CreateError error
 	GetError    error
 }
@@ -161,9 +170,12 @@ func (m *MockResourceRepository) GetByID(ctx context.Context, userID int64, reso
 func (m *MockResourceRepository) List(ctx context.Context, userID int64, filter resource.Filter, limit, offset int) ([]*resource.Resource, int64, error) {
 	var result []*resource.Resource
 	for _, r := range m.Resources {
-		if r.UserID == userID {
-			result = append(result, r)
+		if r.UserID != userID ||
+			(filter.Provider != "" && r.Provider != filter.Provider) ||
+			(filter.AccountID != 0 && r.AccountID != filter.AccountID) {
+			continue
 		}
+		result = append(result, r)
 	}
 	return result, int64(len(result)), nil
 }
@@ -201,15 +213,52 @@ func (m *MockResourceRepository) ListByProvider(ctx context.Context, userID int6
 	return result, nil
 }
 
-func (m *MockResourceRepository) SaveBatch(ctx context.Context, userID int64, provider string, resources []*resource.Resource) error {
-	for _, r := range resources {
-		r.UserID = userID
-		r.Provider = provider
-		m.Resources[r.ResourceID] = r
+func (m *MockResourceRepository) DeleteByAccount(ctx context.Context, userID int64, accountID int64) error {
+	for key, r := range m.Resources {
+		if r.UserID == userID && r.AccountID == accountID {
+			delete(m.Resources, key)
+		}
+	}
+	return nil
+}
+
+func (m *MockResourceRepository) ListByAccount(ctx context.Context, userID int64, accountID int64) ([]*resource.Resource, error) {
+	var result []*resource.Resource
+	for _, r := range m.Resources {
+		if r.UserID == userID && r.AccountID == accountID {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockResourceRepository) ApplySync(ctx context.Context, run *resource.SyncRun, diff *resource.SyncDiff) error {
+	for _, group := range [][]*resource.Resource{diff.Added, diff.Updated, diff.Unchanged, diff.Removed} {
+		for _, r := range group {
+			r.UserID = run.UserID
+			r.Provider = run.Provider
+			r.AccountID = run.AccountID
+			m.Resources[r.ResourceID] = r
+		}
 	}
+	for _, r := range diff.Purged {
+		delete(m.Resources, r.ResourceID)
+	}
+	run.ID = int64(len(m.SyncRuns) + 1)
+	m.SyncRuns = append(m.SyncRuns, run)
 	return nil
 }
 
+func (m *MockResourceRepository) ListSyncRuns(ctx context.Context, userID int64, accountID int64, limit int) ([]*resource.SyncRun, error) {
+	var result []*resource.SyncRun
+	for i := len(m.SyncRuns) - 1; i >= 0 && len(result) < limit; i-- {
+		if r := m.SyncRuns[i]; r.UserID == userID && r.AccountID == accountID {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
 // MockAlertRepository is a mock implementation of alert.Repository
 type MockAlertRepository struct {
 	Alerts map[int64]*alert.Alert
@@ -389,9 +438,19 @@ func (m *MockDriftRepository) Delete(ctx context.Context, userID int64, id int64
 func (m *MockDriftRepository) List(ctx context.Context, userID int64, filter drift.Filter) ([]*drift.Drift, error) {
 	var result []*drift.Drift
 	for _, d := range m.Drifts {
-		if d.UserID == userID {
-			result = append(result, d)
+		if d.UserID != userID {
+			continue
+		}
+		if filter.ResourceID != "" && d.ResourceID != filter.ResourceID {
+			continue
+		}
+		if filter.Status != "" && d.Status != filter.Status {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, d.Status) {
+			continue
 		}
+		result = append(result, d)
 	}
 	return result, nil
 }
@@ -401,16 +460,85 @@ func (m *MockDriftRepository) ListWithPagination(ctx context.Context, userID int
 	return drifts, int64(len(drifts)), nil
 }
 
-func (m *MockDriftRepository) CountBySeverity(ctx context.Context, userID int64) (map[string]int, error) {
+func (m *MockDriftRepository) GetByFingerprint(ctx context.Context, userID int64, fingerprint string) (*drift.Drift, error) {
+	var found *drift.Drift
+	for _, d := range m.Drifts {
+		if d.UserID == userID && d.Fingerprint == fingerprint && d.Status != drift.StatusResolved {
+			if found == nil || d.ID > found.ID {
+				found = d
+			}
+		}
+	}
+	if found == nil {
+		return nil, errors.NotFound("Drift")
+	}
+	return found, nil
+}
+
+func (m *MockDriftRepository) CountBySeverity(ctx context.Context, userID int64, statuses ...string) (map[string]int, error) {
 	counts := make(map[string]int)
 	for _, d := range m.Drifts {
-		if d.UserID == userID {
+		if d.UserID == userID && (len(statuses) == 0 || slices.Contains(statuses, d.Status)) {
 			counts[d.Severity]++
 		}
 	}
 	return counts, nil
 }
 
+// MockDriftRuleRepository mock
+type MockDriftRuleRepository struct {
+	Rules map[int64]map[string]*drift.Rule
+}
+
+func NewMockDriftRuleRepository() *MockDriftRuleRepository {
+	return &MockDriftRuleRepository{
+		Rules: make(map[int64]map[string]*drift.Rule),
+	}
+}
+
+func (m *MockDriftRuleRepository) Create(ctx context.Context, r *drift.Rule) error {
+	if m.Rules[r.UserID] == nil {
+		m.Rules[r.UserID] = make(map[string]*drift.Rule)
+	}
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	m.Rules[r.UserID][r.ID] = r
+	return nil
+}
+
+func (m *MockDriftRuleRepository) GetByID(ctx context.Context, userID int64, id string) (*drift.Rule, error) {
+	r, ok := m.Rules[userID][id]
+	if !ok {
+		return nil, errors.NotFound("Drift rule")
+	}
+	return r, nil
+}
+
+func (m *MockDriftRuleRepository) Update(ctx context.Context, r *drift.Rule) error {
+	if _, ok := m.Rules[r.UserID][r.ID]; !ok {
+		return errors.NotFound("Drift rule")
+	}
+	r.UpdatedAt = time.Now()
+	m.Rules[r.UserID][r.ID] = r
+	return nil
+}
+
+func (m *MockDriftRuleRepository) Delete(ctx context.Context, userID int64, id string) error {
+	if _, ok := m.Rules[userID][id]; !ok {
+		return errors.NotFound("Drift rule")
+	}
+	delete(m.Rules[userID], id)
+	return nil
+}
+
+func (m *MockDriftRuleRepository) List(ctx context.Context, userID int64) ([]*drift.Rule, error) {
+	var result []*drift.Rule
+	for _, r := range m.Rules[userID] {
+		result = append(result, r)
+	}
+	return result, nil
+}
+
 // MockAnomalyRepository mock
 type MockAnomalyRepository struct {
 	Anomalies map[int64]*anomaly.Anomaly
@@ -480,29 +608,58 @@ func (m *MockAnomalyRepository) CountBySeverity(ctx context.Context, userID int6
 
 // MockProviderRepository mock
 type MockProviderRepository struct {
-	Providers map[string]*provider.Provider
+	Providers   map[int64]*provider.Provider
+	ExternalIDs map[int64]string // AWS external IDs by user ID
+	NextID      int64
 }
 
 func NewMockProviderRepository() *MockProviderRepository {
 	return &MockProviderRepository{
-		Providers: make(map[string]*provider.Provider),
+		Providers:   make(map[int64]*provider.Provider),
+		ExternalIDs: make(map[int64]string),
+		NextID:      1,
 	}
 }
 
 func (m *MockProviderRepository) Upsert(ctx context.Context, p *provider.Provider) error {
-	key := p.Provider
-	m.Providers[key] = p
+	if p.Name == "" {
+		p.Name = provider.DefaultAccountName
+	}
+	for id, existing := range m.Providers {
+		if existing.UserID == p.UserID && existing.Provider == p.Provider && existing.Name == p.Name {
+			p.ID = id
+			m.Providers[id] = p
+			return nil
+		}
+	}
+	p.ID = m.NextID
+	m.NextID++
+	m.Providers[p.ID] = p
 	return nil
 }
 
-func (m *MockProviderRepository) GetByProvider(ctx context.Context, userID int64, providerType string) (*provider.Provider, error) {
-	p, ok := m.Providers[providerType]
+func (m *MockProviderRepository) GetByID(ctx context.Context, userID int64, id int64) (*provider.Provider, error) {
+	p, ok := m.Providers[id]
 	if !ok || p.UserID != userID {
-		return nil, fmt.Errorf("provider not found")
+		return nil, errors.NotFound("Provider account")
 	}
 	return p, nil
 }
 
+func (m *MockProviderRepository) GetByProvider(ctx context.Context, userID int64, providerType string) (*provider.Provider, error) {
+	accounts, _ := m.ListByProvider(ctx, userID, providerType)
+	if len(accounts) == 0 {
+		return nil, errors.NotFound("Provider")
+	}
+	first := accounts[0]
+	for _, p := range accounts {
+		if p.ID < first.ID {
+			first = p
+		}
+	}
+	return first, nil
+}
+
 func (m *MockProviderRepository) List(ctx context.Context, userID int64) ([]*provider.Provider, error) {
 	var result []*provider.Provider
 	for _, p := range m.Providers {
@@ -510,28 +667,64 @@ func (m *MockProviderRepository) List(ctx context.Context, userID int64) ([]*pro
 			result = append(result, p)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (m *MockProviderRepository) ListByProvider(ctx context.Context, userID int64, providerType string) ([]*provider.Provider, error) {
+	var result []*provider.Provider
+	for _, p := range m.Providers {
+		if p.UserID == userID && p.Provider == providerType {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
 	return result, nil
 }
 
-func (m *MockProviderRepository) Delete(ctx context.Context, userID int64, providerType string) error {
-	delete(m.Providers, providerType)
+func (m *MockProviderRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	if p, ok := m.Providers[id]; !ok || p.UserID != userID {
+		return errors.NotFound("Provider account")
+	}
+	delete(m.Providers, id)
 	return nil
 }
 
-func (m *MockProviderRepository) UpdateSyncStatus(ctx context.Context, userID int64, providerType string, lastSynced time.Time) error {
-	if p, ok := m.Providers[providerType]; ok && p.UserID == userID {
-		p.LastSynced = &lastSynced
-		return nil
+func (m *MockProviderRepository) UpdateSyncStatus(ctx context.Context, userID int64, id int64, status, message string, syncedAt time.Time) error {
+	p, ok := m.Providers[id]
+	if !ok || p.UserID != userID {
+		return errors.NotFound("Provider account")
 	}
-	return fmt.Errorf("provider not found")
+	p.SyncStatus = status
+	p.SyncMessage = message
+	if status == provider.SyncStatusSynced {
+		p.LastSynced = &syncedAt
+	}
+	return nil
 }
 
-func (m *MockProviderRepository) UpdateConnectionStatus(ctx context.Context, userID int64, providerType string, isConnected bool) error {
-	if p, ok := m.Providers[providerType]; ok && p.UserID == userID {
-		p.IsConnected = isConnected
-		return nil
+func (m *MockProviderRepository) UpdateConnectionStatus(ctx context.Context, userID int64, id int64, isConnected bool) error {
+	p, ok := m.Providers[id]
+	if !ok || p.UserID != userID {
+		return errors.NotFound("Provider account")
+	}
+	p.IsConnected = isConnected
+	return nil
+}
+
+func (m *MockProviderRepository) GetAWSExternalID(ctx context.Context, userID int64) (string, error) {
+	externalID, ok := m.ExternalIDs[userID]
+	if !ok {
+		return "", errors.NotFound("AWS external ID")
 	}
-	return fmt.Errorf("provider not found")
+	return externalID, nil
+}
+
+func (m *MockProviderRepository) CreateAWSExternalID(ctx context.Context, userID int64, externalID string) error {
+	if _, ok := m.ExternalIDs[userID]; !ok {
+		m.ExternalIDs[userID] = externalID
+	}
+	return nil
 }
 
 // MockBaselineRepository is a mock implementation of baseline.Repository
@@ -802,3 +995,908 @@ func (m *MockVulnerabilityRepository) GetLatestScan(ctx context.Context, userID
 	}
 	return latest, nil
 }
+
+// MockRemediationRepository is a mock implementation of remediation.Repository
+type MockRemediationRepository struct {
+	Actions map[string]*remediation.Action
+}
+
+func NewMockRemediationRepository() *MockRemediationRepository {
+	return &MockRemediationRepository{
+		Actions: make(map[string]*remediation.Action),
+	}
+}
+
+func (m *MockRemediationRepository) Create(ctx context.Context, action *remediation.Action) error {
+	if action.ID == "" {
+		action.ID = fmt.Sprintf("action-%d", len(m.Actions)+1)
+	}
+	m.Actions[action.ID] = action
+	return nil
+}
+
+func (m *MockRemediationRepository) GetByID(ctx context.Context, id string) (*remediation.Action, error) {
+	a, ok := m.Actions[id]
+	if !ok {
+		return nil, errors.NotFound("Remediation action")
+	}
+	return a, nil
+}
+
+func (m *MockRemediationRepository) Update(ctx context.Context, action *remediation.Action) error {
+	if _, ok := m.Actions[action.ID]; !ok {
+		return errors.NotFound("Remediation action")
+	}
+	m.Actions[action.ID] = action
+	return nil
+}
+
+func (m *MockRemediationRepository) Delete(ctx context.Context, id string) error {
+	delete(m.Actions, id)
+	return nil
+}
+
+func (m *MockRemediationRepository) List(ctx context.Context, filter remediation.Filter, limit, offset int) ([]*remediation.Action, int64, error) {
+	var result []*remediation.Action
+	for _, a := range m.Actions {
+		if filter.UserID != 0 && a.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != "" && a.Status != filter.Status {
+			continue
+		}
+		if filter.RemediationType != "" && a.RemediationType != filter.RemediationType {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockRemediationRepository) GetByDriftID(ctx context.Context, driftID string) ([]*remediation.Action, error) {
+	var result []*remediation.Action
+	for _, a := range m.Actions {
+		if a.DriftID != nil && *a.DriftID == driftID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockRemediationRepository) GetByVulnerabilityID(ctx context.Context, vulnerabilityID string) ([]*remediation.Action, error) {
+	var result []*remediation.Action
+	for _, a := range m.Actions {
+		if a.VulnerabilityID != nil && *a.VulnerabilityID == vulnerabilityID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockRemediationRepository) GetPendingApprovals(ctx context.Context, userID int64) ([]*remediation.Action, error) {
+	var result []*remediation.Action
+	for _, a := range m.Actions {
+		if a.UserID == userID && a.Status == remediation.ActionStatusPending && a.ApprovalRequired {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockRemediationRepository) CountByStatus(ctx context.Context, userID int64) (map[remediation.ActionStatus]int, error) {
+	counts := make(map[remediation.ActionStatus]int)
+	for _, a := range m.Actions {
+		if a.UserID == userID {
+			counts[a.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// MockJobRepository is a mock implementation of job.Repository. Executions
+// are updated from background goroutines, so access is synchronized.
+type MockJobRepository struct {
+	mu         sync.Mutex
+	Jobs       map[string]*job.ScheduledJob
+	Executions map[string]*job.JobExecution
+	Leases     map[string]*job.Lease
+}
+
+func NewMockJobRepository() *MockJobRepository {
+	return &MockJobRepository{
+		Jobs:       make(map[string]*job.ScheduledJob),
+		Executions: make(map[string]*job.JobExecution),
+		Leases:     make(map[string]*job.Lease),
+	}
+}
+
+func (m *MockJobRepository) CreateJob(ctx context.Context, j *job.ScheduledJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Jobs[j.ID] = j
+	return nil
+}
+
+func (m *MockJobRepository) GetJob(ctx context.Context, id string) (*job.ScheduledJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.Jobs[id]
+	if !ok {
+		return nil, errors.NotFound("Job")
+	}
+	return j, nil
+}
+
+func (m *MockJobRepository) GetJobByUserAndType(ctx context.Context, userID int64, jobType job.JobType) (*job.ScheduledJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.Jobs {
+		if j.UserID == userID && j.JobType == jobType {
+			return j, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockJobRepository) UpdateJob(ctx context.Context, j *job.ScheduledJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.Jobs[j.ID]; !ok {
+		return errors.NotFound("Job")
+	}
+	m.Jobs[j.ID] = j
+	return nil
+}
+
+func (m *MockJobRepository) DeleteJob(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Jobs, id)
+	return nil
+}
+
+func (m *MockJobRepository) ListJobs(ctx context.Context, userID int64, filter job.Filter, limit, offset int) ([]*job.ScheduledJob, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*job.ScheduledJob
+	for _, j := range m.Jobs {
+		if j.UserID == userID && (filter.JobType == "" || j.JobType == filter.JobType) {
+			result = append(result, j)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockJobRepository) GetEnabledJobs(ctx context.Context) ([]*job.ScheduledJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*job.ScheduledJob
+	for _, j := range m.Jobs {
+		if j.IsEnabled {
+			result = append(result, j)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockJobRepository) UpdateLastRun(ctx context.Context, id string, lastRun, nextRun interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.Jobs[id]
+	if !ok {
+		return nil
+	}
+	if t, ok := lastRun.(time.Time); ok {
+		j.LastRun = &t
+	}
+	if t, ok := nextRun.(*time.Time); ok {
+		j.NextRun = t
+	}
+	return nil
+}
+
+func (m *MockJobRepository) CreateExecution(ctx context.Context, e *job.JobExecution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *e
+	m.Executions[e.ID] = &copied
+	return nil
+}
+
+func (m *MockJobRepository) GetExecution(ctx context.Context, id string) (*job.JobExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.Executions[id]
+	if !ok {
+		return nil, errors.NotFound("Job execution")
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (m *MockJobRepository) UpdateExecution(ctx context.Context, e *job.JobExecution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.Executions[e.ID]; !ok {
+		return errors.NotFound("Job execution")
+	}
+	copied := *e
+	m.Executions[e.ID] = &copied
+	return nil
+}
+
+func (m *MockJobRepository) ListExecutions(ctx context.Context, filter job.ExecutionFilter, limit, offset int) ([]*job.JobExecution, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*job.JobExecution
+	for _, e := range m.Executions {
+		if filter.JobID != "" && e.JobID != filter.JobID {
+			continue
+		}
+		if filter.Status != "" && e.Status != filter.Status {
+			continue
+		}
+		copied := *e
+		result = append(result, &copied)
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockJobRepository) GetLatestExecution(ctx context.Context, jobID string) (*job.JobExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *job.JobExecution
+	for _, e := range m.Executions {
+		if e.JobID == jobID && (latest == nil || (e.StartedAt != nil && latest.StartedAt != nil && e.StartedAt.After(*latest.StartedAt))) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (m *MockJobRepository) GetRunningExecutions(ctx context.Context) ([]*job.JobExecution, error) {
+	executions, _, err := m.ListExecutions(ctx, job.ExecutionFilter{Status: job.ExecutionStatusRunning}, 0, 0)
+	return executions, err
+}
+
+func (m *MockJobRepository) CleanupOldExecutions(ctx context.Context, olderThan interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockJobRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	l, ok := m.Leases[name]
+	if ok && l.Holder != holder && l.ExpiresAt.After(now) {
+		return false, nil
+	}
+	if !ok || l.Holder != holder {
+		l = &job.Lease{Name: name, Holder: holder, AcquiredAt: now}
+		m.Leases[name] = l
+	}
+	l.HeartbeatAt = now
+	l.ExpiresAt = now.Add(ttl)
+	return true, nil
+}
+
+func (m *MockJobRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.Leases[name]; ok && l.Holder == holder {
+		delete(m.Leases, name)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) ListLeases(ctx context.Context) ([]*job.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*job.Lease
+	for _, l := range m.Leases {
+		if !l.ExpiresAt.Before(time.Now()) {
+			copied := *l
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockJobRepository) DeleteExpiredLeases(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for name, l := range m.Leases {
+		if l.ExpiresAt.Before(time.Now()) {
+			delete(m.Leases, name)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockNotificationRepository is a mock implementation of notification.Repository.
+// Webhook deliveries are updated from background goroutines, so access is
+// synchronized.
+type MockNotificationRepository struct {
+	mu          sync.Mutex
+	Preferences map[string]*notification.Preference
+	Logs        map[string]*notification.Log
+	Webhooks    map[string]*notification.Webhook
+	Deliveries  map[string]*notification.WebhookDelivery
+}
+
+func NewMockNotificationRepository() *MockNotificationRepository {
+	return &MockNotificationRepository{
+		Preferences: make(map[string]*notification.Preference),
+		Logs:        make(map[string]*notification.Log),
+		Webhooks:    make(map[string]*notification.Webhook),
+		Deliveries:  make(map[string]*notification.WebhookDelivery),
+	}
+}
+
+func (m *MockNotificationRepository) CreatePreference(ctx context.Context, p *notification.Preference) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Preferences[p.ID] = p
+	return nil
+}
+
+func (m *MockNotificationRepository) GetPreference(ctx context.Context, userID int64, channel notification.Channel) (*notification.Preference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.Preferences {
+		if p.UserID == userID && p.Channel == channel {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockNotificationRepository) UpdatePreference(ctx context.Context, p *notification.Preference) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Preferences[p.ID] = p
+	return nil
+}
+
+func (m *MockNotificationRepository) ListPreferences(ctx context.Context, userID int64) ([]*notification.Preference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.Preference
+	for _, p := range m.Preferences {
+		if p.UserID == userID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockNotificationRepository) DeletePreference(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Preferences, id)
+	return nil
+}
+
+func (m *MockNotificationRepository) CreateLog(ctx context.Context, l *notification.Log) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *l
+	m.Logs[l.ID] = &copied
+	return nil
+}
+
+func (m *MockNotificationRepository) UpdateLog(ctx context.Context, l *notification.Log) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *l
+	m.Logs[l.ID] = &copied
+	return nil
+}
+
+func (m *MockNotificationRepository) ListLogs(ctx context.Context, filter notification.LogFilter, limit, offset int) ([]*notification.Log, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.Log
+	for _, l := range m.Logs {
+		if l.UserID == filter.UserID && (filter.Status == "" || l.Status == filter.Status) {
+			copied := *l
+			result = append(result, &copied)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockNotificationRepository) GetPendingLogs(ctx context.Context) ([]*notification.Log, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.Log
+	for _, l := range m.Logs {
+		if l.Status == notification.DeliveryStatusPending || l.Status == notification.DeliveryStatusRetrying {
+			copied := *l
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockNotificationRepository) CreateWebhook(ctx context.Context, w *notification.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Webhooks[w.ID] = w
+	return nil
+}
+
+func (m *MockNotificationRepository) GetWebhook(ctx context.Context, id string) (*notification.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.Webhooks[id]
+	if !ok {
+		return nil, errors.NotFound("Webhook")
+	}
+	copied := *w
+	return &copied, nil
+}
+
+func (m *MockNotificationRepository) UpdateWebhook(ctx context.Context, w *notification.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *w
+	m.Webhooks[w.ID] = &copied
+	return nil
+}
+
+func (m *MockNotificationRepository) DeleteWebhook(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Webhooks, id)
+	return nil
+}
+
+func (m *MockNotificationRepository) ListWebhooks(ctx context.Context, userID int64, limit, offset int) ([]*notification.Webhook, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.Webhook
+	for _, w := range m.Webhooks {
+		if w.UserID == userID {
+			result = append(result, w)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockNotificationRepository) GetWebhooksForEvent(ctx context.Context, userID int64, eventType notification.EventType) ([]*notification.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.Webhook
+	for _, w := range m.Webhooks {
+		if w.UserID == userID && w.IsEnabled && slices.Contains(w.Events, eventType) {
+			copied := *w
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockNotificationRepository) CreateDelivery(ctx context.Context, d *notification.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *d
+	m.Deliveries[d.ID] = &copied
+	return nil
+}
+
+func (m *MockNotificationRepository) UpdateDelivery(ctx context.Context, d *notification.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *d
+	m.Deliveries[d.ID] = &copied
+	return nil
+}
+
+func (m *MockNotificationRepository) GetDelivery(ctx context.Context, id string) (*notification.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.Deliveries[id]
+	if !ok {
+		return nil, errors.NotFound("Webhook delivery")
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *MockNotificationRepository) GetPendingDeliveries(ctx context.Context) ([]*notification.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var result []*notification.WebhookDelivery
+	for _, d := range m.Deliveries {
+		due := d.NextAttemptAt == nil || !d.NextAttemptAt.After(now)
+		if (d.Status == notification.DeliveryStatusPending || d.Status == notification.DeliveryStatusRetrying) && due {
+			copied := *d
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockNotificationRepository) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*notification.WebhookDelivery, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*notification.WebhookDelivery
+	for _, d := range m.Deliveries {
+		if d.WebhookID == webhookID {
+			copied := *d
+			result = append(result, &copied)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+// MockCostRepository is a mock implementation of cost.Repository. Costs are
+// filtered like the SQL repository; daily costs only honor the provider filter.
+type MockCostRepository struct {
+	mu              sync.Mutex
+	Costs           []*cost.Cost
+	Anomalies       map[string]*cost.CostAnomaly
+	Optimizations   map[string]*cost.CostOptimization
+	Budgets         map[string]*cost.Budget
+	BudgetAlerts    []*cost.BudgetAlert
+	AllocationRules map[string]*cost.AllocationRule
+}
+
+func NewMockCostRepository() *MockCostRepository {
+	return &MockCostRepository{
+		Anomalies:       make(map[string]*cost.CostAnomaly),
+		Optimizations:   make(map[string]*cost.CostOptimization),
+		Budgets:         make(map[string]*cost.Budget),
+		AllocationRules: make(map[string]*cost.AllocationRule),
+	}
+}
+
+func (m *MockCostRepository) CreateCost(ctx context.Context, c *cost.Cost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c.ID == "" {
+		c.ID = fmt.Sprintf("cost-%d", len(m.Costs)+1)
+	}
+	copied := *c
+	m.Costs = append(m.Costs, &copied)
+	return nil
+}
+
+func (m *MockCostRepository) GetCostsByDateRange(ctx context.Context, userID int64, filter cost.Filter, startDate, endDate time.Time) ([]*cost.Cost, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.Cost
+	for _, c := range m.Costs {
+		if c.UserID != userID || c.CostDate.Before(startDate) || c.CostDate.After(endDate) {
+			continue
+		}
+		if (filter.Provider != "" && c.Provider != filter.Provider) ||
+			(filter.ServiceName != "" && c.ServiceName != filter.ServiceName) ||
+			(filter.Region != "" && c.Region != filter.Region) ||
+			(filter.ResourceID != "" && (c.ResourceID == nil || *c.ResourceID != filter.ResourceID)) {
+			continue
+		}
+		copied := *c
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (m *MockCostRepository) GetCostSummary(ctx context.Context, userID int64, filter cost.Filter, startDate, endDate time.Time) (*cost.CostSummary, error) {
+	costs, _ := m.GetCostsByDateRange(ctx, userID, cost.Filter{Provider: filter.Provider, ServiceName: filter.ServiceName, Region: filter.Region}, startDate, endDate)
+	summary := &cost.CostSummary{
+		Provider:  filter.Provider,
+		Currency:  "USD",
+		StartDate: startDate,
+		EndDate:   endDate,
+		ByService: make(map[string]float64),
+		ByRegion:  make(map[string]float64),
+	}
+	for _, c := range costs {
+		summary.TotalCost += c.DailyCost
+		summary.ByService[c.ServiceName] += c.DailyCost
+		if c.Region != "" {
+			summary.ByRegion[c.Region] += c.DailyCost
+		}
+	}
+	return summary, nil
+}
+
+func (m *MockCostRepository) GetDailyCosts(ctx context.Context, userID int64, filter cost.Filter, days int) ([]cost.CostDataPoint, error) {
+	costs, _ := m.GetCostsByDateRange(ctx, userID, cost.Filter{Provider: filter.Provider}, time.Now().AddDate(0, 0, -days), time.Now())
+	byDate := make(map[time.Time]float64)
+	for _, c := range costs {
+		byDate[c.CostDate] += c.DailyCost
+	}
+	var result []cost.CostDataPoint
+	for date, total := range byDate {
+		result = append(result, cost.CostDataPoint{Date: date, Cost: total})
+	}
+	slices.SortFunc(result, func(a, b cost.CostDataPoint) int { return a.Date.Compare(b.Date) })
+	return result, nil
+}
+
+func (m *MockCostRepository) DeleteCostsByDate(ctx context.Context, userID int64, beforeDate time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Costs = slices.DeleteFunc(m.Costs, func(c *cost.Cost) bool {
+		return c.UserID == userID && c.CostDate.Before(beforeDate)
+	})
+	return nil
+}
+
+func (m *MockCostRepository) CreateAnomaly(ctx context.Context, a *cost.CostAnomaly) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a.ID == "" {
+		a.ID = fmt.Sprintf("anomaly-%d", len(m.Anomalies)+1)
+	}
+	copied := *a
+	m.Anomalies[a.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) GetAnomaly(ctx context.Context, id string) (*cost.CostAnomaly, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.Anomalies[id]
+	if !ok {
+		return nil, errors.NotFound("Cost anomaly")
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *MockCostRepository) GetAnomalyByFingerprint(ctx context.Context, userID int64, fingerprint string) (*cost.CostAnomaly, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.Anomalies {
+		if a.UserID == userID && a.Fingerprint == fingerprint {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, errors.NotFound("Cost anomaly")
+}
+
+func (m *MockCostRepository) UpdateAnomaly(ctx context.Context, a *cost.CostAnomaly) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *a
+	m.Anomalies[a.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) ListAnomalies(ctx context.Context, userID int64, status string, limit, offset int) ([]*cost.CostAnomaly, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.CostAnomaly
+	for _, a := range m.Anomalies {
+		if a.UserID == userID && (status == "" || a.Status == status) {
+			copied := *a
+			result = append(result, &copied)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockCostRepository) CreateOptimization(ctx context.Context, o *cost.CostOptimization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o.ID == "" {
+		o.ID = fmt.Sprintf("optimization-%d", len(m.Optimizations)+1)
+	}
+	copied := *o
+	m.Optimizations[o.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) GetOptimization(ctx context.Context, id string) (*cost.CostOptimization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.Optimizations[id]
+	if !ok {
+		return nil, errors.NotFound("Cost optimization")
+	}
+	copied := *o
+	return &copied, nil
+}
+
+func (m *MockCostRepository) GetOptimizationByFingerprint(ctx context.Context, userID int64, fingerprint string) (*cost.CostOptimization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *cost.CostOptimization
+	for _, o := range m.Optimizations {
+		if o.UserID == userID && o.Fingerprint == fingerprint && (latest == nil || o.CreatedAt.After(latest.CreatedAt)) {
+			latest = o
+		}
+	}
+	if latest == nil {
+		return nil, errors.NotFound("Cost optimization")
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (m *MockCostRepository) UpdateOptimization(ctx context.Context, o *cost.CostOptimization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *o
+	m.Optimizations[o.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) ListOptimizations(ctx context.Context, userID int64, status string, limit, offset int) ([]*cost.CostOptimization, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.CostOptimization
+	for _, o := range m.Optimizations {
+		if o.UserID == userID && (status == "" || o.Status == status) {
+			copied := *o
+			result = append(result, &copied)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockCostRepository) GetTotalPotentialSavings(ctx context.Context, userID int64) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total float64
+	for _, o := range m.Optimizations {
+		if o.UserID == userID && o.Status == cost.OptStatusPending {
+			total += o.EstimatedSavings
+		}
+	}
+	return total, nil
+}
+
+func (m *MockCostRepository) CreateBudget(ctx context.Context, b *cost.Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b.ID == "" {
+		b.ID = fmt.Sprintf("budget-%d", len(m.Budgets)+1)
+	}
+	copied := *b
+	m.Budgets[b.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) GetBudget(ctx context.Context, userID int64, id string) (*cost.Budget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.Budgets[id]
+	if !ok || b.UserID != userID {
+		return nil, errors.NotFound("Budget")
+	}
+	copied := *b
+	return &copied, nil
+}
+
+func (m *MockCostRepository) UpdateBudget(ctx context.Context, b *cost.Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.Budgets[b.ID]; !ok || existing.UserID != b.UserID {
+		return errors.NotFound("Budget")
+	}
+	copied := *b
+	m.Budgets[b.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) DeleteBudget(ctx context.Context, userID int64, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := m.Budgets[id]; !ok || b.UserID != userID {
+		return errors.NotFound("Budget")
+	}
+	delete(m.Budgets, id)
+	return nil
+}
+
+func (m *MockCostRepository) ListBudgets(ctx context.Context, userID int64) ([]*cost.Budget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.Budget
+	for _, b := range m.Budgets {
+		if b.UserID == userID {
+			copied := *b
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockCostRepository) RecordBudgetAlert(ctx context.Context, a *cost.BudgetAlert) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.BudgetAlerts {
+		if existing.BudgetID == a.BudgetID && existing.PeriodKey == a.PeriodKey &&
+			existing.ThresholdType == a.ThresholdType && existing.ThresholdPercent == a.ThresholdPercent {
+			return false, nil
+		}
+	}
+	if a.ID == "" {
+		a.ID = fmt.Sprintf("budget-alert-%d", len(m.BudgetAlerts)+1)
+	}
+	copied := *a
+	m.BudgetAlerts = append(m.BudgetAlerts, &copied)
+	return true, nil
+}
+
+func (m *MockCostRepository) ListBudgetAlerts(ctx context.Context, budgetID string, periodKey string) ([]*cost.BudgetAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.BudgetAlert
+	for _, a := range m.BudgetAlerts {
+		if a.BudgetID == budgetID && (periodKey == "" || a.PeriodKey == periodKey) {
+			copied := *a
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockCostRepository) CreateAllocationRule(ctx context.Context, rule *cost.AllocationRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("allocation-%d", len(m.AllocationRules)+1)
+	}
+	copied := *rule
+	m.AllocationRules[rule.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) GetAllocationRule(ctx context.Context, userID int64, id string) (*cost.AllocationRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rule, ok := m.AllocationRules[id]
+	if !ok || rule.UserID != userID {
+		return nil, errors.NotFound("Allocation rule")
+	}
+	copied := *rule
+	return &copied, nil
+}
+
+func (m *MockCostRepository) UpdateAllocationRule(ctx context.Context, rule *cost.AllocationRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.AllocationRules[rule.ID]; !ok || existing.UserID != rule.UserID {
+		return errors.NotFound("Allocation rule")
+	}
+	copied := *rule
+	m.AllocationRules[rule.ID] = &copied
+	return nil
+}
+
+func (m *MockCostRepository) DeleteAllocationRule(ctx context.Context, userID int64, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rule, ok := m.AllocationRules[id]; !ok || rule.UserID != userID {
+		return errors.NotFound("Allocation rule")
+	}
+	delete(m.AllocationRules, id)
+	return nil
+}
+
+func (m *MockCostRepository) ListAllocationRules(ctx context.Context, userID int64) ([]*cost.AllocationRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*cost.AllocationRule
+	for _, rule := range m.AllocationRules {
+		if rule.UserID == userID {
+			copied := *rule
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"testing"
 
+	"github.com/pratik-mahalle/infraudit/internal/pkg/secrets"
 	_ "modernc.org/sqlite"
 )
 
@@ -40,32 +41,26 @@ func NewTestDB(t *testing.T) *sql.DB {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		user_id INTEGER NOT NULL,
 		provider VARCHAR(50) NOT NULL,
+		name VARCHAR(255) NOT NULL DEFAULT 'default',
 		is_connected BOOLEAN DEFAULT FALSE,
 		last_synced TIMESTAMP,
-		aws_access_key_id VARCHAR(255),
-		aws_secret_access_key VARCHAR(255),
-		aws_region VARCHAR(50),
-		gcp_project_id VARCHAR(255),
-		gcp_service_account_json TEXT,
-		gcp_region VARCHAR(50),
-		azure_tenant_id VARCHAR(255),
-		azure_client_id VARCHAR(255),
-		azure_client_secret VARCHAR(255),
-		azure_subscription_id VARCHAR(255),
-		azure_location VARCHAR(50),
-		sync_status VARCHAR(50),
-		sync_message TEXT,
+		sync_status VARCHAR(50) NOT NULL DEFAULT '',
+		sync_message TEXT NOT NULL DEFAULT '',
+		credentials TEXT,
+		credentials_data_key TEXT,
+		credentials_key_version INTEGER NOT NULL DEFAULT 0,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-		UNIQUE(user_id, provider)
+		UNIQUE(user_id, provider, name)
 	);
 
 	CREATE TABLE IF NOT EXISTS resources (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		user_id INTEGER NOT NULL,
 		provider VARCHAR(50) NOT NULL,
-		type VARCHAR(100) NOT NULL,
+		account_id INTEGER,
+		resource_type VARCHAR(100) NOT NULL,
 		resource_id VARCHAR(255) NOT NULL,
 		name VARCHAR(255) NOT NULL,
 		region VARCHAR(100),
@@ -75,12 +70,27 @@ func NewTestDB(t *testing.T) *sql.DB {
 		metadata TEXT,
 		configuration TEXT,
 		last_scanned TIMESTAMP,
+		missing_since TIMESTAMP,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 		UNIQUE(user_id, provider, resource_id)
 	);
 
+	CREATE TABLE IF NOT EXISTS resource_sync_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		provider VARCHAR(50) NOT NULL,
+		account_id INTEGER NOT NULL,
+		synced_at TIMESTAMP NOT NULL,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		removed INTEGER NOT NULL DEFAULT 0,
+		purged INTEGER NOT NULL DEFAULT 0,
+		changes JSON,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS alerts (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		user_id INTEGER NOT NULL,
@@ -199,3 +209,17 @@ func CleanupDB(db *sql.DB) {
 		db.Close()
 	}
 }
+
+// NewTestKeyring creates a keyring with one random master key for encrypting
+// credentials in tests
+func NewTestKeyring(t *testing.T) *secrets.Keyring {
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate master key: %v", err)
+	}
+	keyring, err := secrets.NewKeyring(map[int][]byte{1: key}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	return keyring
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
 )
 
+// LeaderChecker reports whether this node is the elected leader
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
 // DriftScanner handles periodic drift detection scans
 type DriftScanner struct {
 	driftService    drift.Service
@@ -17,6 +22,7 @@ type DriftScanner struct {
 	userService     user.Service
 	userRepo        user.Repository
 	interval        time.Duration
+	leader          LeaderChecker
 	logger          *logger.Logger
 }
 
@@ -60,8 +66,19 @@ func (s *DriftScanner) Start(ctx context.Context) {
 	}
 }
 
+// SetLeaderChecker makes the scanner run only on the elected leader, so
+// replicas sharing a database do not scan every user more than once
+func (s *DriftScanner) SetLeaderChecker(leader LeaderChecker) {
+	s.leader = leader
+}
+
 // scanAllUsers performs drift detection for all users with connected providers
 func (s *DriftScanner) scanAllUsers(ctx context.Context) {
+	if s.leader != nil && !s.leader.IsLeader() {
+		s.logger.Debug("Skipping drift detection scan, this node is not the leader")
+		return
+	}
+
 	s.logger.Info("Starting drift detection scan for all users")
 
 	// Get all users
@@ -102,8 +119,13 @@ func (s *DriftScanner) scanUser(ctx context.Context, userID int64) error {
 		return nil
 	}
 
-	// Sync resources from all providers
+	// Sync resources from all providers. Sync covers every account of a
+	// provider, so each provider type is synced once.
+	synced := make(map[string]bool)
 	for _, provider := range providers {
+		if synced[provider.Provider] {
+			continue
+		}
 		if !provider.IsConnected {
 			s.logger.WithFields(map[string]interface{}{
 				"user_id":  userID,
@@ -113,6 +135,7 @@ func (s *DriftScanner) scanUser(ctx context.Context, userID int64) error {
 			continue
 		}
 
+		synced[provider.Provider] = true
 		if err := s.providerService.Sync(ctx, userID, provider.Provider); err != nil {
 			s.logger.WithFields(map[string]interface{}{
 				"user_id":  userID,
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/pratik-mahalle/infraudit/internal/domain/notification"
+	"github.com/pratik-mahalle/infraudit/internal/pkg/logger"
+)
+
+// NotificationDispatcher periodically retries notifications and webhook
+// deliveries that failed or were never completed
+type NotificationDispatcher struct {
+	notificationService notification.Service
+	interval            time.Duration
+	leader              LeaderChecker
+	logger              *logger.Logger
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher worker
+func NewNotificationDispatcher(
+	notificationService notification.Service,
+	interval time.Duration,
+	log *logger.Logger,
+) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		notificationService: notificationService,
+		interval:            interval,
+		logger:              log,
+	}
+}
+
+// SetLeaderChecker makes the dispatcher run only on the elected leader, so
+// replicas sharing a database do not deliver the same retry twice
+func (d *NotificationDispatcher) SetLeaderChecker(leader LeaderChecker) {
+	d.leader = leader
+}
+
+// Start begins draining pending deliveries every interval
+func (d *NotificationDispatcher) Start(ctx context.Context) {
+	d.logger.Info("Starting notification dispatcher worker")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.dispatch(ctx)
+		case <-ctx.Done():
+			d.logger.Info("Notification dispatcher worker stopped")
+			return
+		}
+	}
+}
+
+// dispatch drains pending notifications and webhook deliveries once
+func (d *NotificationDispatcher) dispatch(ctx context.Context) {
+	if d.leader != nil && !d.leader.IsLeader() {
+		d.logger.Debug("Skipping notification dispatch, this node is not the leader")
+		return
+	}
+
+	if err := d.notificationService.ProcessPendingNotifications(ctx); err != nil {
+		d.logger.ErrorWithErr(err, "Failed to process pending notifications")
+	}
+	if err := d.notificationService.ProcessPendingWebhookDeliveries(ctx); err != nil {
+		d.logger.ErrorWithErr(err, "Failed to process pending webhook deliveries")
+	}
+}
@@ -0,0 +1,22 @@
+-- Migration: Add custom drift rules
+-- User-defined rules override built-in drift rules with the same rule_id
+
+CREATE TABLE IF NOT EXISTS drift_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    rule_id VARCHAR(255) NOT NULL,
+    description TEXT,
+    resource_types JSON,
+    field VARCHAR(500) NOT NULL,
+    change_types JSON,
+    old_predicate JSON,
+    new_predicate JSON,
+    severity VARCHAR(20) NOT NULL,
+    drift_type VARCHAR(50) NOT NULL,
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, rule_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_drift_rules_user_id ON drift_rules(user_id);
@@ -0,0 +1,8 @@
+-- Migration: Drift deduplication and lifecycle tracking
+-- Repeat detections of the same drift update one record instead of creating new ones
+
+ALTER TABLE drifts ADD COLUMN fingerprint VARCHAR(64) DEFAULT '';
+ALTER TABLE drifts ADD COLUMN last_seen_at TIMESTAMP;
+ALTER TABLE drifts ADD COLUMN occurrence_count INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_drifts_fingerprint ON drifts(user_id, fingerprint);
@@ -0,0 +1,4 @@
+-- Migration: Store structured field-level changes on drifts
+-- JSON array of {path, field, old_value, new_value, change_type, rule_id, severity}
+
+ALTER TABLE drifts ADD COLUMN changes TEXT DEFAULT '';
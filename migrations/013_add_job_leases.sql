@@ -0,0 +1,12 @@
+-- Migration: Job leases for running several API replicas
+-- A node holds a lease while it renews it; an expired lease can be taken over
+
+CREATE TABLE IF NOT EXISTS job_leases (
+    name VARCHAR(255) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL,
+    heartbeat_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_leases_expires_at ON job_leases(expires_at);
@@ -0,0 +1,31 @@
+-- Migration: Webhook delivery retries with backoff and a dead-letter state
+-- The status CHECK constraint cannot be altered in SQLite, so the table is rebuilt
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries_new (
+    id TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,  -- JSON string
+    status VARCHAR(50) NOT NULL CHECK (status IN ('pending', 'sent', 'failed', 'retrying', 'dead_letter')),
+    response_status INTEGER,
+    response_body TEXT,
+    retry_count INTEGER DEFAULT 0,
+    attempted_at TIMESTAMP,
+    next_attempt_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+INSERT INTO webhook_deliveries_new (id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, delivered_at, created_at)
+SELECT id, webhook_id, event_type, payload, status, response_status, response_body, retry_count, delivered_at, created_at
+FROM webhook_deliveries;
+
+DROP TABLE webhook_deliveries;
+
+ALTER TABLE webhook_deliveries_new RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_type ON webhook_deliveries(event_type);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries(next_attempt_at);
@@ -0,0 +1,38 @@
+-- Migration: Cost budgets with threshold alerts
+-- A threshold fires at most once per budget period, enforced by the unique key on cost_budget_alerts
+
+CREATE TABLE IF NOT EXISTS cost_budgets (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    period VARCHAR(20) NOT NULL CHECK (period IN ('monthly', 'quarterly')),
+    amount DECIMAL(15, 4) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    provider VARCHAR(50) DEFAULT '',
+    service_name VARCHAR(255) DEFAULT '',
+    region VARCHAR(100) DEFAULT '',
+    tag_key VARCHAR(255) DEFAULT '',
+    tag_value VARCHAR(255) DEFAULT '',
+    thresholds JSON,
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_budgets_user_id ON cost_budgets(user_id);
+
+CREATE TABLE IF NOT EXISTS cost_budget_alerts (
+    id VARCHAR(36) PRIMARY KEY,
+    budget_id VARCHAR(36) NOT NULL,
+    user_id BIGINT NOT NULL,
+    period_key VARCHAR(20) NOT NULL,
+    threshold_type VARCHAR(20) NOT NULL,
+    threshold_percent DECIMAL(7, 2) NOT NULL,
+    spend DECIMAL(15, 4) NOT NULL,
+    budget_amount DECIMAL(15, 4) NOT NULL,
+    triggered_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (budget_id) REFERENCES cost_budgets(id) ON DELETE CASCADE,
+    UNIQUE(budget_id, period_key, threshold_type, threshold_percent)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_budget_alerts_budget_id ON cost_budget_alerts(budget_id);
@@ -0,0 +1,38 @@
+-- Migration: Rebuild cost anomalies for series-level detection
+-- The original table predates the cost and anomaly repositories and lacks the
+-- columns both of them query. It is rebuilt with provider/service/region
+-- series, expected and actual costs, a fingerprint for deduplication and the
+-- drivers that explain each anomaly.
+
+CREATE TABLE IF NOT EXISTS cost_anomalies_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(50),
+    resource_id VARCHAR(255),
+    service_name VARCHAR(255),
+    region VARCHAR(100),
+    anomaly_type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    expected_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    actual_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    deviation_percentage DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    description TEXT,
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    drivers JSON,
+    status VARCHAR(50) DEFAULT 'open',
+    detected_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO cost_anomalies_new (id, user_id, resource_id, anomaly_type, severity, expected_cost, actual_cost, deviation_percentage, status, detected_at, created_at)
+SELECT id, user_id, resource_id, anomaly_type, severity, previous_cost, current_cost, percentage, status, detected_at, created_at
+FROM cost_anomalies;
+
+DROP TABLE cost_anomalies;
+
+ALTER TABLE cost_anomalies_new RENAME TO cost_anomalies;
+
+CREATE INDEX IF NOT EXISTS idx_cost_anomalies_user_id ON cost_anomalies(user_id);
+CREATE INDEX IF NOT EXISTS idx_cost_anomalies_status ON cost_anomalies(status);
+CREATE INDEX IF NOT EXISTS idx_cost_anomalies_detected_at ON cost_anomalies(detected_at);
+CREATE INDEX IF NOT EXISTS idx_cost_anomalies_fingerprint ON cost_anomalies(user_id, fingerprint);
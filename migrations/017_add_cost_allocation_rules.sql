@@ -0,0 +1,15 @@
+-- Migration: Tag-based cost allocation rules for showback reports
+-- Tag keys, value aliases and split rules are stored as JSON on the rule
+
+CREATE TABLE IF NOT EXISTS cost_allocation_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    tag_keys JSON NOT NULL,
+    aliases JSON,
+    splits JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_allocation_rules_user_id ON cost_allocation_rules(user_id);
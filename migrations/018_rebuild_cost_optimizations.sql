@@ -0,0 +1,37 @@
+-- Migration: Rebuild cost optimizations for rule-based analysis
+-- Resource IDs such as Azure resource paths do not fit in 36 characters, and
+-- findings need a fingerprint of the resource and rule so a rerun of the
+-- analyzer refreshes them instead of adding duplicates.
+
+CREATE TABLE IF NOT EXISTS cost_optimizations_new (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(512),
+    resource_type VARCHAR(100),
+    optimization_type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    current_cost DECIMAL(15, 4) NOT NULL,
+    estimated_savings DECIMAL(15, 4) NOT NULL,
+    savings_percent DECIMAL(5, 2),
+    implementation VARCHAR(50),
+    status VARCHAR(50) DEFAULT 'pending',
+    details JSON,
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO cost_optimizations_new (id, user_id, provider, resource_id, resource_type, optimization_type, title, description, current_cost, estimated_savings, savings_percent, implementation, status, details, created_at, updated_at)
+SELECT id, user_id, provider, resource_id, resource_type, optimization_type, title, description, current_cost, estimated_savings, savings_percent, implementation, status, details, created_at, updated_at
+FROM cost_optimizations;
+
+DROP TABLE cost_optimizations;
+
+ALTER TABLE cost_optimizations_new RENAME TO cost_optimizations;
+
+CREATE INDEX IF NOT EXISTS idx_cost_optimizations_user_id ON cost_optimizations(user_id);
+CREATE INDEX IF NOT EXISTS idx_cost_optimizations_status ON cost_optimizations(status);
+CREATE INDEX IF NOT EXISTS idx_cost_optimizations_optimization_type ON cost_optimizations(optimization_type);
+CREATE INDEX IF NOT EXISTS idx_cost_optimizations_fingerprint ON cost_optimizations(user_id, fingerprint);
@@ -0,0 +1,45 @@
+-- Migration: Encrypt provider credentials at rest
+-- Credentials move from plain per-field columns into one envelope-encrypted
+-- JSON document: the ciphertext, its data key wrapped by a master key, and
+-- the master key version. Existing credentials are carried over as plain JSON
+-- with key version 0 and are encrypted by the next save or by running
+-- `migrate reencrypt-credentials`.
+
+CREATE TABLE IF NOT EXISTS provider_accounts_new (
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    is_connected INTEGER DEFAULT 0,
+    last_synced TIMESTAMP,
+    credentials TEXT,
+    credentials_data_key TEXT,
+    credentials_key_version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, provider),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO provider_accounts_new (user_id, provider, is_connected, last_synced, credentials, credentials_key_version, created_at, updated_at)
+SELECT user_id, provider, is_connected, last_synced,
+    json_object(
+        'aws_access_key_id', COALESCE(aws_access_key_id, ''),
+        'aws_secret_access_key', COALESCE(aws_secret_access_key, ''),
+        'aws_region', COALESCE(aws_region, ''),
+        'gcp_project_id', COALESCE(gcp_project_id, ''),
+        'gcp_service_account_json', COALESCE(gcp_service_account_json, ''),
+        'gcp_region', COALESCE(gcp_region, ''),
+        'azure_tenant_id', COALESCE(azure_tenant_id, ''),
+        'azure_client_id', COALESCE(azure_client_id, ''),
+        'azure_client_secret', COALESCE(azure_client_secret, ''),
+        'azure_subscription_id', COALESCE(azure_subscription_id, ''),
+        'azure_location', COALESCE(azure_location, '')
+    ),
+    0, created_at, updated_at
+FROM provider_accounts;
+
+DROP TABLE provider_accounts;
+
+ALTER TABLE provider_accounts_new RENAME TO provider_accounts;
+
+CREATE INDEX IF NOT EXISTS idx_provider_accounts_user_id ON provider_accounts(user_id);
+CREATE INDEX IF NOT EXISTS idx_provider_accounts_key_version ON provider_accounts(credentials_key_version);
@@ -0,0 +1,43 @@
+-- Migration: Multiple accounts per provider
+-- Provider accounts get their own IDs and names so a user can connect many
+-- AWS accounts, GCP projects and Azure subscriptions, plus the outcome of
+-- their last sync. Existing accounts are named "default". Resources record
+-- the account they were synced from.
+
+CREATE TABLE IF NOT EXISTS provider_accounts_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT 'default',
+    is_connected INTEGER DEFAULT 0,
+    last_synced TIMESTAMP,
+    sync_status VARCHAR(50) NOT NULL DEFAULT '',
+    sync_message TEXT NOT NULL DEFAULT '',
+    credentials TEXT,
+    credentials_data_key TEXT,
+    credentials_key_version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, provider, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO provider_accounts_new (user_id, provider, name, is_connected, last_synced, credentials, credentials_data_key, credentials_key_version, created_at, updated_at)
+SELECT user_id, provider, 'default', is_connected, last_synced, credentials, credentials_data_key, credentials_key_version, created_at, updated_at
+FROM provider_accounts;
+
+DROP TABLE provider_accounts;
+
+ALTER TABLE provider_accounts_new RENAME TO provider_accounts;
+
+CREATE INDEX IF NOT EXISTS idx_provider_accounts_user_id ON provider_accounts(user_id, provider);
+CREATE INDEX IF NOT EXISTS idx_provider_accounts_key_version ON provider_accounts(credentials_key_version);
+
+ALTER TABLE resources ADD COLUMN account_id INTEGER;
+
+UPDATE resources SET account_id = (
+    SELECT p.id FROM provider_accounts p
+    WHERE p.user_id = resources.user_id AND p.provider = resources.provider
+);
+
+CREATE INDEX IF NOT EXISTS idx_resources_account_id ON resources(user_id, account_id);
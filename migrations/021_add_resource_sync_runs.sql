@@ -0,0 +1,23 @@
+-- Migration: Stale resource reconciliation
+-- Syncs now diff an account's resources instead of replacing them. A
+-- resource that vanishes from its provider is marked terminated and records
+-- when it went missing; it is deleted after a grace period. Each sync
+-- records what it added, updated, removed and purged.
+
+ALTER TABLE resources ADD COLUMN missing_since TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS resource_sync_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    account_id INTEGER NOT NULL,
+    synced_at TIMESTAMP NOT NULL,
+    added INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    removed INTEGER NOT NULL DEFAULT 0,
+    purged INTEGER NOT NULL DEFAULT 0,
+    changes JSON,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_sync_runs_account ON resource_sync_runs(user_id, account_id, synced_at);
@@ -0,0 +1,7 @@
+-- Migration: Compliance framework catalogs
+-- Built-in frameworks are loaded from versioned catalog files on startup.
+-- The catalog revision is recorded so unchanged catalogs are skipped, and
+-- the source separates built-in frameworks from imported custom ones.
+
+ALTER TABLE compliance_frameworks ADD COLUMN source VARCHAR(20) DEFAULT 'builtin';
+ALTER TABLE compliance_frameworks ADD COLUMN catalog_revision INTEGER DEFAULT 0;
@@ -0,0 +1,19 @@
+-- Migration: Cross-framework control links
+-- Links equivalent controls across frameworks so a check defined for one
+-- control also counts for the controls it is linked to. Both sides are
+-- stored by framework and control ID because the linked framework may be
+-- loaded after the one that declares the link. Links belong to the framework
+-- whose catalog declares them and are replaced when that catalog is loaded.
+
+CREATE TABLE IF NOT EXISTS compliance_control_links (
+    id VARCHAR(36) PRIMARY KEY,
+    framework_id VARCHAR(36) NOT NULL,
+    control_id VARCHAR(50) NOT NULL,
+    linked_framework_id VARCHAR(36) NOT NULL,
+    linked_control_id VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (framework_id, control_id, linked_framework_id, linked_control_id),
+    FOREIGN KEY (framework_id) REFERENCES compliance_frameworks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_compliance_control_links_linked ON compliance_control_links(linked_framework_id, linked_control_id);
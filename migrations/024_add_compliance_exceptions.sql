@@ -0,0 +1,29 @@
+-- Migration: Compliance exceptions
+-- An exception accepts the risk of a failing control for one resource, or
+-- for every resource of the control when resource_id is empty. Approved
+-- exceptions that have not expired turn matching failures into excepted
+-- findings, which do not count against the compliance percentage.
+
+CREATE TABLE IF NOT EXISTS compliance_exceptions (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    framework_id VARCHAR(36) NOT NULL,
+    control_id VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(255) DEFAULT '',
+    justification TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by BIGINT NOT NULL,
+    approved_by BIGINT,
+    approved_at TIMESTAMP,
+    rejection_reason TEXT DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    expiry_notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (framework_id) REFERENCES compliance_frameworks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_compliance_exceptions_user_status ON compliance_exceptions(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_compliance_exceptions_control ON compliance_exceptions(framework_id, control_id);
+
+ALTER TABLE compliance_assessments ADD COLUMN excepted_controls INT DEFAULT 0;
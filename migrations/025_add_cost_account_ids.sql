@@ -0,0 +1,17 @@
+-- Migration: Cost records per provider account
+-- Cost records remember the provider account they were synced from, so the
+-- costs of several accounts of one provider are kept apart. Existing records
+-- are assigned when the user has a single account for the provider.
+
+ALTER TABLE resource_costs ADD COLUMN account_id INTEGER;
+
+UPDATE resource_costs SET account_id = (
+    SELECT MIN(p.id) FROM provider_accounts p
+    WHERE p.user_id = resource_costs.user_id AND p.provider = resource_costs.provider
+)
+WHERE (
+    SELECT COUNT(*) FROM provider_accounts p
+    WHERE p.user_id = resource_costs.user_id AND p.provider = resource_costs.provider
+) = 1;
+
+CREATE INDEX IF NOT EXISTS idx_resource_costs_account_id ON resource_costs(user_id, account_id);
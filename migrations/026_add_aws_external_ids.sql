@@ -0,0 +1,11 @@
+-- Migration: AWS external IDs per user
+-- Roles assumed with the server's own AWS identity must require the user's
+-- external ID in their trust policy, so one user cannot have the server
+-- assume a role that another user set up. The ID is generated by the server
+-- and never changes.
+
+CREATE TABLE IF NOT EXISTS aws_external_ids (
+    user_id BIGINT PRIMARY KEY,
+    external_id VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
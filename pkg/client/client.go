@@ -58,11 +58,29 @@ func (c *Client) GetToken() string {
 
 // doRequest performs an HTTP request with proper error handling
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	respBody, err := c.send(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+
+	// Parse success response
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// send performs an HTTP request and returns the body of a successful
+// response
+func (c *Client) send(ctx context.Context, method, path string, body interface{}, accept string) ([]byte, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		reqBody = bytes.NewBuffer(jsonData)
 	}
@@ -70,12 +88,12 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	url := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept", accept)
 
 	// Add authentication
 	if c.token != "" {
@@ -87,34 +105,27 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	// Perform request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
 		var apiErr APIError
 		if err := json.Unmarshal(respBody, &apiErr); err != nil {
-			return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+			return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
 		}
 		apiErr.StatusCode = resp.StatusCode
-		return &apiErr
+		return nil, &apiErr
 	}
 
-	// Parse success response
-	if result != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
-	}
-
-	return nil
+	return respBody, nil
 }
 
 // Resources returns the resource management service
@@ -152,3 +163,9 @@ func (c *Client) Anomalies() *AnomalyService {
 func (c *Client) DoRaw(ctx context.Context, method, path string, body interface{}, result interface{}) error {
 	return c.doRequest(ctx, method, path, body, result)
 }
+
+// Download performs a GET request and returns the response body as is.
+// This is useful for endpoints that return files, such as reports.
+func (c *Client) Download(ctx context.Context, path string) ([]byte, error) {
+	return c.send(ctx, http.MethodGet, path, nil, "*/*")
+}
@@ -84,11 +84,25 @@ type Drift struct {
 	ActualConfig   map[string]interface{} `json:"actual_config"`
 	Status         string                 `json:"status"` // detected, investigating, resolved
 	DetectedAt     time.Time              `json:"detected_at"`
-	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+	LastSeenAt     *time.Time             `json:"lastSeenAt,omitempty"`
+	Occurrences    int                    `json:"occurrenceCount"`
+	ResolvedAt     *time.Time             `json:"resolvedAt,omitempty"`
+	Changes        []DriftChange          `json:"changes,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
 }
 
+// DriftChange represents a single field-level change between baseline and current configuration
+type DriftChange struct {
+	Path       string      `json:"path"`
+	Field      string      `json:"field"`
+	OldValue   interface{} `json:"oldValue"`
+	NewValue   interface{} `json:"newValue"`
+	ChangeType string      `json:"changeType"` // added, removed, modified
+	RuleID     string      `json:"ruleId,omitempty"`
+	Severity   string      `json:"severity,omitempty"`
+}
+
 // Anomaly represents a cost anomaly
 type Anomaly struct {
 	ID               int64                  `json:"id"`